@@ -63,6 +63,7 @@ func main() {
 	configServerSSLDisabled := configCmd.Bool("disable-ssl", false, "Disable SSL (use http to connect to server)")
 	configServerSelfSignedCertificateAccepted := configCmd.Bool("accept-sscrt", false, "Accept Self-signed server certificate")
 	configServerSelfSignedCertificateRefused := configCmd.Bool("refuse-sscrt", false, "Refuse Self-signed server certificate")
+	configStreamingProfile := configCmd.String("streaming-profile", "", "Set streaming quality: name of a server transcoding profile (e.g. opus-96) or \""+cli.OriginalStreamingProfile+"\"")
 
 	configCmd.Usage = func() {
 		fmt.Printf("\nUsage: %s config [OPTIONS]\n", mainCommand)
@@ -243,6 +244,7 @@ func main() {
 			configServerSelfSignedCertificate,
 			*configUsername,
 			*configPassword,
+			*configStreamingProfile,
 			*configClearCachedSelfSignedServerCertificate)
 
 	} else if versionCmd.Parsed() {
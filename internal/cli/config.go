@@ -4,6 +4,9 @@ import (
 	"fmt"
 )
 
+// OriginalStreamingProfile is the streaming profile value resetting streaming quality to the original song content
+const OriginalStreamingProfile = "original"
+
 func (c *ClientApp) Config(
 	serverHostname string,
 	serverPort int64,
@@ -11,6 +14,7 @@ func (c *ClientApp) Config(
 	serverSelfSignedCertificate *bool,
 	username string,
 	password string,
+	streamingProfile string,
 	clearCachedServerCertificate bool) {
 	shouldSaveConfig := false
 
@@ -58,6 +62,17 @@ func (c *ClientApp) Config(
 		fmt.Println("Password updated")
 	}
 
+	if streamingProfile != "" {
+		if streamingProfile == OriginalStreamingProfile {
+			c.config.ClientEditableConfig.StreamingProfile = ""
+			fmt.Println("Streaming quality updated: songs will be streamed in their original format")
+		} else {
+			c.config.ClientEditableConfig.StreamingProfile = streamingProfile
+			fmt.Println("Streaming quality updated: songs will be transcoded with the " + streamingProfile + " server profile")
+		}
+		shouldSaveConfig = true
+	}
+
 	if clearCachedServerCertificate {
 		c.config.SetCert(nil)
 		fmt.Println("Cached server certificate has been deleted")
@@ -40,6 +40,7 @@ type ClientEditableConfig struct {
 	Username         string `json:"username"`
 	Password         string `json:"password"`
 	Timeout          int64  `json:"timeout"`
+	StreamingProfile string `json:"streamingProfile"` // Transcoding profile used to stream songs, empty for the original content
 }
 
 func NewClientEditableConfig(draftClientEditableConfig *ClientEditableConfig) *ClientEditableConfig {
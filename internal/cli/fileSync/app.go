@@ -280,6 +280,9 @@ func (a *App) sync() {
 			),
 		)
 
+		// Playlists mirror the playlist folder tree
+		playlistFolderPaths := getPlaylistFolderPaths(fileSyncReport.PlaylistFolders)
+
 		// Delete playlists
 
 		for _, playlistId := range fileSyncReport.DeletedPlaylistIds {
@@ -303,6 +306,7 @@ func (a *App) sync() {
 						logrus.Warningf("Unable to delete \"%s\": %s\n", fullpath, err.Error())
 						playlistSyncErrors++
 					} else {
+						deleteVoidPlaylistFolders(a.fileSyncMusicFolder+"/playlists", fileSyncLocalPlaylist.Filepath)
 						delete(a.fileSyncConfig.FileSyncLocalPlaylists, playlistId)
 						playlistSyncDeletedPlaylists++
 						logrus.Debugf("\"%s\" deleted\n", fileSyncLocalPlaylist.Filepath)
@@ -332,6 +336,9 @@ func (a *App) sync() {
 
 					// Create playlist folder(s)
 					newpath := tool.SanitizeFilename(playlist.Name) + ".m3u8"
+					if playlist.FolderId != nil {
+						newpath = playlistFolderPaths[*playlist.FolderId] + newpath
+					}
 					fullNewpath := a.fileSyncMusicFolder + "/playlists/" + newpath
 
 					index := strings.LastIndex(fullNewpath, "/")
@@ -352,16 +359,18 @@ func (a *App) sync() {
 						return
 					}
 
+					songsFolder := strings.Repeat("../", strings.Count(newpath, "/")+1) + "songs/"
 					for _, songId := range playlist.SongIds {
 						if fileSyncLocalSong, ok := a.fileSyncConfig.FileSyncLocalSongs[songId]; ok {
-							fmt.Fprintln(file, "../songs/"+fileSyncLocalSong.Filepath)
+							fmt.Fprintln(file, songsFolder+fileSyncLocalSong.Filepath)
 						}
 					}
 					file.Close()
 
 					// Delete old playlist
-					if fileSyncLocalPlaylist, ok := a.fileSyncConfig.FileSyncLocalPlaylists[playlist.Id]; ok {
+					if fileSyncLocalPlaylist, ok := a.fileSyncConfig.FileSyncLocalPlaylists[playlist.Id]; ok && fileSyncLocalPlaylist.Filepath != newpath {
 						os.Remove(a.fileSyncMusicFolder + "/playlists/" + fileSyncLocalPlaylist.Filepath)
+						deleteVoidPlaylistFolders(a.fileSyncMusicFolder+"/playlists", fileSyncLocalPlaylist.Filepath)
 					}
 
 					// Rename new playlist
@@ -415,6 +424,49 @@ func deleteVoidParentFolder(filename string) {
 	}
 }
 
+// deleteVoidPlaylistFolders delete the empty folders containing a playlist file, from the deepest one up to rootFolder (excluded)
+func deleteVoidPlaylistFolders(rootFolder string, filepath string) {
+	for ind := strings.LastIndex(filepath, "/"); ind != -1; ind = strings.LastIndex(filepath, "/") {
+		filepath = filepath[:ind]
+		if os.Remove(rootFolder+"/"+filepath) != nil {
+			return
+		}
+	}
+}
+
+// getPlaylistFolderPaths return the relative path of each playlist folder, ending with a slash
+func getPlaylistFolderPaths(playlistFolders []restApiV1.PlaylistFolder) map[restApiV1.PlaylistFolderId]string {
+	playlistFolderMap := make(map[restApiV1.PlaylistFolderId]*restApiV1.PlaylistFolder, len(playlistFolders))
+	for idx := range playlistFolders {
+		playlistFolderMap[playlistFolders[idx].Id] = &playlistFolders[idx]
+	}
+
+	playlistFolderPaths := make(map[restApiV1.PlaylistFolderId]string, len(playlistFolders))
+	for _, playlistFolder := range playlistFolders {
+		path := ""
+		visitedFolderIds := make(map[restApiV1.PlaylistFolderId]struct{})
+		for folder := &playlistFolder; folder != nil; {
+			if _, ok := visitedFolderIds[folder.Id]; ok {
+				break
+			}
+			visitedFolderIds[folder.Id] = struct{}{}
+			// Folder names made of dots would move out of the playlists folder
+			folderName := tool.SanitizeFilename(folder.Name)
+			if strings.Trim(folderName, ".") == "" {
+				folderName = "_"
+			}
+			path = folderName + "/" + path
+			if folder.ParentFolderId == nil {
+				break
+			}
+			folder = playlistFolderMap[*folder.ParentFolderId]
+		}
+		playlistFolderPaths[playlistFolder.Id] = path
+	}
+
+	return playlistFolderPaths
+}
+
 func (a *App) saveFileSyncConfig() {
 	logrus.Debugf("Save: %s", a.getCompleteFileSyncFilename())
 	rawConfig, err := json.MarshalIndent(a.fileSyncConfig, "", "\t")
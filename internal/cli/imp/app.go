@@ -88,11 +88,18 @@ func (a *App) start() {
 				case strings.HasSuffix(lowerCasePath, ".mp3"):
 					logrus.Debugf("Detect mp3 file: %s", path)
 					songFormat = restApiV1.SongFormatMp3
-					/*
-						case strings.HasSuffix(lowerCasePath, ".ogg") || strings.HasSuffix(lowerCasePath, ".oga"):
-							logrus.Debugf("Detect ogg file: %s", path)
-							songFormat = model.SongFormatOgg
-					*/
+
+				case strings.HasSuffix(lowerCasePath, ".ogg") || strings.HasSuffix(lowerCasePath, ".oga"):
+					logrus.Debugf("Detect ogg file: %s", path)
+					songFormat = restApiV1.SongFormatOgg
+
+				case strings.HasSuffix(lowerCasePath, ".opus"):
+					logrus.Debugf("Detect opus file: %s", path)
+					songFormat = restApiV1.SongFormatOpus
+
+				case strings.HasSuffix(lowerCasePath, ".m4a"):
+					logrus.Debugf("Detect m4a file: %s", path)
+					songFormat = restApiV1.SongFormatM4a
 				}
 
 				if songFormat != restApiV1.SongFormatUnknown {
@@ -110,6 +117,7 @@ func (a *App) start() {
 
 	// Try to import every song files previously identified
 	importedSongs := 0
+	alreadyPresentSongs := 0
 
 	if len(filesNameToImport) == 0 {
 		fmt.Println("No files to import")
@@ -185,6 +193,9 @@ func (a *App) start() {
 					if apiErr == nil {
 						importedSongs++
 						lastAlbumId = song.AlbumId
+					} else if apiErr.Code() == restApiV1.DuplicateSongErrorCode {
+						alreadyPresentSongs++
+						logrus.Debugf("File %s already present: %s", fileName, apiErr.Description())
 					} else {
 						songBar.Abort(true)
 						logrus.Warnf("Unable to import file %s: %v", fileName, apiErr)
@@ -208,7 +219,7 @@ func (a *App) start() {
 	} else {
 		fmt.Print("Import done: ")
 	}
-	fmt.Printf("%d songs imported\n", importedSongs)
+	fmt.Printf("%d songs imported, %d already present\n", importedSongs, alreadyPresentSongs)
 
 	// Cleaning
 	select {
@@ -1,17 +1,25 @@
 package ui
 
 import (
+	"bytes"
 	"codeberg.org/tslocum/cview"
 	"github.com/jypelle/mifasol/restApiV1"
+	"io/ioutil"
+	"net/http"
+	"strconv"
 )
 
 type AlbumEditComponent struct {
 	*cview.Form
-	nameInputField  *cview.InputField
-	uiApp           *App
-	albumId         restApiV1.AlbumId
-	albumMeta       *restApiV1.AlbumMeta
-	originPrimitive cview.Primitive
+	nameInputField       *cview.InputField
+	albumArtistDropDowns []*cview.DropDown
+	coverInputField      *cview.InputField
+	removeCoverCheckbox  *cview.CheckBox
+	coverTextView        *cview.TextView
+	uiApp                *App
+	albumId              restApiV1.AlbumId
+	albumMeta            *restApiV1.AlbumMeta
+	originPrimitive      cview.Primitive
 }
 
 func OpenAlbumCreateComponent(uiApp *App, originPrimitive cview.Primitive) {
@@ -38,11 +46,31 @@ func OpenAlbumEditComponent(uiApp *App, albumId restApiV1.AlbumId, albumMeta *re
 	c.nameInputField.SetText(albumMeta.Name)
 	c.nameInputField.SetFieldWidth(50)
 
+	// Cover
+	c.coverInputField = cview.NewInputField()
+	c.coverInputField.SetLabel("New cover (jpeg/png file)")
+	c.coverInputField.SetFieldWidth(50)
+
+	c.removeCoverCheckbox = cview.NewCheckBox()
+	c.removeCoverCheckbox.SetLabel("Remove cover")
+
+	c.coverTextView = cview.NewTextView()
+	c.coverTextView.SetDynamicColors(true)
+
 	c.Form = cview.NewForm()
 	c.Form.SetFieldTextColorFocused(cview.Styles.PrimitiveBackgroundColor)
 	c.Form.SetFieldBackgroundColorFocused(cview.Styles.PrimaryTextColor)
 
 	c.Form.AddFormItem(c.nameInputField)
+	for _, artistId := range albumMeta.AlbumArtistIds {
+		c.addAlbumArtist(artistId)
+	}
+	c.addAlbumArtist("")
+	c.Form.AddFormItem(c.coverInputField)
+	if album, ok := uiApp.LocalDb().Albums[albumId]; ok && album.CoverFg {
+		c.Form.AddFormItem(c.removeCoverCheckbox)
+		c.loadCover()
+	}
 	c.Form.AddButton("Save", c.save)
 	c.Form.AddButton("Cancel", c.cancel)
 	if c.albumId != "" {
@@ -52,29 +80,103 @@ func OpenAlbumEditComponent(uiApp *App, albumId restApiV1.AlbumId, albumMeta *re
 		c.Form.SetBorder(true)
 		c.Form.SetTitle("Create album")
 	}
-	uiApp.pagesComponent.AddAndSwitchToPage("albumEdit", c, true)
+
+	flex := cview.NewFlex()
+	flex.SetDirection(cview.FlexColumn)
+	flex.AddItem(c.Form, 0, 1, true)
+	flex.AddItem(c.coverTextView, 34, 0, false)
+
+	uiApp.pagesComponent.AddAndSwitchToPage("albumEdit", flex, true)
 
 }
 
+func (c *AlbumEditComponent) loadCover() {
+	coverReader, _, cliErr := c.uiApp.restClient.ReadAlbumCover(c.albumId)
+	if cliErr != nil {
+		c.uiApp.ClientErrorMessage("Unable to retrieve album cover", cliErr)
+		return
+	}
+	defer coverReader.Close()
+
+	preview, err := coverPreview(coverReader, 32)
+	if err != nil {
+		c.uiApp.WarningMessage("Unable to display album cover")
+		return
+	}
+	c.coverTextView.SetText(preview)
+}
+
 func (c *AlbumEditComponent) save() {
 	c.albumMeta.Name = c.nameInputField.GetText()
-	if c.albumId != "" {
-		_, cliErr := c.uiApp.restClient.UpdateAlbum(c.albumId, c.albumMeta)
+
+	// Album artists
+	c.albumMeta.AlbumArtistIds = nil
+	for _, albumArtistDropDown := range c.albumArtistDropDowns {
+		selectedArtistInd, _ := albumArtistDropDown.GetCurrentOption()
+		if selectedArtistInd > 0 {
+			c.albumMeta.AlbumArtistIds = append(c.albumMeta.AlbumArtistIds, c.uiApp.localDb.OrderedArtists[selectedArtistInd].Id)
+		}
+	}
+
+	albumId := c.albumId
+	if albumId != "" {
+		_, cliErr := c.uiApp.restClient.UpdateAlbum(albumId, c.albumMeta)
 		if cliErr != nil {
 			c.uiApp.ClientErrorMessage("Unable to update the album", cliErr)
 			return
 		}
 	} else {
-		_, cliErr := c.uiApp.restClient.CreateAlbum(c.albumMeta)
+		album, cliErr := c.uiApp.restClient.CreateAlbum(c.albumMeta)
 		if cliErr != nil {
 			c.uiApp.ClientErrorMessage("Unable to create the album", cliErr)
 			return
 		}
+		albumId = album.Id
 	}
+
+	// Update cover
+	if c.coverInputField.GetText() != "" {
+		content, err := ioutil.ReadFile(c.coverInputField.GetText())
+		if err != nil {
+			c.uiApp.WarningMessage("Unable to read cover file: " + c.coverInputField.GetText())
+			return
+		}
+		_, cliErr := c.uiApp.restClient.UpdateAlbumCover(albumId, http.DetectContentType(content), bytes.NewReader(content))
+		if cliErr != nil {
+			c.uiApp.ClientErrorMessage("Unable to update the album cover", cliErr)
+			return
+		}
+	} else if c.removeCoverCheckbox.IsChecked() {
+		_, cliErr := c.uiApp.restClient.DeleteAlbumCover(albumId)
+		if cliErr != nil {
+			c.uiApp.ClientErrorMessage("Unable to remove the album cover", cliErr)
+			return
+		}
+	}
+
 	c.close()
 	c.uiApp.Reload()
 }
 
+func (c *AlbumEditComponent) addAlbumArtist(artistId restApiV1.ArtistId) {
+	albumArtistDropDown := cview.NewDropDown()
+	albumArtistDropDown.SetLabel("Album artist " + strconv.Itoa(len(c.albumArtistDropDowns)+1))
+	selectedArtistInd := 0
+	for ind, artist := range c.uiApp.localDb.OrderedArtists {
+		if ind == 0 {
+			albumArtistDropDown.AddOptionsSimple("(Unknown artist)")
+		} else {
+			albumArtistDropDown.AddOptionsSimple(artist.Name)
+			if artistId == artist.Id {
+				selectedArtistInd = ind
+			}
+		}
+	}
+	albumArtistDropDown.SetCurrentOption(selectedArtistInd)
+	c.albumArtistDropDowns = append(c.albumArtistDropDowns, albumArtistDropDown)
+	c.Form.AddFormItem(albumArtistDropDown)
+}
+
 func (c *AlbumEditComponent) cancel() {
 	c.close()
 }
@@ -232,12 +232,15 @@ func (a *App) ConfirmArtistDelete(artist *restApiV1.Artist) {
 		return
 	}
 
+	// Songs where the artist is only credited (featuring, composer...) are kept
+	artistSongs := a.localDb.MainArtistSongs(artist.Id)
+
 	currentFocus := a.cviewApp.GetFocus()
 	modal := cview.NewModal()
-	if len(a.localDb.ArtistOrderedSongs[artist.Id]) == 1 {
+	if len(artistSongs) == 1 {
 		modal.SetText(fmt.Sprintf("Do you want to delete \"%s\" and its song ?", artist.Name))
-	} else if len(a.localDb.ArtistOrderedSongs[artist.Id]) > 1 {
-		modal.SetText(fmt.Sprintf("Do you want to delete \"%s\" and its %d songs ?", artist.Name, len(a.localDb.ArtistOrderedSongs[artist.Id])))
+	} else if len(artistSongs) > 1 {
+		modal.SetText(fmt.Sprintf("Do you want to delete \"%s\" and its %d songs ?", artist.Name, len(artistSongs)))
 	} else {
 		modal.SetText(fmt.Sprintf("Do you want to delete \"%s\" ?", artist.Name))
 	}
@@ -256,7 +259,7 @@ func (a *App) ConfirmArtistDelete(artist *restApiV1.Artist) {
 					a.Reload()
 				}()
 
-				for _, song := range a.localDb.ArtistOrderedSongs[artist.Id] {
+				for _, song := range artistSongs {
 					mfModal.SetText(fmt.Sprintf("Deleting \"%s\" from \"%s\"", song.Name, artist.Name))
 					a.cviewApp.Draw()
 
@@ -281,6 +284,70 @@ func (a *App) ConfirmArtistDelete(artist *restApiV1.Artist) {
 	a.pagesComponent.AddPage("artistDeleteConfirm", modal, false, true)
 }
 
+func (a *App) ConfirmGenreDelete(genre *restApiV1.Genre) {
+	// Only admin can delete a genre
+	if !a.IsConnectedUserAdmin() {
+		a.WarningMessage("Only administrator can delete this genre")
+		return
+	}
+
+	currentFocus := a.cviewApp.GetFocus()
+	modal := cview.NewModal()
+	if len(a.localDb.GenreOrderedSongs[genre.Id]) == 1 {
+		modal.SetText(fmt.Sprintf("Do you want to delete \"%s\" and remove it from its song ?", genre.Name))
+	} else if len(a.localDb.GenreOrderedSongs[genre.Id]) > 1 {
+		modal.SetText(fmt.Sprintf("Do you want to delete \"%s\" and remove it from its %d songs ?", genre.Name, len(a.localDb.GenreOrderedSongs[genre.Id])))
+	} else {
+		modal.SetText(fmt.Sprintf("Do you want to delete \"%s\" ?", genre.Name))
+	}
+	modal.AddButtons([]string{"Yes", "Cancel"})
+	modal.SetDoneFunc(func(buttonIndex int, buttonLabel string) {
+		a.pagesComponent.HidePage("genreDeleteConfirm")
+		a.pagesComponent.RemovePage("genreDeleteConfirm")
+		a.cviewApp.SetFocus(currentFocus)
+
+		if buttonLabel == "Yes" {
+			mfModal := a.OpenModalMessage("Deleting...")
+
+			go func() {
+				defer func() {
+					mfModal.Close()
+					a.Reload()
+				}()
+
+				// Songs are kept, only their link to the genre is removed
+				for _, song := range a.localDb.GenreOrderedSongs[genre.Id] {
+					mfModal.SetText(fmt.Sprintf("Removing \"%s\" from \"%s\"", genre.Name, song.Name))
+					a.cviewApp.Draw()
+
+					songMeta := song.SongMeta.Copy()
+					songMeta.GenreIds = nil
+					for _, genreId := range song.GenreIds {
+						if genreId != genre.Id {
+							songMeta.GenreIds = append(songMeta.GenreIds, genreId)
+						}
+					}
+					_, cliErr := a.restClient.UpdateSong(song.Id, songMeta)
+					if cliErr != nil {
+						a.ClientErrorMessage(fmt.Sprintf("Unable to update \"%s\"", song.Name), cliErr)
+						return
+					}
+				}
+				mfModal.SetText(fmt.Sprintf("Deleting \"%s\"", genre.Name))
+				a.cviewApp.Draw()
+
+				_, cliErr := a.restClient.DeleteGenre(genre.Id)
+				if cliErr != nil {
+					a.ClientErrorMessage(fmt.Sprintf("Unable to delete \"%s\"", genre.Name), cliErr)
+					return
+				}
+			}()
+		}
+	})
+
+	a.pagesComponent.AddPage("genreDeleteConfirm", modal, false, true)
+}
+
 func (a *App) ConfirmAlbumDelete(album *restApiV1.Album) {
 	// Only admin can delete an album
 	if !a.IsConnectedUserAdmin() {
@@ -378,6 +445,48 @@ func (a *App) ConfirmPlaylistDelete(playlist *restApiV1.Playlist) {
 	a.pagesComponent.AddPage("playlistDeleteConfirm", modal, false, true)
 }
 
+func (a *App) ConfirmPlaylistFolderDelete(playlistFolder *restApiV1.PlaylistFolder) {
+	// Only admin can delete a playlist folder
+	if !a.IsConnectedUserAdmin() {
+		a.WarningMessage("Only administrator can delete this playlist folder")
+		return
+	}
+
+	// Only empty folders can be deleted
+	if len(a.localDb.FolderOrderedPlaylistFolders[playlistFolder.Id])+len(a.localDb.FolderOrderedPlaylists[playlistFolder.Id]) > 0 {
+		a.WarningMessage("Move or delete the content of \"" + playlistFolder.Name + "\" before deleting it")
+		return
+	}
+
+	currentFocus := a.cviewApp.GetFocus()
+	modal := cview.NewModal()
+	modal.SetText("Do you want to delete \"" + playlistFolder.Name + "\" ?")
+	modal.AddButtons([]string{"Yes", "Cancel"})
+	modal.SetDoneFunc(func(buttonIndex int, buttonLabel string) {
+		a.pagesComponent.HidePage("playlistFolderDeleteConfirm")
+		a.pagesComponent.RemovePage("playlistFolderDeleteConfirm")
+		a.cviewApp.SetFocus(currentFocus)
+
+		if buttonLabel == "Yes" {
+			mfModal := a.OpenModalMessage(fmt.Sprintf("Deleting \"%s\"", playlistFolder.Name))
+
+			go func() {
+				defer func() {
+					mfModal.Close()
+					a.Reload()
+				}()
+
+				_, cliErr := a.restClient.DeletePlaylistFolder(playlistFolder.Id)
+				if cliErr != nil {
+					a.ClientErrorMessage(fmt.Sprintf("Unable to delete \"%s\"", playlistFolder.Name), cliErr)
+					return
+				}
+			}()
+		}
+	})
+	a.pagesComponent.AddPage("playlistFolderDeleteConfirm", modal, false, true)
+}
+
 func (a *App) ConfirmUserDelete(user *restApiV1.User) {
 	// Only admin can delete a user
 	if !a.IsConnectedUserAdmin() {
@@ -1,17 +1,25 @@
 package ui
 
 import (
+	"bytes"
 	"codeberg.org/tslocum/cview"
 	"github.com/jypelle/mifasol/restApiV1"
+	"io/ioutil"
+	"net/http"
 )
 
 type ArtistEditComponent struct {
 	*cview.Form
-	nameInputField  *cview.InputField
-	uiApp           *App
-	artistId        restApiV1.ArtistId
-	artistMeta      *restApiV1.ArtistMeta
-	originPrimitive cview.Primitive
+	nameInputField        *cview.InputField
+	sortNameInputField    *cview.InputField
+	biographyInputField   *cview.InputField
+	pictureInputField     *cview.InputField
+	removePictureCheckbox *cview.CheckBox
+	pictureTextView       *cview.TextView
+	uiApp                 *App
+	artistId              restApiV1.ArtistId
+	artistMeta            *restApiV1.ArtistMeta
+	originPrimitive       cview.Primitive
 }
 
 func OpenArtistCreateComponent(uiApp *App, originPrimitive cview.Primitive) {
@@ -38,11 +46,39 @@ func OpenArtistEditComponent(uiApp *App, artistId restApiV1.ArtistId, artistMeta
 	c.nameInputField.SetText(artistMeta.Name)
 	c.nameInputField.SetFieldWidth(50)
 
+	c.sortNameInputField = cview.NewInputField()
+	c.sortNameInputField.SetLabel("Sort name")
+	c.sortNameInputField.SetText(artistMeta.SortName)
+	c.sortNameInputField.SetFieldWidth(50)
+
+	c.biographyInputField = cview.NewInputField()
+	c.biographyInputField.SetLabel("Biography")
+	c.biographyInputField.SetText(artistMeta.Biography)
+	c.biographyInputField.SetFieldWidth(50)
+
+	// Picture
+	c.pictureInputField = cview.NewInputField()
+	c.pictureInputField.SetLabel("New picture (jpeg/png file)")
+	c.pictureInputField.SetFieldWidth(50)
+
+	c.removePictureCheckbox = cview.NewCheckBox()
+	c.removePictureCheckbox.SetLabel("Remove picture")
+
+	c.pictureTextView = cview.NewTextView()
+	c.pictureTextView.SetDynamicColors(true)
+
 	c.Form = cview.NewForm()
 	c.Form.SetFieldTextColorFocused(cview.Styles.PrimitiveBackgroundColor)
 	c.Form.SetFieldBackgroundColorFocused(cview.Styles.PrimaryTextColor)
 
 	c.Form.AddFormItem(c.nameInputField)
+	c.Form.AddFormItem(c.sortNameInputField)
+	c.Form.AddFormItem(c.biographyInputField)
+	c.Form.AddFormItem(c.pictureInputField)
+	if artist, ok := uiApp.LocalDb().Artists[artistId]; ok && artist.PictureFg {
+		c.Form.AddFormItem(c.removePictureCheckbox)
+		c.loadPicture()
+	}
 	c.Form.AddButton("Save", c.save)
 	c.Form.AddButton("Cancel", c.cancel)
 	if c.artistId != "" {
@@ -53,24 +89,70 @@ func OpenArtistEditComponent(uiApp *App, artistId restApiV1.ArtistId, artistMeta
 		c.Form.SetTitle("Create artist")
 	}
 
-	uiApp.pagesComponent.AddAndSwitchToPage("artistEdit", c, true)
+	flex := cview.NewFlex()
+	flex.SetDirection(cview.FlexColumn)
+	flex.AddItem(c.Form, 0, 1, true)
+	flex.AddItem(c.pictureTextView, 34, 0, false)
+
+	uiApp.pagesComponent.AddAndSwitchToPage("artistEdit", flex, true)
+}
+
+func (c *ArtistEditComponent) loadPicture() {
+	pictureReader, _, cliErr := c.uiApp.restClient.ReadArtistPicture(c.artistId)
+	if cliErr != nil {
+		c.uiApp.ClientErrorMessage("Unable to retrieve artist picture", cliErr)
+		return
+	}
+	defer pictureReader.Close()
+
+	preview, err := coverPreview(pictureReader, 32)
+	if err != nil {
+		c.uiApp.WarningMessage("Unable to display artist picture")
+		return
+	}
+	c.pictureTextView.SetText(preview)
 }
 
 func (c *ArtistEditComponent) save() {
 	c.artistMeta.Name = c.nameInputField.GetText()
-	if c.artistId != "" {
-		_, cliErr := c.uiApp.restClient.UpdateArtist(c.artistId, c.artistMeta)
+	c.artistMeta.SortName = c.sortNameInputField.GetText()
+	c.artistMeta.Biography = c.biographyInputField.GetText()
+	artistId := c.artistId
+	if artistId != "" {
+		_, cliErr := c.uiApp.restClient.UpdateArtist(artistId, c.artistMeta)
 		if cliErr != nil {
 			c.uiApp.ClientErrorMessage("Unable to update the artist", cliErr)
 			return
 		}
 	} else {
-		_, cliErr := c.uiApp.restClient.CreateArtist(c.artistMeta)
+		artist, cliErr := c.uiApp.restClient.CreateArtist(c.artistMeta)
 		if cliErr != nil {
 			c.uiApp.ClientErrorMessage("Unable to create the artist", cliErr)
 			return
 		}
+		artistId = artist.Id
 	}
+
+	// Update picture
+	if c.pictureInputField.GetText() != "" {
+		content, err := ioutil.ReadFile(c.pictureInputField.GetText())
+		if err != nil {
+			c.uiApp.WarningMessage("Unable to read picture file: " + c.pictureInputField.GetText())
+			return
+		}
+		_, cliErr := c.uiApp.restClient.UpdateArtistPicture(artistId, http.DetectContentType(content), bytes.NewReader(content))
+		if cliErr != nil {
+			c.uiApp.ClientErrorMessage("Unable to update the artist picture", cliErr)
+			return
+		}
+	} else if c.removePictureCheckbox.IsChecked() {
+		_, cliErr := c.uiApp.restClient.DeleteArtistPicture(artistId)
+		if cliErr != nil {
+			c.uiApp.ClientErrorMessage("Unable to remove the artist picture", cliErr)
+			return
+		}
+	}
+
 	c.close()
 	c.uiApp.Reload()
 }
@@ -9,9 +9,12 @@ var ColorTitleStr = "#441800"
 
 var ColorTitleBackground = tcell.NewHexColor(0xf0f0f0)
 var ColorTitleUnfocusedBackground = tcell.NewHexColor(0xa0a0a0)
+var ColorWaveformUnplayedStr = "#A0A0A0"
 
 var ColorArtist = tcell.NewHexColor(0xA0A9CC)
 var ColorArtistStr = "#A0A9CC"
+var ColorGenre = tcell.NewHexColor(0xA2C975)
+var ColorGenreStr = "#A2C975"
 var ColorAlbum = tcell.NewHexColor(0x5ADFDF)
 var ColorAlbumStr = "#5ADFDF"
 var ColorPlaylist = tcell.NewHexColor(0xFFB500)
@@ -20,6 +23,7 @@ var ColorSong = tcell.NewHexColor(0xFFFFE5)
 var ColorSongStr = "#FFFFE5"
 var ColorUser = tcell.NewHexColor(0xFFFACD)
 var ColorUserStr = "#FFFACD"
+var ColorRatingStr = "#FFD700"
 
 var ColorSelected = tcell.NewHexColor(0x602020)
 var ColorUnfocusedSelected = tcell.NewHexColor(0x402020)
@@ -0,0 +1,41 @@
+package ui
+
+import (
+	"fmt"
+	"image"
+	_ "image/jpeg"
+	_ "image/png"
+	"io"
+	"strings"
+)
+
+// coverPreview render a picture as colored half-blocks (2 pixels per character)
+func coverPreview(reader io.Reader, width int) (string, error) {
+	img, _, err := image.Decode(reader)
+	if err != nil {
+		return "", err
+	}
+
+	bounds := img.Bounds()
+	if bounds.Dx() == 0 || bounds.Dy() == 0 {
+		return "", nil
+	}
+
+	height := width * bounds.Dy() / bounds.Dx()
+	height += height % 2
+
+	pixelColor := func(x, y int) string {
+		r, g, b, _ := img.At(bounds.Min.X+x*bounds.Dx()/width, bounds.Min.Y+y*bounds.Dy()/height).RGBA()
+		return fmt.Sprintf("#%02x%02x%02x", r>>8, g>>8, b>>8)
+	}
+
+	var text strings.Builder
+	for y := 0; y < height; y += 2 {
+		for x := 0; x < width; x++ {
+			text.WriteString("[" + pixelColor(x, y) + ":" + pixelColor(x, y+1) + "]▀")
+		}
+		text.WriteString("[-:-]\n")
+	}
+
+	return text.String(), nil
+}
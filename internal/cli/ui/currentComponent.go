@@ -52,8 +52,8 @@ func NewCurrentComponent(uiApp *App) *CurrentComponent {
 				OpenPlaylistContentSaveAsComponent(c.uiApp, c.songIds, c.srcPlaylistId, c)
 			case 's':
 				if c.uiApp.currentComponent.IsModified() {
-					if c.srcPlaylistId == nil {
-						// Save content as new playlist
+					if c.srcPlaylistId == nil || uiApp.localDb.Playlists[*c.srcPlaylistId].SmartRules != nil {
+						// Save content as new playlist, smart playlist content coming from its rules
 						OpenPlaylistContentSaveAsComponent(c.uiApp, c.songIds, c.srcPlaylistId, c)
 					} else {
 						// Save
@@ -254,6 +254,36 @@ func (c *CurrentComponent) LoadSongsFromArtist(artist *restApiV1.Artist) {
 	c.AddSongsFromArtist(artist)
 }
 
+func (c *CurrentComponent) AddSongsFromComposer(artist *restApiV1.Artist) {
+	for _, song := range c.uiApp.localDb.ComposerOrderedSongs[artist.Id] {
+		c.AddSong(song.Id)
+	}
+}
+
+func (c *CurrentComponent) LoadSongsFromComposer(artist *restApiV1.Artist) {
+	c.Clear()
+	c.SetModified(true)
+	c.AddSongsFromComposer(artist)
+}
+
+func (c *CurrentComponent) AddSongsFromGenre(genre *restApiV1.Genre) {
+	if genre != nil {
+		for _, song := range c.uiApp.localDb.GenreOrderedSongs[genre.Id] {
+			c.AddSong(song.Id)
+		}
+	} else {
+		for _, song := range c.uiApp.localDb.UnknownGenreSongs {
+			c.AddSong(song.Id)
+		}
+	}
+}
+
+func (c *CurrentComponent) LoadSongsFromGenre(genre *restApiV1.Genre) {
+	c.Clear()
+	c.SetModified(true)
+	c.AddSongsFromGenre(genre)
+}
+
 func (c *CurrentComponent) AddSongsFromPlaylist(playlist *restApiV1.Playlist) {
 	for _, songId := range playlist.SongIds {
 		c.AddSong(songId)
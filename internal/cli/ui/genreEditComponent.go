@@ -0,0 +1,86 @@
+package ui
+
+import (
+	"codeberg.org/tslocum/cview"
+	"github.com/jypelle/mifasol/restApiV1"
+)
+
+type GenreEditComponent struct {
+	*cview.Form
+	nameInputField  *cview.InputField
+	uiApp           *App
+	genreId         restApiV1.GenreId
+	genreMeta       *restApiV1.GenreMeta
+	originPrimitive cview.Primitive
+}
+
+func OpenGenreCreateComponent(uiApp *App, originPrimitive cview.Primitive) {
+	OpenGenreEditComponent(uiApp, "", &restApiV1.GenreMeta{}, originPrimitive)
+}
+
+func OpenGenreEditComponent(uiApp *App, genreId restApiV1.GenreId, genreMeta *restApiV1.GenreMeta, originPrimitive cview.Primitive) {
+
+	// Only admin can create or edit a genre
+	if !uiApp.IsConnectedUserAdmin() {
+		uiApp.WarningMessage("Only administrator can create or edit a genre")
+		return
+	}
+
+	c := &GenreEditComponent{
+		uiApp:           uiApp,
+		genreId:         genreId,
+		genreMeta:       genreMeta,
+		originPrimitive: originPrimitive,
+	}
+
+	c.nameInputField = cview.NewInputField()
+	c.nameInputField.SetLabel("Name")
+	c.nameInputField.SetText(genreMeta.Name)
+	c.nameInputField.SetFieldWidth(50)
+
+	c.Form = cview.NewForm()
+	c.Form.SetFieldTextColorFocused(cview.Styles.PrimitiveBackgroundColor)
+	c.Form.SetFieldBackgroundColorFocused(cview.Styles.PrimaryTextColor)
+
+	c.Form.AddFormItem(c.nameInputField)
+	c.Form.AddButton("Save", c.save)
+	c.Form.AddButton("Cancel", c.cancel)
+	if c.genreId != "" {
+		c.Form.SetBorder(true)
+		c.Form.SetTitle("Edit genre")
+	} else {
+		c.Form.SetBorder(true)
+		c.Form.SetTitle("Create genre")
+	}
+
+	uiApp.pagesComponent.AddAndSwitchToPage("genreEdit", c, true)
+}
+
+func (c *GenreEditComponent) save() {
+	c.genreMeta.Name = c.nameInputField.GetText()
+	if c.genreId != "" {
+		_, cliErr := c.uiApp.restClient.UpdateGenre(c.genreId, c.genreMeta)
+		if cliErr != nil {
+			c.uiApp.ClientErrorMessage("Unable to update the genre", cliErr)
+			return
+		}
+	} else {
+		_, cliErr := c.uiApp.restClient.CreateGenre(c.genreMeta)
+		if cliErr != nil {
+			c.uiApp.ClientErrorMessage("Unable to create the genre", cliErr)
+			return
+		}
+	}
+
+	c.close()
+	c.uiApp.Reload()
+}
+
+func (c *GenreEditComponent) cancel() {
+	c.close()
+}
+
+func (c *GenreEditComponent) close() {
+	c.uiApp.pagesComponent.RemovePage("genreEdit")
+	c.uiApp.cviewApp.SetFocus(c.originPrimitive)
+}
@@ -38,16 +38,18 @@ func NewHelpComponent(uiApp *App) *HelpComponent {
 
 [` + color.ColorHelpTitle2Str + `::u]"Library" shortcuts[-::-]
 
-'c'    : Create album / artist
-'e'    : Edit song / album / artist / playlist
-'d'    : Delete song / album / artist / playlist
-'a'    : Add song / album / artist / playlist to current playlist
-'l'    : Load song / album / artist / playlist to current playlist
+'c'    : Create album / artist / genre / smart playlist
+'e'    : Edit song / album / artist / genre / playlist / playlist folder
+'d'    : Delete song / album / artist / genre / playlist / playlist folder
+'a'    : Add song / album / artist / genre / playlist to current playlist
+'l'    : Load song / album / artist / genre / playlist to current playlist
 'f'    : Add to / Remove from favorite songs / playlists
-'/'    : Filter by song / album / artist name
+'n'    : Create playlist folder
+'0'-'5': Rate song (0 to remove rating)
+'/'    : Filter by song / album / artist / genre name
 <LEFT> : Previous item
 <RIGHT>: Next item
-<ENTER>: Play song / Artist's songs / Album's songs / Playlist's songs
+<ENTER>: Play song / Artist's songs / Album's songs / Genre's songs / Playlist's songs / Folder's playlists
 <BACK> : Go back
 
 [` + color.ColorHelpTitle2Str + `::u]"Playlist" shortcuts[-::-]
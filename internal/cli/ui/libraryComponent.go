@@ -10,6 +10,7 @@ import (
 	"github.com/jypelle/mifasol/restApiV1"
 	"strconv"
 	"strings"
+	"time"
 )
 
 type LibraryComponent struct {
@@ -22,15 +23,22 @@ type LibraryComponent struct {
 	songs                []*restApiV1.Song
 	albums               []*restApiV1.Album
 	artists              []*restApiV1.Artist
+	genres               []*restApiV1.Genre
+	playlistFolders      []*restApiV1.PlaylistFolder
 	playlists            []*restApiV1.Playlist
+	statsItems           []*libraryStatsItem
 }
 
 type libraryFilter struct {
 	libraryType libraryType
 	artistId    *restApiV1.ArtistId
+	composerId  *restApiV1.ArtistId
 	albumId     *restApiV1.AlbumId
+	genreId     *restApiV1.GenreId
 	playlistId  *restApiV1.PlaylistId
+	folderId    *restApiV1.PlaylistFolderId
 	userId      *restApiV1.UserId
+	statsPeriod *tool.StatsPeriod
 	nameFilter  *string
 
 	index    int
@@ -42,10 +50,14 @@ type libraryType int64
 const (
 	libraryTypeMenu libraryType = iota
 	libraryTypeArtists
+	libraryTypeComposers
 	libraryTypeAlbums
+	libraryTypeGenres
 	libraryTypePlaylists
 	libraryTypeSongs
 	libraryTypeUsers
+	libraryTypeStatsPeriods
+	libraryTypeStats
 )
 
 func (l libraryFilter) label() string {
@@ -58,20 +70,27 @@ func (l libraryFilter) label() string {
 		} else {
 			return "Favorite artists from %s"
 		}
+	case libraryTypeComposers:
+		return "All composers"
 	case libraryTypeAlbums:
 		if l.userId == nil {
 			return "All albums"
 		} else {
 			return "Favorite albums from %s"
 		}
+	case libraryTypeGenres:
+		return "All genres"
 	case libraryTypePlaylists:
 		if l.userId == nil {
+			if l.folderId != nil {
+				return "Playlists from %s"
+			}
 			return "All playlists"
 		} else {
 			return "Favorite playlists from %s"
 		}
 	case libraryTypeSongs:
-		if l.userId == nil && l.playlistId == nil && l.artistId == nil && l.albumId == nil {
+		if l.userId == nil && l.playlistId == nil && l.artistId == nil && l.composerId == nil && l.albumId == nil && l.genreId == nil {
 			return "All songs"
 		}
 		if l.playlistId != nil {
@@ -87,6 +106,9 @@ func (l libraryFilter) label() string {
 				return "Songs from unknown artists"
 			}
 		}
+		if l.composerId != nil {
+			return "Songs composed by %s"
+		}
 		if l.albumId != nil {
 			if *l.albumId != restApiV1.UnknownAlbumId {
 				return "Songs from %s"
@@ -94,8 +116,19 @@ func (l libraryFilter) label() string {
 				return "Songs from unknown album"
 			}
 		}
+		if l.genreId != nil {
+			if *l.genreId != restApiV1.UnknownGenreId {
+				return "Songs from %s"
+			} else {
+				return "Songs from unknown genre"
+			}
+		}
 	case libraryTypeUsers:
 		return "All users"
+	case libraryTypeStatsPeriods:
+		return "Statistics"
+	case libraryTypeStats:
+		return "Statistics: %s"
 	}
 	return ""
 }
@@ -107,8 +140,11 @@ const (
 	libraryMenuMyFavoriteAlbums
 	libraryMenuMyFavoritePlaylists
 	libraryMenuMyFavoriteSongs
+	libraryMenuMyStatistics
 	libraryMenuAllArtists
+	libraryMenuAllComposers
 	libraryMenuAllAlbums
+	libraryMenuAllGenres
 	libraryMenuAllPlaylists
 	libraryMenuAllSongs
 	libraryMenuAllUsers
@@ -124,10 +160,16 @@ func (c libraryMenu) label() string {
 		return "Favorite playlists"
 	case libraryMenuMyFavoriteSongs:
 		return "Favorite songs"
+	case libraryMenuMyStatistics:
+		return "Statistics"
 	case libraryMenuAllArtists:
 		return "All artists"
+	case libraryMenuAllComposers:
+		return "All composers"
 	case libraryMenuAllAlbums:
 		return "All albums"
+	case libraryMenuAllGenres:
+		return "All genres"
 	case libraryMenuAllPlaylists:
 		return "All playlists"
 	case libraryMenuAllSongs:
@@ -143,8 +185,11 @@ var libraryMenus = []libraryMenu{
 	libraryMenuMyFavoriteAlbums,
 	libraryMenuMyFavoritePlaylists,
 	libraryMenuMyFavoriteSongs,
+	libraryMenuMyStatistics,
 	libraryMenuAllArtists,
+	libraryMenuAllComposers,
 	libraryMenuAllAlbums,
+	libraryMenuAllGenres,
 	libraryMenuAllPlaylists,
 	libraryMenuAllSongs,
 	libraryMenuAllUsers,
@@ -172,16 +217,25 @@ func NewLibraryComponent(uiApp *App) *LibraryComponent {
 		switch c.currentFilter().libraryType {
 		case libraryTypeMenu:
 			return libraryMenus[c.list.GetCurrentItem()].label()
-		case libraryTypeArtists:
+		case libraryTypeArtists, libraryTypeComposers:
 			return c.getMainTextArtist(c.artists[c.list.GetCurrentItem()], c.currentFilter().position)
 		case libraryTypeAlbums:
 			return c.getMainTextAlbum(c.albums[c.list.GetCurrentItem()], c.currentFilter().position)
+		case libraryTypeGenres:
+			return c.getMainTextGenre(c.genres[c.list.GetCurrentItem()])
 		case libraryTypePlaylists:
-			return c.getMainTextPlaylist(c.playlists[c.list.GetCurrentItem()], nil, c.currentFilter().position)
+			if playlistFolder := c.selectedPlaylistFolder(); playlistFolder != nil {
+				return c.getMainTextPlaylistFolder(playlistFolder)
+			}
+			return c.getMainTextPlaylist(c.selectedPlaylist(), nil, c.currentFilter().position)
 		case libraryTypeUsers:
 			return c.getMainTextUser(c.uiApp.LocalDb().OrderedUsers[c.list.GetCurrentItem()])
 		case libraryTypeSongs:
 			return c.getMainTextSong(c.songs[c.list.GetCurrentItem()], c.currentFilter().albumId, c.currentFilter().artistId, c.currentFilter().position)
+		case libraryTypeStatsPeriods:
+			return tool.StatsPeriods[c.list.GetCurrentItem()].Label(time.Now())
+		case libraryTypeStats:
+			return c.statsItems[c.list.GetCurrentItem()].text
 		}
 		return ""
 	})
@@ -223,11 +277,20 @@ func NewLibraryComponent(uiApp *App) *LibraryComponent {
 					case libraryTypeArtists:
 						artist := c.artists[c.list.GetCurrentItem()]
 						c.uiApp.CurrentComponent().AddSongsFromArtist(artist)
+					case libraryTypeComposers:
+						artist := c.artists[c.list.GetCurrentItem()]
+						c.uiApp.CurrentComponent().AddSongsFromComposer(artist)
 					case libraryTypeAlbums:
 						album := c.albums[c.list.GetCurrentItem()]
 						c.uiApp.CurrentComponent().AddSongsFromAlbum(album)
+					case libraryTypeGenres:
+						genre := c.genres[c.list.GetCurrentItem()]
+						c.uiApp.CurrentComponent().AddSongsFromGenre(genre)
 					case libraryTypePlaylists:
-						playlist := c.playlists[c.list.GetCurrentItem()]
+						playlist := c.selectedPlaylist()
+						if playlist == nil {
+							return nil
+						}
 						c.uiApp.CurrentComponent().AddSongsFromPlaylist(playlist)
 					case libraryTypeSongs:
 						song := c.songs[c.list.GetCurrentItem()]
@@ -243,12 +306,19 @@ func NewLibraryComponent(uiApp *App) *LibraryComponent {
 					case libraryTypeArtists:
 						artist := c.artists[c.list.GetCurrentItem()]
 						c.uiApp.CurrentComponent().LoadSongsFromArtist(artist)
+					case libraryTypeComposers:
+						artist := c.artists[c.list.GetCurrentItem()]
+						c.uiApp.CurrentComponent().LoadSongsFromComposer(artist)
 					case libraryTypeAlbums:
 						album := c.albums[c.list.GetCurrentItem()]
 						c.uiApp.CurrentComponent().LoadSongsFromAlbum(album)
+					case libraryTypeGenres:
+						genre := c.genres[c.list.GetCurrentItem()]
+						c.uiApp.CurrentComponent().LoadSongsFromGenre(genre)
 					case libraryTypePlaylists:
-						playlist := c.playlists[c.list.GetCurrentItem()]
-						c.uiApp.CurrentComponent().LoadSongsFromPlaylist(playlist)
+						if playlist := c.selectedPlaylist(); playlist != nil {
+							c.uiApp.CurrentComponent().LoadSongsFromPlaylist(playlist)
+						}
 					case libraryTypeSongs:
 						song := c.songs[c.list.GetCurrentItem()]
 						c.uiApp.CurrentComponent().LoadSong(song.Id)
@@ -259,10 +329,14 @@ func NewLibraryComponent(uiApp *App) *LibraryComponent {
 			case 'c':
 				if currentFilter.libraryType != libraryTypeMenu {
 					switch currentFilter.libraryType {
-					case libraryTypeArtists:
+					case libraryTypeArtists, libraryTypeComposers:
 						OpenArtistCreateComponent(c.uiApp, c)
 					case libraryTypeAlbums:
 						OpenAlbumCreateComponent(c.uiApp, c)
+					case libraryTypeGenres:
+						OpenGenreCreateComponent(c.uiApp, c)
+					case libraryTypePlaylists:
+						OpenSmartPlaylistCreateComponent(c.uiApp, currentFilter.folderId, c)
 					case libraryTypeUsers:
 						OpenUserCreateComponent(c.uiApp, c)
 					}
@@ -272,7 +346,7 @@ func NewLibraryComponent(uiApp *App) *LibraryComponent {
 			case 'd':
 				if c.list.GetItemCount() > 0 && currentFilter.libraryType != libraryTypeMenu {
 					switch currentFilter.libraryType {
-					case libraryTypeArtists:
+					case libraryTypeArtists, libraryTypeComposers:
 						artist := c.artists[c.list.GetCurrentItem()]
 						if artist != nil {
 							c.uiApp.ConfirmArtistDelete(artist)
@@ -282,9 +356,16 @@ func NewLibraryComponent(uiApp *App) *LibraryComponent {
 						if album != nil {
 							c.uiApp.ConfirmAlbumDelete(album)
 						}
+					case libraryTypeGenres:
+						genre := c.genres[c.list.GetCurrentItem()]
+						if genre != nil {
+							c.uiApp.ConfirmGenreDelete(genre)
+						}
 					case libraryTypePlaylists:
-						playlist := c.playlists[c.list.GetCurrentItem()]
-						if playlist != nil {
+						if playlistFolder := c.selectedPlaylistFolder(); playlistFolder != nil {
+							c.uiApp.ConfirmPlaylistFolderDelete(playlistFolder)
+						}
+						if playlist := c.selectedPlaylist(); playlist != nil {
 							c.uiApp.ConfirmPlaylistDelete(playlist)
 						}
 					case libraryTypeUsers:
@@ -304,7 +385,7 @@ func NewLibraryComponent(uiApp *App) *LibraryComponent {
 			case 'e':
 				if c.list.GetItemCount() > 0 && currentFilter.libraryType != libraryTypeMenu {
 					switch currentFilter.libraryType {
-					case libraryTypeArtists:
+					case libraryTypeArtists, libraryTypeComposers:
 						artist := c.artists[c.list.GetCurrentItem()]
 						if artist != nil {
 							OpenArtistEditComponent(c.uiApp, artist.Id, &artist.ArtistMeta, c)
@@ -314,9 +395,16 @@ func NewLibraryComponent(uiApp *App) *LibraryComponent {
 						if album != nil {
 							OpenAlbumEditComponent(c.uiApp, album.Id, &album.AlbumMeta, c)
 						}
+					case libraryTypeGenres:
+						genre := c.genres[c.list.GetCurrentItem()]
+						if genre != nil {
+							OpenGenreEditComponent(c.uiApp, genre.Id, &genre.GenreMeta, c)
+						}
 					case libraryTypePlaylists:
-						playlist := c.playlists[c.list.GetCurrentItem()]
-						if playlist != nil {
+						if playlistFolder := c.selectedPlaylistFolder(); playlistFolder != nil {
+							OpenPlaylistFolderEditComponent(c.uiApp, playlistFolder.Id, &playlistFolder.PlaylistFolderMeta, c)
+						}
+						if playlist := c.selectedPlaylist(); playlist != nil {
 							OpenPlaylistEditComponent(c.uiApp, playlist.Id, &playlist.PlaylistMeta, c)
 						}
 					case libraryTypeUsers:
@@ -337,7 +425,7 @@ func NewLibraryComponent(uiApp *App) *LibraryComponent {
 				if c.list.GetItemCount() > 0 && currentFilter.libraryType != libraryTypeMenu {
 					switch currentFilter.libraryType {
 					case libraryTypePlaylists:
-						playlist := c.playlists[c.list.GetCurrentItem()]
+						playlist := c.selectedPlaylist()
 						if playlist != nil {
 							myFavoritePlaylistIds := c.uiApp.LocalDb().UserFavoritePlaylistIds[c.uiApp.ConnectedUserId()]
 							favoritePlaylistId := restApiV1.FavoritePlaylistId{
@@ -391,11 +479,26 @@ func NewLibraryComponent(uiApp *App) *LibraryComponent {
 					}
 				}
 				return nil
+			case 'n':
+				if currentFilter.libraryType == libraryTypePlaylists && currentFilter.userId == nil {
+					OpenPlaylistFolderCreateComponent(c.uiApp, currentFilter.folderId, c)
+				}
+				return nil
+			case '0', '1', '2', '3', '4', '5':
+				if c.list.GetItemCount() > 0 && currentFilter.libraryType == libraryTypeSongs {
+					song := c.songs[c.list.GetCurrentItem()]
+					if song != nil {
+						c.rateSong(song, int64(event.Rune()-'0'))
+					}
+				}
+				return nil
 			case '/':
 				switch currentFilter.libraryType {
 				case libraryTypeSongs,
 					libraryTypeAlbums,
-					libraryTypeArtists:
+					libraryTypeGenres,
+					libraryTypeArtists,
+					libraryTypeComposers:
 					if c.currentFilter().nameFilter == nil {
 						initNameFilter := ""
 						c.currentFilter().nameFilter = &initNameFilter
@@ -430,10 +533,16 @@ func NewLibraryComponent(uiApp *App) *LibraryComponent {
 						c.GoToFavoritePlaylistsFromUserFilter(c.uiApp.ConnectedUserId())
 					case libraryMenuMyFavoriteSongs:
 						c.GoToFavoriteSongsFromUserFilter(c.uiApp.ConnectedUserId())
+					case libraryMenuMyStatistics:
+						c.GoToStatsPeriodsFilter()
 					case libraryMenuAllArtists:
 						c.GoToAllArtistsFilter()
+					case libraryMenuAllComposers:
+						c.GoToAllComposersFilter()
 					case libraryMenuAllAlbums:
 						c.GoToAllAlbumsFilter()
+					case libraryMenuAllGenres:
+						c.GoToAllGenresFilter()
 					case libraryMenuAllPlaylists:
 						c.GoToAllPlaylistsFilter()
 					case libraryMenuAllSongs:
@@ -449,6 +558,9 @@ func NewLibraryComponent(uiApp *App) *LibraryComponent {
 						songId, artistId, albumId := c.getPositionnedIdArtist(c.artists[c.list.GetCurrentItem()], c.currentFilter().position)
 						c.open(songId, artistId, albumId)
 					}
+				case libraryTypeComposers:
+					artist := c.artists[c.list.GetCurrentItem()]
+					c.GoToSongsFromComposerFilter(artist.Id)
 				case libraryTypeAlbums:
 					album := c.albums[c.list.GetCurrentItem()]
 					if album == nil {
@@ -457,13 +569,28 @@ func NewLibraryComponent(uiApp *App) *LibraryComponent {
 						songId, artistId, albumId := c.getPositionnedIdAlbum(c.albums[c.list.GetCurrentItem()], c.currentFilter().position)
 						c.open(songId, artistId, albumId)
 					}
+				case libraryTypeGenres:
+					genre := c.genres[c.list.GetCurrentItem()]
+					if genre == nil {
+						c.GoToSongsFromUnknownGenreFilter()
+					} else {
+						c.GoToSongsFromGenreFilter(genre.Id)
+					}
 				case libraryTypePlaylists:
-					playlist := c.playlists[c.list.GetCurrentItem()]
-					c.GoToSongsFromPlaylistFilter(playlist.Id)
+					if playlistFolder := c.selectedPlaylistFolder(); playlistFolder != nil {
+						c.GoToPlaylistsFromFolderFilter(playlistFolder.Id)
+					} else {
+						c.GoToSongsFromPlaylistFilter(c.selectedPlaylist().Id)
+					}
 				case libraryTypeSongs:
 					songId, artistId, albumId := c.getPositionnedIdSong(c.songs[c.list.GetCurrentItem()], c.currentFilter().albumId, c.currentFilter().artistId, c.currentFilter().position)
 					c.open(songId, artistId, albumId)
 				case libraryTypeUsers:
+				case libraryTypeStatsPeriods:
+					c.GoToStatsFilter(tool.StatsPeriods[c.list.GetCurrentItem()])
+				case libraryTypeStats:
+					statsItem := c.statsItems[c.list.GetCurrentItem()]
+					c.open(statsItem.songId, statsItem.artistId, statsItem.albumId)
 				}
 			}
 			return nil
@@ -543,14 +670,26 @@ func (c *LibraryComponent) GoToAllArtistsFilter() {
 	c.historizeLibraryFilter(&libraryFilter{libraryType: libraryTypeArtists})
 }
 
+func (c *LibraryComponent) GoToAllComposersFilter() {
+	c.historizeLibraryFilter(&libraryFilter{libraryType: libraryTypeComposers})
+}
+
 func (c *LibraryComponent) GoToAllAlbumsFilter() {
 	c.historizeLibraryFilter(&libraryFilter{libraryType: libraryTypeAlbums})
 }
 
+func (c *LibraryComponent) GoToAllGenresFilter() {
+	c.historizeLibraryFilter(&libraryFilter{libraryType: libraryTypeGenres})
+}
+
 func (c *LibraryComponent) GoToAllPlaylistsFilter() {
 	c.historizeLibraryFilter(&libraryFilter{libraryType: libraryTypePlaylists})
 }
 
+func (c *LibraryComponent) GoToPlaylistsFromFolderFilter(playlistFolderId restApiV1.PlaylistFolderId) {
+	c.historizeLibraryFilter(&libraryFilter{libraryType: libraryTypePlaylists, folderId: &playlistFolderId})
+}
+
 func (c *LibraryComponent) GoToAllSongsFilter() {
 	c.historizeLibraryFilter(&libraryFilter{libraryType: libraryTypeSongs})
 }
@@ -575,6 +714,18 @@ func (c *LibraryComponent) GoToSongsFromUnknownArtistFilter() {
 	c.historizeLibraryFilter(&libraryFilter{libraryType: libraryTypeSongs, artistId: &restApiV1.UnknownArtistId})
 }
 
+func (c *LibraryComponent) GoToSongsFromComposerFilter(artistId restApiV1.ArtistId) {
+	c.historizeLibraryFilter(&libraryFilter{libraryType: libraryTypeSongs, composerId: &artistId})
+}
+
+func (c *LibraryComponent) GoToSongsFromGenreFilter(genreId restApiV1.GenreId) {
+	c.historizeLibraryFilter(&libraryFilter{libraryType: libraryTypeSongs, genreId: &genreId})
+}
+
+func (c *LibraryComponent) GoToSongsFromUnknownGenreFilter() {
+	c.historizeLibraryFilter(&libraryFilter{libraryType: libraryTypeSongs, genreId: &restApiV1.UnknownGenreId})
+}
+
 func (c *LibraryComponent) GoToSongsFromPlaylistFilter(playlistId restApiV1.PlaylistId) {
 	c.historizeLibraryFilter(&libraryFilter{libraryType: libraryTypeSongs, playlistId: &playlistId})
 }
@@ -595,6 +746,14 @@ func (c *LibraryComponent) GoToFavoriteSongsFromUserFilter(userId restApiV1.User
 	c.historizeLibraryFilter(&libraryFilter{libraryType: libraryTypeSongs, userId: &userId})
 }
 
+func (c *LibraryComponent) GoToStatsPeriodsFilter() {
+	c.historizeLibraryFilter(&libraryFilter{libraryType: libraryTypeStatsPeriods})
+}
+
+func (c *LibraryComponent) GoToStatsFilter(statsPeriod tool.StatsPeriod) {
+	c.historizeLibraryFilter(&libraryFilter{libraryType: libraryTypeStats, statsPeriod: &statsPeriod})
+}
+
 func (c *LibraryComponent) RefreshView() {
 	c.RefreshList()
 	c.refreshNameFilter()
@@ -602,7 +761,7 @@ func (c *LibraryComponent) RefreshView() {
 }
 
 func (c *LibraryComponent) RefreshList() {
-	// Redirection to menu when filter references obsolete artist/album/playlist/user id
+	// Redirection to menu when filter references obsolete artist/album/genre/playlist/user id
 	currentFilter := c.currentFilter()
 
 	if currentFilter.albumId != nil && *currentFilter.albumId != restApiV1.UnknownAlbumId {
@@ -617,12 +776,30 @@ func (c *LibraryComponent) RefreshList() {
 			return
 		}
 	}
+	if currentFilter.composerId != nil {
+		if _, ok := c.uiApp.LocalDb().Artists[*currentFilter.composerId]; !ok {
+			c.ResetToMenuFilter()
+			return
+		}
+	}
+	if currentFilter.genreId != nil && *currentFilter.genreId != restApiV1.UnknownGenreId {
+		if _, ok := c.uiApp.LocalDb().Genres[*currentFilter.genreId]; !ok {
+			c.ResetToMenuFilter()
+			return
+		}
+	}
 	if currentFilter.playlistId != nil {
 		if _, ok := c.uiApp.LocalDb().Playlists[*currentFilter.playlistId]; !ok {
 			c.ResetToMenuFilter()
 			return
 		}
 	}
+	if currentFilter.folderId != nil {
+		if _, ok := c.uiApp.LocalDb().PlaylistFolders[*currentFilter.folderId]; !ok {
+			c.ResetToMenuFilter()
+			return
+		}
+	}
 	if currentFilter.userId != nil {
 		if _, ok := c.uiApp.LocalDb().Users[*currentFilter.userId]; !ok {
 			c.ResetToMenuFilter()
@@ -634,14 +811,17 @@ func (c *LibraryComponent) RefreshList() {
 	c.list.Clear()
 	oldIndex := currentFilter.index
 	c.songs = nil
+	c.playlistFolders = nil
 	title := c.getTitlePrefix() + ": " + currentFilter.label()
 	switch currentFilter.libraryType {
 	case libraryTypeMenu:
 		for _, libraryMenu := range libraryMenus {
 			c.list.AddItem(libraryMenu.label())
 		}
-	case libraryTypeArtists:
-		if currentFilter.userId == nil {
+	case libraryTypeArtists, libraryTypeComposers:
+		if currentFilter.libraryType == libraryTypeComposers {
+			c.artists = c.uiApp.LocalDb().OrderedComposers
+		} else if currentFilter.userId == nil {
 			c.artists = c.uiApp.LocalDb().OrderedArtists
 		} else {
 			user := c.uiApp.LocalDb().Users[*currentFilter.userId]
@@ -688,9 +868,37 @@ func (c *LibraryComponent) RefreshList() {
 		for _, album := range c.albums {
 			c.list.AddItem(c.getMainTextAlbum(album, -1))
 		}
+	case libraryTypeGenres:
+		c.genres = c.uiApp.LocalDb().OrderedGenres
+
+		// Remove non-matching genre names
+		if currentFilter.nameFilter != nil {
+			searchNameFilter := tool.SearchLib(*currentFilter.nameFilter)
+			var filteredGenres []*restApiV1.Genre
+			for _, genre := range c.genres {
+				if genre == nil || strings.Contains(tool.SearchLib(genre.Name), searchNameFilter) {
+					filteredGenres = append(filteredGenres, genre)
+				}
+			}
+			c.genres = filteredGenres
+		}
+
+		for _, genre := range c.genres {
+			c.list.AddItem(c.getMainTextGenre(genre))
+		}
 	case libraryTypePlaylists:
 		if currentFilter.userId == nil {
-			c.playlists = c.uiApp.LocalDb().OrderedPlaylists
+			// Folder content, sub folders first
+			var folderId restApiV1.PlaylistFolderId
+			if currentFilter.folderId != nil {
+				folderId = *currentFilter.folderId
+				title = fmt.Sprintf(title, c.uiApp.LocalDb().PlaylistFolderLabel(folderId))
+			}
+			c.playlistFolders = c.uiApp.LocalDb().FolderOrderedPlaylistFolders[folderId]
+			c.playlists = c.uiApp.LocalDb().FolderOrderedPlaylists[folderId]
+			for _, playlistFolder := range c.playlistFolders {
+				c.list.AddItem(c.getMainTextPlaylistFolder(playlistFolder))
+			}
 		} else {
 			user := c.uiApp.LocalDb().Users[*currentFilter.userId]
 			title = fmt.Sprintf(title, user.Name)
@@ -698,7 +906,7 @@ func (c *LibraryComponent) RefreshList() {
 		}
 		c.loadPlaylists(c.playlists, nil)
 	case libraryTypeSongs:
-		if currentFilter.userId == nil && currentFilter.playlistId == nil && currentFilter.artistId == nil && currentFilter.albumId == nil {
+		if currentFilter.userId == nil && currentFilter.playlistId == nil && currentFilter.artistId == nil && currentFilter.composerId == nil && currentFilter.albumId == nil && currentFilter.genreId == nil {
 			c.songs = c.uiApp.LocalDb().OrderedSongs
 		}
 		if currentFilter.playlistId != nil {
@@ -732,6 +940,20 @@ func (c *LibraryComponent) RefreshList() {
 				c.songs = c.uiApp.LocalDb().UnknownArtistSongs
 			}
 		}
+		if currentFilter.composerId != nil {
+			artist := c.uiApp.LocalDb().Artists[*currentFilter.composerId]
+			title = fmt.Sprintf(title, artist.Name)
+			c.songs = c.uiApp.LocalDb().ComposerOrderedSongs[artist.Id]
+		}
+		if currentFilter.genreId != nil {
+			if *currentFilter.genreId != restApiV1.UnknownGenreId {
+				genre := c.uiApp.LocalDb().Genres[*currentFilter.genreId]
+				title = fmt.Sprintf(title, genre.Name)
+				c.songs = c.uiApp.LocalDb().GenreOrderedSongs[genre.Id]
+			} else {
+				c.songs = c.uiApp.LocalDb().UnknownGenreSongs
+			}
+		}
 		// Remove non-matching song names
 		if currentFilter.nameFilter != nil {
 			searchNameFilter := tool.SearchLib(*currentFilter.nameFilter)
@@ -758,6 +980,13 @@ func (c *LibraryComponent) RefreshList() {
 		for _, user := range c.uiApp.LocalDb().OrderedUsers {
 			c.list.AddItem(c.getMainTextUser(user))
 		}
+	case libraryTypeStatsPeriods:
+		for _, statsPeriod := range tool.StatsPeriods {
+			c.list.AddItem(statsPeriod.Label(time.Now()))
+		}
+	case libraryTypeStats:
+		title = fmt.Sprintf(title, currentFilter.statsPeriod.Label(time.Now()))
+		c.loadStats(*currentFilter.statsPeriod)
 	}
 	c.title.SetText(title)
 	c.list.SetCurrentItem(oldIndex)
@@ -819,6 +1048,9 @@ func (c *LibraryComponent) getMainTextSong(song *restApiV1.Song, fromAlbumId *re
 			underline = "u"
 		}
 		text += "[" + color.ColorSongStr + "::" + underline + "]" + cview.Escape(song.Name) + "[" + color.ColorWhiteStr + "::-]"
+		if rating, ok := c.uiApp.LocalDb().UserSongRatings[c.uiApp.ConnectedUserId()][song.Id]; ok {
+			text += " [" + color.ColorRatingStr + "]" + strings.Repeat("★", int(rating)) + "[" + color.ColorWhiteStr + "]"
+		}
 	}
 	currentPosition++
 
@@ -858,6 +1090,32 @@ func (c *LibraryComponent) getMainTextSong(song *restApiV1.Song, fromAlbumId *re
 	return text
 }
 
+// rateSong give stars to a song for the connected user, 0 to remove its rating
+func (c *LibraryComponent) rateSong(song *restApiV1.Song, rating int64) {
+	songRatingId := restApiV1.SongRatingId{
+		UserId: c.uiApp.ConnectedUserId(),
+		SongId: song.Id,
+	}
+	if rating == 0 {
+		if _, ok := c.uiApp.LocalDb().UserSongRatings[c.uiApp.ConnectedUserId()][song.Id]; !ok {
+			return
+		}
+		_, cliErr := c.uiApp.restClient.DeleteSongRating(songRatingId)
+		if cliErr != nil {
+			c.uiApp.ClientErrorMessage("Unable to remove song rating", cliErr)
+			return
+		}
+	} else {
+		_, cliErr := c.uiApp.restClient.UpdateSongRating(&restApiV1.SongRatingMeta{Id: songRatingId, Rating: rating})
+		if cliErr != nil {
+			c.uiApp.ClientErrorMessage("Unable to rate song", cliErr)
+			return
+		}
+	}
+	c.uiApp.LocalDb().SetMySongRating(song.Id, rating)
+	c.RefreshList()
+}
+
 func (c *LibraryComponent) getPositionnedIdAlbum(album *restApiV1.Album, highlightPosition int) (songId *restApiV1.SongId, artistId *restApiV1.ArtistId, albumId *restApiV1.AlbumId) {
 	currentPosition := 0
 
@@ -891,12 +1149,17 @@ func (c *LibraryComponent) getMainTextAlbum(album *restApiV1.Album, highlightPos
 
 	if album == nil {
 		if currentPosition >= highlightPosition {
-			text += "[" + color.ColorWhiteStr + "]" + cview.Escape("(Unknown album)") + "[" + color.ColorWhiteStr + "] (" + strconv.Itoa(len(c.uiApp.LocalDb().UnknownAlbumSongs)) + ")"
+			text += "  [" + color.ColorWhiteStr + "]" + cview.Escape("(Unknown album)") + "[" + color.ColorWhiteStr + "] (" + strconv.Itoa(len(c.uiApp.LocalDb().UnknownAlbumSongs)) + " - " + tool.FormatDuration(c.uiApp.LocalDb().AlbumDuration(restApiV1.UnknownAlbumId)) + ")"
 		}
 		currentPosition++
 	} else {
 		if currentPosition >= highlightPosition {
-			text += "[" + color.ColorAlbumStr + "]" + cview.Escape(album.Name) + "[" + color.ColorWhiteStr + "] (" + strconv.Itoa(len(c.uiApp.LocalDb().AlbumOrderedSongs[album.Id])) + ")"
+			if album.CoverFg {
+				text += "▣ "
+			} else {
+				text += "  "
+			}
+			text += "[" + color.ColorAlbumStr + "]" + cview.Escape(album.Name) + "[" + color.ColorWhiteStr + "] (" + strconv.Itoa(len(c.uiApp.LocalDb().AlbumOrderedSongs[album.Id])) + " - " + tool.FormatDuration(c.uiApp.LocalDb().AlbumDuration(album.Id)) + ")"
 		}
 		currentPosition++
 
@@ -940,12 +1203,24 @@ func (c *LibraryComponent) getMainTextArtist(artist *restApiV1.Artist, highlight
 
 	if artist == nil {
 		if currentPosition >= highlightPosition {
-			text += "[" + color.ColorWhiteStr + "]" + cview.Escape("(Unknown artist)") + "[" + color.ColorWhiteStr + "] (" + strconv.Itoa(len(c.uiApp.LocalDb().UnknownArtistSongs)) + ")"
+			text += "  [" + color.ColorWhiteStr + "]" + cview.Escape("(Unknown artist)") + "[" + color.ColorWhiteStr + "] (" + strconv.Itoa(len(c.uiApp.LocalDb().UnknownArtistSongs)) + ")"
 		}
 		currentPosition++
 	} else {
 		if currentPosition >= highlightPosition {
-			text += "[" + color.ColorArtistStr + "]" + cview.Escape(artist.Name) + "[" + color.ColorWhiteStr + "] (" + strconv.Itoa(len(c.uiApp.LocalDb().ArtistOrderedSongs[artist.Id])) + ")"
+			if artist.PictureFg {
+				text += "▣ "
+			} else {
+				text += "  "
+			}
+			artistSongs := c.uiApp.LocalDb().ArtistOrderedSongs[artist.Id]
+			if c.currentFilter().libraryType == libraryTypeComposers {
+				artistSongs = c.uiApp.LocalDb().ComposerOrderedSongs[artist.Id]
+			}
+			text += "[" + color.ColorArtistStr + "]" + cview.Escape(artist.Name) + "[" + color.ColorWhiteStr + "] (" + strconv.Itoa(len(artistSongs)) + ")"
+			if artist.Biography != "" {
+				text += " [::d]" + cview.Escape(tool.CharacterTruncate(artist.Biography, 60)) + "[::-]"
+			}
 		}
 		currentPosition++
 	}
@@ -953,6 +1228,41 @@ func (c *LibraryComponent) getMainTextArtist(artist *restApiV1.Artist, highlight
 	return text
 }
 
+func (c *LibraryComponent) getMainTextGenre(genre *restApiV1.Genre) string {
+	text := ""
+
+	if genre == nil {
+		text += "  [" + color.ColorWhiteStr + "]" + cview.Escape("(Unknown genre)") + "[" + color.ColorWhiteStr + "] (" + strconv.Itoa(len(c.uiApp.LocalDb().UnknownGenreSongs)) + ")"
+	} else {
+		text += "  [" + color.ColorGenreStr + "]" + cview.Escape(genre.Name) + "[" + color.ColorWhiteStr + "] (" + strconv.Itoa(len(c.uiApp.LocalDb().GenreOrderedSongs[genre.Id])) + ")"
+	}
+
+	return text
+}
+
+// selectedPlaylistFolder return the highlighted playlist folder, nil when a playlist is highlighted
+func (c *LibraryComponent) selectedPlaylistFolder() *restApiV1.PlaylistFolder {
+	ind := c.list.GetCurrentItem()
+	if ind < len(c.playlistFolders) {
+		return c.playlistFolders[ind]
+	}
+	return nil
+}
+
+// selectedPlaylist return the highlighted playlist, nil when a playlist folder is highlighted
+func (c *LibraryComponent) selectedPlaylist() *restApiV1.Playlist {
+	ind := c.list.GetCurrentItem() - len(c.playlistFolders)
+	if ind >= 0 && ind < len(c.playlists) {
+		return c.playlists[ind]
+	}
+	return nil
+}
+
+func (c *LibraryComponent) getMainTextPlaylistFolder(playlistFolder *restApiV1.PlaylistFolder) string {
+	return "▸ [" + color.ColorPlaylistStr + "::b]" + cview.Escape(playlistFolder.Name) + "[" + color.ColorWhiteStr + "::-] (" +
+		strconv.Itoa(len(c.uiApp.LocalDb().FolderOrderedPlaylistFolders[playlistFolder.Id])+len(c.uiApp.LocalDb().FolderOrderedPlaylists[playlistFolder.Id])) + ")"
+}
+
 func (c *LibraryComponent) loadPlaylists(playlists []*restApiV1.Playlist, fromOwnerUserId *restApiV1.UserId) {
 	for _, playlist := range playlists {
 		c.list.AddItem(c.getMainTextPlaylist(playlist, fromOwnerUserId, -1))
@@ -972,7 +1282,11 @@ func (c *LibraryComponent) getMainTextPlaylist(playlist *restApiV1.Playlist, fro
 	}
 
 	if currentPosition >= highlightPosition {
-		text += "[" + color.ColorPlaylistStr + "]" + cview.Escape(playlist.Name) + "[" + color.ColorWhiteStr + "] (" + strconv.Itoa(len(c.uiApp.LocalDb().Playlists[playlist.Id].SongIds)) + ")"
+		text += "[" + color.ColorPlaylistStr + "]" + cview.Escape(playlist.Name) + "[" + color.ColorWhiteStr + "]"
+		if playlist.SmartRules != nil {
+			text += " [::d]smart[::-]"
+		}
+		text += " (" + strconv.Itoa(len(c.uiApp.LocalDb().Playlists[playlist.Id].SongIds)) + " - " + tool.FormatDuration(c.uiApp.LocalDb().PlaylistDuration(playlist.Id)) + ")"
 	}
 	currentPosition++
 
@@ -0,0 +1,114 @@
+package ui
+
+import (
+	"codeberg.org/tslocum/cview"
+	"fmt"
+	"github.com/jypelle/mifasol/internal/cli/ui/color"
+	"github.com/jypelle/mifasol/internal/tool"
+	"github.com/jypelle/mifasol/restApiV1"
+	"strings"
+	"time"
+)
+
+// libraryStatsDayBarWidth is the width of the bar drawn for the most listened day
+const libraryStatsDayBarWidth = 30
+
+// libraryStatsItem is a line of the statistics view, opening the song, artist or album it refers to
+type libraryStatsItem struct {
+	text     string
+	songId   *restApiV1.SongId
+	artistId *restApiV1.ArtistId
+	albumId  *restApiV1.AlbumId
+}
+
+// loadStats fill the list with the listening statistics of the connected user
+func (c *LibraryComponent) loadStats(statsPeriod tool.StatsPeriod) {
+	c.statsItems = nil
+
+	from, to := statsPeriod.Bounds(time.Now())
+	userStats, cliErr := c.uiApp.restClient.ReadUserStats(c.uiApp.ConnectedUserId(), from, to)
+	if cliErr != nil {
+		c.uiApp.ClientErrorMessage("Unable to retrieve statistics", cliErr)
+		return
+	}
+
+	c.addStatsItem(&libraryStatsItem{text: fmt.Sprintf("%d plays - %s listened", userStats.PlayCount, tool.FormatDuration(userStats.ListenedDuration))})
+
+	if len(userStats.TopSongs) > 0 {
+		c.addStatsHeader("Top songs")
+		for ind, songStats := range userStats.TopSongs {
+			if song, ok := c.uiApp.LocalDb().Songs[songStats.SongId]; ok {
+				c.addStatsItem(&libraryStatsItem{
+					text:   fmt.Sprintf("%2d. [%s]%s[%s] %s", ind+1, color.ColorSongStr, cview.Escape(song.Name), color.ColorWhiteStr, formatPlayStats(songStats.PlayStats)),
+					songId: &song.Id,
+				})
+			}
+		}
+	}
+
+	if len(userStats.TopArtists) > 0 {
+		c.addStatsHeader("Top artists")
+		for ind, artistStats := range userStats.TopArtists {
+			if artist, ok := c.uiApp.LocalDb().Artists[artistStats.ArtistId]; ok {
+				c.addStatsItem(&libraryStatsItem{
+					text:     fmt.Sprintf("%2d. [%s]%s[%s] %s", ind+1, color.ColorArtistStr, cview.Escape(artist.Name), color.ColorWhiteStr, formatPlayStats(artistStats.PlayStats)),
+					artistId: &artist.Id,
+				})
+			}
+		}
+	}
+
+	if len(userStats.TopAlbums) > 0 {
+		c.addStatsHeader("Top albums")
+		for ind, albumStats := range userStats.TopAlbums {
+			if album, ok := c.uiApp.LocalDb().Albums[albumStats.AlbumId]; ok {
+				c.addStatsItem(&libraryStatsItem{
+					text:    fmt.Sprintf("%2d. [%s]%s[%s] %s", ind+1, color.ColorAlbumStr, cview.Escape(album.Name), color.ColorWhiteStr, formatPlayStats(albumStats.PlayStats)),
+					albumId: &album.Id,
+				})
+			}
+		}
+	}
+
+	if len(userStats.Formats) > 0 {
+		c.addStatsHeader("Formats")
+		for _, formatStats := range userStats.Formats {
+			c.addStatsItem(&libraryStatsItem{
+				text: fmt.Sprintf("    %-5s %3d%% %s", formatStats.Format.String(), formatStats.PlayCount*100/userStats.PlayCount, formatPlayStats(formatStats.PlayStats)),
+			})
+		}
+	}
+
+	if len(userStats.Days) > 0 {
+		c.addStatsHeader("Listening time per day")
+		var maxListenedDuration int64
+		for _, dayStats := range userStats.Days {
+			if dayStats.ListenedDuration > maxListenedDuration {
+				maxListenedDuration = dayStats.ListenedDuration
+			}
+		}
+		for _, dayStats := range userStats.Days {
+			barWidth := 1
+			if maxListenedDuration > 0 {
+				barWidth = int(dayStats.ListenedDuration*libraryStatsDayBarWidth/maxListenedDuration) + 1
+			}
+			c.addStatsItem(&libraryStatsItem{
+				text: fmt.Sprintf("    %s [%s]%s[%s] %s", dayStats.Day, color.ColorPlaylistStr, strings.Repeat("█", barWidth), color.ColorWhiteStr, tool.FormatDuration(dayStats.ListenedDuration)),
+			})
+		}
+	}
+}
+
+func (c *LibraryComponent) addStatsHeader(header string) {
+	c.addStatsItem(&libraryStatsItem{text: "[::b]" + header + "[::-]"})
+}
+
+func (c *LibraryComponent) addStatsItem(statsItem *libraryStatsItem) {
+	c.statsItems = append(c.statsItems, statsItem)
+	c.list.AddItem(statsItem.text)
+}
+
+// formatPlayStats return the play count & listened duration of a group of plays
+func formatPlayStats(playStats restApiV1.PlayStats) string {
+	return fmt.Sprintf("(%d plays - %s)", playStats.PlayCount, tool.FormatDuration(playStats.ListenedDuration))
+}
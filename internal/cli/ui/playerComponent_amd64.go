@@ -22,6 +22,8 @@ type PlayerComponent struct {
 	titleBox    *cview.TextView
 	volumeBox   *cview.TextView
 	progressBox *cview.TextView
+	lyricsBox   *cview.TextView
+	waveformBox *cview.TextView
 	uiApp       *App
 
 	volume          int
@@ -32,9 +34,26 @@ type PlayerComponent struct {
 
 	refreshTicker *time.Ticker
 
-	playingSong *restApiV1.Song
+	playingSong       *restApiV1.Song
+	playingSongFormat restApiV1.SongFormat
+
+	playingLyrics        *restApiV1.SongLyrics
+	playingLyricsLineInd int
+
+	playingWaveform *restApiV1.SongWaveform
+
+	// Play history of the current song
+	playStartTs      int64
+	listenedDuration time.Duration
+	lastPosition     time.Duration
 }
 
+// playerWaveformPointCount is the width of the waveform drawn next to the progress
+const playerWaveformPointCount = 24
+
+// waveformBlocks are the characters drawing peaks, from silence to full scale
+var waveformBlocks = []rune("▁▂▃▄▅▆▇█")
+
 func NewPlayerComponent(uiApp *App, volume int) *PlayerComponent {
 
 	// Init soundcard
@@ -57,6 +76,16 @@ func NewPlayerComponent(uiApp *App, volume int) *PlayerComponent {
 	c.progressBox.SetBackgroundColor(color.ColorTitleBackground)
 	c.progressBox.SetTextAlign(cview.AlignRight)
 
+	c.lyricsBox = cview.NewTextView()
+	c.lyricsBox.SetDynamicColors(true)
+	c.lyricsBox.SetBackgroundColor(color.ColorTitleBackground)
+	c.lyricsBox.SetTextAlign(cview.AlignCenter)
+
+	c.waveformBox = cview.NewTextView()
+	c.waveformBox.SetDynamicColors(true)
+	c.waveformBox.SetBackgroundColor(color.ColorTitleBackground)
+	c.waveformBox.SetTextAlign(cview.AlignRight)
+
 	c.volumeBox = cview.NewTextView()
 	c.volumeBox.SetDynamicColors(true)
 	c.SetVolume(volume)
@@ -66,6 +95,8 @@ func NewPlayerComponent(uiApp *App, volume int) *PlayerComponent {
 	c.Flex = cview.NewFlex()
 	c.Flex.SetDirection(cview.FlexColumn)
 	c.Flex.AddItem(c.titleBox, 0, 1, false)
+	c.Flex.AddItem(c.lyricsBox, 0, 0, false)
+	c.Flex.AddItem(c.waveformBox, 0, 0, false)
 	c.Flex.AddItem(c.progressBox, 14, 0, false)
 	c.Flex.AddItem(c.volumeBox, 7, 0, false)
 
@@ -170,16 +201,30 @@ func (c *PlayerComponent) Play(songId restApiV1.SongId) {
 		return
 	}
 
+	c.reportPlay(false)
+
+	// Song content is transcoded by the server when a streaming profile is configured
+	songReader, songSize, songFormat, cliErr := c.uiApp.restClient.ReadSongStreamContent(song.Id, c.uiApp.StreamingProfile)
+	if cliErr != nil {
+		c.uiApp.ClientErrorMessage("Unable to retrieve content for: "+song.Name, cliErr)
+		return
+	}
+
+	// No opus & m4a decoder available for the console player
+	if songFormat == restApiV1.SongFormatOpus || songFormat == restApiV1.SongFormatM4a {
+		songReader.Close()
+		c.uiApp.WarningMessage("Unable to play " + song.Name + ": " + songFormat.String() + " format is not supported by the console player")
+		return
+	}
+
 	c.playingSong = song
+	c.playingSongFormat = songFormat
 
 	c.uiApp.Message("Start playing: " + c.getMainTextSong(c.playingSong))
 	c.uiApp.cviewApp.Draw()
 
-	songReader, songSize, cliErr := c.uiApp.restClient.ReadSongContent(song.Id)
-	if cliErr != nil {
-		c.uiApp.ClientErrorMessage("Unable to retrieve content for: "+c.playingSong.Name, cliErr)
-		return
-	}
+	c.loadLyrics(song)
+	c.loadWaveform(song)
 
 	speaker.Clear()
 
@@ -188,7 +233,7 @@ func (c *PlayerComponent) Play(songId restApiV1.SongId) {
 	var err error
 	var decoder func(rc io.ReadCloser) (s beep.StreamSeekCloser, format beep.Format, err error)
 
-	switch song.Format {
+	switch songFormat {
 	case restApiV1.SongFormatFlac:
 		decoder = func(rc io.ReadCloser) (s beep.StreamSeekCloser, format beep.Format, err error) {
 			return flac.Decode(rc)
@@ -198,7 +243,7 @@ func (c *PlayerComponent) Play(songId restApiV1.SongId) {
 	case restApiV1.SongFormatMp3:
 		decoder = mp3.Decode
 	default:
-		c.uiApp.WarningMessage("Unknown format: " + song.Format.String())
+		c.uiApp.WarningMessage("Unknown format: " + songFormat.String())
 		return
 	}
 
@@ -221,6 +266,17 @@ func (c *PlayerComponent) Play(songId restApiV1.SongId) {
 		Silent:   c.volume == 0,
 	}
 
+	speaker.Lock()
+	c.playStartTs = time.Now().UnixNano()
+	c.listenedDuration = 0
+	c.lastPosition = 0
+	speaker.Unlock()
+
+	cliErr = c.uiApp.restClient.SubmitNowPlaying(&restApiV1.NowPlayingMeta{UserId: c.uiApp.ConnectedUserId(), SongId: songId})
+	if cliErr != nil {
+		c.uiApp.ClientErrorMessage("Unable to report now playing song", cliErr)
+	}
+
 	speaker.Play(
 		beep.Seq(
 			c.volumeStreamer,
@@ -229,6 +285,7 @@ func (c *PlayerComponent) Play(songId restApiV1.SongId) {
 					c.uiApp.cviewApp.QueueUpdateDraw(func() {
 						c.titleBox.SetText("[" + color.ColorTitleStr + "]Stopped: " + c.getCompleteMainTextSong(c.playingSong))
 						c.refreshProgress()
+						c.reportPlay(true)
 						c.controlStreamer = nil
 						c.volumeStreamer = nil
 						c.musicStreamer = nil
@@ -247,6 +304,94 @@ func (c *PlayerComponent) Play(songId restApiV1.SongId) {
 
 }
 
+// reportPlay send the play of the current song to the server once it has ended or has been listened long enough
+func (c *PlayerComponent) reportPlay(ended bool) {
+	speaker.Lock()
+	playStartTs := c.playStartTs
+	listenedDuration := c.listenedDuration.Milliseconds()
+	c.playStartTs = 0
+	speaker.Unlock()
+
+	if c.playingSong == nil || playStartTs == 0 {
+		return
+	}
+	if !(ended && listenedDuration > 0) && !restApiV1.IsPlayReportable(listenedDuration, c.playingSong.Duration) {
+		return
+	}
+
+	_, cliErr := c.uiApp.restClient.CreatePlayEvent(&restApiV1.PlayEventMeta{
+		UserId:           c.uiApp.ConnectedUserId(),
+		SongId:           c.playingSong.Id,
+		StartTs:          playStartTs,
+		ListenedDuration: listenedDuration,
+		ClientKind:       restApiV1.ClientKindConsole,
+	})
+	if cliErr != nil {
+		c.uiApp.ClientErrorMessage("Unable to report play of: "+c.playingSong.Name, cliErr)
+	}
+}
+
+// loadLyrics retrieve the time-synced lyrics of the song, the lyrics box is only displayed for synced lyrics
+func (c *PlayerComponent) loadLyrics(song *restApiV1.Song) {
+	var songLyrics *restApiV1.SongLyrics
+	if song.LyricsFg {
+		lyrics, cliErr := c.uiApp.restClient.ReadSongLyrics(song.Id)
+		if cliErr != nil {
+			c.uiApp.ClientErrorMessage("Unable to retrieve lyrics for: "+song.Name, cliErr)
+		} else if lyrics.SyncedFg {
+			songLyrics = lyrics
+		}
+	}
+
+	speaker.Lock()
+	c.playingLyrics = songLyrics
+	c.playingLyricsLineInd = -1
+	speaker.Unlock()
+
+	c.lyricsBox.SetText("")
+	if songLyrics != nil {
+		c.Flex.ResizeItem(c.lyricsBox, 0, 1)
+	} else {
+		c.Flex.ResizeItem(c.lyricsBox, 0, 0)
+	}
+}
+
+// loadWaveform retrieve the song waveform, the waveform box is only displayed once the server has computed it
+func (c *PlayerComponent) loadWaveform(song *restApiV1.Song) {
+	songWaveform, cliErr := c.uiApp.restClient.ReadSongWaveform(song.Id, playerWaveformPointCount)
+	if cliErr != nil && cliErr.Code() != restApiV1.NotFoundErrorCode {
+		c.uiApp.ClientErrorMessage("Unable to retrieve waveform for: "+song.Name, cliErr)
+	}
+
+	speaker.Lock()
+	c.playingWaveform = songWaveform
+	speaker.Unlock()
+
+	c.waveformBox.SetText("")
+	if songWaveform != nil {
+		c.Flex.ResizeItem(c.waveformBox, playerWaveformPointCount+1, 0)
+	} else {
+		c.Flex.ResizeItem(c.waveformBox, 0, 0)
+	}
+}
+
+// drawWaveform return the waveform text, the played part being highlighted
+func (c *PlayerComponent) drawWaveform(position time.Duration) string {
+	played := 0
+	if c.playingSong.Duration > 0 {
+		played = int(position.Milliseconds() * int64(len(c.playingWaveform.Peaks)) / c.playingSong.Duration)
+	}
+
+	waveform := "[" + color.ColorTitleStr + "]"
+	for ind, peak := range c.playingWaveform.Peaks {
+		if ind == played {
+			waveform += "[" + color.ColorWaveformUnplayedStr + "]"
+		}
+		waveform += string(waveformBlocks[peak*(len(waveformBlocks)-1)/255])
+	}
+	return waveform
+}
+
 func (c *PlayerComponent) getMainTextSong(song *restApiV1.Song) string {
 	songName := cview.Escape(song.Name)
 
@@ -266,7 +411,7 @@ func (c *PlayerComponent) getCompleteMainTextSong(song *restApiV1.Song) string {
 			artistsName += " / " + cview.Escape(c.uiApp.localDb.Artists[artistId].Name)
 		}
 	}
-	format := cview.Escape(" [" + song.Format.String() + "/" + song.BitDepth.String() + "/" + strconv.Itoa(int(c.musicFormat.SampleRate)) + "hz]")
+	format := cview.Escape(" [" + c.playingSongFormat.String() + "/" + song.BitDepth.String() + "/" + strconv.Itoa(int(c.musicFormat.SampleRate)) + "hz]")
 
 	return songName + albumName + artistsName + format
 }
@@ -274,17 +419,46 @@ func (c *PlayerComponent) getCompleteMainTextSong(song *restApiV1.Song) string {
 func (c *PlayerComponent) refreshProgress() {
 	speaker.Lock()
 	if c.controlStreamer != nil {
-		duration := c.musicFormat.SampleRate.D(c.musicStreamer.Position()).Round(time.Second)
+		position := c.musicFormat.SampleRate.D(c.musicStreamer.Position())
+
+		// Listened duration ignore seeks
+		if delta := position - c.lastPosition; delta > 0 && delta <= 2*time.Second {
+			c.listenedDuration += delta
+		}
+		c.lastPosition = position
+		duration := position.Round(time.Second)
 		min := duration / time.Minute
 		duration -= min * time.Minute
 		sec := duration / time.Second
 
-		duration = c.musicFormat.SampleRate.D(c.musicStreamer.Len()).Round(time.Second)
+		if c.playingSong.Duration > 0 {
+			duration = (time.Duration(c.playingSong.Duration) * time.Millisecond).Round(time.Second)
+		} else {
+			duration = c.musicFormat.SampleRate.D(c.musicStreamer.Len()).Round(time.Second)
+		}
 		totalMin := duration / time.Minute
 		duration -= totalMin * time.Minute
 		totalSec := duration / time.Second
 
 		c.progressBox.SetText("[" + color.ColorTitleStr + "]" + fmt.Sprintf("%02d:%02d / %02d:%02d", min, sec, totalMin, totalSec))
+
+		if c.playingWaveform != nil {
+			c.waveformBox.SetText(c.drawWaveform(position))
+		}
+
+		// Display the synced lyrics line
+		if c.playingLyrics != nil {
+			lineInd := c.playingLyrics.LineIndexAt(position.Milliseconds())
+			if lineInd != c.playingLyricsLineInd {
+				c.playingLyricsLineInd = lineInd
+				lyricsLine := ""
+				if lineInd >= 0 {
+					lyricsLine = c.playingLyrics.Lines[lineInd].Text
+				}
+				c.lyricsBox.SetText("[" + color.ColorTitleStr + "]" + cview.Escape(lyricsLine))
+			}
+		}
+
 		c.uiApp.cviewApp.Draw()
 
 	}
@@ -18,6 +18,13 @@ type PlaylistContentSaveAsComponent struct {
 
 func OpenPlaylistContentSaveAsComponent(uiApp *App, songIds []restApiV1.SongId, srcPlaylistId *restApiV1.PlaylistId, originPrimitive cview.Primitive) {
 
+	// Smart playlist content comes from its rules, so it can only be saved as another playlist
+	if srcPlaylistId != nil {
+		if srcPlaylist, ok := uiApp.localDb.Playlists[*srcPlaylistId]; ok && srcPlaylist.SmartRules != nil {
+			srcPlaylistId = nil
+		}
+	}
+
 	// Only admin or playlist owner can edit playlist content
 	if srcPlaylistId != nil && !uiApp.IsConnectedUserAdmin() && !uiApp.localDb.IsPlaylistOwnedBy(*srcPlaylistId, uiApp.ConnectedUserId()) {
 		uiApp.WarningMessage("Only administrator or playlist owner can edit playlist content")
@@ -41,8 +48,8 @@ func OpenPlaylistContentSaveAsComponent(uiApp *App, songIds []restApiV1.SongId,
 	c.orderedFilteredPlaylists = append(c.orderedFilteredPlaylists, nil)
 	selectedPlaylistInd := 0
 	for _, playlist := range uiApp.localDb.OrderedPlaylists {
-		// Only admin or playlist owner can update a playlist content
-		if uiApp.localDb.IsPlaylistOwnedBy(playlist.Id, uiApp.ConnectedUserId()) || uiApp.IsConnectedUserAdmin() {
+		// Only admin or playlist owner can update a playlist content, smart playlist content coming from its rules
+		if playlist.SmartRules == nil && (uiApp.localDb.IsPlaylistOwnedBy(playlist.Id, uiApp.ConnectedUserId()) || uiApp.IsConnectedUserAdmin()) {
 			c.orderedFilteredPlaylists = append(c.orderedFilteredPlaylists, playlist)
 		}
 	}
@@ -4,22 +4,55 @@ import (
 	"codeberg.org/tslocum/cview"
 	"github.com/jypelle/mifasol/restApiV1"
 	"strconv"
+	"strings"
 )
 
 type PlaylistEditComponent struct {
 	*cview.Form
 	nameInputField  *cview.InputField
+	folderDropDown  *playlistFolderDropDown
 	ownerDropDowns  []*cview.DropDown
 	uiApp           *App
 	playlistId      restApiV1.PlaylistId
 	playlistMeta    *restApiV1.PlaylistMeta
 	originPrimitive cview.Primitive
+
+	artistDropDown            *cview.DropDown
+	albumDropDown             *cview.DropDown
+	minYearInputField         *cview.InputField
+	maxYearInputField         *cview.InputField
+	formatDropDown            *cview.DropDown
+	bitDepthDropDown          *cview.DropDown
+	explicitDropDown          *cview.DropDown
+	addedWithinDaysInputField *cview.InputField
+	ruleUserDropDown          *cview.DropDown
+	favoriteCheckBox          *cview.CheckBox
+	minRatingDropDown         *cview.DropDown
+	minPlayCountInputField    *cview.InputField
+}
+
+// smartPlaylistFormats are the formats proposed by the smart playlist rules, unknown format meaning any format
+var smartPlaylistFormats = []restApiV1.SongFormat{
+	restApiV1.SongFormatUnknown,
+	restApiV1.SongFormatFlac,
+	restApiV1.SongFormatMp3,
+	restApiV1.SongFormatOgg,
+	restApiV1.SongFormatOpus,
+	restApiV1.SongFormatM4a,
+}
+
+func OpenSmartPlaylistCreateComponent(uiApp *App, folderId *restApiV1.PlaylistFolderId, originPrimitive cview.Primitive) {
+	OpenPlaylistEditComponent(uiApp, "", &restApiV1.PlaylistMeta{
+		OwnerUserIds: []restApiV1.UserId{uiApp.ConnectedUserId()},
+		SmartRules:   &restApiV1.SmartPlaylistRules{},
+		FolderId:     folderId,
+	}, originPrimitive)
 }
 
 func OpenPlaylistEditComponent(uiApp *App, playlistId restApiV1.PlaylistId, playlistMeta *restApiV1.PlaylistMeta, originPrimitive cview.Primitive) {
 
 	// Only admin or playlist owner can edit a playlist
-	if !uiApp.IsConnectedUserAdmin() && !uiApp.localDb.IsPlaylistOwnedBy(playlistId, uiApp.ConnectedUserId()) {
+	if playlistId != "" && !uiApp.IsConnectedUserAdmin() && !uiApp.localDb.IsPlaylistOwnedBy(playlistId, uiApp.ConnectedUserId()) {
 		uiApp.WarningMessage("Only administrator or playlist owner can edit a playlist")
 		return
 	}
@@ -41,15 +74,26 @@ func OpenPlaylistEditComponent(uiApp *App, playlistId restApiV1.PlaylistId, play
 	c.Form.SetFieldBackgroundColorFocused(cview.Styles.PrimaryTextColor)
 
 	c.Form.AddFormItem(c.nameInputField)
+	c.folderDropDown = newPlaylistFolderDropDown(uiApp, "Folder", c.playlistMeta.FolderId, nil)
+	c.Form.AddFormItem(c.folderDropDown)
 	for _, userId := range c.playlistMeta.OwnerUserIds {
 		c.addOwner(userId)
 	}
 	c.addOwner("")
 
+	// Rules of smart playlist
+	if c.playlistMeta.SmartRules != nil {
+		c.addSmartRulesFormItems()
+	}
+
 	c.Form.AddButton("Save", c.save)
 	c.Form.AddButton("Cancel", c.cancel)
 	c.Form.SetBorder(true)
-	c.Form.SetTitle("Edit Playlist")
+	if c.playlistId != "" {
+		c.Form.SetTitle("Edit Playlist")
+	} else {
+		c.Form.SetTitle("Create smart playlist")
+	}
 
 	uiApp.pagesComponent.AddAndSwitchToPage("playlistEdit", c, true)
 
@@ -59,6 +103,9 @@ func (c *PlaylistEditComponent) save() {
 	// Name
 	c.playlistMeta.Name = c.nameInputField.GetText()
 
+	// Folder
+	c.playlistMeta.FolderId = c.folderDropDown.selectedFolderId()
+
 	// Owners
 	c.playlistMeta.OwnerUserIds = nil
 	for _, ownerDropDown := range c.ownerDropDowns {
@@ -70,10 +117,23 @@ func (c *PlaylistEditComponent) save() {
 		}
 	}
 
-	_, cliErr := c.uiApp.restClient.UpdatePlaylist(c.playlistId, c.playlistMeta)
-	if cliErr != nil {
-		c.uiApp.ClientErrorMessage("Unable to update the playlist", cliErr)
-		return
+	// Rules of smart playlist
+	if c.playlistMeta.SmartRules != nil {
+		c.playlistMeta.SmartRules = c.smartRules()
+	}
+
+	if c.playlistId != "" {
+		_, cliErr := c.uiApp.restClient.UpdatePlaylist(c.playlistId, c.playlistMeta)
+		if cliErr != nil {
+			c.uiApp.ClientErrorMessage("Unable to update the playlist", cliErr)
+			return
+		}
+	} else {
+		_, cliErr := c.uiApp.restClient.CreatePlaylist(c.playlistMeta)
+		if cliErr != nil {
+			c.uiApp.ClientErrorMessage("Unable to create the playlist", cliErr)
+			return
+		}
 	}
 
 	c.close()
@@ -100,7 +160,194 @@ func (c *PlaylistEditComponent) addOwner(userId restApiV1.UserId) {
 	c.Form.AddFormItem(ownerDropDown)
 }
 
+func (c *PlaylistEditComponent) addSmartRulesFormItems() {
+	smartRules := c.playlistMeta.SmartRules
+
+	// Artist
+	c.artistDropDown = cview.NewDropDown()
+	c.artistDropDown.SetLabel("Artist")
+	selectedArtistInd := 0
+	for ind, artist := range c.uiApp.localDb.OrderedArtists {
+		if ind == 0 {
+			c.artistDropDown.AddOptionsSimple("(Any artist)")
+		} else {
+			c.artistDropDown.AddOptionsSimple(artist.Name)
+			if smartRules.ArtistId != nil && *smartRules.ArtistId == artist.Id {
+				selectedArtistInd = ind
+			}
+		}
+	}
+	c.artistDropDown.SetCurrentOption(selectedArtistInd)
+	c.Form.AddFormItem(c.artistDropDown)
+
+	// Album
+	c.albumDropDown = cview.NewDropDown()
+	c.albumDropDown.SetLabel("Album")
+	selectedAlbumInd := 0
+	for ind, album := range c.uiApp.localDb.OrderedAlbums {
+		if ind == 0 {
+			c.albumDropDown.AddOptionsSimple("(Any album)")
+		} else {
+			c.albumDropDown.AddOptionsSimple(album.Name)
+			if smartRules.AlbumId != nil && *smartRules.AlbumId == album.Id {
+				selectedAlbumInd = ind
+			}
+		}
+	}
+	c.albumDropDown.SetCurrentOption(selectedAlbumInd)
+	c.Form.AddFormItem(c.albumDropDown)
+
+	// Year range
+	c.minYearInputField = newInt64InputField("From year", 4, smartRules.MinYear)
+	c.Form.AddFormItem(c.minYearInputField)
+	c.maxYearInputField = newInt64InputField("To year", 4, smartRules.MaxYear)
+	c.Form.AddFormItem(c.maxYearInputField)
+
+	// Format
+	c.formatDropDown = cview.NewDropDown()
+	c.formatDropDown.SetLabel("Format")
+	selectedFormatInd := 0
+	for ind, format := range smartPlaylistFormats {
+		if ind == 0 {
+			c.formatDropDown.AddOptionsSimple("(Any format)")
+		} else {
+			c.formatDropDown.AddOptionsSimple(format.String())
+			if smartRules.Format != nil && *smartRules.Format == format {
+				selectedFormatInd = ind
+			}
+		}
+	}
+	c.formatDropDown.SetCurrentOption(selectedFormatInd)
+	c.Form.AddFormItem(c.formatDropDown)
+
+	// Bit depth
+	c.bitDepthDropDown = cview.NewDropDown()
+	c.bitDepthDropDown.SetLabel("Bit depth")
+	c.bitDepthDropDown.AddOptionsSimple("(Any bit depth)", restApiV1.SongBitDepth16.String(), restApiV1.SongBitDepth24.String())
+	if smartRules.BitDepth != nil {
+		c.bitDepthDropDown.SetCurrentOption(int(*smartRules.BitDepth))
+	} else {
+		c.bitDepthDropDown.SetCurrentOption(0)
+	}
+	c.Form.AddFormItem(c.bitDepthDropDown)
+
+	// Explicit flag
+	c.explicitDropDown = cview.NewDropDown()
+	c.explicitDropDown.SetLabel("Explicit")
+	c.explicitDropDown.AddOptionsSimple("(Any song)", "Only explicit songs", "No explicit songs")
+	selectedExplicitInd := 0
+	if smartRules.ExplicitFg != nil {
+		if *smartRules.ExplicitFg {
+			selectedExplicitInd = 1
+		} else {
+			selectedExplicitInd = 2
+		}
+	}
+	c.explicitDropDown.SetCurrentOption(selectedExplicitInd)
+	c.Form.AddFormItem(c.explicitDropDown)
+
+	// Recently added
+	c.addedWithinDaysInputField = newInt64InputField("Added within days", 5, smartRules.AddedWithinDays)
+	c.Form.AddFormItem(c.addedWithinDaysInputField)
+
+	// User rules
+	c.ruleUserDropDown = cview.NewDropDown()
+	c.ruleUserDropDown.SetLabel("For user")
+	c.ruleUserDropDown.AddOptionsSimple("(Nobody)")
+	selectedUserInd := 0
+	for ind, user := range c.uiApp.localDb.OrderedUsers {
+		c.ruleUserDropDown.AddOptionsSimple(user.Name)
+		if smartRules.UserId != nil && *smartRules.UserId == user.Id {
+			selectedUserInd = ind + 1
+		}
+	}
+	c.ruleUserDropDown.SetCurrentOption(selectedUserInd)
+	c.Form.AddFormItem(c.ruleUserDropDown)
+
+	c.favoriteCheckBox = cview.NewCheckBox()
+	c.favoriteCheckBox.SetLabel("Only user favorite songs")
+	c.favoriteCheckBox.SetChecked(smartRules.FavoriteFg)
+	c.Form.AddFormItem(c.favoriteCheckBox)
+
+	c.minRatingDropDown = cview.NewDropDown()
+	c.minRatingDropDown.SetLabel("Min user rating")
+	c.minRatingDropDown.AddOptionsSimple("(Any rating)")
+	for rating := int64(restApiV1.SongRatingMin); rating <= restApiV1.SongRatingMax; rating++ {
+		c.minRatingDropDown.AddOptionsSimple(strings.Repeat("★", int(rating)))
+	}
+	if smartRules.MinRating != nil {
+		c.minRatingDropDown.SetCurrentOption(int(*smartRules.MinRating))
+	} else {
+		c.minRatingDropDown.SetCurrentOption(0)
+	}
+	c.Form.AddFormItem(c.minRatingDropDown)
+
+	c.minPlayCountInputField = newInt64InputField("Min user play count", 5, smartRules.MinPlayCount)
+	c.Form.AddFormItem(c.minPlayCountInputField)
+}
+
+// smartRules return the smart playlist rules filled in the form
+func (c *PlaylistEditComponent) smartRules() *restApiV1.SmartPlaylistRules {
+	smartRules := &restApiV1.SmartPlaylistRules{}
+
+	if selectedArtistInd, _ := c.artistDropDown.GetCurrentOption(); selectedArtistInd > 0 {
+		artistId := c.uiApp.localDb.OrderedArtists[selectedArtistInd].Id
+		smartRules.ArtistId = &artistId
+	}
+	if selectedAlbumInd, _ := c.albumDropDown.GetCurrentOption(); selectedAlbumInd > 0 {
+		albumId := c.uiApp.localDb.OrderedAlbums[selectedAlbumInd].Id
+		smartRules.AlbumId = &albumId
+	}
+	smartRules.MinYear = int64InputFieldValue(c.minYearInputField)
+	smartRules.MaxYear = int64InputFieldValue(c.maxYearInputField)
+	if selectedFormatInd, _ := c.formatDropDown.GetCurrentOption(); selectedFormatInd > 0 {
+		format := smartPlaylistFormats[selectedFormatInd]
+		smartRules.Format = &format
+	}
+	if selectedBitDepthInd, _ := c.bitDepthDropDown.GetCurrentOption(); selectedBitDepthInd > 0 {
+		bitDepth := restApiV1.SongBitDepth(selectedBitDepthInd)
+		smartRules.BitDepth = &bitDepth
+	}
+	if selectedExplicitInd, _ := c.explicitDropDown.GetCurrentOption(); selectedExplicitInd > 0 {
+		explicitFg := selectedExplicitInd == 1
+		smartRules.ExplicitFg = &explicitFg
+	}
+	smartRules.AddedWithinDays = int64InputFieldValue(c.addedWithinDaysInputField)
+	if selectedUserInd, _ := c.ruleUserDropDown.GetCurrentOption(); selectedUserInd > 0 {
+		userId := c.uiApp.localDb.OrderedUsers[selectedUserInd-1].Id
+		smartRules.UserId = &userId
+	}
+	smartRules.FavoriteFg = c.favoriteCheckBox.IsChecked()
+	if selectedMinRatingInd, _ := c.minRatingDropDown.GetCurrentOption(); selectedMinRatingInd > 0 {
+		minRating := int64(selectedMinRatingInd)
+		smartRules.MinRating = &minRating
+	}
+	smartRules.MinPlayCount = int64InputFieldValue(c.minPlayCountInputField)
+
+	return smartRules
+}
+
 func (c *PlaylistEditComponent) close() {
 	c.uiApp.pagesComponent.RemovePage("playlistEdit")
 	c.uiApp.cviewApp.SetFocus(c.originPrimitive)
 }
+
+// newInt64InputField return an input field holding an optional number
+func newInt64InputField(label string, fieldWidth int, value *int64) *cview.InputField {
+	inputField := cview.NewInputField()
+	inputField.SetLabel(label)
+	inputField.SetFieldWidth(fieldWidth)
+	if value != nil {
+		inputField.SetText(strconv.FormatInt(*value, 10))
+	}
+	return inputField
+}
+
+// int64InputFieldValue return the number of an input field, nil when empty or invalid
+func int64InputFieldValue(inputField *cview.InputField) *int64 {
+	value, err := strconv.ParseInt(strings.TrimSpace(inputField.GetText()), 10, 64)
+	if err != nil {
+		return nil
+	}
+	return &value
+}
@@ -0,0 +1,130 @@
+package ui
+
+import (
+	"codeberg.org/tslocum/cview"
+	"github.com/jypelle/mifasol/restApiV1"
+)
+
+type PlaylistFolderEditComponent struct {
+	*cview.Form
+	nameInputField       *cview.InputField
+	parentFolderDropDown *playlistFolderDropDown
+	uiApp                *App
+	playlistFolderId     restApiV1.PlaylistFolderId
+	playlistFolderMeta   *restApiV1.PlaylistFolderMeta
+	originPrimitive      cview.Primitive
+}
+
+func OpenPlaylistFolderCreateComponent(uiApp *App, parentFolderId *restApiV1.PlaylistFolderId, originPrimitive cview.Primitive) {
+	OpenPlaylistFolderEditComponent(uiApp, "", &restApiV1.PlaylistFolderMeta{ParentFolderId: parentFolderId}, originPrimitive)
+}
+
+func OpenPlaylistFolderEditComponent(uiApp *App, playlistFolderId restApiV1.PlaylistFolderId, playlistFolderMeta *restApiV1.PlaylistFolderMeta, originPrimitive cview.Primitive) {
+
+	// Only admin can create or edit a playlist folder
+	if !uiApp.IsConnectedUserAdmin() {
+		uiApp.WarningMessage("Only administrator can create or edit a playlist folder")
+		return
+	}
+
+	c := &PlaylistFolderEditComponent{
+		uiApp:              uiApp,
+		playlistFolderId:   playlistFolderId,
+		playlistFolderMeta: playlistFolderMeta.Copy(),
+		originPrimitive:    originPrimitive,
+	}
+
+	c.nameInputField = cview.NewInputField()
+	c.nameInputField.SetLabel("Name")
+	c.nameInputField.SetText(playlistFolderMeta.Name)
+	c.nameInputField.SetFieldWidth(50)
+
+	// A folder can't be moved inside itself
+	var excludedFolderId *restApiV1.PlaylistFolderId
+	if playlistFolderId != "" {
+		excludedFolderId = &playlistFolderId
+	}
+	c.parentFolderDropDown = newPlaylistFolderDropDown(uiApp, "Parent folder", playlistFolderMeta.ParentFolderId, excludedFolderId)
+
+	c.Form = cview.NewForm()
+	c.Form.SetFieldTextColorFocused(cview.Styles.PrimitiveBackgroundColor)
+	c.Form.SetFieldBackgroundColorFocused(cview.Styles.PrimaryTextColor)
+
+	c.Form.AddFormItem(c.nameInputField)
+	c.Form.AddFormItem(c.parentFolderDropDown)
+	c.Form.AddButton("Save", c.save)
+	c.Form.AddButton("Cancel", c.cancel)
+	c.Form.SetBorder(true)
+	if c.playlistFolderId != "" {
+		c.Form.SetTitle("Edit playlist folder")
+	} else {
+		c.Form.SetTitle("Create playlist folder")
+	}
+
+	uiApp.pagesComponent.AddAndSwitchToPage("playlistFolderEdit", c, true)
+}
+
+func (c *PlaylistFolderEditComponent) save() {
+	c.playlistFolderMeta.Name = c.nameInputField.GetText()
+	c.playlistFolderMeta.ParentFolderId = c.parentFolderDropDown.selectedFolderId()
+	if c.playlistFolderId != "" {
+		_, cliErr := c.uiApp.restClient.UpdatePlaylistFolder(c.playlistFolderId, c.playlistFolderMeta)
+		if cliErr != nil {
+			c.uiApp.ClientErrorMessage("Unable to update the playlist folder", cliErr)
+			return
+		}
+	} else {
+		_, cliErr := c.uiApp.restClient.CreatePlaylistFolder(c.playlistFolderMeta)
+		if cliErr != nil {
+			c.uiApp.ClientErrorMessage("Unable to create the playlist folder", cliErr)
+			return
+		}
+	}
+
+	c.close()
+	c.uiApp.Reload()
+}
+
+func (c *PlaylistFolderEditComponent) cancel() {
+	c.close()
+}
+
+func (c *PlaylistFolderEditComponent) close() {
+	c.uiApp.pagesComponent.RemovePage("playlistFolderEdit")
+	c.uiApp.cviewApp.SetFocus(c.originPrimitive)
+}
+
+// playlistFolderDropDown let choose a playlist folder, the first option being the root folder
+type playlistFolderDropDown struct {
+	*cview.DropDown
+	playlistFolders []*restApiV1.PlaylistFolder
+}
+
+// newPlaylistFolderDropDown return a drop down listing the playlist folders, excludedFolderId and its sub folders excepted
+func newPlaylistFolderDropDown(uiApp *App, label string, selectedFolderId *restApiV1.PlaylistFolderId, excludedFolderId *restApiV1.PlaylistFolderId) *playlistFolderDropDown {
+	d := &playlistFolderDropDown{DropDown: cview.NewDropDown()}
+	d.SetLabel(label)
+	d.AddOptionsSimple("(Root)")
+	selectedInd := 0
+	for _, playlistFolder := range uiApp.localDb.OrderedPlaylistFolders {
+		if excludedFolderId != nil && uiApp.localDb.IsPlaylistFolderInside(playlistFolder.Id, *excludedFolderId) {
+			continue
+		}
+		d.playlistFolders = append(d.playlistFolders, playlistFolder)
+		d.AddOptionsSimple(uiApp.localDb.PlaylistFolderLabel(playlistFolder.Id))
+		if selectedFolderId != nil && *selectedFolderId == playlistFolder.Id {
+			selectedInd = len(d.playlistFolders)
+		}
+	}
+	d.SetCurrentOption(selectedInd)
+	return d
+}
+
+// selectedFolderId return the chosen folder, nil for the root folder
+func (d *playlistFolderDropDown) selectedFolderId() *restApiV1.PlaylistFolderId {
+	selectedInd, _ := d.GetCurrentOption()
+	if selectedInd <= 0 {
+		return nil
+	}
+	return &d.playlistFolders[selectedInd-1].Id
+}
@@ -1,8 +1,10 @@
 package ui
 
 import (
+	"bytes"
 	"codeberg.org/tslocum/cview"
 	"github.com/jypelle/mifasol/restApiV1"
+	"io/ioutil"
 	"strconv"
 )
 
@@ -11,9 +13,14 @@ type SongEditComponent struct {
 	nameInputField            *cview.InputField
 	publicationYearInputField *cview.InputField
 	albumDropDown             *cview.DropDown
+	discNumberInputField      *cview.InputField
 	trackNumberInputField     *cview.InputField
 	explicitFgCheckbox        *cview.CheckBox
 	artistDropDowns           []*cview.DropDown
+	composerDropDowns         []*cview.DropDown
+	genreDropDowns            []*cview.DropDown
+	lyricsInputField          *cview.InputField
+	removeLyricsCheckbox      *cview.CheckBox
 	uiApp                     *App
 	song                      *restApiV1.Song
 	originPrimitive           cview.Primitive
@@ -64,6 +71,15 @@ func OpenSongEditComponent(uiApp *App, song *restApiV1.Song, originPrimitive cvi
 	}
 	c.albumDropDown.SetCurrentOption(selectedAlbumInd)
 
+	// Disc number
+	c.discNumberInputField = cview.NewInputField()
+	c.discNumberInputField.SetLabel("Disc number")
+	c.discNumberInputField.SetFieldWidth(4)
+
+	if song.DiscNumber != nil {
+		c.discNumberInputField.SetText(strconv.FormatInt(*song.DiscNumber, 10))
+	}
+
 	// Track number
 	c.trackNumberInputField = cview.NewInputField()
 	c.trackNumberInputField.SetLabel("Track number")
@@ -78,6 +94,14 @@ func OpenSongEditComponent(uiApp *App, song *restApiV1.Song, originPrimitive cvi
 	c.explicitFgCheckbox.SetLabel("Explicit")
 	c.explicitFgCheckbox.SetChecked(song.ExplicitFg)
 
+	// Lyrics
+	c.lyricsInputField = cview.NewInputField()
+	c.lyricsInputField.SetLabel("New lyrics (txt/lrc file)")
+	c.lyricsInputField.SetFieldWidth(50)
+
+	c.removeLyricsCheckbox = cview.NewCheckBox()
+	c.removeLyricsCheckbox.SetLabel("Remove lyrics")
+
 	c.Form = cview.NewForm()
 	c.Form.SetFieldTextColorFocused(cview.Styles.PrimitiveBackgroundColor)
 	c.Form.SetFieldBackgroundColorFocused(cview.Styles.PrimaryTextColor)
@@ -85,6 +109,7 @@ func OpenSongEditComponent(uiApp *App, song *restApiV1.Song, originPrimitive cvi
 	c.Form.AddFormItem(c.nameInputField)
 	c.Form.AddFormItem(c.publicationYearInputField)
 	c.Form.AddFormItem(c.albumDropDown)
+	c.Form.AddFormItem(c.discNumberInputField)
 	c.Form.AddFormItem(c.trackNumberInputField)
 	c.Form.AddFormItem(c.explicitFgCheckbox)
 
@@ -93,6 +118,23 @@ func OpenSongEditComponent(uiApp *App, song *restApiV1.Song, originPrimitive cvi
 	}
 	c.addArtist("")
 
+	for _, songArtist := range c.song.Artists {
+		if songArtist.Role == restApiV1.ArtistRoleComposer {
+			c.addComposer(songArtist.ArtistId)
+		}
+	}
+	c.addComposer("")
+
+	for _, genreId := range c.song.GenreIds {
+		c.addGenre(genreId)
+	}
+	c.addGenre("")
+
+	c.Form.AddFormItem(c.lyricsInputField)
+	if song.LyricsFg {
+		c.Form.AddFormItem(c.removeLyricsCheckbox)
+	}
+
 	c.Form.AddButton("Save", c.save)
 	c.Form.AddButton("Cancel", c.cancel)
 	c.Form.SetBorder(true)
@@ -137,6 +179,40 @@ func (c *SongEditComponent) save() {
 		}
 	}
 
+	// Composers, other credited artists are kept
+	var songArtists []restApiV1.SongArtist
+	for _, songArtist := range c.song.Artists {
+		if songArtist.Role != restApiV1.ArtistRoleComposer {
+			songArtists = append(songArtists, songArtist)
+		}
+	}
+	for _, composerDropDown := range c.composerDropDowns {
+		selectedArtistInd, _ := composerDropDown.GetCurrentOption()
+		if selectedArtistInd > 0 {
+			songArtists = append(songArtists, restApiV1.SongArtist{ArtistId: c.uiApp.localDb.OrderedArtists[selectedArtistInd].Id, Role: restApiV1.ArtistRoleComposer})
+		}
+	}
+	c.song.Artists = songArtists
+
+	// Genres
+	c.song.GenreIds = nil
+	for _, genreDropDown := range c.genreDropDowns {
+		selectedGenreInd, _ := genreDropDown.GetCurrentOption()
+		if selectedGenreInd > 0 {
+			c.song.GenreIds = append(c.song.GenreIds, c.uiApp.localDb.OrderedGenres[selectedGenreInd].Id)
+		}
+	}
+
+	// Disc number
+	c.song.SongMeta.DiscNumber = nil
+	if c.discNumberInputField.GetText() != "" {
+
+		discNumber, err := strconv.ParseInt(c.discNumberInputField.GetText(), 10, 64)
+		if err == nil {
+			c.song.SongMeta.DiscNumber = &discNumber
+		}
+	}
+
 	// Track number
 	c.song.SongMeta.TrackNumber = nil
 	if c.trackNumberInputField.GetText() != "" {
@@ -156,6 +232,26 @@ func (c *SongEditComponent) save() {
 		return
 	}
 
+	// Update lyrics
+	if c.lyricsInputField.GetText() != "" {
+		content, err := ioutil.ReadFile(c.lyricsInputField.GetText())
+		if err != nil {
+			c.uiApp.WarningMessage("Unable to read lyrics file: " + c.lyricsInputField.GetText())
+			return
+		}
+		_, cliErr := c.uiApp.restClient.UpdateSongLyrics(c.song.Id, bytes.NewReader(content))
+		if cliErr != nil {
+			c.uiApp.ClientErrorMessage("Unable to update the song lyrics", cliErr)
+			return
+		}
+	} else if c.removeLyricsCheckbox.IsChecked() {
+		_, cliErr := c.uiApp.restClient.DeleteSongLyrics(c.song.Id)
+		if cliErr != nil {
+			c.uiApp.ClientErrorMessage("Unable to remove the song lyrics", cliErr)
+			return
+		}
+	}
+
 	c.close()
 	c.uiApp.Reload()
 }
@@ -183,6 +279,44 @@ func (c *SongEditComponent) addArtist(artistId restApiV1.ArtistId) {
 	c.Form.AddFormItem(artistDropDown)
 }
 
+func (c *SongEditComponent) addComposer(artistId restApiV1.ArtistId) {
+	composerDropDown := cview.NewDropDown()
+	composerDropDown.SetLabel("Composer " + strconv.Itoa(len(c.composerDropDowns)+1))
+	selectedArtistInd := 0
+	for ind, artist := range c.uiApp.localDb.OrderedArtists {
+		if ind == 0 {
+			composerDropDown.AddOptionsSimple("(Unknown composer)")
+		} else {
+			composerDropDown.AddOptionsSimple(artist.Name)
+			if artistId == artist.Id {
+				selectedArtistInd = ind
+			}
+		}
+	}
+	composerDropDown.SetCurrentOption(selectedArtistInd)
+	c.composerDropDowns = append(c.composerDropDowns, composerDropDown)
+	c.Form.AddFormItem(composerDropDown)
+}
+
+func (c *SongEditComponent) addGenre(genreId restApiV1.GenreId) {
+	genreDropDown := cview.NewDropDown()
+	genreDropDown.SetLabel("Genre " + strconv.Itoa(len(c.genreDropDowns)+1))
+	selectedGenreInd := 0
+	for ind, genre := range c.uiApp.localDb.OrderedGenres {
+		if ind == 0 {
+			genreDropDown.AddOptionsSimple("(Unknown genre)")
+		} else {
+			genreDropDown.AddOptionsSimple(genre.Name)
+			if genreId == genre.Id {
+				selectedGenreInd = ind
+			}
+		}
+	}
+	genreDropDown.SetCurrentOption(selectedGenreInd)
+	c.genreDropDowns = append(c.genreDropDowns, genreDropDown)
+	c.Form.AddFormItem(genreDropDown)
+}
+
 func (c *SongEditComponent) close() {
 	c.uiApp.pagesComponent.RemovePage("songEdit")
 	c.uiApp.cviewApp.SetFocus(c.originPrimitive)
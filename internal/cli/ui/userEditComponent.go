@@ -3,6 +3,7 @@ package ui
 import (
 	"codeberg.org/tslocum/cview"
 	"github.com/jypelle/mifasol/restApiV1"
+	"strconv"
 )
 
 type UserEditComponent struct {
@@ -12,9 +13,16 @@ type UserEditComponent struct {
 	hideExplicitBox    *cview.CheckBox
 	adminCheckBox      *cview.CheckBox
 	uiApp              *App
-	userId             restApiV1.UserId
-	userMeta           *restApiV1.UserMeta
-	originPrimitive    cview.Primitive
+
+	scrobbling                *restApiV1.Scrobbling
+	scrobblingServiceDropDown *cview.DropDown
+	scrobblingApiRootUrlField *cview.InputField
+	scrobblingTokenField      *cview.InputField
+	scrobblingApiKeyField     *cview.InputField
+	scrobblingApiSecretField  *cview.InputField
+	userId                    restApiV1.UserId
+	userMeta                  *restApiV1.UserMeta
+	originPrimitive           cview.Primitive
 }
 
 func OpenUserCreateComponent(uiApp *App, originPrimitive cview.Primitive) {
@@ -66,6 +74,11 @@ func OpenUserEditComponent(uiApp *App, userId restApiV1.UserId, userMeta *restAp
 		c.Form.AddFormItem(c.adminCheckBox)
 	}
 
+	// Scrobbling settings of existing users
+	if c.userId != "" {
+		c.addScrobblingFormItems()
+	}
+
 	c.Form.AddButton("Save", c.save)
 	c.Form.AddButton("Cancel", c.cancel)
 	if c.userId != "" {
@@ -96,6 +109,10 @@ func (c *UserEditComponent) save() {
 			return
 		}
 
+		if !c.saveScrobbling() {
+			return
+		}
+
 		// Update username/password stored in config file on self edit
 		if c.uiApp.ConnectedUserId() == c.userId {
 			c.uiApp.ClientEditableConfig.Username = userMetaComplete.Name
@@ -123,6 +140,97 @@ func (c *UserEditComponent) save() {
 	c.uiApp.Reload()
 }
 
+func (c *UserEditComponent) addScrobblingFormItems() {
+	scrobbling, cliErr := c.uiApp.restClient.ReadScrobbling(c.userId)
+	if cliErr != nil && cliErr.Code() != restApiV1.NotFoundErrorCode {
+		c.uiApp.ClientErrorMessage("Unable to retrieve scrobbling settings", cliErr)
+	}
+	c.scrobbling = scrobbling
+
+	c.scrobblingServiceDropDown = cview.NewDropDown()
+	c.scrobblingServiceDropDown.SetLabel("Scrobbling")
+	c.scrobblingServiceDropDown.AddOptionsSimple("(None)")
+	selectedServiceInd := 0
+	for ind, serviceType := range restApiV1.ScrobblingServiceTypes {
+		c.scrobblingServiceDropDown.AddOptionsSimple(string(serviceType))
+		if scrobbling != nil && scrobbling.ServiceType == serviceType {
+			selectedServiceInd = ind + 1
+		}
+	}
+	c.scrobblingServiceDropDown.SetCurrentOption(selectedServiceInd)
+	c.Form.AddFormItem(c.scrobblingServiceDropDown)
+
+	c.scrobblingApiRootUrlField = cview.NewInputField()
+	c.scrobblingApiRootUrlField.SetLabel("Scrobbling api root url")
+	c.scrobblingApiRootUrlField.SetPlaceholder("Service default")
+	c.scrobblingApiRootUrlField.SetFieldWidth(50)
+	c.Form.AddFormItem(c.scrobblingApiRootUrlField)
+
+	c.scrobblingTokenField = cview.NewInputField()
+	c.scrobblingTokenField.SetLabel("Scrobbling token")
+	c.scrobblingTokenField.SetMaskCharacter('*')
+	c.scrobblingTokenField.SetFieldWidth(50)
+	c.Form.AddFormItem(c.scrobblingTokenField)
+
+	c.scrobblingApiKeyField = cview.NewInputField()
+	c.scrobblingApiKeyField.SetLabel("Scrobbling api key")
+	c.scrobblingApiKeyField.SetPlaceholder("Last.fm only")
+	c.scrobblingApiKeyField.SetFieldWidth(50)
+	c.Form.AddFormItem(c.scrobblingApiKeyField)
+
+	c.scrobblingApiSecretField = cview.NewInputField()
+	c.scrobblingApiSecretField.SetLabel("Scrobbling api secret")
+	c.scrobblingApiSecretField.SetPlaceholder("Last.fm only")
+	c.scrobblingApiSecretField.SetMaskCharacter('*')
+	c.scrobblingApiSecretField.SetFieldWidth(50)
+	c.Form.AddFormItem(c.scrobblingApiSecretField)
+
+	if scrobbling != nil {
+		c.scrobblingApiRootUrlField.SetText(scrobbling.ApiRootUrl)
+		c.scrobblingApiKeyField.SetText(scrobbling.ApiKey)
+
+		// Secrets are never sent back, an empty field keep them
+		c.scrobblingTokenField.SetPlaceholder("Unchanged")
+		c.scrobblingApiSecretField.SetPlaceholder("Unchanged")
+
+		if scrobbling.LastError != "" {
+			c.uiApp.WarningMessage(strconv.FormatInt(scrobbling.PendingListenCount, 10) + " listens waiting to be scrobbled: " + scrobbling.LastError)
+		}
+	}
+}
+
+// saveScrobbling update or remove the scrobbling settings and return false on failure
+func (c *UserEditComponent) saveScrobbling() bool {
+	if c.scrobblingServiceDropDown == nil {
+		return true
+	}
+
+	serviceInd, _ := c.scrobblingServiceDropDown.GetCurrentOption()
+	if serviceInd <= 0 {
+		if c.scrobbling != nil {
+			_, cliErr := c.uiApp.restClient.DeleteScrobbling(c.userId)
+			if cliErr != nil {
+				c.uiApp.ClientErrorMessage("Unable to remove scrobbling settings", cliErr)
+				return false
+			}
+		}
+		return true
+	}
+
+	_, cliErr := c.uiApp.restClient.UpdateScrobbling(c.userId, &restApiV1.ScrobblingMeta{
+		ServiceType: restApiV1.ScrobblingServiceTypes[serviceInd-1],
+		ApiRootUrl:  c.scrobblingApiRootUrlField.GetText(),
+		Token:       c.scrobblingTokenField.GetText(),
+		ApiKey:      c.scrobblingApiKeyField.GetText(),
+		ApiSecret:   c.scrobblingApiSecretField.GetText(),
+	})
+	if cliErr != nil {
+		c.uiApp.ClientErrorMessage("Unable to update scrobbling settings", cliErr)
+		return false
+	}
+	return true
+}
+
 func (c *UserEditComponent) cancel() {
 	c.close()
 }
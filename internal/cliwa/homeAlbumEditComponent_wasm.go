@@ -1,15 +1,23 @@
 package cliwa
 
 import (
+	"bytes"
+	"fmt"
 	"github.com/jypelle/mifasol/internal/cliwa/jst"
 	"github.com/jypelle/mifasol/restApiV1"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall/js"
 )
 
 type HomeAlbumEditComponent struct {
-	app       *App
-	albumId   restApiV1.AlbumId
-	albumMeta *restApiV1.AlbumMeta
-	closed    bool
+	app            *App
+	albumId        restApiV1.AlbumId
+	albumMeta      *restApiV1.AlbumMeta
+	closed         bool
+	newArtistNames []string
 }
 
 func NewHomeAlbumEditComponent(app *App, albumId restApiV1.AlbumId, albumMeta *restApiV1.AlbumMeta) *HomeAlbumEditComponent {
@@ -23,9 +31,25 @@ func NewHomeAlbumEditComponent(app *App, albumId restApiV1.AlbumId, albumMeta *r
 }
 
 func (c *HomeAlbumEditComponent) Render() {
+	albumEdit := struct {
+		Name     string
+		CoverUrl string
+	}{
+		Name: c.albumMeta.Name,
+	}
+
+	if album, ok := c.app.localDb.Albums[c.albumId]; ok && album.CoverFg {
+		token, cliErr := c.app.restClient.GetToken()
+		if cliErr != nil {
+			c.app.HomeComponent.MessageComponent.ClientErrorMessage("Unable to retrieve token", cliErr)
+		} else {
+			albumEdit.CoverUrl = "/api/v1/albumCovers/" + string(c.albumId) + "?bearer=" + token.AccessToken + "&ts=" + strconv.FormatInt(album.UpdateTs, 10)
+		}
+	}
+
 	div := jst.Id("homeMainModal")
 	div.Set("innerHTML", c.app.RenderTemplate(
-		c.albumMeta, "home/albumEdit/index"),
+		albumEdit, "home/albumEdit/index"),
 	)
 
 	form := jst.Id("albumEditForm")
@@ -33,6 +57,86 @@ func (c *HomeAlbumEditComponent) Render() {
 	cancelButton := jst.Id("albumEditCancelButton")
 	cancelButton.Call("addEventListener", "click", c.app.AddEventFunc(c.cancelAction))
 
+	// Album artists
+	artistCurrentList := jst.Id("albumEditArtistCurrentList")
+	artistSearchInput := jst.Id("albumEditArtistSearchInput")
+	artistSearchClean := jst.Id("albumEditArtistSearchClean")
+	artistSearchList := jst.Id("albumEditArtistSearchList")
+
+	// Remove album artist
+	artistCurrentList.Call("addEventListener", "click", c.app.AddRichEventFunc(func(this js.Value, i []js.Value) {
+		link := i[0].Get("target").Call("closest", ".artistLink")
+		if !link.Truthy() {
+			return
+		}
+		dataset := link.Get("dataset")
+
+		artistId := restApiV1.ArtistId(dataset.Get("artistid").String())
+		if artistId != "" {
+			for idx, albumArtistId := range c.albumMeta.AlbumArtistIds {
+				if albumArtistId == artistId {
+					c.albumMeta.AlbumArtistIds = append(c.albumMeta.AlbumArtistIds[0:idx], c.albumMeta.AlbumArtistIds[idx+1:]...)
+					break
+				}
+			}
+		} else {
+			artistIdx := dataset.Get("artistidx").Int()
+			if artistIdx < len(c.newArtistNames) {
+				c.newArtistNames = append(c.newArtistNames[0:artistIdx], c.newArtistNames[artistIdx+1:]...)
+			}
+		}
+
+		// Refresh current album artists
+		c.refreshCurrentArtistAction()
+	}))
+
+	// Search album artist
+	artistSearchInput.Call("addEventListener", "keypress", c.app.AddBlockingRichEventFunc(func(this js.Value, i []js.Value) {
+		if i[0].Get("which").Int() == 13 {
+			i[0].Call("preventDefault")
+		}
+	}))
+	artistSearchInput.Call("addEventListener", "input", c.app.AddEventFunc(c.artistSearchAction))
+	artistSearchInput.Call("addEventListener", "focusout", c.app.AddRichEventFunc(func(this js.Value, i []js.Value) {
+		relatedTarget := i[0].Get("relatedTarget")
+		if relatedTarget.Truthy() && relatedTarget.Call("closest", ".artistLink, .newArtistLink").Truthy() {
+			return
+		}
+		// Clear search input
+		artistSearchInput.Set("value", "")
+		c.artistSearchAction()
+	}))
+	artistSearchClean.Call("addEventListener", "click", c.app.AddEventFunc(func() {
+		// Clear search input
+		artistSearchInput.Set("value", "")
+		c.artistSearchAction()
+	}))
+
+	// Add album artist
+	artistSearchList.Call("addEventListener", "click", c.app.AddRichEventFunc(func(this js.Value, i []js.Value) {
+		link := i[0].Get("target").Call("closest", ".artistLink, .newArtistLink")
+		if !link.Truthy() {
+			return
+		}
+		dataset := link.Get("dataset")
+
+		switch link.Get("className").String() {
+		case "artistLink":
+			c.albumMeta.AlbumArtistIds = append(c.albumMeta.AlbumArtistIds, restApiV1.ArtistId(dataset.Get("artistid").String()))
+		case "newArtistLink":
+			c.newArtistNames = append(c.newArtistNames, strings.TrimSpace(artistSearchInput.Get("value").String()))
+		}
+
+		// Clear search input
+		artistSearchInput.Set("value", "")
+		c.artistSearchAction()
+
+		// Refresh current album artists
+		c.refreshCurrentArtistAction()
+	}))
+
+	c.refreshCurrentArtistAction()
+
 }
 
 func (c *HomeAlbumEditComponent) saveAction() {
@@ -45,17 +149,70 @@ func (c *HomeAlbumEditComponent) saveAction() {
 	albumName := jst.Id("albumEditAlbumName")
 	c.albumMeta.Name = albumName.Get("value").String()
 
+	// Album artists
+	for _, newArtistName := range c.newArtistNames {
+		// Create new artist
+		newArtist, cliErr := c.app.restClient.CreateArtist(&restApiV1.ArtistMeta{Name: newArtistName})
+		if cliErr != nil {
+			c.app.HomeComponent.MessageComponent.ClientErrorMessage(fmt.Sprintf("Unable to create the artist %s", newArtistName), cliErr)
+			c.close()
+			c.app.HomeComponent.Reload()
+			c.app.HideLoader()
+			return
+		}
+		c.albumMeta.AlbumArtistIds = append(c.albumMeta.AlbumArtistIds, newArtist.Id)
+	}
+	c.newArtistNames = nil
+
 	if c.albumId != "" {
 		_, cliErr := c.app.restClient.UpdateAlbum(c.albumId, c.albumMeta)
 		if cliErr != nil {
 			c.app.HomeComponent.MessageComponent.ClientErrorMessage("Unable to update the album", cliErr)
 		}
 	} else {
-		_, cliErr := c.app.restClient.CreateAlbum(c.albumMeta)
+		album, cliErr := c.app.restClient.CreateAlbum(c.albumMeta)
 		if cliErr != nil {
 			c.app.HomeComponent.MessageComponent.ClientErrorMessage("Unable to create the album", cliErr)
+		} else {
+			c.albumId = album.Id
 		}
 	}
+
+	if c.albumId != "" {
+		// Load the new cover before uploading it
+		coverFiles := jst.Id("albumEditCover").Get("files")
+		if coverFiles.Length() > 0 {
+			reader := js.Global().Get("FileReader").New()
+			reader.Call("addEventListener", "load", c.app.AddRichEventFunc(c.uploadCoverAction))
+			reader.Call("readAsArrayBuffer", coverFiles.Index(0))
+			return
+		}
+
+		removeCover := jst.Id("albumEditRemoveCover")
+		if !removeCover.IsNull() && removeCover.Get("checked").Bool() {
+			_, cliErr := c.app.restClient.DeleteAlbumCover(c.albumId)
+			if cliErr != nil {
+				c.app.HomeComponent.MessageComponent.ClientErrorMessage("Unable to remove the album cover", cliErr)
+			}
+		}
+	}
+
+	c.close()
+	c.app.HomeComponent.Reload()
+	c.app.HideLoader()
+}
+
+func (c *HomeAlbumEditComponent) uploadCoverAction(this js.Value, args []js.Value) {
+	result := this.Get("result")
+	jscontent := js.Global().Get("Uint8Array").New(result)
+	content := make([]byte, jscontent.Length())
+	js.CopyBytesToGo(content, jscontent)
+
+	_, cliErr := c.app.restClient.UpdateAlbumCover(c.albumId, http.DetectContentType(content), bytes.NewReader(content))
+	if cliErr != nil {
+		c.app.HomeComponent.MessageComponent.ClientErrorMessage("Unable to update the album cover", cliErr)
+	}
+
 	c.close()
 	c.app.HomeComponent.Reload()
 	c.app.HideLoader()
@@ -72,3 +229,97 @@ func (c *HomeAlbumEditComponent) close() {
 	c.closed = true
 	c.app.HomeComponent.CloseModal()
 }
+
+func (c *HomeAlbumEditComponent) refreshCurrentArtistAction() {
+	type ArtistCurrentItem struct {
+		ArtistId   restApiV1.ArtistId
+		ArtistIdx  int
+		ArtistName string
+	}
+
+	var resultArtistList []*ArtistCurrentItem
+
+	for _, artistId := range c.albumMeta.AlbumArtistIds {
+		resultArtistList = append(resultArtistList, &ArtistCurrentItem{
+			ArtistId:   artistId,
+			ArtistName: c.app.localDb.Artists[artistId].Name,
+		})
+	}
+
+	for idx, newArtistName := range c.newArtistNames {
+		resultArtistList = append(resultArtistList, &ArtistCurrentItem{
+			ArtistId:   "",
+			ArtistIdx:  idx,
+			ArtistName: newArtistName,
+		})
+	}
+
+	artistCurrentList := jst.Id("albumEditArtistCurrentList")
+	artistCurrentList.Set("innerHTML", c.app.RenderTemplate(
+		resultArtistList, "home/songEdit/artistCurrentList"),
+	)
+}
+
+func (c *HomeAlbumEditComponent) artistSearchAction() {
+	artistSearchInput := jst.Id("albumEditArtistSearchInput")
+	artistSearchList := jst.Id("albumEditArtistSearchList")
+
+	nameFilter := strings.TrimSpace(artistSearchInput.Get("value").String())
+
+	type ArtistSearchItem struct {
+		ArtistId        restApiV1.ArtistId
+		ArtistName      string
+		ArtistSongCount int
+	}
+
+	var resultArtistList []*ArtistSearchItem
+
+	if nameFilter != "" {
+		lowerNameFilter := strings.ToLower(nameFilter)
+		for _, artist := range c.app.localDb.OrderedArtists {
+
+			if artist == nil || !strings.Contains(strings.ToLower(artist.Name), lowerNameFilter) {
+				continue
+			}
+
+			artistOfCurrentAlbum := false
+			for _, albumArtistId := range c.albumMeta.AlbumArtistIds {
+				if artist.Id == albumArtistId {
+					artistOfCurrentAlbum = true
+					break
+				}
+			}
+			if artistOfCurrentAlbum {
+				continue
+			}
+
+			resultArtistList = append(resultArtistList, &ArtistSearchItem{
+				ArtistId:        artist.Id,
+				ArtistName:      artist.Name,
+				ArtistSongCount: len(c.app.localDb.ArtistOrderedSongs[artist.Id]),
+			})
+		}
+
+		sort.SliceStable(resultArtistList, func(i, j int) bool {
+			return len(resultArtistList[i].ArtistName) < len(resultArtistList[j].ArtistName)
+		})
+
+		if len(resultArtistList) > 100 {
+			resultArtistList = resultArtistList[0:100]
+		}
+
+		artistSearchList.Set("innerHTML", c.app.RenderTemplate(
+			struct {
+				ArtistList []*ArtistSearchItem
+				NameFilter string
+			}{
+				ArtistList: resultArtistList,
+				NameFilter: nameFilter,
+			}, "home/songEdit/artistSearchList"),
+		)
+		artistSearchList.Get("style").Set("display", "block")
+	} else {
+		artistSearchList.Set("innerHTML", "")
+		artistSearchList.Get("style").Set("display", "none")
+	}
+}
@@ -1,8 +1,12 @@
 package cliwa
 
 import (
+	"bytes"
 	"github.com/jypelle/mifasol/internal/cliwa/jst"
 	"github.com/jypelle/mifasol/restApiV1"
+	"net/http"
+	"strconv"
+	"syscall/js"
 )
 
 type HomeArtistEditComponent struct {
@@ -23,9 +27,29 @@ func NewHomeArtistEditComponent(app *App, artistId restApiV1.ArtistId, artistMet
 }
 
 func (c *HomeArtistEditComponent) Render() {
+	artistEdit := struct {
+		Name       string
+		SortName   string
+		Biography  string
+		PictureUrl string
+	}{
+		Name:      c.artistMeta.Name,
+		SortName:  c.artistMeta.SortName,
+		Biography: c.artistMeta.Biography,
+	}
+
+	if artist, ok := c.app.localDb.Artists[c.artistId]; ok && artist.PictureFg {
+		token, cliErr := c.app.restClient.GetToken()
+		if cliErr != nil {
+			c.app.HomeComponent.MessageComponent.ClientErrorMessage("Unable to retrieve token", cliErr)
+		} else {
+			artistEdit.PictureUrl = "/api/v1/artistPictures/" + string(c.artistId) + "?bearer=" + token.AccessToken + "&ts=" + strconv.FormatInt(artist.UpdateTs, 10)
+		}
+	}
+
 	div := jst.Id("homeMainModal")
 	div.Set("innerHTML", c.app.RenderTemplate(
-		c.artistMeta, "home/artistEdit/index"),
+		artistEdit, "home/artistEdit/index"),
 	)
 
 	form := jst.Id("artistEditForm")
@@ -44,6 +68,10 @@ func (c *HomeArtistEditComponent) saveAction() {
 
 	artistName := jst.Id("artistEditArtistName")
 	c.artistMeta.Name = artistName.Get("value").String()
+	artistSortName := jst.Id("artistEditArtistSortName")
+	c.artistMeta.SortName = artistSortName.Get("value").String()
+	artistBiography := jst.Id("artistEditArtistBiography")
+	c.artistMeta.Biography = artistBiography.Get("value").String()
 
 	if c.artistId != "" {
 		_, cliErr := c.app.restClient.UpdateArtist(c.artistId, c.artistMeta)
@@ -51,11 +79,49 @@ func (c *HomeArtistEditComponent) saveAction() {
 			c.app.HomeComponent.MessageComponent.ClientErrorMessage("Unable to update the artist", cliErr)
 		}
 	} else {
-		_, cliErr := c.app.restClient.CreateArtist(c.artistMeta)
+		artist, cliErr := c.app.restClient.CreateArtist(c.artistMeta)
 		if cliErr != nil {
 			c.app.HomeComponent.MessageComponent.ClientErrorMessage("Unable to create the artist", cliErr)
+		} else {
+			c.artistId = artist.Id
 		}
 	}
+
+	if c.artistId != "" {
+		// Load the new picture before uploading it
+		pictureFiles := jst.Id("artistEditPicture").Get("files")
+		if pictureFiles.Length() > 0 {
+			reader := js.Global().Get("FileReader").New()
+			reader.Call("addEventListener", "load", c.app.AddRichEventFunc(c.uploadPictureAction))
+			reader.Call("readAsArrayBuffer", pictureFiles.Index(0))
+			return
+		}
+
+		removePicture := jst.Id("artistEditRemovePicture")
+		if !removePicture.IsNull() && removePicture.Get("checked").Bool() {
+			_, cliErr := c.app.restClient.DeleteArtistPicture(c.artistId)
+			if cliErr != nil {
+				c.app.HomeComponent.MessageComponent.ClientErrorMessage("Unable to remove the artist picture", cliErr)
+			}
+		}
+	}
+
+	c.close()
+	c.app.HomeComponent.Reload()
+	c.app.HideLoader()
+}
+
+func (c *HomeArtistEditComponent) uploadPictureAction(this js.Value, args []js.Value) {
+	result := this.Get("result")
+	jscontent := js.Global().Get("Uint8Array").New(result)
+	content := make([]byte, jscontent.Length())
+	js.CopyBytesToGo(content, jscontent)
+
+	_, cliErr := c.app.restClient.UpdateArtistPicture(c.artistId, http.DetectContentType(content), bytes.NewReader(content))
+	if cliErr != nil {
+		c.app.HomeComponent.MessageComponent.ClientErrorMessage("Unable to update the artist picture", cliErr)
+	}
+
 	c.close()
 	c.app.HomeComponent.Reload()
 	c.app.HideLoader()
@@ -26,16 +26,16 @@ func NewHomeConfirmDeleteComponent(
 
 	switch v := id.(type) {
 	case restApiV1.ArtistId:
-		if len(app.localDb.ArtistOrderedSongs[v]) == 1 {
+		if len(app.localDb.MainArtistSongs(v)) == 1 {
 			c.name = template.HTML(fmt.Sprintf(
 				"Do you want to delete <span class=\"artistLink\">%s</span> and its song ?",
 				html.EscapeString(app.localDb.Artists[v].Name),
 			))
-		} else if len(app.localDb.ArtistOrderedSongs[v]) > 1 {
+		} else if len(app.localDb.MainArtistSongs(v)) > 1 {
 			c.name = template.HTML(fmt.Sprintf(
 				"Do you want to delete <span class=\"artistLink\">%s</span> and its %d songs ?",
 				html.EscapeString(app.localDb.Artists[v].Name),
-				len(app.localDb.ArtistOrderedSongs[v]),
+				len(app.localDb.MainArtistSongs(v)),
 			))
 		} else {
 			c.name = template.HTML(fmt.Sprintf(
@@ -67,6 +67,10 @@ func NewHomeConfirmDeleteComponent(
 		c.name = template.HTML(fmt.Sprintf(
 			"Do you want to delete <span class=\"playlistLink\">%s</span> ?", html.EscapeString(app.localDb.Playlists[v].Name),
 		))
+	case restApiV1.PlaylistFolderId:
+		c.name = template.HTML(fmt.Sprintf(
+			"Do you want to delete <span class=\"playlistFolderLink\">%s</span> ?", html.EscapeString(app.localDb.PlaylistFolders[v].Name),
+		))
 	case restApiV1.UserId:
 		c.name = template.HTML(fmt.Sprintf(
 			"Do you want to delete <span class=\"userLink\">%s</span> ?", html.EscapeString(app.localDb.Users[v].Name),
@@ -102,7 +106,8 @@ func (c *HomeConfirmDeleteComponent) deleteAction() {
 	switch v := c.id.(type) {
 	case restApiV1.ArtistId:
 		artist := c.app.localDb.Artists[v]
-		for _, song := range c.app.localDb.ArtistOrderedSongs[v] {
+		// Songs where the artist is only credited (featuring, composer...) are kept
+		for _, song := range c.app.localDb.MainArtistSongs(v) {
 			c.app.ShowLoader(fmt.Sprintf("Deleting <span class=\"songLink\">%s</span> from <span class=\"artistLink\">%s</span>", html.EscapeString(song.Name), html.EscapeString(artist.Name)))
 			_, cliErr := c.app.restClient.DeleteSong(song.Id)
 			if cliErr != nil {
@@ -148,6 +153,14 @@ func (c *HomeConfirmDeleteComponent) deleteAction() {
 			c.app.HomeComponent.MessageComponent.ClientErrorMessage(fmt.Sprintf("Unable to delete <span class=\"playlistLink\">%s</span>", html.EscapeString(playlist.Name)), cliErr)
 			return
 		}
+	case restApiV1.PlaylistFolderId:
+		playlistFolder := c.app.localDb.PlaylistFolders[v]
+		c.app.ShowLoader(fmt.Sprintf("Deleting <span class=\"playlistFolderLink\">%s</span>", html.EscapeString(playlistFolder.Name)))
+		_, cliErr := c.app.restClient.DeletePlaylistFolder(v)
+		if cliErr != nil {
+			c.app.HomeComponent.MessageComponent.ClientErrorMessage(fmt.Sprintf("Unable to delete <span class=\"playlistFolderLink\">%s</span>", html.EscapeString(playlistFolder.Name)), cliErr)
+			return
+		}
 	case restApiV1.UserId:
 		user := c.app.localDb.Users[v]
 		c.app.ShowLoader(fmt.Sprintf("Deleting <span class=\"userLink\">%s</span>", html.EscapeString(user.Name)))
@@ -58,8 +58,8 @@ func (c *HomeCurrentComponent) Render() {
 	currentSaveButton := jst.Id("currentSaveButton")
 	currentSaveButton.Call("addEventListener", "click", c.app.AddEventFunc(func() {
 		if c.modified {
-			if c.srcPlaylistId == nil {
-				// Save content as new playlist
+			if c.srcPlaylistId == nil || c.app.localDb.Playlists[*c.srcPlaylistId].SmartRules != nil {
+				// Save content as new playlist, smart playlist content coming from its rules
 				component := NewHomePlaylistContentSaveAsComponent(c.app, c.songIds)
 
 				c.app.HomeComponent.OpenModal()
@@ -247,6 +247,26 @@ func (c *HomeCurrentComponent) AddSongsFromArtistAction(artistId restApiV1.Artis
 	c.RefreshView(0, false)
 }
 
+func (c *HomeCurrentComponent) AddSongsFromComposerAction(artistId restApiV1.ArtistId) {
+	for _, song := range c.app.localDb.ComposerOrderedSongs[artistId] {
+		c.tryToAppendSong(song)
+	}
+	c.RefreshView(0, false)
+}
+
+func (c *HomeCurrentComponent) AddSongsFromGenreAction(genreId restApiV1.GenreId) {
+	if genreId != restApiV1.UnknownGenreId {
+		for _, song := range c.app.localDb.GenreOrderedSongs[genreId] {
+			c.tryToAppendSong(song)
+		}
+	} else {
+		for _, song := range c.app.localDb.UnknownGenreSongs {
+			c.tryToAppendSong(song)
+		}
+	}
+	c.RefreshView(0, false)
+}
+
 func (c *HomeCurrentComponent) AddSongsFromPlaylistAction(playlistId restApiV1.PlaylistId) {
 	for _, songId := range c.app.localDb.Playlists[playlistId].SongIds {
 		c.tryToAppendSong(c.app.localDb.Songs[songId])
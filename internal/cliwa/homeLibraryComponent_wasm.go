@@ -3,21 +3,27 @@ package cliwa
 import (
 	"fmt"
 	"github.com/jypelle/mifasol/internal/cliwa/jst"
+	"github.com/jypelle/mifasol/internal/tool"
 	"github.com/jypelle/mifasol/restApiV1"
 	"github.com/sirupsen/logrus"
 	"html"
+	"strconv"
 	"strings"
 	"syscall/js"
+	"time"
 )
 
 type libraryType int64
 
 const (
 	LibraryTypeArtists libraryType = iota
+	LibraryTypeComposers
 	LibraryTypeAlbums
+	LibraryTypeGenres
 	LibraryTypePlaylists
 	LibraryTypeSongs
 	LibraryTypeUsers
+	LibraryTypeStats
 )
 
 const LibraryPageSize = 50
@@ -25,17 +31,24 @@ const LibraryPageSize = 50
 type libraryState struct {
 	libraryType         libraryType
 	artistId            *restApiV1.ArtistId
+	composerId          *restApiV1.ArtistId
 	albumId             *restApiV1.AlbumId
+	genreId             *restApiV1.GenreId
 	playlistId          *restApiV1.PlaylistId
+	playlistFolderId    *restApiV1.PlaylistFolderId
 	userId              *restApiV1.UserId
 	nameFilter          *string
 	onlyFavoritesFilter bool
+	statsPeriod         tool.StatsPeriod
 	displayedPage       int
 	cachedArtists       []*restApiV1.Artist
 	cachedAlbums        []*restApiV1.Album
+	cachedGenres        []*restApiV1.Genre
 	cachedSongs         []*restApiV1.Song
 	cachedPlaylists     []*restApiV1.Playlist
+	cachedFolders       []*restApiV1.PlaylistFolder
 	cachedUsers         []*restApiV1.User
+	cachedStats         *restApiV1.UserStats
 }
 
 func (s *libraryState) cachedSize() int {
@@ -45,6 +58,9 @@ func (s *libraryState) cachedSize() int {
 	if len(s.cachedAlbums) > 0 {
 		return len(s.cachedAlbums)
 	}
+	if len(s.cachedGenres) > 0 {
+		return len(s.cachedGenres)
+	}
 	if len(s.cachedSongs) > 0 {
 		return len(s.cachedSongs)
 	}
@@ -81,24 +97,41 @@ func (c *LibraryComponent) Render() {
 
 	libraryArtistsButton := jst.Id("libraryArtistsButton")
 	libraryArtistsButton.Call("addEventListener", "click", c.app.AddEventFunc(c.ShowArtistsAction))
+	libraryComposersButton := jst.Id("libraryComposersButton")
+	libraryComposersButton.Call("addEventListener", "click", c.app.AddEventFunc(c.ShowComposersAction))
 	libraryAlbumsButton := jst.Id("libraryAlbumsButton")
 	libraryAlbumsButton.Call("addEventListener", "click", c.app.AddEventFunc(c.ShowAlbumsAction))
+	libraryGenresButton := jst.Id("libraryGenresButton")
+	libraryGenresButton.Call("addEventListener", "click", c.app.AddEventFunc(c.ShowGenresAction))
 	librarySongsButton := jst.Id("librarySongsButton")
 	librarySongsButton.Call("addEventListener", "click", c.app.AddEventFunc(c.ShowSongsAction))
 	libraryPlaylistsButton := jst.Id("libraryPlaylistsButton")
 	libraryPlaylistsButton.Call("addEventListener", "click", c.app.AddEventFunc(c.ShowPlaylistsAction))
 	libraryUsersButton := jst.Id("libraryUsersButton")
 	libraryUsersButton.Call("addEventListener", "click", c.app.AddEventFunc(c.ShowUsersAction))
+	libraryStatsButton := jst.Id("libraryStatsButton")
+	libraryStatsButton.Call("addEventListener", "click", c.app.AddEventFunc(c.ShowStatsAction))
 	libraryAddToPlaylistButton := jst.Id("libraryAddToPlaylistButton")
 	libraryAddToPlaylistButton.Call("addEventListener", "click", c.app.AddEventFunc(c.AddToPlaylistAction))
 	libraryCreateButton := jst.Id("libraryCreateButton")
 	libraryCreateButton.Call("addEventListener", "click", c.app.AddEventFunc(func() {
-		if c.libraryState.libraryType == LibraryTypeUsers {
+		switch c.libraryState.libraryType {
+		case LibraryTypeUsers:
 			component := NewHomeUserCreateComponent(c.app)
 			c.app.HomeComponent.OpenModal()
 			component.Render()
+		case LibraryTypePlaylists:
+			component := NewHomeSmartPlaylistCreateComponent(c.app, c.libraryState.playlistFolderId)
+			c.app.HomeComponent.OpenModal()
+			component.Render()
 		}
 	}))
+	libraryCreateFolderButton := jst.Id("libraryCreateFolderButton")
+	libraryCreateFolderButton.Call("addEventListener", "click", c.app.AddEventFunc(func() {
+		component := NewHomePlaylistFolderCreateComponent(c.app, c.libraryState.playlistFolderId)
+		c.app.HomeComponent.OpenModal()
+		component.Render()
+	}))
 
 	librarySearchInput := jst.Id("librarySearchInput")
 	librarySearchInput.Call("addEventListener", "input", c.app.AddEventFunc(c.SearchAction))
@@ -106,6 +139,15 @@ func (c *LibraryComponent) Render() {
 	libraryOnlyFavoritesButton.Call("addEventListener", "click", c.app.AddEventFunc(c.FavoritesSwitchAction))
 
 	libraryList := jst.Id("libraryList")
+	libraryList.Call("addEventListener", "change", c.app.AddRichEventFunc(func(this js.Value, i []js.Value) {
+		statsPeriodSelect := i[0].Get("target").Call("closest", ".statsPeriodSelect")
+		if !statsPeriodSelect.Truthy() {
+			return
+		}
+		statsPeriod, _ := strconv.ParseInt(statsPeriodSelect.Get("value").String(), 10, 64)
+		c.libraryState.statsPeriod = tool.StatsPeriod(statsPeriod)
+		c.RefreshView()
+	}))
 	libraryList.Call("addEventListener", "scroll", c.app.AddRichEventFunc(func(this js.Value, i []js.Value) {
 		scrollHeight := libraryList.Get("scrollHeight").Int()
 		scrollTop := libraryList.Get("scrollTop").Int()
@@ -128,8 +170,10 @@ func (c *LibraryComponent) Render() {
 		link := i[0].Get("target").Call("closest",
 			".artistLink, .artistEditLink, .artistDeleteLink, .artistAddToPlaylistLink, "+
 				".albumLink, .albumEditLink, .albumDeleteLink, .albumAddToPlaylistLink, "+
+				".genreLink, .genreAddToPlaylistLink, "+
+				".playlistFolderLink, .playlistFolderEditLink, .playlistFolderDeleteLink, "+
 				".playlistLink, .playlistEditLink, .playlistDeleteLink, .playlistFavoriteLink, .playlistAddToPlaylistLink, .playlistLoadToPlaylistLink, "+
-				".songEditLink, .songDeleteLink, .songFavoriteLink, .songAddToPlaylistLink, .songPlayNowLink, .songDownloadLink, "+
+				".songEditLink, .songDeleteLink, .songFavoriteLink, .songRatingLink, .songAddToPlaylistLink, .songPlayNowLink, .songDownloadLink, "+
 				".userEditLink, .userDeleteLink")
 		if !link.Truthy() {
 			return
@@ -139,7 +183,11 @@ func (c *LibraryComponent) Render() {
 		switch link.Get("className").String() {
 		case "artistLink":
 			artistId := dataset.Get("artistid").String()
-			c.OpenArtistAction(restApiV1.ArtistId(artistId))
+			if c.libraryState.libraryType == LibraryTypeComposers {
+				c.OpenComposerAction(restApiV1.ArtistId(artistId))
+			} else {
+				c.OpenArtistAction(restApiV1.ArtistId(artistId))
+			}
 		case "artistEditLink":
 			artistId := restApiV1.ArtistId(dataset.Get("artistid").String())
 			if artistId != restApiV1.UnknownArtistId {
@@ -156,7 +204,11 @@ func (c *LibraryComponent) Render() {
 
 		case "artistAddToPlaylistLink":
 			artistId := dataset.Get("artistid").String()
-			c.app.HomeComponent.CurrentComponent.AddSongsFromArtistAction(restApiV1.ArtistId(artistId))
+			if c.libraryState.libraryType == LibraryTypeComposers {
+				c.app.HomeComponent.CurrentComponent.AddSongsFromComposerAction(restApiV1.ArtistId(artistId))
+			} else {
+				c.app.HomeComponent.CurrentComponent.AddSongsFromArtistAction(restApiV1.ArtistId(artistId))
+			}
 		case "albumLink":
 			albumId := dataset.Get("albumid").String()
 			c.OpenAlbumAction(restApiV1.AlbumId(albumId))
@@ -178,6 +230,33 @@ func (c *LibraryComponent) Render() {
 		case "albumAddToPlaylistLink":
 			albumId := restApiV1.AlbumId(dataset.Get("albumid").String())
 			c.app.HomeComponent.CurrentComponent.AddSongsFromAlbumAction(albumId)
+		case "genreLink":
+			genreId := restApiV1.GenreId(dataset.Get("genreid").String())
+			c.OpenGenreAction(genreId)
+		case "genreAddToPlaylistLink":
+			genreId := restApiV1.GenreId(dataset.Get("genreid").String())
+			c.app.HomeComponent.CurrentComponent.AddSongsFromGenreAction(genreId)
+		case "playlistFolderLink":
+			var playlistFolderId *restApiV1.PlaylistFolderId
+			if value := dataset.Get("playlistfolderid").String(); value != "" {
+				playlistFolderId = (*restApiV1.PlaylistFolderId)(&value)
+			}
+			c.OpenPlaylistFolderAction(playlistFolderId)
+		case "playlistFolderEditLink":
+			playlistFolderId := restApiV1.PlaylistFolderId(dataset.Get("playlistfolderid").String())
+			component := NewHomePlaylistFolderEditComponent(c.app, playlistFolderId, &c.app.localDb.PlaylistFolders[playlistFolderId].PlaylistFolderMeta)
+			c.app.HomeComponent.OpenModal()
+			component.Render()
+		case "playlistFolderDeleteLink":
+			playlistFolderId := restApiV1.PlaylistFolderId(dataset.Get("playlistfolderid").String())
+			// Only empty folders can be deleted
+			if len(c.app.localDb.FolderOrderedPlaylistFolders[playlistFolderId])+len(c.app.localDb.FolderOrderedPlaylists[playlistFolderId]) > 0 {
+				c.app.HomeComponent.MessageComponent.Message("Move or delete the content of the folder before deleting it")
+				return
+			}
+			component := NewHomeConfirmDeleteComponent(c.app, playlistFolderId)
+			c.app.HomeComponent.OpenModal()
+			component.Render()
 		case "playlistLink":
 			playlistId := restApiV1.PlaylistId(dataset.Get("playlistid").String())
 			c.OpenPlaylistAction(playlistId)
@@ -271,6 +350,10 @@ func (c *LibraryComponent) Render() {
 
 				logrus.Info("Activate")
 			}
+		case "songRatingLink":
+			songId := restApiV1.SongId(dataset.Get("songid").String())
+			rating, _ := strconv.ParseInt(dataset.Get("rating").String(), 10, 64)
+			c.RateSongAction(songId, rating, link.Get("parentElement"))
 		case "songPlayNowLink":
 			songId := restApiV1.SongId(dataset.Get("songid").String())
 			c.app.HomeComponent.PlayerComponent.PlaySongAction(songId)
@@ -314,22 +397,29 @@ func (c *LibraryComponent) computeCache() {
 	// Clear cache
 	c.libraryState.cachedArtists = nil
 	c.libraryState.cachedAlbums = nil
+	c.libraryState.cachedGenres = nil
 	c.libraryState.cachedSongs = nil
 	c.libraryState.cachedPlaylists = nil
+	c.libraryState.cachedFolders = nil
 	c.libraryState.cachedUsers = nil
+	c.libraryState.cachedStats = nil
 
 	// Compute cache
 	switch c.libraryState.libraryType {
-	case LibraryTypeArtists:
+	case LibraryTypeArtists, LibraryTypeComposers:
 		c.computeArtistList()
 	case LibraryTypeAlbums:
 		c.computeAlbumList()
+	case LibraryTypeGenres:
+		c.computeGenreList()
 	case LibraryTypePlaylists:
 		c.computePlaylistList()
 	case LibraryTypeSongs:
 		c.computeSongList()
 	case LibraryTypeUsers:
 		c.computeUserList()
+	case LibraryTypeStats:
+		c.computeStats()
 	}
 }
 
@@ -357,11 +447,17 @@ func (c *LibraryComponent) RefreshView() {
 		libraryAddToPlaylistButton.Set("disabled", true)
 	}
 	libraryCreateButton := jst.Id("libraryCreateButton")
-	if c.libraryState.libraryType == LibraryTypeUsers {
+	if c.libraryState.libraryType == LibraryTypeUsers || c.libraryState.libraryType == LibraryTypePlaylists {
 		libraryCreateButton.Set("disabled", false)
 	} else {
 		libraryCreateButton.Set("disabled", true)
 	}
+	libraryCreateFolderButton := jst.Id("libraryCreateFolderButton")
+	if c.isPlaylistFolderView() && c.app.IsConnectedUserAdmin() {
+		libraryCreateFolderButton.Set("disabled", false)
+	} else {
+		libraryCreateFolderButton.Set("disabled", true)
+	}
 
 	// Update list
 	c.updateLibraryList(0)
@@ -370,7 +466,9 @@ func (c *LibraryComponent) RefreshView() {
 func (c *LibraryComponent) computeArtistList() {
 	var artistList []*restApiV1.Artist
 
-	if c.libraryState.onlyFavoritesFilter {
+	if c.libraryState.libraryType == LibraryTypeComposers {
+		artistList = c.app.localDb.OrderedComposers
+	} else if c.libraryState.onlyFavoritesFilter {
 		artistList = c.app.localDb.UserOrderedFavoriteArtists[c.app.ConnectedUserId()]
 	} else {
 		artistList = c.app.localDb.OrderedArtists
@@ -413,6 +511,23 @@ func (c *LibraryComponent) computeAlbumList() {
 	}
 }
 
+func (c *LibraryComponent) computeGenreList() {
+	genreList := c.app.localDb.OrderedGenres
+
+	if c.libraryState.nameFilter != nil {
+		lowerNameFilter := strings.ToLower(*c.libraryState.nameFilter)
+		for _, genre := range genreList {
+			if genre != nil && !strings.Contains(strings.ToLower(genre.Name), lowerNameFilter) {
+				continue
+			}
+
+			c.libraryState.cachedGenres = append(c.libraryState.cachedGenres, genre)
+		}
+	} else {
+		c.libraryState.cachedGenres = genreList
+	}
+}
+
 func (c *LibraryComponent) computeSongList() {
 
 	var songList []*restApiV1.Song
@@ -429,12 +544,20 @@ func (c *LibraryComponent) computeSongList() {
 			} else {
 				songList = c.app.localDb.ArtistOrderedSongs[*c.libraryState.artistId]
 			}
+		} else if c.libraryState.composerId != nil {
+			songList = c.app.localDb.ComposerOrderedSongs[*c.libraryState.composerId]
 		} else if c.libraryState.albumId != nil {
 			if *c.libraryState.albumId == restApiV1.UnknownAlbumId {
 				songList = c.app.localDb.UnknownAlbumSongs
 			} else {
 				songList = c.app.localDb.AlbumOrderedSongs[*c.libraryState.albumId]
 			}
+		} else if c.libraryState.genreId != nil {
+			if *c.libraryState.genreId == restApiV1.UnknownGenreId {
+				songList = c.app.localDb.UnknownGenreSongs
+			} else {
+				songList = c.app.localDb.GenreOrderedSongs[*c.libraryState.genreId]
+			}
 		} else {
 			if c.libraryState.onlyFavoritesFilter {
 				songList = c.app.localDb.UserOrderedFavoriteSongs[c.app.ConnectedUserId()]
@@ -498,9 +621,31 @@ func (c *LibraryComponent) computeSongList() {
 	}
 }
 
+// isPlaylistFolderView check if the library lists the content of a playlist folder, searching and favorites showing every playlist
+func (c *LibraryComponent) isPlaylistFolderView() bool {
+	return c.libraryState.libraryType == LibraryTypePlaylists && c.libraryState.userId == nil && !c.libraryState.onlyFavoritesFilter && c.libraryState.nameFilter == nil
+}
+
 func (c *LibraryComponent) computePlaylistList() {
 	var playlistList []*restApiV1.Playlist
 
+	// Go back to the root folder when the current folder is deleted
+	if c.libraryState.playlistFolderId != nil {
+		if _, ok := c.app.localDb.PlaylistFolders[*c.libraryState.playlistFolderId]; !ok {
+			c.libraryState.playlistFolderId = nil
+		}
+	}
+
+	if c.isPlaylistFolderView() {
+		var playlistFolderId restApiV1.PlaylistFolderId
+		if c.libraryState.playlistFolderId != nil {
+			playlistFolderId = *c.libraryState.playlistFolderId
+		}
+		c.libraryState.cachedFolders = c.app.localDb.FolderOrderedPlaylistFolders[playlistFolderId]
+		c.libraryState.cachedPlaylists = c.app.localDb.FolderOrderedPlaylists[playlistFolderId]
+		return
+	}
+
 	if c.libraryState.onlyFavoritesFilter {
 		playlistList = c.app.localDb.UserOrderedFavoritePlaylists[c.app.ConnectedUserId()]
 	} else {
@@ -540,6 +685,16 @@ func (c *LibraryComponent) computeUserList() {
 	}
 }
 
+func (c *LibraryComponent) computeStats() {
+	from, to := c.libraryState.statsPeriod.Bounds(time.Now())
+	userStats, cliErr := c.app.restClient.ReadUserStats(c.app.ConnectedUserId(), from, to)
+	if cliErr != nil {
+		c.app.HomeComponent.MessageComponent.ClientErrorMessage("Unable to retrieve statistics", cliErr)
+		return
+	}
+	c.libraryState.cachedStats = userStats
+}
+
 func (c *LibraryComponent) updateTitle() {
 
 	var title string
@@ -551,20 +706,27 @@ func (c *LibraryComponent) updateTitle() {
 		} else {
 			title = fmt.Sprintf(`Favorite artists from <span class="userLink">%s</span>`, html.EscapeString(c.app.localDb.Users[*c.libraryState.userId].Name))
 		}
+	case LibraryTypeComposers:
+		title = `Composers`
 	case LibraryTypeAlbums:
 		if c.libraryState.userId == nil {
 			title = `Albums`
 		} else {
 			title = fmt.Sprintf(`Favorite albums from <span class="userLink">%s</span>`, html.EscapeString(c.app.localDb.Users[*c.libraryState.userId].Name))
 		}
+	case LibraryTypeGenres:
+		title = `Genres`
 	case LibraryTypePlaylists:
 		if c.libraryState.userId == nil {
 			title = `Playlists`
+			if c.isPlaylistFolderView() && c.libraryState.playlistFolderId != nil {
+				title = fmt.Sprintf(`Playlists / %s`, html.EscapeString(c.app.localDb.PlaylistFolderLabel(*c.libraryState.playlistFolderId)))
+			}
 		} else {
 			title = fmt.Sprintf(`Favorite playlists from <span class="userLink">%s</span>`, html.EscapeString(c.app.localDb.Users[*c.libraryState.userId].Name))
 		}
 	case LibraryTypeSongs:
-		if c.libraryState.userId == nil && c.libraryState.playlistId == nil && c.libraryState.artistId == nil && c.libraryState.albumId == nil {
+		if c.libraryState.userId == nil && c.libraryState.playlistId == nil && c.libraryState.artistId == nil && c.libraryState.composerId == nil && c.libraryState.albumId == nil && c.libraryState.genreId == nil {
 			title = `Songs`
 		}
 		if c.libraryState.playlistId != nil {
@@ -580,6 +742,9 @@ func (c *LibraryComponent) updateTitle() {
 				title = "Songs from unknown artists"
 			}
 		}
+		if c.libraryState.composerId != nil {
+			title = fmt.Sprintf(`Songs composed by <span class="artistLink">%s</span>`, html.EscapeString(c.app.localDb.Artists[*c.libraryState.composerId].Name))
+		}
 		if c.libraryState.albumId != nil {
 			if *c.libraryState.albumId != restApiV1.UnknownAlbumId {
 				title = fmt.Sprintf(`Songs from <span class="albumLink">%s</span>`, html.EscapeString(c.app.localDb.Albums[*c.libraryState.albumId].Name))
@@ -587,8 +752,17 @@ func (c *LibraryComponent) updateTitle() {
 				title = "Songs from unknown album"
 			}
 		}
+		if c.libraryState.genreId != nil {
+			if *c.libraryState.genreId != restApiV1.UnknownGenreId {
+				title = fmt.Sprintf(`Songs from <span class="genreLink">%s</span>`, html.EscapeString(c.app.localDb.Genres[*c.libraryState.genreId].Name))
+			} else {
+				title = "Songs from unknown genre"
+			}
+		}
 	case LibraryTypeUsers:
 		title = "Users"
+	case LibraryTypeStats:
+		title = "Statistics"
 	}
 
 	titleSpan := jst.Id("libraryTitle")
@@ -625,7 +799,7 @@ func (c *LibraryComponent) updateLibraryList(direction int) {
 	}
 
 	switch c.libraryState.libraryType {
-	case LibraryTypeArtists:
+	case LibraryTypeArtists, LibraryTypeComposers:
 		divContentPreviousPage = c.renderArtistItemList(c.libraryState.cachedArtists[minIdx:step1Idx])
 		divContentCurrentPage = c.renderArtistItemList(c.libraryState.cachedArtists[step1Idx:step2Idx])
 		divContentNextPage = c.renderArtistItemList(c.libraryState.cachedArtists[step2Idx:maxIdx])
@@ -633,8 +807,16 @@ func (c *LibraryComponent) updateLibraryList(direction int) {
 		divContentPreviousPage = c.renderAlbumItemList(c.libraryState.cachedAlbums[minIdx:step1Idx])
 		divContentCurrentPage = c.renderAlbumItemList(c.libraryState.cachedAlbums[step1Idx:step2Idx])
 		divContentNextPage = c.renderAlbumItemList(c.libraryState.cachedAlbums[step2Idx:maxIdx])
+	case LibraryTypeGenres:
+		divContentPreviousPage = c.renderGenreItemList(c.libraryState.cachedGenres[minIdx:step1Idx])
+		divContentCurrentPage = c.renderGenreItemList(c.libraryState.cachedGenres[step1Idx:step2Idx])
+		divContentNextPage = c.renderGenreItemList(c.libraryState.cachedGenres[step2Idx:maxIdx])
 	case LibraryTypePlaylists:
 		divContentPreviousPage = c.renderPlaylistItemList(c.libraryState.cachedPlaylists[minIdx:step1Idx])
+		// Sub folders are listed before the playlists of the folder
+		if minIdx == 0 && c.isPlaylistFolderView() {
+			divContentPreviousPage = c.renderPlaylistFolderItemList(c.libraryState.cachedFolders) + divContentPreviousPage
+		}
 		divContentCurrentPage = c.renderPlaylistItemList(c.libraryState.cachedPlaylists[step1Idx:step2Idx])
 		divContentNextPage = c.renderPlaylistItemList(c.libraryState.cachedPlaylists[step2Idx:maxIdx])
 	case LibraryTypeSongs:
@@ -645,6 +827,8 @@ func (c *LibraryComponent) updateLibraryList(direction int) {
 		divContentPreviousPage = c.renderUserItemList(c.libraryState.cachedUsers[minIdx:step1Idx])
 		divContentCurrentPage = c.renderUserItemList(c.libraryState.cachedUsers[step1Idx:step2Idx])
 		divContentNextPage = c.renderUserItemList(c.libraryState.cachedUsers[step2Idx:maxIdx])
+	case LibraryTypeStats:
+		divContentCurrentPage = c.renderStats()
 	}
 	var newScrollTop int
 	libraryList.Set("innerHTML", divContentPreviousPage)
@@ -664,14 +848,21 @@ func (c *LibraryComponent) updateLibraryList(direction int) {
 
 func (c *LibraryComponent) renderArtistItemList(artistList []*restApiV1.Artist) string {
 	type ArtistItem struct {
-		ArtistId        string
-		ArtistName      string
-		ArtistSongCount int
-		IsEditable      bool
+		ArtistId         string
+		ArtistName       string
+		ArtistSongCount  int
+		ArtistPictureUrl string
+		ArtistBiography  string
+		IsEditable       bool
 	}
 
 	var artistItemList = make([]ArtistItem, len(artistList))
 
+	token, cliErr := c.app.restClient.GetToken()
+	if cliErr != nil {
+		c.app.HomeComponent.MessageComponent.ClientErrorMessage("Unable to retrieve token", cliErr)
+	}
+
 	for artistIdx, artist := range artistList {
 		if artist == nil {
 			artistItemList[artistIdx].ArtistId = string(restApiV1.UnknownArtistId)
@@ -681,7 +872,15 @@ func (c *LibraryComponent) renderArtistItemList(artistList []*restApiV1.Artist)
 		} else {
 			artistItemList[artistIdx].ArtistId = string(artist.Id)
 			artistItemList[artistIdx].ArtistName = artist.Name
-			artistItemList[artistIdx].ArtistSongCount = len(c.app.localDb.ArtistOrderedSongs[artist.Id])
+			if c.libraryState.libraryType == LibraryTypeComposers {
+				artistItemList[artistIdx].ArtistSongCount = len(c.app.localDb.ComposerOrderedSongs[artist.Id])
+			} else {
+				artistItemList[artistIdx].ArtistSongCount = len(c.app.localDb.ArtistOrderedSongs[artist.Id])
+			}
+			if artist.PictureFg && token != nil {
+				artistItemList[artistIdx].ArtistPictureUrl = "/api/v1/artistPictures/" + string(artist.Id) + "?bearer=" + token.AccessToken + "&ts=" + strconv.FormatInt(artist.UpdateTs, 10)
+			}
+			artistItemList[artistIdx].ArtistBiography = tool.CharacterTruncate(artist.Biography, 120)
 			artistItemList[artistIdx].IsEditable = c.app.IsConnectedUserAdmin()
 		}
 	}
@@ -694,6 +893,8 @@ func (c *LibraryComponent) renderAlbumItemList(albumList []*restApiV1.Album) str
 		AlbumId        string
 		AlbumName      string
 		AlbumSongCount int
+		AlbumDuration  string
+		AlbumCoverUrl  string
 		Artists        []struct {
 			ArtistId   string
 			ArtistName string
@@ -703,16 +904,26 @@ func (c *LibraryComponent) renderAlbumItemList(albumList []*restApiV1.Album) str
 
 	var albumItemList = make([]AlbumItem, len(albumList))
 
+	token, cliErr := c.app.restClient.GetToken()
+	if cliErr != nil {
+		c.app.HomeComponent.MessageComponent.ClientErrorMessage("Unable to retrieve token", cliErr)
+	}
+
 	for albumIdx, album := range albumList {
 		if album == nil {
 			albumItemList[albumIdx].AlbumId = string(restApiV1.UnknownAlbumId)
 			albumItemList[albumIdx].AlbumName = "(Unknown album)"
 			albumItemList[albumIdx].AlbumSongCount = len(c.app.localDb.UnknownAlbumSongs)
+			albumItemList[albumIdx].AlbumDuration = tool.FormatDuration(c.app.localDb.AlbumDuration(restApiV1.UnknownAlbumId))
 			albumItemList[albumIdx].IsEditable = false
 		} else {
 			albumItemList[albumIdx].AlbumId = string(album.Id)
 			albumItemList[albumIdx].AlbumName = album.Name
 			albumItemList[albumIdx].AlbumSongCount = len(c.app.localDb.AlbumOrderedSongs[album.Id])
+			albumItemList[albumIdx].AlbumDuration = tool.FormatDuration(c.app.localDb.AlbumDuration(album.Id))
+			if album.CoverFg && token != nil {
+				albumItemList[albumIdx].AlbumCoverUrl = "/api/v1/albumCovers/" + string(album.Id) + "?bearer=" + token.AccessToken + "&ts=" + strconv.FormatInt(album.UpdateTs, 10)
+			}
 			for _, artistId := range album.ArtistIds {
 				albumItemList[albumIdx].Artists = append(albumItemList[albumIdx].Artists, struct {
 					ArtistId   string
@@ -729,15 +940,76 @@ func (c *LibraryComponent) renderAlbumItemList(albumList []*restApiV1.Album) str
 	return c.app.RenderTemplate(albumItemList, "home/library/albumItemList")
 }
 
+func (c *LibraryComponent) renderGenreItemList(genreList []*restApiV1.Genre) string {
+	type GenreItem struct {
+		GenreId        string
+		GenreName      string
+		GenreSongCount int
+	}
+
+	var genreItemList = make([]GenreItem, len(genreList))
+
+	for genreIdx, genre := range genreList {
+		if genre == nil {
+			genreItemList[genreIdx].GenreId = string(restApiV1.UnknownGenreId)
+			genreItemList[genreIdx].GenreName = "(Unknown genre)"
+			genreItemList[genreIdx].GenreSongCount = len(c.app.localDb.UnknownGenreSongs)
+		} else {
+			genreItemList[genreIdx].GenreId = string(genre.Id)
+			genreItemList[genreIdx].GenreName = genre.Name
+			genreItemList[genreIdx].GenreSongCount = len(c.app.localDb.GenreOrderedSongs[genre.Id])
+		}
+	}
+
+	return c.app.RenderTemplate(genreItemList, "home/library/genreItemList")
+}
+
+// RateSongAction give stars to a song for the connected user, clicking its current rating removes it
+func (c *LibraryComponent) RateSongAction(songId restApiV1.SongId, rating int64, ratingWidget js.Value) {
+	songRatingId := restApiV1.SongRatingId{
+		UserId: c.app.ConnectedUserId(),
+		SongId: songId,
+	}
+
+	if c.app.localDb.UserSongRatings[c.app.ConnectedUserId()][songId] == rating {
+		_, cliErr := c.app.restClient.DeleteSongRating(songRatingId)
+		if cliErr != nil {
+			c.app.HomeComponent.MessageComponent.ClientErrorMessage("Unable to remove song rating", cliErr)
+			return
+		}
+		rating = 0
+	} else {
+		_, cliErr := c.app.restClient.UpdateSongRating(&restApiV1.SongRatingMeta{Id: songRatingId, Rating: rating})
+		if cliErr != nil {
+			c.app.HomeComponent.MessageComponent.ClientErrorMessage("Unable to rate song", cliErr)
+			return
+		}
+	}
+	c.app.localDb.SetMySongRating(songId, rating)
+
+	// Light the stars up to the new rating
+	stars := ratingWidget.Get("children")
+	for i := 0; i < stars.Length(); i++ {
+		stars.Index(i).Get("dataset").Set("lit", strconv.FormatBool(int64(i) < rating))
+	}
+}
+
 func (c *LibraryComponent) renderSongItemList(songList []*restApiV1.Song) string {
 
+	type RatingStar struct {
+		Rating int64
+		Lit    bool
+	}
+
 	type SongItem struct {
-		SongId    string
-		Favorite  bool
-		SongName  string
-		AlbumId   *string
-		AlbumName string
-		Artists   []struct {
+		SongId       string
+		Favorite     bool
+		RatingStars  []RatingStar
+		SongName     string
+		SongDuration string
+		AlbumId      *string
+		AlbumName    string
+		Artists      []struct {
 			ArtistId   string
 			ArtistName string
 		}
@@ -752,7 +1024,14 @@ func (c *LibraryComponent) renderSongItemList(songList []*restApiV1.Song) string
 
 		songItemList[songIdx].SongId = string(song.Id)
 		songItemList[songIdx].Favorite = favorite
+		rating := c.app.localDb.UserSongRatings[c.app.ConnectedUserId()][song.Id]
+		for starRating := int64(restApiV1.SongRatingMin); starRating <= restApiV1.SongRatingMax; starRating++ {
+			songItemList[songIdx].RatingStars = append(songItemList[songIdx].RatingStars, RatingStar{Rating: starRating, Lit: starRating <= rating})
+		}
 		songItemList[songIdx].SongName = song.Name
+		if song.Duration > 0 {
+			songItemList[songIdx].SongDuration = tool.FormatDuration(song.Duration)
+		}
 		songItemList[songIdx].ExplicitFg = song.ExplicitFg
 		songItemList[songIdx].IsEditable = c.app.IsConnectedUserAdmin()
 
@@ -777,12 +1056,46 @@ func (c *LibraryComponent) renderSongItemList(songList []*restApiV1.Song) string
 	return c.app.RenderTemplate(songItemList, "home/library/songItemList")
 }
 
+func (c *LibraryComponent) renderPlaylistFolderItemList(playlistFolderList []*restApiV1.PlaylistFolder) string {
+	type PlaylistFolderItem struct {
+		PlaylistFolderId string
+		Name             string
+		ContentCount     int
+		IsParent         bool
+		IsEditable       bool
+	}
+
+	var playlistFolderItemList []PlaylistFolderItem
+
+	// Link to the parent folder
+	if c.libraryState.playlistFolderId != nil {
+		playlistFolderItem := PlaylistFolderItem{Name: "..", IsParent: true}
+		if parentFolderId := c.app.localDb.PlaylistFolders[*c.libraryState.playlistFolderId].ParentFolderId; parentFolderId != nil {
+			playlistFolderItem.PlaylistFolderId = string(*parentFolderId)
+		}
+		playlistFolderItemList = append(playlistFolderItemList, playlistFolderItem)
+	}
+
+	for _, playlistFolder := range playlistFolderList {
+		playlistFolderItemList = append(playlistFolderItemList, PlaylistFolderItem{
+			PlaylistFolderId: string(playlistFolder.Id),
+			Name:             playlistFolder.Name,
+			ContentCount:     len(c.app.localDb.FolderOrderedPlaylistFolders[playlistFolder.Id]) + len(c.app.localDb.FolderOrderedPlaylists[playlistFolder.Id]),
+			IsEditable:       c.app.IsConnectedUserAdmin(),
+		})
+	}
+
+	return c.app.RenderTemplate(playlistFolderItemList, "home/library/playlistFolderItemList")
+}
+
 func (c *LibraryComponent) renderPlaylistItemList(playlistList []*restApiV1.Playlist) string {
 	type PlaylistItem struct {
 		PlaylistId        string
 		Favorite          bool
 		Name              string
+		Smart             bool
 		PlaylistSongCount int
+		PlaylistDuration  string
 		OwnerUsers        []struct {
 			UserId   string
 			UserName string
@@ -799,7 +1112,9 @@ func (c *LibraryComponent) renderPlaylistItemList(playlistList []*restApiV1.Play
 		playlistItemList[playlistIdx].PlaylistId = string(playlist.Id)
 		playlistItemList[playlistIdx].Favorite = favorite
 		playlistItemList[playlistIdx].Name = playlist.Name
+		playlistItemList[playlistIdx].Smart = playlist.SmartRules != nil
 		playlistItemList[playlistIdx].PlaylistSongCount = len(playlist.SongIds)
+		playlistItemList[playlistIdx].PlaylistDuration = tool.FormatDuration(c.app.localDb.PlaylistDuration(playlist.Id))
 		playlistItemList[playlistIdx].IsEditable = c.app.IsConnectedUserAdmin() || c.app.localDb.IsPlaylistOwnedBy(playlist.Id, c.app.ConnectedUserId())
 		playlistItemList[playlistIdx].IsDeletable = playlist.Id != restApiV1.IncomingPlaylistId && (c.app.IsConnectedUserAdmin() || c.app.localDb.IsPlaylistOwnedBy(playlist.Id, c.app.ConnectedUserId()))
 
@@ -836,6 +1151,82 @@ func (c *LibraryComponent) renderUserItemList(userList []*restApiV1.User) string
 	return c.app.RenderTemplate(userItemList, "home/library/userItemList")
 }
 
+func (c *LibraryComponent) renderStats() string {
+	type StatsPeriodItem struct {
+		Value    int64
+		Label    string
+		Selected bool
+	}
+	type StatsItem struct {
+		Rank             int
+		Id               string
+		Name             string
+		Percent          int64
+		PlayCount        int64
+		ListenedDuration string
+	}
+	type StatsView struct {
+		Periods          []StatsPeriodItem
+		Loaded           bool
+		PlayCount        int64
+		ListenedDuration string
+		TopSongs         []StatsItem
+		TopArtists       []StatsItem
+		TopAlbums        []StatsItem
+		Formats          []StatsItem
+		Days             []StatsItem
+	}
+
+	var statsView StatsView
+	for _, statsPeriod := range tool.StatsPeriods {
+		statsView.Periods = append(statsView.Periods, StatsPeriodItem{
+			Value:    int64(statsPeriod),
+			Label:    statsPeriod.Label(time.Now()),
+			Selected: statsPeriod == c.libraryState.statsPeriod,
+		})
+	}
+
+	userStats := c.libraryState.cachedStats
+	if userStats == nil {
+		return c.app.RenderTemplate(statsView, "home/library/stats")
+	}
+	statsView.Loaded = true
+	statsView.PlayCount = userStats.PlayCount
+	statsView.ListenedDuration = tool.FormatDuration(userStats.ListenedDuration)
+
+	for ind, songStats := range userStats.TopSongs {
+		if song, ok := c.app.localDb.Songs[songStats.SongId]; ok {
+			statsView.TopSongs = append(statsView.TopSongs, StatsItem{Rank: ind + 1, Id: string(song.Id), Name: song.Name, PlayCount: songStats.PlayCount, ListenedDuration: tool.FormatDuration(songStats.ListenedDuration)})
+		}
+	}
+	for ind, artistStats := range userStats.TopArtists {
+		if artist, ok := c.app.localDb.Artists[artistStats.ArtistId]; ok {
+			statsView.TopArtists = append(statsView.TopArtists, StatsItem{Rank: ind + 1, Id: string(artist.Id), Name: artist.Name, PlayCount: artistStats.PlayCount, ListenedDuration: tool.FormatDuration(artistStats.ListenedDuration)})
+		}
+	}
+	for ind, albumStats := range userStats.TopAlbums {
+		if album, ok := c.app.localDb.Albums[albumStats.AlbumId]; ok {
+			statsView.TopAlbums = append(statsView.TopAlbums, StatsItem{Rank: ind + 1, Id: string(album.Id), Name: album.Name, PlayCount: albumStats.PlayCount, ListenedDuration: tool.FormatDuration(albumStats.ListenedDuration)})
+		}
+	}
+	for _, formatStats := range userStats.Formats {
+		statsView.Formats = append(statsView.Formats, StatsItem{Name: formatStats.Format.String(), Percent: formatStats.PlayCount * 100 / userStats.PlayCount, PlayCount: formatStats.PlayCount, ListenedDuration: tool.FormatDuration(formatStats.ListenedDuration)})
+	}
+
+	// Day bars are relative to the most listened day
+	var maxListenedDuration int64
+	for _, dayStats := range userStats.Days {
+		if dayStats.ListenedDuration > maxListenedDuration {
+			maxListenedDuration = dayStats.ListenedDuration
+		}
+	}
+	for _, dayStats := range userStats.Days {
+		statsView.Days = append(statsView.Days, StatsItem{Name: dayStats.Day, Percent: dayStats.ListenedDuration * 100 / maxListenedDuration, PlayCount: dayStats.PlayCount, ListenedDuration: tool.FormatDuration(dayStats.ListenedDuration)})
+	}
+
+	return c.app.RenderTemplate(statsView, "home/library/stats")
+}
+
 func (c *LibraryComponent) ShowArtistsAction() {
 	c.libraryState = libraryState{
 		libraryType: LibraryTypeArtists,
@@ -844,6 +1235,14 @@ func (c *LibraryComponent) ShowArtistsAction() {
 	c.RefreshView()
 }
 
+func (c *LibraryComponent) ShowComposersAction() {
+	c.libraryState = libraryState{
+		libraryType: LibraryTypeComposers,
+	}
+	jst.Id("librarySearchInput").Set("value", "")
+	c.RefreshView()
+}
+
 func (c *LibraryComponent) ShowAlbumsAction() {
 	c.libraryState = libraryState{
 		libraryType: LibraryTypeAlbums,
@@ -852,6 +1251,14 @@ func (c *LibraryComponent) ShowAlbumsAction() {
 	c.RefreshView()
 }
 
+func (c *LibraryComponent) ShowGenresAction() {
+	c.libraryState = libraryState{
+		libraryType: LibraryTypeGenres,
+	}
+	jst.Id("librarySearchInput").Set("value", "")
+	c.RefreshView()
+}
+
 func (c *LibraryComponent) ShowSongsAction() {
 	c.libraryState = libraryState{
 		libraryType: LibraryTypeSongs,
@@ -875,6 +1282,13 @@ func (c *LibraryComponent) ShowUsersAction() {
 	c.RefreshView()
 }
 
+func (c *LibraryComponent) ShowStatsAction() {
+	c.libraryState = libraryState{
+		libraryType: LibraryTypeStats,
+	}
+	c.RefreshView()
+}
+
 func (c *LibraryComponent) AddToPlaylistAction() {
 	if len(c.libraryState.cachedSongs) > 0 {
 		c.app.HomeComponent.CurrentComponent.AddSongsAction(c.libraryState.cachedSongs)
@@ -899,6 +1313,24 @@ func (c *LibraryComponent) OpenArtistAction(artistId restApiV1.ArtistId) {
 	c.RefreshView()
 }
 
+func (c *LibraryComponent) OpenComposerAction(artistId restApiV1.ArtistId) {
+	c.libraryState = libraryState{
+		libraryType: LibraryTypeSongs,
+		composerId:  &artistId,
+	}
+	jst.Id("librarySearchInput").Set("value", "")
+	c.RefreshView()
+}
+
+func (c *LibraryComponent) OpenGenreAction(genreId restApiV1.GenreId) {
+	c.libraryState = libraryState{
+		libraryType: LibraryTypeSongs,
+		genreId:     &genreId,
+	}
+	jst.Id("librarySearchInput").Set("value", "")
+	c.RefreshView()
+}
+
 func (c *LibraryComponent) OpenPlaylistAction(playlistId restApiV1.PlaylistId) {
 	c.libraryState = libraryState{
 		libraryType: LibraryTypeSongs,
@@ -908,6 +1340,15 @@ func (c *LibraryComponent) OpenPlaylistAction(playlistId restApiV1.PlaylistId) {
 	c.RefreshView()
 }
 
+func (c *LibraryComponent) OpenPlaylistFolderAction(playlistFolderId *restApiV1.PlaylistFolderId) {
+	c.libraryState = libraryState{
+		libraryType:      LibraryTypePlaylists,
+		playlistFolderId: playlistFolderId,
+	}
+	jst.Id("librarySearchInput").Set("value", "")
+	c.RefreshView()
+}
+
 func (c *LibraryComponent) FavoritesSwitchAction() {
 	c.libraryState.onlyFavoritesFilter = !c.libraryState.onlyFavoritesFilter
 	c.RefreshView()
@@ -5,14 +5,29 @@ import (
 	"github.com/jypelle/mifasol/internal/cliwa/jst"
 	"github.com/jypelle/mifasol/restApiV1"
 	"github.com/sirupsen/logrus"
+	"math"
+	"net/url"
 	"strconv"
+	"syscall/js"
+	"time"
 )
 
+// playerWaveformPointCount is the number of bars of the waveform drawn behind the seek slider
+const playerWaveformPointCount = 200
+
 type HomePlayerComponent struct {
 	app                   *App
 	volume                float64
 	muted                 bool
 	autoRefreshSeekSlider bool
+	knownDuration         bool
+	waveform              *restApiV1.SongWaveform
+
+	// Play history of the current song
+	playingSongId   restApiV1.SongId
+	playStartTs     int64
+	listenedTime    float64
+	lastCurrentTime float64
 }
 
 func NewHomePlayerComponent(app *App) *HomePlayerComponent {
@@ -36,8 +51,15 @@ func (c *HomePlayerComponent) Render() {
 	playerMuteButton := jst.Id("playerMuteButton")
 	playerVolumeSlider := jst.Id("playerVolumeSlider")
 
-	playerAudio.Call("addEventListener", "ended", c.app.AddEventFunc(c.app.HomeComponent.CurrentComponent.PlayNextSongAction))
+	playerAudio.Call("addEventListener", "ended", c.app.AddEventFunc(func() {
+		c.reportPlay(true)
+		c.app.HomeComponent.CurrentComponent.PlayNextSongAction()
+	}))
 	playerAudio.Call("addEventListener", "loadedmetadata", c.app.AddEventFunc(func() {
+		// Fallback for songs without known duration
+		if c.knownDuration {
+			return
+		}
 		duration := playerAudio.Get("duration").Int()
 		logrus.Infof("duration: %d", duration)
 		playerDuration.Set("innerHTML", fmt.Sprintf("%d:%02d", duration/60, duration%60))
@@ -45,10 +67,18 @@ func (c *HomePlayerComponent) Render() {
 		playerSeekSlider.Set("value", 0)
 	}))
 	playerAudio.Call("addEventListener", "timeupdate", c.app.AddEventFunc(func() {
+		// Listened time ignore seeks
+		preciseCurrentTime := playerAudio.Get("currentTime").Float()
+		if delta := preciseCurrentTime - c.lastCurrentTime; delta > 0 && delta <= 2 {
+			c.listenedTime += delta
+		}
+		c.lastCurrentTime = preciseCurrentTime
+
 		currentTime := playerAudio.Get("currentTime").Int()
 		playerCurrentTime.Set("innerHTML", fmt.Sprintf("%d:%02d", currentTime/60, currentTime%60))
 		if c.autoRefreshSeekSlider {
 			playerSeekSlider.Set("value", currentTime)
+			c.drawWaveform()
 		}
 	}))
 
@@ -95,6 +125,42 @@ func (c *HomePlayerComponent) Render() {
 	playerVolumeSlider.Call("addEventListener", "change", adjustVolumeFunc)
 	playerVolumeSlider.Call("addEventListener", "input", adjustVolumeFunc)
 
+	// Streaming quality is a per-device setting, kept in localStorage
+	playerStreamingProfileSelect := jst.Id("playerStreamingProfileSelect")
+	c.renderStreamingProfiles()
+	playerStreamingProfileSelect.Call("addEventListener", "change", c.app.AddEventFunc(func() {
+		c.app.config.StreamingProfile = playerStreamingProfileSelect.Get("value").String()
+		jst.LocalStorage.Set("mifasolStreamingProfile", c.app.config.StreamingProfile)
+	}))
+}
+
+// renderStreamingProfiles fill the streaming quality list with the server transcoding profiles
+func (c *HomePlayerComponent) renderStreamingProfiles() {
+	transcodingProfiles, cliErr := c.app.restClient.ReadTranscodingProfiles()
+	if cliErr != nil {
+		logrus.Errorf("Unable to retrieve transcoding profiles: %v", cliErr)
+	}
+
+	// Fallback to the original content when the stored profile is no more available
+	c.app.config.StreamingProfile = ""
+	if streamingProfile := jst.LocalStorage.Call("getItem", "mifasolStreamingProfile"); streamingProfile.Type() == js.TypeString {
+		for _, transcodingProfile := range transcodingProfiles {
+			if transcodingProfile.Name == streamingProfile.String() {
+				c.app.config.StreamingProfile = transcodingProfile.Name
+			}
+		}
+	}
+
+	jst.Id("playerStreamingProfileSelect").Set("innerHTML", c.app.RenderTemplate(
+		struct {
+			StreamingProfile    string
+			TranscodingProfiles []restApiV1.TranscodingProfile
+		}{
+			StreamingProfile:    c.app.config.StreamingProfile,
+			TranscodingProfiles: transcodingProfiles,
+		},
+		"home/player/streamingProfileOptions"),
+	)
 }
 
 func (c *HomePlayerComponent) PlaySongAction(songId restApiV1.SongId) {
@@ -104,18 +170,118 @@ func (c *HomePlayerComponent) PlaySongAction(songId restApiV1.SongId) {
 		return
 	}
 
+	c.reportPlay(false)
+
 	playerPlayButton := jst.Id("playerPlayButton")
 	playerPlayButton.Set("innerHTML", `<i class="fas fa-pause"></i>`)
 
+	// Use the duration extracted by the server
+	song := c.app.localDb.Songs[songId]
+	c.knownDuration = song.Duration > 0
+	if c.knownDuration {
+		duration := song.Duration / 1000
+		jst.Id("playerDuration").Set("innerHTML", fmt.Sprintf("%d:%02d", duration/60, duration%60))
+		playerSeekSlider := jst.Id("playerSeekSlider")
+		playerSeekSlider.Set("max", duration)
+		playerSeekSlider.Set("value", 0)
+	}
+
 	player := jst.Id("playerAudio")
-	player.Set("src", "/api/v1/songContents/"+string(songId)+"?bearer="+token.AccessToken)
+	src := "/api/v1/songContents/" + string(songId) + "?bearer=" + token.AccessToken
+	if c.app.config.StreamingProfile != "" {
+		src += "&" + restApiV1.TranscodingProfileParam + "=" + url.QueryEscape(c.app.config.StreamingProfile)
+	}
+	player.Set("src", src)
 	player.Call("play")
 
+	c.playingSongId = songId
+	c.playStartTs = time.Now().UnixNano()
+	c.listenedTime = 0
+	c.lastCurrentTime = 0
+
+	cliErr = c.app.restClient.SubmitNowPlaying(&restApiV1.NowPlayingMeta{UserId: c.app.ConnectedUserId(), SongId: songId})
+	if cliErr != nil {
+		logrus.Errorf("Unable to report now playing song: %v", cliErr)
+	}
+
+	c.loadWaveform(songId)
+
 	c.app.HomeComponent.MessageComponent.Message(`Playing ` + c.InlineSong(songId))
 
 	return
 }
 
+// reportPlay send the play of the current song to the server once it has ended or has been listened long enough
+func (c *HomePlayerComponent) reportPlay(ended bool) {
+	playStartTs := c.playStartTs
+	c.playStartTs = 0
+	if playStartTs == 0 {
+		return
+	}
+
+	song, ok := c.app.localDb.Songs[c.playingSongId]
+	if !ok {
+		return
+	}
+	listenedDuration := int64(c.listenedTime * 1000)
+	if !(ended && listenedDuration > 0) && !restApiV1.IsPlayReportable(listenedDuration, song.Duration) {
+		return
+	}
+
+	_, cliErr := c.app.restClient.CreatePlayEvent(&restApiV1.PlayEventMeta{
+		UserId:           c.app.ConnectedUserId(),
+		SongId:           c.playingSongId,
+		StartTs:          playStartTs,
+		ListenedDuration: listenedDuration,
+		ClientKind:       restApiV1.ClientKindWeb,
+	})
+	if cliErr != nil {
+		logrus.Errorf("Unable to report song play: %v", cliErr)
+	}
+}
+
+// loadWaveform retrieve the song waveform, no waveform is drawn until the server has computed it
+func (c *HomePlayerComponent) loadWaveform(songId restApiV1.SongId) {
+	songWaveform, cliErr := c.app.restClient.ReadSongWaveform(songId, playerWaveformPointCount)
+	if cliErr != nil && cliErr.Code() != restApiV1.NotFoundErrorCode {
+		logrus.Errorf("Unable to retrieve song waveform: %v", cliErr)
+	}
+	c.waveform = songWaveform
+	c.drawWaveform()
+}
+
+// drawWaveform draw the song peaks behind the seek slider, highlighting the played part
+func (c *HomePlayerComponent) drawWaveform() {
+	canvas := jst.Id("playerWaveform")
+	width := canvas.Get("clientWidth").Int()
+	height := canvas.Get("clientHeight").Int()
+	canvas.Set("width", width)
+	canvas.Set("height", height)
+
+	context := canvas.Call("getContext", "2d")
+	context.Call("clearRect", 0, 0, width, height)
+	if c.waveform == nil || len(c.waveform.Peaks) == 0 || width == 0 {
+		return
+	}
+
+	playerAudio := jst.Id("playerAudio")
+	progress := 0.0
+	if duration := playerAudio.Get("duration").Float(); duration > 0 {
+		progress = playerAudio.Get("currentTime").Float() / duration
+	}
+
+	barWidth := float64(width) / float64(len(c.waveform.Peaks))
+	for ind, peak := range c.waveform.Peaks {
+		if float64(ind)/float64(len(c.waveform.Peaks)) < progress {
+			context.Set("fillStyle", "#48899c")
+		} else {
+			context.Set("fillStyle", "#444444")
+		}
+		barHeight := math.Max(1, float64(peak)*float64(height)/255)
+		context.Call("fillRect", float64(ind)*barWidth, (float64(height)-barHeight)/2, math.Max(barWidth-1, 1), barHeight)
+	}
+}
+
 func (c *HomePlayerComponent) InlineSong(songId restApiV1.SongId) string {
 
 	song := c.app.localDb.Songs[songId]
@@ -182,6 +182,8 @@ func (c *HomePlaylistContentSaveAsComponent) playlistSearchAction() {
 			if
 			// Only admin or playlist owner can update a playlist content
 			(!c.app.localDb.IsPlaylistOwnedBy(playlist.Id, c.app.ConnectedUserId()) && !c.app.IsConnectedUserAdmin()) ||
+				// Smart playlist content comes from its rules
+				playlist.SmartRules != nil ||
 				// Name filter should match
 				!strings.Contains(strings.ToLower(playlist.Name), lowerNameFilter) {
 				continue
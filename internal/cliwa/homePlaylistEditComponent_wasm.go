@@ -4,6 +4,7 @@ import (
 	"github.com/jypelle/mifasol/internal/cliwa/jst"
 	"github.com/jypelle/mifasol/restApiV1"
 	"sort"
+	"strconv"
 	"strings"
 	"syscall/js"
 )
@@ -15,6 +16,21 @@ type HomePlaylistEditComponent struct {
 	closed       bool
 }
 
+// smartRulesOption is an option of a smart playlist rule select
+type smartRulesOption struct {
+	Value    string
+	Label    string
+	Selected bool
+}
+
+func NewHomeSmartPlaylistCreateComponent(app *App, folderId *restApiV1.PlaylistFolderId) *HomePlaylistEditComponent {
+	return NewHomePlaylistEditComponent(app, "", &restApiV1.PlaylistMeta{
+		OwnerUserIds: []restApiV1.UserId{app.ConnectedUserId()},
+		FolderId:     folderId,
+		SmartRules:   &restApiV1.SmartPlaylistRules{},
+	})
+}
+
 func NewHomePlaylistEditComponent(app *App, playlistId restApiV1.PlaylistId, playlistMeta *restApiV1.PlaylistMeta) *HomePlaylistEditComponent {
 	c := &HomePlaylistEditComponent{
 		app:          app,
@@ -28,7 +44,7 @@ func NewHomePlaylistEditComponent(app *App, playlistId restApiV1.PlaylistId, pla
 func (c *HomePlaylistEditComponent) Render() {
 	div := jst.Id("homeMainModal")
 	div.Set("innerHTML", c.app.RenderTemplate(
-		c.playlistMeta, "home/playlistEdit/index"),
+		c.playlistItem(), "home/playlistEdit/index"),
 	)
 
 	form := jst.Id("playlistEditForm")
@@ -127,10 +143,23 @@ func (c *HomePlaylistEditComponent) saveAction() {
 
 	playlistName := jst.Id("playlistEditPlaylistName")
 	c.playlistMeta.Name = playlistName.Get("value").String()
+	c.playlistMeta.FolderId = selectedPlaylistFolderId(jst.Id("playlistEditFolder"))
 
-	_, cliErr := c.app.restClient.UpdatePlaylist(c.playlistId, c.playlistMeta)
-	if cliErr != nil {
-		c.app.HomeComponent.MessageComponent.ClientErrorMessage("Unable to update the playlist", cliErr)
+	// Rules of smart playlist
+	if c.playlistMeta.SmartRules != nil {
+		c.playlistMeta.SmartRules = c.smartRules()
+	}
+
+	if c.playlistId != "" {
+		_, cliErr := c.app.restClient.UpdatePlaylist(c.playlistId, c.playlistMeta)
+		if cliErr != nil {
+			c.app.HomeComponent.MessageComponent.ClientErrorMessage("Unable to update the playlist", cliErr)
+		}
+	} else {
+		_, cliErr := c.app.restClient.CreatePlaylist(c.playlistMeta)
+		if cliErr != nil {
+			c.app.HomeComponent.MessageComponent.ClientErrorMessage("Unable to create the playlist", cliErr)
+		}
 	}
 
 	c.close()
@@ -138,6 +167,115 @@ func (c *HomePlaylistEditComponent) saveAction() {
 	c.app.HideLoader()
 }
 
+// playlistItem return the playlist to render, with the options of the smart playlist rules
+func (c *HomePlaylistEditComponent) playlistItem() interface{} {
+	playlistItem := struct {
+		*restApiV1.PlaylistMeta
+		IsNewPlaylist   bool
+		Folders         []smartRulesOption
+		Artists         []smartRulesOption
+		Albums          []smartRulesOption
+		MinYear         string
+		MaxYear         string
+		Formats         []smartRulesOption
+		BitDepths       []smartRulesOption
+		Explicits       []smartRulesOption
+		AddedWithinDays string
+		Users           []smartRulesOption
+		FavoriteFg      bool
+		MinRatings      []smartRulesOption
+		MinPlayCount    string
+	}{
+		PlaylistMeta:  c.playlistMeta,
+		IsNewPlaylist: c.playlistId == "",
+		Folders:       playlistFolderOptions(c.app, c.playlistMeta.FolderId, nil),
+	}
+
+	smartRules := c.playlistMeta.SmartRules
+	if smartRules == nil {
+		return &playlistItem
+	}
+
+	playlistItem.Artists = []smartRulesOption{{Label: "(Any artist)"}}
+	for _, artist := range c.app.localDb.OrderedArtists {
+		if artist != nil {
+			playlistItem.Artists = append(playlistItem.Artists, smartRulesOption{Value: string(artist.Id), Label: artist.Name, Selected: smartRules.ArtistId != nil && *smartRules.ArtistId == artist.Id})
+		}
+	}
+	playlistItem.Albums = []smartRulesOption{{Label: "(Any album)"}}
+	for _, album := range c.app.localDb.OrderedAlbums {
+		if album != nil {
+			playlistItem.Albums = append(playlistItem.Albums, smartRulesOption{Value: string(album.Id), Label: album.Name, Selected: smartRules.AlbumId != nil && *smartRules.AlbumId == album.Id})
+		}
+	}
+	playlistItem.MinYear = formatOptionalInt64(smartRules.MinYear)
+	playlistItem.MaxYear = formatOptionalInt64(smartRules.MaxYear)
+	playlistItem.Formats = []smartRulesOption{{Label: "(Any format)"}}
+	for _, format := range []restApiV1.SongFormat{restApiV1.SongFormatFlac, restApiV1.SongFormatMp3, restApiV1.SongFormatOgg, restApiV1.SongFormatOpus, restApiV1.SongFormatM4a} {
+		playlistItem.Formats = append(playlistItem.Formats, smartRulesOption{Value: strconv.FormatInt(int64(format), 10), Label: format.String(), Selected: smartRules.Format != nil && *smartRules.Format == format})
+	}
+	playlistItem.BitDepths = []smartRulesOption{{Label: "(Any bit depth)"}}
+	for _, bitDepth := range []restApiV1.SongBitDepth{restApiV1.SongBitDepth16, restApiV1.SongBitDepth24} {
+		playlistItem.BitDepths = append(playlistItem.BitDepths, smartRulesOption{Value: strconv.FormatInt(int64(bitDepth), 10), Label: bitDepth.String(), Selected: smartRules.BitDepth != nil && *smartRules.BitDepth == bitDepth})
+	}
+	playlistItem.Explicits = []smartRulesOption{
+		{Label: "(Any song)"},
+		{Value: "true", Label: "Only explicit songs", Selected: smartRules.ExplicitFg != nil && *smartRules.ExplicitFg},
+		{Value: "false", Label: "No explicit songs", Selected: smartRules.ExplicitFg != nil && !*smartRules.ExplicitFg},
+	}
+	playlistItem.AddedWithinDays = formatOptionalInt64(smartRules.AddedWithinDays)
+	playlistItem.Users = []smartRulesOption{{Label: "(Nobody)"}}
+	for _, user := range c.app.localDb.OrderedUsers {
+		playlistItem.Users = append(playlistItem.Users, smartRulesOption{Value: string(user.Id), Label: user.Name, Selected: smartRules.UserId != nil && *smartRules.UserId == user.Id})
+	}
+	playlistItem.FavoriteFg = smartRules.FavoriteFg
+	playlistItem.MinRatings = []smartRulesOption{{Label: "(Any rating)"}}
+	for rating := int64(restApiV1.SongRatingMin); rating <= restApiV1.SongRatingMax; rating++ {
+		playlistItem.MinRatings = append(playlistItem.MinRatings, smartRulesOption{Value: strconv.FormatInt(rating, 10), Label: strings.Repeat("★", int(rating)), Selected: smartRules.MinRating != nil && *smartRules.MinRating == rating})
+	}
+	playlistItem.MinPlayCount = formatOptionalInt64(smartRules.MinPlayCount)
+
+	return &playlistItem
+}
+
+// smartRules return the smart playlist rules filled in the form
+func (c *HomePlaylistEditComponent) smartRules() *restApiV1.SmartPlaylistRules {
+	smartRules := &restApiV1.SmartPlaylistRules{}
+
+	if value := jst.Id("playlistEditArtist").Get("value").String(); value != "" {
+		artistId := restApiV1.ArtistId(value)
+		smartRules.ArtistId = &artistId
+	}
+	if value := jst.Id("playlistEditAlbum").Get("value").String(); value != "" {
+		albumId := restApiV1.AlbumId(value)
+		smartRules.AlbumId = &albumId
+	}
+	smartRules.MinYear = parseOptionalInt64(jst.Id("playlistEditMinYear").Get("value").String())
+	smartRules.MaxYear = parseOptionalInt64(jst.Id("playlistEditMaxYear").Get("value").String())
+	if format := parseOptionalInt64(jst.Id("playlistEditFormat").Get("value").String()); format != nil {
+		songFormat := restApiV1.SongFormat(*format)
+		smartRules.Format = &songFormat
+	}
+	if bitDepth := parseOptionalInt64(jst.Id("playlistEditBitDepth").Get("value").String()); bitDepth != nil {
+		songBitDepth := restApiV1.SongBitDepth(*bitDepth)
+		smartRules.BitDepth = &songBitDepth
+	}
+	if value := jst.Id("playlistEditExplicit").Get("value").String(); value != "" {
+		explicitFg := value == "true"
+		smartRules.ExplicitFg = &explicitFg
+	}
+	smartRules.AddedWithinDays = parseOptionalInt64(jst.Id("playlistEditAddedWithinDays").Get("value").String())
+	if value := jst.Id("playlistEditUser").Get("value").String(); value != "" {
+		userId := restApiV1.UserId(value)
+		smartRules.UserId = &userId
+	}
+	smartRules.FavoriteFg = jst.Id("playlistEditFavoriteFg").Get("checked").Bool()
+	smartRules.MinRating = parseOptionalInt64(jst.Id("playlistEditMinRating").Get("value").String())
+	smartRules.MinPlayCount = parseOptionalInt64(jst.Id("playlistEditMinPlayCount").Get("value").String())
+
+	return smartRules
+}
+
 func (c *HomePlaylistEditComponent) cancelAction() {
 	if c.closed {
 		return
@@ -236,3 +374,20 @@ func (c *HomePlaylistEditComponent) ownerSearchAction() {
 		ownerSearchList.Get("style").Set("display", "none")
 	}
 }
+
+// formatOptionalInt64 return the text of an optional number, empty when nil
+func formatOptionalInt64(value *int64) string {
+	if value == nil {
+		return ""
+	}
+	return strconv.FormatInt(*value, 10)
+}
+
+// parseOptionalInt64 return the number of a text, nil when empty or invalid
+func parseOptionalInt64(text string) *int64 {
+	value, err := strconv.ParseInt(strings.TrimSpace(text), 10, 64)
+	if err != nil {
+		return nil
+	}
+	return &value
+}
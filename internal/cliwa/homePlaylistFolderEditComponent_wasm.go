@@ -0,0 +1,122 @@
+package cliwa
+
+import (
+	"github.com/jypelle/mifasol/internal/cliwa/jst"
+	"github.com/jypelle/mifasol/restApiV1"
+	"syscall/js"
+)
+
+type HomePlaylistFolderEditComponent struct {
+	app                *App
+	playlistFolderId   restApiV1.PlaylistFolderId
+	playlistFolderMeta *restApiV1.PlaylistFolderMeta
+	closed             bool
+}
+
+func NewHomePlaylistFolderCreateComponent(app *App, parentFolderId *restApiV1.PlaylistFolderId) *HomePlaylistFolderEditComponent {
+	return NewHomePlaylistFolderEditComponent(app, "", &restApiV1.PlaylistFolderMeta{ParentFolderId: parentFolderId})
+}
+
+func NewHomePlaylistFolderEditComponent(app *App, playlistFolderId restApiV1.PlaylistFolderId, playlistFolderMeta *restApiV1.PlaylistFolderMeta) *HomePlaylistFolderEditComponent {
+	c := &HomePlaylistFolderEditComponent{
+		app:                app,
+		playlistFolderId:   playlistFolderId,
+		playlistFolderMeta: playlistFolderMeta.Copy(),
+	}
+
+	return c
+}
+
+func (c *HomePlaylistFolderEditComponent) Render() {
+	var excludedFolderId *restApiV1.PlaylistFolderId
+	if c.playlistFolderId != "" {
+		excludedFolderId = &c.playlistFolderId
+	}
+
+	playlistFolderEdit := struct {
+		Name              string
+		IsNewFolder       bool
+		ParentFolderItems []smartRulesOption
+	}{
+		Name:              c.playlistFolderMeta.Name,
+		IsNewFolder:       c.playlistFolderId == "",
+		ParentFolderItems: playlistFolderOptions(c.app, c.playlistFolderMeta.ParentFolderId, excludedFolderId),
+	}
+
+	div := jst.Id("homeMainModal")
+	div.Set("innerHTML", c.app.RenderTemplate(
+		playlistFolderEdit, "home/playlistFolderEdit/index"),
+	)
+
+	form := jst.Id("playlistFolderEditForm")
+	form.Call("addEventListener", "submit", c.app.AddEventFuncPreventDefault(c.saveAction))
+	cancelButton := jst.Id("playlistFolderEditCancelButton")
+	cancelButton.Call("addEventListener", "click", c.app.AddEventFunc(c.cancelAction))
+}
+
+func (c *HomePlaylistFolderEditComponent) saveAction() {
+	if c.closed {
+		return
+	}
+
+	c.app.ShowLoader("Updating playlist folder")
+
+	playlistFolderName := jst.Id("playlistFolderEditName")
+	c.playlistFolderMeta.Name = playlistFolderName.Get("value").String()
+	c.playlistFolderMeta.ParentFolderId = selectedPlaylistFolderId(jst.Id("playlistFolderEditParentFolder"))
+
+	if c.playlistFolderId != "" {
+		_, cliErr := c.app.restClient.UpdatePlaylistFolder(c.playlistFolderId, c.playlistFolderMeta)
+		if cliErr != nil {
+			c.app.HomeComponent.MessageComponent.ClientErrorMessage("Unable to update the playlist folder", cliErr)
+		}
+	} else {
+		_, cliErr := c.app.restClient.CreatePlaylistFolder(c.playlistFolderMeta)
+		if cliErr != nil {
+			c.app.HomeComponent.MessageComponent.ClientErrorMessage("Unable to create the playlist folder", cliErr)
+		}
+	}
+
+	c.close()
+	c.app.HomeComponent.Reload()
+	c.app.HideLoader()
+}
+
+func (c *HomePlaylistFolderEditComponent) cancelAction() {
+	if c.closed {
+		return
+	}
+	c.close()
+}
+
+func (c *HomePlaylistFolderEditComponent) close() {
+	c.closed = true
+	c.app.HomeComponent.CloseModal()
+}
+
+// playlistFolderOptions return the options of a playlist folder select, the first one being the root folder.
+// excludedFolderId and its sub folders are not listed
+func playlistFolderOptions(app *App, selectedFolderId *restApiV1.PlaylistFolderId, excludedFolderId *restApiV1.PlaylistFolderId) []smartRulesOption {
+	options := []smartRulesOption{{Label: "(Root)"}}
+	for _, playlistFolder := range app.localDb.OrderedPlaylistFolders {
+		if excludedFolderId != nil && app.localDb.IsPlaylistFolderInside(playlistFolder.Id, *excludedFolderId) {
+			continue
+		}
+		options = append(options, smartRulesOption{
+			Value:    string(playlistFolder.Id),
+			Label:    app.localDb.PlaylistFolderLabel(playlistFolder.Id),
+			Selected: selectedFolderId != nil && *selectedFolderId == playlistFolder.Id,
+		})
+	}
+	return options
+}
+
+// selectedPlaylistFolderId return the folder chosen in a playlist folder select, nil for the root folder
+func selectedPlaylistFolderId(folderSelect js.Value) *restApiV1.PlaylistFolderId {
+	value := folderSelect.Get("value").String()
+	if value == "" {
+		return nil
+	}
+	playlistFolderId := restApiV1.PlaylistFolderId(value)
+	return &playlistFolderId
+}
@@ -263,6 +263,17 @@ func (c *HomeSongEditComponent) saveAction() {
 		}
 	}
 
+	// DiscNumber
+	c.songMeta.DiscNumber = nil
+	discNumberStr := jst.Id("songEditDiscNumber").Get("value").String()
+	if discNumberStr != "" {
+
+		discNumber, err := strconv.ParseInt(discNumberStr, 10, 64)
+		if err == nil {
+			c.songMeta.DiscNumber = &discNumber
+		}
+	}
+
 	// TrackNumber
 	c.songMeta.TrackNumber = nil
 	trackNumberStr := jst.Id("songEditTrackNumber").Get("value").String()
@@ -39,13 +39,13 @@ func (c *HomeUploadSongComponent) Render() {
 	uploadSongFolder.Call("addEventListener", "change", c.app.AddEventFunc(func() {
 		files := uploadSongFolder.Get("files")
 
-		// Keep only flac and mp3 files
+		// Keep only flac, mp3, ogg, opus and m4a files
 		c.songFiles = nil
 		c.songFilesIdx = 0
 		for i := 0; i < files.Length(); i++ {
 			file := files.Index(i)
 			lowerName := strings.ToLower(file.Get("name").String())
-			if strings.HasSuffix(lowerName, ".mp3") || strings.HasSuffix(lowerName, ".flac") {
+			if strings.HasSuffix(lowerName, ".mp3") || strings.HasSuffix(lowerName, ".flac") || strings.HasSuffix(lowerName, ".ogg") || strings.HasSuffix(lowerName, ".oga") || strings.HasSuffix(lowerName, ".opus") || strings.HasSuffix(lowerName, ".m4a") {
 				c.songFiles = append(c.songFiles, file)
 			}
 		}
@@ -103,12 +103,20 @@ func (c *HomeUploadSongComponent) uploadSongAction(this js.Value, args []js.Valu
 	var songFormat restApiV1.SongFormat
 	if strings.HasSuffix(lowerName, ".flac") {
 		songFormat = restApiV1.SongFormatFlac
+	} else if strings.HasSuffix(lowerName, ".ogg") || strings.HasSuffix(lowerName, ".oga") {
+		songFormat = restApiV1.SongFormatOgg
+	} else if strings.HasSuffix(lowerName, ".opus") {
+		songFormat = restApiV1.SongFormatOpus
+	} else if strings.HasSuffix(lowerName, ".m4a") {
+		songFormat = restApiV1.SongFormatM4a
 	} else {
 		songFormat = restApiV1.SongFormatMp3
 	}
 
 	_, cliErr := c.app.restClient.CreateSongContent(songFormat, bytes.NewReader(content))
-	if cliErr != nil {
+	if cliErr != nil && cliErr.Code() == restApiV1.DuplicateSongErrorCode {
+		c.app.HomeComponent.MessageComponent.WarningMessage(fmt.Sprintf("Song %s already present", songFile.Get("name")))
+	} else if cliErr != nil {
 		c.app.HomeComponent.MessageComponent.ClientErrorMessage(fmt.Sprintf("Unable to upload song %s", songFile.Get("name")), cliErr)
 	}
 
@@ -9,6 +9,7 @@ type HomeUserEditComponent struct {
 	app              *App
 	userId           restApiV1.UserId
 	userMetaComplete *restApiV1.UserMetaComplete
+	scrobbling       *restApiV1.Scrobbling
 	closed           bool
 }
 
@@ -29,14 +30,33 @@ func NewHomeUserEditComponent(app *App, userId restApiV1.UserId, userMeta *restA
 func (c *HomeUserEditComponent) Render() {
 	div := jst.Id("homeMainModal")
 
+	// Scrobbling settings of existing users
+	if c.userId != "" {
+		scrobbling, cliErr := c.app.restClient.ReadScrobbling(c.userId)
+		if cliErr != nil && cliErr.Code() != restApiV1.NotFoundErrorCode {
+			c.app.HomeComponent.MessageComponent.ClientErrorMessage("Unable to retrieve scrobbling settings", cliErr)
+		}
+		c.scrobbling = scrobbling
+	}
+	scrobblingMeta := &restApiV1.ScrobblingMeta{}
+	if c.scrobbling != nil {
+		scrobblingMeta = &c.scrobbling.ScrobblingMeta
+	}
+
 	userItem := struct {
 		*restApiV1.UserMetaComplete
-		IsNewUser            bool
-		IsConnectedUserAdmin bool
+		IsNewUser              bool
+		IsConnectedUserAdmin   bool
+		Scrobbling             *restApiV1.Scrobbling
+		ScrobblingMeta         *restApiV1.ScrobblingMeta
+		ScrobblingServiceTypes []restApiV1.ScrobblingServiceType
 	}{
-		UserMetaComplete:     c.userMetaComplete,
-		IsNewUser:            c.userId == "",
-		IsConnectedUserAdmin: c.app.IsConnectedUserAdmin(),
+		UserMetaComplete:       c.userMetaComplete,
+		IsNewUser:              c.userId == "",
+		IsConnectedUserAdmin:   c.app.IsConnectedUserAdmin(),
+		Scrobbling:             c.scrobbling,
+		ScrobblingMeta:         scrobblingMeta,
+		ScrobblingServiceTypes: restApiV1.ScrobblingServiceTypes,
 	}
 	div.Set("innerHTML", c.app.RenderTemplate(
 		&userItem, "home/userEdit/index"),
@@ -84,6 +104,8 @@ func (c *HomeUserEditComponent) saveAction() {
 		_, cliErr := c.app.restClient.UpdateUser(c.userId, c.userMetaComplete)
 		if cliErr != nil {
 			c.app.HomeComponent.MessageComponent.ClientErrorMessage("Unable to update the user", cliErr)
+		} else {
+			c.saveScrobbling()
 		}
 
 		// Update username/password stored on self edit
@@ -105,6 +127,31 @@ func (c *HomeUserEditComponent) saveAction() {
 	c.app.HideLoader()
 }
 
+// saveScrobbling update or remove the scrobbling settings of the edited user
+func (c *HomeUserEditComponent) saveScrobbling() {
+	serviceType := restApiV1.ScrobblingServiceType(jst.Id("userEditScrobblingServiceType").Get("value").String())
+	if serviceType == "" {
+		if c.scrobbling != nil {
+			_, cliErr := c.app.restClient.DeleteScrobbling(c.userId)
+			if cliErr != nil {
+				c.app.HomeComponent.MessageComponent.ClientErrorMessage("Unable to remove scrobbling settings", cliErr)
+			}
+		}
+		return
+	}
+
+	_, cliErr := c.app.restClient.UpdateScrobbling(c.userId, &restApiV1.ScrobblingMeta{
+		ServiceType: serviceType,
+		ApiRootUrl:  jst.Id("userEditScrobblingApiRootUrl").Get("value").String(),
+		Token:       jst.Id("userEditScrobblingToken").Get("value").String(),
+		ApiKey:      jst.Id("userEditScrobblingApiKey").Get("value").String(),
+		ApiSecret:   jst.Id("userEditScrobblingApiSecret").Get("value").String(),
+	})
+	if cliErr != nil {
+		c.app.HomeComponent.MessageComponent.ClientErrorMessage("Unable to update scrobbling settings", cliErr)
+	}
+}
+
 func (c *HomeUserEditComponent) cancelAction() {
 	if c.closed {
 		return
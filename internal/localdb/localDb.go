@@ -5,6 +5,7 @@ import (
 	"github.com/jypelle/mifasol/restClientV1"
 	"golang.org/x/text/collate"
 	"sort"
+	"strings"
 )
 
 type LocalDb struct {
@@ -15,17 +16,22 @@ type LocalDb struct {
 
 	Albums                  map[restApiV1.AlbumId]*restApiV1.Album
 	Artists                 map[restApiV1.ArtistId]*restApiV1.Artist
+	Genres                  map[restApiV1.GenreId]*restApiV1.Genre
 	Playlists               map[restApiV1.PlaylistId]*restApiV1.Playlist
+	PlaylistFolders         map[restApiV1.PlaylistFolderId]*restApiV1.PlaylistFolder
 	Songs                   map[restApiV1.SongId]*restApiV1.Song
 	Users                   map[restApiV1.UserId]*restApiV1.User
 	UserFavoritePlaylistIds map[restApiV1.UserId]map[restApiV1.PlaylistId]struct{}
 	UserFavoriteSongIds     map[restApiV1.UserId]map[restApiV1.SongId]struct{}
+	UserSongRatings         map[restApiV1.UserId]map[restApiV1.SongId]int64
 
-	OrderedAlbums    []*restApiV1.Album
-	OrderedArtists   []*restApiV1.Artist
-	OrderedPlaylists []*restApiV1.Playlist
-	OrderedSongs     []*restApiV1.Song
-	OrderedUsers     []*restApiV1.User
+	OrderedAlbums          []*restApiV1.Album
+	OrderedArtists         []*restApiV1.Artist
+	OrderedGenres          []*restApiV1.Genre
+	OrderedPlaylists       []*restApiV1.Playlist
+	OrderedPlaylistFolders []*restApiV1.PlaylistFolder // Sorted by folder path
+	OrderedSongs           []*restApiV1.Song
+	OrderedUsers           []*restApiV1.User
 
 	UserOrderedFavoriteArtists   map[restApiV1.UserId][]*restApiV1.Artist
 	UserOrderedFavoriteAlbums    map[restApiV1.UserId][]*restApiV1.Album
@@ -37,6 +43,16 @@ type LocalDb struct {
 
 	ArtistOrderedSongs map[restApiV1.ArtistId][]*restApiV1.Song
 	UnknownArtistSongs []*restApiV1.Song
+
+	OrderedComposers     []*restApiV1.Artist
+	ComposerOrderedSongs map[restApiV1.ArtistId][]*restApiV1.Song
+
+	GenreOrderedSongs map[restApiV1.GenreId][]*restApiV1.Song
+	UnknownGenreSongs []*restApiV1.Song
+
+	// Content of each playlist folder, the root folder having an empty id
+	FolderOrderedPlaylistFolders map[restApiV1.PlaylistFolderId][]*restApiV1.PlaylistFolder
+	FolderOrderedPlaylists       map[restApiV1.PlaylistFolderId][]*restApiV1.Playlist
 }
 
 func NewLocalDb(restClient *restClientV1.RestClient, collator *collate.Collator) *LocalDb {
@@ -57,6 +73,105 @@ func (l *LocalDb) IsPlaylistOwnedBy(playlistId restApiV1.PlaylistId, userId rest
 	return false
 }
 
+// PlaylistFolderPath return the names of a folder and of its parent folders, from the root folder
+func (l *LocalDb) PlaylistFolderPath(playlistFolderId restApiV1.PlaylistFolderId) []string {
+	var names []string
+	visitedFolderIds := make(map[restApiV1.PlaylistFolderId]struct{})
+	for {
+		playlistFolder, ok := l.PlaylistFolders[playlistFolderId]
+		if !ok {
+			break
+		}
+		if _, ok := visitedFolderIds[playlistFolderId]; ok {
+			break
+		}
+		visitedFolderIds[playlistFolderId] = struct{}{}
+		names = append([]string{playlistFolder.Name}, names...)
+		if playlistFolder.ParentFolderId == nil {
+			break
+		}
+		playlistFolderId = *playlistFolder.ParentFolderId
+	}
+	return names
+}
+
+// PlaylistFolderLabel return the path of a folder as displayed by the clients
+func (l *LocalDb) PlaylistFolderLabel(playlistFolderId restApiV1.PlaylistFolderId) string {
+	return strings.Join(l.PlaylistFolderPath(playlistFolderId), " / ")
+}
+
+// IsPlaylistFolderInside check if a folder is the folder ancestorFolderId or one of its sub folders
+func (l *LocalDb) IsPlaylistFolderInside(playlistFolderId restApiV1.PlaylistFolderId, ancestorFolderId restApiV1.PlaylistFolderId) bool {
+	visitedFolderIds := make(map[restApiV1.PlaylistFolderId]struct{})
+	for {
+		if playlistFolderId == ancestorFolderId {
+			return true
+		}
+		playlistFolder, ok := l.PlaylistFolders[playlistFolderId]
+		if !ok || playlistFolder.ParentFolderId == nil {
+			return false
+		}
+		if _, ok := visitedFolderIds[playlistFolderId]; ok {
+			return false
+		}
+		visitedFolderIds[playlistFolderId] = struct{}{}
+		playlistFolderId = *playlistFolder.ParentFolderId
+	}
+}
+
+// playlistFolderKey return the folder a playlist or a folder belongs to, unknown folders being replaced by the root folder
+func (l *LocalDb) playlistFolderKey(playlistFolderId *restApiV1.PlaylistFolderId) restApiV1.PlaylistFolderId {
+	if playlistFolderId != nil {
+		if _, ok := l.PlaylistFolders[*playlistFolderId]; ok {
+			return *playlistFolderId
+		}
+	}
+	return ""
+}
+
+// AlbumDuration return the total running time of an album in milliseconds
+func (l *LocalDb) AlbumDuration(albumId restApiV1.AlbumId) int64 {
+	var songs []*restApiV1.Song
+	if albumId != restApiV1.UnknownAlbumId {
+		songs = l.AlbumOrderedSongs[albumId]
+	} else {
+		songs = l.UnknownAlbumSongs
+	}
+
+	var duration int64
+	for _, song := range songs {
+		duration += song.Duration
+	}
+	return duration
+}
+
+// PlaylistDuration return the total running time of a playlist in milliseconds
+func (l *LocalDb) PlaylistDuration(playlistId restApiV1.PlaylistId) int64 {
+	var duration int64
+	if playlist, ok := l.Playlists[playlistId]; ok {
+		for _, songId := range playlist.SongIds {
+			if song, ok := l.Songs[songId]; ok {
+				duration += song.Duration
+			}
+		}
+	}
+	return duration
+}
+
+// MainArtistSongs return the songs of an artist where he is a main artist
+func (l *LocalDb) MainArtistSongs(artistId restApiV1.ArtistId) []*restApiV1.Song {
+	var songs []*restApiV1.Song
+	for _, song := range l.ArtistOrderedSongs[artistId] {
+		for _, songArtistId := range song.ArtistIds {
+			if songArtistId == artistId {
+				songs = append(songs, song)
+				break
+			}
+		}
+	}
+	return songs
+}
+
 func (l *LocalDb) AddSongToMyFavorite(songId restApiV1.SongId) {
 	l.UserFavoriteSongIds[l.restClient.UserId()][songId] = struct{}{}
 	l.refreshUserOrderedFavoriteSongs(l.restClient.UserId())
@@ -67,6 +182,15 @@ func (l *LocalDb) RemoveSongFromMyFavorite(songId restApiV1.SongId) {
 	l.refreshUserOrderedFavoriteSongs(l.restClient.UserId())
 }
 
+// SetMySongRating change the stars given to a song by the connected user, 0 to remove the rating
+func (l *LocalDb) SetMySongRating(songId restApiV1.SongId, rating int64) {
+	if rating == 0 {
+		delete(l.UserSongRatings[l.restClient.UserId()], songId)
+	} else {
+		l.UserSongRatings[l.restClient.UserId()][songId] = rating
+	}
+}
+
 func (l *LocalDb) AddPlaylistToMyFavorite(playlistId restApiV1.PlaylistId) {
 	l.UserFavoritePlaylistIds[l.restClient.UserId()][playlistId] = struct{}{}
 	l.refreshUserOrderedFavoritePlaylists(l.restClient.UserId())
@@ -90,10 +214,13 @@ func (l *LocalDb) Refresh() restClientV1.ClientError {
 		l.Songs = make(map[restApiV1.SongId]*restApiV1.Song, len(syncReport.Songs))
 		l.Albums = make(map[restApiV1.AlbumId]*restApiV1.Album, len(syncReport.Albums))
 		l.Artists = make(map[restApiV1.ArtistId]*restApiV1.Artist, len(syncReport.Artists))
+		l.Genres = make(map[restApiV1.GenreId]*restApiV1.Genre, len(syncReport.Genres))
 		l.Playlists = make(map[restApiV1.PlaylistId]*restApiV1.Playlist, len(syncReport.Playlists))
+		l.PlaylistFolders = make(map[restApiV1.PlaylistFolderId]*restApiV1.PlaylistFolder, len(syncReport.PlaylistFolders))
 		l.Users = make(map[restApiV1.UserId]*restApiV1.User, len(syncReport.Users))
 		l.UserFavoritePlaylistIds = make(map[restApiV1.UserId]map[restApiV1.PlaylistId]struct{}, len(syncReport.Users))
 		l.UserFavoriteSongIds = make(map[restApiV1.UserId]map[restApiV1.SongId]struct{}, len(syncReport.Users))
+		l.UserSongRatings = make(map[restApiV1.UserId]map[restApiV1.SongId]int64, len(syncReport.Users))
 	} else {
 		// Remove deleted items
 		for _, songId := range syncReport.DeletedSongIds {
@@ -105,13 +232,20 @@ func (l *LocalDb) Refresh() restClientV1.ClientError {
 		for _, artistId := range syncReport.DeletedArtistIds {
 			delete(l.Artists, artistId)
 		}
+		for _, genreId := range syncReport.DeletedGenreIds {
+			delete(l.Genres, genreId)
+		}
 		for _, playlistId := range syncReport.DeletedPlaylistIds {
 			delete(l.Playlists, playlistId)
 		}
+		for _, playlistFolderId := range syncReport.DeletedPlaylistFolderIds {
+			delete(l.PlaylistFolders, playlistFolderId)
+		}
 		for _, userId := range syncReport.DeletedUserIds {
 			delete(l.Users, userId)
 			delete(l.UserFavoritePlaylistIds, userId)
 			delete(l.UserFavoriteSongIds, userId)
+			delete(l.UserSongRatings, userId)
 		}
 		for _, favoritePlaylistId := range syncReport.DeletedFavoritePlaylistIds {
 			if favoritePlaylistIds, ok := l.UserFavoritePlaylistIds[favoritePlaylistId.UserId]; ok {
@@ -123,6 +257,11 @@ func (l *LocalDb) Refresh() restClientV1.ClientError {
 				delete(favoriteSongIds, favoriteSongId.SongId)
 			}
 		}
+		for _, songRatingId := range syncReport.DeletedSongRatingIds {
+			if songRatings, ok := l.UserSongRatings[songRatingId.UserId]; ok {
+				delete(songRatings, songRatingId.SongId)
+			}
+		}
 	}
 
 	// Create in-memory indexes
@@ -145,12 +284,24 @@ func (l *LocalDb) Refresh() restClientV1.ClientError {
 		l.Artists[artist.Id] = artist
 	}
 
+	// Indexing genres
+	for idx := range syncReport.Genres {
+		genre := &syncReport.Genres[idx]
+		l.Genres[genre.Id] = genre
+	}
+
 	// Indexing playlists
 	for idx := range syncReport.Playlists {
 		playlist := &syncReport.Playlists[idx]
 		l.Playlists[playlist.Id] = playlist
 	}
 
+	// Indexing playlist folders
+	for idx := range syncReport.PlaylistFolders {
+		playlistFolder := &syncReport.PlaylistFolders[idx]
+		l.PlaylistFolders[playlistFolder.Id] = playlistFolder
+	}
+
 	// Indexing users
 	for idx := range syncReport.Users {
 		user := &syncReport.Users[idx]
@@ -161,6 +312,9 @@ func (l *LocalDb) Refresh() restClientV1.ClientError {
 		if _, ok := l.UserFavoriteSongIds[user.Id]; !ok {
 			l.UserFavoriteSongIds[user.Id] = make(map[restApiV1.SongId]struct{}, 2)
 		}
+		if _, ok := l.UserSongRatings[user.Id]; !ok {
+			l.UserSongRatings[user.Id] = make(map[restApiV1.SongId]int64, 2)
+		}
 	}
 
 	// Indexing favorite playlists
@@ -175,6 +329,12 @@ func (l *LocalDb) Refresh() restClientV1.ClientError {
 		l.UserFavoriteSongIds[favoriteSong.Id.UserId][favoriteSong.Id.SongId] = struct{}{}
 	}
 
+	// Indexing song ratings
+	for idx := range syncReport.SongRatings {
+		songRating := &syncReport.SongRatings[idx]
+		l.UserSongRatings[songRating.Id.UserId][songRating.Id.SongId] = songRating.Rating
+	}
+
 	// OrderedSongs
 	l.OrderedSongs = make([]*restApiV1.Song, 0, len(l.Songs))
 	for _, song := range l.Songs {
@@ -205,9 +365,18 @@ func (l *LocalDb) Refresh() restClientV1.ClientError {
 
 	l.sortArtistList(l.OrderedArtists)
 
-	// AlbumOrderedSongs & ArtistOrderedSongs
+	// OrderedGenres
+	l.OrderedGenres = make([]*restApiV1.Genre, 1, len(l.Genres)+1)
+	for _, genre := range l.Genres {
+		l.OrderedGenres = append(l.OrderedGenres, genre)
+	}
+
+	l.sortGenreList(l.OrderedGenres)
+
+	// AlbumOrderedSongs, ArtistOrderedSongs & ComposerOrderedSongs
 	l.AlbumOrderedSongs = make(map[restApiV1.AlbumId][]*restApiV1.Song, len(l.OrderedAlbums))
 	l.ArtistOrderedSongs = make(map[restApiV1.ArtistId][]*restApiV1.Song, len(l.OrderedArtists))
+	l.ComposerOrderedSongs = make(map[restApiV1.ArtistId][]*restApiV1.Song)
 	l.UnknownAlbumSongs = nil
 	l.UnknownArtistSongs = nil
 
@@ -217,16 +386,31 @@ func (l *LocalDb) Refresh() restClientV1.ClientError {
 		} else {
 			l.UnknownAlbumSongs = append(l.UnknownAlbumSongs, song)
 		}
+		songArtistIds := make(map[restApiV1.ArtistId]struct{}, len(song.ArtistIds)+len(song.Artists))
 		if len(song.ArtistIds) > 0 {
 			for _, artistId := range song.ArtistIds {
 				l.ArtistOrderedSongs[artistId] = append(l.ArtistOrderedSongs[artistId], song)
+				songArtistIds[artistId] = struct{}{}
 			}
 		} else {
 			l.UnknownArtistSongs = append(l.UnknownArtistSongs, song)
 		}
+		// Songs are also listed under their other credited artists
+		for _, songArtist := range song.Artists {
+			if songArtist.Role == restApiV1.ArtistRoleComposer {
+				l.ComposerOrderedSongs[songArtist.ArtistId] = append(l.ComposerOrderedSongs[songArtist.ArtistId], song)
+			}
+			if _, ok := songArtistIds[songArtist.ArtistId]; !ok {
+				l.ArtistOrderedSongs[songArtist.ArtistId] = append(l.ArtistOrderedSongs[songArtist.ArtistId], song)
+				songArtistIds[songArtist.ArtistId] = struct{}{}
+			}
+		}
 	}
 	for _, songs := range l.AlbumOrderedSongs {
 		sort.Slice(songs, func(i, j int) bool {
+			if discNumberOrFirst(songs[i]) != discNumberOrFirst(songs[j]) {
+				return discNumberOrFirst(songs[i]) < discNumberOrFirst(songs[j])
+			}
 			if songs[i].TrackNumber != nil {
 				if songs[j].TrackNumber != nil {
 					if *songs[i].TrackNumber != *songs[j].TrackNumber {
@@ -259,42 +443,10 @@ func (l *LocalDb) Refresh() restClientV1.ClientError {
 	})
 
 	for _, songs := range l.ArtistOrderedSongs {
-		sort.Slice(songs, func(i, j int) bool {
-			if songs[i].AlbumId != restApiV1.UnknownAlbumId {
-				if songs[j].AlbumId != restApiV1.UnknownAlbumId {
-					if songs[i].AlbumId != songs[j].AlbumId {
-						return l.collator.CompareString(l.Albums[songs[i].AlbumId].Name, l.Albums[songs[j].AlbumId].Name) == -1
-					} else {
-						if songs[i].TrackNumber != nil {
-							if songs[j].TrackNumber != nil {
-								if *songs[i].TrackNumber != *songs[j].TrackNumber {
-									return *songs[i].TrackNumber < *songs[j].TrackNumber
-								}
-							} else {
-								return true
-							}
-						} else {
-							if songs[j].TrackNumber != nil {
-								return false
-							}
-						}
-					}
-				} else {
-					return false
-				}
-			} else {
-				if songs[j].AlbumId != restApiV1.UnknownAlbumId {
-					return true
-				}
-			}
-
-			songNameCompare := l.collator.CompareString(songs[i].Name, songs[j].Name)
-			if songNameCompare != 0 {
-				return songNameCompare == -1
-			} else {
-				return songs[i].CreationTs < songs[j].CreationTs
-			}
-		})
+		l.sortArtistSongList(songs)
+	}
+	for _, songs := range l.ComposerOrderedSongs {
+		l.sortArtistSongList(songs)
 	}
 	sort.Slice(l.UnknownArtistSongs, func(i, j int) bool {
 		songNameCompare := l.collator.CompareString(l.UnknownArtistSongs[i].Name, l.UnknownArtistSongs[j].Name)
@@ -305,6 +457,28 @@ func (l *LocalDb) Refresh() restClientV1.ClientError {
 		}
 	})
 
+	// OrderedComposers
+	l.OrderedComposers = make([]*restApiV1.Artist, 0, len(l.ComposerOrderedSongs))
+	for artistId := range l.ComposerOrderedSongs {
+		l.OrderedComposers = append(l.OrderedComposers, l.Artists[artistId])
+	}
+
+	l.sortArtistList(l.OrderedComposers)
+
+	// GenreOrderedSongs
+	l.GenreOrderedSongs = make(map[restApiV1.GenreId][]*restApiV1.Song, len(l.OrderedGenres))
+	l.UnknownGenreSongs = nil
+
+	for _, song := range l.OrderedSongs {
+		if len(song.GenreIds) > 0 {
+			for _, genreId := range song.GenreIds {
+				l.GenreOrderedSongs[genreId] = append(l.GenreOrderedSongs[genreId], song)
+			}
+		} else {
+			l.UnknownGenreSongs = append(l.UnknownGenreSongs, song)
+		}
+	}
+
 	// OrderedPlaylists
 	l.OrderedPlaylists = make([]*restApiV1.Playlist, 0, len(l.Playlists))
 	for _, playlist := range l.Playlists {
@@ -319,6 +493,34 @@ func (l *LocalDb) Refresh() restClientV1.ClientError {
 		}
 	})
 
+	// OrderedPlaylistFolders
+	playlistFolderLabels := make(map[restApiV1.PlaylistFolderId]string, len(l.PlaylistFolders))
+	l.OrderedPlaylistFolders = make([]*restApiV1.PlaylistFolder, 0, len(l.PlaylistFolders))
+	for _, playlistFolder := range l.PlaylistFolders {
+		playlistFolderLabels[playlistFolder.Id] = l.PlaylistFolderLabel(playlistFolder.Id)
+		l.OrderedPlaylistFolders = append(l.OrderedPlaylistFolders, playlistFolder)
+	}
+	sort.Slice(l.OrderedPlaylistFolders, func(i, j int) bool {
+		playlistFolderLabelCompare := l.collator.CompareString(playlistFolderLabels[l.OrderedPlaylistFolders[i].Id], playlistFolderLabels[l.OrderedPlaylistFolders[j].Id])
+		if playlistFolderLabelCompare != 0 {
+			return playlistFolderLabelCompare == -1
+		} else {
+			return l.OrderedPlaylistFolders[i].CreationTs < l.OrderedPlaylistFolders[j].CreationTs
+		}
+	})
+
+	// FolderOrderedPlaylistFolders & FolderOrderedPlaylists
+	l.FolderOrderedPlaylistFolders = make(map[restApiV1.PlaylistFolderId][]*restApiV1.PlaylistFolder)
+	for _, playlistFolder := range l.OrderedPlaylistFolders {
+		parentFolderId := l.playlistFolderKey(playlistFolder.ParentFolderId)
+		l.FolderOrderedPlaylistFolders[parentFolderId] = append(l.FolderOrderedPlaylistFolders[parentFolderId], playlistFolder)
+	}
+	l.FolderOrderedPlaylists = make(map[restApiV1.PlaylistFolderId][]*restApiV1.Playlist)
+	for _, playlist := range l.OrderedPlaylists {
+		folderId := l.playlistFolderKey(playlist.FolderId)
+		l.FolderOrderedPlaylists[folderId] = append(l.FolderOrderedPlaylists[folderId], playlist)
+	}
+
 	// OrderedUsers
 	l.OrderedUsers = make([]*restApiV1.User, 0, len(l.Users))
 	for _, user := range l.Users {
@@ -435,7 +637,7 @@ func (l *LocalDb) sortArtistList(artistList []*restApiV1.Artist) {
 		if artistList[j] == nil {
 			return false
 		}
-		artistNameCompare := l.collator.CompareString(artistList[i].Name, artistList[j].Name)
+		artistNameCompare := l.collator.CompareString(artistList[i].GetSortName(), artistList[j].GetSortName())
 		if artistNameCompare != 0 {
 			return artistNameCompare == -1
 		} else {
@@ -445,6 +647,66 @@ func (l *LocalDb) sortArtistList(artistList []*restApiV1.Artist) {
 
 }
 
+// sortArtistSongList sort the songs of an artist by album, disc & track number then name
+func (l *LocalDb) sortArtistSongList(songs []*restApiV1.Song) {
+	sort.Slice(songs, func(i, j int) bool {
+		if songs[i].AlbumId != restApiV1.UnknownAlbumId {
+			if songs[j].AlbumId != restApiV1.UnknownAlbumId {
+				if songs[i].AlbumId != songs[j].AlbumId {
+					return l.collator.CompareString(l.Albums[songs[i].AlbumId].Name, l.Albums[songs[j].AlbumId].Name) == -1
+				} else {
+					if discNumberOrFirst(songs[i]) != discNumberOrFirst(songs[j]) {
+						return discNumberOrFirst(songs[i]) < discNumberOrFirst(songs[j])
+					}
+					if songs[i].TrackNumber != nil {
+						if songs[j].TrackNumber != nil {
+							if *songs[i].TrackNumber != *songs[j].TrackNumber {
+								return *songs[i].TrackNumber < *songs[j].TrackNumber
+							}
+						} else {
+							return true
+						}
+					} else {
+						if songs[j].TrackNumber != nil {
+							return false
+						}
+					}
+				}
+			} else {
+				return false
+			}
+		} else {
+			if songs[j].AlbumId != restApiV1.UnknownAlbumId {
+				return true
+			}
+		}
+
+		songNameCompare := l.collator.CompareString(songs[i].Name, songs[j].Name)
+		if songNameCompare != 0 {
+			return songNameCompare == -1
+		} else {
+			return songs[i].CreationTs < songs[j].CreationTs
+		}
+	})
+}
+
+func (l *LocalDb) sortGenreList(genreList []*restApiV1.Genre) {
+	sort.Slice(genreList, func(i, j int) bool {
+		if genreList[i] == nil {
+			return true
+		}
+		if genreList[j] == nil {
+			return false
+		}
+		genreNameCompare := l.collator.CompareString(genreList[i].Name, genreList[j].Name)
+		if genreNameCompare != 0 {
+			return genreNameCompare == -1
+		} else {
+			return genreList[i].CreationTs < genreList[j].CreationTs
+		}
+	})
+}
+
 func (l *LocalDb) sortAlbumList(albumList []*restApiV1.Album) {
 	sort.Slice(albumList, func(i, j int) bool {
 		if albumList[i] == nil {
@@ -461,3 +723,11 @@ func (l *LocalDb) sortAlbumList(albumList []*restApiV1.Album) {
 		}
 	})
 }
+
+// discNumberOrFirst return the disc number of a song, songs without disc number being considered on the first disc
+func discNumberOrFirst(song *restApiV1.Song) int64 {
+	if song.DiscNumber != nil {
+		return *song.DiscNumber
+	}
+	return 1
+}
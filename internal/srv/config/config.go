@@ -2,9 +2,11 @@ package config
 
 import (
 	"encoding/json"
+	"github.com/jypelle/mifasol/restApiV1"
 	"github.com/sirupsen/logrus"
 	"io/ioutil"
 	"path/filepath"
+	"regexp"
 )
 
 const configFilename = "config.json"
@@ -15,6 +17,8 @@ const configDataDirName = "data"
 const configSongsDirName = "songs"
 const configAlbumsDirName = "albums"
 const configAuthorsDirName = "authors"
+const configUploadsDirName = "uploads"
+const configTranscodingsDirName = "transcodings"
 
 const configKeyFilename = "key.pem"
 const configCertFilename = "cert.pem"
@@ -31,10 +35,36 @@ type ServerConfig struct {
 }
 
 type ServerEditableConfig struct {
-	Hostnames []string `json:"hostnames"`
-	Port      int64    `json:"port"`
-	Ssl       bool     `json:"ssl"`
-	Timeout   int64    `json:"timeout"`
+	Hostnames           []string             `json:"hostnames"`
+	Port                int64                `json:"port"`
+	Ssl                 bool                 `json:"ssl"`
+	Timeout             int64                `json:"timeout"`
+	TranscodingProfiles []TranscodingProfile `json:"transcodingProfiles"`
+}
+
+// TranscodingProfile define an external encoder command line used to stream songs in another format.
+// In the command, {input} is replaced by the song file name and {output} by the transcoded file name,
+// without {output} the encoder should write the transcoded content to its standard output
+type TranscodingProfile struct {
+	Name    string   `json:"name"`
+	Format  string   `json:"format"`
+	Command []string `json:"command"`
+}
+
+// transcodingProfileNameRegexp match the allowed profile names, used as folder names for the transcoding cache
+var transcodingProfileNameRegexp = regexp.MustCompile(`^[A-Za-z0-9._-]+$`)
+
+var defaultTranscodingProfiles = []TranscodingProfile{
+	{
+		Name:    "mp3-192",
+		Format:  restApiV1.SongFormatMp3.String(),
+		Command: []string{"ffmpeg", "-v", "error", "-y", "-i", "{input}", "-map", "0:a:0", "-c:a", "libmp3lame", "-b:a", "192k", "-f", "mp3", "{output}"},
+	},
+	{
+		Name:    "opus-96",
+		Format:  restApiV1.SongFormatOpus.String(),
+		Command: []string{"ffmpeg", "-v", "error", "-y", "-i", "{input}", "-map", "0:a:0", "-c:a", "libopus", "-b:a", "96k", "-f", "opus", "{output}"},
+	},
 }
 
 func (sc ServerConfig) GetCompleteConfigFilename() string {
@@ -65,6 +95,14 @@ func (sc ServerConfig) GetCompleteConfigAuthorsDirName() string {
 	return filepath.Join(sc.ConfigDir, configDataDirName, configAuthorsDirName)
 }
 
+func (sc ServerConfig) GetCompleteConfigUploadsDirName() string {
+	return filepath.Join(sc.ConfigDir, configDataDirName, configUploadsDirName)
+}
+
+func (sc ServerConfig) GetCompleteConfigTranscodingsDirName() string {
+	return filepath.Join(sc.ConfigDir, configDataDirName, configTranscodingsDirName)
+}
+
 func (sc ServerConfig) GetCompleteConfigKeyFilename() string {
 	return filepath.Join(sc.ConfigDir, configKeyFilename)
 }
@@ -82,6 +120,8 @@ func NewServerEditableConfig(draftServerEditableConfig *ServerEditableConfig) *S
 			Port:      DefaultPort,
 			Ssl:       DefaultSsl,
 			Timeout:   DefaultTimeout,
+
+			TranscodingProfiles: defaultTranscodingProfiles,
 		}
 	} else {
 		serverEditableConfig = *draftServerEditableConfig
@@ -93,11 +133,48 @@ func NewServerEditableConfig(draftServerEditableConfig *ServerEditableConfig) *S
 			serverEditableConfig.Timeout = 3600
 		}
 
+		if serverEditableConfig.TranscodingProfiles == nil {
+			serverEditableConfig.TranscodingProfiles = defaultTranscodingProfiles
+		} else {
+			serverEditableConfig.TranscodingProfiles = checkTranscodingProfiles(serverEditableConfig.TranscodingProfiles)
+		}
 	}
 
 	return &serverEditableConfig
 }
 
+// checkTranscodingProfiles drop invalid transcoding profiles
+func checkTranscodingProfiles(transcodingProfiles []TranscodingProfile) []TranscodingProfile {
+	checkedTranscodingProfiles := []TranscodingProfile{}
+	names := make(map[string]bool)
+	for _, transcodingProfile := range transcodingProfiles {
+		switch {
+		case !transcodingProfileNameRegexp.MatchString(transcodingProfile.Name):
+			logrus.Warnf("Ignoring transcoding profile with invalid name: %s", transcodingProfile.Name)
+		case names[transcodingProfile.Name]:
+			logrus.Warnf("Ignoring duplicate transcoding profile: %s", transcodingProfile.Name)
+		case restApiV1.SongFormatFromString(transcodingProfile.Format) == restApiV1.SongFormatUnknown:
+			logrus.Warnf("Ignoring transcoding profile %s with unknown format: %s", transcodingProfile.Name, transcodingProfile.Format)
+		case len(transcodingProfile.Command) == 0:
+			logrus.Warnf("Ignoring transcoding profile %s without command", transcodingProfile.Name)
+		default:
+			names[transcodingProfile.Name] = true
+			checkedTranscodingProfiles = append(checkedTranscodingProfiles, transcodingProfile)
+		}
+	}
+	return checkedTranscodingProfiles
+}
+
+// GetTranscodingProfile return the transcoding profile with the given name
+func (sc ServerConfig) GetTranscodingProfile(name string) (*TranscodingProfile, bool) {
+	for ind := range sc.TranscodingProfiles {
+		if sc.TranscodingProfiles[ind].Name == name {
+			return &sc.TranscodingProfiles[ind], true
+		}
+	}
+	return nil, false
+}
+
 func (sc *ServerConfig) Save() {
 	logrus.Debugf("Save config file: %s", sc.GetCompleteConfigFilename())
 	rawConfig, err := json.MarshalIndent(sc.ServerEditableConfig, "", "\t")
@@ -1,6 +1,9 @@
 package entity
 
-import "github.com/jypelle/mifasol/restApiV1"
+import (
+	"database/sql"
+	"github.com/jypelle/mifasol/restApiV1"
+)
 
 // Album
 
@@ -9,6 +12,9 @@ type AlbumEntity struct {
 	CreationTs int64             `db:"creation_ts"`
 	UpdateTs   int64             `db:"update_ts"`
 	Name       string            `db:"name"`
+	CoverFg    bool              `db:"cover_fg"`
+	Loudness   sql.NullFloat64   `db:"loudness"`
+	TruePeak   sql.NullFloat64   `db:"true_peak"`
 }
 
 func (e *AlbumEntity) Fill(s *restApiV1.Album) {
@@ -16,6 +22,17 @@ func (e *AlbumEntity) Fill(s *restApiV1.Album) {
 	s.CreationTs = e.CreationTs
 	s.UpdateTs = e.UpdateTs
 	s.Name = e.Name
+	s.CoverFg = e.CoverFg
+	if e.Loudness.Valid {
+		s.Loudness = &e.Loudness.Float64
+	} else {
+		s.Loudness = nil
+	}
+	if e.TruePeak.Valid {
+		s.TruePeak = &e.TruePeak.Float64
+	} else {
+		s.TruePeak = nil
+	}
 }
 
 func (e *AlbumEntity) LoadMeta(s *restApiV1.AlbumMeta) {
@@ -24,6 +41,11 @@ func (e *AlbumEntity) LoadMeta(s *restApiV1.AlbumMeta) {
 	}
 }
 
+type AlbumArtistEntity struct {
+	AlbumId  restApiV1.AlbumId  `db:"album_id"`
+	ArtistId restApiV1.ArtistId `db:"artist_id"`
+}
+
 type DeletedAlbumEntity struct {
 	AlbumId  restApiV1.AlbumId `db:"album_id"`
 	DeleteTs int64             `db:"delete_ts"`
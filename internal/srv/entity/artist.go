@@ -9,24 +9,33 @@ type ArtistEntity struct {
 	CreationTs int64              `db:"creation_ts" json:"creation_ts"`
 	UpdateTs   int64              `db:"update_ts" json:"update_ts"`
 	Name       string             `db:"name" json:"name"`
+	SortName   string             `db:"sort_name" json:"sort_name"`
+	Biography  string             `db:"biography" json:"biography"`
+	PictureFg  bool               `db:"picture_fg" json:"picture_fg"`
 }
 
 func (e *ArtistEntity) Fill(s *restApiV1.Artist) {
 	s.Id = e.ArtistId
 	s.CreationTs = e.CreationTs
 	s.UpdateTs = e.UpdateTs
+	s.PictureFg = e.PictureFg
 	s.Name = e.Name
+	s.SortName = e.SortName
+	s.Biography = e.Biography
 }
 
 func (e *ArtistEntity) LoadMeta(s *restApiV1.ArtistMeta) {
 	if s != nil {
 		e.Name = s.Name
+		e.SortName = s.SortName
+		e.Biography = s.Biography
 	}
 }
 
 type ArtistSongEntity struct {
-	ArtistId restApiV1.ArtistId `db:"artist_id"`
-	SongId   restApiV1.SongId   `db:"song_id"`
+	ArtistId restApiV1.ArtistId   `db:"artist_id"`
+	SongId   restApiV1.SongId     `db:"song_id"`
+	Role     restApiV1.ArtistRole `db:"role"`
 }
 
 type DeletedArtistEntity struct {
@@ -6,9 +6,10 @@ import (
 )
 
 type FavoriteSongEntity struct {
-	UserId   restApiV1.UserId `db:"user_id"`
-	SongId   restApiV1.SongId `db:"song_id"`
-	UpdateTs int64            `db:"update_ts"`
+	UserId          restApiV1.UserId `db:"user_id"`
+	SongId          restApiV1.SongId `db:"song_id"`
+	UpdateTs        int64            `db:"update_ts"`
+	SmartPlaylistFg bool             `db:"smart_playlist_fg"`
 }
 
 func (e *FavoriteSongEntity) Fill(s *restApiV1.FavoriteSong) {
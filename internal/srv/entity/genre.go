@@ -0,0 +1,35 @@
+package entity
+
+import "github.com/jypelle/mifasol/restApiV1"
+
+// Genre
+
+type GenreEntity struct {
+	GenreId    restApiV1.GenreId `db:"genre_id" json:"genre_id"`
+	CreationTs int64             `db:"creation_ts" json:"creation_ts"`
+	UpdateTs   int64             `db:"update_ts" json:"update_ts"`
+	Name       string            `db:"name" json:"name"`
+}
+
+func (e *GenreEntity) Fill(s *restApiV1.Genre) {
+	s.Id = e.GenreId
+	s.CreationTs = e.CreationTs
+	s.UpdateTs = e.UpdateTs
+	s.Name = e.Name
+}
+
+func (e *GenreEntity) LoadMeta(s *restApiV1.GenreMeta) {
+	if s != nil {
+		e.Name = s.Name
+	}
+}
+
+type GenreSongEntity struct {
+	GenreId restApiV1.GenreId `db:"genre_id"`
+	SongId  restApiV1.SongId  `db:"song_id"`
+}
+
+type DeletedGenreEntity struct {
+	GenreId  restApiV1.GenreId `db:"genre_id"`
+	DeleteTs int64             `db:"delete_ts"`
+}
@@ -0,0 +1,35 @@
+package entity
+
+import (
+	"github.com/jypelle/mifasol/restApiV1"
+)
+
+type PlayEventEntity struct {
+	PlayEventId      restApiV1.PlayEventId `db:"play_event_id"`
+	CreationTs       int64                 `db:"creation_ts"`
+	UserId           restApiV1.UserId      `db:"user_id"`
+	SongId           restApiV1.SongId      `db:"song_id"`
+	StartTs          int64                 `db:"start_ts"`
+	ListenedDuration int64                 `db:"listened_duration"`
+	ClientKind       restApiV1.ClientKind  `db:"client_kind"`
+}
+
+func (e *PlayEventEntity) Fill(s *restApiV1.PlayEvent) {
+	s.Id = e.PlayEventId
+	s.CreationTs = e.CreationTs
+	s.UserId = e.UserId
+	s.SongId = e.SongId
+	s.StartTs = e.StartTs
+	s.ListenedDuration = e.ListenedDuration
+	s.ClientKind = e.ClientKind
+}
+
+func (e *PlayEventEntity) LoadMeta(s *restApiV1.PlayEventMeta) {
+	if s != nil {
+		e.UserId = s.UserId
+		e.SongId = s.SongId
+		e.StartTs = s.StartTs
+		e.ListenedDuration = s.ListenedDuration
+		e.ClientKind = s.ClientKind
+	}
+}
@@ -1,6 +1,8 @@
 package entity
 
 import (
+	"database/sql"
+	"encoding/json"
 	"github.com/jypelle/mifasol/restApiV1"
 )
 
@@ -12,6 +14,8 @@ type PlaylistEntity struct {
 	UpdateTs        int64                `db:"update_ts"`
 	ContentUpdateTs int64                `db:"content_update_ts"`
 	Name            string               `db:"name"`
+	SmartRules      sql.NullString       `db:"smart_rules"`
+	FolderId        sql.NullString       `db:"folder_id"`
 }
 
 func (e *PlaylistEntity) Fill(s *restApiV1.Playlist) {
@@ -20,12 +24,39 @@ func (e *PlaylistEntity) Fill(s *restApiV1.Playlist) {
 	s.UpdateTs = e.UpdateTs
 	s.ContentUpdateTs = e.ContentUpdateTs
 	s.Name = e.Name
+	s.SmartRules = e.DecodeSmartRules()
+	s.FolderId = nil
+	if e.FolderId.Valid {
+		folderId := restApiV1.PlaylistFolderId(e.FolderId.String)
+		s.FolderId = &folderId
+	}
 }
 
 func (e *PlaylistEntity) LoadMeta(s *restApiV1.PlaylistMeta) {
 	if s != nil {
 		e.Name = s.Name
+		e.SmartRules = sql.NullString{}
+		if s.SmartRules != nil {
+			rawSmartRules, _ := json.Marshal(s.SmartRules)
+			e.SmartRules = sql.NullString{String: string(rawSmartRules), Valid: true}
+		}
+		e.FolderId = sql.NullString{}
+		if s.FolderId != nil {
+			e.FolderId = sql.NullString{String: string(*s.FolderId), Valid: true}
+		}
+	}
+}
+
+// DecodeSmartRules return the rules of a smart playlist, nil for a static playlist
+func (e *PlaylistEntity) DecodeSmartRules() *restApiV1.SmartPlaylistRules {
+	if !e.SmartRules.Valid {
+		return nil
+	}
+	var smartRules restApiV1.SmartPlaylistRules
+	if json.Unmarshal([]byte(e.SmartRules.String), &smartRules) != nil {
+		return nil
 	}
+	return &smartRules
 }
 
 type PlaylistSongEntity struct {
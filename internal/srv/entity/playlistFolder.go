@@ -0,0 +1,43 @@
+package entity
+
+import (
+	"database/sql"
+	"github.com/jypelle/mifasol/restApiV1"
+)
+
+// Playlist folder
+
+type PlaylistFolderEntity struct {
+	PlaylistFolderId restApiV1.PlaylistFolderId `db:"playlist_folder_id"`
+	CreationTs       int64                      `db:"creation_ts"`
+	UpdateTs         int64                      `db:"update_ts"`
+	Name             string                     `db:"name"`
+	ParentFolderId   sql.NullString             `db:"parent_folder_id"`
+}
+
+func (e *PlaylistFolderEntity) Fill(s *restApiV1.PlaylistFolder) {
+	s.Id = e.PlaylistFolderId
+	s.CreationTs = e.CreationTs
+	s.UpdateTs = e.UpdateTs
+	s.Name = e.Name
+	s.ParentFolderId = nil
+	if e.ParentFolderId.Valid {
+		parentFolderId := restApiV1.PlaylistFolderId(e.ParentFolderId.String)
+		s.ParentFolderId = &parentFolderId
+	}
+}
+
+func (e *PlaylistFolderEntity) LoadMeta(s *restApiV1.PlaylistFolderMeta) {
+	if s != nil {
+		e.Name = s.Name
+		e.ParentFolderId = sql.NullString{}
+		if s.ParentFolderId != nil {
+			e.ParentFolderId = sql.NullString{String: string(*s.ParentFolderId), Valid: true}
+		}
+	}
+}
+
+type DeletedPlaylistFolderEntity struct {
+	PlaylistFolderId restApiV1.PlaylistFolderId `db:"playlist_folder_id"`
+	DeleteTs         int64                      `db:"delete_ts"`
+}
@@ -0,0 +1,54 @@
+package entity
+
+import (
+	"github.com/jypelle/mifasol/restApiV1"
+)
+
+type ScrobblingEntity struct {
+	UserId      restApiV1.UserId                `db:"user_id"`
+	ServiceType restApiV1.ScrobblingServiceType `db:"service_type"`
+	ApiRootUrl  string                          `db:"api_root_url"`
+	Token       string                          `db:"token"`
+	ApiKey      string                          `db:"api_key"`
+	ApiSecret   string                          `db:"api_secret"`
+	LastError   string                          `db:"last_error"`
+	LastErrorTs int64                           `db:"last_error_ts"`
+	UpdateTs    int64                           `db:"update_ts"`
+}
+
+// Fill copy the settings without their secrets
+func (e *ScrobblingEntity) Fill(s *restApiV1.Scrobbling) {
+	s.UserId = e.UserId
+	s.UpdateTs = e.UpdateTs
+	s.ServiceType = e.ServiceType
+	s.ApiRootUrl = e.ApiRootUrl
+	s.ApiKey = e.ApiKey
+	s.LastError = e.LastError
+	s.LastErrorTs = e.LastErrorTs
+}
+
+func (e *ScrobblingEntity) LoadMeta(s *restApiV1.ScrobblingMeta) {
+	if s != nil {
+		e.ServiceType = s.ServiceType
+		e.ApiRootUrl = s.ApiRootUrl
+		e.ApiKey = s.ApiKey
+		if s.Token != "" {
+			e.Token = s.Token
+		}
+		if s.ApiSecret != "" {
+			e.ApiSecret = s.ApiSecret
+		}
+	}
+}
+
+type ScrobbleEntity struct {
+	ScrobbleId    string           `db:"scrobble_id"`
+	UserId        restApiV1.UserId `db:"user_id"`
+	ListenedTs    int64            `db:"listened_ts"`
+	TrackName     string           `db:"track_name"`
+	ArtistName    string           `db:"artist_name"`
+	AlbumName     string           `db:"album_name"`
+	Duration      int64            `db:"duration"`
+	AttemptCount  int64            `db:"attempt_count"`
+	NextAttemptTs int64            `db:"next_attempt_ts"`
+}
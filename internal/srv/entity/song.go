@@ -8,17 +8,29 @@ import (
 // Song
 
 type SongEntity struct {
-	SongId          restApiV1.SongId       `db:"song_id"`
-	CreationTs      int64                  `db:"creation_ts"`
-	UpdateTs        int64                  `db:"update_ts"`
-	Name            string                 `db:"name"`
-	Format          restApiV1.SongFormat   `db:"format"`
-	Size            int64                  `db:"size"`
-	BitDepth        restApiV1.SongBitDepth `db:"bit_depth"`
-	PublicationYear sql.NullInt64          `db:"publication_year"`
-	AlbumId         restApiV1.AlbumId      `db:"album_id"`
-	TrackNumber     sql.NullInt64          `db:"track_number"`
-	ExplicitFg      bool                   `db:"explicit_fg"`
+	SongId             restApiV1.SongId       `db:"song_id"`
+	CreationTs         int64                  `db:"creation_ts"`
+	UpdateTs           int64                  `db:"update_ts"`
+	Name               string                 `db:"name"`
+	Format             restApiV1.SongFormat   `db:"format"`
+	Size               int64                  `db:"size"`
+	BitDepth           restApiV1.SongBitDepth `db:"bit_depth"`
+	Duration           int64                  `db:"duration"`
+	SampleRate         int64                  `db:"sample_rate"`
+	Channels           int64                  `db:"channels"`
+	BitRate            int64                  `db:"bit_rate"`
+	AudioPropertiesFg  bool                   `db:"audio_properties_fg"`
+	PublicationYear    sql.NullInt64          `db:"publication_year"`
+	AlbumId            restApiV1.AlbumId      `db:"album_id"`
+	DiscNumber         sql.NullInt64          `db:"disc_number"`
+	TrackNumber        sql.NullInt64          `db:"track_number"`
+	ExplicitFg         bool                   `db:"explicit_fg"`
+	ContentHash        string                 `db:"content_hash"`
+	Loudness           sql.NullFloat64        `db:"loudness"`
+	TruePeak           sql.NullFloat64        `db:"true_peak"`
+	LoudnessAnalysisFg bool                   `db:"loudness_analysis_fg"`
+	Lyrics             string                 `db:"lyrics"`
+	LyricsFg           bool                   `db:"lyrics_fg"`
 }
 
 func (e *SongEntity) Fill(s *restApiV1.Song) {
@@ -29,18 +41,38 @@ func (e *SongEntity) Fill(s *restApiV1.Song) {
 	s.Format = e.Format
 	s.Size = e.Size
 	s.BitDepth = e.BitDepth
+	s.Duration = e.Duration
+	s.SampleRate = e.SampleRate
+	s.Channels = e.Channels
+	s.BitRate = e.BitRate
 	if e.PublicationYear.Valid {
 		s.PublicationYear = &e.PublicationYear.Int64
 	} else {
 		s.PublicationYear = nil
 	}
 	s.AlbumId = e.AlbumId
+	if e.DiscNumber.Valid {
+		s.DiscNumber = &e.DiscNumber.Int64
+	} else {
+		s.DiscNumber = nil
+	}
 	if e.TrackNumber.Valid {
 		s.TrackNumber = &e.TrackNumber.Int64
 	} else {
 		s.TrackNumber = nil
 	}
 	s.ExplicitFg = e.ExplicitFg
+	if e.Loudness.Valid {
+		s.Loudness = &e.Loudness.Float64
+	} else {
+		s.Loudness = nil
+	}
+	if e.TruePeak.Valid {
+		s.TruePeak = &e.TruePeak.Float64
+	} else {
+		s.TruePeak = nil
+	}
+	s.LyricsFg = e.LyricsFg
 }
 
 func (e *SongEntity) LoadMeta(s *restApiV1.SongMeta) {
@@ -49,6 +81,10 @@ func (e *SongEntity) LoadMeta(s *restApiV1.SongMeta) {
 		e.Format = s.Format
 		e.Size = s.Size
 		e.BitDepth = s.BitDepth
+		e.Duration = s.Duration
+		e.SampleRate = s.SampleRate
+		e.Channels = s.Channels
+		e.BitRate = s.BitRate
 		if s.PublicationYear != nil {
 			e.PublicationYear.Int64 = *s.PublicationYear
 			e.PublicationYear.Valid = true
@@ -56,6 +92,12 @@ func (e *SongEntity) LoadMeta(s *restApiV1.SongMeta) {
 			e.PublicationYear.Valid = false
 		}
 		e.AlbumId = s.AlbumId
+		if s.DiscNumber != nil {
+			e.DiscNumber.Int64 = *s.DiscNumber
+			e.DiscNumber.Valid = true
+		} else {
+			e.DiscNumber.Valid = false
+		}
 		if s.TrackNumber != nil {
 			e.TrackNumber.Int64 = *s.TrackNumber
 			e.TrackNumber.Valid = true
@@ -0,0 +1,32 @@
+package entity
+
+import (
+	"github.com/jypelle/mifasol/restApiV1"
+)
+
+type SongRatingEntity struct {
+	UserId   restApiV1.UserId `db:"user_id"`
+	SongId   restApiV1.SongId `db:"song_id"`
+	Rating   int64            `db:"rating"`
+	UpdateTs int64            `db:"update_ts"`
+}
+
+func (e *SongRatingEntity) Fill(s *restApiV1.SongRating) {
+	s.Id = restApiV1.SongRatingId{UserId: e.UserId, SongId: e.SongId}
+	s.Rating = e.Rating
+	s.UpdateTs = e.UpdateTs
+}
+
+func (e *SongRatingEntity) LoadMeta(s *restApiV1.SongRatingMeta) {
+	if s != nil {
+		e.UserId = s.Id.UserId
+		e.SongId = s.Id.SongId
+		e.Rating = s.Rating
+	}
+}
+
+type DeletedSongRatingEntity struct {
+	UserId   restApiV1.UserId `db:"user_id"`
+	SongId   restApiV1.SongId `db:"song_id"`
+	DeleteTs int64            `db:"delete_ts"`
+}
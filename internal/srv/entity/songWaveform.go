@@ -0,0 +1,11 @@
+package entity
+
+import (
+	"github.com/jypelle/mifasol/restApiV1"
+)
+
+type SongWaveformEntity struct {
+	SongId   restApiV1.SongId `db:"song_id"`
+	Peaks    []byte           `db:"peaks"`
+	UpdateTs int64            `db:"update_ts"`
+}
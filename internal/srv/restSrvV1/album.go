@@ -18,11 +18,17 @@ func (s *RestServer) readAlbums(w http.ResponseWriter, r *http.Request) {
 		s.log.Panicf("Unable to interpret data to read the albums: %v", err)
 	}
 
-	albums, err := s.store.ReadAlbums(nil, &albumFilter)
+	albums, nextCursor, totalCount, err := s.store.ReadAlbumsPage(nil, &albumFilter)
 	if err != nil {
+		if err == storeerror.ErrInvalidPage {
+			s.apiErrorCodeResponse(w, restApiV1.InvalideRequestErrorCode)
+			return
+		}
 		s.log.Panicf("Unable to read albums: %v", err)
 	}
 
+	writePageHeaders(w, nextCursor, totalCount)
+
 	tool.WriteJsonResponse(w, albums)
 }
 
@@ -49,6 +55,12 @@ func (s *RestServer) readAlbum(w http.ResponseWriter, r *http.Request) {
 func (s *RestServer) createAlbum(w http.ResponseWriter, r *http.Request) {
 	s.log.Debugf("Create album")
 
+	// Only admin can create an album
+	if !isConnectedUserAdmin(r) {
+		s.apiErrorCodeResponse(w, restApiV1.ForbiddenErrorCode)
+		return
+	}
+
 	var albumMeta restApiV1.AlbumMeta
 	err := json.NewDecoder(r.Body).Decode(&albumMeta)
 	if err != nil {
@@ -60,6 +72,10 @@ func (s *RestServer) createAlbum(w http.ResponseWriter, r *http.Request) {
 
 	album, err := s.store.CreateAlbum(nil, &albumMeta)
 	if err != nil {
+		if err == storeerror.ErrNotFound {
+			s.apiErrorCodeResponse(w, restApiV1.NotFoundErrorCode)
+			return
+		}
 		s.log.Panicf("Unable to create the album: %v", err)
 	}
 
@@ -74,6 +90,12 @@ func (s *RestServer) updateAlbum(w http.ResponseWriter, r *http.Request) {
 
 	s.log.Debugf("Update album: %s", albumId)
 
+	// Only admin can update an album
+	if !isConnectedUserAdmin(r) {
+		s.apiErrorCodeResponse(w, restApiV1.ForbiddenErrorCode)
+		return
+	}
+
 	var albumMeta restApiV1.AlbumMeta
 	err := json.NewDecoder(r.Body).Decode(&albumMeta)
 	if err != nil {
@@ -82,6 +104,10 @@ func (s *RestServer) updateAlbum(w http.ResponseWriter, r *http.Request) {
 
 	album, err := s.store.UpdateAlbum(nil, albumId, &albumMeta)
 	if err != nil {
+		if err == storeerror.ErrNotFound {
+			s.apiErrorCodeResponse(w, restApiV1.NotFoundErrorCode)
+			return
+		}
 		s.log.Panicf("Unable to update the album: %v", err)
 	}
 
@@ -96,6 +122,12 @@ func (s *RestServer) deleteAlbum(w http.ResponseWriter, r *http.Request) {
 
 	s.log.Debugf("Delete album: %s", albumId)
 
+	// Only admin can delete an album
+	if !isConnectedUserAdmin(r) {
+		s.apiErrorCodeResponse(w, restApiV1.ForbiddenErrorCode)
+		return
+	}
+
 	album, err := s.store.DeleteAlbum(nil, albumId)
 	if err != nil {
 		if err == storeerror.ErrDeleteAlbumWithSongs {
@@ -0,0 +1,105 @@
+package restSrvV1
+
+import (
+	"errors"
+	"github.com/gorilla/mux"
+	"github.com/jypelle/mifasol/internal/srv/storeerror"
+	"github.com/jypelle/mifasol/internal/tool"
+	"github.com/jypelle/mifasol/restApiV1"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// maxPictureSize is the maximum size of an uploaded album cover or artist picture
+const maxPictureSize = 10 << 20
+
+func (s *RestServer) readAlbumCover(w http.ResponseWriter, r *http.Request) {
+
+	vars := mux.Vars(r)
+	albumId := restApiV1.AlbumId(vars["id"])
+
+	s.log.Debugf("Read album cover: %s", albumId)
+
+	albumCover, album, err := s.store.ReadAlbumCover(nil, albumId)
+	if err != nil {
+		if err == storeerror.ErrNotFound {
+			s.apiErrorCodeResponse(w, restApiV1.NotFoundErrorCode)
+			return
+		}
+		s.log.Panicf("Unable to read album cover: %v", err)
+	}
+	defer albumCover.Close()
+
+	http.ServeContent(w, r, "", time.Unix(0, album.UpdateTs), albumCover)
+}
+
+func (s *RestServer) updateAlbumCover(w http.ResponseWriter, r *http.Request) {
+
+	vars := mux.Vars(r)
+	albumId := restApiV1.AlbumId(vars["id"])
+
+	s.log.Debugf("Update album cover: %s", albumId)
+
+	// Only admin can update an album cover
+	if !isConnectedUserAdmin(r) {
+		s.apiErrorCodeResponse(w, restApiV1.ForbiddenErrorCode)
+		return
+	}
+
+	content, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, maxPictureSize))
+	if err != nil {
+		var maxBytesError *http.MaxBytesError
+		if errors.As(err, &maxBytesError) {
+			s.apiErrorCodeResponse(w, restApiV1.InvalidAlbumCoverErrorCode)
+			return
+		}
+		s.log.Panicf("Unable to read album cover content: %v", err)
+	}
+
+	if !strings.HasPrefix(http.DetectContentType(content), "image/") {
+		s.apiErrorCodeResponse(w, restApiV1.InvalidAlbumCoverErrorCode)
+		return
+	}
+
+	album, err := s.store.UpdateAlbumCover(nil, albumId, content)
+	if err != nil {
+		if err == storeerror.ErrNotFound {
+			s.apiErrorCodeResponse(w, restApiV1.NotFoundErrorCode)
+			return
+		}
+		if err == storeerror.ErrInvalidAlbumCover {
+			s.apiErrorCodeResponse(w, restApiV1.InvalidAlbumCoverErrorCode)
+			return
+		}
+		s.log.Panicf("Unable to update the album cover: %v", err)
+	}
+
+	tool.WriteJsonResponse(w, album)
+}
+
+func (s *RestServer) deleteAlbumCover(w http.ResponseWriter, r *http.Request) {
+
+	vars := mux.Vars(r)
+	albumId := restApiV1.AlbumId(vars["id"])
+
+	s.log.Debugf("Delete album cover: %s", albumId)
+
+	// Only admin can delete an album cover
+	if !isConnectedUserAdmin(r) {
+		s.apiErrorCodeResponse(w, restApiV1.ForbiddenErrorCode)
+		return
+	}
+
+	album, err := s.store.DeleteAlbumCover(nil, albumId)
+	if err != nil {
+		if err == storeerror.ErrNotFound {
+			s.apiErrorCodeResponse(w, restApiV1.NotFoundErrorCode)
+			return
+		}
+		s.log.Panicf("Unable to delete the album cover: %v", err)
+	}
+
+	tool.WriteJsonResponse(w, album)
+}
@@ -18,11 +18,17 @@ func (s *RestServer) readArtists(w http.ResponseWriter, r *http.Request) {
 		s.log.Panicf("Unable to interpret data to read the artists: %v", err)
 	}
 
-	artists, err := s.store.ReadArtists(nil, &artistFilter)
+	artists, nextCursor, totalCount, err := s.store.ReadArtistsPage(nil, &artistFilter)
 	if err != nil {
+		if err == storeerror.ErrInvalidPage {
+			s.apiErrorCodeResponse(w, restApiV1.InvalideRequestErrorCode)
+			return
+		}
 		s.log.Panicf("Unable to read artists: %v", err)
 	}
 
+	writePageHeaders(w, nextCursor, totalCount)
+
 	w.WriteHeader(http.StatusCreated)
 	tool.WriteJsonResponse(w, artists)
 }
@@ -49,6 +55,12 @@ func (s *RestServer) readArtist(w http.ResponseWriter, r *http.Request) {
 func (s *RestServer) createArtist(w http.ResponseWriter, r *http.Request) {
 	s.log.Debugf("Create artist")
 
+	// Only admin can create an artist
+	if !isConnectedUserAdmin(r) {
+		s.apiErrorCodeResponse(w, restApiV1.ForbiddenErrorCode)
+		return
+	}
+
 	var artistMeta restApiV1.ArtistMeta
 	err := json.NewDecoder(r.Body).Decode(&artistMeta)
 	if err != nil {
@@ -70,6 +82,12 @@ func (s *RestServer) updateArtist(w http.ResponseWriter, r *http.Request) {
 
 	s.log.Debugf("Update artist: %s", artistId)
 
+	// Only admin can update an artist
+	if !isConnectedUserAdmin(r) {
+		s.apiErrorCodeResponse(w, restApiV1.ForbiddenErrorCode)
+		return
+	}
+
 	var artistMeta restApiV1.ArtistMeta
 	err := json.NewDecoder(r.Body).Decode(&artistMeta)
 	if err != nil {
@@ -92,6 +110,12 @@ func (s *RestServer) deleteArtist(w http.ResponseWriter, r *http.Request) {
 
 	s.log.Debugf("Delete artist: %s", artistId)
 
+	// Only admin can delete an artist
+	if !isConnectedUserAdmin(r) {
+		s.apiErrorCodeResponse(w, restApiV1.ForbiddenErrorCode)
+		return
+	}
+
 	artist, err := s.store.DeleteArtist(nil, artistId)
 	if err != nil {
 		if err == storeerror.ErrDeleteArtistWithSongs {
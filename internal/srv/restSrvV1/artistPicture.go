@@ -0,0 +1,96 @@
+package restSrvV1
+
+import (
+	"errors"
+	"github.com/gorilla/mux"
+	"github.com/jypelle/mifasol/internal/srv/storeerror"
+	"github.com/jypelle/mifasol/internal/tool"
+	"github.com/jypelle/mifasol/restApiV1"
+	"io/ioutil"
+	"net/http"
+	"time"
+)
+
+func (s *RestServer) readArtistPicture(w http.ResponseWriter, r *http.Request) {
+
+	vars := mux.Vars(r)
+	artistId := restApiV1.ArtistId(vars["id"])
+
+	s.log.Debugf("Read artist picture: %s", artistId)
+
+	artistPicture, artist, err := s.store.ReadArtistPicture(nil, artistId)
+	if err != nil {
+		if err == storeerror.ErrNotFound {
+			s.apiErrorCodeResponse(w, restApiV1.NotFoundErrorCode)
+			return
+		}
+		s.log.Panicf("Unable to read artist picture: %v", err)
+	}
+	defer artistPicture.Close()
+
+	http.ServeContent(w, r, "", time.Unix(0, artist.UpdateTs), artistPicture)
+}
+
+func (s *RestServer) updateArtistPicture(w http.ResponseWriter, r *http.Request) {
+
+	vars := mux.Vars(r)
+	artistId := restApiV1.ArtistId(vars["id"])
+
+	s.log.Debugf("Update artist picture: %s", artistId)
+
+	// Only admin can update an artist picture
+	if !isConnectedUserAdmin(r) {
+		s.apiErrorCodeResponse(w, restApiV1.ForbiddenErrorCode)
+		return
+	}
+
+	content, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, maxPictureSize))
+	if err != nil {
+		var maxBytesError *http.MaxBytesError
+		if errors.As(err, &maxBytesError) {
+			s.apiErrorCodeResponse(w, restApiV1.InvalidArtistPictureErrorCode)
+			return
+		}
+		s.log.Panicf("Unable to read artist picture content: %v", err)
+	}
+
+	artist, err := s.store.UpdateArtistPicture(nil, artistId, content)
+	if err != nil {
+		if err == storeerror.ErrNotFound {
+			s.apiErrorCodeResponse(w, restApiV1.NotFoundErrorCode)
+			return
+		}
+		if err == storeerror.ErrInvalidArtistPicture {
+			s.apiErrorCodeResponse(w, restApiV1.InvalidArtistPictureErrorCode)
+			return
+		}
+		s.log.Panicf("Unable to update the artist picture: %v", err)
+	}
+
+	tool.WriteJsonResponse(w, artist)
+}
+
+func (s *RestServer) deleteArtistPicture(w http.ResponseWriter, r *http.Request) {
+
+	vars := mux.Vars(r)
+	artistId := restApiV1.ArtistId(vars["id"])
+
+	s.log.Debugf("Delete artist picture: %s", artistId)
+
+	// Only admin can delete an artist picture
+	if !isConnectedUserAdmin(r) {
+		s.apiErrorCodeResponse(w, restApiV1.ForbiddenErrorCode)
+		return
+	}
+
+	artist, err := s.store.DeleteArtistPicture(nil, artistId)
+	if err != nil {
+		if err == storeerror.ErrNotFound {
+			s.apiErrorCodeResponse(w, restApiV1.NotFoundErrorCode)
+			return
+		}
+		s.log.Panicf("Unable to delete the artist picture: %v", err)
+	}
+
+	tool.WriteJsonResponse(w, artist)
+}
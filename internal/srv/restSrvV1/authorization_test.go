@@ -0,0 +1,294 @@
+package restSrvV1
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/jypelle/mifasol/internal/srv/config"
+	"github.com/jypelle/mifasol/internal/srv/store"
+	"github.com/jypelle/mifasol/restApiV1"
+)
+
+type testServer struct {
+	t      *testing.T
+	store  *store.Store
+	router *mux.Router
+}
+
+func newTestServer(t *testing.T) *testServer {
+	serverConfig := config.ServerConfig{ConfigDir: t.TempDir()}
+	serverConfig.ServerEditableConfig = config.NewServerEditableConfig(nil)
+	for _, dirName := range []string{
+		serverConfig.GetCompleteConfigSongsDirName(),
+		serverConfig.GetCompleteConfigAlbumsDirName(),
+		serverConfig.GetCompleteConfigAuthorsDirName(),
+		serverConfig.GetCompleteConfigDbDirName(),
+	} {
+		if err := os.MkdirAll(dirName, 0770); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ts := &testServer{
+		t:      t,
+		store:  store.NewStore(&serverConfig),
+		router: mux.NewRouter(),
+	}
+	NewRestServer(ts.store, ts.router.PathPrefix("/api/v1").Subrouter())
+
+	return ts
+}
+
+// createUser create a user and return it with its access token
+func (ts *testServer) createUser(name string, adminFg bool) (*restApiV1.User, string) {
+	user, err := ts.store.CreateUser(nil, &restApiV1.UserMetaComplete{
+		UserMeta: restApiV1.UserMeta{Name: name, AdminFg: adminFg},
+		Password: name,
+	})
+	if err != nil {
+		ts.t.Fatal(err)
+	}
+
+	response := ts.request("", "POST", "/api/v1/token?"+url.Values{
+		"grant_type": {"password"},
+		"username":   {name},
+		"password":   {name},
+	}.Encode(), nil)
+	if response.Code != http.StatusOK {
+		ts.t.Fatalf("Unable to get a token for %s: %d", name, response.Code)
+	}
+	var token restApiV1.Token
+	if err := json.NewDecoder(response.Body).Decode(&token); err != nil {
+		ts.t.Fatal(err)
+	}
+
+	return user, token.AccessToken
+}
+
+// createPlaylist create a playlist owned by ownerUserId
+func (ts *testServer) createPlaylist(name string, ownerUserId restApiV1.UserId) *restApiV1.Playlist {
+	playlist, err := ts.store.CreatePlaylist(nil, &restApiV1.PlaylistMeta{Name: name, OwnerUserIds: []restApiV1.UserId{ownerUserId}}, true)
+	if err != nil {
+		ts.t.Fatal(err)
+	}
+	return playlist
+}
+
+func (ts *testServer) request(accessToken string, method string, path string, body interface{}) *httptest.ResponseRecorder {
+	var content bytes.Buffer
+	if body != nil {
+		if err := json.NewEncoder(&content).Encode(body); err != nil {
+			ts.t.Fatal(err)
+		}
+	}
+	request := httptest.NewRequest(method, path, &content)
+	if accessToken != "" {
+		request.Header.Set("Authorization", "Bearer "+accessToken)
+	}
+	response := httptest.NewRecorder()
+	ts.router.ServeHTTP(response, request)
+	return response
+}
+
+// expectStatus check the status of the response to a request
+func (ts *testServer) expectStatus(accessToken string, method string, path string, body interface{}, status int) {
+	ts.t.Helper()
+	response := ts.request(accessToken, method, path, body)
+	if response.Code != status {
+		ts.t.Errorf("%s %s: status %d expected, got %d (%s)", method, path, status, response.Code, response.Body.String())
+	}
+}
+
+// expectErrorCode check the error code of the response to a request
+func (ts *testServer) expectErrorCode(accessToken string, method string, path string, body interface{}, errorCode restApiV1.ErrorCode) {
+	ts.t.Helper()
+	response := ts.request(accessToken, method, path, body)
+	var apiError restApiV1.ApiError
+	json.NewDecoder(response.Body).Decode(&apiError)
+	if response.Code != errorCode.StatusCode() || apiError.ErrorCode != errorCode {
+		ts.t.Errorf("%s %s: %s expected, got %d %s", method, path, errorCode, response.Code, apiError.ErrorCode)
+	}
+}
+
+func TestTokenRequired(t *testing.T) {
+	ts := newTestServer(t)
+
+	ts.expectErrorCode("", "GET", "/api/v1/users", nil, restApiV1.InvalidTokenErrorCode)
+	ts.expectErrorCode("unknown", "GET", "/api/v1/users", nil, restApiV1.InvalidTokenErrorCode)
+}
+
+func TestUserAuthorization(t *testing.T) {
+	ts := newTestServer(t)
+	admin, adminToken := ts.createUser("admin", true)
+	alice, aliceToken := ts.createUser("alice", false)
+	bob, _ := ts.createUser("bob", false)
+
+	// Only admin can create a user
+	ts.expectErrorCode(aliceToken, "POST", "/api/v1/users", &restApiV1.UserMetaComplete{UserMeta: restApiV1.UserMeta{Name: "carol"}, Password: "carol"}, restApiV1.ForbiddenErrorCode)
+	ts.expectStatus(adminToken, "POST", "/api/v1/users", &restApiV1.UserMetaComplete{UserMeta: restApiV1.UserMeta{Name: "carol"}, Password: "carol"}, http.StatusCreated)
+
+	// Only admin can edit another user
+	ts.expectErrorCode(aliceToken, "PUT", "/api/v1/users/"+string(bob.Id), &restApiV1.UserMetaComplete{UserMeta: restApiV1.UserMeta{Name: "bobby"}}, restApiV1.ForbiddenErrorCode)
+	ts.expectStatus(aliceToken, "PUT", "/api/v1/users/"+string(alice.Id), &restApiV1.UserMetaComplete{UserMeta: restApiV1.UserMeta{Name: "alicia"}}, http.StatusOK)
+	ts.expectStatus(adminToken, "PUT", "/api/v1/users/"+string(bob.Id), &restApiV1.UserMetaComplete{UserMeta: restApiV1.UserMeta{Name: "bobby"}}, http.StatusOK)
+	ts.expectErrorCode(adminToken, "PUT", "/api/v1/users/unknown", &restApiV1.UserMetaComplete{UserMeta: restApiV1.UserMeta{Name: "unknown"}}, restApiV1.NotFoundErrorCode)
+
+	// Only admin can grant administrator rights
+	ts.expectErrorCode(aliceToken, "PUT", "/api/v1/users/"+string(alice.Id), &restApiV1.UserMetaComplete{UserMeta: restApiV1.UserMeta{Name: "alicia", AdminFg: true}}, restApiV1.ForbiddenErrorCode)
+	ts.expectStatus(adminToken, "PUT", "/api/v1/users/"+string(alice.Id), &restApiV1.UserMetaComplete{UserMeta: restApiV1.UserMeta{Name: "alicia", AdminFg: true}}, http.StatusOK)
+	ts.expectStatus(aliceToken, "PUT", "/api/v1/users/"+string(bob.Id), &restApiV1.UserMetaComplete{UserMeta: restApiV1.UserMeta{Name: "bob"}}, http.StatusOK)
+	ts.expectStatus(adminToken, "PUT", "/api/v1/users/"+string(alice.Id), &restApiV1.UserMetaComplete{UserMeta: restApiV1.UserMeta{Name: "alice"}}, http.StatusOK)
+
+	// Only admin can delete another user
+	ts.expectErrorCode(aliceToken, "DELETE", "/api/v1/users/"+string(bob.Id), nil, restApiV1.ForbiddenErrorCode)
+	ts.expectErrorCode(adminToken, "DELETE", "/api/v1/users/"+string(admin.Id), nil, restApiV1.DeleteUserYourselfErrorCode)
+	ts.expectStatus(adminToken, "DELETE", "/api/v1/users/"+string(bob.Id), nil, http.StatusOK)
+	ts.expectErrorCode(adminToken, "DELETE", "/api/v1/users/"+string(bob.Id), nil, restApiV1.NotFoundErrorCode)
+}
+
+func TestPlaylistAuthorization(t *testing.T) {
+	ts := newTestServer(t)
+	_, adminToken := ts.createUser("admin", true)
+	alice, aliceToken := ts.createUser("alice", false)
+	bob, bobToken := ts.createUser("bob", false)
+	playlist := ts.createPlaylist("alice's", alice.Id)
+	playlistPath := "/api/v1/playlists/" + string(playlist.Id)
+
+	// Only admin can create a playlist without being one of its owners
+	ts.expectErrorCode(bobToken, "POST", "/api/v1/playlists", &restApiV1.PlaylistMeta{Name: "for alice", OwnerUserIds: []restApiV1.UserId{alice.Id}}, restApiV1.ForbiddenErrorCode)
+	ts.expectStatus(bobToken, "POST", "/api/v1/playlists", &restApiV1.PlaylistMeta{Name: "shared", OwnerUserIds: []restApiV1.UserId{alice.Id, bob.Id}}, http.StatusCreated)
+	ts.expectStatus(adminToken, "POST", "/api/v1/playlists", &restApiV1.PlaylistMeta{Name: "for alice", OwnerUserIds: []restApiV1.UserId{alice.Id}}, http.StatusCreated)
+
+	// Only admin or owner can edit a playlist
+	ts.expectErrorCode(bobToken, "PUT", playlistPath, &restApiV1.PlaylistMeta{Name: "bob's", OwnerUserIds: []restApiV1.UserId{bob.Id}}, restApiV1.ForbiddenErrorCode)
+	ts.expectStatus(aliceToken, "PUT", playlistPath, &restApiV1.PlaylistMeta{Name: "alice's favorites", OwnerUserIds: []restApiV1.UserId{alice.Id}}, http.StatusOK)
+	ts.expectStatus(adminToken, "PUT", playlistPath, &restApiV1.PlaylistMeta{Name: "alice's", OwnerUserIds: []restApiV1.UserId{alice.Id}}, http.StatusOK)
+	ts.expectErrorCode(aliceToken, "PUT", "/api/v1/playlists/unknown", &restApiV1.PlaylistMeta{Name: "unknown", OwnerUserIds: []restApiV1.UserId{alice.Id}}, restApiV1.NotFoundErrorCode)
+
+	// Only admin or owner can delete a playlist
+	ts.expectErrorCode(bobToken, "DELETE", playlistPath, nil, restApiV1.ForbiddenErrorCode)
+	ts.expectStatus(aliceToken, "DELETE", playlistPath, nil, http.StatusOK)
+	adminDeletedPlaylist := ts.createPlaylist("alice's other", alice.Id)
+	ts.expectStatus(adminToken, "DELETE", "/api/v1/playlists/"+string(adminDeletedPlaylist.Id), nil, http.StatusOK)
+}
+
+func TestFavoriteAuthorization(t *testing.T) {
+	ts := newTestServer(t)
+	_, adminToken := ts.createUser("admin", true)
+	alice, aliceToken := ts.createUser("alice", false)
+	_, bobToken := ts.createUser("bob", false)
+	playlist := ts.createPlaylist("alice's", alice.Id)
+	favoritePlaylistMeta := &restApiV1.FavoritePlaylistMeta{Id: restApiV1.FavoritePlaylistId{UserId: alice.Id, PlaylistId: playlist.Id}}
+	favoritePlaylistPath := "/api/v1/favoritePlaylists/" + string(alice.Id) + "/" + string(playlist.Id)
+
+	// Only admin can edit favorite playlists of another user
+	ts.expectErrorCode(bobToken, "POST", "/api/v1/favoritePlaylists", favoritePlaylistMeta, restApiV1.ForbiddenErrorCode)
+	ts.expectStatus(aliceToken, "POST", "/api/v1/favoritePlaylists", favoritePlaylistMeta, http.StatusCreated)
+	ts.expectErrorCode(bobToken, "DELETE", favoritePlaylistPath, nil, restApiV1.ForbiddenErrorCode)
+	ts.expectStatus(aliceToken, "DELETE", favoritePlaylistPath, nil, http.StatusOK)
+	ts.expectStatus(adminToken, "POST", "/api/v1/favoritePlaylists", favoritePlaylistMeta, http.StatusCreated)
+	ts.expectStatus(adminToken, "DELETE", favoritePlaylistPath, nil, http.StatusOK)
+
+	// Only admin can edit favorite songs of another user
+	favoriteSongMeta := &restApiV1.FavoriteSongMeta{Id: restApiV1.FavoriteSongId{UserId: alice.Id, SongId: "song"}}
+	ts.expectErrorCode(bobToken, "POST", "/api/v1/favoriteSongs", favoriteSongMeta, restApiV1.ForbiddenErrorCode)
+	ts.expectErrorCode(bobToken, "DELETE", "/api/v1/favoriteSongs/"+string(alice.Id)+"/song", nil, restApiV1.ForbiddenErrorCode)
+}
+
+func TestPersonalDataAuthorization(t *testing.T) {
+	ts := newTestServer(t)
+	_, adminToken := ts.createUser("admin", true)
+	alice, aliceToken := ts.createUser("alice", false)
+	_, bobToken := ts.createUser("bob", false)
+	scrobblingPath := "/api/v1/scrobblings/" + string(alice.Id)
+	scrobblingMeta := &restApiV1.ScrobblingMeta{ServiceType: restApiV1.ScrobblingServiceTypeListenBrainz, Token: "token"}
+
+	// Only admin can rate songs or report plays for another user
+	ts.expectErrorCode(bobToken, "PUT", "/api/v1/songRatings", &restApiV1.SongRatingMeta{Id: restApiV1.SongRatingId{UserId: alice.Id, SongId: "song"}, Rating: 5}, restApiV1.ForbiddenErrorCode)
+	ts.expectErrorCode(bobToken, "DELETE", "/api/v1/songRatings/"+string(alice.Id)+"/song", nil, restApiV1.ForbiddenErrorCode)
+	ts.expectErrorCode(bobToken, "POST", "/api/v1/plays", &restApiV1.PlayEventMeta{UserId: alice.Id, SongId: "song"}, restApiV1.ForbiddenErrorCode)
+	ts.expectErrorCode(bobToken, "POST", "/api/v1/nowPlaying", &restApiV1.NowPlayingMeta{UserId: alice.Id, SongId: "song"}, restApiV1.ForbiddenErrorCode)
+
+	// Only admin can manage the scrobbling of another user
+	ts.expectErrorCode(bobToken, "PUT", scrobblingPath, scrobblingMeta, restApiV1.ForbiddenErrorCode)
+	ts.expectStatus(aliceToken, "PUT", scrobblingPath, scrobblingMeta, http.StatusOK)
+	ts.expectErrorCode(bobToken, "GET", scrobblingPath, nil, restApiV1.ForbiddenErrorCode)
+	ts.expectStatus(adminToken, "GET", scrobblingPath, nil, http.StatusOK)
+	ts.expectErrorCode(bobToken, "DELETE", scrobblingPath, nil, restApiV1.ForbiddenErrorCode)
+	ts.expectStatus(aliceToken, "DELETE", scrobblingPath, nil, http.StatusOK)
+}
+
+func TestLibraryAuthorization(t *testing.T) {
+	ts := newTestServer(t)
+	_, adminToken := ts.createUser("admin", true)
+	_, aliceToken := ts.createUser("alice", false)
+
+	// Only admin can edit the library
+	for _, route := range []struct {
+		method string
+		path   string
+		body   interface{}
+	}{
+		{"POST", "/api/v1/albums", &restApiV1.AlbumMeta{Name: "album"}},
+		{"PUT", "/api/v1/albums/album", &restApiV1.AlbumMeta{Name: "album"}},
+		{"DELETE", "/api/v1/albums/album", nil},
+		{"PUT", "/api/v1/albumCovers/album", nil},
+		{"DELETE", "/api/v1/albumCovers/album", nil},
+		{"POST", "/api/v1/artists", &restApiV1.ArtistMeta{Name: "artist"}},
+		{"PUT", "/api/v1/artists/artist", &restApiV1.ArtistMeta{Name: "artist"}},
+		{"DELETE", "/api/v1/artists/artist", nil},
+		{"PUT", "/api/v1/artistPictures/artist", nil},
+		{"DELETE", "/api/v1/artistPictures/artist", nil},
+		{"POST", "/api/v1/genres", &restApiV1.GenreMeta{Name: "genre"}},
+		{"PUT", "/api/v1/genres/genre", &restApiV1.GenreMeta{Name: "genre"}},
+		{"DELETE", "/api/v1/genres/genre", nil},
+		{"POST", "/api/v1/playlistFolders", &restApiV1.PlaylistFolderMeta{Name: "folder"}},
+		{"PUT", "/api/v1/playlistFolders/folder", &restApiV1.PlaylistFolderMeta{Name: "folder"}},
+		{"DELETE", "/api/v1/playlistFolders/folder", nil},
+		{"POST", "/api/v1/songContents", nil},
+		{"POST", "/api/v1/songContentsForAlbum/album", nil},
+		{"PUT", "/api/v1/songs/song", &restApiV1.SongMeta{Name: "song"}},
+		{"DELETE", "/api/v1/songs/song", nil},
+		{"PUT", "/api/v1/songLyrics/song", nil},
+		{"DELETE", "/api/v1/songLyrics/song", nil},
+	} {
+		ts.expectErrorCode(aliceToken, route.method, route.path, route.body, restApiV1.ForbiddenErrorCode)
+	}
+
+	ts.expectStatus(adminToken, "POST", "/api/v1/albums", &restApiV1.AlbumMeta{Name: "album"}, http.StatusCreated)
+	ts.expectStatus(adminToken, "POST", "/api/v1/artists", &restApiV1.ArtistMeta{Name: "artist"}, http.StatusOK)
+	ts.expectStatus(adminToken, "POST", "/api/v1/genres", &restApiV1.GenreMeta{Name: "genre"}, http.StatusOK)
+	ts.expectStatus(adminToken, "POST", "/api/v1/playlistFolders", &restApiV1.PlaylistFolderMeta{Name: "folder"}, http.StatusCreated)
+}
+
+func TestPersonalReadAuthorization(t *testing.T) {
+	ts := newTestServer(t)
+	_, adminToken := ts.createUser("admin", true)
+	alice, aliceToken := ts.createUser("alice", false)
+	_, bobToken := ts.createUser("bob", false)
+
+	// Only admin can read personal data of another user
+	for _, path := range []string{
+		"/api/v1/stats/" + string(alice.Id),
+		"/api/v1/songPlayStats/" + string(alice.Id),
+		"/api/v1/fileSyncReport/0/" + string(alice.Id),
+	} {
+		ts.expectErrorCode(bobToken, "GET", path, nil, restApiV1.ForbiddenErrorCode)
+		ts.expectStatus(aliceToken, "GET", path, nil, http.StatusOK)
+		ts.expectStatus(adminToken, "GET", path, nil, http.StatusOK)
+	}
+
+	// Only admin can read play events of another user or of every user
+	aliceFilter := &restApiV1.PlayEventFilter{UserId: &alice.Id}
+	ts.expectErrorCode(bobToken, "GET", "/api/v1/plays", aliceFilter, restApiV1.ForbiddenErrorCode)
+	ts.expectErrorCode(aliceToken, "GET", "/api/v1/plays", &restApiV1.PlayEventFilter{}, restApiV1.ForbiddenErrorCode)
+	ts.expectStatus(aliceToken, "GET", "/api/v1/plays", aliceFilter, http.StatusOK)
+	ts.expectStatus(adminToken, "GET", "/api/v1/plays", aliceFilter, http.StatusOK)
+	ts.expectStatus(adminToken, "GET", "/api/v1/plays", &restApiV1.PlayEventFilter{}, http.StatusOK)
+}
@@ -28,6 +28,12 @@ func (s *RestServer) createFavoritePlaylist(w http.ResponseWriter, r *http.Reque
 		s.log.Panicf("Unable to interpret data to create the favorite playlist: %v", err)
 	}
 
+	// Only admin can edit favorites of another user
+	if !isConnectedUserOrAdmin(r, favoritePlaylistMeta.Id.UserId) {
+		s.apiErrorCodeResponse(w, restApiV1.ForbiddenErrorCode)
+		return
+	}
+
 	favoritePlaylist, err := s.store.CreateFavoritePlaylist(nil, &favoritePlaylistMeta, true)
 	if err != nil {
 		s.log.Panicf("Unable to create the favorite playlist: %v", err)
@@ -46,6 +52,12 @@ func (s *RestServer) deleteFavoritePlaylist(w http.ResponseWriter, r *http.Reque
 
 	s.log.Debugf("Delete favorite playlist: %v", favoritePlaylistId)
 
+	// Only admin can edit favorites of another user
+	if !isConnectedUserOrAdmin(r, userId) {
+		s.apiErrorCodeResponse(w, restApiV1.ForbiddenErrorCode)
+		return
+	}
+
 	favoritePlaylist, err := s.store.DeleteFavoritePlaylist(nil, favoritePlaylistId)
 	if err != nil {
 		s.log.Panicf("Unable to delete favorite playlist: %v", err)
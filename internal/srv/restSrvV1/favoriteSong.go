@@ -28,6 +28,12 @@ func (s *RestServer) createFavoriteSong(w http.ResponseWriter, r *http.Request)
 		s.log.Panicf("Unable to interpret data to create the favorite song: %v", err)
 	}
 
+	// Only admin can edit favorites of another user
+	if !isConnectedUserOrAdmin(r, favoriteSongMeta.Id.UserId) {
+		s.apiErrorCodeResponse(w, restApiV1.ForbiddenErrorCode)
+		return
+	}
+
 	favoriteSong, err := s.store.CreateFavoriteSong(nil, &favoriteSongMeta, true)
 	if err != nil {
 		s.log.Panicf("Unable to create the favorite song: %v", err)
@@ -46,6 +52,12 @@ func (s *RestServer) deleteFavoriteSong(w http.ResponseWriter, r *http.Request)
 
 	s.log.Debugf("Delete favorite song: %v", favoriteSongId)
 
+	// Only admin can edit favorites of another user
+	if !isConnectedUserOrAdmin(r, userId) {
+		s.apiErrorCodeResponse(w, restApiV1.ForbiddenErrorCode)
+		return
+	}
+
 	favoriteSong, err := s.store.DeleteFavoriteSong(nil, favoriteSongId)
 	if err != nil {
 		s.log.Panicf("Unable to delete favorite song: %v", err)
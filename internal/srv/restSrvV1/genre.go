@@ -0,0 +1,131 @@
+package restSrvV1
+
+import (
+	"encoding/json"
+	"github.com/gorilla/mux"
+	"github.com/jypelle/mifasol/internal/srv/storeerror"
+	"github.com/jypelle/mifasol/internal/tool"
+	"github.com/jypelle/mifasol/restApiV1"
+	"net/http"
+)
+
+func (s *RestServer) readGenres(w http.ResponseWriter, r *http.Request) {
+	s.log.Debugf("Read genres")
+
+	var genreFilter restApiV1.GenreFilter
+	err := json.NewDecoder(r.Body).Decode(&genreFilter)
+	if err != nil {
+		s.log.Panicf("Unable to interpret data to read the genres: %v", err)
+	}
+
+	genres, nextCursor, totalCount, err := s.store.ReadGenresPage(nil, &genreFilter)
+	if err != nil {
+		if err == storeerror.ErrInvalidPage {
+			s.apiErrorCodeResponse(w, restApiV1.InvalideRequestErrorCode)
+			return
+		}
+		s.log.Panicf("Unable to read genres: %v", err)
+	}
+
+	writePageHeaders(w, nextCursor, totalCount)
+
+	w.WriteHeader(http.StatusCreated)
+	tool.WriteJsonResponse(w, genres)
+}
+
+func (s *RestServer) readGenre(w http.ResponseWriter, r *http.Request) {
+
+	vars := mux.Vars(r)
+	genreId := restApiV1.GenreId(vars["id"])
+
+	s.log.Debugf("Read genre: %s", genreId)
+
+	genre, err := s.store.ReadGenre(nil, genreId)
+	if err != nil {
+		if err == storeerror.ErrNotFound {
+			s.apiErrorCodeResponse(w, restApiV1.NotFoundErrorCode)
+			return
+		}
+		s.log.Panicf("Unable to read genre: %v", err)
+	}
+
+	tool.WriteJsonResponse(w, genre)
+}
+
+func (s *RestServer) createGenre(w http.ResponseWriter, r *http.Request) {
+	s.log.Debugf("Create genre")
+
+	// Only admin can create a genre
+	if !isConnectedUserAdmin(r) {
+		s.apiErrorCodeResponse(w, restApiV1.ForbiddenErrorCode)
+		return
+	}
+
+	var genreMeta restApiV1.GenreMeta
+	err := json.NewDecoder(r.Body).Decode(&genreMeta)
+	if err != nil {
+		s.log.Panicf("Unable to interpret data to create the genre: %v", err)
+	}
+
+	genre, err := s.store.CreateGenre(nil, &genreMeta)
+	if err != nil {
+		s.log.Panicf("Unable to create the genre: %v", err)
+	}
+
+	tool.WriteJsonResponse(w, genre)
+}
+
+func (s *RestServer) updateGenre(w http.ResponseWriter, r *http.Request) {
+
+	vars := mux.Vars(r)
+	genreId := restApiV1.GenreId(vars["id"])
+
+	s.log.Debugf("Update genre: %s", genreId)
+
+	// Only admin can update a genre
+	if !isConnectedUserAdmin(r) {
+		s.apiErrorCodeResponse(w, restApiV1.ForbiddenErrorCode)
+		return
+	}
+
+	var genreMeta restApiV1.GenreMeta
+	err := json.NewDecoder(r.Body).Decode(&genreMeta)
+	if err != nil {
+		s.log.Panicf("Unable to interpret data to update the genre: %v", err)
+	}
+
+	genre, err := s.store.UpdateGenre(nil, genreId, &genreMeta)
+	if err != nil {
+		s.log.Panicf("Unable to update the genre: %v", err)
+	}
+
+	tool.WriteJsonResponse(w, genre)
+
+}
+
+func (s *RestServer) deleteGenre(w http.ResponseWriter, r *http.Request) {
+
+	vars := mux.Vars(r)
+	genreId := restApiV1.GenreId(vars["id"])
+
+	s.log.Debugf("Delete genre: %s", genreId)
+
+	// Only admin can delete a genre
+	if !isConnectedUserAdmin(r) {
+		s.apiErrorCodeResponse(w, restApiV1.ForbiddenErrorCode)
+		return
+	}
+
+	genre, err := s.store.DeleteGenre(nil, genreId)
+	if err != nil {
+		if err == storeerror.ErrDeleteGenreWithSongs {
+			s.apiErrorCodeResponse(w, restApiV1.DeleteGenreWithSongsErrorCode)
+			return
+		}
+
+		s.log.Panicf("Unable to delete genre: %v", err)
+	}
+
+	tool.WriteJsonResponse(w, genre)
+
+}
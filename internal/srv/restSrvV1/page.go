@@ -0,0 +1,15 @@
+package restSrvV1
+
+import (
+	"github.com/jypelle/mifasol/restApiV1"
+	"net/http"
+	"strconv"
+)
+
+// writePageHeaders set the paging headers of a list response
+func writePageHeaders(w http.ResponseWriter, nextCursor string, totalCount int64) {
+	w.Header().Set(restApiV1.TotalCountHeader, strconv.FormatInt(totalCount, 10))
+	if nextCursor != "" {
+		w.Header().Set(restApiV1.NextCursorHeader, nextCursor)
+	}
+}
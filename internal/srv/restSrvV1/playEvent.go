@@ -0,0 +1,90 @@
+package restSrvV1
+
+import (
+	"encoding/json"
+	"github.com/gorilla/mux"
+	"github.com/jypelle/mifasol/internal/srv/storeerror"
+	"github.com/jypelle/mifasol/internal/tool"
+	"github.com/jypelle/mifasol/restApiV1"
+	"net/http"
+)
+
+func (s *RestServer) readPlayEvents(w http.ResponseWriter, r *http.Request) {
+	s.log.Debugf("Read play events")
+
+	var playEventFilter restApiV1.PlayEventFilter
+	err := json.NewDecoder(r.Body).Decode(&playEventFilter)
+	if err != nil {
+		s.log.Panicf("Unable to interpret data to read the play events: %v", err)
+	}
+
+	// Only admin can read play events of another user or of every user
+	if !isConnectedUserAdmin(r) && (playEventFilter.UserId == nil || !isConnectedUserOrAdmin(r, *playEventFilter.UserId)) {
+		s.apiErrorCodeResponse(w, restApiV1.ForbiddenErrorCode)
+		return
+	}
+
+	playEvents, err := s.store.ReadPlayEvents(nil, &playEventFilter)
+	if err != nil {
+		s.log.Panicf("Unable to read play events: %v", err)
+	}
+
+	tool.WriteJsonResponse(w, playEvents)
+}
+
+func (s *RestServer) createPlayEvent(w http.ResponseWriter, r *http.Request) {
+	s.log.Debugf("Create play event")
+
+	var playEventMeta restApiV1.PlayEventMeta
+	err := json.NewDecoder(r.Body).Decode(&playEventMeta)
+	if err != nil {
+		s.log.Panicf("Unable to interpret data to create the play event: %v", err)
+	}
+
+	// Only admin can report plays for another user
+	if !isConnectedUserOrAdmin(r, playEventMeta.UserId) {
+		s.apiErrorCodeResponse(w, restApiV1.ForbiddenErrorCode)
+		return
+	}
+
+	playEvent, err := s.store.CreatePlayEvent(nil, &playEventMeta)
+	if err != nil {
+		if err == storeerror.ErrNotFound {
+			s.apiErrorCodeResponse(w, restApiV1.NotFoundErrorCode)
+			return
+		}
+		if err == storeerror.ErrInvalidPlayEvent {
+			s.apiErrorCodeResponse(w, restApiV1.InvalideRequestErrorCode)
+			return
+		}
+		s.log.Panicf("Unable to create the play event: %v", err)
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	tool.WriteJsonResponse(w, playEvent)
+}
+
+func (s *RestServer) readSongPlayStats(w http.ResponseWriter, r *http.Request) {
+
+	vars := mux.Vars(r)
+	userId := restApiV1.UserId(vars["userId"])
+
+	s.log.Debugf("Read song play stats: %s", userId)
+
+	// Only admin can read song play stats of another user
+	if !isConnectedUserOrAdmin(r, userId) {
+		s.apiErrorCodeResponse(w, restApiV1.ForbiddenErrorCode)
+		return
+	}
+
+	songPlayStats, err := s.store.ReadSongPlayStats(nil, userId)
+	if err != nil {
+		if err == storeerror.ErrNotFound {
+			s.apiErrorCodeResponse(w, restApiV1.NotFoundErrorCode)
+			return
+		}
+		s.log.Panicf("Unable to read song play stats: %v", err)
+	}
+
+	tool.WriteJsonResponse(w, songPlayStats)
+}
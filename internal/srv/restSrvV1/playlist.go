@@ -18,11 +18,17 @@ func (s *RestServer) readPlaylists(w http.ResponseWriter, r *http.Request) {
 		s.log.Panicf("Unable to interpret data to read the playlists: %v", err)
 	}
 
-	playlists, err := s.store.ReadPlaylists(nil, &playlistFilter)
+	playlists, nextCursor, totalCount, err := s.store.ReadPlaylistsPage(nil, &playlistFilter)
 	if err != nil {
+		if err == storeerror.ErrInvalidPage {
+			s.apiErrorCodeResponse(w, restApiV1.InvalideRequestErrorCode)
+			return
+		}
 		s.log.Panicf("Unable to read playlists: %v", err)
 	}
 
+	writePageHeaders(w, nextCursor, totalCount)
+
 	tool.WriteJsonResponse(w, playlists)
 }
 
@@ -55,8 +61,22 @@ func (s *RestServer) createPlaylist(w http.ResponseWriter, r *http.Request) {
 		s.log.Panicf("Unable to interpret data to create the playlist: %v", err)
 	}
 
+	// Only admin can create a playlist without being one of its owners
+	if !isConnectedUserOwnerOrAdmin(r, playlistMeta.OwnerUserIds) {
+		s.apiErrorCodeResponse(w, restApiV1.ForbiddenErrorCode)
+		return
+	}
+
 	playlist, err := s.store.CreatePlaylist(nil, &playlistMeta, true)
 	if err != nil {
+		if err == storeerror.ErrInvalidSmartPlaylist {
+			s.apiErrorCodeResponse(w, restApiV1.InvalidSmartPlaylistErrorCode)
+			return
+		}
+		if err == storeerror.ErrInvalidPlaylistFolder {
+			s.apiErrorCodeResponse(w, restApiV1.InvalidPlaylistFolderErrorCode)
+			return
+		}
 		s.log.Panicf("Unable to create the playlist: %v", err)
 	}
 
@@ -77,8 +97,20 @@ func (s *RestServer) updatePlaylist(w http.ResponseWriter, r *http.Request) {
 		s.log.Panicf("Unable to interpret data to update the playlist: %v", err)
 	}
 
+	if !s.checkPlaylistOwnership(w, r, playlistId) {
+		return
+	}
+
 	playlist, err := s.store.UpdatePlaylist(nil, playlistId, &playlistMeta, true)
 	if err != nil {
+		if err == storeerror.ErrInvalidSmartPlaylist {
+			s.apiErrorCodeResponse(w, restApiV1.InvalidSmartPlaylistErrorCode)
+			return
+		}
+		if err == storeerror.ErrInvalidPlaylistFolder {
+			s.apiErrorCodeResponse(w, restApiV1.InvalidPlaylistFolderErrorCode)
+			return
+		}
 		s.log.Panicf("Unable to update the playlist: %v", err)
 	}
 
@@ -93,6 +125,10 @@ func (s *RestServer) deletePlaylist(w http.ResponseWriter, r *http.Request) {
 
 	s.log.Debugf("Delete playlist: %s", playlistId)
 
+	if !s.checkPlaylistOwnership(w, r, playlistId) {
+		return
+	}
+
 	playlist, err := s.store.DeletePlaylist(nil, playlistId)
 	if err != nil {
 		s.log.Panicf("Unable to delete playlist: %v", err)
@@ -101,3 +137,23 @@ func (s *RestServer) deletePlaylist(w http.ResponseWriter, r *http.Request) {
 	tool.WriteJsonResponse(w, playlist)
 
 }
+
+// checkPlaylistOwnership check that the connected user is an administrator or an owner of the playlist, writing the error response otherwise
+func (s *RestServer) checkPlaylistOwnership(w http.ResponseWriter, r *http.Request, playlistId restApiV1.PlaylistId) bool {
+	playlist, err := s.store.ReadPlaylist(nil, playlistId)
+	if err != nil {
+		if err == storeerror.ErrNotFound {
+			s.apiErrorCodeResponse(w, restApiV1.NotFoundErrorCode)
+			return false
+		}
+		s.log.Panicf("Unable to read playlist: %v", err)
+	}
+
+	// Only admin or playlist owner can edit or delete a playlist
+	if !isConnectedUserOwnerOrAdmin(r, playlist.OwnerUserIds) {
+		s.apiErrorCodeResponse(w, restApiV1.ForbiddenErrorCode)
+		return false
+	}
+
+	return true
+}
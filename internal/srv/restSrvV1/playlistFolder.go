@@ -0,0 +1,140 @@
+package restSrvV1
+
+import (
+	"encoding/json"
+	"github.com/gorilla/mux"
+	"github.com/jypelle/mifasol/internal/srv/storeerror"
+	"github.com/jypelle/mifasol/internal/tool"
+	"github.com/jypelle/mifasol/restApiV1"
+	"net/http"
+)
+
+func (s *RestServer) readPlaylistFolders(w http.ResponseWriter, r *http.Request) {
+	s.log.Debugf("Read playlist folders")
+
+	var playlistFolderFilter restApiV1.PlaylistFolderFilter
+	err := json.NewDecoder(r.Body).Decode(&playlistFolderFilter)
+	if err != nil {
+		s.log.Panicf("Unable to interpret data to read the playlist folders: %v", err)
+	}
+
+	playlistFolders, err := s.store.ReadPlaylistFolders(nil, &playlistFolderFilter)
+	if err != nil {
+		s.log.Panicf("Unable to read playlist folders: %v", err)
+	}
+
+	tool.WriteJsonResponse(w, playlistFolders)
+}
+
+func (s *RestServer) readPlaylistFolder(w http.ResponseWriter, r *http.Request) {
+
+	vars := mux.Vars(r)
+	playlistFolderId := restApiV1.PlaylistFolderId(vars["id"])
+
+	s.log.Debugf("Read playlist folder: %s", playlistFolderId)
+
+	playlistFolder, err := s.store.ReadPlaylistFolder(nil, playlistFolderId)
+	if err != nil {
+		if err == storeerror.ErrNotFound {
+			s.apiErrorCodeResponse(w, restApiV1.NotFoundErrorCode)
+			return
+		}
+		s.log.Panicf("Unable to read playlist folder: %v", err)
+	}
+
+	tool.WriteJsonResponse(w, playlistFolder)
+}
+
+func (s *RestServer) createPlaylistFolder(w http.ResponseWriter, r *http.Request) {
+	s.log.Debugf("Create playlist folder")
+
+	// Only admin can create a playlist folder
+	if !isConnectedUserAdmin(r) {
+		s.apiErrorCodeResponse(w, restApiV1.ForbiddenErrorCode)
+		return
+	}
+
+	var playlistFolderMeta restApiV1.PlaylistFolderMeta
+	err := json.NewDecoder(r.Body).Decode(&playlistFolderMeta)
+	if err != nil {
+		s.log.Panicf("Unable to interpret data to create the playlist folder: %v", err)
+	}
+
+	playlistFolder, err := s.store.CreatePlaylistFolder(nil, &playlistFolderMeta)
+	if err != nil {
+		if err == storeerror.ErrInvalidPlaylistFolder {
+			s.apiErrorCodeResponse(w, restApiV1.InvalidPlaylistFolderErrorCode)
+			return
+		}
+		s.log.Panicf("Unable to create the playlist folder: %v", err)
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	tool.WriteJsonResponse(w, playlistFolder)
+}
+
+func (s *RestServer) updatePlaylistFolder(w http.ResponseWriter, r *http.Request) {
+
+	vars := mux.Vars(r)
+	playlistFolderId := restApiV1.PlaylistFolderId(vars["id"])
+
+	s.log.Debugf("Update playlist folder: %s", playlistFolderId)
+
+	// Only admin can update a playlist folder
+	if !isConnectedUserAdmin(r) {
+		s.apiErrorCodeResponse(w, restApiV1.ForbiddenErrorCode)
+		return
+	}
+
+	var playlistFolderMeta restApiV1.PlaylistFolderMeta
+	err := json.NewDecoder(r.Body).Decode(&playlistFolderMeta)
+	if err != nil {
+		s.log.Panicf("Unable to interpret data to update the playlist folder: %v", err)
+	}
+
+	playlistFolder, err := s.store.UpdatePlaylistFolder(nil, playlistFolderId, &playlistFolderMeta)
+	if err != nil {
+		if err == storeerror.ErrNotFound {
+			s.apiErrorCodeResponse(w, restApiV1.NotFoundErrorCode)
+			return
+		}
+		if err == storeerror.ErrInvalidPlaylistFolder {
+			s.apiErrorCodeResponse(w, restApiV1.InvalidPlaylistFolderErrorCode)
+			return
+		}
+		s.log.Panicf("Unable to update the playlist folder: %v", err)
+	}
+
+	tool.WriteJsonResponse(w, playlistFolder)
+
+}
+
+func (s *RestServer) deletePlaylistFolder(w http.ResponseWriter, r *http.Request) {
+
+	vars := mux.Vars(r)
+	playlistFolderId := restApiV1.PlaylistFolderId(vars["id"])
+
+	s.log.Debugf("Delete playlist folder: %s", playlistFolderId)
+
+	// Only admin can delete a playlist folder
+	if !isConnectedUserAdmin(r) {
+		s.apiErrorCodeResponse(w, restApiV1.ForbiddenErrorCode)
+		return
+	}
+
+	playlistFolder, err := s.store.DeletePlaylistFolder(nil, playlistFolderId)
+	if err != nil {
+		if err == storeerror.ErrNotFound {
+			s.apiErrorCodeResponse(w, restApiV1.NotFoundErrorCode)
+			return
+		}
+		if err == storeerror.ErrDeletePlaylistFolderWithContent {
+			s.apiErrorCodeResponse(w, restApiV1.DeletePlaylistFolderWithContentErrorCode)
+			return
+		}
+		s.log.Panicf("Unable to delete playlist folder: %v", err)
+	}
+
+	tool.WriteJsonResponse(w, playlistFolder)
+
+}
@@ -1,6 +1,7 @@
 package restSrvV1
 
 import (
+	"context"
 	"github.com/gorilla/mux"
 	"github.com/jypelle/mifasol/internal/srv/store"
 	"github.com/jypelle/mifasol/internal/srv/storeerror"
@@ -11,6 +12,11 @@ import (
 	"sync"
 )
 
+type contextKey int
+
+// contextKeyUser is the request context key of the connected user
+const contextKeyUser contextKey = iota
+
 type RestServer struct {
 	store     *store.Store
 	subRouter *mux.Router
@@ -37,6 +43,10 @@ func NewRestServer(store *store.Store, subRouter *mux.Router) *RestServer {
 	restServer.subRouter.HandleFunc("/albums/{id}", restServer.updateAlbum).Methods("PUT")
 	restServer.subRouter.HandleFunc("/albums/{id}", restServer.deleteAlbum).Methods("DELETE")
 
+	restServer.subRouter.HandleFunc("/albumCovers/{id}", restServer.readAlbumCover).Methods("GET")
+	restServer.subRouter.HandleFunc("/albumCovers/{id}", restServer.updateAlbumCover).Methods("PUT")
+	restServer.subRouter.HandleFunc("/albumCovers/{id}", restServer.deleteAlbumCover).Methods("DELETE")
+
 	restServer.subRouter.HandleFunc("/artists", restServer.readArtists).Methods("GET")
 	restServer.subRouter.HandleFunc("/artists", restServer.readArtists).Methods("POST").Headers("x-http-method-override", "GET")
 	restServer.subRouter.HandleFunc("/artists/{id}", restServer.readArtist).Methods("GET")
@@ -44,6 +54,17 @@ func NewRestServer(store *store.Store, subRouter *mux.Router) *RestServer {
 	restServer.subRouter.HandleFunc("/artists/{id}", restServer.updateArtist).Methods("PUT")
 	restServer.subRouter.HandleFunc("/artists/{id}", restServer.deleteArtist).Methods("DELETE")
 
+	restServer.subRouter.HandleFunc("/artistPictures/{id}", restServer.readArtistPicture).Methods("GET")
+	restServer.subRouter.HandleFunc("/artistPictures/{id}", restServer.updateArtistPicture).Methods("PUT")
+	restServer.subRouter.HandleFunc("/artistPictures/{id}", restServer.deleteArtistPicture).Methods("DELETE")
+
+	restServer.subRouter.HandleFunc("/genres", restServer.readGenres).Methods("GET")
+	restServer.subRouter.HandleFunc("/genres", restServer.readGenres).Methods("POST").Headers("x-http-method-override", "GET")
+	restServer.subRouter.HandleFunc("/genres/{id}", restServer.readGenre).Methods("GET")
+	restServer.subRouter.HandleFunc("/genres", restServer.createGenre).Methods("POST")
+	restServer.subRouter.HandleFunc("/genres/{id}", restServer.updateGenre).Methods("PUT")
+	restServer.subRouter.HandleFunc("/genres/{id}", restServer.deleteGenre).Methods("DELETE")
+
 	restServer.subRouter.HandleFunc("/playlists", restServer.readPlaylists).Methods("GET")
 	restServer.subRouter.HandleFunc("/playlists", restServer.readPlaylists).Methods("POST").Headers("x-http-method-override", "GET")
 	restServer.subRouter.HandleFunc("/playlists/{id}", restServer.readPlaylist).Methods("GET")
@@ -51,16 +72,30 @@ func NewRestServer(store *store.Store, subRouter *mux.Router) *RestServer {
 	restServer.subRouter.HandleFunc("/playlists/{id}", restServer.updatePlaylist).Methods("PUT")
 	restServer.subRouter.HandleFunc("/playlists/{id}", restServer.deletePlaylist).Methods("DELETE")
 
+	restServer.subRouter.HandleFunc("/playlistFolders", restServer.readPlaylistFolders).Methods("GET")
+	restServer.subRouter.HandleFunc("/playlistFolders", restServer.readPlaylistFolders).Methods("POST").Headers("x-http-method-override", "GET")
+	restServer.subRouter.HandleFunc("/playlistFolders/{id}", restServer.readPlaylistFolder).Methods("GET")
+	restServer.subRouter.HandleFunc("/playlistFolders", restServer.createPlaylistFolder).Methods("POST")
+	restServer.subRouter.HandleFunc("/playlistFolders/{id}", restServer.updatePlaylistFolder).Methods("PUT")
+	restServer.subRouter.HandleFunc("/playlistFolders/{id}", restServer.deletePlaylistFolder).Methods("DELETE")
+
 	restServer.subRouter.HandleFunc("/songs", restServer.readSongs).Methods("GET")
 	restServer.subRouter.HandleFunc("/songs", restServer.readSongs).Methods("POST").Headers("x-http-method-override", "GET")
 	restServer.subRouter.HandleFunc("/songs/{id}", restServer.readSong).Methods("GET")
 	restServer.subRouter.HandleFunc("/songContents/{id}", restServer.readSongContent).Methods("GET")
 	restServer.subRouter.HandleFunc("/songContents", restServer.createSongContent).Methods("POST")
+	restServer.subRouter.HandleFunc("/transcodingProfiles", restServer.readTranscodingProfiles).Methods("GET")
 	restServer.subRouter.HandleFunc("/songContentsForAlbum/{id}", restServer.createSongContentForAlbum).Methods("POST")
 	restServer.subRouter.HandleFunc("/songWithContents", restServer.createSongWithContent).Methods("POST")
 	restServer.subRouter.HandleFunc("/songs/{id}", restServer.updateSong).Methods("PUT")
 	restServer.subRouter.HandleFunc("/songs/{id}", restServer.deleteSong).Methods("DELETE")
 
+	restServer.subRouter.HandleFunc("/songLyrics/{id}", restServer.readSongLyrics).Methods("GET")
+	restServer.subRouter.HandleFunc("/songLyrics/{id}", restServer.updateSongLyrics).Methods("PUT")
+	restServer.subRouter.HandleFunc("/songLyrics/{id}", restServer.deleteSongLyrics).Methods("DELETE")
+
+	restServer.subRouter.HandleFunc("/songWaveforms/{id}", restServer.readSongWaveform).Methods("GET")
+
 	restServer.subRouter.HandleFunc("/users", restServer.readUsers).Methods("GET")
 	restServer.subRouter.HandleFunc("/users", restServer.readUsers).Methods("POST").Headers("x-http-method-override", "GET")
 	restServer.subRouter.HandleFunc("/users/{id}", restServer.readUser).Methods("GET")
@@ -78,6 +113,23 @@ func NewRestServer(store *store.Store, subRouter *mux.Router) *RestServer {
 	restServer.subRouter.HandleFunc("/favoriteSongs", restServer.createFavoriteSong).Methods("POST")
 	restServer.subRouter.HandleFunc("/favoriteSongs/{userId}/{songId}", restServer.deleteFavoriteSong).Methods("DELETE")
 
+	restServer.subRouter.HandleFunc("/songRatings", restServer.readSongRatings).Methods("GET")
+	restServer.subRouter.HandleFunc("/songRatings", restServer.readSongRatings).Methods("POST").Headers("x-http-method-override", "GET")
+	restServer.subRouter.HandleFunc("/songRatings", restServer.updateSongRating).Methods("PUT")
+	restServer.subRouter.HandleFunc("/songRatings/{userId}/{songId}", restServer.deleteSongRating).Methods("DELETE")
+
+	restServer.subRouter.HandleFunc("/plays", restServer.readPlayEvents).Methods("GET")
+	restServer.subRouter.HandleFunc("/plays", restServer.readPlayEvents).Methods("POST").Headers("x-http-method-override", "GET")
+	restServer.subRouter.HandleFunc("/plays", restServer.createPlayEvent).Methods("POST")
+	restServer.subRouter.HandleFunc("/songPlayStats/{userId}", restServer.readSongPlayStats).Methods("GET")
+	restServer.subRouter.HandleFunc("/stats/{userId}", restServer.readUserStats).Methods("GET")
+	restServer.subRouter.HandleFunc("/nowPlaying", restServer.submitNowPlaying).Methods("POST")
+	restServer.subRouter.HandleFunc("/scrobblings/{userId}", restServer.readScrobbling).Methods("GET")
+	restServer.subRouter.HandleFunc("/scrobblings/{userId}", restServer.updateScrobbling).Methods("PUT")
+	restServer.subRouter.HandleFunc("/scrobblings/{userId}", restServer.deleteScrobbling).Methods("DELETE")
+
+	restServer.subRouter.HandleFunc("/search", restServer.search).Methods("GET")
+
 	restServer.subRouter.HandleFunc("/syncReport/{fromTs}", restServer.readSyncReport).Methods("GET")
 	restServer.subRouter.HandleFunc("/fileSyncReport/{fromTs}/{userId}", restServer.readFileSyncReport).Methods("GET")
 
@@ -137,7 +189,7 @@ func NewRestServer(store *store.Store, subRouter *mux.Router) *RestServer {
 					return
 				}
 				restServer.log.Debugln("User: " + user.Name)
-				//			context.WithValue(r.Context(), contextKeyUser, user)
+				r = r.WithContext(context.WithValue(r.Context(), contextKeyUser, user))
 
 			}
 
@@ -147,3 +199,33 @@ func NewRestServer(store *store.Store, subRouter *mux.Router) *RestServer {
 
 	return restServer
 }
+
+// connectedUser return the user authenticated by the request token
+func connectedUser(r *http.Request) *restApiV1.User {
+	return r.Context().Value(contextKeyUser).(*restApiV1.User)
+}
+
+// isConnectedUserAdmin check if the user authenticated by the request token is an administrator
+func isConnectedUserAdmin(r *http.Request) bool {
+	return connectedUser(r).AdminFg
+}
+
+// isConnectedUserOrAdmin check if the user authenticated by the request token is userId or an administrator
+func isConnectedUserOrAdmin(r *http.Request, userId restApiV1.UserId) bool {
+	user := connectedUser(r)
+	return user.Id == userId || user.AdminFg
+}
+
+// isConnectedUserOwnerOrAdmin check if the user authenticated by the request token is one of the owners or an administrator
+func isConnectedUserOwnerOrAdmin(r *http.Request, ownerUserIds []restApiV1.UserId) bool {
+	user := connectedUser(r)
+	if user.AdminFg {
+		return true
+	}
+	for _, ownerUserId := range ownerUserIds {
+		if ownerUserId == user.Id {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,122 @@
+package restSrvV1
+
+import (
+	"encoding/json"
+	"github.com/gorilla/mux"
+	"github.com/jypelle/mifasol/internal/srv/storeerror"
+	"github.com/jypelle/mifasol/internal/tool"
+	"github.com/jypelle/mifasol/restApiV1"
+	"net/http"
+)
+
+func (s *RestServer) readScrobbling(w http.ResponseWriter, r *http.Request) {
+
+	vars := mux.Vars(r)
+	userId := restApiV1.UserId(vars["userId"])
+
+	s.log.Debugf("Read scrobbling: %s", userId)
+
+	// Only admin can manage the scrobbling of another user
+	if !isConnectedUserOrAdmin(r, userId) {
+		s.apiErrorCodeResponse(w, restApiV1.ForbiddenErrorCode)
+		return
+	}
+
+	scrobbling, err := s.store.ReadScrobbling(nil, userId)
+	if err != nil {
+		if err == storeerror.ErrNotFound {
+			s.apiErrorCodeResponse(w, restApiV1.NotFoundErrorCode)
+			return
+		}
+		s.log.Panicf("Unable to read scrobbling: %v", err)
+	}
+
+	tool.WriteJsonResponse(w, scrobbling)
+}
+
+func (s *RestServer) updateScrobbling(w http.ResponseWriter, r *http.Request) {
+
+	vars := mux.Vars(r)
+	userId := restApiV1.UserId(vars["userId"])
+
+	s.log.Debugf("Update scrobbling: %s", userId)
+
+	// Only admin can manage the scrobbling of another user
+	if !isConnectedUserOrAdmin(r, userId) {
+		s.apiErrorCodeResponse(w, restApiV1.ForbiddenErrorCode)
+		return
+	}
+
+	var scrobblingMeta restApiV1.ScrobblingMeta
+	err := json.NewDecoder(r.Body).Decode(&scrobblingMeta)
+	if err != nil {
+		s.log.Panicf("Unable to interpret data to update the scrobbling: %v", err)
+	}
+
+	scrobbling, err := s.store.UpdateScrobbling(nil, userId, &scrobblingMeta)
+	if err != nil {
+		if err == storeerror.ErrNotFound {
+			s.apiErrorCodeResponse(w, restApiV1.NotFoundErrorCode)
+			return
+		}
+		if err == storeerror.ErrInvalidScrobbling {
+			s.apiErrorCodeResponse(w, restApiV1.InvalidScrobblingErrorCode)
+			return
+		}
+		s.log.Panicf("Unable to update the scrobbling: %v", err)
+	}
+
+	tool.WriteJsonResponse(w, scrobbling)
+}
+
+func (s *RestServer) deleteScrobbling(w http.ResponseWriter, r *http.Request) {
+
+	vars := mux.Vars(r)
+	userId := restApiV1.UserId(vars["userId"])
+
+	s.log.Debugf("Delete scrobbling: %s", userId)
+
+	// Only admin can manage the scrobbling of another user
+	if !isConnectedUserOrAdmin(r, userId) {
+		s.apiErrorCodeResponse(w, restApiV1.ForbiddenErrorCode)
+		return
+	}
+
+	scrobbling, err := s.store.DeleteScrobbling(nil, userId)
+	if err != nil {
+		if err == storeerror.ErrNotFound {
+			s.apiErrorCodeResponse(w, restApiV1.NotFoundErrorCode)
+			return
+		}
+		s.log.Panicf("Unable to delete scrobbling: %v", err)
+	}
+
+	tool.WriteJsonResponse(w, scrobbling)
+}
+
+func (s *RestServer) submitNowPlaying(w http.ResponseWriter, r *http.Request) {
+	s.log.Debugf("Submit now playing")
+
+	var nowPlayingMeta restApiV1.NowPlayingMeta
+	err := json.NewDecoder(r.Body).Decode(&nowPlayingMeta)
+	if err != nil {
+		s.log.Panicf("Unable to interpret data to submit the now playing song: %v", err)
+	}
+
+	// Only admin can report plays for another user
+	if !isConnectedUserOrAdmin(r, nowPlayingMeta.UserId) {
+		s.apiErrorCodeResponse(w, restApiV1.ForbiddenErrorCode)
+		return
+	}
+
+	err = s.store.SubmitNowPlaying(nil, &nowPlayingMeta)
+	if err != nil {
+		if err == storeerror.ErrNotFound {
+			s.apiErrorCodeResponse(w, restApiV1.NotFoundErrorCode)
+			return
+		}
+		s.log.Panicf("Unable to submit the now playing song: %v", err)
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
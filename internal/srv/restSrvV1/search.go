@@ -0,0 +1,40 @@
+package restSrvV1
+
+import (
+	"github.com/jypelle/mifasol/internal/tool"
+	"github.com/jypelle/mifasol/restApiV1"
+	"net/http"
+	"strconv"
+)
+
+const (
+	searchDefaultLimit = 50
+	searchMaxLimit     = 500
+)
+
+func (s *RestServer) search(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query().Get("q")
+
+	s.log.Debugf("Search: %s", query)
+
+	limit := searchDefaultLimit
+	if rawLimit := r.URL.Query().Get("limit"); rawLimit != "" {
+		var err error
+		limit, err = strconv.Atoi(rawLimit)
+		if err != nil || limit <= 0 {
+			s.log.Warningf("Unable to interpret limit: %s", rawLimit)
+			s.apiErrorCodeResponse(w, restApiV1.InvalideRequestErrorCode)
+			return
+		}
+		if limit > searchMaxLimit {
+			limit = searchMaxLimit
+		}
+	}
+
+	searchResults, err := s.store.Search(nil, query, limit)
+	if err != nil {
+		s.log.Panicf("Unable to search: %v", err)
+	}
+
+	tool.WriteJsonResponse(w, searchResults)
+}
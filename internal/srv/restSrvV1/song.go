@@ -7,6 +7,7 @@ import (
 	"github.com/jypelle/mifasol/internal/tool"
 	"github.com/jypelle/mifasol/restApiV1"
 	"net/http"
+	"os"
 	"time"
 )
 
@@ -19,11 +20,17 @@ func (s *RestServer) readSongs(w http.ResponseWriter, r *http.Request) {
 		s.log.Panicf("Unable to interpret data to read the songs: %v", err)
 	}
 
-	songs, err := s.store.ReadSongs(nil, &songFilter)
+	songs, nextCursor, totalCount, err := s.store.ReadSongsPage(nil, &songFilter)
 	if err != nil {
+		if err == storeerror.ErrInvalidPage {
+			s.apiErrorCodeResponse(w, restApiV1.InvalideRequestErrorCode)
+			return
+		}
 		s.log.Panicf("Unable to read songs: %v", err)
 	}
 
+	writePageHeaders(w, nextCursor, totalCount)
+
 	tool.WriteJsonResponse(w, songs)
 }
 
@@ -62,26 +69,54 @@ func (s *RestServer) readSongContent(w http.ResponseWriter, r *http.Request) {
 		s.log.Panicf("Unable to read song: %v", err)
 	}
 
-	songContent, err := s.store.ReadSongContent(song)
+	// Stream the original content or its transcoding with the requested profile
+	var songContent *os.File
+	format := song.Format
+	profileName := r.URL.Query().Get(restApiV1.TranscodingProfileParam)
+	if profileName == "" {
+		songContent, err = s.store.ReadSongContent(song)
+	} else {
+		songContent, format, err = s.store.ReadSongTranscodedContent(song, profileName)
+	}
 	if err != nil {
 		if err == storeerror.ErrNotFound {
 			s.apiErrorCodeResponse(w, restApiV1.NotFoundErrorCode)
 			return
 		}
+		if err == storeerror.ErrUnknownTranscoding {
+			s.apiErrorCodeResponse(w, restApiV1.UnknownTranscodingProfileErrorCode)
+			return
+		}
 		s.log.Panicf("Unable to read song content: %v", err)
 	}
 
-	w.Header().Set("Content-Type", song.Format.MimeType())
+	w.Header().Set("Content-Type", format.MimeType())
 	http.ServeContent(w, r, "", time.Unix(0, song.UpdateTs), songContent)
 	songContent.Close()
 }
 
+func (s *RestServer) readTranscodingProfiles(w http.ResponseWriter, r *http.Request) {
+	s.log.Debugf("Read transcoding profiles")
+
+	tool.WriteJsonResponse(w, s.store.ReadTranscodingProfiles())
+}
+
 func (s *RestServer) createSongContent(w http.ResponseWriter, r *http.Request) {
 	s.log.Debugf("Create song from raw content")
 
-	song, err := s.store.CreateSongFromRawContent(nil, r.Body, restApiV1.UnknownAlbumId)
+	// Only admin can upload a song
+	if !isConnectedUserAdmin(r) {
+		s.apiErrorCodeResponse(w, restApiV1.ForbiddenErrorCode)
+		return
+	}
+
+	song, err := s.store.CreateSongFromRawContent(nil, r.Body, restApiV1.UnknownAlbumId, connectedUser(r).Id)
 
 	if err != nil {
+		if err == storeerror.ErrDuplicateSong {
+			s.apiErrorResponse(w, restApiV1.ApiError{ErrorCode: restApiV1.DuplicateSongErrorCode, ErrorDescription: "Song already present: " + string(song.Id)})
+			return
+		}
 		s.log.Panicf("Unable to create the song: %v", err)
 	}
 
@@ -92,12 +127,22 @@ func (s *RestServer) createSongContent(w http.ResponseWriter, r *http.Request) {
 func (s *RestServer) createSongContentForAlbum(w http.ResponseWriter, r *http.Request) {
 	s.log.Debugf("Create song from raw content and try to link it to a specific albumId")
 
+	// Only admin can upload a song
+	if !isConnectedUserAdmin(r) {
+		s.apiErrorCodeResponse(w, restApiV1.ForbiddenErrorCode)
+		return
+	}
+
 	vars := mux.Vars(r)
 	lastAlbumId := restApiV1.AlbumId(vars["id"])
 
-	song, err := s.store.CreateSongFromRawContent(nil, r.Body, lastAlbumId)
+	song, err := s.store.CreateSongFromRawContent(nil, r.Body, lastAlbumId, connectedUser(r).Id)
 
 	if err != nil {
+		if err == storeerror.ErrDuplicateSong {
+			s.apiErrorResponse(w, restApiV1.ApiError{ErrorCode: restApiV1.DuplicateSongErrorCode, ErrorDescription: "Song already present: " + string(song.Id)})
+			return
+		}
 		s.log.Panicf("Unable to create the song: %v", err)
 	}
 
@@ -117,6 +162,12 @@ func (s *RestServer) updateSong(w http.ResponseWriter, r *http.Request) {
 
 	s.log.Debugf("Update song: %s", songId)
 
+	// Only admin can update a song
+	if !isConnectedUserAdmin(r) {
+		s.apiErrorCodeResponse(w, restApiV1.ForbiddenErrorCode)
+		return
+	}
+
 	var songMeta restApiV1.SongMeta
 	err := json.NewDecoder(r.Body).Decode(&songMeta)
 	if err != nil {
@@ -139,6 +190,12 @@ func (s *RestServer) deleteSong(w http.ResponseWriter, r *http.Request) {
 
 	s.log.Debugf("Delete song: %s", songId)
 
+	// Only admin can delete a song
+	if !isConnectedUserAdmin(r) {
+		s.apiErrorCodeResponse(w, restApiV1.ForbiddenErrorCode)
+		return
+	}
+
 	song, err := s.store.DeleteSong(nil, songId)
 	if err != nil {
 		s.log.Panicf("Unable to delete song: %v", err)
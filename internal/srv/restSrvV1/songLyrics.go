@@ -0,0 +1,97 @@
+package restSrvV1
+
+import (
+	"errors"
+	"github.com/gorilla/mux"
+	"github.com/jypelle/mifasol/internal/srv/storeerror"
+	"github.com/jypelle/mifasol/internal/tool"
+	"github.com/jypelle/mifasol/restApiV1"
+	"io/ioutil"
+	"net/http"
+)
+
+// maxLyricsSize is the maximum size of uploaded song lyrics
+const maxLyricsSize = 1 << 20
+
+func (s *RestServer) readSongLyrics(w http.ResponseWriter, r *http.Request) {
+
+	vars := mux.Vars(r)
+	songId := restApiV1.SongId(vars["id"])
+
+	s.log.Debugf("Read song lyrics: %s", songId)
+
+	songLyrics, err := s.store.ReadSongLyrics(nil, songId)
+	if err != nil {
+		if err == storeerror.ErrNotFound {
+			s.apiErrorCodeResponse(w, restApiV1.NotFoundErrorCode)
+			return
+		}
+		s.log.Panicf("Unable to read song lyrics: %v", err)
+	}
+
+	tool.WriteJsonResponse(w, songLyrics)
+}
+
+func (s *RestServer) updateSongLyrics(w http.ResponseWriter, r *http.Request) {
+
+	vars := mux.Vars(r)
+	songId := restApiV1.SongId(vars["id"])
+
+	s.log.Debugf("Update song lyrics: %s", songId)
+
+	// Only admin can update song lyrics
+	if !isConnectedUserAdmin(r) {
+		s.apiErrorCodeResponse(w, restApiV1.ForbiddenErrorCode)
+		return
+	}
+
+	content, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, maxLyricsSize))
+	if err != nil {
+		var maxBytesError *http.MaxBytesError
+		if errors.As(err, &maxBytesError) {
+			s.apiErrorCodeResponse(w, restApiV1.InvalidSongLyricsErrorCode)
+			return
+		}
+		s.log.Panicf("Unable to read song lyrics content: %v", err)
+	}
+
+	song, err := s.store.UpdateSongLyrics(nil, songId, content)
+	if err != nil {
+		if err == storeerror.ErrNotFound {
+			s.apiErrorCodeResponse(w, restApiV1.NotFoundErrorCode)
+			return
+		}
+		if err == storeerror.ErrInvalidSongLyrics {
+			s.apiErrorCodeResponse(w, restApiV1.InvalidSongLyricsErrorCode)
+			return
+		}
+		s.log.Panicf("Unable to update the song lyrics: %v", err)
+	}
+
+	tool.WriteJsonResponse(w, song)
+}
+
+func (s *RestServer) deleteSongLyrics(w http.ResponseWriter, r *http.Request) {
+
+	vars := mux.Vars(r)
+	songId := restApiV1.SongId(vars["id"])
+
+	s.log.Debugf("Delete song lyrics: %s", songId)
+
+	// Only admin can delete song lyrics
+	if !isConnectedUserAdmin(r) {
+		s.apiErrorCodeResponse(w, restApiV1.ForbiddenErrorCode)
+		return
+	}
+
+	song, err := s.store.DeleteSongLyrics(nil, songId)
+	if err != nil {
+		if err == storeerror.ErrNotFound {
+			s.apiErrorCodeResponse(w, restApiV1.NotFoundErrorCode)
+			return
+		}
+		s.log.Panicf("Unable to delete the song lyrics: %v", err)
+	}
+
+	tool.WriteJsonResponse(w, song)
+}
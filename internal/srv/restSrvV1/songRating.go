@@ -0,0 +1,79 @@
+package restSrvV1
+
+import (
+	"encoding/json"
+	"github.com/gorilla/mux"
+	"github.com/jypelle/mifasol/internal/srv/storeerror"
+	"github.com/jypelle/mifasol/internal/tool"
+	"github.com/jypelle/mifasol/restApiV1"
+	"net/http"
+)
+
+func (s *RestServer) readSongRatings(w http.ResponseWriter, r *http.Request) {
+	s.log.Debugf("Read song ratings")
+
+	songRatings, err := s.store.ReadSongRatings(nil, &restApiV1.SongRatingFilter{})
+	if err != nil {
+		s.log.Panicf("Unable to read song ratings: %v", err)
+	}
+
+	tool.WriteJsonResponse(w, songRatings)
+}
+
+func (s *RestServer) updateSongRating(w http.ResponseWriter, r *http.Request) {
+	s.log.Debugf("Update song rating")
+
+	var songRatingMeta restApiV1.SongRatingMeta
+	err := json.NewDecoder(r.Body).Decode(&songRatingMeta)
+	if err != nil {
+		s.log.Panicf("Unable to interpret data to update the song rating: %v", err)
+	}
+
+	// Only admin can rate songs for another user
+	if !isConnectedUserOrAdmin(r, songRatingMeta.Id.UserId) {
+		s.apiErrorCodeResponse(w, restApiV1.ForbiddenErrorCode)
+		return
+	}
+
+	songRating, err := s.store.UpdateSongRating(nil, &songRatingMeta)
+	if err != nil {
+		switch err {
+		case storeerror.ErrNotFound:
+			s.apiErrorCodeResponse(w, restApiV1.NotFoundErrorCode)
+			return
+		case storeerror.ErrInvalidSongRating:
+			s.apiErrorCodeResponse(w, restApiV1.InvalidSongRatingErrorCode)
+			return
+		}
+		s.log.Panicf("Unable to update the song rating: %v", err)
+	}
+
+	tool.WriteJsonResponse(w, songRating)
+}
+
+func (s *RestServer) deleteSongRating(w http.ResponseWriter, r *http.Request) {
+
+	vars := mux.Vars(r)
+	userId := restApiV1.UserId(vars["userId"])
+	songId := restApiV1.SongId(vars["songId"])
+	songRatingId := restApiV1.SongRatingId{UserId: userId, SongId: songId}
+
+	s.log.Debugf("Delete song rating: %v", songRatingId)
+
+	// Only admin can rate songs for another user
+	if !isConnectedUserOrAdmin(r, userId) {
+		s.apiErrorCodeResponse(w, restApiV1.ForbiddenErrorCode)
+		return
+	}
+
+	songRating, err := s.store.DeleteSongRating(nil, songRatingId)
+	if err != nil {
+		if err == storeerror.ErrNotFound {
+			s.apiErrorCodeResponse(w, restApiV1.NotFoundErrorCode)
+			return
+		}
+		s.log.Panicf("Unable to delete song rating: %v", err)
+	}
+
+	tool.WriteJsonResponse(w, songRating)
+}
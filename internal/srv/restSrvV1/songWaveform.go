@@ -0,0 +1,51 @@
+package restSrvV1
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/gorilla/mux"
+	"github.com/jypelle/mifasol/internal/srv/storeerror"
+	"github.com/jypelle/mifasol/restApiV1"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+func (s *RestServer) readSongWaveform(w http.ResponseWriter, r *http.Request) {
+
+	vars := mux.Vars(r)
+	songId := restApiV1.SongId(vars["id"])
+
+	s.log.Debugf("Read song waveform: %s", songId)
+
+	pointCount := restApiV1.SongWaveformMaxPoints
+	if rawPointCount := r.URL.Query().Get(restApiV1.SongWaveformPointsParam); rawPointCount != "" {
+		var err error
+		pointCount, err = strconv.Atoi(rawPointCount)
+		if err != nil || pointCount <= 0 {
+			s.apiErrorCodeResponse(w, restApiV1.InvalideRequestErrorCode)
+			return
+		}
+	}
+
+	songWaveform, err := s.store.ReadSongWaveform(nil, songId, pointCount)
+	if err != nil {
+		if err == storeerror.ErrNotFound {
+			s.apiErrorCodeResponse(w, restApiV1.NotFoundErrorCode)
+			return
+		}
+		s.log.Panicf("Unable to read song waveform: %v", err)
+	}
+
+	content, err := json.Marshal(songWaveform)
+	if err != nil {
+		s.log.Panicf("Unable to serialize song waveform: %v", err)
+	}
+
+	// A computed waveform never change, clients can keep it
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "private, max-age=31536000")
+	w.Header().Set("ETag", fmt.Sprintf(`"%d-%d"`, songWaveform.UpdateTs, len(songWaveform.Peaks)))
+	http.ServeContent(w, r, "", time.Unix(0, songWaveform.UpdateTs), bytes.NewReader(content))
+}
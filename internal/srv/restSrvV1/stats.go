@@ -0,0 +1,65 @@
+package restSrvV1
+
+import (
+	"github.com/gorilla/mux"
+	"github.com/jypelle/mifasol/internal/srv/storeerror"
+	"github.com/jypelle/mifasol/internal/tool"
+	"github.com/jypelle/mifasol/restApiV1"
+	"net/http"
+	"time"
+)
+
+func (s *RestServer) readUserStats(w http.ResponseWriter, r *http.Request) {
+
+	vars := mux.Vars(r)
+	userId := restApiV1.UserId(vars["userId"])
+
+	s.log.Debugf("Read user stats: %s", userId)
+
+	// Only admin can read stats of another user
+	if !isConnectedUserOrAdmin(r, userId) {
+		s.apiErrorCodeResponse(w, restApiV1.ForbiddenErrorCode)
+		return
+	}
+
+	// Period bounds are days in server local time, both included
+	var from, to *string
+	var fromTs, toTs *int64
+	if rawFrom := r.URL.Query().Get(restApiV1.StatsFromParam); rawFrom != "" {
+		fromDay, err := time.ParseInLocation(restApiV1.StatsDateLayout, rawFrom, time.Local)
+		if err != nil {
+			s.apiErrorCodeResponse(w, restApiV1.InvalideRequestErrorCode)
+			return
+		}
+		from = &rawFrom
+		fromDayTs := fromDay.UnixNano()
+		fromTs = &fromDayTs
+	}
+	if rawTo := r.URL.Query().Get(restApiV1.StatsToParam); rawTo != "" {
+		toDay, err := time.ParseInLocation(restApiV1.StatsDateLayout, rawTo, time.Local)
+		if err != nil {
+			s.apiErrorCodeResponse(w, restApiV1.InvalideRequestErrorCode)
+			return
+		}
+		to = &rawTo
+		toDayTs := toDay.AddDate(0, 0, 1).UnixNano()
+		toTs = &toDayTs
+	}
+	if fromTs != nil && toTs != nil && *fromTs >= *toTs {
+		s.apiErrorCodeResponse(w, restApiV1.InvalideRequestErrorCode)
+		return
+	}
+
+	userStats, err := s.store.ReadUserStats(nil, userId, fromTs, toTs)
+	if err != nil {
+		if err == storeerror.ErrNotFound {
+			s.apiErrorCodeResponse(w, restApiV1.NotFoundErrorCode)
+			return
+		}
+		s.log.Panicf("Unable to read user stats: %v", err)
+	}
+	userStats.From = from
+	userStats.To = to
+
+	tool.WriteJsonResponse(w, userStats)
+}
@@ -39,6 +39,12 @@ func (s *RestServer) readFileSyncReport(w http.ResponseWriter, r *http.Request)
 	}
 	userId := restApiV1.UserId(vars["userId"])
 
+	// Only admin can read the file sync report of another user
+	if !isConnectedUserOrAdmin(r, userId) {
+		s.apiErrorCodeResponse(w, restApiV1.ForbiddenErrorCode)
+		return
+	}
+
 	fileSyncReport, err := s.store.ReadFileSyncReport(fromTs, userId)
 	if err != nil {
 		s.log.Panicf("Unable to read sync report: %v", err)
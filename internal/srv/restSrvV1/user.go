@@ -43,6 +43,12 @@ func (s *RestServer) readUser(w http.ResponseWriter, r *http.Request) {
 func (s *RestServer) createUser(w http.ResponseWriter, r *http.Request) {
 	s.log.Debugf("Create user")
 
+	// Only admin can create a user
+	if !isConnectedUserAdmin(r) {
+		s.apiErrorCodeResponse(w, restApiV1.ForbiddenErrorCode)
+		return
+	}
+
 	var userMetaComplete restApiV1.UserMetaComplete
 	err := json.NewDecoder(r.Body).Decode(&userMetaComplete)
 	if err != nil {
@@ -71,6 +77,27 @@ func (s *RestServer) updateUser(w http.ResponseWriter, r *http.Request) {
 		s.log.Panicf("Unable to interpret data to update the user: %v", err)
 	}
 
+	// Only admin can edit another user
+	if !isConnectedUserOrAdmin(r, userId) {
+		s.apiErrorCodeResponse(w, restApiV1.ForbiddenErrorCode)
+		return
+	}
+
+	oldUser, err := s.store.ReadUser(nil, userId)
+	if err != nil {
+		if err == storeerror.ErrNotFound {
+			s.apiErrorCodeResponse(w, restApiV1.NotFoundErrorCode)
+			return
+		}
+		s.log.Panicf("Unable to read user: %v", err)
+	}
+
+	// Only admin can grant or revoke administrator rights
+	if !isConnectedUserAdmin(r) && userMetaComplete.AdminFg != oldUser.AdminFg {
+		s.apiErrorCodeResponse(w, restApiV1.ForbiddenErrorCode)
+		return
+	}
+
 	user, err := s.store.UpdateUser(nil, userId, &userMetaComplete)
 	if err != nil {
 		s.log.Panicf("Unable to update the user: %v", err)
@@ -87,8 +114,24 @@ func (s *RestServer) deleteUser(w http.ResponseWriter, r *http.Request) {
 
 	s.log.Debugf("Delete user: %s", userId)
 
+	// Only admin can delete a user
+	if !isConnectedUserAdmin(r) {
+		s.apiErrorCodeResponse(w, restApiV1.ForbiddenErrorCode)
+		return
+	}
+
+	// You can't delete yourself
+	if connectedUser(r).Id == userId {
+		s.apiErrorCodeResponse(w, restApiV1.DeleteUserYourselfErrorCode)
+		return
+	}
+
 	user, err := s.store.DeleteUser(nil, userId)
 	if err != nil {
+		if err == storeerror.ErrNotFound {
+			s.apiErrorCodeResponse(w, restApiV1.NotFoundErrorCode)
+			return
+		}
 		s.log.Panicf("Unable to delete user: %v", err)
 	}
 
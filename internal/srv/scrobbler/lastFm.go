@@ -0,0 +1,108 @@
+package scrobbler
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/json"
+	"github.com/jypelle/mifasol/restApiV1"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// lastFmInvalidParametersError is the Last.fm error code of a refused track
+const lastFmInvalidParametersError = 6
+
+func submitLastFmListens(ctx context.Context, settings *restApiV1.ScrobblingMeta, listens []Listen) error {
+	params := url.Values{}
+	params.Set("method", "track.scrobble")
+	for ind, listen := range listens {
+		suffix := "[" + strconv.Itoa(ind) + "]"
+		setLastFmTrackParams(params, &listen.Track, suffix)
+		params.Set("timestamp"+suffix, strconv.FormatInt(listen.ListenedTs/1e9, 10))
+	}
+	return postLastFmRequest(ctx, settings, params)
+}
+
+func submitLastFmNowPlaying(ctx context.Context, settings *restApiV1.ScrobblingMeta, track *Track) error {
+	params := url.Values{}
+	params.Set("method", "track.updateNowPlaying")
+	setLastFmTrackParams(params, track, "")
+	return postLastFmRequest(ctx, settings, params)
+}
+
+func setLastFmTrackParams(params url.Values, track *Track, suffix string) {
+	params.Set("artist"+suffix, track.ArtistName)
+	params.Set("track"+suffix, track.Name)
+	if track.AlbumName != "" {
+		params.Set("album"+suffix, track.AlbumName)
+	}
+	if track.Duration > 0 {
+		params.Set("duration"+suffix, strconv.FormatInt(track.Duration/1000, 10))
+	}
+}
+
+// postLastFmRequest sign and send a write request of the Last.fm 2.0 api
+func postLastFmRequest(ctx context.Context, settings *restApiV1.ScrobblingMeta, params url.Values) error {
+	params.Set("api_key", settings.ApiKey)
+	params.Set("sk", settings.Token)
+	params.Set("api_sig", lastFmSignature(params, settings.ApiSecret))
+	params.Set("format", "json")
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, apiRootUrl(settings), strings.NewReader(params.Encode()))
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	response, err := httpClient.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	// Errors may come with a 200 status: {"error": 9, "message": "..."}
+	var errorBody struct {
+		Error   int    `json:"error"`
+		Message string `json:"message"`
+	}
+	rawBody, _ := io.ReadAll(io.LimitReader(response.Body, 65536))
+	json.Unmarshal(rawBody, &errorBody)
+
+	if response.StatusCode == http.StatusOK && errorBody.Error == 0 {
+		return nil
+	}
+	if errorBody.Message == "" {
+		errorBody.Message = http.StatusText(response.StatusCode)
+	}
+
+	return &Error{
+		StatusCode: response.StatusCode,
+		Message:    errorBody.Message,
+		// Refused tracks are permanent, other errors (session, rate limit, outage) may be temporary
+		Permanent: errorBody.Error == lastFmInvalidParametersError,
+	}
+}
+
+// lastFmSignature return the md5 of the sorted parameters, concatenated with the shared secret
+func lastFmSignature(params url.Values, apiSecret string) string {
+	keys := make([]string, 0, len(params))
+	for key := range params {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var signature strings.Builder
+	for _, key := range keys {
+		signature.WriteString(key)
+		signature.WriteString(params.Get(key))
+	}
+	signature.WriteString(apiSecret)
+
+	sum := md5.Sum([]byte(signature.String()))
+	return hex.EncodeToString(sum[:])
+}
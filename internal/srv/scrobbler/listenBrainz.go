@@ -0,0 +1,106 @@
+package scrobbler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"github.com/jypelle/mifasol/internal/version"
+	"github.com/jypelle/mifasol/restApiV1"
+	"io"
+	"net/http"
+	"strings"
+)
+
+type listenBrainzSubmission struct {
+	ListenType string                `json:"listen_type"`
+	Payload    []listenBrainzPayload `json:"payload"`
+}
+
+type listenBrainzPayload struct {
+	ListenedAt    int64                     `json:"listened_at,omitempty"`
+	TrackMetadata listenBrainzTrackMetadata `json:"track_metadata"`
+}
+
+type listenBrainzTrackMetadata struct {
+	ArtistName     string                 `json:"artist_name"`
+	TrackName      string                 `json:"track_name"`
+	ReleaseName    string                 `json:"release_name,omitempty"`
+	AdditionalInfo map[string]interface{} `json:"additional_info"`
+}
+
+func listenBrainzTrackMetadataOf(track *Track) listenBrainzTrackMetadata {
+	additionalInfo := map[string]interface{}{
+		"submission_client":         "mifasol",
+		"submission_client_version": version.AppVersion.String(),
+	}
+	if track.Duration > 0 {
+		additionalInfo["duration_ms"] = track.Duration
+	}
+	return listenBrainzTrackMetadata{
+		ArtistName:     track.ArtistName,
+		TrackName:      track.Name,
+		ReleaseName:    track.AlbumName,
+		AdditionalInfo: additionalInfo,
+	}
+}
+
+func submitListenBrainzListens(ctx context.Context, settings *restApiV1.ScrobblingMeta, listens []Listen) error {
+	submission := listenBrainzSubmission{ListenType: "single"}
+	if len(listens) > 1 {
+		submission.ListenType = "import"
+	}
+	for _, listen := range listens {
+		submission.Payload = append(submission.Payload, listenBrainzPayload{
+			ListenedAt:    listen.ListenedTs / 1e9,
+			TrackMetadata: listenBrainzTrackMetadataOf(&listen.Track),
+		})
+	}
+	return postListenBrainzSubmission(ctx, settings, &submission)
+}
+
+func submitListenBrainzNowPlaying(ctx context.Context, settings *restApiV1.ScrobblingMeta, track *Track) error {
+	return postListenBrainzSubmission(ctx, settings, &listenBrainzSubmission{
+		ListenType: "playing_now",
+		Payload:    []listenBrainzPayload{{TrackMetadata: listenBrainzTrackMetadataOf(track)}},
+	})
+}
+
+func postListenBrainzSubmission(ctx context.Context, settings *restApiV1.ScrobblingMeta, submission *listenBrainzSubmission) error {
+	content, err := json.Marshal(submission)
+	if err != nil {
+		return err
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimRight(apiRootUrl(settings), "/")+"/1/submit-listens", bytes.NewReader(content))
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("Authorization", "Token "+settings.Token)
+
+	response, err := httpClient.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode == http.StatusOK {
+		return nil
+	}
+
+	// Error body: {"code": 400, "error": "..."}
+	var errorBody struct {
+		Error string `json:"error"`
+	}
+	rawBody, _ := io.ReadAll(io.LimitReader(response.Body, 4096))
+	if json.Unmarshal(rawBody, &errorBody) != nil || errorBody.Error == "" {
+		errorBody.Error = http.StatusText(response.StatusCode)
+	}
+
+	return &Error{
+		StatusCode: response.StatusCode,
+		Message:    errorBody.Error,
+		// Invalid listens are refused, other errors (token, rate limit, outage) may be temporary
+		Permanent: response.StatusCode == http.StatusBadRequest,
+	}
+}
@@ -0,0 +1,75 @@
+package scrobbler
+
+import (
+	"context"
+	"fmt"
+	"github.com/jypelle/mifasol/restApiV1"
+	"net/http"
+	"time"
+)
+
+// MaxListensPerRequest is the number of listens submitted at once, the lowest limit of supported services
+const MaxListensPerRequest = 50
+
+var httpClient = &http.Client{Timeout: 30 * time.Second}
+
+// Track describe a song to a scrobbling service
+type Track struct {
+	Name       string
+	ArtistName string
+	AlbumName  string
+	Duration   int64 // In milliseconds
+}
+
+// Listen is a track listened by a user
+type Listen struct {
+	Track
+	ListenedTs int64 // Listen start timestamp
+}
+
+// Error is a request refused by a scrobbling service
+type Error struct {
+	StatusCode int
+	Message    string
+	Permanent  bool // The request will never be accepted, resubmitting it is useless
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("scrobbling service answered %d: %s", e.StatusCode, e.Message)
+}
+
+// IsPermanent return true when a submission failed in a way retries can't fix
+func IsPermanent(err error) bool {
+	scrobblerErr, ok := err.(*Error)
+	return ok && scrobblerErr.Permanent
+}
+
+// SubmitListens send completed listens, at most MaxListensPerRequest
+func SubmitListens(ctx context.Context, settings *restApiV1.ScrobblingMeta, listens []Listen) error {
+	switch settings.ServiceType {
+	case restApiV1.ScrobblingServiceTypeListenBrainz:
+		return submitListenBrainzListens(ctx, settings, listens)
+	case restApiV1.ScrobblingServiceTypeLastFm:
+		return submitLastFmListens(ctx, settings, listens)
+	}
+	return &Error{Message: "unknown service type " + string(settings.ServiceType), Permanent: true}
+}
+
+// SubmitNowPlaying send the track a user just started to listen to
+func SubmitNowPlaying(ctx context.Context, settings *restApiV1.ScrobblingMeta, track *Track) error {
+	switch settings.ServiceType {
+	case restApiV1.ScrobblingServiceTypeListenBrainz:
+		return submitListenBrainzNowPlaying(ctx, settings, track)
+	case restApiV1.ScrobblingServiceTypeLastFm:
+		return submitLastFmNowPlaying(ctx, settings, track)
+	}
+	return &Error{Message: "unknown service type " + string(settings.ServiceType), Permanent: true}
+}
+
+// apiRootUrl return the api root url of the settings, or the service default one
+func apiRootUrl(settings *restApiV1.ScrobblingMeta) string {
+	if settings.ApiRootUrl != "" {
+		return settings.ApiRootUrl
+	}
+	return settings.ServiceType.DefaultApiRootUrl()
+}
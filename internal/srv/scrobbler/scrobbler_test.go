@@ -0,0 +1,184 @@
+package scrobbler
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"testing"
+
+	"github.com/jypelle/mifasol/restApiV1"
+)
+
+var testListens = []Listen{
+	{Track: Track{Name: "Song 1", ArtistName: "Artist 1", AlbumName: "Album", Duration: 215000}, ListenedTs: 1700000000 * 1e9},
+	{Track: Track{Name: "Song 2", ArtistName: "Artist 2"}, ListenedTs: 1700000300 * 1e9},
+}
+
+func TestListenBrainzSubmission(t *testing.T) {
+	var submission listenBrainzSubmission
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/1/submit-listens" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		if r.Header.Get("Authorization") != "Token user-token" {
+			t.Errorf("unexpected authorization %q", r.Header.Get("Authorization"))
+		}
+		if err := json.NewDecoder(r.Body).Decode(&submission); err != nil {
+			t.Error(err)
+		}
+	}))
+	defer server.Close()
+
+	settings := &restApiV1.ScrobblingMeta{ServiceType: restApiV1.ScrobblingServiceTypeListenBrainz, ApiRootUrl: server.URL + "/", Token: "user-token"}
+	err := SubmitListens(context.Background(), settings, testListens)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if submission.ListenType != "import" || len(submission.Payload) != 2 {
+		t.Fatalf("unexpected submission %+v", submission)
+	}
+	payload := submission.Payload[0]
+	if payload.ListenedAt != 1700000000 ||
+		payload.TrackMetadata.TrackName != "Song 1" ||
+		payload.TrackMetadata.ArtistName != "Artist 1" ||
+		payload.TrackMetadata.ReleaseName != "Album" ||
+		payload.TrackMetadata.AdditionalInfo["duration_ms"] != float64(215000) {
+		t.Errorf("unexpected payload %+v", payload)
+	}
+	if _, ok := submission.Payload[1].TrackMetadata.AdditionalInfo["duration_ms"]; ok {
+		t.Errorf("unknown duration must not be sent")
+	}
+
+	// A single listen is submitted as such
+	err = SubmitListens(context.Background(), settings, testListens[:1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if submission.ListenType != "single" {
+		t.Errorf("single listen type expected, got %s", submission.ListenType)
+	}
+}
+
+func TestListenBrainzErrors(t *testing.T) {
+	for _, testCase := range []struct {
+		statusCode int
+		permanent  bool
+	}{
+		{http.StatusBadRequest, true},
+		{http.StatusUnauthorized, false},
+		{http.StatusTooManyRequests, false},
+		{http.StatusServiceUnavailable, false},
+	} {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(testCase.statusCode)
+			w.Write([]byte(`{"code": 0, "error": "refused"}`))
+		}))
+
+		settings := &restApiV1.ScrobblingMeta{ServiceType: restApiV1.ScrobblingServiceTypeListenBrainz, ApiRootUrl: server.URL, Token: "user-token"}
+		err := SubmitListens(context.Background(), settings, testListens)
+		scrobblerErr, ok := err.(*Error)
+		if !ok || scrobblerErr.StatusCode != testCase.statusCode || scrobblerErr.Message != "refused" || IsPermanent(err) != testCase.permanent {
+			t.Errorf("%d: unexpected error %v", testCase.statusCode, err)
+		}
+
+		server.Close()
+	}
+}
+
+// expectedLastFmSignature sign the parameters as described by the Last.fm api, format and api_sig excluded
+func expectedLastFmSignature(params url.Values, apiSecret string) string {
+	var keys []string
+	for key := range params {
+		if key != "format" && key != "api_sig" {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	signature := ""
+	for _, key := range keys {
+		signature += key + params.Get(key)
+	}
+	sum := md5.Sum([]byte(signature + apiSecret))
+	return hex.EncodeToString(sum[:])
+}
+
+func TestLastFmSubmission(t *testing.T) {
+	var params url.Values
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/2.0/" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		if err := r.ParseForm(); err != nil {
+			t.Error(err)
+		}
+		params = r.PostForm
+		w.Write([]byte(`{"scrobbles": {}}`))
+	}))
+	defer server.Close()
+
+	settings := &restApiV1.ScrobblingMeta{ServiceType: restApiV1.ScrobblingServiceTypeLastFm, ApiRootUrl: server.URL + "/2.0/", Token: "session-key", ApiKey: "api-key", ApiSecret: "api-secret"}
+	err := SubmitListens(context.Background(), settings, testListens)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for key, value := range map[string]string{
+		"method":       "track.scrobble",
+		"api_key":      "api-key",
+		"sk":           "session-key",
+		"format":       "json",
+		"artist[0]":    "Artist 1",
+		"track[0]":     "Song 1",
+		"album[0]":     "Album",
+		"duration[0]":  "215",
+		"timestamp[0]": "1700000000",
+		"artist[1]":    "Artist 2",
+		"track[1]":     "Song 2",
+		"timestamp[1]": "1700000300",
+	} {
+		if params.Get(key) != value {
+			t.Errorf("%s: %q expected, got %q", key, value, params.Get(key))
+		}
+	}
+	if _, ok := params["album[1]"]; ok {
+		t.Errorf("unknown album must not be sent")
+	}
+	if params.Get("api_sig") != expectedLastFmSignature(params, "api-secret") {
+		t.Errorf("invalid api_sig %s", params.Get("api_sig"))
+	}
+}
+
+func TestLastFmErrors(t *testing.T) {
+	for _, testCase := range []struct {
+		statusCode int
+		body       string
+		permanent  bool
+	}{
+		// Errors may come with a 200 status
+		{http.StatusOK, `{"error": 6, "message": "refused"}`, true},
+		{http.StatusOK, `{"error": 9, "message": "refused"}`, false},
+		{http.StatusForbidden, `{"error": 9, "message": "refused"}`, false},
+		{http.StatusServiceUnavailable, `{"error": 11, "message": "refused"}`, false},
+	} {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(testCase.statusCode)
+			w.Write([]byte(testCase.body))
+		}))
+
+		settings := &restApiV1.ScrobblingMeta{ServiceType: restApiV1.ScrobblingServiceTypeLastFm, ApiRootUrl: server.URL, Token: "session-key", ApiKey: "api-key", ApiSecret: "api-secret"}
+		err := SubmitListens(context.Background(), settings, testListens)
+		scrobblerErr, ok := err.(*Error)
+		if !ok || scrobblerErr.Message != "refused" || IsPermanent(err) != testCase.permanent {
+			t.Errorf("%d %s: unexpected error %v", testCase.statusCode, testCase.body, err)
+		}
+
+		server.Close()
+	}
+}
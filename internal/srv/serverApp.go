@@ -142,7 +142,7 @@ func (s *ServerApp) Start() {
 
 	// Start serving REST request
 	if s.Ssl {
-		logrus.Printf("Server listening on https://localhost" + s.httpServer.Addr + " using a self-signed certificate")
+		logrus.Printf("Server listening on https://localhost%s using a self-signed certificate", s.httpServer.Addr)
 		go func() {
 			err := s.httpServer.ListenAndServeTLS(s.GetCompleteConfigCertFilename(), s.GetCompleteConfigKeyFilename())
 			if err != nil && err != http.ErrServerClosed {
@@ -151,7 +151,7 @@ func (s *ServerApp) Start() {
 		}()
 
 	} else {
-		logrus.Printf("Server listening on http://localhost" + s.httpServer.Addr)
+		logrus.Printf("Server listening on http://localhost%s", s.httpServer.Addr)
 		go func() {
 			err := s.httpServer.ListenAndServe()
 			if err != nil && err != http.ErrServerClosed {
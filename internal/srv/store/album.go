@@ -7,6 +7,8 @@ import (
 	"github.com/jypelle/mifasol/internal/srv/storeerror"
 	"github.com/jypelle/mifasol/internal/tool"
 	"github.com/jypelle/mifasol/restApiV1"
+	"os"
+	"strings"
 	"time"
 )
 
@@ -35,17 +37,24 @@ func (s *Store) ReadAlbums(externalTrn *sqlx.Tx, filter *restApiV1.AlbumFilter)
 	}
 
 	queryArgs := make(map[string]interface{})
-	if filter.FromTs != nil {
-		queryArgs["from_ts"] = *filter.FromTs
-	} else if filter.Name != nil {
-		queryArgs["name"] = *filter.Name
-	}
 
-	orderBy := "a.update_ts ASC"
-	if filter.OrderBy != nil {
-		if *filter.OrderBy == restApiV1.AlbumFilterOrderByName {
-			orderBy = "a.name ASC"
+	// Albums are paged in a subquery as they are read with one row per artist
+	var pageCondition string
+	if filter.Limit != nil || filter.Cursor != nil {
+		pageSortKeys := albumSortKeys(filter.OrderBy, "pa")
+		cursorConditions, err := pageConditions(pageSortKeys, albumOrderByName(filter.OrderBy), filter.Limit, filter.Cursor, queryArgs)
+		if err != nil {
+			return nil, err
 		}
+		pageCondition = `AND album_id IN (
+			SELECT pa.album_id
+			FROM album pa
+			WHERE 1>0
+			` + albumFilterConditions(filter, "pa", queryArgs) + `
+			` + cursorConditions + `
+			ORDER BY ` + strings.Join(pageSortKeys, " ASC, ") + ` ASC
+			` + pageLimit(filter.Limit, queryArgs) + `
+		) `
 	}
 
 	rows, err := txn.NamedQuery(
@@ -54,18 +63,24 @@ func (s *Store) ReadAlbums(externalTrn *sqlx.Tx, filter *restApiV1.AlbumFilter)
 				a.creation_ts,
 				a.update_ts,
 				a.name,
+				a.cover_fg,
+				a.loudness,
+				a.true_peak,
 				null as artist_id,
 				null as artist_name
 			FROM album a
 			WHERE 1>0
-			`+tool.TernStr(filter.FromTs != nil, "AND a.update_ts >= :from_ts ", "")+`
-			`+tool.TernStr(filter.Name != nil, "AND a.name LIKE :name ", "")+`
+			`+albumFilterConditions(filter, "a", queryArgs)+`
+			`+pageCondition+`
 			UNION ALL
 			SELECT
 				a.album_id,
 				a.creation_ts,
 				a.update_ts,
 				a.name,
+				a.cover_fg,
+				a.loudness,
+				a.true_peak,
 				ar.artist_id,
 				ar.name as artist_name
 			FROM (
@@ -74,30 +89,39 @@ func (s *Store) ReadAlbums(externalTrn *sqlx.Tx, filter *restApiV1.AlbumFilter)
 					aa.creation_ts,
 					aa.update_ts,
 					aa.name,
+					aa.cover_fg,
+					aa.loudness,
+					aa.true_peak,
 					count(song_id)/2 as album_minimum_song_count_per_artist
 				FROM album aa
 				LEFT JOIN song ss using(album_id)
 				WHERE 1>0
-				`+tool.TernStr(filter.FromTs != nil, "AND aa.update_ts >= :from_ts ", "")+`
-				`+tool.TernStr(filter.Name != nil, "AND aa.name LIKE :name ", "")+`
+				`+albumFilterConditions(filter, "aa", queryArgs)+`
+				`+pageCondition+`
 				GROUP BY
 					aa.album_id,
 					aa.creation_ts,
 					aa.update_ts,
-					aa.name
+					aa.name,
+					aa.cover_fg,
+					aa.loudness,
+					aa.true_peak
 			) a
 			LEFT JOIN song s using(album_id)
-			LEFT JOIN artist_song ars USING (song_id)
+			LEFT JOIN artist_song ars ON ars.song_id = s.song_id AND ars.role = 'main'
 			LEFT JOIN artist ar ON ar.artist_id = ars.artist_id
 			GROUP BY
 				a.album_id,
 				a.creation_ts,
 				a.update_ts,
 				a.name,
+				a.cover_fg,
+				a.loudness,
+				a.true_peak,
 				ar.artist_id,
 				ar.name
-			HAVING count(distinct song_id) > album_minimum_song_count_per_artist
-			ORDER BY `+orderBy+`, artist_name, ar.artist_id`,
+			HAVING count(distinct s.song_id) > album_minimum_song_count_per_artist
+			ORDER BY `+strings.Join(albumSortKeys(filter.OrderBy, "a"), " ASC, ")+` ASC, artist_name, ar.artist_id`,
 		queryArgs,
 	)
 	if err != nil {
@@ -137,9 +161,129 @@ func (s *Store) ReadAlbums(externalTrn *sqlx.Tx, filter *restApiV1.AlbumFilter)
 		albums = append(albums, *currentAlbum)
 	}
 
+	// Explicit album artists take precedence over the ones derived from songs
+	albumArtistIds, err := s.readAlbumArtistIds(txn, nil)
+	if err != nil {
+		return nil, err
+	}
+	for ind := range albums {
+		if artistIds, ok := albumArtistIds[albums[ind].Id]; ok {
+			albums[ind].AlbumArtistIds = artistIds
+			albums[ind].ArtistIds = artistIds
+		}
+	}
+
 	return albums, nil
 }
 
+// albumFilterConditions return the conditions selecting the albums matching a filter, on the album table alias
+func albumFilterConditions(filter *restApiV1.AlbumFilter, alias string, queryArgs map[string]interface{}) string {
+	if filter.FromTs != nil {
+		queryArgs["from_ts"] = *filter.FromTs
+	}
+	if filter.Name != nil {
+		queryArgs["name"] = *filter.Name
+	}
+
+	return tool.IfStr(filter.FromTs != nil, "AND "+alias+".update_ts >= :from_ts ") +
+		tool.IfStr(filter.Name != nil, "AND "+alias+".name LIKE :name ")
+}
+
+func albumOrderByName(orderBy *restApiV1.AlbumFilterOrderBy) string {
+	if orderBy == nil {
+		return ""
+	}
+	return string(*orderBy)
+}
+
+// albumSortKeys return the columns albums are sorted by, on the album table alias, the album id comes last to get a total order
+func albumSortKeys(orderBy *restApiV1.AlbumFilterOrderBy, alias string) []string {
+	switch albumOrderByName(orderBy) {
+	case string(restApiV1.AlbumFilterOrderByName):
+		return []string{alias + ".name", alias + ".album_id"}
+	case string(restApiV1.AlbumFilterOrderByCreationTs):
+		return []string{alias + ".creation_ts", alias + ".album_id"}
+	default:
+		return []string{alias + ".update_ts", alias + ".album_id"}
+	}
+}
+
+// albumSortKeyValues return the values of the album sort keys
+func albumSortKeyValues(album *restApiV1.Album, orderBy *restApiV1.AlbumFilterOrderBy) []interface{} {
+	switch albumOrderByName(orderBy) {
+	case string(restApiV1.AlbumFilterOrderByName):
+		return []interface{}{album.Name, album.Id}
+	case string(restApiV1.AlbumFilterOrderByCreationTs):
+		return []interface{}{album.CreationTs, album.Id}
+	default:
+		return []interface{}{album.UpdateTs, album.Id}
+	}
+}
+
+// CountAlbums return the number of albums matching a filter, paging excluded
+func (s *Store) CountAlbums(externalTrn *sqlx.Tx, filter *restApiV1.AlbumFilter) (int64, error) {
+	var err error
+
+	// Check available transaction
+	txn := externalTrn
+	if txn == nil {
+		txn, err = s.db.Beginx()
+		if err != nil {
+			return 0, err
+		}
+		defer txn.Rollback()
+	}
+
+	queryArgs := make(map[string]interface{})
+
+	return namedCount(txn, "SELECT count(*) FROM album a WHERE 1>0 "+albumFilterConditions(filter, "a", queryArgs), queryArgs)
+}
+
+// ReadAlbumsPage return a page of albums, the cursor of the next page (empty for the last page) and the number of albums matching the filter
+func (s *Store) ReadAlbumsPage(externalTrn *sqlx.Tx, filter *restApiV1.AlbumFilter) ([]restApiV1.Album, string, int64, error) {
+	var err error
+
+	// Check available transaction
+	txn := externalTrn
+	if txn == nil {
+		txn, err = s.db.Beginx()
+		if err != nil {
+			return nil, "", 0, err
+		}
+		defer txn.Rollback()
+	}
+
+	totalCount, err := s.CountAlbums(txn, filter)
+	if err != nil {
+		return nil, "", 0, err
+	}
+
+	if filter.Limit == nil {
+		albums, err := s.ReadAlbums(txn, filter)
+		return albums, "", totalCount, err
+	}
+
+	// Read one more album to know if there is a next page
+	pageFilter := *filter
+	limit := *filter.Limit + 1
+	pageFilter.Limit = &limit
+
+	albums, err := s.ReadAlbums(txn, &pageFilter)
+	if err != nil {
+		return nil, "", 0, err
+	}
+
+	var nextCursor string
+	if len(albums) > *filter.Limit {
+		albums = albums[:*filter.Limit]
+		if len(albums) > 0 {
+			nextCursor = encodePageCursor(albumOrderByName(filter.OrderBy), albumSortKeyValues(&albums[len(albums)-1], filter.OrderBy))
+		}
+	}
+
+	return albums, nextCursor, totalCount, nil
+}
+
 func (s *Store) ReadAlbum(externalTrn *sqlx.Tx, albumId restApiV1.AlbumId) (*restApiV1.Album, error) {
 	var err error
 
@@ -172,7 +316,7 @@ func (s *Store) ReadAlbum(externalTrn *sqlx.Tx, albumId restApiV1.AlbumId) (*res
 		FROM song s
 		JOIN artist_song USING (song_id)
 		JOIN artist a USING (artist_id)
-		WHERE album_id = ?
+		WHERE album_id = ? AND role = 'main'
 		GROUP BY artist_id
 		HAVING count(*) > (SELECT count(*)/2 FROM song where album_id = ? )
 		ORDER BY a.name, a.artist_id`,
@@ -187,6 +331,16 @@ func (s *Store) ReadAlbum(externalTrn *sqlx.Tx, albumId restApiV1.AlbumId) (*res
 	albumEntity.Fill(&album)
 	album.ArtistIds = artistIds
 
+	// Explicit album artists take precedence over the ones derived from songs
+	albumArtistIds, err := s.readAlbumArtistIds(txn, &albumId)
+	if err != nil {
+		return nil, err
+	}
+	if artistIds, ok := albumArtistIds[albumId]; ok {
+		album.AlbumArtistIds = artistIds
+		album.ArtistIds = artistIds
+	}
+
 	return &album, nil
 }
 
@@ -228,12 +382,24 @@ func (s *Store) CreateAlbum(externalTrn *sqlx.Tx, albumMeta *restApiV1.AlbumMeta
 			)
 	`, &albumEntity)
 
+	if err != nil {
+		return nil, err
+	}
+	err = albumSearchIndex.index(txn, string(albumEntity.AlbumId), albumEntity.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	// Create album artists link
+	albumArtistIds, err := s.setAlbumArtistIds(txn, albumEntity.AlbumId, albumMeta.AlbumArtistIds)
 	if err != nil {
 		return nil, err
 	}
 
 	var album restApiV1.Album
 	albumEntity.Fill(&album)
+	album.AlbumArtistIds = albumArtistIds
+	album.ArtistIds = albumArtistIds
 
 	// Commit transaction
 	if externalTrn == nil {
@@ -262,6 +428,10 @@ func (s *Store) UpdateAlbum(externalTrn *sqlx.Tx, albumId restApiV1.AlbumId, alb
 	}
 
 	oldName := albumEntity.Name
+	oldAlbumArtistIds, err := s.readAlbumArtistIds(txn, &albumId)
+	if err != nil {
+		return nil, err
+	}
 
 	albumEntity.LoadMeta(albumMeta)
 	albumEntity.UpdateTs = time.Now().UnixNano()
@@ -274,12 +444,22 @@ func (s *Store) UpdateAlbum(externalTrn *sqlx.Tx, albumId restApiV1.AlbumId, alb
 		WHERE album_id = :album_id
 	`, &albumEntity)
 
+	if err != nil {
+		return nil, err
+	}
+	err = albumSearchIndex.index(txn, string(albumId), albumEntity.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	// Update album artists link
+	albumArtistIds, err := s.setAlbumArtistIds(txn, albumId, albumMeta.AlbumArtistIds)
 	if err != nil {
 		return nil, err
 	}
 
 	// Update tags in songs content
-	if oldName != albumEntity.Name {
+	if oldName != albumEntity.Name || !isArtistIdsEqual(oldAlbumArtistIds[albumId], albumArtistIds) {
 		songs, err := s.ReadSongs(txn, &restApiV1.SongFilter{AlbumId: &albumId})
 		if err != nil {
 			return nil, err
@@ -290,15 +470,17 @@ func (s *Store) UpdateAlbum(externalTrn *sqlx.Tx, albumId restApiV1.AlbumId, alb
 		}
 	}
 
-	var album restApiV1.Album
-	albumEntity.Fill(&album)
+	album, err := s.ReadAlbum(txn, albumId)
+	if err != nil {
+		return nil, err
+	}
 
 	// Commit transaction
 	if externalTrn == nil {
 		txn.Commit()
 	}
 
-	return &album, nil
+	return album, nil
 }
 
 func (s *Store) DeleteAlbum(externalTrn *sqlx.Tx, albumId restApiV1.AlbumId) (*restApiV1.Album, error) {
@@ -336,6 +518,16 @@ func (s *Store) DeleteAlbum(externalTrn *sqlx.Tx, albumId restApiV1.AlbumId) (*r
 	if err != nil {
 		return nil, err
 	}
+	err = albumSearchIndex.unindex(txn, string(albumId))
+	if err != nil {
+		return nil, err
+	}
+
+	// Delete album artists link
+	_, err = txn.Exec("DELETE FROM album_artist WHERE album_id = ?", albumId)
+	if err != nil {
+		return nil, err
+	}
 
 	// Archive albumId
 	_, err = txn.NamedExec(`
@@ -352,6 +544,11 @@ func (s *Store) DeleteAlbum(externalTrn *sqlx.Tx, albumId restApiV1.AlbumId) (*r
 		return nil, err
 	}
 
+	// Delete album cover
+	if albumEntity.CoverFg {
+		os.Remove(s.getAlbumCoverFileName(albumId))
+	}
+
 	// Commit transaction
 	if externalTrn == nil {
 		txn.Commit()
@@ -411,7 +608,9 @@ func (s *Store) GetDeletedAlbumIds(externalTrn *sqlx.Tx, fromTs int64) ([]restAp
 	return albumIds, nil
 }
 
-func (s *Store) getAlbumIdFromAlbumName(externalTrn *sqlx.Tx, albumName string, lastAlbumId restApiV1.AlbumId) (restApiV1.AlbumId, error) {
+// getAlbumIdFromAlbumName find the album matching the album name and the album artists names, creating it when needed.
+// Without album artists, the song main artists names are matched against the artists of the album
+func (s *Store) getAlbumIdFromAlbumName(externalTrn *sqlx.Tx, albumName string, albumArtistNames []string, artistNames []string, lastAlbumId restApiV1.AlbumId) (restApiV1.AlbumId, error) {
 	var err error
 
 	var albumId = restApiV1.UnknownAlbumId
@@ -428,33 +627,64 @@ func (s *Store) getAlbumIdFromAlbumName(externalTrn *sqlx.Tx, albumName string,
 			defer txn.Rollback()
 		}
 
+		// Find album artists
+		var albumArtistIds []restApiV1.ArtistId
+		albumArtistIds, err = s.getArtistIdsFromArtistNames(txn, albumArtistNames)
+		if err != nil {
+			return restApiV1.UnknownAlbumId, err
+		}
+		albumArtistIds = tool.DeduplicateArtistId(albumArtistIds)
+		err = s.sortArtistIds(txn, albumArtistIds)
+		if err != nil {
+			return restApiV1.UnknownAlbumId, err
+		}
+
+		// Find song main artists
+		var artistIds []restApiV1.ArtistId
+		if len(albumArtistIds) == 0 {
+			artistIds, err = s.getArtistIdsFromArtistNames(txn, artistNames)
+			if err != nil {
+				return restApiV1.UnknownAlbumId, err
+			}
+			artistIds = tool.DeduplicateArtistId(artistIds)
+			err = s.sortArtistIds(txn, artistIds)
+			if err != nil {
+				return restApiV1.UnknownAlbumId, err
+			}
+		}
+
 		var albums []restApiV1.Album
 		albums, err = s.ReadAlbums(txn, &restApiV1.AlbumFilter{Name: &albumName})
 		if err != nil {
 			return restApiV1.UnknownAlbumId, err
 		}
-		if len(albums) > 0 {
+
+		// Keep albums with the same album artists, albums without explicit album artists being matched with their songs artists.
+		// Without album artists, keep albums sharing a main artist with the song
+		var matchingAlbums []restApiV1.Album
+		for _, album := range albums {
+			if len(albumArtistIds) > 0 && isArtistIdsEqual(album.ArtistIds, albumArtistIds) ||
+				len(albumArtistIds) == 0 && isArtistIdsOverlapping(album.ArtistIds, artistIds) {
+				matchingAlbums = append(matchingAlbums, album)
+			}
+		}
+
+		if len(matchingAlbums) > 0 {
 			// Link the song to an existing album
 			if lastAlbumId == restApiV1.UnknownAlbumId {
-				albumId = albums[0].Id
+				albumId = matchingAlbums[0].Id
 			} else {
-				for _, album := range albums {
+				for _, album := range matchingAlbums {
 					if album.Id == lastAlbumId {
 						albumId = lastAlbumId
 					}
 				}
-				if albumId == restApiV1.UnknownAlbumId {
-					// Create the album before linking it to the song
-					var album, e = s.CreateAlbum(txn, &restApiV1.AlbumMeta{Name: albumName})
-					if e != nil {
-						return restApiV1.UnknownAlbumId, e
-					}
-					albumId = album.Id
-				}
 			}
-		} else {
+		}
+
+		if albumId == restApiV1.UnknownAlbumId {
 			// Create the album before linking it to the song
-			var album, e = s.CreateAlbum(txn, &restApiV1.AlbumMeta{Name: albumName})
+			var album, e = s.CreateAlbum(txn, &restApiV1.AlbumMeta{Name: albumName, AlbumArtistIds: albumArtistIds})
 			if e != nil {
 				return restApiV1.UnknownAlbumId, e
 			}
@@ -470,3 +700,128 @@ func (s *Store) getAlbumIdFromAlbumName(externalTrn *sqlx.Tx, albumName string,
 
 	return albumId, nil
 }
+
+// readAlbumArtistIds return the explicit album artists sorted by name, of all albums or only of the given album
+func (s *Store) readAlbumArtistIds(txn *sqlx.Tx, albumId *restApiV1.AlbumId) (map[restApiV1.AlbumId][]restApiV1.ArtistId, error) {
+	queryArgs := make(map[string]interface{})
+	if albumId != nil {
+		queryArgs["album_id"] = *albumId
+	}
+
+	rows, err := txn.NamedQuery(
+		`SELECT
+				aa.album_id,
+				aa.artist_id
+			FROM album_artist aa
+			JOIN artist ar USING (artist_id)
+			WHERE 1>0
+			`+tool.TernStr(albumId != nil, "AND aa.album_id = :album_id ", "")+`
+			ORDER BY ar.name, ar.artist_id`,
+		queryArgs,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	albumArtistIds := make(map[restApiV1.AlbumId][]restApiV1.ArtistId)
+	for rows.Next() {
+		var albumArtistEntity entity.AlbumArtistEntity
+		err = rows.StructScan(&albumArtistEntity)
+		if err != nil {
+			return nil, err
+		}
+		albumArtistIds[albumArtistEntity.AlbumId] = append(albumArtistIds[albumArtistEntity.AlbumId], albumArtistEntity.ArtistId)
+	}
+
+	return albumArtistIds, nil
+}
+
+// setAlbumArtistIds replace the explicit album artists and return them sorted by name
+func (s *Store) setAlbumArtistIds(txn *sqlx.Tx, albumId restApiV1.AlbumId, artistIds []restApiV1.ArtistId) ([]restApiV1.ArtistId, error) {
+	// Reorder artists (and check their existence)
+	artistIds = tool.DeduplicateArtistId(artistIds)
+	err := s.sortArtistIds(txn, artistIds)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = txn.Exec("DELETE FROM album_artist WHERE album_id = ?", albumId)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, artistId := range artistIds {
+		_, err = txn.NamedExec(`
+			INSERT INTO	album_artist (
+			    album_id,
+				artist_id
+			)
+			VALUES (
+			    :album_id,
+				:artist_id
+			)
+		`, &entity.AlbumArtistEntity{AlbumId: albumId, ArtistId: artistId})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if len(artistIds) == 0 {
+		return nil, nil
+	}
+
+	return artistIds, nil
+}
+
+// readArtistAlbumIds return the albums having the artist as explicit album artist
+func (s *Store) readArtistAlbumIds(txn *sqlx.Tx, artistId restApiV1.ArtistId) ([]restApiV1.AlbumId, error) {
+	albumIds := []restApiV1.AlbumId{}
+	err := txn.Select(&albumIds, "SELECT album_id FROM album_artist WHERE artist_id = ?", artistId)
+	if err != nil {
+		return nil, err
+	}
+
+	return albumIds, nil
+}
+
+// touchAlbums flag albums as updated and rewrite their songs tags
+func (s *Store) touchAlbums(txn *sqlx.Tx, albumIds []restApiV1.AlbumId) error {
+	now := time.Now().UnixNano()
+	for _, albumId := range albumIds {
+		_, err := txn.Exec("UPDATE album SET update_ts = ? WHERE album_id = ?", now, albumId)
+		if err != nil {
+			return err
+		}
+
+		err = s.refreshAlbumSongsContentTag(txn, albumId)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// countAlbumDiscs return the number of distinct discs of an album, songs without disc number being on the first disc
+func (s *Store) countAlbumDiscs(externalTrn *sqlx.Tx, albumId restApiV1.AlbumId) (int64, error) {
+	var err error
+
+	// Check available transaction
+	txn := externalTrn
+	if txn == nil {
+		txn, err = s.db.Beginx()
+		if err != nil {
+			return 0, err
+		}
+		defer txn.Rollback()
+	}
+
+	var discCount int64
+	err = txn.Get(&discCount, `SELECT count(DISTINCT coalesce(disc_number, 1)) FROM song WHERE album_id = ?`, albumId)
+	if err != nil {
+		return 0, err
+	}
+
+	return discCount, nil
+}
@@ -0,0 +1,366 @@
+package store
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/binary"
+	"github.com/jmoiron/sqlx"
+	"github.com/jypelle/mifasol/internal/srv/entity"
+	"github.com/jypelle/mifasol/internal/srv/storeerror"
+	"github.com/jypelle/mifasol/restApiV1"
+	"image"
+	_ "image/jpeg"
+	_ "image/png"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+type albumCover struct {
+	mimeType string
+	width    int
+	height   int
+	content  []byte
+}
+
+func (s *Store) getAlbumCoverFileName(albumId restApiV1.AlbumId) string {
+	return filepath.Join(s.serverConfig.GetCompleteConfigAlbumsDirName(), string(albumId))
+}
+
+// newAlbumCover check the picture is a jpeg or png image
+func newAlbumCover(content []byte) (*albumCover, error) {
+	mimeType := http.DetectContentType(content)
+	if mimeType != "image/jpeg" && mimeType != "image/png" {
+		return nil, storeerror.ErrInvalidAlbumCover
+	}
+
+	config, _, err := image.DecodeConfig(bytes.NewReader(content))
+	if err != nil {
+		return nil, storeerror.ErrInvalidAlbumCover
+	}
+
+	return &albumCover{
+		mimeType: mimeType,
+		width:    config.Width,
+		height:   config.Height,
+		content:  content,
+	}, nil
+}
+
+func (s *Store) ReadAlbumCover(externalTrn *sqlx.Tx, albumId restApiV1.AlbumId) (*os.File, *restApiV1.Album, error) {
+	album, err := s.ReadAlbum(externalTrn, albumId)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if !album.CoverFg {
+		return nil, nil, storeerror.ErrNotFound
+	}
+
+	file, err := os.Open(s.getAlbumCoverFileName(albumId))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return file, album, nil
+}
+
+// readAlbumCover return the album cover, or nil if the album doesn't have one
+func (s *Store) readAlbumCover(album *restApiV1.Album) (*albumCover, error) {
+	if !album.CoverFg {
+		return nil, nil
+	}
+
+	content, err := ioutil.ReadFile(s.getAlbumCoverFileName(album.Id))
+	if err != nil {
+		return nil, err
+	}
+
+	return newAlbumCover(content)
+}
+
+// UpdateAlbumCover replace the album cover, the picture being moved in place once the album is flagged.
+// Every song of the album is then rewritten to embed the new cover: the cost of the call grows with the album size
+func (s *Store) UpdateAlbumCover(externalTrn *sqlx.Tx, albumId restApiV1.AlbumId, content []byte) (*restApiV1.Album, error) {
+	var err error
+
+	_, err = newAlbumCover(content)
+	if err != nil {
+		return nil, err
+	}
+
+	// Check available transaction
+	txn := externalTrn
+	if txn == nil {
+		txn, err = s.db.Beginx()
+		if err != nil {
+			return nil, err
+		}
+		defer txn.Rollback()
+	}
+
+	coverTmpFileName, err := s.storeAlbumCover(txn, albumId, content)
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(coverTmpFileName)
+
+	// Commit transaction
+	if externalTrn == nil {
+		err = txn.Commit()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err = os.Rename(coverTmpFileName, s.getAlbumCoverFileName(albumId))
+	if err != nil {
+		return nil, err
+	}
+
+	// Embed the cover in songs content
+	err = s.refreshAlbumSongsContentTag(externalTrn, albumId)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.ReadAlbum(externalTrn, albumId)
+}
+
+func (s *Store) DeleteAlbumCover(externalTrn *sqlx.Tx, albumId restApiV1.AlbumId) (*restApiV1.Album, error) {
+	var err error
+
+	// Check available transaction
+	txn := externalTrn
+	if txn == nil {
+		txn, err = s.db.Beginx()
+		if err != nil {
+			return nil, err
+		}
+		defer txn.Rollback()
+	}
+
+	var albumEntity entity.AlbumEntity
+	err = txn.Get(&albumEntity, `SELECT * FROM album WHERE album_id = ?`, albumId)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, storeerror.ErrNotFound
+		}
+		return nil, err
+	}
+
+	if albumEntity.CoverFg {
+		albumEntity.CoverFg = false
+		albumEntity.UpdateTs = time.Now().UnixNano()
+
+		_, err = txn.NamedExec(`
+			UPDATE album
+			SET cover_fg = :cover_fg,
+				update_ts = :update_ts
+			WHERE album_id = :album_id
+		`, &albumEntity)
+		if err != nil {
+			return nil, err
+		}
+
+		// Remove the cover from songs content
+		err = s.refreshAlbumSongsContentTag(txn, albumId)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	album, err := s.ReadAlbum(txn, albumId)
+	if err != nil {
+		return nil, err
+	}
+
+	// Commit transaction
+	if externalTrn == nil {
+		err = txn.Commit()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Remove the picture once the album is no longer flagged
+	err = os.Remove(s.getAlbumCoverFileName(albumId))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	return album, nil
+}
+
+// storeAlbumCover save the album cover picture in a temporary file and flag the album.
+// The caller has to move the temporary file to the album cover file name once the album is flagged
+func (s *Store) storeAlbumCover(txn *sqlx.Tx, albumId restApiV1.AlbumId, content []byte) (string, error) {
+	var albumEntity entity.AlbumEntity
+	err := txn.Get(&albumEntity, `SELECT * FROM album WHERE album_id = ?`, albumId)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return "", storeerror.ErrNotFound
+		}
+		return "", err
+	}
+
+	err = os.MkdirAll(s.serverConfig.GetCompleteConfigAlbumsDirName(), 0770)
+	if err != nil {
+		return "", err
+	}
+	coverTmpFile, err := os.CreateTemp(s.serverConfig.GetCompleteConfigAlbumsDirName(), "cover-*")
+	if err != nil {
+		return "", err
+	}
+	err = coverTmpFile.Chmod(0660)
+	if err == nil {
+		_, err = coverTmpFile.Write(content)
+	}
+	closeErr := coverTmpFile.Close()
+	if err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(coverTmpFile.Name())
+		return "", err
+	}
+
+	albumEntity.CoverFg = true
+	albumEntity.UpdateTs = time.Now().UnixNano()
+
+	_, err = txn.NamedExec(`
+		UPDATE album
+		SET cover_fg = :cover_fg,
+			update_ts = :update_ts
+		WHERE album_id = :album_id
+	`, &albumEntity)
+	if err != nil {
+		os.Remove(coverTmpFile.Name())
+		return "", err
+	}
+
+	return coverTmpFile.Name(), nil
+}
+
+// refreshAlbumSongsContentTag rewrite tags of every song of an album, reading and writing back each song file.
+// Songs update timestamp is kept as only their content tags change
+func (s *Store) refreshAlbumSongsContentTag(externalTrn *sqlx.Tx, albumId restApiV1.AlbumId) error {
+	var err error
+
+	// Check available transaction
+	txn := externalTrn
+	if txn == nil {
+		txn, err = s.db.Beginx()
+		if err != nil {
+			return err
+		}
+		defer txn.Rollback()
+	}
+
+	songEntities := []entity.SongEntity{}
+	err = txn.Select(&songEntities, "SELECT * FROM song WHERE album_id = ?", albumId)
+	if err != nil {
+		return err
+	}
+
+	for _, songEntity := range songEntities {
+		err = s.UpdateSongContentTag(txn, &songEntity)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Commit transaction
+	if externalTrn == nil {
+		return txn.Commit()
+	}
+
+	return nil
+}
+
+// extractSongCover return the picture embedded in song content, or nil if there is none
+func extractSongCover(format restApiV1.SongFormat, content *songContent) *albumCover {
+	var picture []byte
+	switch format {
+	case restApiV1.SongFormatFlac:
+		picture = extractFlacCover(content)
+	case restApiV1.SongFormatMp3:
+		picture = extractMp3Cover(content)
+	case restApiV1.SongFormatOgg:
+		picture = extractOggCover(content)
+	case restApiV1.SongFormatOpus:
+		picture = extractOpusCover(content)
+	case restApiV1.SongFormatM4a:
+		picture = extractM4aCover(content)
+	}
+
+	if picture == nil {
+		return nil
+	}
+
+	cover, err := newAlbumCover(picture)
+	if err != nil {
+		return nil
+	}
+
+	return cover
+}
+
+// pictureBlockFrontCover is the picture type of a front cover (flac PICTURE block & id3v2 APIC frame)
+const pictureBlockFrontCover = 3
+
+// parsePictureBlock decode a flac PICTURE block (also used by vorbis METADATA_BLOCK_PICTURE comment)
+func parsePictureBlock(data []byte) (pictureType uint32, picture []byte, ok bool) {
+	pos := 0
+	readUint32 := func() (uint32, bool) {
+		if pos+4 > len(data) {
+			return 0, false
+		}
+		v := binary.BigEndian.Uint32(data[pos:])
+		pos += 4
+		return v, true
+	}
+
+	if pictureType, ok = readUint32(); !ok {
+		return 0, nil, false
+	}
+	// Skip mime type & description
+	for i := 0; i < 2; i++ {
+		length, ok := readUint32()
+		if !ok || pos+int(length) > len(data) {
+			return 0, nil, false
+		}
+		pos += int(length)
+	}
+	// Skip width, height, color depth & number of colors
+	pos += 16
+	length, ok := readUint32()
+	if !ok || pos+int(length) > len(data) {
+		return 0, nil, false
+	}
+
+	return pictureType, data[pos : pos+int(length)], true
+}
+
+// marshalPictureBlock encode the cover as a flac PICTURE block
+func (c *albumCover) marshalPictureBlock() []byte {
+	buffer := new(bytes.Buffer)
+	writeUint32 := func(v uint32) {
+		binary.Write(buffer, binary.BigEndian, v)
+	}
+
+	writeUint32(pictureBlockFrontCover)
+	writeUint32(uint32(len(c.mimeType)))
+	buffer.WriteString(c.mimeType)
+	writeUint32(0)
+	writeUint32(uint32(c.width))
+	writeUint32(uint32(c.height))
+	writeUint32(24)
+	writeUint32(0)
+	writeUint32(uint32(len(c.content)))
+	buffer.Write(c.content)
+
+	return buffer.Bytes()
+}
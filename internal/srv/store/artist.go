@@ -7,7 +7,9 @@ import (
 	"github.com/jypelle/mifasol/internal/srv/storeerror"
 	"github.com/jypelle/mifasol/internal/tool"
 	"github.com/jypelle/mifasol/restApiV1"
+	"os"
 	"sort"
+	"strings"
 	"time"
 )
 
@@ -29,32 +31,22 @@ func (s *Store) ReadArtists(externalTrn *sqlx.Tx, filter *restApiV1.ArtistFilter
 	}
 
 	queryArgs := make(map[string]interface{})
-	if filter.FromTs != nil {
-		queryArgs["from_ts"] = *filter.FromTs
-	}
-	if filter.Name != nil {
-		queryArgs["name"] = *filter.Name
-	}
-	if filter.SongId != nil {
-		queryArgs["song_id"] = *filter.SongId
-	}
 
-	orderBy := "a.update_ts ASC"
-	if filter.OrderBy != nil {
-		if *filter.OrderBy == restApiV1.ArtistFilterOrderByName {
-			orderBy = "a.name ASC"
-		}
+	sortKeys := artistSortKeys(filter.OrderBy)
+	cursorConditions, err := pageConditions(sortKeys, artistOrderByName(filter.OrderBy), filter.Limit, filter.Cursor, queryArgs)
+	if err != nil {
+		return nil, err
 	}
 
 	rows, err := txn.NamedQuery(
 		`SELECT
 				a.*
 			FROM artist a
-			`+tool.TernStr(filter.SongId != nil, "JOIN artist_song asg ON asg.artist_id = a.artist_id AND asg.song_id = :song_id ", "")+`
 			WHERE 1>0
-			`+tool.TernStr(filter.FromTs != nil, "AND a.update_ts >= :from_ts ", "")+`
-			`+tool.TernStr(filter.Name != nil, "AND a.name LIKE :name ", "")+`
-			ORDER BY `+orderBy,
+			`+artistFilterConditions(filter, queryArgs)+`
+			`+cursorConditions+`
+			ORDER BY `+strings.Join(sortKeys, " ASC, ")+` ASC
+			`+pageLimit(filter.Limit, queryArgs),
 		queryArgs,
 	)
 	if err != nil {
@@ -81,6 +73,121 @@ func (s *Store) ReadArtists(externalTrn *sqlx.Tx, filter *restApiV1.ArtistFilter
 
 }
 
+// artistFilterConditions return the conditions selecting the artists matching a filter
+func artistFilterConditions(filter *restApiV1.ArtistFilter, queryArgs map[string]interface{}) string {
+	if filter.FromTs != nil {
+		queryArgs["from_ts"] = *filter.FromTs
+	}
+	if filter.Name != nil {
+		queryArgs["name"] = *filter.Name
+	}
+	if filter.SongId != nil {
+		queryArgs["song_id"] = *filter.SongId
+	}
+	if filter.Role != nil {
+		queryArgs["role"] = *filter.Role
+	}
+
+	return tool.TernStr(filter.SongId != nil, "AND EXISTS (SELECT 1 FROM artist_song asg WHERE asg.artist_id = a.artist_id AND asg.song_id = :song_id "+tool.TernStr(filter.Role != nil, "AND asg.role = :role", "")+") ", "") +
+		tool.TernStr(filter.FromTs != nil, "AND a.update_ts >= :from_ts ", "") +
+		tool.TernStr(filter.Name != nil, "AND a.name LIKE :name ", "")
+}
+
+func artistOrderByName(orderBy *restApiV1.ArtistFilterOrderBy) string {
+	if orderBy == nil {
+		return ""
+	}
+	return string(*orderBy)
+}
+
+// artistSortKeys return the sql expressions artists are sorted by, the artist id comes last to get a total order
+func artistSortKeys(orderBy *restApiV1.ArtistFilterOrderBy) []string {
+	switch artistOrderByName(orderBy) {
+	case string(restApiV1.ArtistFilterOrderByName):
+		return []string{"CASE WHEN a.sort_name <> '' THEN a.sort_name ELSE a.name END", "a.name", "a.artist_id"}
+	case string(restApiV1.ArtistFilterOrderByCreationTs):
+		return []string{"a.creation_ts", "a.artist_id"}
+	default:
+		return []string{"a.update_ts", "a.artist_id"}
+	}
+}
+
+// artistSortKeyValues return the values of the artist sort keys
+func artistSortKeyValues(artist *restApiV1.Artist, orderBy *restApiV1.ArtistFilterOrderBy) []interface{} {
+	switch artistOrderByName(orderBy) {
+	case string(restApiV1.ArtistFilterOrderByName):
+		return []interface{}{artist.GetSortName(), artist.Name, artist.Id}
+	case string(restApiV1.ArtistFilterOrderByCreationTs):
+		return []interface{}{artist.CreationTs, artist.Id}
+	default:
+		return []interface{}{artist.UpdateTs, artist.Id}
+	}
+}
+
+// CountArtists return the number of artists matching a filter, paging excluded
+func (s *Store) CountArtists(externalTrn *sqlx.Tx, filter *restApiV1.ArtistFilter) (int64, error) {
+	var err error
+
+	// Check available transaction
+	txn := externalTrn
+	if txn == nil {
+		txn, err = s.db.Beginx()
+		if err != nil {
+			return 0, err
+		}
+		defer txn.Rollback()
+	}
+
+	queryArgs := make(map[string]interface{})
+
+	return namedCount(txn, "SELECT count(*) FROM artist a WHERE 1>0 "+artistFilterConditions(filter, queryArgs), queryArgs)
+}
+
+// ReadArtistsPage return a page of artists, the cursor of the next page (empty for the last page) and the number of artists matching the filter
+func (s *Store) ReadArtistsPage(externalTrn *sqlx.Tx, filter *restApiV1.ArtistFilter) ([]restApiV1.Artist, string, int64, error) {
+	var err error
+
+	// Check available transaction
+	txn := externalTrn
+	if txn == nil {
+		txn, err = s.db.Beginx()
+		if err != nil {
+			return nil, "", 0, err
+		}
+		defer txn.Rollback()
+	}
+
+	totalCount, err := s.CountArtists(txn, filter)
+	if err != nil {
+		return nil, "", 0, err
+	}
+
+	if filter.Limit == nil {
+		artists, err := s.ReadArtists(txn, filter)
+		return artists, "", totalCount, err
+	}
+
+	// Read one more artist to know if there is a next page
+	pageFilter := *filter
+	limit := *filter.Limit + 1
+	pageFilter.Limit = &limit
+
+	artists, err := s.ReadArtists(txn, &pageFilter)
+	if err != nil {
+		return nil, "", 0, err
+	}
+
+	var nextCursor string
+	if len(artists) > *filter.Limit {
+		artists = artists[:*filter.Limit]
+		if len(artists) > 0 {
+			nextCursor = encodePageCursor(artistOrderByName(filter.OrderBy), artistSortKeyValues(&artists[len(artists)-1], filter.OrderBy))
+		}
+	}
+
+	return artists, nextCursor, totalCount, nil
+}
+
 func (s *Store) ReadArtist(externalTrn *sqlx.Tx, artistId restApiV1.ArtistId) (*restApiV1.Artist, error) {
 	var err error
 
@@ -138,16 +245,24 @@ func (s *Store) CreateArtist(externalTrn *sqlx.Tx, artistMeta *restApiV1.ArtistM
 			    artist_id,
 				creation_ts,
 			    update_ts,
-				name
+				name,
+				sort_name,
+				biography
 			)
 			VALUES (
 			    :artist_id,
 				:creation_ts,
 				:update_ts,
-				:name
+				:name,
+				:sort_name,
+				:biography
 			)
 	`, &artistEntity)
 
+	if err != nil {
+		return nil, err
+	}
+	err = artistSearchIndex.index(txn, string(artistEntity.ArtistId), artistEntity.Name)
 	if err != nil {
 		return nil, err
 	}
@@ -192,10 +307,16 @@ func (s *Store) UpdateArtist(externalTrn *sqlx.Tx, artistId restApiV1.ArtistId,
 	_, err = txn.NamedExec(`
 		UPDATE artist
 		SET name = :name,
+			sort_name = :sort_name,
+			biography = :biography,
 			update_ts = :update_ts
 		WHERE artist_id = :artist_id
 	`, &artistEntity)
 
+	if err != nil {
+		return nil, err
+	}
+	err = artistSearchIndex.index(txn, string(artistId), artistEntity.Name)
 	if err != nil {
 		return nil, err
 	}
@@ -209,6 +330,16 @@ func (s *Store) UpdateArtist(externalTrn *sqlx.Tx, artistId restApiV1.ArtistId,
 		for _, song := range songs {
 			s.UpdateSong(txn, song.Id, nil, &artistId, false)
 		}
+
+		// Update albums having the artist as album artist
+		albumIds, err := s.readArtistAlbumIds(txn, artistId)
+		if err != nil {
+			return nil, err
+		}
+		err = s.touchAlbums(txn, albumIds)
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	// Commit transaction
@@ -261,6 +392,32 @@ func (s *Store) DeleteArtist(externalTrn *sqlx.Tx, artistId restApiV1.ArtistId)
 	if err != nil {
 		return nil, err
 	}
+	err = artistSearchIndex.unindex(txn, string(artistId))
+	if err != nil {
+		return nil, err
+	}
+
+	// Delete album artists link
+	albumIds, err := s.readArtistAlbumIds(txn, artistId)
+	if err != nil {
+		return nil, err
+	}
+	_, err = txn.Exec("DELETE FROM album_artist WHERE artist_id = ?", artistId)
+	if err != nil {
+		return nil, err
+	}
+	err = s.touchAlbums(txn, albumIds)
+	if err != nil {
+		return nil, err
+	}
+
+	// Remove artist picture
+	if artistEntity.PictureFg {
+		err = os.Remove(s.getArtistPictureFileName(artistId))
+		if err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+	}
 
 	// Archive artistId
 	_, err = txn.NamedExec(`
@@ -396,6 +553,21 @@ func isArtistIdsEqual(a, b []restApiV1.ArtistId) bool {
 	return true
 }
 
+// isArtistIdsOverlapping check if two artist lists share an artist, two empty lists being considered as overlapping
+func isArtistIdsOverlapping(a, b []restApiV1.ArtistId) bool {
+	if len(a) == 0 && len(b) == 0 {
+		return true
+	}
+	for _, v := range a {
+		for _, w := range b {
+			if v == w {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 func (s *Store) sortArtistIds(externalTrn *sqlx.Tx, artistIds []restApiV1.ArtistId) error {
 
 	var artists []*restApiV1.Artist
@@ -0,0 +1,149 @@
+package store
+
+import (
+	"database/sql"
+	"github.com/jmoiron/sqlx"
+	"github.com/jypelle/mifasol/internal/srv/entity"
+	"github.com/jypelle/mifasol/internal/srv/storeerror"
+	"github.com/jypelle/mifasol/restApiV1"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+func (s *Store) getArtistPictureFileName(artistId restApiV1.ArtistId) string {
+	return filepath.Join(s.serverConfig.GetCompleteConfigAuthorsDirName(), string(artistId))
+}
+
+func (s *Store) ReadArtistPicture(externalTrn *sqlx.Tx, artistId restApiV1.ArtistId) (*os.File, *restApiV1.Artist, error) {
+	artist, err := s.ReadArtist(externalTrn, artistId)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if !artist.PictureFg {
+		return nil, nil, storeerror.ErrNotFound
+	}
+
+	file, err := os.Open(s.getArtistPictureFileName(artistId))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return file, artist, nil
+}
+
+func (s *Store) UpdateArtistPicture(externalTrn *sqlx.Tx, artistId restApiV1.ArtistId, content []byte) (*restApiV1.Artist, error) {
+	var err error
+
+	// Check available transaction
+	txn := externalTrn
+	if txn == nil {
+		txn, err = s.db.Beginx()
+		if err != nil {
+			return nil, err
+		}
+		defer txn.Rollback()
+	}
+
+	// Artist pictures follow the same rules as album covers
+	_, err = newAlbumCover(content)
+	if err != nil {
+		return nil, storeerror.ErrInvalidArtistPicture
+	}
+
+	var artistEntity entity.ArtistEntity
+	err = txn.Get(&artistEntity, `SELECT * FROM artist WHERE artist_id = ?`, artistId)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, storeerror.ErrNotFound
+		}
+		return nil, err
+	}
+
+	err = os.MkdirAll(s.serverConfig.GetCompleteConfigAuthorsDirName(), 0770)
+	if err != nil {
+		return nil, err
+	}
+	err = ioutil.WriteFile(s.getArtistPictureFileName(artistId), content, 0660)
+	if err != nil {
+		return nil, err
+	}
+
+	artistEntity.PictureFg = true
+	artistEntity.UpdateTs = time.Now().UnixNano()
+
+	_, err = txn.NamedExec(`
+		UPDATE artist
+		SET picture_fg = :picture_fg,
+			update_ts = :update_ts
+		WHERE artist_id = :artist_id
+	`, &artistEntity)
+	if err != nil {
+		return nil, err
+	}
+
+	// Commit transaction
+	if externalTrn == nil {
+		txn.Commit()
+	}
+
+	var artist restApiV1.Artist
+	artistEntity.Fill(&artist)
+
+	return &artist, nil
+}
+
+func (s *Store) DeleteArtistPicture(externalTrn *sqlx.Tx, artistId restApiV1.ArtistId) (*restApiV1.Artist, error) {
+	var err error
+
+	// Check available transaction
+	txn := externalTrn
+	if txn == nil {
+		txn, err = s.db.Beginx()
+		if err != nil {
+			return nil, err
+		}
+		defer txn.Rollback()
+	}
+
+	var artistEntity entity.ArtistEntity
+	err = txn.Get(&artistEntity, `SELECT * FROM artist WHERE artist_id = ?`, artistId)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, storeerror.ErrNotFound
+		}
+		return nil, err
+	}
+
+	if artistEntity.PictureFg {
+		artistEntity.PictureFg = false
+		artistEntity.UpdateTs = time.Now().UnixNano()
+
+		_, err = txn.NamedExec(`
+			UPDATE artist
+			SET picture_fg = :picture_fg,
+				update_ts = :update_ts
+			WHERE artist_id = :artist_id
+		`, &artistEntity)
+		if err != nil {
+			return nil, err
+		}
+
+		err = os.Remove(s.getArtistPictureFileName(artistId))
+		if err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+	}
+
+	// Commit transaction
+	if externalTrn == nil {
+		txn.Commit()
+	}
+
+	var artist restApiV1.Artist
+	artistEntity.Fill(&artist)
+
+	return &artist, nil
+}
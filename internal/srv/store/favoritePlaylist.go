@@ -123,6 +123,12 @@ func (s *Store) CreateFavoritePlaylist(externalTrn *sqlx.Tx, favoritePlaylistMet
 		if err != nil {
 			return nil, err
 		}
+
+		// Add smart playlist songs to favorite songs
+		err = s.addSmartPlaylistSongsToFavorite(txn, favoritePlaylistMeta.Id.UserId, favoritePlaylistMeta.Id.PlaylistId)
+		if err != nil {
+			return nil, err
+		}
 		/*
 			// Add favorite playlist songs to favorite songs
 			_, err = txn.NamedExec(`
@@ -180,6 +186,9 @@ func (s *Store) CreateFavoritePlaylist(externalTrn *sqlx.Tx, favoritePlaylistMet
 		if externalTrn == nil {
 			txn.Commit()
 		}
+
+		// Refresh smart playlists in background
+		s.requestSmartPlaylistsRefresh()
 	}
 
 	var favoritePlaylist restApiV1.FavoritePlaylist
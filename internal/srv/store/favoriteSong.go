@@ -64,6 +64,12 @@ func (s *Store) ReadFavoriteSongs(externalTrn *sqlx.Tx, filter *restApiV1.Favori
 }
 
 func (s *Store) CreateFavoriteSong(externalTrn *sqlx.Tx, favoriteSongMeta *restApiV1.FavoriteSongMeta, check bool) (*restApiV1.FavoriteSong, error) {
+	return s.createFavoriteSong(externalTrn, favoriteSongMeta, false)
+}
+
+// createFavoriteSong add a song to the favorite songs of a user, flagging it when a favorite smart playlist brought it.
+// A song the user explicitly adds loses the flag, so it is kept when it stops matching the smart playlist rules
+func (s *Store) createFavoriteSong(externalTrn *sqlx.Tx, favoriteSongMeta *restApiV1.FavoriteSongMeta, fromSmartPlaylist bool) (*restApiV1.FavoriteSong, error) {
 	var err error
 
 	// Check available transaction
@@ -87,7 +93,8 @@ func (s *Store) CreateFavoriteSong(externalTrn *sqlx.Tx, favoriteSongMeta *restA
 		now := time.Now().UnixNano()
 
 		favoriteSongEntity = entity.FavoriteSongEntity{
-			UpdateTs: now,
+			UpdateTs:        now,
+			SmartPlaylistFg: fromSmartPlaylist,
 		}
 		favoriteSongEntity.LoadMeta(favoriteSongMeta)
 
@@ -95,12 +102,14 @@ func (s *Store) CreateFavoriteSong(externalTrn *sqlx.Tx, favoriteSongMeta *restA
 			INSERT INTO	favorite_song (
 			    user_id,
 				song_id,
-			    update_ts
+			    update_ts,
+				smart_playlist_fg
 			)
 			VALUES (
 			    :user_id,
 				:song_id,
-				:update_ts
+				:update_ts,
+				:smart_playlist_fg
 			)
 		`, &favoriteSongEntity)
 		if err != nil {
@@ -144,6 +153,21 @@ func (s *Store) CreateFavoriteSong(externalTrn *sqlx.Tx, favoriteSongMeta *restA
 		if externalTrn == nil {
 			txn.Commit()
 		}
+
+		// Refresh smart playlists in background
+		s.requestSmartPlaylistsRefresh()
+	} else if favoriteSongEntity.SmartPlaylistFg && !fromSmartPlaylist {
+		// The user now explicitly holds the song
+		favoriteSongEntity.SmartPlaylistFg = false
+		_, err = txn.Exec("UPDATE favorite_song SET smart_playlist_fg = 0 WHERE user_id = ? AND song_id = ?", favoriteSongEntity.UserId, favoriteSongEntity.SongId)
+		if err != nil {
+			return nil, err
+		}
+
+		// Commit transaction
+		if externalTrn == nil {
+			txn.Commit()
+		}
 	}
 
 	var favoriteSong restApiV1.FavoriteSong
@@ -224,6 +248,9 @@ func (s *Store) DeleteFavoriteSong(externalTrn *sqlx.Tx, favoriteSongId restApiV
 		txn.Commit()
 	}
 
+	// Refresh smart playlists in background
+	s.requestSmartPlaylistsRefresh()
+
 	var favoriteSong restApiV1.FavoriteSong
 	favoriteSongEntity.Fill(&favoriteSong)
 
@@ -0,0 +1,526 @@
+package store
+
+import (
+	"database/sql"
+	"github.com/jmoiron/sqlx"
+	"github.com/jypelle/mifasol/internal/srv/entity"
+	"github.com/jypelle/mifasol/internal/srv/storeerror"
+	"github.com/jypelle/mifasol/internal/tool"
+	"github.com/jypelle/mifasol/restApiV1"
+	"sort"
+	"strings"
+	"time"
+)
+
+func (s *Store) ReadGenres(externalTrn *sqlx.Tx, filter *restApiV1.GenreFilter) ([]restApiV1.Genre, error) {
+	if s.serverConfig.DebugMode {
+		defer tool.TimeTrack(time.Now(), "ReadGenres")
+	}
+
+	var err error
+
+	// Check available transaction
+	txn := externalTrn
+	if txn == nil {
+		txn, err = s.db.Beginx()
+		if err != nil {
+			return nil, err
+		}
+		defer txn.Rollback()
+	}
+
+	queryArgs := make(map[string]interface{})
+
+	sortKeys := genreSortKeys(filter.OrderBy)
+	cursorConditions, err := pageConditions(sortKeys, genreOrderByName(filter.OrderBy), filter.Limit, filter.Cursor, queryArgs)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := txn.NamedQuery(
+		`SELECT
+				g.*
+			FROM genre g
+			WHERE 1>0
+			`+genreFilterConditions(filter, queryArgs)+`
+			`+cursorConditions+`
+			ORDER BY `+strings.Join(sortKeys, " ASC, ")+` ASC
+			`+pageLimit(filter.Limit, queryArgs),
+		queryArgs,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	genres := []restApiV1.Genre{}
+
+	for rows.Next() {
+		var genreEntity entity.GenreEntity
+		err = rows.StructScan(&genreEntity)
+		if err != nil {
+			return nil, err
+		}
+
+		var genre restApiV1.Genre
+		genreEntity.Fill(&genre)
+
+		genres = append(genres, genre)
+	}
+
+	return genres, nil
+
+}
+
+// genreFilterConditions return the conditions selecting the genres matching a filter
+func genreFilterConditions(filter *restApiV1.GenreFilter, queryArgs map[string]interface{}) string {
+	if filter.FromTs != nil {
+		queryArgs["from_ts"] = *filter.FromTs
+	}
+	if filter.Name != nil {
+		queryArgs["name"] = *filter.Name
+	}
+	if filter.SongId != nil {
+		queryArgs["song_id"] = *filter.SongId
+	}
+
+	return tool.TernStr(filter.SongId != nil, "AND EXISTS (SELECT 1 FROM genre_song gs WHERE gs.genre_id = g.genre_id AND gs.song_id = :song_id) ", "") +
+		tool.TernStr(filter.FromTs != nil, "AND g.update_ts >= :from_ts ", "") +
+		tool.TernStr(filter.Name != nil, "AND g.name LIKE :name ", "")
+}
+
+func genreOrderByName(orderBy *restApiV1.GenreFilterOrderBy) string {
+	if orderBy == nil {
+		return ""
+	}
+	return string(*orderBy)
+}
+
+// genreSortKeys return the sql expressions genres are sorted by, the genre id comes last to get a total order
+func genreSortKeys(orderBy *restApiV1.GenreFilterOrderBy) []string {
+	switch genreOrderByName(orderBy) {
+	case string(restApiV1.GenreFilterOrderByName):
+		return []string{"g.name", "g.genre_id"}
+	case string(restApiV1.GenreFilterOrderByCreationTs):
+		return []string{"g.creation_ts", "g.genre_id"}
+	default:
+		return []string{"g.update_ts", "g.genre_id"}
+	}
+}
+
+// genreSortKeyValues return the values of the genre sort keys
+func genreSortKeyValues(genre *restApiV1.Genre, orderBy *restApiV1.GenreFilterOrderBy) []interface{} {
+	switch genreOrderByName(orderBy) {
+	case string(restApiV1.GenreFilterOrderByName):
+		return []interface{}{genre.Name, genre.Id}
+	case string(restApiV1.GenreFilterOrderByCreationTs):
+		return []interface{}{genre.CreationTs, genre.Id}
+	default:
+		return []interface{}{genre.UpdateTs, genre.Id}
+	}
+}
+
+// CountGenres return the number of genres matching a filter, paging excluded
+func (s *Store) CountGenres(externalTrn *sqlx.Tx, filter *restApiV1.GenreFilter) (int64, error) {
+	var err error
+
+	// Check available transaction
+	txn := externalTrn
+	if txn == nil {
+		txn, err = s.db.Beginx()
+		if err != nil {
+			return 0, err
+		}
+		defer txn.Rollback()
+	}
+
+	queryArgs := make(map[string]interface{})
+
+	return namedCount(txn, "SELECT count(*) FROM genre g WHERE 1>0 "+genreFilterConditions(filter, queryArgs), queryArgs)
+}
+
+// ReadGenresPage return a page of genres, the cursor of the next page (empty for the last page) and the number of genres matching the filter
+func (s *Store) ReadGenresPage(externalTrn *sqlx.Tx, filter *restApiV1.GenreFilter) ([]restApiV1.Genre, string, int64, error) {
+	var err error
+
+	// Check available transaction
+	txn := externalTrn
+	if txn == nil {
+		txn, err = s.db.Beginx()
+		if err != nil {
+			return nil, "", 0, err
+		}
+		defer txn.Rollback()
+	}
+
+	totalCount, err := s.CountGenres(txn, filter)
+	if err != nil {
+		return nil, "", 0, err
+	}
+
+	if filter.Limit == nil {
+		genres, err := s.ReadGenres(txn, filter)
+		return genres, "", totalCount, err
+	}
+
+	// Read one more genre to know if there is a next page
+	pageFilter := *filter
+	limit := *filter.Limit + 1
+	pageFilter.Limit = &limit
+
+	genres, err := s.ReadGenres(txn, &pageFilter)
+	if err != nil {
+		return nil, "", 0, err
+	}
+
+	var nextCursor string
+	if len(genres) > *filter.Limit {
+		genres = genres[:*filter.Limit]
+		if len(genres) > 0 {
+			nextCursor = encodePageCursor(genreOrderByName(filter.OrderBy), genreSortKeyValues(&genres[len(genres)-1], filter.OrderBy))
+		}
+	}
+
+	return genres, nextCursor, totalCount, nil
+}
+
+func (s *Store) ReadGenre(externalTrn *sqlx.Tx, genreId restApiV1.GenreId) (*restApiV1.Genre, error) {
+	var err error
+
+	// Check available transaction
+	txn := externalTrn
+	if txn == nil {
+		txn, err = s.db.Beginx()
+		if err != nil {
+			return nil, err
+		}
+		defer txn.Rollback()
+	}
+
+	var genreEntity entity.GenreEntity
+
+	err = txn.Get(&genreEntity, "SELECT * FROM genre WHERE genre_id = ?", genreId)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, storeerror.ErrNotFound
+		}
+		return nil, err
+	}
+
+	var genre restApiV1.Genre
+	genreEntity.Fill(&genre)
+
+	return &genre, nil
+}
+
+func (s *Store) CreateGenre(externalTrn *sqlx.Tx, genreMeta *restApiV1.GenreMeta) (*restApiV1.Genre, error) {
+	var err error
+
+	// Check available transaction
+	txn := externalTrn
+	if txn == nil {
+		txn, err = s.db.Beginx()
+		if err != nil {
+			return nil, err
+		}
+		defer txn.Rollback()
+	}
+
+	// Store genre
+	now := time.Now().UnixNano()
+
+	genreEntity := entity.GenreEntity{
+		GenreId:    restApiV1.GenreId(tool.CreateUlid()),
+		CreationTs: now,
+		UpdateTs:   now,
+	}
+	genreEntity.LoadMeta(genreMeta)
+
+	_, err = txn.NamedExec(`
+			INSERT INTO	genre (
+			    genre_id,
+				creation_ts,
+			    update_ts,
+				name
+			)
+			VALUES (
+			    :genre_id,
+				:creation_ts,
+				:update_ts,
+				:name
+			)
+	`, &genreEntity)
+
+	if err != nil {
+		return nil, err
+	}
+
+	// Commit transaction
+	if externalTrn == nil {
+		txn.Commit()
+	}
+
+	var genre restApiV1.Genre
+	genreEntity.Fill(&genre)
+
+	return &genre, nil
+
+}
+
+func (s *Store) UpdateGenre(externalTrn *sqlx.Tx, genreId restApiV1.GenreId, genreMeta *restApiV1.GenreMeta) (*restApiV1.Genre, error) {
+	var err error
+
+	// Check available transaction
+	txn := externalTrn
+	if txn == nil {
+		txn, err = s.db.Beginx()
+		if err != nil {
+			return nil, err
+		}
+		defer txn.Rollback()
+	}
+
+	var genreEntity entity.GenreEntity
+	err = txn.Get(&genreEntity, "SELECT * FROM genre WHERE genre_id = ?", genreId)
+	if err != nil {
+		return nil, err
+	}
+
+	oldName := genreEntity.Name
+
+	genreEntity.LoadMeta(genreMeta)
+	genreEntity.UpdateTs = time.Now().UnixNano()
+
+	// Update genre
+	_, err = txn.NamedExec(`
+		UPDATE genre
+		SET name = :name,
+			update_ts = :update_ts
+		WHERE genre_id = :genre_id
+	`, &genreEntity)
+
+	if err != nil {
+		return nil, err
+	}
+
+	// Update tags in songs content
+	if oldName != genreEntity.Name {
+		songs, err := s.ReadSongs(txn, &restApiV1.SongFilter{GenreId: &genreId})
+		if err != nil {
+			return nil, err
+		}
+		for _, song := range songs {
+			s.UpdateSong(txn, song.Id, nil, nil, false)
+		}
+	}
+
+	// Commit transaction
+	if externalTrn == nil {
+		txn.Commit()
+	}
+
+	var genre restApiV1.Genre
+	genreEntity.Fill(&genre)
+
+	return &genre, nil
+}
+
+func (s *Store) DeleteGenre(externalTrn *sqlx.Tx, genreId restApiV1.GenreId) (*restApiV1.Genre, error) {
+	if s.serverConfig.DebugMode {
+		defer tool.TimeTrack(time.Now(), "DeleteGenre")
+	}
+
+	var err error
+
+	// Check available transaction
+	txn := externalTrn
+	if txn == nil {
+		txn, err = s.db.Beginx()
+		if err != nil {
+			return nil, err
+		}
+		defer txn.Rollback()
+	}
+
+	deleteTs := time.Now().UnixNano()
+
+	var genreEntity entity.GenreEntity
+	err = txn.Get(&genreEntity, `SELECT * FROM genre WHERE genre_id = ?`, genreId)
+	if err != nil {
+		return nil, err
+	}
+
+	// Check songs link
+	songs, err := s.ReadSongs(txn, &restApiV1.SongFilter{GenreId: &genreId})
+	if err != nil {
+		return nil, err
+	}
+	if len(songs) > 0 {
+		return nil, storeerror.ErrDeleteGenreWithSongs
+	}
+
+	// Delete genre
+	_, err = txn.Exec("DELETE FROM genre WHERE genre_id = ?", genreId)
+	if err != nil {
+		return nil, err
+	}
+
+	// Archive genreId
+	_, err = txn.NamedExec(`
+			INSERT INTO	deleted_genre (
+			    genre_id,
+				delete_ts
+			)
+			VALUES (
+			    :genre_id,
+				:delete_ts
+			)
+	`, &entity.DeletedGenreEntity{GenreId: genreEntity.GenreId, DeleteTs: deleteTs})
+	if err != nil {
+		return nil, err
+	}
+
+	// Commit transaction
+	if externalTrn == nil {
+		txn.Commit()
+	}
+
+	var genre restApiV1.Genre
+	genreEntity.Fill(&genre)
+
+	return &genre, nil
+}
+
+func (s *Store) GetDeletedGenreIds(externalTrn *sqlx.Tx, fromTs int64) ([]restApiV1.GenreId, error) {
+	if s.serverConfig.DebugMode {
+		defer tool.TimeTrack(time.Now(), "GetDeletedGenreIds")
+	}
+
+	var err error
+
+	// Check available transaction
+	txn := externalTrn
+	if txn == nil {
+		txn, err = s.db.Beginx()
+		if err != nil {
+			return nil, err
+		}
+		defer txn.Rollback()
+	}
+
+	queryArgs := make(map[string]interface{})
+	queryArgs["from_ts"] = fromTs
+	rows, err := txn.NamedQuery(
+		`SELECT
+				g.*
+			FROM deleted_genre g
+			WHERE g.delete_ts >= :from_ts
+			ORDER BY g.delete_ts ASC
+		`,
+		queryArgs,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	genreIds := []restApiV1.GenreId{}
+
+	for rows.Next() {
+		var deletedGenreEntity entity.DeletedGenreEntity
+		err = rows.StructScan(&deletedGenreEntity)
+		if err != nil {
+			return nil, err
+		}
+
+		genreIds = append(genreIds, deletedGenreEntity.GenreId)
+	}
+
+	return genreIds, nil
+}
+
+func (s *Store) getGenreIdsFromGenreNames(externalTrn *sqlx.Tx, genreNames []string) ([]restApiV1.GenreId, error) {
+	var e error
+
+	// Check available transaction
+	txn := externalTrn
+	if txn == nil {
+		txn, e = s.db.Beginx()
+		if e != nil {
+			return nil, e
+		}
+		defer txn.Rollback()
+	}
+
+	var genreIds []restApiV1.GenreId
+
+	for _, genreName := range genreNames {
+		genreName = normalizeString(genreName)
+		if genreName != "" {
+			var genres []restApiV1.Genre
+			genres, e = s.ReadGenres(txn, &restApiV1.GenreFilter{Name: &genreName})
+
+			if e != nil {
+				return nil, e
+			}
+			var genreId restApiV1.GenreId
+			if len(genres) > 0 {
+				// Link the song to an existing genre
+				genreId = genres[0].Id
+			} else {
+				// Create the genre before linking it to the song
+				genre, err := s.CreateGenre(txn, &restApiV1.GenreMeta{Name: genreName})
+				if err != nil {
+					return nil, err
+				}
+				genreId = genre.Id
+			}
+			genreIds = append(genreIds, genreId)
+		}
+	}
+
+	// Commit transaction
+	if externalTrn == nil {
+		txn.Commit()
+	}
+
+	return genreIds, nil
+}
+
+func isGenreIdsEqual(a, b []restApiV1.GenreId) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i, v := range a {
+		if v != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *Store) sortGenreIds(externalTrn *sqlx.Tx, genreIds []restApiV1.GenreId) error {
+
+	var genres []*restApiV1.Genre
+
+	for _, genreId := range genreIds {
+		genre, e := s.ReadGenre(externalTrn, genreId)
+		if e != nil {
+			return e
+		}
+		genres = append(genres, genre)
+	}
+
+	sort.Slice(genreIds, func(i, j int) bool {
+		genreI := genres[i]
+		genreJ := genres[j]
+		if genreI.Name < genreJ.Name {
+			return true
+		}
+		if genreI.Name > genreJ.Name {
+			return false
+		}
+		return genreI.Id < genreJ.Id
+	})
+	return nil
+}
@@ -0,0 +1,216 @@
+package store
+
+import (
+	"math"
+)
+
+// EBU R128 / ITU-R BS.1770 loudness meter
+
+const (
+	loudnessAbsoluteGate = -70.0
+	loudnessRelativeGate = -10.0
+
+	// truePeakTapsPerPhase is the length of each polyphase branch of the true peak interpolation filter
+	truePeakTapsPerPhase = 12
+)
+
+type biquadFilter struct {
+	b0, b1, b2 float64
+	a1, a2     float64
+	z1, z2     float64
+}
+
+func (f *biquadFilter) process(x float64) float64 {
+	y := f.b0*x + f.z1
+	f.z1 = f.b1*x - f.a1*y + f.z2
+	f.z2 = f.b2*x - f.a2*y
+	return y
+}
+
+// newKWeightingFilters return the pre-filter (high shelf) and the RLB filter (high pass) of BS.1770 for the given sample rate
+func newKWeightingFilters(sampleRate float64) (biquadFilter, biquadFilter) {
+	// High shelf
+	f0 := 1681.974450955533
+	g := 3.999843853973347
+	q := 0.7071752369554196
+	k := math.Tan(math.Pi * f0 / sampleRate)
+	vh := math.Pow(10, g/20)
+	vb := math.Pow(vh, 0.4996667741545416)
+	a0 := 1 + k/q + k*k
+	shelf := biquadFilter{
+		b0: (vh + vb*k/q + k*k) / a0,
+		b1: 2 * (k*k - vh) / a0,
+		b2: (vh - vb*k/q + k*k) / a0,
+		a1: 2 * (k*k - 1) / a0,
+		a2: (1 - k/q + k*k) / a0,
+	}
+
+	// High pass
+	f0 = 38.13547087602444
+	q = 0.5003270373238773
+	k = math.Tan(math.Pi * f0 / sampleRate)
+	a0 = 1 + k/q + k*k
+	highPass := biquadFilter{
+		b0: 1,
+		b1: -2,
+		b2: 1,
+		a1: 2 * (k*k - 1) / a0,
+		a2: (1 - k/q + k*k) / a0,
+	}
+
+	return shelf, highPass
+}
+
+type loudnessMeter struct {
+	channels int
+
+	// K-weighting filters per channel
+	shelves    []biquadFilter
+	highPasses []biquadFilter
+
+	// Mean square of every 100ms step, gating blocks are 4 consecutive steps (75% overlap)
+	stepLength int
+	stepFrames int
+	stepEnergy float64
+	steps      []float64
+
+	// True peak interpolation
+	oversampling int
+	coefficients []float64
+	histories    [][]float64
+	truePeak     float64
+}
+
+func newLoudnessMeter(sampleRate int, channels int) *loudnessMeter {
+	m := &loudnessMeter{
+		channels:   channels,
+		shelves:    make([]biquadFilter, channels),
+		highPasses: make([]biquadFilter, channels),
+		stepLength: sampleRate / 10,
+		histories:  make([][]float64, channels),
+	}
+
+	for c := 0; c < channels; c++ {
+		m.shelves[c], m.highPasses[c] = newKWeightingFilters(float64(sampleRate))
+		m.histories[c] = make([]float64, truePeakTapsPerPhase)
+	}
+
+	// Interpolate up to at least 192kHz to catch inter-sample peaks
+	m.oversampling = 1
+	for sampleRate*m.oversampling < 192000 && m.oversampling < 4 {
+		m.oversampling *= 2
+	}
+	m.coefficients = truePeakCoefficients(m.oversampling)
+
+	return m
+}
+
+// truePeakCoefficients return a windowed sinc low pass filter interpolating by the given factor
+func truePeakCoefficients(oversampling int) []float64 {
+	length := oversampling * truePeakTapsPerPhase
+	coefficients := make([]float64, length)
+	center := float64(length-1) / 2
+	for i := range coefficients {
+		x := (float64(i) - center) / float64(oversampling)
+		sinc := 1.0
+		if x != 0 {
+			sinc = math.Sin(math.Pi*x) / (math.Pi * x)
+		}
+		// Blackman window
+		window := 0.42 - 0.5*math.Cos(2*math.Pi*float64(i)/float64(length-1)) + 0.08*math.Cos(4*math.Pi*float64(i)/float64(length-1))
+		coefficients[i] = sinc * window
+	}
+
+	// Unity gain for each phase
+	for phase := 0; phase < oversampling; phase++ {
+		sum := 0.0
+		for tap := 0; tap < truePeakTapsPerPhase; tap++ {
+			sum += coefficients[tap*oversampling+phase]
+		}
+		for tap := 0; tap < truePeakTapsPerPhase; tap++ {
+			coefficients[tap*oversampling+phase] /= sum
+		}
+	}
+
+	return coefficients
+}
+
+// addFrame feed the meter with one sample per channel
+func (m *loudnessMeter) addFrame(samples []float64) {
+	for c := 0; c < m.channels; c++ {
+		x := samples[c]
+
+		// Loudness
+		y := m.highPasses[c].process(m.shelves[c].process(x))
+		m.stepEnergy += y * y
+
+		// True peak
+		history := m.histories[c]
+		copy(history[1:], history[:len(history)-1])
+		history[0] = x
+		for phase := 0; phase < m.oversampling; phase++ {
+			value := 0.0
+			for tap, h := range history {
+				value += m.coefficients[tap*m.oversampling+phase] * h
+			}
+			if math.Abs(value) > m.truePeak {
+				m.truePeak = math.Abs(value)
+			}
+		}
+		if math.Abs(x) > m.truePeak {
+			m.truePeak = math.Abs(x)
+		}
+	}
+
+	m.stepFrames++
+	if m.stepFrames == m.stepLength {
+		m.steps = append(m.steps, m.stepEnergy/float64(m.stepLength))
+		m.stepEnergy = 0
+		m.stepFrames = 0
+	}
+}
+
+// integratedLoudness return the gated loudness in LUFS, ok is false when the content is too short or silent
+func (m *loudnessMeter) integratedLoudness() (loudness float64, ok bool) {
+	var blocks []float64
+	for i := 3; i < len(m.steps); i++ {
+		block := (m.steps[i-3] + m.steps[i-2] + m.steps[i-1] + m.steps[i]) / 4
+		if energyToLoudness(block) > loudnessAbsoluteGate {
+			blocks = append(blocks, block)
+		}
+	}
+	if len(blocks) == 0 {
+		return 0, false
+	}
+
+	relativeGate := energyToLoudness(meanEnergy(blocks, 0)) + loudnessRelativeGate
+	energy := meanEnergy(blocks, loudnessToEnergy(relativeGate))
+	if energy == 0 {
+		return 0, false
+	}
+
+	return energyToLoudness(energy), true
+}
+
+func meanEnergy(blocks []float64, threshold float64) float64 {
+	sum := 0.0
+	count := 0
+	for _, block := range blocks {
+		if block > threshold {
+			sum += block
+			count++
+		}
+	}
+	if count == 0 {
+		return 0
+	}
+	return sum / float64(count)
+}
+
+func energyToLoudness(energy float64) float64 {
+	return -0.691 + 10*math.Log10(energy)
+}
+
+func loudnessToEnergy(loudness float64) float64 {
+	return math.Pow(10, (loudness+0.691)/10)
+}
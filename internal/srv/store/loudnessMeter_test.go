@@ -0,0 +1,45 @@
+package store
+
+import (
+	"math"
+	"testing"
+)
+
+// TestLoudnessMeterReferenceSine check the meter against EBU Tech 3341 test case 1:
+// a 20s stereo 1kHz sine at -23 dBFS must read -23 LUFS
+func TestLoudnessMeterReferenceSine(t *testing.T) {
+	amplitude := math.Pow(10, -23.0/20)
+
+	for _, sampleRate := range []int{44100, 48000} {
+		meter := newLoudnessMeter(sampleRate, 2)
+		for i := 0; i < 20*sampleRate; i++ {
+			x := amplitude * math.Sin(2*math.Pi*1000*float64(i)/float64(sampleRate))
+			meter.addFrame([]float64{x, x})
+		}
+
+		loudness, ok := meter.integratedLoudness()
+		if !ok {
+			t.Fatalf("%d Hz: loudness not measured", sampleRate)
+		}
+		if math.Abs(loudness-(-23)) > 0.1 {
+			t.Errorf("%d Hz: expected loudness -23.0 +/- 0.1 LUFS, got %.2f LUFS", sampleRate, loudness)
+		}
+
+		// True peak of a sine is its amplitude
+		if math.Abs(meter.truePeak-amplitude)/amplitude > 0.01 {
+			t.Errorf("%d Hz: expected true peak %.5f, got %.5f", sampleRate, amplitude, meter.truePeak)
+		}
+	}
+}
+
+// TestLoudnessMeterSilence check silent content isn't measured
+func TestLoudnessMeterSilence(t *testing.T) {
+	meter := newLoudnessMeter(48000, 2)
+	for i := 0; i < 5*48000; i++ {
+		meter.addFrame([]float64{0, 0})
+	}
+
+	if _, ok := meter.integratedLoudness(); ok {
+		t.Errorf("expected silence not to be measured")
+	}
+}
@@ -0,0 +1,10 @@
+-- +migrate Up
+
+-- Song audio properties
+
+alter table song add column duration integer not null default 0;
+alter table song add column sample_rate integer not null default 0;
+alter table song add column channels integer not null default 0;
+alter table song add column bit_rate integer not null default 0;
+
+create index song_duration_index on song (duration);
@@ -0,0 +1,5 @@
+-- +migrate Up
+
+-- Album cover
+
+alter table album add column cover_fg bool not null default false;
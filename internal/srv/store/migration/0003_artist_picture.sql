@@ -0,0 +1,7 @@
+-- +migrate Up
+
+-- Artist picture, biography & sort name
+
+alter table artist add column sort_name text not null default '';
+alter table artist add column biography text not null default '';
+alter table artist add column picture_fg bool not null default false;
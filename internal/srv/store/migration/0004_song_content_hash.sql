@@ -0,0 +1,6 @@
+-- +migrate Up
+
+-- Hash of song audio payload, to detect duplicates
+
+alter table song add column content_hash text not null default '';
+create index song_content_hash_index on song(content_hash);
@@ -0,0 +1,9 @@
+-- +migrate Up
+
+-- Song & album loudness (EBU R128)
+
+alter table song add column loudness real;
+alter table song add column true_peak real;
+alter table song add column loudness_analysis_fg bool not null default false;
+alter table album add column loudness real;
+alter table album add column true_peak real;
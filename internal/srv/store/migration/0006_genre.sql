@@ -0,0 +1,26 @@
+-- +migrate Up
+
+-- Genre
+
+create table genre
+(
+    genre_id    text    not null primary key,
+    creation_ts integer not null,
+    update_ts   integer not null,
+    name        text    not null
+);
+
+create table deleted_genre
+(
+    genre_id  text    not null primary key,
+    delete_ts integer not null
+);
+
+create table genre_song
+(
+    genre_id text not null,
+    song_id  text not null,
+    primary key (genre_id, song_id)
+);
+
+create index genre_song_song_id_index on genre_song (song_id);
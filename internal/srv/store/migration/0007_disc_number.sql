@@ -0,0 +1,5 @@
+-- +migrate Up
+
+-- Disc number of multi-disc albums
+
+alter table song add column disc_number integer;
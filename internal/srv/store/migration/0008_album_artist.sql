@@ -0,0 +1,12 @@
+-- +migrate Up
+
+-- Explicit album artists
+
+create table album_artist
+(
+    album_id  text not null,
+    artist_id text not null,
+    primary key (album_id, artist_id)
+);
+
+create index album_artist_artist_id_index on album_artist (artist_id);
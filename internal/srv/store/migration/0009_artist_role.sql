@@ -0,0 +1,19 @@
+-- +migrate Up
+
+-- Artist role on songs (main, featuring, composer, performer, conductor)
+
+create table artist_song_role
+(
+    artist_id text not null,
+    song_id   text not null,
+    role      text not null default 'main',
+    primary key (artist_id, song_id, role)
+);
+
+insert into artist_song_role (artist_id, song_id, role) select artist_id, song_id, 'main' from artist_song;
+
+drop table artist_song;
+
+alter table artist_song_role rename to artist_song;
+
+create index artist_song_song_id_index on artist_song (song_id);
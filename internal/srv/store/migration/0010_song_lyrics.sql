@@ -0,0 +1,6 @@
+-- +migrate Up
+
+-- Song lyrics (plain text or LRC time-synced lyrics)
+
+alter table song add column lyrics text not null default '';
+alter table song add column lyrics_fg bool not null default false;
@@ -0,0 +1,13 @@
+-- +migrate Up
+
+-- Full-text search on names, accents and case are ignored
+
+create virtual table song_fts using fts5(song_id unindexed, name, tokenize = 'unicode61 remove_diacritics 2');
+create virtual table album_fts using fts5(album_id unindexed, name, tokenize = 'unicode61 remove_diacritics 2');
+create virtual table artist_fts using fts5(artist_id unindexed, name, tokenize = 'unicode61 remove_diacritics 2');
+create virtual table playlist_fts using fts5(playlist_id unindexed, name, tokenize = 'unicode61 remove_diacritics 2');
+
+insert into song_fts (song_id, name) select song_id, name from song;
+insert into album_fts (album_id, name) select album_id, name from album;
+insert into artist_fts (artist_id, name) select artist_id, name from artist;
+insert into playlist_fts (playlist_id, name) select playlist_id, name from playlist;
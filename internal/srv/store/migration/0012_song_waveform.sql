@@ -0,0 +1,10 @@
+-- +migrate Up
+
+-- Song waveform (downsampled peak amplitudes, empty when the song can't be decoded)
+
+create table song_waveform
+(
+    song_id   text    not null primary key,
+    peaks     blob    not null,
+    update_ts integer not null
+);
@@ -0,0 +1,17 @@
+-- +migrate Up
+
+-- Play history (listened duration in milliseconds)
+
+create table play_event
+(
+    play_event_id     text    not null primary key,
+    creation_ts       integer not null,
+    user_id           text    not null,
+    song_id           text    not null,
+    start_ts          integer not null,
+    listened_duration integer not null,
+    client_kind       text    not null
+);
+
+create index play_event_user_id_start_ts_index on play_event (user_id, start_ts);
+create index play_event_song_id_index on play_event (song_id);
@@ -0,0 +1,31 @@
+-- +migrate Up
+
+-- Scrobbling settings of each user
+create table scrobbling
+(
+    user_id       text    not null primary key,
+    service_type  text    not null,
+    api_root_url  text    not null,
+    token         text    not null,
+    api_key       text    not null,
+    api_secret    text    not null,
+    last_error    text    not null,
+    last_error_ts integer not null,
+    update_ts     integer not null
+);
+
+-- Listens waiting to be forwarded, with a snapshot of the song tags (duration in milliseconds)
+create table scrobble
+(
+    scrobble_id     text    not null primary key,
+    user_id         text    not null,
+    listened_ts     integer not null,
+    track_name      text    not null,
+    artist_name     text    not null,
+    album_name      text    not null,
+    duration        integer not null,
+    attempt_count   integer not null,
+    next_attempt_ts integer not null
+);
+
+create index scrobble_user_id_next_attempt_ts_index on scrobble (user_id, next_attempt_ts);
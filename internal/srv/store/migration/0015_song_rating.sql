@@ -0,0 +1,20 @@
+-- +migrate Up
+
+-- Song rating (from 1 to 5 stars)
+
+create table song_rating
+(
+    user_id   text    not null,
+    song_id   text    not null,
+    rating    integer not null,
+    update_ts integer not null,
+    primary key (user_id, song_id)
+);
+
+create table deleted_song_rating
+(
+    user_id   text    not null,
+    song_id   text    not null,
+    delete_ts integer not null,
+    primary key (user_id, song_id)
+);
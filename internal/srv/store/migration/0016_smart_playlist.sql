@@ -0,0 +1,5 @@
+-- +migrate Up
+
+-- Smart playlist rules (json encoded, null for a static playlist)
+
+alter table playlist add column smart_rules text null;
@@ -0,0 +1,24 @@
+-- +migrate Up
+
+-- Playlist folder (nested)
+
+create table playlist_folder
+(
+    playlist_folder_id text    not null primary key,
+    creation_ts        integer not null,
+    update_ts          integer not null,
+    name               text    not null,
+    parent_folder_id   text    null
+);
+
+create index playlist_folder_parent_folder_id_index on playlist_folder (parent_folder_id);
+
+create table deleted_playlist_folder
+(
+    playlist_folder_id text    not null primary key,
+    delete_ts          integer not null
+);
+
+alter table playlist add column folder_id text null;
+
+create index playlist_folder_id_index on playlist (folder_id);
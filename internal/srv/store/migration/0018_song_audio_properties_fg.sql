@@ -0,0 +1,6 @@
+-- +migrate Up
+
+-- Flag songs whose audio properties extraction has been done, even unsuccessfully
+
+alter table song add column audio_properties_fg integer not null default 0;
+update song set audio_properties_fg = 1 where sample_rate > 0;
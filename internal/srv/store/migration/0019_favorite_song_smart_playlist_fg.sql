@@ -0,0 +1,5 @@
+-- +migrate Up
+
+-- Flag favorite songs added by a favorite smart playlist, the only ones removed when they stop matching its rules
+
+alter table favorite_song add column smart_playlist_fg integer not null default 0;
@@ -0,0 +1,250 @@
+package store
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+)
+
+var errInvalidMp4Stream = errors.New("Invalid mp4 stream")
+
+// mp4ContainerBoxTypes list the boxes whose payload is made of child boxes
+var mp4ContainerBoxTypes = map[string]bool{
+	"moov": true,
+	"udta": true,
+	"meta": true,
+	"ilst": true,
+	"trak": true,
+	"mdia": true,
+	"minf": true,
+	"stbl": true,
+}
+
+type mp4Box struct {
+	boxType   string
+	largeSize bool
+	// prefix hold the version & flags of full boxes having children (meta)
+	prefix   []byte
+	data     []byte
+	children []*mp4Box
+	// payload give access to the data left in the song file (mdat)
+	payload *io.SectionReader
+}
+
+// parseMp4Boxes decode a box sequence, walking through container boxes
+func parseMp4Boxes(content []byte, parentType string) ([]*mp4Box, error) {
+	var boxes []*mp4Box
+
+	pos := 0
+	for pos < len(content) {
+		if pos+8 > len(content) {
+			return nil, errInvalidMp4Stream
+		}
+		size := int(binary.BigEndian.Uint32(content[pos:]))
+		box := &mp4Box{boxType: string(content[pos+4 : pos+8])}
+		headerSize := 8
+		switch size {
+		case 0:
+			size = len(content) - pos
+		case 1:
+			if pos+16 > len(content) {
+				return nil, errInvalidMp4Stream
+			}
+			size = int(binary.BigEndian.Uint64(content[pos+8:]))
+			headerSize = 16
+			box.largeSize = true
+		}
+		if size < headerSize || pos+size > len(content) {
+			return nil, errInvalidMp4Stream
+		}
+		payload := content[pos+headerSize : pos+size]
+		pos += size
+
+		// Items of ilst (©nam, trkn, ...) are containers of data boxes
+		if mp4ContainerBoxTypes[box.boxType] || parentType == "ilst" {
+			// meta is a full box, except in some quicktime files
+			if box.boxType == "meta" && len(payload) >= 8 && string(payload[4:8]) != "hdlr" {
+				box.prefix = payload[:4]
+				payload = payload[4:]
+			}
+			children, err := parseMp4Boxes(payload, box.boxType)
+			if err != nil {
+				return nil, err
+			}
+			box.children = children
+		} else {
+			box.data = payload
+		}
+
+		boxes = append(boxes, box)
+	}
+
+	return boxes, nil
+}
+
+// readMp4Boxes read the top-level boxes of mp4 content, media data stay in the song file
+func readMp4Boxes(content *songContent) ([]*mp4Box, error) {
+	var boxes []*mp4Box
+
+	pos := int64(0)
+	for pos < content.size {
+		header, err := content.readAt(pos, 16)
+		if err != nil {
+			return nil, err
+		}
+		if len(header) < 8 {
+			return nil, errInvalidMp4Stream
+		}
+		size := int64(binary.BigEndian.Uint32(header))
+		boxType := string(header[4:8])
+		headerSize := int64(8)
+		switch size {
+		case 0:
+			size = content.size - pos
+		case 1:
+			if len(header) < 16 {
+				return nil, errInvalidMp4Stream
+			}
+			size = int64(binary.BigEndian.Uint64(header[8:]))
+			headerSize = 16
+		}
+		if size < headerSize || pos+size > content.size {
+			return nil, errInvalidMp4Stream
+		}
+
+		if boxType == "mdat" {
+			boxes = append(boxes, &mp4Box{
+				boxType:   boxType,
+				largeSize: headerSize == 16,
+				payload:   content.section(pos+headerSize, size-headerSize),
+			})
+		} else {
+			rawBox, err := content.readAt(pos, size)
+			if err != nil {
+				return nil, err
+			}
+			box, err := parseMp4Boxes(rawBox, "")
+			if err != nil {
+				return nil, err
+			}
+			boxes = append(boxes, box...)
+		}
+		pos += size
+	}
+
+	return boxes, nil
+}
+
+// writeMp4Boxes encode top-level boxes, copying media data from the song file
+func writeMp4Boxes(writer io.Writer, boxes []*mp4Box) error {
+	for _, box := range boxes {
+		buffer := new(bytes.Buffer)
+		box.marshal(buffer)
+		_, err := writer.Write(buffer.Bytes())
+		if err != nil {
+			return err
+		}
+		if box.payload != nil {
+			_, err = io.Copy(writer, box.payloadReader())
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// payloadReader return a reader of the data left in the song file, from its start
+func (b *mp4Box) payloadReader() *io.SectionReader {
+	return io.NewSectionReader(b.payload, 0, b.payload.Size())
+}
+
+// size return the encoded size of the box
+func (b *mp4Box) size() int {
+	size := 8 + len(b.prefix) + len(b.data)
+	if b.payload != nil {
+		size += int(b.payload.Size())
+	}
+	if b.largeSize {
+		size += 8
+	}
+	for _, child := range b.children {
+		size += child.size()
+	}
+	return size
+}
+
+// marshal encode the box and its children, data left in the song file excepted
+func (b *mp4Box) marshal(buffer *bytes.Buffer) {
+	if b.largeSize {
+		binary.Write(buffer, binary.BigEndian, uint32(1))
+		buffer.WriteString(b.boxType)
+		binary.Write(buffer, binary.BigEndian, uint64(b.size()))
+	} else {
+		binary.Write(buffer, binary.BigEndian, uint32(b.size()))
+		buffer.WriteString(b.boxType)
+	}
+	buffer.Write(b.prefix)
+	buffer.Write(b.data)
+	for _, child := range b.children {
+		child.marshal(buffer)
+	}
+}
+
+// child return the first child box of the given type
+func (b *mp4Box) child(boxType string) *mp4Box {
+	for _, child := range b.children {
+		if child.boxType == boxType {
+			return child
+		}
+	}
+	return nil
+}
+
+// mp4Path return the first descendant box following the given types
+func mp4Path(boxes []*mp4Box, boxTypes ...string) *mp4Box {
+	current := &mp4Box{children: boxes}
+	for _, boxType := range boxTypes {
+		current = current.child(boxType)
+		if current == nil {
+			return nil
+		}
+	}
+	return current
+}
+
+// mp4ShiftChunkOffsets move the chunk offsets of every track, used when moov size change before mdat
+func mp4ShiftChunkOffsets(moov *mp4Box, delta int64) {
+	for _, trak := range moov.children {
+		if trak.boxType != "trak" {
+			continue
+		}
+		stbl := mp4Path(trak.children, "mdia", "minf", "stbl")
+		if stbl == nil {
+			continue
+		}
+		for _, box := range stbl.children {
+			switch box.boxType {
+			case "stco":
+				if len(box.data) < 8 {
+					continue
+				}
+				count := int(binary.BigEndian.Uint32(box.data[4:]))
+				for i := 0; i < count && 8+4*i+4 <= len(box.data); i++ {
+					offset := box.data[8+4*i:]
+					binary.BigEndian.PutUint32(offset, uint32(int64(binary.BigEndian.Uint32(offset))+delta))
+				}
+			case "co64":
+				if len(box.data) < 8 {
+					continue
+				}
+				count := int(binary.BigEndian.Uint32(box.data[4:]))
+				for i := 0; i < count && 8+8*i+8 <= len(box.data); i++ {
+					offset := box.data[8+8*i:]
+					binary.BigEndian.PutUint64(offset, uint64(int64(binary.BigEndian.Uint64(offset))+delta))
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,294 @@
+package store
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"github.com/jypelle/mifasol/restApiV1"
+	"io"
+)
+
+var errInvalidOggStream = errors.New("Invalid ogg stream")
+
+const (
+	oggHeaderTypeContinued = 0x01
+	oggHeaderTypeBos       = 0x02
+)
+
+type oggPage struct {
+	headerType byte
+	granule    uint64
+	serial     uint32
+	sequence   uint32
+	segments   []byte
+	data       []byte
+}
+
+var oggCrcTable = func() [256]uint32 {
+	var table [256]uint32
+	for i := range table {
+		r := uint32(i) << 24
+		for j := 0; j < 8; j++ {
+			if r&0x80000000 != 0 {
+				r = r<<1 ^ 0x04c11db7
+			} else {
+				r <<= 1
+			}
+		}
+		table[i] = r
+	}
+	return table
+}()
+
+func oggCrc(data []byte) uint32 {
+	var crc uint32
+	for _, b := range data {
+		crc = crc<<8 ^ oggCrcTable[byte(crc>>24)^b]
+	}
+	return crc
+}
+
+// readOggPage read the next page of an ogg stream, io.EOF is returned at the end of the stream
+func readOggPage(reader io.Reader) (*oggPage, error) {
+	header := make([]byte, 27)
+	_, err := io.ReadFull(reader, header)
+	if err == io.EOF {
+		return nil, io.EOF
+	}
+	if err != nil || string(header[0:4]) != "OggS" {
+		return nil, errInvalidOggStream
+	}
+
+	page := &oggPage{
+		headerType: header[5],
+		granule:    binary.LittleEndian.Uint64(header[6:]),
+		serial:     binary.LittleEndian.Uint32(header[14:]),
+		sequence:   binary.LittleEndian.Uint32(header[18:]),
+		segments:   make([]byte, header[26]),
+	}
+	_, err = io.ReadFull(reader, page.segments)
+	if err != nil {
+		return nil, errInvalidOggStream
+	}
+
+	dataLength := 0
+	for _, segment := range page.segments {
+		dataLength += int(segment)
+	}
+	page.data = make([]byte, dataLength)
+	_, err = io.ReadFull(reader, page.data)
+	if err != nil {
+		return nil, errInvalidOggStream
+	}
+
+	return page, nil
+}
+
+// readOggHeaderPages read the pages holding the first packets of the first logical stream, the reader is left on the following page.
+// Pages of other logical streams met meanwhile are returned too. It also return whether the last packet ends its page
+func readOggHeaderPages(reader io.Reader, packetCount int) ([][]byte, []*oggPage, bool, error) {
+	var pages []*oggPage
+	var packets [][]byte
+	var packet []byte
+	for {
+		page, err := readOggPage(reader)
+		if err == io.EOF {
+			return nil, nil, false, errInvalidOggStream
+		}
+		if err != nil {
+			return nil, nil, false, err
+		}
+		pages = append(pages, page)
+
+		// Packets are parsed as pages are read
+		if page.serial != pages[0].serial {
+			continue
+		}
+		pos := 0
+		for segmentIdx, segment := range page.segments {
+			packet = append(packet, page.data[pos:pos+int(segment)]...)
+			pos += int(segment)
+			if segment < 255 {
+				packets = append(packets, packet)
+				packet = nil
+				if len(packets) == packetCount {
+					return packets, pages, segmentIdx == len(page.segments)-1, nil
+				}
+			}
+		}
+	}
+}
+
+// marshal encode the page and compute its checksum
+func (p *oggPage) marshal() []byte {
+	buffer := make([]byte, 27+len(p.segments)+len(p.data))
+	copy(buffer, "OggS")
+	buffer[5] = p.headerType
+	binary.LittleEndian.PutUint64(buffer[6:], p.granule)
+	binary.LittleEndian.PutUint32(buffer[14:], p.serial)
+	binary.LittleEndian.PutUint32(buffer[18:], p.sequence)
+	buffer[26] = byte(len(p.segments))
+	copy(buffer[27:], p.segments)
+	copy(buffer[27+len(p.segments):], p.data)
+	binary.LittleEndian.PutUint32(buffer[22:], oggCrc(buffer))
+
+	return buffer
+}
+
+// rewriteOggHeaderPackets copy an ogg stream replacing the header packets of its first logical stream, renumbering the following pages
+func rewriteOggHeaderPackets(reader io.Reader, writer io.Writer, packets [][]byte) error {
+	_, headerPages, pageEnded, err := readOggHeaderPages(reader, len(packets))
+	if err != nil {
+		return err
+	}
+
+	// Audio data must start on a fresh page
+	if !pageEnded {
+		return errInvalidOggStream
+	}
+
+	serial := headerPages[0].serial
+
+	// Identification header stays alone on the first page, the others are packed together
+	var newPages []*oggPage
+	newPages = append(newPages, packOggPackets(packets[:1], serial)...)
+	newPages = append(newPages, packOggPackets(packets[1:], serial)...)
+	newPages[0].headerType |= oggHeaderTypeBos
+
+	for sequence, page := range newPages {
+		page.sequence = uint32(sequence)
+	}
+
+	// The identification page stays first, the other header pages take the place of the first old one.
+	// Pages of other logical streams met between header pages are copied through
+	_, err = writer.Write(newPages[0].marshal())
+	if err != nil {
+		return err
+	}
+	oldHeaderPageCount := 1
+	for _, page := range headerPages[1:] {
+		if page.serial != serial {
+			_, err = writer.Write(page.marshal())
+			if err != nil {
+				return err
+			}
+			continue
+		}
+		if oldHeaderPageCount == 1 {
+			err = writeOggPages(writer, newPages[1:])
+			if err != nil {
+				return err
+			}
+		}
+		oldHeaderPageCount++
+	}
+	if oldHeaderPageCount == 1 {
+		err = writeOggPages(writer, newPages[1:])
+		if err != nil {
+			return err
+		}
+	}
+	shift := uint32(len(newPages) - oldHeaderPageCount)
+
+	for {
+		page, err := readOggPage(reader)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if page.serial == serial {
+			page.sequence += shift
+		}
+		_, err = writer.Write(page.marshal())
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// writeOggPages write the pages one after another
+func writeOggPages(writer io.Writer, pages []*oggPage) error {
+	for _, page := range pages {
+		_, err := writer.Write(page.marshal())
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// packOggPackets lace header packets into pages
+func packOggPackets(packets [][]byte, serial uint32) []*oggPage {
+	var pages []*oggPage
+
+	page := &oggPage{serial: serial, granule: ^uint64(0)}
+	for _, packet := range packets {
+		remaining := packet
+		for {
+			if len(page.segments) == 255 {
+				pages = append(pages, page)
+				page = &oggPage{serial: serial, granule: ^uint64(0)}
+				if len(remaining) < len(packet) {
+					page.headerType = oggHeaderTypeContinued
+				}
+			}
+			segment := len(remaining)
+			if segment > 255 {
+				segment = 255
+			}
+			page.segments = append(page.segments, byte(segment))
+			page.data = append(page.data, remaining[:segment]...)
+			remaining = remaining[segment:]
+			if segment < 255 {
+				// Header pages have a zero granule position once a packet is complete
+				page.granule = 0
+				break
+			}
+		}
+	}
+	pages = append(pages, page)
+
+	return pages
+}
+
+// readOggLastGranule read the remaining pages and return the last granule position of a logical stream
+func readOggLastGranule(reader io.Reader, serial uint32) (uint64, error) {
+	var lastGranule uint64
+	for {
+		page, err := readOggPage(reader)
+		if err == io.EOF {
+			return lastGranule, nil
+		}
+		if err != nil {
+			return 0, err
+		}
+		if page.serial == serial && page.granule != ^uint64(0) {
+			lastGranule = page.granule
+		}
+	}
+}
+
+// oggSniffLength is the content length needed to identify the codec of an ogg stream
+const oggSniffLength = 27 + 255 + 8
+
+// sniffOggFormat identify the codec of the first logical stream from its identification header
+func sniffOggFormat(content []byte) restApiV1.SongFormat {
+	if len(content) < 27 {
+		return restApiV1.SongFormatUnknown
+	}
+	firstPacketPos := 27 + int(content[26])
+	if firstPacketPos > len(content) {
+		return restApiV1.SongFormatUnknown
+	}
+
+	firstPacket := content[firstPacketPos:]
+	switch {
+	case bytes.HasPrefix(firstPacket, []byte(opusIdentificationHeader)):
+		return restApiV1.SongFormatOpus
+	case bytes.HasPrefix(firstPacket, []byte(vorbisIdentificationHeader)):
+		return restApiV1.SongFormatOgg
+	}
+	return restApiV1.SongFormatUnknown
+}
@@ -0,0 +1,192 @@
+package store
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/go-flac/flacvorbis"
+)
+
+const (
+	oggTestVorbisSerial   = 0x1000
+	oggTestSkeletonSerial = 0x2000
+)
+
+type oggTestPage struct {
+	page *oggPage
+	raw  []byte
+}
+
+func newOggTestCommentPacket(title string) []byte {
+	cmt := flacvorbis.New()
+	cmt.Add("TITLE", title)
+	return marshalOggVorbisComment(cmt, vorbisCommentHeader, true)
+}
+
+// newOggTestStream build a vorbis stream multiplexed with a skeleton stream whose pages sit between the vorbis header pages
+func newOggTestStream(t *testing.T, commentPacket []byte) ([]byte, [][]byte) {
+	packets := [][]byte{
+		append([]byte(vorbisIdentificationHeader), make([]byte, 23)...),
+		commentPacket,
+		append([]byte(vorbisSetupHeader), bytes.Repeat([]byte{0x42}, 1000)...),
+	}
+
+	vorbisHeaderPages := packOggPackets(packets[1:], oggTestVorbisSerial)
+	if len(vorbisHeaderPages) < 2 {
+		t.Fatalf("the comment packet must span several pages")
+	}
+
+	var pages []*oggPage
+	identificationPage := packOggPackets(packets[:1], oggTestVorbisSerial)[0]
+	identificationPage.headerType |= oggHeaderTypeBos
+	pages = append(pages, identificationPage)
+	pages = append(pages, &oggPage{headerType: oggHeaderTypeBos, serial: oggTestSkeletonSerial, segments: []byte{8}, data: []byte("fishead\x00")})
+	pages = append(pages, vorbisHeaderPages[0])
+	pages = append(pages, &oggPage{serial: oggTestSkeletonSerial, sequence: 1, segments: []byte{8}, data: []byte("fisbone\x00")})
+	pages = append(pages, vorbisHeaderPages[1:]...)
+	pages = append(pages, &oggPage{headerType: 0x04, serial: oggTestSkeletonSerial, sequence: 2, segments: []byte{0}})
+	for i := 0; i < 3; i++ {
+		pages = append(pages, &oggPage{serial: oggTestVorbisSerial, granule: uint64(1024 * (i + 1)), segments: []byte{100}, data: bytes.Repeat([]byte{byte(i)}, 100)})
+	}
+
+	var sequence uint32
+	var content bytes.Buffer
+	for _, page := range pages {
+		if page.serial == oggTestVorbisSerial {
+			page.sequence = sequence
+			sequence++
+		}
+		content.Write(page.marshal())
+	}
+
+	return content.Bytes(), packets
+}
+
+// readOggTestPages read every page with its raw content
+func readOggTestPages(t *testing.T, content []byte) []oggTestPage {
+	var pages []oggTestPage
+	reader := bytes.NewReader(content)
+	pos := 0
+	for {
+		page, err := readOggPage(reader)
+		if err == io.EOF {
+			return pages
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		pageLength := 27 + len(page.segments) + len(page.data)
+		pages = append(pages, oggTestPage{page: page, raw: content[pos : pos+pageLength]})
+		pos += pageLength
+	}
+}
+
+func rewriteOggTestStream(t *testing.T, content []byte, packets [][]byte) []byte {
+	var rewrittenContent bytes.Buffer
+	err := rewriteOggHeaderPackets(bytes.NewReader(content), &rewrittenContent, packets)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return rewrittenContent.Bytes()
+}
+
+// checkOggTestStream check checksums, sequence numbers, header packets and pages of the other logical stream
+func checkOggTestStream(t *testing.T, content []byte, originalContent []byte, packets [][]byte) {
+	t.Helper()
+
+	pages := readOggTestPages(t, content)
+	originalPages := readOggTestPages(t, originalContent)
+
+	for idx, page := range pages {
+		raw := append([]byte(nil), page.raw...)
+		binary.LittleEndian.PutUint32(raw[22:], 0)
+		if oggCrc(raw) != binary.LittleEndian.Uint32(page.raw[22:]) {
+			t.Errorf("page %d: invalid checksum", idx)
+		}
+	}
+
+	// Beginning of stream pages come first
+	if len(pages) < 2 || pages[0].page.serial != oggTestVorbisSerial || pages[0].page.headerType&oggHeaderTypeBos == 0 ||
+		pages[1].page.serial != oggTestSkeletonSerial || pages[1].page.headerType&oggHeaderTypeBos == 0 {
+		t.Fatalf("beginning of stream pages expected first")
+	}
+
+	// Vorbis pages are numbered without gap and audio pages are kept
+	var sequence uint32
+	var audioPages, originalAudioPages []*oggPage
+	for _, page := range pages {
+		if page.page.serial != oggTestVorbisSerial {
+			continue
+		}
+		if page.page.sequence != sequence {
+			t.Errorf("sequence %d expected, got %d", sequence, page.page.sequence)
+		}
+		sequence++
+		if page.page.granule != 0 && page.page.granule != ^uint64(0) {
+			audioPages = append(audioPages, page.page)
+		}
+	}
+	for _, page := range originalPages {
+		if page.page.serial == oggTestVorbisSerial && page.page.granule != 0 && page.page.granule != ^uint64(0) {
+			originalAudioPages = append(originalAudioPages, page.page)
+		}
+	}
+	if len(audioPages) != len(originalAudioPages) {
+		t.Fatalf("%d audio pages expected, got %d", len(originalAudioPages), len(audioPages))
+	}
+	for idx, page := range audioPages {
+		if page.granule != originalAudioPages[idx].granule || !bytes.Equal(page.data, originalAudioPages[idx].data) {
+			t.Errorf("audio page %d altered", idx)
+		}
+	}
+
+	// Skeleton pages are copied through, in the same order
+	var skeletonPages, originalSkeletonPages [][]byte
+	for _, page := range pages {
+		if page.page.serial == oggTestSkeletonSerial {
+			skeletonPages = append(skeletonPages, page.raw)
+		}
+	}
+	for _, page := range originalPages {
+		if page.page.serial == oggTestSkeletonSerial {
+			originalSkeletonPages = append(originalSkeletonPages, page.raw)
+		}
+	}
+	if len(skeletonPages) != len(originalSkeletonPages) {
+		t.Fatalf("%d skeleton pages expected, got %d", len(originalSkeletonPages), len(skeletonPages))
+	}
+	for idx, page := range skeletonPages {
+		if !bytes.Equal(page, originalSkeletonPages[idx]) {
+			t.Errorf("skeleton page %d altered", idx)
+		}
+	}
+
+	readPackets, _, err := readVorbisHeaderPackets(bytes.NewReader(content))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for idx, packet := range packets {
+		if !bytes.Equal(readPackets[idx], packet) {
+			t.Errorf("header packet %d altered", idx)
+		}
+	}
+}
+
+func TestOggHeaderRewriteRoundTrip(t *testing.T) {
+	originalContent, originalPackets := newOggTestStream(t, newOggTestCommentPacket(strings.Repeat("a", 70000)))
+
+	// Shrink the comment header from two pages to one
+	packets := [][]byte{originalPackets[0], newOggTestCommentPacket("short"), originalPackets[2]}
+	content := rewriteOggTestStream(t, originalContent, packets)
+	checkOggTestStream(t, content, originalContent, packets)
+
+	// Restore the original comment header
+	restoredContent := rewriteOggTestStream(t, content, originalPackets)
+	checkOggTestStream(t, restoredContent, originalContent, originalPackets)
+	if len(readOggTestPages(t, restoredContent)) != len(readOggTestPages(t, originalContent)) {
+		t.Errorf("the restored stream must have as many pages as the original one")
+	}
+}
@@ -0,0 +1,113 @@
+package store
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"github.com/jmoiron/sqlx"
+	"github.com/jypelle/mifasol/internal/srv/storeerror"
+	"strconv"
+	"strings"
+)
+
+// pageCursor hold the sort key of the last item of a page, the next page start right after it
+type pageCursor struct {
+	OrderBy string        `json:"o"`
+	Keys    []interface{} `json:"k"`
+}
+
+// encodePageCursor return the opaque cursor of the page following the item with the given sort key
+func encodePageCursor(orderBy string, keys []interface{}) string {
+	content, _ := json.Marshal(pageCursor{OrderBy: orderBy, Keys: keys})
+	return base64.RawURLEncoding.EncodeToString(content)
+}
+
+// decodePageCursor return the sort key held by a cursor, the cursor must come from a page with the same order
+func decodePageCursor(cursor string, orderBy string, keyCount int) ([]interface{}, error) {
+	content, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, storeerror.ErrInvalidPage
+	}
+
+	// Timestamps in nanoseconds don't fit in a float64
+	decoder := json.NewDecoder(bytes.NewReader(content))
+	decoder.UseNumber()
+
+	var decodedCursor pageCursor
+	err = decoder.Decode(&decodedCursor)
+	if err != nil || decodedCursor.OrderBy != orderBy || len(decodedCursor.Keys) != keyCount {
+		return nil, storeerror.ErrInvalidPage
+	}
+
+	for ind, key := range decodedCursor.Keys {
+		switch k := key.(type) {
+		case json.Number:
+			if intKey, err := k.Int64(); err == nil {
+				decodedCursor.Keys[ind] = intKey
+			} else if floatKey, err := k.Float64(); err == nil {
+				decodedCursor.Keys[ind] = floatKey
+			} else {
+				return nil, storeerror.ErrInvalidPage
+			}
+		case string:
+		default:
+			return nil, storeerror.ErrInvalidPage
+		}
+	}
+
+	return decodedCursor.Keys, nil
+}
+
+// pageConditions return the sql conditions restricting a query to the page starting after the cursor
+func pageConditions(sortKeys []string, orderBy string, limit *int, cursor *string, queryArgs map[string]interface{}) (string, error) {
+	if limit != nil && *limit < 0 {
+		return "", storeerror.ErrInvalidPage
+	}
+	if cursor == nil {
+		return "", nil
+	}
+
+	cursorKeys, err := decodePageCursor(*cursor, orderBy, len(sortKeys))
+	if err != nil {
+		return "", err
+	}
+
+	// Compare sort keys as a row value
+	cursorArgs := make([]string, len(cursorKeys))
+	for ind, cursorKey := range cursorKeys {
+		argName := "cursor_key_" + strconv.Itoa(ind)
+		queryArgs[argName] = cursorKey
+		cursorArgs[ind] = ":" + argName
+	}
+
+	return "AND (" + strings.Join(sortKeys, ", ") + ") > (" + strings.Join(cursorArgs, ", ") + ") ", nil
+}
+
+// pageLimit return the sql limit clause of a page
+func pageLimit(limit *int, queryArgs map[string]interface{}) string {
+	if limit == nil {
+		return ""
+	}
+	queryArgs["page_limit"] = *limit
+	return "LIMIT :page_limit "
+}
+
+// namedCount run a count query with named arguments
+func namedCount(txn *sqlx.Tx, query string, queryArgs map[string]interface{}) (int64, error) {
+	query, args, err := sqlx.Named(query, queryArgs)
+	if err != nil {
+		return 0, err
+	}
+
+	var count int64
+	err = txn.Get(&count, txn.Rebind(query), args...)
+	return count, err
+}
+
+// int64OrZero return the value of an optional number, sorting missing numbers first
+func int64OrZero(value *int64) int64 {
+	if value == nil {
+		return 0
+	}
+	return *value
+}
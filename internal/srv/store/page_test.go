@@ -0,0 +1,113 @@
+package store
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/jypelle/mifasol/internal/srv/storeerror"
+)
+
+func TestPageCursorRoundTrip(t *testing.T) {
+	// Nanosecond timestamps don't fit in a float64
+	keys := []interface{}{"name", int64(1792309004896396431), "01GENRE"}
+
+	decodedKeys, err := decodePageCursor(encodePageCursor("name", keys), "name", len(keys))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for ind, key := range keys {
+		if decodedKeys[ind] != key {
+			t.Errorf("key %d: %v expected, got %v", ind, key, decodedKeys[ind])
+		}
+	}
+}
+
+func TestPageCursorTampered(t *testing.T) {
+	cursor := encodePageCursor("name", []interface{}{"name", "01GENRE"})
+	content, _ := base64.RawURLEncoding.DecodeString(cursor)
+
+	for name, tamperedCursor := range map[string]string{
+		"not base64":  "!" + cursor,
+		"not json":    base64.RawURLEncoding.EncodeToString(content[1:]),
+		"object key":  base64.RawURLEncoding.EncodeToString([]byte(`{"o":"name","k":[{"a":1},"01GENRE"]}`)),
+		"missing key": base64.RawURLEncoding.EncodeToString([]byte(`{"o":"name","k":["name"]}`)),
+		"other order": encodePageCursor("updateTs", []interface{}{"name", "01GENRE"}),
+		"huge number": base64.RawURLEncoding.EncodeToString([]byte(`{"o":"name","k":[1e999,"01GENRE"]}`)),
+	} {
+		_, err := decodePageCursor(tamperedCursor, "name", 2)
+		if err != storeerror.ErrInvalidPage {
+			t.Errorf("%s: invalid page expected, got %v", name, err)
+		}
+		_, err = pageConditions([]string{"name", "id"}, "name", nil, &tamperedCursor, map[string]interface{}{})
+		if err != storeerror.ErrInvalidPage {
+			t.Errorf("%s: invalid page expected from page conditions, got %v", name, err)
+		}
+	}
+
+	negativeLimit := -1
+	_, err := pageConditions([]string{"name", "id"}, "name", &negativeLimit, nil, map[string]interface{}{})
+	if err != storeerror.ErrInvalidPage {
+		t.Errorf("invalid page expected for a negative limit, got %v", err)
+	}
+}
+
+// TestPageConditionsTiedSortKeys read items sharing the same sort key page by page, the id breaking the ties
+func TestPageConditionsTiedSortKeys(t *testing.T) {
+	db, err := sqlx.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+
+	db.MustExec("CREATE TABLE item (name TEXT NOT NULL, id TEXT NOT NULL)")
+	expectedIds := []string{"a1", "a2", "a3", "a4", "a5", "b1", "c1", "c2"}
+	for _, id := range []string{"c2", "a3", "b1", "a5", "a1", "c1", "a4", "a2"} {
+		db.MustExec("INSERT INTO item (name, id) VALUES (?, ?)", id[:1], id)
+	}
+
+	txn, err := db.Beginx()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer txn.Rollback()
+
+	sortKeys := []string{"name", "id"}
+	limit := 2
+	var cursor *string
+	var readIds []string
+	for page := 0; page < len(expectedIds); page++ {
+		queryArgs := make(map[string]interface{})
+		cursorConditions, err := pageConditions(sortKeys, "name", &limit, cursor, queryArgs)
+		if err != nil {
+			t.Fatal(err)
+		}
+		query, args, err := sqlx.Named("SELECT name, id FROM item WHERE 1=1 "+cursorConditions+"ORDER BY name, id "+pageLimit(&limit, queryArgs), queryArgs)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var items []struct {
+			Name string `db:"name"`
+			Id   string `db:"id"`
+		}
+		err = txn.Select(&items, txn.Rebind(query), args...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(items) == 0 {
+			break
+		}
+		for _, item := range items {
+			readIds = append(readIds, item.Id)
+		}
+		lastItem := items[len(items)-1]
+		nextCursor := encodePageCursor("name", []interface{}{lastItem.Name, lastItem.Id})
+		cursor = &nextCursor
+	}
+
+	if strings.Join(readIds, ",") != strings.Join(expectedIds, ",") {
+		t.Errorf("%v expected, got %v", expectedIds, readIds)
+	}
+}
@@ -0,0 +1,217 @@
+package store
+
+import (
+	"github.com/jmoiron/sqlx"
+	"github.com/jypelle/mifasol/internal/srv/entity"
+	"github.com/jypelle/mifasol/internal/srv/storeerror"
+	"github.com/jypelle/mifasol/internal/tool"
+	"github.com/jypelle/mifasol/restApiV1"
+	"time"
+)
+
+func (s *Store) ReadPlayEvents(externalTrn *sqlx.Tx, filter *restApiV1.PlayEventFilter) ([]restApiV1.PlayEvent, error) {
+	if s.serverConfig.DebugMode {
+		defer tool.TimeTrack(time.Now(), "ReadPlayEvents")
+	}
+
+	var err error
+
+	// Check available transaction
+	txn := externalTrn
+	if txn == nil {
+		txn, err = s.db.Beginx()
+		if err != nil {
+			return nil, err
+		}
+		defer txn.Rollback()
+	}
+
+	queryArgs := make(map[string]interface{})
+	if filter.UserId != nil {
+		queryArgs["user_id"] = *filter.UserId
+	}
+	if filter.SongId != nil {
+		queryArgs["song_id"] = *filter.SongId
+	}
+	if filter.FromTs != nil {
+		queryArgs["from_ts"] = *filter.FromTs
+	}
+	if filter.ToTs != nil {
+		queryArgs["to_ts"] = *filter.ToTs
+	}
+
+	rows, err := txn.NamedQuery(
+		`SELECT
+				p.*
+			FROM play_event p
+			WHERE 1>0
+			`+tool.TernStr(filter.UserId != nil, "AND p.user_id = :user_id ", "")+`
+			`+tool.TernStr(filter.SongId != nil, "AND p.song_id = :song_id ", "")+`
+			`+tool.TernStr(filter.FromTs != nil, "AND p.start_ts >= :from_ts ", "")+`
+			`+tool.TernStr(filter.ToTs != nil, "AND p.start_ts < :to_ts ", "")+`
+			ORDER BY p.start_ts DESC, p.play_event_id DESC
+		`,
+		queryArgs,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	playEvents := []restApiV1.PlayEvent{}
+
+	for rows.Next() {
+		var playEventEntity entity.PlayEventEntity
+		err = rows.StructScan(&playEventEntity)
+		if err != nil {
+			return nil, err
+		}
+
+		var playEvent restApiV1.PlayEvent
+		playEventEntity.Fill(&playEvent)
+		playEvents = append(playEvents, playEvent)
+	}
+
+	return playEvents, nil
+}
+
+func (s *Store) CreatePlayEvent(externalTrn *sqlx.Tx, playEventMeta *restApiV1.PlayEventMeta) (*restApiV1.PlayEvent, error) {
+	var err error
+
+	// Check available transaction
+	txn := externalTrn
+	if txn == nil {
+		txn, err = s.db.Beginx()
+		if err != nil {
+			return nil, err
+		}
+		defer txn.Rollback()
+	}
+
+	if playEventMeta.ListenedDuration <= 0 {
+		return nil, storeerror.ErrInvalidPlayEvent
+	}
+
+	// Check user & song
+	var count int64
+	err = txn.Get(&count, "SELECT (SELECT count(*) FROM user WHERE user_id = ?) * (SELECT count(*) FROM song WHERE song_id = ?)", playEventMeta.UserId, playEventMeta.SongId)
+	if err != nil {
+		return nil, err
+	}
+	if count == 0 {
+		return nil, storeerror.ErrNotFound
+	}
+
+	now := time.Now().UnixNano()
+
+	playEventEntity := entity.PlayEventEntity{
+		PlayEventId: restApiV1.PlayEventId(tool.CreateUlid()),
+		CreationTs:  now,
+	}
+	playEventEntity.LoadMeta(playEventMeta)
+
+	// Play without start is supposed to have just ended
+	if playEventEntity.StartTs <= 0 {
+		playEventEntity.StartTs = now - playEventEntity.ListenedDuration*int64(time.Millisecond)
+	}
+	if !playEventEntity.ClientKind.IsValid() {
+		playEventEntity.ClientKind = restApiV1.ClientKindOther
+	}
+
+	_, err = txn.NamedExec(`
+			INSERT INTO	play_event (
+			    play_event_id,
+			    creation_ts,
+			    user_id,
+				song_id,
+				start_ts,
+				listened_duration,
+				client_kind
+			)
+			VALUES (
+			    :play_event_id,
+			    :creation_ts,
+			    :user_id,
+				:song_id,
+				:start_ts,
+				:listened_duration,
+				:client_kind
+			)
+		`, &playEventEntity)
+	if err != nil {
+		return nil, err
+	}
+
+	scrobbled, err := s.enqueueScrobble(txn, &playEventEntity)
+	if err != nil {
+		return nil, err
+	}
+
+	// Commit transaction
+	if externalTrn == nil {
+		txn.Commit()
+	}
+
+	// Refresh smart playlists in background
+	s.requestSmartPlaylistsRefresh()
+
+	if scrobbled {
+		s.requestScrobbleForwarding()
+	}
+
+	var playEvent restApiV1.PlayEvent
+	playEventEntity.Fill(&playEvent)
+
+	return &playEvent, nil
+}
+
+// ReadSongPlayStats return play count & last play of each song played by a user
+func (s *Store) ReadSongPlayStats(externalTrn *sqlx.Tx, userId restApiV1.UserId) ([]restApiV1.SongPlayStats, error) {
+	var err error
+
+	// Check available transaction
+	txn := externalTrn
+	if txn == nil {
+		txn, err = s.db.Beginx()
+		if err != nil {
+			return nil, err
+		}
+		defer txn.Rollback()
+	}
+
+	var userCount int64
+	err = txn.Get(&userCount, "SELECT count(*) FROM user WHERE user_id = ?", userId)
+	if err != nil {
+		return nil, err
+	}
+	if userCount == 0 {
+		return nil, storeerror.ErrNotFound
+	}
+
+	rows, err := txn.Query(`
+			SELECT
+				song_id,
+				count(*),
+				max(start_ts)
+			FROM play_event
+			WHERE user_id = ?
+			GROUP BY song_id
+			ORDER BY song_id
+		`, userId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	songPlayStats := []restApiV1.SongPlayStats{}
+	for rows.Next() {
+		var stats restApiV1.SongPlayStats
+		err = rows.Scan(&stats.SongId, &stats.PlayCount, &stats.LastPlayTs)
+		if err != nil {
+			return nil, err
+		}
+		songPlayStats = append(songPlayStats, stats)
+	}
+
+	return songPlayStats, rows.Err()
+}
@@ -10,6 +10,7 @@ import (
 	"github.com/jypelle/mifasol/restApiV1"
 	"reflect"
 	"sort"
+	"strings"
 	"time"
 )
 
@@ -31,31 +32,24 @@ func (s *Store) ReadPlaylists(externalTrn *sqlx.Tx, filter *restApiV1.PlaylistFi
 	}
 
 	queryArgs := make(map[string]interface{})
-	if filter.FromTs != nil {
-		queryArgs["from_ts"] = *filter.FromTs
-	}
-	if filter.FavoriteUserId != nil {
-		queryArgs["favorite_user_id"] = *filter.FavoriteUserId
-	}
-	if filter.FavoriteFromTs != nil {
-		queryArgs["favorite_from_ts"] = *filter.FavoriteFromTs
-	}
-	orderBy := "p.update_ts ASC"
-	if filter.OrderBy != nil {
-		if *filter.OrderBy == restApiV1.PlaylistFilterOrderByName {
-			orderBy = "p.name ASC"
-		}
+	filterJoins, filterConditions := playlistFilterClauses(filter, queryArgs)
+
+	sortKeys := playlistSortKeys(filter.OrderBy)
+	cursorConditions, err := pageConditions(sortKeys, playlistOrderByName(filter.OrderBy), filter.Limit, filter.Cursor, queryArgs)
+	if err != nil {
+		return nil, err
 	}
 
 	rows, err := txn.NamedQuery(
 		`SELECT
 				p.*
 			FROM playlist p
-			`+tool.TernStr(filter.FavoriteUserId != nil, "JOIN favorite_playlist fp ON fp.playlist_id = p.playlist_id AND fp.user_id = :favorite_user_id ", "")+`
+			`+filterJoins+`
 			WHERE 1>0
-			`+tool.TernStr(filter.FromTs != nil, "AND p.update_ts >= :from_ts ", "")+`
-			`+tool.TernStr(filter.FavoriteUserId != nil && filter.FavoriteFromTs != nil, "AND (fp.update_ts >= :favorite_from_ts OR p.content_update_ts >= :favorite_from_ts) ", "")+`
-			ORDER BY `+orderBy,
+			`+filterConditions+`
+			`+cursorConditions+`
+			ORDER BY `+strings.Join(sortKeys, " ASC, ")+` ASC
+			`+pageLimit(filter.Limit, queryArgs),
 		queryArgs,
 	)
 	if err != nil {
@@ -109,6 +103,122 @@ func (s *Store) ReadPlaylists(externalTrn *sqlx.Tx, filter *restApiV1.PlaylistFi
 	return playlists, nil
 }
 
+// playlistFilterClauses return the joins & conditions selecting the playlists matching a filter
+func playlistFilterClauses(filter *restApiV1.PlaylistFilter, queryArgs map[string]interface{}) (string, string) {
+	if filter.FromTs != nil {
+		queryArgs["from_ts"] = *filter.FromTs
+	}
+	if filter.FavoriteUserId != nil {
+		queryArgs["favorite_user_id"] = *filter.FavoriteUserId
+	}
+	if filter.FavoriteFromTs != nil {
+		queryArgs["favorite_from_ts"] = *filter.FavoriteFromTs
+	}
+
+	joins := tool.TernStr(filter.FavoriteUserId != nil, "JOIN favorite_playlist fp ON fp.playlist_id = p.playlist_id AND fp.user_id = :favorite_user_id ", "")
+
+	conditions := tool.TernStr(filter.FromTs != nil, "AND p.update_ts >= :from_ts ", "") +
+		tool.TernStr(filter.FavoriteUserId != nil && filter.FavoriteFromTs != nil, "AND (fp.update_ts >= :favorite_from_ts OR p.content_update_ts >= :favorite_from_ts) ", "")
+
+	return joins, conditions
+}
+
+func playlistOrderByName(orderBy *restApiV1.PlaylistFilterOrderBy) string {
+	if orderBy == nil {
+		return ""
+	}
+	return string(*orderBy)
+}
+
+// playlistSortKeys return the columns playlists are sorted by, the playlist id comes last to get a total order
+func playlistSortKeys(orderBy *restApiV1.PlaylistFilterOrderBy) []string {
+	switch playlistOrderByName(orderBy) {
+	case string(restApiV1.PlaylistFilterOrderByName):
+		return []string{"p.name", "p.playlist_id"}
+	case string(restApiV1.PlaylistFilterOrderByCreationTs):
+		return []string{"p.creation_ts", "p.playlist_id"}
+	default:
+		return []string{"p.update_ts", "p.playlist_id"}
+	}
+}
+
+// playlistSortKeyValues return the values of the playlist sort keys
+func playlistSortKeyValues(playlist *restApiV1.Playlist, orderBy *restApiV1.PlaylistFilterOrderBy) []interface{} {
+	switch playlistOrderByName(orderBy) {
+	case string(restApiV1.PlaylistFilterOrderByName):
+		return []interface{}{playlist.Name, playlist.Id}
+	case string(restApiV1.PlaylistFilterOrderByCreationTs):
+		return []interface{}{playlist.CreationTs, playlist.Id}
+	default:
+		return []interface{}{playlist.UpdateTs, playlist.Id}
+	}
+}
+
+// CountPlaylists return the number of playlists matching a filter, paging excluded
+func (s *Store) CountPlaylists(externalTrn *sqlx.Tx, filter *restApiV1.PlaylistFilter) (int64, error) {
+	var err error
+
+	// Check available transaction
+	txn := externalTrn
+	if txn == nil {
+		txn, err = s.db.Beginx()
+		if err != nil {
+			return 0, err
+		}
+		defer txn.Rollback()
+	}
+
+	queryArgs := make(map[string]interface{})
+	filterJoins, filterConditions := playlistFilterClauses(filter, queryArgs)
+
+	return namedCount(txn, "SELECT count(*) FROM playlist p "+filterJoins+"WHERE 1>0 "+filterConditions, queryArgs)
+}
+
+// ReadPlaylistsPage return a page of playlists, the cursor of the next page (empty for the last page) and the number of playlists matching the filter
+func (s *Store) ReadPlaylistsPage(externalTrn *sqlx.Tx, filter *restApiV1.PlaylistFilter) ([]restApiV1.Playlist, string, int64, error) {
+	var err error
+
+	// Check available transaction
+	txn := externalTrn
+	if txn == nil {
+		txn, err = s.db.Beginx()
+		if err != nil {
+			return nil, "", 0, err
+		}
+		defer txn.Rollback()
+	}
+
+	totalCount, err := s.CountPlaylists(txn, filter)
+	if err != nil {
+		return nil, "", 0, err
+	}
+
+	if filter.Limit == nil {
+		playlists, err := s.ReadPlaylists(txn, filter)
+		return playlists, "", totalCount, err
+	}
+
+	// Read one more playlist to know if there is a next page
+	pageFilter := *filter
+	limit := *filter.Limit + 1
+	pageFilter.Limit = &limit
+
+	playlists, err := s.ReadPlaylists(txn, &pageFilter)
+	if err != nil {
+		return nil, "", 0, err
+	}
+
+	var nextCursor string
+	if len(playlists) > *filter.Limit {
+		playlists = playlists[:*filter.Limit]
+		if len(playlists) > 0 {
+			nextCursor = encodePageCursor(playlistOrderByName(filter.OrderBy), playlistSortKeyValues(&playlists[len(playlists)-1], filter.OrderBy))
+		}
+	}
+
+	return playlists, nextCursor, totalCount, nil
+}
+
 func (s *Store) ReadPlaylist(externalTrn *sqlx.Tx, playlistId restApiV1.PlaylistId) (*restApiV1.Playlist, error) {
 	var err error
 
@@ -219,6 +329,10 @@ func (s *Store) CreatePlaylist(externalTrn *sqlx.Tx, playlistMeta *restApiV1.Pla
 func (s *Store) CreateInternalPlaylist(externalTrn *sqlx.Tx, playlistId restApiV1.PlaylistId, playlistMeta *restApiV1.PlaylistMeta, check bool) (*restApiV1.Playlist, error) {
 	var err error
 
+	if playlistMeta.SmartRules != nil && !restApiV1.IsSmartPlaylistRulesValid(playlistMeta.SmartRules) {
+		return nil, storeerror.ErrInvalidSmartPlaylist
+	}
+
 	// Check available transaction
 	txn := externalTrn
 	if txn == nil {
@@ -229,6 +343,12 @@ func (s *Store) CreateInternalPlaylist(externalTrn *sqlx.Tx, playlistId restApiV
 		defer txn.Rollback()
 	}
 
+	// Check folder
+	err = s.checkPlaylistFolderId(txn, playlistMeta.FolderId)
+	if err != nil {
+		return nil, err
+	}
+
 	// Store playlist
 	now := time.Now().UnixNano()
 
@@ -250,20 +370,28 @@ func (s *Store) CreateInternalPlaylist(externalTrn *sqlx.Tx, playlistId restApiV
 				creation_ts,
 			    update_ts,
 			    content_update_ts,
-				name
+				name,
+				smart_rules,
+				folder_id
 			)
 			VALUES (
 			    :playlist_id,
 				:creation_ts,
 			    :update_ts,
 			    :content_update_ts,
-				:name
+				:name,
+				:smart_rules,
+				:folder_id
 			)`,
 		&playlistEntity,
 	)
 	if err != nil {
 		return nil, err
 	}
+	err = playlistSearchIndex.index(txn, string(playlistId), playlistEntity.Name)
+	if err != nil {
+		return nil, err
+	}
 
 	// Clean owner list
 	playlistMeta.OwnerUserIds = tool.DeduplicateUserId(playlistMeta.OwnerUserIds)
@@ -311,36 +439,44 @@ func (s *Store) CreateInternalPlaylist(externalTrn *sqlx.Tx, playlistId restApiV
 	}
 
 	// Create songs link
-	for position, songId := range playlistMeta.SongIds {
-		// Check song id
-		if check {
-			var songEntity entity.SongEntity
-			err = txn.Get(&songEntity, `SELECT * FROM song WHERE song_id = ?`, songId)
+	if playlistMeta.SmartRules != nil {
+		// Smart playlist songs come from its rules
+		err = s.refreshSmartPlaylist(txn, &playlistEntity, now)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		for position, songId := range playlistMeta.SongIds {
+			// Check song id
+			if check {
+				var songEntity entity.SongEntity
+				err = txn.Get(&songEntity, `SELECT * FROM song WHERE song_id = ?`, songId)
+				if err != nil {
+					return nil, err
+				}
+			}
+
+			// Store song link
+			queryArgs := make(map[string]interface{})
+			queryArgs["playlist_id"] = playlistId
+			queryArgs["position"] = position
+			queryArgs["song_id"] = songId
+			_, err = txn.NamedExec(`
+					INSERT INTO	playlist_song (
+						playlist_id,
+						position,
+						song_id
+					)
+					VALUES (
+						:playlist_id,
+						:position,
+						:song_id
+					)
+					`, queryArgs)
 			if err != nil {
 				return nil, err
 			}
 		}
-
-		// Store song link
-		queryArgs := make(map[string]interface{})
-		queryArgs["playlist_id"] = playlistId
-		queryArgs["position"] = position
-		queryArgs["song_id"] = songId
-		_, err = txn.NamedExec(`
-				INSERT INTO	playlist_song (
-					playlist_id,
-					position,
-					song_id
-				)
-				VALUES (
-					:playlist_id,
-					:position,
-					:song_id
-				)
-				`, queryArgs)
-		if err != nil {
-			return nil, err
-		}
 	}
 
 	// Commit transaction
@@ -357,6 +493,10 @@ func (s *Store) CreateInternalPlaylist(externalTrn *sqlx.Tx, playlistId restApiV
 func (s *Store) UpdatePlaylist(externalTrn *sqlx.Tx, playlistId restApiV1.PlaylistId, playlistMeta *restApiV1.PlaylistMeta, check bool) (*restApiV1.Playlist, error) {
 	var err error
 
+	if playlistMeta.SmartRules != nil && !restApiV1.IsSmartPlaylistRulesValid(playlistMeta.SmartRules) {
+		return nil, storeerror.ErrInvalidSmartPlaylist
+	}
+
 	// Check available transaction
 	txn := externalTrn
 	if txn == nil {
@@ -389,7 +529,14 @@ func (s *Store) UpdatePlaylist(externalTrn *sqlx.Tx, playlistId restApiV1.Playli
 		return nil, err
 	}
 
+	// Check folder
+	err = s.checkPlaylistFolderId(txn, playlistMeta.FolderId)
+	if err != nil {
+		return nil, err
+	}
+
 	playlistOldName := playlistEntity.Name
+	playlistOldFolderId := playlistEntity.FolderId
 
 	playlistEntity.LoadMeta(playlistMeta)
 
@@ -399,25 +546,31 @@ func (s *Store) UpdatePlaylist(externalTrn *sqlx.Tx, playlistId restApiV1.Playli
 		return playlistMeta.OwnerUserIds[i] < playlistMeta.OwnerUserIds[j]
 	})
 
-	// Detect song list update
-	songIdsUpdated := !reflect.DeepEqual(playlistOldSongIds, playlistMeta.SongIds)
+	// Detect song list update, smart playlist songs coming from its rules
+	songIdsUpdated := playlistMeta.SmartRules == nil && !reflect.DeepEqual(playlistOldSongIds, playlistMeta.SongIds)
 
 	// Update playlist update timestamp
 	playlistEntity.UpdateTs = now
 
 	// Update playlist update content timestamp
-	if playlistOldName != playlistEntity.Name || songIdsUpdated {
+	if playlistOldName != playlistEntity.Name || playlistOldFolderId != playlistEntity.FolderId || songIdsUpdated {
 		playlistEntity.ContentUpdateTs = now
 	}
 
 	_, err = txn.NamedExec(`
 		UPDATE playlist
 		SET name = :name,
+			smart_rules = :smart_rules,
+			folder_id = :folder_id,
 			update_ts = :update_ts,
 			content_update_ts = :content_update_ts
 		WHERE playlist_id = :playlist_id
 	`, &playlistEntity)
 
+	if err != nil {
+		return nil, err
+	}
+	err = playlistSearchIndex.index(txn, string(playlistId), playlistEntity.Name)
 	if err != nil {
 		return nil, err
 	}
@@ -498,6 +651,14 @@ func (s *Store) UpdatePlaylist(externalTrn *sqlx.Tx, playlistId restApiV1.Playli
 		}
 	}
 
+	// Update smart playlist songs
+	if playlistMeta.SmartRules != nil {
+		err = s.refreshSmartPlaylist(txn, &playlistEntity, now)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	// Commit transaction
 	if externalTrn == nil {
 		txn.Commit()
@@ -657,6 +818,10 @@ func (s *Store) DeletePlaylist(externalTrn *sqlx.Tx, playlistId restApiV1.Playli
 	if err != nil {
 		return nil, err
 	}
+	err = playlistSearchIndex.unindex(txn, string(playlistId))
+	if err != nil {
+		return nil, err
+	}
 
 	// Archive playlistId
 	_, err = txn.NamedExec(`
@@ -0,0 +1,369 @@
+package store
+
+import (
+	"database/sql"
+	"github.com/jmoiron/sqlx"
+	"github.com/jypelle/mifasol/internal/srv/entity"
+	"github.com/jypelle/mifasol/internal/srv/storeerror"
+	"github.com/jypelle/mifasol/internal/tool"
+	"github.com/jypelle/mifasol/restApiV1"
+	"strings"
+	"time"
+)
+
+// playlistFolderSubtreeQuery select the ids of the folder :playlist_folder_id and of every folder inside it
+const playlistFolderSubtreeQuery = `
+	WITH RECURSIVE subtree(playlist_folder_id) AS (
+		SELECT :playlist_folder_id
+		UNION
+		SELECT f.playlist_folder_id FROM playlist_folder f JOIN subtree st ON f.parent_folder_id = st.playlist_folder_id
+	)
+	SELECT playlist_folder_id FROM subtree`
+
+func (s *Store) ReadPlaylistFolders(externalTrn *sqlx.Tx, filter *restApiV1.PlaylistFolderFilter) ([]restApiV1.PlaylistFolder, error) {
+	if s.serverConfig.DebugMode {
+		defer tool.TimeTrack(time.Now(), "ReadPlaylistFolders")
+	}
+
+	var err error
+
+	// Check available transaction
+	txn := externalTrn
+	if txn == nil {
+		txn, err = s.db.Beginx()
+		if err != nil {
+			return nil, err
+		}
+		defer txn.Rollback()
+	}
+
+	queryArgs := make(map[string]interface{})
+	if filter.FromTs != nil {
+		queryArgs["from_ts"] = *filter.FromTs
+	}
+
+	rows, err := txn.NamedQuery(
+		`SELECT
+				f.*
+			FROM playlist_folder f
+			WHERE 1>0
+			`+tool.TernStr(filter.FromTs != nil, "AND f.update_ts >= :from_ts ", "")+`
+			ORDER BY f.update_ts ASC`,
+		queryArgs,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	playlistFolders := []restApiV1.PlaylistFolder{}
+
+	for rows.Next() {
+		var playlistFolderEntity entity.PlaylistFolderEntity
+		err = rows.StructScan(&playlistFolderEntity)
+		if err != nil {
+			return nil, err
+		}
+
+		var playlistFolder restApiV1.PlaylistFolder
+		playlistFolderEntity.Fill(&playlistFolder)
+
+		playlistFolders = append(playlistFolders, playlistFolder)
+	}
+
+	return playlistFolders, nil
+}
+
+func (s *Store) ReadPlaylistFolder(externalTrn *sqlx.Tx, playlistFolderId restApiV1.PlaylistFolderId) (*restApiV1.PlaylistFolder, error) {
+	var err error
+
+	// Check available transaction
+	txn := externalTrn
+	if txn == nil {
+		txn, err = s.db.Beginx()
+		if err != nil {
+			return nil, err
+		}
+		defer txn.Rollback()
+	}
+
+	var playlistFolderEntity entity.PlaylistFolderEntity
+
+	err = txn.Get(&playlistFolderEntity, "SELECT * FROM playlist_folder WHERE playlist_folder_id = ?", playlistFolderId)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, storeerror.ErrNotFound
+		}
+		return nil, err
+	}
+
+	var playlistFolder restApiV1.PlaylistFolder
+	playlistFolderEntity.Fill(&playlistFolder)
+
+	return &playlistFolder, nil
+}
+
+func (s *Store) CreatePlaylistFolder(externalTrn *sqlx.Tx, playlistFolderMeta *restApiV1.PlaylistFolderMeta) (*restApiV1.PlaylistFolder, error) {
+	var err error
+
+	if strings.TrimSpace(playlistFolderMeta.Name) == "" {
+		return nil, storeerror.ErrInvalidPlaylistFolder
+	}
+
+	// Check available transaction
+	txn := externalTrn
+	if txn == nil {
+		txn, err = s.db.Beginx()
+		if err != nil {
+			return nil, err
+		}
+		defer txn.Rollback()
+	}
+
+	// Check parent folder
+	err = s.checkPlaylistFolderId(txn, playlistFolderMeta.ParentFolderId)
+	if err != nil {
+		return nil, err
+	}
+
+	// Store playlist folder
+	now := time.Now().UnixNano()
+
+	playlistFolderEntity := entity.PlaylistFolderEntity{
+		PlaylistFolderId: restApiV1.PlaylistFolderId(tool.CreateUlid()),
+		CreationTs:       now,
+		UpdateTs:         now,
+	}
+	playlistFolderEntity.LoadMeta(playlistFolderMeta)
+
+	_, err = txn.NamedExec(`
+			INSERT INTO	playlist_folder (
+			    playlist_folder_id,
+				creation_ts,
+			    update_ts,
+				name,
+				parent_folder_id
+			)
+			VALUES (
+			    :playlist_folder_id,
+				:creation_ts,
+				:update_ts,
+				:name,
+				:parent_folder_id
+			)
+	`, &playlistFolderEntity)
+	if err != nil {
+		return nil, err
+	}
+
+	// Commit transaction
+	if externalTrn == nil {
+		txn.Commit()
+	}
+
+	var playlistFolder restApiV1.PlaylistFolder
+	playlistFolderEntity.Fill(&playlistFolder)
+
+	return &playlistFolder, nil
+}
+
+func (s *Store) UpdatePlaylistFolder(externalTrn *sqlx.Tx, playlistFolderId restApiV1.PlaylistFolderId, playlistFolderMeta *restApiV1.PlaylistFolderMeta) (*restApiV1.PlaylistFolder, error) {
+	var err error
+
+	if strings.TrimSpace(playlistFolderMeta.Name) == "" {
+		return nil, storeerror.ErrInvalidPlaylistFolder
+	}
+
+	// Check available transaction
+	txn := externalTrn
+	if txn == nil {
+		txn, err = s.db.Beginx()
+		if err != nil {
+			return nil, err
+		}
+		defer txn.Rollback()
+	}
+
+	var playlistFolderEntity entity.PlaylistFolderEntity
+	err = txn.Get(&playlistFolderEntity, "SELECT * FROM playlist_folder WHERE playlist_folder_id = ?", playlistFolderId)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, storeerror.ErrNotFound
+		}
+		return nil, err
+	}
+
+	// Check parent folder, a folder can't be moved inside itself
+	err = s.checkPlaylistFolderId(txn, playlistFolderMeta.ParentFolderId)
+	if err != nil {
+		return nil, err
+	}
+	if playlistFolderMeta.ParentFolderId != nil {
+		count, err := namedCount(txn, "SELECT count(*) FROM ("+playlistFolderSubtreeQuery+") WHERE playlist_folder_id = :parent_folder_id",
+			map[string]interface{}{"playlist_folder_id": playlistFolderId, "parent_folder_id": *playlistFolderMeta.ParentFolderId},
+		)
+		if err != nil {
+			return nil, err
+		}
+		if count > 0 {
+			return nil, storeerror.ErrInvalidPlaylistFolder
+		}
+	}
+
+	oldName := playlistFolderEntity.Name
+	oldParentFolderId := playlistFolderEntity.ParentFolderId
+
+	now := time.Now().UnixNano()
+
+	playlistFolderEntity.LoadMeta(playlistFolderMeta)
+	playlistFolderEntity.UpdateTs = now
+
+	// Update playlist folder
+	_, err = txn.NamedExec(`
+		UPDATE playlist_folder
+		SET name = :name,
+			parent_folder_id = :parent_folder_id,
+			update_ts = :update_ts
+		WHERE playlist_folder_id = :playlist_folder_id
+	`, &playlistFolderEntity)
+	if err != nil {
+		return nil, err
+	}
+
+	// Renaming or moving the folder changes the path of the playlists inside it
+	if oldName != playlistFolderEntity.Name || oldParentFolderId != playlistFolderEntity.ParentFolderId {
+		_, err = txn.NamedExec(`
+			UPDATE playlist
+			SET update_ts = :update_ts,
+				content_update_ts = :update_ts
+			WHERE folder_id IN (`+playlistFolderSubtreeQuery+`)
+		`, map[string]interface{}{"playlist_folder_id": playlistFolderId, "update_ts": now})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Commit transaction
+	if externalTrn == nil {
+		txn.Commit()
+	}
+
+	var playlistFolder restApiV1.PlaylistFolder
+	playlistFolderEntity.Fill(&playlistFolder)
+
+	return &playlistFolder, nil
+}
+
+func (s *Store) DeletePlaylistFolder(externalTrn *sqlx.Tx, playlistFolderId restApiV1.PlaylistFolderId) (*restApiV1.PlaylistFolder, error) {
+	if s.serverConfig.DebugMode {
+		defer tool.TimeTrack(time.Now(), "DeletePlaylistFolder")
+	}
+
+	var err error
+
+	// Check available transaction
+	txn := externalTrn
+	if txn == nil {
+		txn, err = s.db.Beginx()
+		if err != nil {
+			return nil, err
+		}
+		defer txn.Rollback()
+	}
+
+	deleteTs := time.Now().UnixNano()
+
+	var playlistFolderEntity entity.PlaylistFolderEntity
+	err = txn.Get(&playlistFolderEntity, "SELECT * FROM playlist_folder WHERE playlist_folder_id = ?", playlistFolderId)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, storeerror.ErrNotFound
+		}
+		return nil, err
+	}
+
+	// Check folder content
+	var count int64
+	err = txn.Get(&count, "SELECT (SELECT count(*) FROM playlist_folder WHERE parent_folder_id = ?) + (SELECT count(*) FROM playlist WHERE folder_id = ?)", playlistFolderId, playlistFolderId)
+	if err != nil {
+		return nil, err
+	}
+	if count > 0 {
+		return nil, storeerror.ErrDeletePlaylistFolderWithContent
+	}
+
+	// Delete playlist folder
+	_, err = txn.Exec("DELETE FROM playlist_folder WHERE playlist_folder_id = ?", playlistFolderId)
+	if err != nil {
+		return nil, err
+	}
+
+	// Archive playlistFolderId
+	_, err = txn.NamedExec(`
+			INSERT INTO	deleted_playlist_folder (
+			    playlist_folder_id,
+				delete_ts
+			)
+			VALUES (
+			    :playlist_folder_id,
+				:delete_ts
+			)
+	`, &entity.DeletedPlaylistFolderEntity{PlaylistFolderId: playlistFolderEntity.PlaylistFolderId, DeleteTs: deleteTs})
+	if err != nil {
+		return nil, err
+	}
+
+	// Commit transaction
+	if externalTrn == nil {
+		txn.Commit()
+	}
+
+	var playlistFolder restApiV1.PlaylistFolder
+	playlistFolderEntity.Fill(&playlistFolder)
+
+	return &playlistFolder, nil
+}
+
+func (s *Store) GetDeletedPlaylistFolderIds(externalTrn *sqlx.Tx, fromTs int64) ([]restApiV1.PlaylistFolderId, error) {
+	if s.serverConfig.DebugMode {
+		defer tool.TimeTrack(time.Now(), "GetDeletedPlaylistFolderIds")
+	}
+
+	var err error
+
+	// Check available transaction
+	txn := externalTrn
+	if txn == nil {
+		txn, err = s.db.Beginx()
+		if err != nil {
+			return nil, err
+		}
+		defer txn.Rollback()
+	}
+
+	playlistFolderIds := []restApiV1.PlaylistFolderId{}
+	err = txn.Select(&playlistFolderIds, "SELECT playlist_folder_id FROM deleted_playlist_folder WHERE delete_ts >= ? ORDER BY delete_ts ASC", fromTs)
+	if err != nil {
+		return nil, err
+	}
+
+	return playlistFolderIds, nil
+}
+
+// checkPlaylistFolderId check that a playlist or a folder can be put into a folder, nil meaning the root folder
+func (s *Store) checkPlaylistFolderId(txn *sqlx.Tx, playlistFolderId *restApiV1.PlaylistFolderId) error {
+	if playlistFolderId == nil {
+		return nil
+	}
+
+	var count int64
+	err := txn.Get(&count, "SELECT count(*) FROM playlist_folder WHERE playlist_folder_id = ?", *playlistFolderId)
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return storeerror.ErrInvalidPlaylistFolder
+	}
+
+	return nil
+}
@@ -0,0 +1,503 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"github.com/jmoiron/sqlx"
+	"github.com/jypelle/mifasol/internal/srv/entity"
+	"github.com/jypelle/mifasol/internal/srv/scrobbler"
+	"github.com/jypelle/mifasol/internal/srv/storeerror"
+	"github.com/jypelle/mifasol/internal/tool"
+	"github.com/jypelle/mifasol/restApiV1"
+	"github.com/sirupsen/logrus"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	// scrobbleMinSongDuration is the duration under which songs are not scrobbled, as required by Last.fm
+	scrobbleMinSongDuration = 30 * 1000
+
+	// Delay before retrying listens refused by an unreachable service, doubled at each attempt
+	scrobbleMinRetryDelay = time.Minute
+	scrobbleMaxRetryDelay = 6 * time.Hour
+
+	// scrobbleCheckInterval is the interval between two checks of listens waiting for a retry
+	scrobbleCheckInterval = time.Minute
+)
+
+func (s *Store) ReadScrobbling(externalTrn *sqlx.Tx, userId restApiV1.UserId) (*restApiV1.Scrobbling, error) {
+	var err error
+
+	// Check available transaction
+	txn := externalTrn
+	if txn == nil {
+		txn, err = s.db.Beginx()
+		if err != nil {
+			return nil, err
+		}
+		defer txn.Rollback()
+	}
+
+	var scrobblingEntity entity.ScrobblingEntity
+	err = txn.Get(&scrobblingEntity, "SELECT * FROM scrobbling WHERE user_id = ?", userId)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, storeerror.ErrNotFound
+		}
+		return nil, err
+	}
+
+	var scrobbling restApiV1.Scrobbling
+	scrobblingEntity.Fill(&scrobbling)
+
+	err = txn.Get(&scrobbling.PendingListenCount, "SELECT count(*) FROM scrobble WHERE user_id = ?", userId)
+	if err != nil {
+		return nil, err
+	}
+
+	return &scrobbling, nil
+}
+
+// UpdateScrobbling create or replace the scrobbling settings of a user, listens waiting for a retry are resubmitted
+func (s *Store) UpdateScrobbling(externalTrn *sqlx.Tx, userId restApiV1.UserId, scrobblingMeta *restApiV1.ScrobblingMeta) (*restApiV1.Scrobbling, error) {
+	var err error
+
+	// Check available transaction
+	txn := externalTrn
+	if txn == nil {
+		txn, err = s.db.Beginx()
+		if err != nil {
+			return nil, err
+		}
+		defer txn.Rollback()
+	}
+
+	var userCount int64
+	err = txn.Get(&userCount, "SELECT count(*) FROM user WHERE user_id = ?", userId)
+	if err != nil {
+		return nil, err
+	}
+	if userCount == 0 {
+		return nil, storeerror.ErrNotFound
+	}
+
+	// Secrets are kept when not provided
+	var scrobblingEntity entity.ScrobblingEntity
+	err = txn.Get(&scrobblingEntity, "SELECT * FROM scrobbling WHERE user_id = ?", userId)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, err
+	}
+	scrobblingEntity.UserId = userId
+	scrobblingEntity.LoadMeta(scrobblingMeta)
+	scrobblingEntity.ApiRootUrl = strings.TrimSpace(scrobblingEntity.ApiRootUrl)
+	scrobblingEntity.LastError = ""
+	scrobblingEntity.LastErrorTs = 0
+	scrobblingEntity.UpdateTs = time.Now().UnixNano()
+
+	if !isScrobblingValid(&scrobblingEntity) {
+		return nil, storeerror.ErrInvalidScrobbling
+	}
+
+	_, err = txn.NamedExec(`
+			INSERT OR REPLACE INTO scrobbling (
+				user_id,
+				service_type,
+				api_root_url,
+				token,
+				api_key,
+				api_secret,
+				last_error,
+				last_error_ts,
+				update_ts
+			)
+			VALUES (
+				:user_id,
+				:service_type,
+				:api_root_url,
+				:token,
+				:api_key,
+				:api_secret,
+				:last_error,
+				:last_error_ts,
+				:update_ts
+			)
+		`, &scrobblingEntity)
+	if err != nil {
+		return nil, err
+	}
+
+	// New settings may fix previous errors
+	_, err = txn.Exec("UPDATE scrobble SET next_attempt_ts = 0 WHERE user_id = ?", userId)
+	if err != nil {
+		return nil, err
+	}
+
+	scrobbling, err := s.ReadScrobbling(txn, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	// Commit transaction
+	if externalTrn == nil {
+		txn.Commit()
+	}
+
+	s.requestScrobbleForwarding()
+
+	return scrobbling, nil
+}
+
+// DeleteScrobbling remove the scrobbling settings of a user with the listens not yet forwarded
+func (s *Store) DeleteScrobbling(externalTrn *sqlx.Tx, userId restApiV1.UserId) (*restApiV1.Scrobbling, error) {
+	var err error
+
+	// Check available transaction
+	txn := externalTrn
+	if txn == nil {
+		txn, err = s.db.Beginx()
+		if err != nil {
+			return nil, err
+		}
+		defer txn.Rollback()
+	}
+
+	scrobbling, err := s.ReadScrobbling(txn, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = txn.Exec("DELETE FROM scrobbling WHERE user_id = ?", userId)
+	if err != nil {
+		return nil, err
+	}
+	_, err = txn.Exec("DELETE FROM scrobble WHERE user_id = ?", userId)
+	if err != nil {
+		return nil, err
+	}
+
+	// Commit transaction
+	if externalTrn == nil {
+		txn.Commit()
+	}
+
+	return scrobbling, nil
+}
+
+// isScrobblingValid check the service type, the api root url and the credentials needed by the service
+func isScrobblingValid(scrobblingEntity *entity.ScrobblingEntity) bool {
+	if !scrobblingEntity.ServiceType.IsValid() || scrobblingEntity.Token == "" {
+		return false
+	}
+	if scrobblingEntity.ServiceType == restApiV1.ScrobblingServiceTypeLastFm && (scrobblingEntity.ApiKey == "" || scrobblingEntity.ApiSecret == "") {
+		return false
+	}
+	if scrobblingEntity.ApiRootUrl != "" {
+		apiRootUrl, err := url.Parse(scrobblingEntity.ApiRootUrl)
+		if err != nil || (apiRootUrl.Scheme != "http" && apiRootUrl.Scheme != "https") || apiRootUrl.Host == "" {
+			return false
+		}
+	}
+	return true
+}
+
+// readScrobbleTrack return the tags of a song as sent to scrobbling services
+func (s *Store) readScrobbleTrack(txn *sqlx.Tx, songEntity *entity.SongEntity) (*scrobbler.Track, error) {
+	artistNames, err := s.readSongArtistNames(txn, songEntity.SongId, restApiV1.ArtistRoleMain)
+	if err != nil {
+		return nil, err
+	}
+
+	track := &scrobbler.Track{
+		Name:       songEntity.Name,
+		ArtistName: strings.Join(artistNames, ", "),
+		Duration:   songEntity.Duration,
+	}
+
+	if songEntity.AlbumId != restApiV1.UnknownAlbumId {
+		err = txn.Get(&track.AlbumName, "SELECT name FROM album WHERE album_id = ?", songEntity.AlbumId)
+		if err != nil && err != sql.ErrNoRows {
+			return nil, err
+		}
+	}
+
+	return track, nil
+}
+
+// enqueueScrobble add a play to the listens to forward, when the user scrobbles and the play is long enough
+func (s *Store) enqueueScrobble(txn *sqlx.Tx, playEventEntity *entity.PlayEventEntity) (bool, error) {
+	var scrobblingCount int64
+	err := txn.Get(&scrobblingCount, "SELECT count(*) FROM scrobbling WHERE user_id = ?", playEventEntity.UserId)
+	if err != nil || scrobblingCount == 0 {
+		return false, err
+	}
+
+	var songEntity entity.SongEntity
+	err = txn.Get(&songEntity, "SELECT * FROM song WHERE song_id = ?", playEventEntity.SongId)
+	if err != nil {
+		return false, err
+	}
+
+	if songEntity.Duration > 0 && songEntity.Duration < scrobbleMinSongDuration {
+		return false, nil
+	}
+	if !restApiV1.IsPlayReportable(playEventEntity.ListenedDuration, songEntity.Duration) {
+		return false, nil
+	}
+
+	track, err := s.readScrobbleTrack(txn, &songEntity)
+	if err != nil {
+		return false, err
+	}
+
+	scrobbleEntity := entity.ScrobbleEntity{
+		ScrobbleId: tool.CreateUlid(),
+		UserId:     playEventEntity.UserId,
+		ListenedTs: playEventEntity.StartTs,
+		TrackName:  track.Name,
+		ArtistName: track.ArtistName,
+		AlbumName:  track.AlbumName,
+		Duration:   track.Duration,
+	}
+
+	_, err = txn.NamedExec(`
+			INSERT INTO scrobble (
+				scrobble_id,
+				user_id,
+				listened_ts,
+				track_name,
+				artist_name,
+				album_name,
+				duration,
+				attempt_count,
+				next_attempt_ts
+			)
+			VALUES (
+				:scrobble_id,
+				:user_id,
+				:listened_ts,
+				:track_name,
+				:artist_name,
+				:album_name,
+				:duration,
+				:attempt_count,
+				:next_attempt_ts
+			)
+		`, &scrobbleEntity)
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// SubmitNowPlaying forward in background the song a user just started to listen to, when the user scrobbles
+func (s *Store) SubmitNowPlaying(externalTrn *sqlx.Tx, nowPlayingMeta *restApiV1.NowPlayingMeta) error {
+	var err error
+
+	// Check available transaction
+	txn := externalTrn
+	if txn == nil {
+		txn, err = s.db.Beginx()
+		if err != nil {
+			return err
+		}
+		defer txn.Rollback()
+	}
+
+	var songEntity entity.SongEntity
+	err = txn.Get(&songEntity, "SELECT * FROM song WHERE song_id = ?", nowPlayingMeta.SongId)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return storeerror.ErrNotFound
+		}
+		return err
+	}
+
+	var scrobblingEntity entity.ScrobblingEntity
+	err = txn.Get(&scrobblingEntity, "SELECT * FROM scrobbling WHERE user_id = ?", nowPlayingMeta.UserId)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil
+		}
+		return err
+	}
+
+	track, err := s.readScrobbleTrack(txn, &songEntity)
+	if err != nil {
+		return err
+	}
+
+	// "Now playing" is short lived, it's neither queued nor retried
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		defer cancel()
+		err := scrobbler.SubmitNowPlaying(ctx, scrobblingMeta(&scrobblingEntity), track)
+		if err != nil {
+			logrus.Debugf("Unable to submit now playing song of user %s: %v", scrobblingEntity.UserId, err)
+		}
+	}()
+
+	return nil
+}
+
+func scrobblingMeta(scrobblingEntity *entity.ScrobblingEntity) *restApiV1.ScrobblingMeta {
+	return &restApiV1.ScrobblingMeta{
+		ServiceType: scrobblingEntity.ServiceType,
+		ApiRootUrl:  scrobblingEntity.ApiRootUrl,
+		Token:       scrobblingEntity.Token,
+		ApiKey:      scrobblingEntity.ApiKey,
+		ApiSecret:   scrobblingEntity.ApiSecret,
+	}
+}
+
+// requestScrobbleForwarding wake up the scrobble forwarding job
+func (s *Store) requestScrobbleForwarding() {
+	select {
+	case s.scrobbleSignal <- struct{}{}:
+	default:
+	}
+}
+
+// runScrobbleForwardingJob forward queued listens each time it's requested and periodically retry the refused ones
+func (s *Store) runScrobbleForwardingJob() {
+	ticker := time.NewTicker(scrobbleCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.scrobbleSignal:
+		case <-ticker.C:
+		}
+
+		err := s.forwardScrobbles()
+		if err != nil {
+			logrus.Warnf("Unable to forward scrobbles: %v", err)
+		}
+	}
+}
+
+// forwardScrobbles submit the due listens of each user, by batch, in listening order
+func (s *Store) forwardScrobbles() error {
+	var userIds []restApiV1.UserId
+	err := s.db.Select(&userIds, "SELECT DISTINCT user_id FROM scrobble WHERE next_attempt_ts <= ?", time.Now().UnixNano())
+	if err != nil {
+		return err
+	}
+
+	for _, userId := range userIds {
+		for {
+			sent, err := s.forwardUserScrobbles(userId)
+			if err != nil {
+				return err
+			}
+			if sent < scrobbler.MaxListensPerRequest {
+				break
+			}
+		}
+	}
+
+	return nil
+}
+
+// forwardUserScrobbles submit a batch of due listens of a user and return the number of forwarded listens.
+// Submission is done outside of any transaction
+func (s *Store) forwardUserScrobbles(userId restApiV1.UserId) (int, error) {
+	var scrobblingEntity entity.ScrobblingEntity
+	err := s.db.Get(&scrobblingEntity, "SELECT * FROM scrobbling WHERE user_id = ?", userId)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return 0, nil
+		}
+		return 0, err
+	}
+
+	var scrobbleEntities []entity.ScrobbleEntity
+	err = s.db.Select(&scrobbleEntities, "SELECT * FROM scrobble WHERE user_id = ? AND next_attempt_ts <= ? ORDER BY listened_ts, scrobble_id LIMIT ?", userId, time.Now().UnixNano(), scrobbler.MaxListensPerRequest)
+	if err != nil || len(scrobbleEntities) == 0 {
+		return 0, err
+	}
+
+	listens := make([]scrobbler.Listen, len(scrobbleEntities))
+	scrobbleIds := make([]string, len(scrobbleEntities))
+	for ind, scrobbleEntity := range scrobbleEntities {
+		listens[ind] = scrobbler.Listen{
+			Track: scrobbler.Track{
+				Name:       scrobbleEntity.TrackName,
+				ArtistName: scrobbleEntity.ArtistName,
+				AlbumName:  scrobbleEntity.AlbumName,
+				Duration:   scrobbleEntity.Duration,
+			},
+			ListenedTs: scrobbleEntity.ListenedTs,
+		}
+		scrobbleIds[ind] = scrobbleEntity.ScrobbleId
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	submitErr := scrobbler.SubmitListens(ctx, scrobblingMeta(&scrobblingEntity), listens)
+	cancel()
+
+	txn, err := s.db.Beginx()
+	if err != nil {
+		return 0, err
+	}
+	defer txn.Rollback()
+
+	if submitErr == nil || scrobbler.IsPermanent(submitErr) {
+		// Accepted listens or listens that will never be accepted are removed
+		query, args, err := sqlx.In("DELETE FROM scrobble WHERE scrobble_id IN (?)", scrobbleIds)
+		if err != nil {
+			return 0, err
+		}
+		_, err = txn.Exec(txn.Rebind(query), args...)
+		if err != nil {
+			return 0, err
+		}
+	} else {
+		// Refused listens are retried later, with an exponential backoff
+		for _, scrobbleEntity := range scrobbleEntities {
+			_, err = txn.Exec("UPDATE scrobble SET attempt_count = ?, next_attempt_ts = ? WHERE scrobble_id = ?",
+				scrobbleEntity.AttemptCount+1,
+				time.Now().Add(scrobbleRetryDelay(scrobbleEntity.AttemptCount+1)).UnixNano(),
+				scrobbleEntity.ScrobbleId,
+			)
+			if err != nil {
+				return 0, err
+			}
+		}
+	}
+
+	// Keep the last error for the user settings page
+	lastError, lastErrorTs := "", int64(0)
+	if submitErr != nil {
+		logrus.Warnf("Unable to forward %d listens of user %s: %v", len(listens), userId, submitErr)
+		lastError, lastErrorTs = submitErr.Error(), time.Now().UnixNano()
+	}
+	_, err = txn.Exec("UPDATE scrobbling SET last_error = ?, last_error_ts = ? WHERE user_id = ?", lastError, lastErrorTs, userId)
+	if err != nil {
+		return 0, err
+	}
+
+	err = txn.Commit()
+	if err != nil {
+		return 0, err
+	}
+
+	if submitErr != nil && !scrobbler.IsPermanent(submitErr) {
+		return 0, nil
+	}
+	return len(listens), nil
+}
+
+// scrobbleRetryDelay return the delay before the next submission of a listen refused attemptCount times
+func scrobbleRetryDelay(attemptCount int64) time.Duration {
+	delay := scrobbleMinRetryDelay
+	for i := int64(1); i < attemptCount && delay < scrobbleMaxRetryDelay; i++ {
+		delay *= 2
+	}
+	if delay > scrobbleMaxRetryDelay {
+		delay = scrobbleMaxRetryDelay
+	}
+	return delay
+}
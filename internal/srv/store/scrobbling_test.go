@@ -0,0 +1,172 @@
+package store
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/jypelle/mifasol/internal/srv/config"
+	"github.com/jypelle/mifasol/internal/srv/entity"
+	"github.com/jypelle/mifasol/restApiV1"
+)
+
+// newScrobblingTestStore return a store whose user forward listens to a mock ListenBrainz service answering with statusCode
+func newScrobblingTestStore(t *testing.T, statusCode *int32, requestCount *int32) (*Store, restApiV1.UserId) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(requestCount, 1)
+		w.WriteHeader(int(atomic.LoadInt32(statusCode)))
+	}))
+	t.Cleanup(server.Close)
+
+	serverConfig := config.ServerConfig{ConfigDir: t.TempDir()}
+	serverConfig.ServerEditableConfig = config.NewServerEditableConfig(nil)
+	for _, dirName := range []string{
+		serverConfig.GetCompleteConfigSongsDirName(),
+		serverConfig.GetCompleteConfigAlbumsDirName(),
+		serverConfig.GetCompleteConfigDbDirName(),
+	} {
+		if err := os.MkdirAll(dirName, 0770); err != nil {
+			t.Fatal(err)
+		}
+	}
+	s := NewStore(&serverConfig)
+
+	user, err := s.CreateUser(nil, &restApiV1.UserMetaComplete{UserMeta: restApiV1.UserMeta{Name: "alice"}, Password: "alice"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = s.UpdateScrobbling(nil, user.Id, &restApiV1.ScrobblingMeta{ServiceType: restApiV1.ScrobblingServiceTypeListenBrainz, ApiRootUrl: server.URL, Token: "token"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return s, user.Id
+}
+
+// enqueueTestScrobbles add due listens straight to the queue and wake up the forwarding job
+func enqueueTestScrobbles(t *testing.T, s *Store, userId restApiV1.UserId, count int) {
+	for i := 0; i < count; i++ {
+		_, err := s.db.Exec(`INSERT INTO scrobble (scrobble_id, user_id, listened_ts, track_name, artist_name, album_name, duration, attempt_count, next_attempt_ts)
+			VALUES (?, ?, ?, 'Song', 'Artist', '', 0, 0, 0)`, "scrobble"+string(rune('a'+i)), userId, int64(i+1)*1e9)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	s.requestScrobbleForwarding()
+}
+
+// waitScrobbles wait for the forwarding job to reach a state of the queue and return the queued listens
+func waitScrobbles(t *testing.T, s *Store, done func(scrobbleEntities []entity.ScrobbleEntity) bool) []entity.ScrobbleEntity {
+	t.Helper()
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		var scrobbleEntities []entity.ScrobbleEntity
+		err := s.db.Select(&scrobbleEntities, "SELECT * FROM scrobble ORDER BY scrobble_id")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if done(scrobbleEntities) {
+			return scrobbleEntities
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("forwarding job timeout, queue: %+v", scrobbleEntities)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func readTestScrobblingLastError(t *testing.T, s *Store, userId restApiV1.UserId) string {
+	scrobbling, err := s.ReadScrobbling(nil, userId)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return scrobbling.LastError
+}
+
+func TestScrobbleForwardingRetry(t *testing.T) {
+	statusCode := int32(http.StatusServiceUnavailable)
+	var requestCount int32
+	s, userId := newScrobblingTestStore(t, &statusCode, &requestCount)
+
+	// Listens refused by an unavailable service are kept and retried after a delay
+	before := time.Now()
+	enqueueTestScrobbles(t, s, userId, 2)
+	scrobbleEntities := waitScrobbles(t, s, func(scrobbleEntities []entity.ScrobbleEntity) bool {
+		return len(scrobbleEntities) == 2 && scrobbleEntities[0].AttemptCount == 1
+	})
+	for _, scrobbleEntity := range scrobbleEntities {
+		delay := time.Duration(scrobbleEntity.NextAttemptTs - before.UnixNano())
+		if scrobbleEntity.AttemptCount != 1 || delay < scrobbleMinRetryDelay || delay > scrobbleMinRetryDelay+10*time.Second {
+			t.Errorf("retry in %s expected after the first attempt, got attempt %d in %s", scrobbleMinRetryDelay, scrobbleEntity.AttemptCount, delay)
+		}
+	}
+	if readTestScrobblingLastError(t, s, userId) == "" {
+		t.Errorf("the last error must be kept")
+	}
+
+	// The delay doubles at each attempt
+	before = time.Now()
+	_, err := s.db.Exec("UPDATE scrobble SET next_attempt_ts = 0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.requestScrobbleForwarding()
+	scrobbleEntities = waitScrobbles(t, s, func(scrobbleEntities []entity.ScrobbleEntity) bool {
+		return len(scrobbleEntities) == 2 && scrobbleEntities[0].AttemptCount == 2
+	})
+	delay := time.Duration(scrobbleEntities[0].NextAttemptTs - before.UnixNano())
+	if delay < 2*scrobbleMinRetryDelay || delay > 2*scrobbleMinRetryDelay+10*time.Second {
+		t.Errorf("retry in %s expected after the second attempt, got %s", 2*scrobbleMinRetryDelay, delay)
+	}
+
+	// Listens accepted once the service is back are removed
+	atomic.StoreInt32(&statusCode, http.StatusOK)
+	_, err = s.db.Exec("UPDATE scrobble SET next_attempt_ts = 0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.requestScrobbleForwarding()
+	waitScrobbles(t, s, func(scrobbleEntities []entity.ScrobbleEntity) bool {
+		return len(scrobbleEntities) == 0
+	})
+	if readTestScrobblingLastError(t, s, userId) != "" {
+		t.Errorf("the last error must be cleared")
+	}
+	if atomic.LoadInt32(&requestCount) != 3 {
+		t.Errorf("3 submissions expected, got %d", atomic.LoadInt32(&requestCount))
+	}
+}
+
+func TestScrobbleForwardingPermanentError(t *testing.T) {
+	statusCode := int32(http.StatusBadRequest)
+	var requestCount int32
+	s, userId := newScrobblingTestStore(t, &statusCode, &requestCount)
+
+	// Listens refused as invalid are dropped
+	enqueueTestScrobbles(t, s, userId, 2)
+	waitScrobbles(t, s, func(scrobbleEntities []entity.ScrobbleEntity) bool {
+		return len(scrobbleEntities) == 0
+	})
+	if readTestScrobblingLastError(t, s, userId) == "" {
+		t.Errorf("the last error must be kept")
+	}
+	if atomic.LoadInt32(&requestCount) != 1 {
+		t.Errorf("1 submission expected, got %d", atomic.LoadInt32(&requestCount))
+	}
+}
+
+func TestScrobbleRetryDelay(t *testing.T) {
+	for attemptCount, expectedDelay := range map[int64]time.Duration{
+		1:  scrobbleMinRetryDelay,
+		2:  2 * scrobbleMinRetryDelay,
+		3:  4 * scrobbleMinRetryDelay,
+		50: scrobbleMaxRetryDelay,
+	} {
+		if delay := scrobbleRetryDelay(attemptCount); delay != expectedDelay {
+			t.Errorf("attempt %d: %s expected, got %s", attemptCount, expectedDelay, delay)
+		}
+	}
+}
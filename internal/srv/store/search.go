@@ -0,0 +1,101 @@
+package store
+
+import (
+	"github.com/jmoiron/sqlx"
+	"github.com/jypelle/mifasol/internal/tool"
+	"github.com/jypelle/mifasol/restApiV1"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// searchIndex is a fts5 table indexing item names
+type searchIndex struct {
+	table    string
+	idColumn string
+}
+
+var (
+	songSearchIndex     = searchIndex{table: "song_fts", idColumn: "song_id"}
+	albumSearchIndex    = searchIndex{table: "album_fts", idColumn: "album_id"}
+	artistSearchIndex   = searchIndex{table: "artist_fts", idColumn: "artist_id"}
+	playlistSearchIndex = searchIndex{table: "playlist_fts", idColumn: "playlist_id"}
+)
+
+// index add or replace the indexed name of an item
+func (i searchIndex) index(txn *sqlx.Tx, id string, name string) error {
+	err := i.unindex(txn, id)
+	if err != nil {
+		return err
+	}
+	_, err = txn.Exec("INSERT INTO "+i.table+" ("+i.idColumn+", name) VALUES (?, ?)", id, name)
+	return err
+}
+
+// unindex remove an item from the index
+func (i searchIndex) unindex(txn *sqlx.Tx, id string) error {
+	_, err := txn.Exec("DELETE FROM "+i.table+" WHERE "+i.idColumn+" = ?", id)
+	return err
+}
+
+// searchMatchExpression convert a user query into a fts5 expression matching items with every word as prefix
+func searchMatchExpression(query string) string {
+	var terms []string
+	for _, word := range strings.FieldsFunc(tool.SearchLib(query), func(r rune) bool { return !unicode.IsLetter(r) && !unicode.IsNumber(r) }) {
+		terms = append(terms, `"`+word+`"*`)
+	}
+	return strings.Join(terms, " ")
+}
+
+// Search return songs, albums, artists and playlists whose name match the query, best matches first
+func (s *Store) Search(externalTrn *sqlx.Tx, query string, limit int) ([]restApiV1.SearchResult, error) {
+	if s.serverConfig.DebugMode {
+		defer tool.TimeTrack(time.Now(), "Search")
+	}
+
+	var err error
+
+	// Check available transaction
+	txn := externalTrn
+	if txn == nil {
+		txn, err = s.db.Beginx()
+		if err != nil {
+			return nil, err
+		}
+		defer txn.Rollback()
+	}
+
+	searchResults := []restApiV1.SearchResult{}
+
+	matchExpression := searchMatchExpression(query)
+	if matchExpression == "" {
+		return searchResults, nil
+	}
+
+	err = txn.Select(&searchResults, `
+		SELECT type, id, name FROM (
+			SELECT 'song' AS type, s.song_id AS id, s.name AS name, bm25(song_fts) AS score
+			FROM song_fts JOIN song s ON s.song_id = song_fts.song_id
+			WHERE song_fts MATCH ?
+			UNION ALL
+			SELECT 'album' AS type, a.album_id AS id, a.name AS name, bm25(album_fts) AS score
+			FROM album_fts JOIN album a ON a.album_id = album_fts.album_id
+			WHERE album_fts MATCH ?
+			UNION ALL
+			SELECT 'artist' AS type, a.artist_id AS id, a.name AS name, bm25(artist_fts) AS score
+			FROM artist_fts JOIN artist a ON a.artist_id = artist_fts.artist_id
+			WHERE artist_fts MATCH ?
+			UNION ALL
+			SELECT 'playlist' AS type, p.playlist_id AS id, p.name AS name, bm25(playlist_fts) AS score
+			FROM playlist_fts JOIN playlist p ON p.playlist_id = playlist_fts.playlist_id
+			WHERE playlist_fts MATCH ?
+		)
+		ORDER BY score, name, id
+		LIMIT ?
+	`, matchExpression, matchExpression, matchExpression, matchExpression, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	return searchResults, nil
+}
@@ -0,0 +1,305 @@
+package store
+
+import (
+	"github.com/jmoiron/sqlx"
+	"github.com/jypelle/mifasol/internal/srv/entity"
+	"github.com/jypelle/mifasol/internal/tool"
+	"github.com/jypelle/mifasol/restApiV1"
+	"github.com/sirupsen/logrus"
+	"reflect"
+	"strings"
+	"time"
+)
+
+const (
+	// smartPlaylistsRefreshDelay group the changes of a burst, like a bulk import, in a single smart playlists refresh
+	smartPlaylistsRefreshDelay = 5 * time.Second
+	// smartPlaylistsRefreshInterval keep the rules on songs creation date up to date
+	smartPlaylistsRefreshInterval = time.Hour
+)
+
+// smartPlaylistSongFilter return the filter selecting the songs of a smart playlist, songs being sorted by album & track
+func smartPlaylistSongFilter(smartRules *restApiV1.SmartPlaylistRules, now int64) *restApiV1.SongFilter {
+	orderBy := restApiV1.SongFilterOrderByTrack
+	filter := &restApiV1.SongFilter{
+		AlbumId:    smartRules.AlbumId,
+		ArtistId:   smartRules.ArtistId,
+		MinYear:    smartRules.MinYear,
+		MaxYear:    smartRules.MaxYear,
+		Format:     smartRules.Format,
+		BitDepth:   smartRules.BitDepth,
+		ExplicitFg: smartRules.ExplicitFg,
+		OrderBy:    &orderBy,
+	}
+	if smartRules.AddedWithinDays != nil {
+		minCreationTs := now - *smartRules.AddedWithinDays*int64(24*time.Hour)
+		filter.MinCreationTs = &minCreationTs
+	}
+	if smartRules.UserId != nil {
+		if smartRules.FavoriteFg {
+			filter.Favorite = &restApiV1.SongFilterFavorite{UserId: *smartRules.UserId}
+		}
+		if smartRules.MinRating != nil {
+			filter.MinRating = &restApiV1.SongFilterMinRating{UserId: *smartRules.UserId, Rating: *smartRules.MinRating}
+		}
+		if smartRules.MinPlayCount != nil {
+			filter.MinPlayCount = &restApiV1.SongFilterMinPlayCount{UserId: *smartRules.UserId, PlayCount: *smartRules.MinPlayCount}
+		}
+	}
+	return filter
+}
+
+// requestSmartPlaylistsRefresh wake up the smart playlists refresh job, to call when songs, favorites, ratings or listens change
+func (s *Store) requestSmartPlaylistsRefresh() {
+	select {
+	case s.smartPlaylistsRefreshSignal <- struct{}{}:
+	default:
+	}
+}
+
+// runSmartPlaylistsRefreshJob refresh smart playlists shortly after each request and periodically
+func (s *Store) runSmartPlaylistsRefreshJob() {
+	ticker := time.NewTicker(smartPlaylistsRefreshInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.smartPlaylistsRefreshSignal:
+			time.Sleep(smartPlaylistsRefreshDelay)
+			// Requests received while waiting are handled by this refresh
+			select {
+			case <-s.smartPlaylistsRefreshSignal:
+			default:
+			}
+		case <-ticker.C:
+		}
+
+		err := s.RefreshSmartPlaylists(nil)
+		if err != nil {
+			logrus.Warnf("Unable to refresh smart playlists: %v", err)
+		}
+	}
+}
+
+// RefreshSmartPlaylists evaluate the rules of every smart playlist to update their songs
+func (s *Store) RefreshSmartPlaylists(externalTrn *sqlx.Tx) error {
+	if s.serverConfig.DebugMode {
+		defer tool.TimeTrack(time.Now(), "RefreshSmartPlaylists")
+	}
+
+	var err error
+
+	// Check available transaction
+	txn := externalTrn
+	if txn == nil {
+		txn, err = s.db.Beginx()
+		if err != nil {
+			return err
+		}
+		defer txn.Rollback()
+	}
+
+	var playlistEntities []entity.PlaylistEntity
+	err = txn.Select(&playlistEntities, "SELECT * FROM playlist WHERE smart_rules IS NOT NULL")
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().UnixNano()
+	for ind := range playlistEntities {
+		err = s.refreshSmartPlaylist(txn, &playlistEntities[ind], now)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Commit transaction
+	if externalTrn == nil {
+		return txn.Commit()
+	}
+
+	return nil
+}
+
+// refreshSmartPlaylist replace the songs of a smart playlist with the songs matching its rules.
+// When they change, the playlist is resynced and the favorite songs of the users having the playlist as favorite follow:
+// new songs are added and songs no longer matching are removed when the user got them from a favorite smart playlist only
+func (s *Store) refreshSmartPlaylist(txn *sqlx.Tx, playlistEntity *entity.PlaylistEntity, now int64) error {
+	smartRules := playlistEntity.DecodeSmartRules()
+	if smartRules == nil {
+		return nil
+	}
+
+	queryArgs := make(map[string]interface{})
+	filter := smartPlaylistSongFilter(smartRules, now)
+	filterJoins, filterConditions := songFilterClauses(filter, queryArgs)
+
+	rows, err := txn.NamedQuery(
+		`SELECT
+				s.song_id
+			FROM song s
+			`+filterJoins+`
+			WHERE 1>0
+			`+filterConditions+`
+			ORDER BY `+strings.Join(songSortKeys(filter.OrderBy), " ASC, ")+` ASC`,
+		queryArgs,
+	)
+	if err != nil {
+		return err
+	}
+	songIds := []restApiV1.SongId{}
+	for rows.Next() {
+		var songId restApiV1.SongId
+		err = rows.Scan(&songId)
+		if err != nil {
+			rows.Close()
+			return err
+		}
+		songIds = append(songIds, songId)
+	}
+	rows.Close()
+
+	oldSongIds := []restApiV1.SongId{}
+	err = txn.Select(&oldSongIds, "SELECT song_id FROM playlist_song WHERE playlist_id = ? ORDER BY position", playlistEntity.PlaylistId)
+	if err != nil {
+		return err
+	}
+
+	if reflect.DeepEqual(oldSongIds, songIds) {
+		return nil
+	}
+
+	// Replace songs link
+	_, err = txn.Exec("DELETE FROM playlist_song WHERE playlist_id = ?", playlistEntity.PlaylistId)
+	if err != nil {
+		return err
+	}
+	for position, songId := range songIds {
+		_, err = txn.NamedExec(`
+				INSERT INTO	playlist_song (
+					playlist_id,
+					position,
+					song_id
+				)
+				VALUES (
+					:playlist_id,
+					:position,
+					:song_id
+				)
+				`, entity.NewPlaylistSongEntity(playlistEntity.PlaylistId, int64(position), songId))
+		if err != nil {
+			return err
+		}
+	}
+
+	// Update playlist update timestamps
+	playlistEntity.UpdateTs = now
+	playlistEntity.ContentUpdateTs = now
+	_, err = txn.NamedExec(`
+		UPDATE playlist
+		SET update_ts = :update_ts,
+			content_update_ts = :content_update_ts
+		WHERE playlist_id = :playlist_id
+	`, playlistEntity)
+	if err != nil {
+		return err
+	}
+
+	// Diff the playlist songs
+	oldSongIdSet := make(map[restApiV1.SongId]struct{}, len(oldSongIds))
+	for _, songId := range oldSongIds {
+		oldSongIdSet[songId] = struct{}{}
+	}
+	var newSongIds []restApiV1.SongId
+	for _, songId := range songIds {
+		if _, ok := oldSongIdSet[songId]; ok {
+			delete(oldSongIdSet, songId)
+		} else {
+			newSongIds = append(newSongIds, songId)
+		}
+	}
+	var removedSongIds []restApiV1.SongId
+	for _, songId := range oldSongIds {
+		if _, ok := oldSongIdSet[songId]; ok {
+			removedSongIds = append(removedSongIds, songId)
+		}
+	}
+	if len(newSongIds) == 0 && len(removedSongIds) == 0 {
+		return nil
+	}
+
+	// Update favorite songs of the users having the playlist as favorite
+	var favoriteUserIds []restApiV1.UserId
+	err = txn.Select(&favoriteUserIds, "SELECT user_id FROM favorite_playlist WHERE playlist_id = ?", playlistEntity.PlaylistId)
+	if err != nil {
+		return err
+	}
+	for _, userId := range favoriteUserIds {
+		err = s.addSongsToFavorite(txn, userId, newSongIds)
+		if err != nil {
+			return err
+		}
+		err = s.removeSongsFromFavorite(txn, userId, removedSongIds)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// addSmartPlaylistSongsToFavorite add the songs of a smart playlist to the favorite songs of a user, to have them file synced with the playlist
+func (s *Store) addSmartPlaylistSongsToFavorite(txn *sqlx.Tx, userId restApiV1.UserId, playlistId restApiV1.PlaylistId) error {
+	songIds := []restApiV1.SongId{}
+	err := txn.Select(&songIds, `
+			SELECT ps.song_id
+			FROM playlist_song ps
+			JOIN playlist p ON p.playlist_id = ps.playlist_id
+			WHERE ps.playlist_id = ? AND p.smart_rules IS NOT NULL
+			ORDER BY ps.position`, playlistId)
+	if err != nil {
+		return err
+	}
+
+	return s.addSongsToFavorite(txn, userId, songIds)
+}
+
+func (s *Store) addSongsToFavorite(txn *sqlx.Tx, userId restApiV1.UserId, songIds []restApiV1.SongId) error {
+	for _, songId := range songIds {
+		_, err := s.createFavoriteSong(txn, &restApiV1.FavoriteSongMeta{Id: restApiV1.FavoriteSongId{UserId: userId, SongId: songId}}, true)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// removeSongsFromFavorite remove songs brought by favorite smart playlists from the favorite songs of a user,
+// except the ones still held by one of the user favorite playlists. Songs the user added are kept
+func (s *Store) removeSongsFromFavorite(txn *sqlx.Tx, userId restApiV1.UserId, songIds []restApiV1.SongId) error {
+	for _, songId := range songIds {
+		var count int64
+		err := txn.Get(&count, `
+			SELECT count(*)
+			FROM favorite_song fs
+			WHERE fs.user_id = ? AND fs.song_id = ? AND fs.smart_playlist_fg = 1
+			AND NOT EXISTS (
+				SELECT 1
+				FROM playlist_song ps
+				JOIN favorite_playlist fp ON fp.playlist_id = ps.playlist_id
+				WHERE fp.user_id = fs.user_id AND ps.song_id = fs.song_id
+			)`, userId, songId)
+		if err != nil {
+			return err
+		}
+		if count == 0 {
+			continue
+		}
+
+		_, err = s.DeleteFavoriteSong(txn, restApiV1.FavoriteSongId{UserId: userId, SongId: songId})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
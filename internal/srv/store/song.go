@@ -12,19 +12,25 @@ import (
 	"github.com/jypelle/mifasol/restApiV1"
 	"github.com/sirupsen/logrus"
 	"io"
-	"io/ioutil"
 	"os"
 	"path/filepath"
 	"sort"
+	"strings"
 	"time"
 )
 
 type SongWithAuthorsEntity struct {
 	entity.SongEntity
 	JsonArtists JsonArtists `db:"json_artists"`
+	JsonGenres  JsonGenres  `db:"json_genres"`
 }
 
-type JsonArtists []entity.ArtistEntity
+type JsonArtists []JsonArtist
+
+type JsonArtist struct {
+	entity.ArtistEntity
+	Role restApiV1.ArtistRole `json:"role"`
+}
 
 func (j JsonArtists) Value() (driver.Value, error) {
 	return json.Marshal(j)
@@ -39,6 +45,21 @@ func (j *JsonArtists) Scan(value interface{}) error {
 	return json.Unmarshal([]byte(b), &j)
 }
 
+type JsonGenres []entity.GenreEntity
+
+func (j JsonGenres) Value() (driver.Value, error) {
+	return json.Marshal(j)
+}
+
+func (j *JsonGenres) Scan(value interface{}) error {
+	b, ok := value.(string)
+	if !ok {
+		return errors.New("type assertion to []byte failed")
+	}
+
+	return json.Unmarshal([]byte(b), &j)
+}
+
 func (s *Store) ReadSongs(externalTrn *sqlx.Tx, filter *restApiV1.SongFilter) ([]restApiV1.Song, error) {
 	if s.serverConfig.DebugMode {
 		defer tool.TimeTrack(time.Now(), "ReadSongs")
@@ -57,25 +78,12 @@ func (s *Store) ReadSongs(externalTrn *sqlx.Tx, filter *restApiV1.SongFilter) ([
 	}
 
 	queryArgs := make(map[string]interface{})
-	if filter.FromTs != nil {
-		queryArgs["from_ts"] = *filter.FromTs
-	}
-	if filter.AlbumId != nil {
-		queryArgs["album_id"] = *filter.AlbumId
-	}
-	if filter.ArtistId != nil {
-		queryArgs["artist_id"] = *filter.ArtistId
-	}
-	if filter.Favorite != nil {
-		queryArgs["favorite_user_id"] = filter.Favorite.UserId
-		queryArgs["favorite_from_ts"] = filter.Favorite.FromTs
-	}
+	filterJoins, filterConditions := songFilterClauses(filter, queryArgs)
 
-	orderBy := "s.song_id ASC"
-	if filter.OrderBy != nil {
-		if *filter.OrderBy == restApiV1.SongFilterOrderByName {
-			orderBy = "s.name ASC"
-		}
+	sortKeys := songSortKeys(filter.OrderBy)
+	cursorConditions, err := pageConditions(sortKeys, songOrderByName(filter.OrderBy), filter.Limit, filter.Cursor, queryArgs)
+	if err != nil {
+		return nil, err
 	}
 
 	rows, err := txn.NamedQuery(
@@ -87,24 +95,36 @@ func (s *Store) ReadSongs(externalTrn *sqlx.Tx, filter *restApiV1.SongFilter) ([
 				s.format,
 				s.size,
 				s.bit_depth,
+				s.duration,
+				s.sample_rate,
+				s.channels,
+				s.bit_rate,
 				s.publication_year,
 				s.album_id,
+				s.disc_number,
 				s.track_number,
 				s.explicit_fg,
+				s.loudness,
+				s.true_peak,
+				s.lyrics_fg,
 				json_group_array(json_object(
 					'artist_id',a.artist_id,
 					'creation_ts',a.creation_ts,
 					'update_ts',a.update_ts,
-					'name',a.name
-				)) as json_artists
+					'name',a.name,
+					'role',asg.role
+				)) as json_artists,
+				(SELECT json_group_array(json_object(
+					'genre_id',g.genre_id,
+					'name',g.name
+				)) FROM genre_song gs JOIN genre g ON g.genre_id = gs.genre_id WHERE gs.song_id = s.song_id) as json_genres
 			FROM song s
-			`+tool.IfStr(filter.ArtistId != nil, "JOIN artist_song asg2 ON asg2.song_id = s.song_id AND asg2.artist_id = :artist_id ")+`
-			`+tool.IfStr(filter.Favorite != nil, `JOIN favorite_song fs ON fs.song_id = s.song_id AND fs.user_id = :favorite_user_id AND (fs.update_ts >= :favorite_from_ts OR s.update_ts >= :favorite_from_ts ) `)+`
+			`+filterJoins+`
 			LEFT JOIN artist_song asg ON asg.song_id = s.song_id
 			LEFT JOIN artist a ON a.artist_id = asg.artist_id
 			WHERE 1>0
-			`+tool.IfStr(filter.FromTs != nil, "AND s.update_ts >= :from_ts ")+`
-			`+tool.IfStr(filter.AlbumId != nil, "AND s.album_id = :album_id ")+`
+			`+filterConditions+`
+			`+cursorConditions+`
 			GROUP BY
 				s.song_id,
 				s.creation_ts,
@@ -113,11 +133,20 @@ func (s *Store) ReadSongs(externalTrn *sqlx.Tx, filter *restApiV1.SongFilter) ([
 				s.format,
 				s.size,
 				s.bit_depth,
+				s.duration,
+				s.sample_rate,
+				s.channels,
+				s.bit_rate,
 				s.publication_year,
 				s.album_id,
+				s.disc_number,
 				s.track_number,
-				s.explicit_fg
-			ORDER BY `+orderBy,
+				s.explicit_fg,
+				s.loudness,
+				s.true_peak,
+				s.lyrics_fg
+			ORDER BY `+strings.Join(sortKeys, " ASC, ")+` ASC
+			`+pageLimit(filter.Limit, queryArgs),
 		queryArgs,
 	)
 	if err != nil {
@@ -145,15 +174,34 @@ func (s *Store) ReadSongs(externalTrn *sqlx.Tx, filter *restApiV1.SongFilter) ([
 			}
 			return artistI.ArtistId < artistJ.ArtistId
 		})
+		// Sort genres
+		sort.Slice(songEntity.JsonGenres, func(i, j int) bool {
+			genreI := songEntity.JsonGenres[i]
+			genreJ := songEntity.JsonGenres[j]
+			if genreI.Name < genreJ.Name {
+				return true
+			}
+			if genreI.Name > genreJ.Name {
+				return false
+			}
+			return genreI.GenreId < genreJ.GenreId
+		})
 
 		var song restApiV1.Song
 		songEntity.Fill(&song)
 
 		for _, artistEntity := range songEntity.JsonArtists {
 			if artistEntity.ArtistId != "" {
-				song.ArtistIds = append(song.ArtistIds, artistEntity.ArtistId)
+				if artistEntity.Role == restApiV1.ArtistRoleMain {
+					song.ArtistIds = append(song.ArtistIds, artistEntity.ArtistId)
+				} else {
+					song.Artists = append(song.Artists, restApiV1.SongArtist{ArtistId: artistEntity.ArtistId, Role: artistEntity.Role})
+				}
 			}
 		}
+		for _, genreEntity := range songEntity.JsonGenres {
+			song.GenreIds = append(song.GenreIds, genreEntity.GenreId)
+		}
 
 		songs = append(songs, song)
 	}
@@ -161,6 +209,192 @@ func (s *Store) ReadSongs(externalTrn *sqlx.Tx, filter *restApiV1.SongFilter) ([
 	return songs, nil
 }
 
+// songFilterClauses return the joins & conditions selecting the songs matching a filter
+func songFilterClauses(filter *restApiV1.SongFilter, queryArgs map[string]interface{}) (string, string) {
+	if filter.FromTs != nil {
+		queryArgs["from_ts"] = *filter.FromTs
+	}
+	if filter.AlbumId != nil {
+		queryArgs["album_id"] = *filter.AlbumId
+	}
+	if filter.ArtistId != nil {
+		queryArgs["artist_id"] = *filter.ArtistId
+	}
+	if filter.ArtistRole != nil {
+		queryArgs["artist_role"] = *filter.ArtistRole
+	}
+	if filter.GenreId != nil {
+		queryArgs["genre_id"] = *filter.GenreId
+	}
+	if filter.Favorite != nil {
+		queryArgs["favorite_user_id"] = filter.Favorite.UserId
+		queryArgs["favorite_from_ts"] = filter.Favorite.FromTs
+	}
+	if filter.MinRating != nil {
+		queryArgs["min_rating_user_id"] = filter.MinRating.UserId
+		queryArgs["min_rating"] = filter.MinRating.Rating
+	}
+	if filter.MinPlayCount != nil {
+		queryArgs["min_play_count_user_id"] = filter.MinPlayCount.UserId
+		queryArgs["min_play_count"] = filter.MinPlayCount.PlayCount
+	}
+	if filter.MinDuration != nil {
+		queryArgs["min_duration"] = *filter.MinDuration
+	}
+	if filter.MaxDuration != nil {
+		queryArgs["max_duration"] = *filter.MaxDuration
+	}
+	if filter.MinYear != nil {
+		queryArgs["min_year"] = *filter.MinYear
+	}
+	if filter.MaxYear != nil {
+		queryArgs["max_year"] = *filter.MaxYear
+	}
+	if filter.Format != nil {
+		queryArgs["format"] = *filter.Format
+	}
+	if filter.BitDepth != nil {
+		queryArgs["bit_depth"] = *filter.BitDepth
+	}
+	if filter.ExplicitFg != nil {
+		queryArgs["explicit_fg"] = *filter.ExplicitFg
+	}
+	if filter.MinCreationTs != nil {
+		queryArgs["min_creation_ts"] = *filter.MinCreationTs
+	}
+
+	joins := tool.IfStr(filter.ArtistId != nil, "JOIN (SELECT DISTINCT song_id FROM artist_song WHERE artist_id = :artist_id "+tool.IfStr(filter.ArtistRole != nil, "AND role = :artist_role")+") asg2 ON asg2.song_id = s.song_id ") +
+		tool.IfStr(filter.GenreId != nil, "JOIN genre_song gs2 ON gs2.song_id = s.song_id AND gs2.genre_id = :genre_id ") +
+		tool.IfStr(filter.Favorite != nil, `JOIN favorite_song fs ON fs.song_id = s.song_id AND fs.user_id = :favorite_user_id AND (fs.update_ts >= :favorite_from_ts OR s.update_ts >= :favorite_from_ts ) `) +
+		tool.IfStr(filter.MinRating != nil, "JOIN song_rating sr ON sr.song_id = s.song_id AND sr.user_id = :min_rating_user_id AND sr.rating >= :min_rating ") +
+		tool.IfStr(filter.MinPlayCount != nil, "JOIN (SELECT song_id FROM play_event WHERE user_id = :min_play_count_user_id GROUP BY song_id HAVING count(*) >= :min_play_count) pe ON pe.song_id = s.song_id ")
+
+	conditions := tool.IfStr(filter.FromTs != nil, "AND s.update_ts >= :from_ts ") +
+		tool.IfStr(filter.AlbumId != nil, "AND s.album_id = :album_id ") +
+		tool.IfStr(filter.MinDuration != nil, "AND s.duration >= :min_duration ") +
+		tool.IfStr(filter.MaxDuration != nil, "AND s.duration <= :max_duration ") +
+		tool.IfStr(filter.MinYear != nil, "AND s.publication_year >= :min_year ") +
+		tool.IfStr(filter.MaxYear != nil, "AND s.publication_year <= :max_year ") +
+		tool.IfStr(filter.Format != nil, "AND s.format = :format ") +
+		tool.IfStr(filter.BitDepth != nil, "AND s.bit_depth = :bit_depth ") +
+		tool.IfStr(filter.ExplicitFg != nil, "AND s.explicit_fg = :explicit_fg ") +
+		tool.IfStr(filter.MinCreationTs != nil, "AND s.creation_ts >= :min_creation_ts ")
+
+	return joins, conditions
+}
+
+func songOrderByName(orderBy *restApiV1.SongFilterOrderBy) string {
+	if orderBy == nil {
+		return ""
+	}
+	return string(*orderBy)
+}
+
+// songSortKeys return the sql expressions songs are sorted by, the song id comes last to get a total order
+func songSortKeys(orderBy *restApiV1.SongFilterOrderBy) []string {
+	switch songOrderByName(orderBy) {
+	case string(restApiV1.SongFilterOrderByName):
+		return []string{"s.name", "s.song_id"}
+	case string(restApiV1.SongFilterOrderByDuration):
+		return []string{"s.duration", "s.song_id"}
+	case string(restApiV1.SongFilterOrderByCreationTs):
+		return []string{"s.creation_ts", "s.song_id"}
+	case string(restApiV1.SongFilterOrderByUpdateTs):
+		return []string{"s.update_ts", "s.song_id"}
+	case string(restApiV1.SongFilterOrderByYear):
+		return []string{"coalesce(s.publication_year, 0)", "s.song_id"}
+	case string(restApiV1.SongFilterOrderByTrack):
+		return []string{"s.album_id", "coalesce(s.disc_number, 0)", "coalesce(s.track_number, 0)", "s.song_id"}
+	default:
+		return []string{"s.song_id"}
+	}
+}
+
+// songSortKeyValues return the values of the song sort keys
+func songSortKeyValues(song *restApiV1.Song, orderBy *restApiV1.SongFilterOrderBy) []interface{} {
+	switch songOrderByName(orderBy) {
+	case string(restApiV1.SongFilterOrderByName):
+		return []interface{}{song.Name, song.Id}
+	case string(restApiV1.SongFilterOrderByDuration):
+		return []interface{}{song.Duration, song.Id}
+	case string(restApiV1.SongFilterOrderByCreationTs):
+		return []interface{}{song.CreationTs, song.Id}
+	case string(restApiV1.SongFilterOrderByUpdateTs):
+		return []interface{}{song.UpdateTs, song.Id}
+	case string(restApiV1.SongFilterOrderByYear):
+		return []interface{}{int64OrZero(song.PublicationYear), song.Id}
+	case string(restApiV1.SongFilterOrderByTrack):
+		return []interface{}{song.AlbumId, int64OrZero(song.DiscNumber), int64OrZero(song.TrackNumber), song.Id}
+	default:
+		return []interface{}{song.Id}
+	}
+}
+
+// CountSongs return the number of songs matching a filter, paging excluded
+func (s *Store) CountSongs(externalTrn *sqlx.Tx, filter *restApiV1.SongFilter) (int64, error) {
+	var err error
+
+	// Check available transaction
+	txn := externalTrn
+	if txn == nil {
+		txn, err = s.db.Beginx()
+		if err != nil {
+			return 0, err
+		}
+		defer txn.Rollback()
+	}
+
+	queryArgs := make(map[string]interface{})
+	filterJoins, filterConditions := songFilterClauses(filter, queryArgs)
+
+	return namedCount(txn, "SELECT count(*) FROM song s "+filterJoins+"WHERE 1>0 "+filterConditions, queryArgs)
+}
+
+// ReadSongsPage return a page of songs, the cursor of the next page (empty for the last page) and the number of songs matching the filter
+func (s *Store) ReadSongsPage(externalTrn *sqlx.Tx, filter *restApiV1.SongFilter) ([]restApiV1.Song, string, int64, error) {
+	var err error
+
+	// Check available transaction
+	txn := externalTrn
+	if txn == nil {
+		txn, err = s.db.Beginx()
+		if err != nil {
+			return nil, "", 0, err
+		}
+		defer txn.Rollback()
+	}
+
+	totalCount, err := s.CountSongs(txn, filter)
+	if err != nil {
+		return nil, "", 0, err
+	}
+
+	if filter.Limit == nil {
+		songs, err := s.ReadSongs(txn, filter)
+		return songs, "", totalCount, err
+	}
+
+	// Read one more song to know if there is a next page
+	pageFilter := *filter
+	limit := *filter.Limit + 1
+	pageFilter.Limit = &limit
+
+	songs, err := s.ReadSongs(txn, &pageFilter)
+	if err != nil {
+		return nil, "", 0, err
+	}
+
+	var nextCursor string
+	if len(songs) > *filter.Limit {
+		songs = songs[:*filter.Limit]
+		if len(songs) > 0 {
+			nextCursor = encodePageCursor(songOrderByName(filter.OrderBy), songSortKeyValues(&songs[len(songs)-1], filter.OrderBy))
+		}
+	}
+
+	return songs, nextCursor, totalCount, nil
+}
+
 func (s *Store) ReadSong(externalTrn *sqlx.Tx, songId restApiV1.SongId) (*restApiV1.Song, error) {
 	var err error
 
@@ -186,15 +420,23 @@ func (s *Store) ReadSong(externalTrn *sqlx.Tx, songId restApiV1.SongId) (*restAp
 
 	// Retrieve song artists
 	artistSongEntities := []entity.ArtistSongEntity{}
-	err = txn.Select(&artistSongEntities, "SELECT * FROM artist_song WHERE song_id = ?", songId)
+	err = txn.Select(&artistSongEntities, "SELECT asg.* FROM artist_song asg JOIN artist a ON a.artist_id = asg.artist_id WHERE asg.song_id = ? ORDER BY a.name, a.artist_id, asg.role", songId)
+	if err != nil {
+		return nil, err
+	}
+
+	// Retrieve song genres
+	genreSongEntities := []entity.GenreSongEntity{}
+	err = txn.Select(&genreSongEntities, "SELECT gs.* FROM genre_song gs JOIN genre g ON g.genre_id = gs.genre_id WHERE gs.song_id = ? ORDER BY g.name, g.genre_id", songId)
 	if err != nil {
 		return nil, err
 	}
 
 	var song restApiV1.Song
 	songEntity.Fill(&song)
-	for _, artistSongEntity := range artistSongEntities {
-		song.ArtistIds = append(song.ArtistIds, artistSongEntity.ArtistId)
+	song.ArtistIds, song.Artists = splitArtistSongEntities(artistSongEntities)
+	for _, genreSongEntity := range genreSongEntities {
+		song.GenreIds = append(song.GenreIds, genreSongEntity.GenreId)
 	}
 
 	return &song, nil
@@ -222,34 +464,75 @@ func (s *Store) GetSongFileName(song *restApiV1.Song) string {
 	return filepath.Join(s.GetSongDirName(song.Id), string(song.Id)+song.Format.Extension())
 }
 
-func (s *Store) CreateSong(externalTrn *sqlx.Tx, songNew *restApiV1.SongNew, check bool) (*restApiV1.Song, error) {
-	var err error
-
+// createSong store a new song, its content is moved from the uploads folder to the songs folder.
+// storedFileNames are the files moved in place, to remove when an external transaction is rolled back
+func (s *Store) createSong(externalTrn *sqlx.Tx, songNew *restApiV1.SongNew, content *songContent, check bool) (song *restApiV1.Song, storedFileNames []string, err error) {
 	// Check available transaction
 	txn := externalTrn
 	if txn == nil {
 		txn, err = s.db.Beginx()
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
 		defer txn.Rollback()
 	}
 
+	// Remove the files moved in place when the song isn't created
+	defer func() {
+		if err != nil {
+			for _, storedFileName := range storedFileNames {
+				os.Remove(storedFileName)
+			}
+		}
+	}()
+
 	// Store song
 	now := time.Now().UnixNano()
 
 	songEntity := entity.SongEntity{
-		SongId:     restApiV1.SongId(tool.CreateUlid()),
-		CreationTs: now,
-		UpdateTs:   now,
+		SongId:            restApiV1.SongId(tool.CreateUlid()),
+		CreationTs:        now,
+		UpdateTs:          now,
+		AudioPropertiesFg: true,
 	}
 	songEntity.LoadMeta(&songNew.SongMeta)
+	songEntity.Lyrics, err = normalizeLyrics([]byte(songNew.Lyrics))
+	if err != nil {
+		return nil, nil, err
+	}
+	songEntity.LyricsFg = songEntity.Lyrics != ""
+
+	// Check the same audio content is not already stored, songs whose content hash can't be computed are never deduplicated
+	songEntity.ContentHash, err = songContentHash(songEntity.Format, content)
+	if err != nil {
+		logrus.Warnf("Unable to compute song content hash: %v", err)
+		songEntity.ContentHash = unavailableContentHash
+	} else {
+		var existingSongIds []restApiV1.SongId
+		err = txn.Select(&existingSongIds, `SELECT song_id FROM song WHERE content_hash = ? LIMIT 1`, songEntity.ContentHash)
+		if err != nil {
+			return nil, nil, err
+		}
+		if len(existingSongIds) > 0 {
+			existingSong, err := s.ReadSong(txn, existingSongIds[0])
+			if err != nil {
+				return nil, nil, err
+			}
+			return existingSong, nil, storeerror.ErrDuplicateSong
+		}
+	}
 
 	// Reorder artists
-	artistIds := tool.DeduplicateArtistId(songNew.ArtistIds)
-	err = s.sortArtistIds(txn, artistIds)
+	artistIds, songArtists, err := s.cleanSongArtists(txn, songNew.ArtistIds, songNew.Artists)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
+	}
+
+	// Reorder genres
+	genreIds := tool.DeduplicateGenreId(songNew.GenreIds)
+	err = s.sortGenreIds(txn, genreIds)
+	if err != nil {
+		return nil, nil, err
 	}
 
 	// Create album link
@@ -259,26 +542,32 @@ func (s *Store) CreateSong(externalTrn *sqlx.Tx, songNew *restApiV1.SongNew, che
 			var albumEntity entity.AlbumEntity
 			err = txn.Get(&albumEntity, `SELECT * FROM album WHERE album_id = ?`, songEntity.AlbumId)
 			if err != nil {
-				return nil, err
+				return nil, nil, err
 			}
 		}
 	}
 
 	// Create artists link
-	for _, artistId := range artistIds {
-		// Store artist song
+	err = insertArtistSongs(txn, songEntity.SongId, artistIds, songArtists)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Create genres link
+	for _, genreId := range genreIds {
+		// Store genre song
 		_, err = txn.NamedExec(`
-			INSERT INTO	artist_song (
-			    artist_id,
+			INSERT INTO	genre_song (
+			    genre_id,
 				song_id
 			)
 			VALUES (
-			    :artist_id,
+			    :genre_id,
 				:song_id
 			)
-		`, &entity.ArtistSongEntity{ArtistId: artistId, SongId: songEntity.SongId})
+		`, &entity.GenreSongEntity{GenreId: genreId, SongId: songEntity.SongId})
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
 	}
 
@@ -292,10 +581,19 @@ func (s *Store) CreateSong(externalTrn *sqlx.Tx, songNew *restApiV1.SongNew, che
 				format,
 				size,
 				bit_depth,
+				duration,
+				sample_rate,
+				channels,
+				bit_rate,
+				audio_properties_fg,
 				publication_year,
 				album_id,
+				disc_number,
 				track_number,
-				explicit_fg
+				explicit_fg,
+				content_hash,
+				lyrics,
+				lyrics_fg
 			)
 			VALUES (
 			    :song_id,
@@ -305,40 +603,77 @@ func (s *Store) CreateSong(externalTrn *sqlx.Tx, songNew *restApiV1.SongNew, che
 				:format,
 				:size,
 				:bit_depth,
+				:duration,
+				:sample_rate,
+				:channels,
+				:bit_rate,
+				:audio_properties_fg,
 				:publication_year,
 				:album_id,
+				:disc_number,
 				:track_number,
-				:explicit_fg
+				:explicit_fg,
+				:content_hash,
+				:lyrics,
+				:lyrics_fg
 			)`,
 		&songEntity,
 	)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
+	}
+	err = songSearchIndex.index(txn, string(songEntity.SongId), songEntity.Name)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Use embedded picture as album cover when missing
+	if songEntity.AlbumId != restApiV1.UnknownAlbumId {
+		album, err := s.ReadAlbum(txn, songEntity.AlbumId)
+		if err != nil {
+			return nil, nil, err
+		}
+		if !album.CoverFg {
+			cover := extractSongCover(songEntity.Format, content)
+			if cover != nil {
+				coverTmpFileName, err := s.storeAlbumCover(txn, songEntity.AlbumId, cover.content)
+				if err != nil {
+					return nil, nil, err
+				}
+				// The cover is needed in place to write the song tags
+				err = os.Rename(coverTmpFileName, s.getAlbumCoverFileName(songEntity.AlbumId))
+				if err != nil {
+					os.Remove(coverTmpFileName)
+					return nil, nil, err
+				}
+				storedFileNames = append(storedFileNames, s.getAlbumCoverFileName(songEntity.AlbumId))
+			}
+		}
 	}
 
-	// Write song content
+	// Move song content
 	err = os.MkdirAll(s.GetSongDirName(songEntity.SongId), 0770)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
-	err = ioutil.WriteFile(s.getSongFileName(songEntity.SongId, songEntity.Format), songNew.Content, 0660)
+	songFileName := s.getSongFileName(songEntity.SongId, songEntity.Format)
+	err = content.moveTo(songFileName)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
+	storedFileNames = append(storedFileNames, songFileName)
 
 	// Update tags in song content
 	err = s.UpdateSongContentTag(txn, &songEntity)
 	if err != nil {
-		// If tags not updated, delete the song file
-		os.Remove(s.getSongFileName(songEntity.SongId, songEntity.Format))
-		return nil, err
+		return nil, nil, err
 	}
 
 	if songEntity.AlbumId != restApiV1.UnknownAlbumId {
 		// Update album
 		_, err = txn.Exec(`UPDATE album SET update_ts = ? WHERE album_id = ?`, now, songEntity.AlbumId)
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
 	}
 
@@ -346,24 +681,32 @@ func (s *Store) CreateSong(externalTrn *sqlx.Tx, songNew *restApiV1.SongNew, che
 	logrus.Debugf("Add song to incoming playlist")
 	_, err = s.AddSongToPlaylist(txn, restApiV1.IncomingPlaylistId, songEntity.SongId, false)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	// Commit transaction
 	if externalTrn == nil {
-		txn.Commit()
+		err = txn.Commit()
+		if err != nil {
+			return nil, nil, err
+		}
 	}
 
-	var song restApiV1.Song
-	songEntity.Fill(&song)
+	// Analyse song loudness & waveform and add song to matching smart playlists in background
+	s.requestSongAnalysis()
+	s.requestSmartPlaylistsRefresh()
+
+	song = &restApiV1.Song{}
+	songEntity.Fill(song)
 	song.ArtistIds = artistIds
+	song.Artists = songArtists
+	song.GenreIds = genreIds
 
-	return &song, nil
+	return song, storedFileNames, nil
 }
 
-func (s *Store) CreateSongFromRawContent(externalTrn *sqlx.Tx, raw io.ReadCloser, lastAlbumId restApiV1.AlbumId) (*restApiV1.Song, error) {
-	var err error
-
+// CreateSongFromRawContent create a song from an uploaded file, its tag rating is given to the uploading user
+func (s *Store) CreateSongFromRawContent(externalTrn *sqlx.Tx, raw io.ReadCloser, lastAlbumId restApiV1.AlbumId, userId restApiV1.UserId) (song *restApiV1.Song, err error) {
 	// Check available transaction
 	txn := externalTrn
 	if txn == nil {
@@ -374,13 +717,21 @@ func (s *Store) CreateSongFromRawContent(externalTrn *sqlx.Tx, raw io.ReadCloser
 		defer txn.Rollback()
 	}
 
-	var content []byte
-	content, err = ioutil.ReadAll(raw)
+	// Spool uploaded content to disk, the temporary file is removed unless moved to the songs folder
+	content, err := s.spoolSongContent(raw)
 	if err != nil {
 		return nil, err
 	}
+	defer content.remove()
 
-	prefix := content[:4]
+	head, err := content.readAt(0, oggSniffLength)
+	if err != nil {
+		return nil, err
+	}
+	prefix := head
+	if len(prefix) > 4 {
+		prefix = prefix[:4]
+	}
 
 	var songNew *restApiV1.SongNew
 
@@ -389,9 +740,19 @@ func (s *Store) CreateSongFromRawContent(externalTrn *sqlx.Tx, raw io.ReadCloser
 	case "fLaC":
 		songNew, err = s.createSongNewFromFlacContent(txn, content, lastAlbumId)
 	case "OggS":
-		songNew, err = s.createSongNewFromOggContent(txn, content, lastAlbumId)
+		// Ogg container may hold vorbis or opus stream
+		if sniffOggFormat(head) == restApiV1.SongFormatOpus {
+			songNew, err = s.createSongNewFromOpusContent(txn, content, lastAlbumId)
+		} else {
+			songNew, err = s.createSongNewFromOggContent(txn, content, lastAlbumId)
+		}
 	default:
-		songNew, err = s.createSongNewFromMp3Content(txn, content, lastAlbumId)
+		// Mp4 content start with a ftyp box
+		if len(head) >= 8 && string(head[4:8]) == "ftyp" {
+			songNew, err = s.createSongNewFromM4aContent(txn, content, lastAlbumId)
+		} else {
+			songNew, err = s.createSongNewFromMp3Content(txn, content, lastAlbumId)
+		}
 	}
 
 	if err != nil {
@@ -399,16 +760,41 @@ func (s *Store) CreateSongFromRawContent(externalTrn *sqlx.Tx, raw io.ReadCloser
 	}
 
 	logrus.Debugf("Create song")
-	var song *restApiV1.Song
-	song, err = s.CreateSong(txn, songNew, false)
+	song, storedFileNames, err := s.createSong(txn, songNew, content, false)
 	if err != nil {
+		if err == storeerror.ErrDuplicateSong {
+			return song, err
+		}
 		return nil, err
 	}
 
+	// Remove the files moved in place when the song creation is rolled back
+	defer func() {
+		if err != nil {
+			for _, storedFileName := range storedFileNames {
+				os.Remove(storedFileName)
+			}
+		}
+	}()
+
+	// Import rating from tags
+	if songNew.Rating != 0 && userId != "" {
+		_, err = s.UpdateSongRating(txn, &restApiV1.SongRatingMeta{
+			Id:     restApiV1.SongRatingId{UserId: userId, SongId: song.Id},
+			Rating: songNew.Rating,
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	logrus.Debugf("Commit")
 	// Commit transaction
 	if externalTrn == nil {
-		txn.Commit()
+		err = txn.Commit()
+		if err != nil {
+			return nil, err
+		}
 	}
 	logrus.Debugf("End commit")
 
@@ -437,16 +823,24 @@ func (s *Store) UpdateSong(externalTrn *sqlx.Tx, songId restApiV1.SongId, songMe
 
 	// Retrieve actual song artists
 	artistSongEntities := []entity.ArtistSongEntity{}
-	err = txn.Select(&artistSongEntities, "SELECT asg.* FROM artist_song asg JOIN artist a ON a.artist_id = asg.artist_id WHERE asg.song_id = ? ORDER BY a.name", songId)
+	err = txn.Select(&artistSongEntities, "SELECT asg.* FROM artist_song asg JOIN artist a ON a.artist_id = asg.artist_id WHERE asg.song_id = ? ORDER BY a.name, a.artist_id, asg.role", songId)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, storeerror.ErrNotFound
 		}
 		return nil, err
 	}
-	var songOldArtistIds []restApiV1.ArtistId
-	for _, artistSongEntity := range artistSongEntities {
-		songOldArtistIds = append(songOldArtistIds, artistSongEntity.ArtistId)
+	songOldArtistIds, songOldArtists := splitArtistSongEntities(artistSongEntities)
+
+	// Retrieve actual song genres
+	genreSongEntities := []entity.GenreSongEntity{}
+	err = txn.Select(&genreSongEntities, "SELECT gs.* FROM genre_song gs JOIN genre g ON g.genre_id = gs.genre_id WHERE gs.song_id = ? ORDER BY g.name, g.genre_id", songId)
+	if err != nil {
+		return nil, err
+	}
+	var songOldGenreIds []restApiV1.GenreId
+	for _, genreSongEntity := range genreSongEntities {
+		songOldGenreIds = append(songOldGenreIds, genreSongEntity.GenreId)
 	}
 
 	// Retrieve actual song album
@@ -458,17 +852,35 @@ func (s *Store) UpdateSong(externalTrn *sqlx.Tx, songId restApiV1.SongId, songMe
 	// Set new artists
 	// Cleaning song new artists
 	var songNewArtistIds []restApiV1.ArtistId
+	var songNewArtists []restApiV1.SongArtist
 	var artistIdsChanged = false
+	var artistsChanged = false
 	// Deduplicate & reorder artists
 	if songMeta != nil {
-		songNewArtistIds = tool.DeduplicateArtistId(songMeta.ArtistIds)
-		err = s.sortArtistIds(txn, songNewArtistIds)
+		songNewArtistIds, songNewArtists, err = s.cleanSongArtists(txn, songMeta.ArtistIds, songMeta.Artists)
 		if err != nil {
 			return nil, err
 		}
 		artistIdsChanged = !isArtistIdsEqual(songOldArtistIds, songNewArtistIds)
+		artistsChanged = !isSongArtistsEqual(songOldArtists, songNewArtists)
 	} else {
 		songNewArtistIds = songOldArtistIds
+		songNewArtists = songOldArtists
+	}
+
+	// Set new genres
+	var songNewGenreIds []restApiV1.GenreId
+	var genreIdsChanged = false
+	// Deduplicate & reorder genres
+	if songMeta != nil {
+		songNewGenreIds = tool.DeduplicateGenreId(songMeta.GenreIds)
+		err = s.sortGenreIds(txn, songNewGenreIds)
+		if err != nil {
+			return nil, err
+		}
+		genreIdsChanged = !isGenreIdsEqual(songOldGenreIds, songNewGenreIds)
+	} else {
+		songNewGenreIds = songOldGenreIds
 	}
 
 	// Update album link
@@ -486,7 +898,7 @@ func (s *Store) UpdateSong(externalTrn *sqlx.Tx, songId restApiV1.SongId, songMe
 	}
 
 	// Update artists link
-	if artistIdsChanged {
+	if artistIdsChanged || artistsChanged {
 		// Delete old links
 		_, err = txn.Exec("DELETE FROM artist_song WHERE song_id = ?", songId)
 		if err != nil {
@@ -494,19 +906,34 @@ func (s *Store) UpdateSong(externalTrn *sqlx.Tx, songId restApiV1.SongId, songMe
 		}
 
 		// Insert new links
-		for _, artistId := range songNewArtistIds {
-			// Store artist song
+		err = insertArtistSongs(txn, songEntity.SongId, songNewArtistIds, songNewArtists)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Update genres link
+	if genreIdsChanged {
+		// Delete old links
+		_, err = txn.Exec("DELETE FROM genre_song WHERE song_id = ?", songId)
+		if err != nil {
+			return nil, err
+		}
+
+		// Insert new links
+		for _, genreId := range songNewGenreIds {
+			// Store genre song
 			_, err = txn.NamedExec(`
-				INSERT INTO	artist_song (
-					artist_id,
+				INSERT INTO	genre_song (
+					genre_id,
 					song_id
 				)
 				VALUES (
-					:artist_id,
+					:genre_id,
 					:song_id
 				)
 				`,
-				&entity.ArtistSongEntity{ArtistId: artistId, SongId: songEntity.SongId},
+				&entity.GenreSongEntity{GenreId: genreId, SongId: songEntity.SongId},
 			)
 			if err != nil {
 				return nil, err
@@ -522,8 +949,13 @@ func (s *Store) UpdateSong(externalTrn *sqlx.Tx, songId restApiV1.SongId, songMe
 		    format = :format,
 		    size = :size,
 		    bit_depth = :bit_depth,
+		    duration = :duration,
+		    sample_rate = :sample_rate,
+		    channels = :channels,
+		    bit_rate = :bit_rate,
 		    publication_year = :publication_year,
 		    album_id = :album_id,
+		    disc_number = :disc_number,
 		    track_number = :track_number,
 		    explicit_fg = :explicit_fg,
 			update_ts = :update_ts
@@ -532,6 +964,10 @@ func (s *Store) UpdateSong(externalTrn *sqlx.Tx, songId restApiV1.SongId, songMe
 	if err != nil {
 		return nil, err
 	}
+	err = songSearchIndex.index(txn, string(songEntity.SongId), songEntity.Name)
+	if err != nil {
+		return nil, err
+	}
 
 	// Update playlists content update
 	_, err = txn.NamedExec(`
@@ -563,13 +999,31 @@ func (s *Store) UpdateSong(externalTrn *sqlx.Tx, songId restApiV1.SongId, songMe
 		}
 	}
 
+	// Refresh albums loudness
+	if songEntity.Loudness.Valid && songEntity.AlbumId != songOldAlbumId {
+		err = s.refreshAlbumLoudness(txn, songOldAlbumId)
+		if err != nil {
+			return nil, err
+		}
+		err = s.refreshAlbumLoudness(txn, songEntity.AlbumId)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	// Commit transaction
 	if externalTrn == nil {
 		txn.Commit()
 	}
 
+	// Refresh smart playlists in background
+	s.requestSmartPlaylistsRefresh()
+
 	var song restApiV1.Song
 	songEntity.Fill(&song)
+	song.ArtistIds = songNewArtistIds
+	song.Artists = songNewArtists
+	song.GenreIds = songNewGenreIds
 
 	return &song, nil
 }
@@ -634,6 +1088,17 @@ func (s *Store) DeleteSong(externalTrn *sqlx.Tx, songId restApiV1.SongId) (*rest
 		return nil, err
 	}
 
+	// Delete genres link
+	queryArgs = make(map[string]interface{})
+	queryArgs["song_id"] = songId
+	_, err = txn.NamedExec(`
+			DELETE FROM	genre_song
+			WHERE song_id = :song_id
+		`, queryArgs)
+	if err != nil {
+		return nil, err
+	}
+
 	// Delete favorite song link
 	queryArgs = make(map[string]interface{})
 	queryArgs["delete_ts"] = deleteTs
@@ -665,6 +1130,42 @@ func (s *Store) DeleteSong(externalTrn *sqlx.Tx, songId restApiV1.SongId) (*rest
 		return nil, err
 	}
 
+	// Delete song ratings
+	queryArgs = make(map[string]interface{})
+	queryArgs["delete_ts"] = deleteTs
+	queryArgs["song_id"] = songId
+	_, err = txn.NamedExec(`
+			INSERT OR REPLACE INTO deleted_song_rating (
+			    user_id,
+			    song_id,
+				delete_ts
+			)
+			SELECT
+			    user_id,
+			    song_id,
+				:delete_ts
+			FROM song_rating
+			WHERE song_id = :song_id
+	`, queryArgs)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = txn.Exec("DELETE FROM song_rating WHERE song_id = ?", songId)
+	if err != nil {
+		return nil, err
+	}
+
+	// Delete song waveform & play history
+	_, err = txn.Exec("DELETE FROM song_waveform WHERE song_id = ?", songId)
+	if err != nil {
+		return nil, err
+	}
+	_, err = txn.Exec("DELETE FROM play_event WHERE song_id = ?", songId)
+	if err != nil {
+		return nil, err
+	}
+
 	// Delete song
 	queryArgs = make(map[string]interface{})
 	queryArgs["song_id"] = songId
@@ -675,6 +1176,10 @@ func (s *Store) DeleteSong(externalTrn *sqlx.Tx, songId restApiV1.SongId) (*rest
 	if err != nil {
 		return nil, err
 	}
+	err = songSearchIndex.unindex(txn, string(songId))
+	if err != nil {
+		return nil, err
+	}
 
 	// Archive songId
 	_, err = txn.NamedExec(`
@@ -702,11 +1207,20 @@ func (s *Store) DeleteSong(externalTrn *sqlx.Tx, songId restApiV1.SongId) (*rest
 		}
 	}
 
+	// Refresh album loudness
+	if song.Loudness != nil {
+		err = s.refreshAlbumLoudness(txn, song.AlbumId)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	// Delete song content
 	err = os.Remove(s.getSongFileName(songId, song.Format))
 	if err != nil {
 		return nil, err
 	}
+	s.removeSongTranscodedContents(songId, "")
 
 	// Commit transaction
 	if externalTrn == nil {
@@ -764,6 +1278,102 @@ func (s *Store) GetDeletedSongIds(externalTrn *sqlx.Tx, fromTs int64) ([]restApi
 	return songIds, nil
 }
 
+// extractSongAudioProperties fill duration, sample rate, channels and bitrate of the song meta from song content,
+// they are left to zero when the extraction fails
+func extractSongAudioProperties(content *songContent, songMeta *restApiV1.SongMeta) error {
+	var err error
+	switch songMeta.Format {
+	case restApiV1.SongFormatFlac:
+		err = extractFlacAudioProperties(content, songMeta)
+	case restApiV1.SongFormatMp3:
+		err = extractMp3AudioProperties(content, songMeta)
+	case restApiV1.SongFormatOgg:
+		err = extractOggAudioProperties(content, songMeta)
+	case restApiV1.SongFormatOpus:
+		err = extractOpusAudioProperties(content, songMeta)
+	case restApiV1.SongFormatM4a:
+		err = extractM4aAudioProperties(content, songMeta)
+	}
+	if err != nil {
+		songMeta.Duration = 0
+		songMeta.SampleRate = 0
+		songMeta.Channels = 0
+		songMeta.BitRate = 0
+	}
+	return err
+}
+
+// refreshSongsAudioProperties compute missing audio properties of already stored songs
+func (s *Store) refreshSongsAudioProperties() error {
+	txn, err := s.db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer txn.Rollback()
+
+	songEntities := []entity.SongEntity{}
+	err = txn.Select(&songEntities, "SELECT * FROM song WHERE audio_properties_fg = 0")
+	if err != nil {
+		return err
+	}
+
+	if len(songEntities) == 0 {
+		return nil
+	}
+
+	logrus.Printf("Computing audio properties of %d songs ...", len(songEntities))
+
+	now := time.Now().UnixNano()
+	for _, songEntity := range songEntities {
+		// Songs whose audio properties can't be extracted are flagged too, to not rescan them at every startup
+		songEntity.AudioPropertiesFg = true
+
+		content, err := openSongContent(s.getSongFileName(songEntity.SongId, songEntity.Format))
+		if err != nil {
+			logrus.Warnf("Unable to read song %s content: %v", songEntity.SongId, err)
+			_, err = txn.Exec("UPDATE song SET audio_properties_fg = 1 WHERE song_id = ?", songEntity.SongId)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+
+		songMeta := restApiV1.SongMeta{Format: songEntity.Format}
+		err = extractSongAudioProperties(content, &songMeta)
+		content.Close()
+		if err != nil {
+			logrus.Warnf("Unable to extract song %s audio properties: %v", songEntity.SongId, err)
+			_, err = txn.Exec("UPDATE song SET audio_properties_fg = 1 WHERE song_id = ?", songEntity.SongId)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+
+		songEntity.Duration = songMeta.Duration
+		songEntity.SampleRate = songMeta.SampleRate
+		songEntity.Channels = songMeta.Channels
+		songEntity.BitRate = songMeta.BitRate
+		songEntity.UpdateTs = now
+
+		_, err = txn.NamedExec(`
+			UPDATE song
+			SET duration = :duration,
+			    sample_rate = :sample_rate,
+			    channels = :channels,
+			    bit_rate = :bit_rate,
+			    audio_properties_fg = :audio_properties_fg,
+				update_ts = :update_ts
+			WHERE song_id = :song_id
+		`, &songEntity)
+		if err != nil {
+			return err
+		}
+	}
+
+	return txn.Commit()
+}
+
 // UpdateSongContentTag update tags in song content
 func (s *Store) UpdateSongContentTag(externalTrn *sqlx.Tx, songEntity *entity.SongEntity) error {
 
@@ -774,6 +1384,10 @@ func (s *Store) UpdateSongContentTag(externalTrn *sqlx.Tx, songEntity *entity.So
 		return s.updateSongContentMp3Tag(externalTrn, songEntity)
 	case restApiV1.SongFormatOgg:
 		return s.updateSongContentOggTag(externalTrn, songEntity)
+	case restApiV1.SongFormatOpus:
+		return s.updateSongContentOpusTag(externalTrn, songEntity)
+	case restApiV1.SongFormatM4a:
+		return s.updateSongContentM4aTag(externalTrn, songEntity)
 	}
 	return nil
 }
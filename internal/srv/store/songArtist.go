@@ -0,0 +1,197 @@
+package store
+
+import (
+	"github.com/jmoiron/sqlx"
+	"github.com/jypelle/mifasol/internal/srv/entity"
+	"github.com/jypelle/mifasol/internal/tool"
+	"github.com/jypelle/mifasol/restApiV1"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// featuringRegexp match the separator between main and featured artists in an artist tag
+var featuringRegexp = regexp.MustCompile(`(?i)\s+(?:feat\.?|ft\.|featuring)\s+`)
+
+// performerInstrumentRegexp match the instrument or part following a performer name
+var performerInstrumentRegexp = regexp.MustCompile(`\s*\([^)]*\)\s*$`)
+
+// splitArtistNames split a raw artist tag value holding several artists
+func splitArtistNames(rawArtistNames string) []string {
+	return strings.FieldsFunc(rawArtistNames, func(r rune) bool { return r == ',' || r == ';' || r == 0 })
+}
+
+// splitFeaturingArtistNames split a raw artist tag value into main and featured artists
+func splitFeaturingArtistNames(rawArtistNames string) (mainArtistNames []string, featuringArtistNames []string) {
+	parts := featuringRegexp.Split(rawArtistNames, 2)
+	mainArtistNames = splitArtistNames(parts[0])
+	if len(parts) > 1 {
+		featuringArtistNames = splitArtistNames(strings.Trim(parts[1], "() "))
+	}
+	return mainArtistNames, featuringArtistNames
+}
+
+// parsePerformerNames extract performer names from raw performer tag values, dropping instruments: "John Doe (guitar)"
+func parsePerformerNames(rawPerformerNames []string) []string {
+	var performerNames []string
+	for _, rawPerformerName := range rawPerformerNames {
+		for _, performerName := range splitArtistNames(rawPerformerName) {
+			performerNames = append(performerNames, performerInstrumentRegexp.ReplaceAllString(performerName, ""))
+		}
+	}
+	return performerNames
+}
+
+// formatArtistNames return the artist tag value of main and featured artists: "Main1, Main2 feat. Featuring1"
+func formatArtistNames(mainArtistNames []string, featuringArtistNames []string) string {
+	artistNamesStr := strings.Join(mainArtistNames, ", ")
+	if len(featuringArtistNames) > 0 {
+		artistNamesStr += " feat. " + strings.Join(featuringArtistNames, ", ")
+	}
+	return artistNamesStr
+}
+
+// getSongArtistsFromArtistNames return the song artists of the given role, creating missing artists
+func (s *Store) getSongArtistsFromArtistNames(txn *sqlx.Tx, artistNames []string, role restApiV1.ArtistRole) ([]restApiV1.SongArtist, error) {
+	artistIds, err := s.getArtistIdsFromArtistNames(txn, artistNames)
+	if err != nil {
+		return nil, err
+	}
+
+	var songArtists []restApiV1.SongArtist
+	for _, artistId := range artistIds {
+		songArtists = append(songArtists, restApiV1.SongArtist{ArtistId: artistId, Role: role})
+	}
+	return songArtists, nil
+}
+
+// getCreditedSongArtistsFromArtistNames return the featured artists, composers, performers & conductors of a song, creating missing artists
+func (s *Store) getCreditedSongArtistsFromArtistNames(txn *sqlx.Tx, featuringArtistNames, composerNames, performerNames, conductorNames []string) ([]restApiV1.SongArtist, error) {
+	var songArtists []restApiV1.SongArtist
+	for _, credit := range []struct {
+		artistNames []string
+		role        restApiV1.ArtistRole
+	}{
+		{featuringArtistNames, restApiV1.ArtistRoleFeaturing},
+		{composerNames, restApiV1.ArtistRoleComposer},
+		{performerNames, restApiV1.ArtistRolePerformer},
+		{conductorNames, restApiV1.ArtistRoleConductor},
+	} {
+		creditedSongArtists, err := s.getSongArtistsFromArtistNames(txn, credit.artistNames, credit.role)
+		if err != nil {
+			return nil, err
+		}
+		songArtists = append(songArtists, creditedSongArtists...)
+	}
+	return songArtists, nil
+}
+
+// readSongArtistNames return the names of the artists linked to a song with the given role, sorted by name
+func (s *Store) readSongArtistNames(txn *sqlx.Tx, songId restApiV1.SongId, role restApiV1.ArtistRole) ([]string, error) {
+	var artistNames []string
+	err := txn.Select(&artistNames, "SELECT a.name FROM artist a JOIN artist_song asg ON asg.artist_id = a.artist_id WHERE asg.song_id = ? AND asg.role = ? ORDER BY a.name, a.artist_id", songId, role)
+	if err != nil {
+		return nil, err
+	}
+	return artistNames, nil
+}
+
+// splitArtistSongEntities split song artists links into main artist ids and other credited artists
+func splitArtistSongEntities(artistSongEntities []entity.ArtistSongEntity) ([]restApiV1.ArtistId, []restApiV1.SongArtist) {
+	var artistIds []restApiV1.ArtistId
+	var songArtists []restApiV1.SongArtist
+	for _, artistSongEntity := range artistSongEntities {
+		if artistSongEntity.Role == restApiV1.ArtistRoleMain {
+			artistIds = append(artistIds, artistSongEntity.ArtistId)
+		} else {
+			songArtists = append(songArtists, restApiV1.SongArtist{ArtistId: artistSongEntity.ArtistId, Role: artistSongEntity.Role})
+		}
+	}
+	return artistIds, songArtists
+}
+
+// cleanSongArtists deduplicate & reorder song artists, main artists credited in songArtists are moved to artistIds
+func (s *Store) cleanSongArtists(txn *sqlx.Tx, artistIds []restApiV1.ArtistId, songArtists []restApiV1.SongArtist) ([]restApiV1.ArtistId, []restApiV1.SongArtist, error) {
+	var cleanedSongArtists []restApiV1.SongArtist
+	keys := make(map[restApiV1.SongArtist]bool)
+	for _, songArtist := range songArtists {
+		if songArtist.Role == restApiV1.ArtistRoleMain {
+			artistIds = append(artistIds, songArtist.ArtistId)
+		} else if songArtist.Role.IsValid() && !keys[songArtist] {
+			keys[songArtist] = true
+			cleanedSongArtists = append(cleanedSongArtists, songArtist)
+		}
+	}
+
+	artistIds = tool.DeduplicateArtistId(artistIds)
+	err := s.sortArtistIds(txn, artistIds)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Reorder other credited artists by name
+	artistNames := make(map[restApiV1.ArtistId]string)
+	for _, songArtist := range cleanedSongArtists {
+		artist, err := s.ReadArtist(txn, songArtist.ArtistId)
+		if err != nil {
+			return nil, nil, err
+		}
+		artistNames[songArtist.ArtistId] = artist.Name
+	}
+	sort.SliceStable(cleanedSongArtists, func(i, j int) bool {
+		nameI := artistNames[cleanedSongArtists[i].ArtistId]
+		nameJ := artistNames[cleanedSongArtists[j].ArtistId]
+		if nameI != nameJ {
+			return nameI < nameJ
+		}
+		if cleanedSongArtists[i].ArtistId != cleanedSongArtists[j].ArtistId {
+			return cleanedSongArtists[i].ArtistId < cleanedSongArtists[j].ArtistId
+		}
+		return cleanedSongArtists[i].Role < cleanedSongArtists[j].Role
+	})
+
+	return artistIds, cleanedSongArtists, nil
+}
+
+func isSongArtistsEqual(a, b []restApiV1.SongArtist) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i, v := range a {
+		if v != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// insertArtistSongs link artists to a song with their role
+func insertArtistSongs(txn *sqlx.Tx, songId restApiV1.SongId, artistIds []restApiV1.ArtistId, songArtists []restApiV1.SongArtist) error {
+	artistSongEntities := make([]entity.ArtistSongEntity, 0, len(artistIds)+len(songArtists))
+	for _, artistId := range artistIds {
+		artistSongEntities = append(artistSongEntities, entity.ArtistSongEntity{ArtistId: artistId, SongId: songId, Role: restApiV1.ArtistRoleMain})
+	}
+	for _, songArtist := range songArtists {
+		artistSongEntities = append(artistSongEntities, entity.ArtistSongEntity{ArtistId: songArtist.ArtistId, SongId: songId, Role: songArtist.Role})
+	}
+
+	for _, artistSongEntity := range artistSongEntities {
+		// Store artist song
+		_, err := txn.NamedExec(`
+			INSERT INTO	artist_song (
+			    artist_id,
+				song_id,
+				role
+			)
+			VALUES (
+			    :artist_id,
+				:song_id,
+				:role
+			)
+		`, &artistSongEntity)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
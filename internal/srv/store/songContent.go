@@ -0,0 +1,160 @@
+package store
+
+import (
+	"bufio"
+	"github.com/sirupsen/logrus"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// songContent give access to a song file without loading it in memory
+type songContent struct {
+	file  *os.File
+	size  int64
+	moved bool
+}
+
+// openSongContent open a stored song file
+func openSongContent(fileName string) (*songContent, error) {
+	file, err := os.Open(fileName)
+	if err != nil {
+		return nil, err
+	}
+
+	fileInfo, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	return &songContent{file: file, size: fileInfo.Size()}, nil
+}
+
+// spoolSongContent copy an uploaded song into a temporary file of the uploads folder
+func (s *Store) spoolSongContent(raw io.Reader) (*songContent, error) {
+	err := os.MkdirAll(s.serverConfig.GetCompleteConfigUploadsDirName(), 0770)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.CreateTemp(s.serverConfig.GetCompleteConfigUploadsDirName(), "upload-*")
+	if err != nil {
+		return nil, err
+	}
+	content := &songContent{file: file}
+
+	err = file.Chmod(0660)
+	if err == nil {
+		content.size, err = io.Copy(file, raw)
+	}
+	if err != nil {
+		content.remove()
+		return nil, err
+	}
+
+	return content, nil
+}
+
+// removeUploadLeftovers delete temporary files of uploads interrupted by a server stop
+func (s *Store) removeUploadLeftovers() {
+	fileNames, _ := filepath.Glob(filepath.Join(s.serverConfig.GetCompleteConfigUploadsDirName(), "upload-*"))
+	for _, fileName := range fileNames {
+		err := os.Remove(fileName)
+		if err != nil {
+			logrus.Warnf("Unable to remove upload leftover %s: %v", fileName, err)
+		}
+	}
+}
+
+// Close release the song file
+func (c *songContent) Close() error {
+	return c.file.Close()
+}
+
+// remove close and delete a temporary song file, unless it has been moved
+func (c *songContent) remove() {
+	c.file.Close()
+	if !c.moved {
+		os.Remove(c.file.Name())
+	}
+}
+
+// moveTo close a temporary song file and move it to its final location
+func (c *songContent) moveTo(fileName string) error {
+	c.file.Close()
+	err := os.Rename(c.file.Name(), fileName)
+	if err != nil {
+		return err
+	}
+	c.moved = true
+	return nil
+}
+
+// reader return a reader of the whole content
+func (c *songContent) reader() *io.SectionReader {
+	return io.NewSectionReader(c.file, 0, c.size)
+}
+
+// bufferedReader return a buffered reader of the whole content, for parsers reading small chunks
+func (c *songContent) bufferedReader() *bufio.Reader {
+	return bufio.NewReader(c.reader())
+}
+
+// section return a reader of a part of the content
+func (c *songContent) section(offset int64, length int64) *io.SectionReader {
+	return io.NewSectionReader(c.file, offset, length)
+}
+
+// readAt return the part of the content starting at offset, truncated at the end of the content
+func (c *songContent) readAt(offset int64, length int64) ([]byte, error) {
+	if offset < 0 || offset > c.size {
+		return nil, io.ErrUnexpectedEOF
+	}
+	if offset+length > c.size {
+		length = c.size - offset
+	}
+
+	buffer := make([]byte, length)
+	_, err := c.file.ReadAt(buffer, offset)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	return buffer, nil
+}
+
+// rewriteSongFile replace a stored song file with a rewritten copy, built next to it then swapped.
+// The original content stay readable while writing and is closed before the swap
+func rewriteSongFile(content *songContent, write func(writer io.Writer) error) error {
+	fileName := content.file.Name()
+
+	file, err := os.CreateTemp(filepath.Dir(fileName), "rewrite-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name())
+	defer file.Close()
+
+	err = file.Chmod(0660)
+	if err != nil {
+		return err
+	}
+
+	writer := bufio.NewWriter(file)
+	err = write(writer)
+	if err != nil {
+		return err
+	}
+	err = writer.Flush()
+	if err != nil {
+		return err
+	}
+	err = file.Close()
+	if err != nil {
+		return err
+	}
+
+	// An open file can't be replaced on windows
+	content.Close()
+	return os.Rename(file.Name(), fileName)
+}
@@ -1,25 +1,23 @@
 package store
 
 import (
-	"bytes"
 	"github.com/go-flac/flacvorbis"
 	"github.com/go-flac/go-flac"
 	"github.com/jmoiron/sqlx"
 	"github.com/jypelle/mifasol/internal/srv/entity"
 	"github.com/jypelle/mifasol/restApiV1"
 	"github.com/sirupsen/logrus"
-	"strconv"
-	"strings"
+	"io"
 )
 
 type MifasolMetaDataBlockVorbisComment struct {
 	flacvorbis.MetaDataBlockVorbisComment
 }
 
-func (s *Store) createSongNewFromFlacContent(externalTrn *sqlx.Tx, content []byte, lastAlbumId restApiV1.AlbumId) (*restApiV1.SongNew, error) {
+func (s *Store) createSongNewFromFlacContent(externalTrn *sqlx.Tx, content *songContent, lastAlbumId restApiV1.AlbumId) (*restApiV1.SongNew, error) {
 
 	// Extract song meta from tags
-	flacFile, err := flac.ParseMetadata(bytes.NewBuffer(content))
+	flacFile, _, err := readFlacMetadata(content)
 	if err != nil {
 		return nil, err
 	}
@@ -37,14 +35,7 @@ func (s *Store) createSongNewFromFlacContent(externalTrn *sqlx.Tx, content []byt
 		cmt = flacvorbis.New()
 	}
 
-	var songNew *restApiV1.SongNew
-
 	var bitDepth = restApiV1.SongBitDepthUnknown
-	var title = ""
-	var albumId restApiV1.AlbumId = restApiV1.UnknownAlbumId
-	var trackNumber *int64 = nil
-	var publicationYear *int64 = nil
-	var artistIds []restApiV1.ArtistId
 
 	// Check available transaction
 	txn := externalTrn
@@ -70,105 +61,31 @@ func (s *Store) createSongNewFromFlacContent(externalTrn *sqlx.Tx, content []byt
 		bitDepth = restApiV1.SongBitDepthUnknown
 	}
 
-	// Extract title
-	titles, err := cmt.Get(flacvorbis.FIELD_TITLE)
-	if err != nil {
-		return nil, err
-	}
-	if len(titles) > 0 {
-		title = titles[0]
-	}
-	logrus.Debugf("Title: %s", title)
-
-	// Extract album name
-	albumName := ""
-	albumNames, err := cmt.Get(flacvorbis.FIELD_ALBUM)
-	if err != nil {
-		return nil, err
-	}
-	if len(albumNames) > 0 {
-		albumName = normalizeString(albumNames[0])
-	}
-
-	// Find Album Id
-	albumId, err = s.getAlbumIdFromAlbumName(txn, albumName, lastAlbumId)
-	if err != nil {
-		return nil, err
-	}
-
-	logrus.Debugf("Album: %s", albumName)
-
-	// Extract track number
-	if albumId != restApiV1.UnknownAlbumId {
-		trackNumbers, err := cmt.Get(flacvorbis.FIELD_TRACKNUMBER)
-		if err != nil {
-			return nil, err
-		}
-
-		if len(trackNumbers) > 0 {
-			parsedTrackNumber, _ := strconv.ParseInt(normalizeString(trackNumbers[0]), 10, 64)
-			if parsedTrackNumber > 0 {
-				trackNumber = &parsedTrackNumber
-			}
-		}
-
-	}
-
-	if trackNumber != nil {
-		logrus.Debugf("Track number: %d", *trackNumber)
+	songNew := &restApiV1.SongNew{
+		SongMeta: restApiV1.SongMeta{
+			Format:     restApiV1.SongFormatFlac,
+			Size:       content.size,
+			BitDepth:   bitDepth,
+			ExplicitFg: false,
+		},
 	}
 
-	// Extract year
-	yearNumbers, err := cmt.Get(flacvorbis.FIELD_DATE)
+	// Extract title, album, track number, year & artists
+	err = s.fillSongMetaFromVorbisComment(txn, cmt, lastAlbumId, &songNew.SongMeta)
 	if err != nil {
 		return nil, err
 	}
 
-	if len(yearNumbers) > 0 {
-		parsedYearNumber, _ := strconv.ParseInt(normalizeString(yearNumbers[0]), 10, 64)
-		if parsedYearNumber > 0 {
-			publicationYear = &parsedYearNumber
-		}
-	}
-
-	if publicationYear != nil {
-		logrus.Debugf("Publication year: %d", *publicationYear)
-	}
-
-	// Extract artists
-	vorbisArtistNames, err := cmt.Get(flacvorbis.FIELD_ARTIST)
-	if err != nil {
-		return nil, err
-	}
+	// Extract lyrics
+	songNew.Lyrics = extractVorbisLyrics(cmt)
 
-	// Extract artists
-	var artistNames []string
-	for _, vorbisArtistName := range vorbisArtistNames {
-		artistNames = append(artistNames, strings.FieldsFunc(vorbisArtistName, func(r rune) bool { return r == ',' || r == ';' || r == ';' })...)
-	}
+	// Extract rating
+	songNew.Rating = extractVorbisRating(cmt)
 
-	// Find Artist IDs
-	logrus.Debugf("Find artist ids")
-	artistIds, err = s.getArtistIdsFromArtistNames(txn, artistNames)
+	// Extract audio properties
+	err = extractSongAudioProperties(content, &songNew.SongMeta)
 	if err != nil {
-		return nil, err
-	}
-
-	logrus.Debugf("Artists: %v", artistNames)
-
-	songNew = &restApiV1.SongNew{
-		SongMeta: restApiV1.SongMeta{
-			Name:            title,
-			Format:          restApiV1.SongFormatFlac,
-			Size:            int64(len(content)),
-			BitDepth:        bitDepth,
-			PublicationYear: publicationYear,
-			AlbumId:         albumId,
-			TrackNumber:     trackNumber,
-			ExplicitFg:      false,
-			ArtistIds:       artistIds,
-		},
-		Content: content,
+		logrus.Warnf("Unable to extract audio properties: %v", err)
 	}
 
 	// Commit transaction
@@ -182,7 +99,13 @@ func (s *Store) createSongNewFromFlacContent(externalTrn *sqlx.Tx, content []byt
 func (s *Store) updateSongContentFlacTag(externalTrn *sqlx.Tx, songEntity *entity.SongEntity) error {
 
 	// region Extract tags
-	flacFile, err := flac.ParseFile(s.getSongFileName(songEntity.SongId, songEntity.Format))
+	content, err := openSongContent(s.getSongFileName(songEntity.SongId, songEntity.Format))
+	if err != nil {
+		return err
+	}
+	defer content.Close()
+
+	flacFile, audioOffset, err := readFlacMetadata(content)
 	if err != nil {
 		return err
 	}
@@ -208,10 +131,6 @@ func (s *Store) updateSongContentFlacTag(externalTrn *sqlx.Tx, songEntity *entit
 
 	// region Update tags with song meta
 
-	// Set title
-	vorbisClean(cmt, flacvorbis.FIELD_TITLE)
-	cmt.Add(flacvorbis.FIELD_TITLE, songEntity.Name)
-
 	// Check available transaction
 	txn := externalTrn
 	if txn == nil {
@@ -222,36 +141,10 @@ func (s *Store) updateSongContentFlacTag(externalTrn *sqlx.Tx, songEntity *entit
 		defer txn.Rollback()
 	}
 
-	// Set album & track number
-	vorbisClean(cmt, flacvorbis.FIELD_ALBUM)
-	vorbisClean(cmt, flacvorbis.FIELD_TRACKNUMBER)
-	if songEntity.AlbumId != restApiV1.UnknownAlbumId {
-		album, err := s.ReadAlbum(txn, songEntity.AlbumId)
-		if err != nil {
-			return err
-		}
-		cmt.Add(flacvorbis.FIELD_ALBUM, album.Name)
-
-		if songEntity.TrackNumber.Valid {
-			cmt.Add(flacvorbis.FIELD_TRACKNUMBER, strconv.FormatInt(songEntity.TrackNumber.Int64, 10))
-		}
-	}
-
-	// Set publication date
-	vorbisClean(cmt, flacvorbis.FIELD_DATE)
-	if songEntity.PublicationYear.Valid {
-		cmt.Add(flacvorbis.FIELD_DATE, strconv.FormatInt(songEntity.PublicationYear.Int64, 10))
-	}
-
-	// Set artists
-	vorbisClean(cmt, flacvorbis.FIELD_ARTIST)
-	artists, err := s.ReadArtists(txn, &restApiV1.ArtistFilter{SongId: &songEntity.SongId})
+	album, err := s.fillVorbisCommentFromSong(txn, cmt, songEntity)
 	if err != nil {
 		return err
 	}
-	for _, artist := range artists {
-		cmt.Add(flacvorbis.FIELD_ARTIST, artist.Name)
-	}
 
 	// endregion
 
@@ -263,7 +156,44 @@ func (s *Store) updateSongContentFlacTag(externalTrn *sqlx.Tx, songEntity *entit
 	} else {
 		flacFile.Meta = append(flacFile.Meta, &metaDataBlock)
 	}
-	flacFile.Save(s.getSongFileName(songEntity.SongId, songEntity.Format))
+
+	// Set album cover
+	if album != nil {
+		cover, err := s.readAlbumCover(album)
+		if err != nil {
+			return err
+		}
+
+		var metaDataBlocks []*flac.MetaDataBlock
+		for _, meta := range flacFile.Meta {
+			if meta.Type != flac.Picture {
+				metaDataBlocks = append(metaDataBlocks, meta)
+			}
+		}
+		if cover != nil {
+			metaDataBlocks = append(metaDataBlocks, &flac.MetaDataBlock{Type: flac.Picture, Data: cover.marshalPictureBlock()})
+		}
+		flacFile.Meta = metaDataBlocks
+	}
+
+	// Audio frames are copied from the original file
+	err = rewriteSongFile(content, func(writer io.Writer) error {
+		_, err := writer.Write([]byte("fLaC"))
+		if err != nil {
+			return err
+		}
+		for ind, meta := range flacFile.Meta {
+			_, err = writer.Write(meta.Marshal(ind == len(flacFile.Meta)-1))
+			if err != nil {
+				return err
+			}
+		}
+		_, err = io.Copy(writer, content.section(audioOffset, content.size-audioOffset))
+		return err
+	})
+	if err != nil {
+		return err
+	}
 
 	// Commit transaction
 	if externalTrn == nil {
@@ -274,19 +204,70 @@ func (s *Store) updateSongContentFlacTag(externalTrn *sqlx.Tx, songEntity *entit
 	return nil
 }
 
-// vorbisClean remove all comments with field name specified by the key parameter
-func vorbisClean(c *flacvorbis.MetaDataBlockVorbisComment, key string) error {
-	res := make([]string, 0)
-	for _, cmt := range c.Comments {
-		p := strings.SplitN(cmt, "=", 2)
-		if len(p) != 2 {
-			return flacvorbis.ErrorMalformedComment
-		}
-		if !strings.EqualFold(p[0], key) {
-			res = append(res, cmt)
-		}
+// readFlacMetadata read the metadata blocks of flac content and return the offset of the audio frames following them
+func readFlacMetadata(content *songContent) (*flac.File, int64, error) {
+	reader := content.reader()
+	flacFile, err := flac.ParseMetadata(reader)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	audioOffset, err := reader.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return flacFile, audioOffset, nil
+}
+
+// extractFlacAudioProperties fill duration, sample rate, channels and bitrate of the song meta from flac content
+func extractFlacAudioProperties(content *songContent, songMeta *restApiV1.SongMeta) error {
+	flacFile, audioOffset, err := readFlacMetadata(content)
+	if err != nil {
+		return err
+	}
+
+	streamInfoBlock, err := flacFile.GetStreamInfo()
+	if err != nil {
+		return err
+	}
+
+	songMeta.SampleRate = int64(streamInfoBlock.SampleRate)
+	songMeta.Channels = int64(streamInfoBlock.ChannelCount)
+	songMeta.Duration = 0
+	songMeta.BitRate = 0
+	if streamInfoBlock.SampleRate > 0 && streamInfoBlock.SampleCount > 0 {
+		songMeta.Duration = streamInfoBlock.SampleCount * 1000 / int64(streamInfoBlock.SampleRate)
+	}
+	if songMeta.Duration > 0 {
+		// Remaining bytes are audio frames
+		songMeta.BitRate = (content.size - audioOffset) * 8 * 1000 / songMeta.Duration
 	}
-	c.Comments = res
 
 	return nil
 }
+
+// extractFlacCover return the picture embedded in flac content, front cover first
+func extractFlacCover(content *songContent) []byte {
+	flacFile, _, err := readFlacMetadata(content)
+	if err != nil {
+		return nil
+	}
+
+	var picture []byte
+	for _, meta := range flacFile.Meta {
+		if meta.Type == flac.Picture {
+			pictureType, data, ok := parsePictureBlock(meta.Data)
+			if ok {
+				if pictureType == pictureBlockFrontCover {
+					return data
+				}
+				if picture == nil {
+					picture = data
+				}
+			}
+		}
+	}
+
+	return picture
+}
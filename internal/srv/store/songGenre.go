@@ -0,0 +1,97 @@
+package store
+
+import (
+	"github.com/jmoiron/sqlx"
+	"github.com/jypelle/mifasol/internal/srv/entity"
+	"github.com/jypelle/mifasol/restApiV1"
+	"strconv"
+	"strings"
+)
+
+// id3v1Genres is the list of standard ID3v1 genres, still referenced by index in TCON frames and m4a gnre items
+var id3v1Genres = []string{
+	"Blues", "Classic Rock", "Country", "Dance", "Disco", "Funk", "Grunge", "Hip-Hop",
+	"Jazz", "Metal", "New Age", "Oldies", "Other", "Pop", "R&B", "Rap",
+	"Reggae", "Rock", "Techno", "Industrial", "Alternative", "Ska", "Death Metal", "Pranks",
+	"Soundtrack", "Euro-Techno", "Ambient", "Trip-Hop", "Vocal", "Jazz+Funk", "Fusion", "Trance",
+	"Classical", "Instrumental", "Acid", "House", "Game", "Sound Clip", "Gospel", "Noise",
+	"AlternRock", "Bass", "Soul", "Punk", "Space", "Meditative", "Instrumental Pop", "Instrumental Rock",
+	"Ethnic", "Gothic", "Darkwave", "Techno-Industrial", "Electronic", "Pop-Folk", "Eurodance", "Dream",
+	"Southern Rock", "Comedy", "Cult", "Gangsta", "Top 40", "Christian Rap", "Pop/Funk", "Jungle",
+	"Native American", "Cabaret", "New Wave", "Psychadelic", "Rave", "Showtunes", "Trailer", "Lo-Fi",
+	"Tribal", "Acid Punk", "Acid Jazz", "Polka", "Retro", "Musical", "Rock & Roll", "Hard Rock",
+}
+
+// id3v1GenreName return the name of a standard ID3v1 genre index
+func id3v1GenreName(index int) string {
+	if index >= 0 && index < len(id3v1Genres) {
+		return id3v1Genres[index]
+	}
+	return ""
+}
+
+// splitGenreNames split a raw genre tag value holding several genres
+func splitGenreNames(rawGenreNames string) []string {
+	return strings.FieldsFunc(rawGenreNames, func(r rune) bool { return r == ',' || r == ';' || r == 0 })
+}
+
+// parseId3GenreNames extract genre names from a TCON frame, resolving "(nn)" and "nn" references to ID3v1 genres
+func parseId3GenreNames(rawGenreNames string) []string {
+	var genreNames []string
+
+	for _, rawGenreName := range splitGenreNames(rawGenreNames) {
+		rawGenreName = strings.TrimSpace(rawGenreName)
+
+		// ID3v2.3 references: "(17)", "(17)(18)" or "(4)Eurodisco" where the text refines the references
+		var referencedGenreNames []string
+		for strings.HasPrefix(rawGenreName, "(") && !strings.HasPrefix(rawGenreName, "((") {
+			end := strings.Index(rawGenreName, ")")
+			if end < 0 {
+				break
+			}
+			reference := rawGenreName[1:end]
+			switch reference {
+			case "RX":
+				referencedGenreNames = append(referencedGenreNames, "Remix")
+			case "CR":
+				referencedGenreNames = append(referencedGenreNames, "Cover")
+			default:
+				index, err := strconv.Atoi(reference)
+				if err == nil && id3v1GenreName(index) != "" {
+					referencedGenreNames = append(referencedGenreNames, id3v1GenreName(index))
+				}
+			}
+			rawGenreName = rawGenreName[end+1:]
+		}
+		rawGenreName = strings.Replace(rawGenreName, "((", "(", 1)
+
+		// ID3v2.4 references: "17"
+		if index, err := strconv.Atoi(rawGenreName); err == nil {
+			rawGenreName = id3v1GenreName(index)
+		}
+
+		if strings.TrimSpace(rawGenreName) != "" {
+			genreNames = append(genreNames, rawGenreName)
+		} else {
+			genreNames = append(genreNames, referencedGenreNames...)
+		}
+	}
+
+	return genreNames
+}
+
+// readSongGenreNames return the names of the genres linked to a song, sorted by name
+func (s *Store) readSongGenreNames(txn *sqlx.Tx, songEntity *entity.SongEntity) ([]string, error) {
+	orderBy := restApiV1.GenreFilterOrderByName
+	genres, err := s.ReadGenres(txn, &restApiV1.GenreFilter{SongId: &songEntity.SongId, OrderBy: &orderBy})
+	if err != nil {
+		return nil, err
+	}
+
+	var genreNames []string
+	for _, genre := range genres {
+		genreNames = append(genreNames, genre.Name)
+	}
+
+	return genreNames, nil
+}
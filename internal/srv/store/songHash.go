@@ -0,0 +1,161 @@
+package store
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"github.com/jypelle/mifasol/internal/srv/entity"
+	"github.com/jypelle/mifasol/restApiV1"
+	"github.com/sirupsen/logrus"
+	"io"
+)
+
+// unavailableContentHash flag songs whose content hash can't be computed, never matching an hexadecimal hash
+const unavailableContentHash = "-"
+
+// songContentHash compute the hash of the audio payload, tags excluded so that retagged copies still match
+func songContentHash(format restApiV1.SongFormat, content *songContent) (string, error) {
+	hash := sha256.New()
+
+	switch format {
+	case restApiV1.SongFormatFlac:
+		// Audio frames follow metadata blocks
+		_, audioOffset, err := readFlacMetadata(content)
+		if err != nil {
+			return "", err
+		}
+		_, err = io.Copy(hash, content.section(audioOffset, content.size-audioOffset))
+		if err != nil {
+			return "", err
+		}
+	case restApiV1.SongFormatMp3:
+		payloadStart, payloadEnd, err := mp3AudioPayloadBounds(content)
+		if err != nil {
+			return "", err
+		}
+		_, err = io.Copy(hash, content.section(payloadStart, payloadEnd-payloadStart))
+		if err != nil {
+			return "", err
+		}
+	case restApiV1.SongFormatOgg, restApiV1.SongFormatOpus:
+		// Page headers are skipped as sequence numbers change with the size of the comment header
+		headerPacketCount := 3
+		if format == restApiV1.SongFormatOpus {
+			headerPacketCount = 2
+		}
+		reader := content.bufferedReader()
+		_, _, _, err := readOggHeaderPages(reader, headerPacketCount)
+		if err != nil {
+			return "", err
+		}
+		for {
+			page, err := readOggPage(reader)
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return "", err
+			}
+			hash.Write(page.data)
+		}
+	case restApiV1.SongFormatM4a:
+		boxes, err := readMp4Boxes(content)
+		if err != nil {
+			return "", err
+		}
+		for _, box := range boxes {
+			if box.boxType == "mdat" {
+				_, err = io.Copy(hash, box.payloadReader())
+				if err != nil {
+					return "", err
+				}
+			}
+		}
+	default:
+		_, err := io.Copy(hash, content.reader())
+		if err != nil {
+			return "", err
+		}
+	}
+
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// mp3AudioPayloadBounds return the position of mp3 audio frames, ID3v2 & ID3v1 tags excluded
+func mp3AudioPayloadBounds(content *songContent) (int64, int64, error) {
+	start := int64(0)
+	end := content.size
+
+	// Skip ID3v2 tag
+	header, err := content.readAt(0, 10)
+	if err != nil {
+		return 0, 0, err
+	}
+	if len(header) >= 10 && string(header[0:3]) == "ID3" {
+		start = 10 + (int64(header[6])<<21 | int64(header[7])<<14 | int64(header[8])<<7 | int64(header[9]))
+		if header[5]&0x10 != 0 {
+			// Footer present
+			start += 10
+		}
+		if start > end {
+			start = end
+		}
+	}
+
+	// Skip ID3v1 tag
+	if end-start >= 128 {
+		trailer, err := content.readAt(end-128, 3)
+		if err != nil {
+			return 0, 0, err
+		}
+		if string(trailer) == "TAG" {
+			end -= 128
+		}
+	}
+
+	return start, end, nil
+}
+
+// refreshSongsContentHash compute missing content hash of already stored songs
+func (s *Store) refreshSongsContentHash() error {
+	txn, err := s.db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer txn.Rollback()
+
+	songEntities := []entity.SongEntity{}
+	err = txn.Select(&songEntities, "SELECT * FROM song WHERE content_hash = ''")
+	if err != nil {
+		return err
+	}
+
+	if len(songEntities) == 0 {
+		return nil
+	}
+
+	logrus.Printf("Computing content hash of %d songs ...", len(songEntities))
+
+	for _, songEntity := range songEntities {
+		contentHash := unavailableContentHash
+
+		content, err := openSongContent(s.getSongFileName(songEntity.SongId, songEntity.Format))
+		if err != nil {
+			logrus.Warnf("Unable to read song %s content: %v", songEntity.SongId, err)
+		} else {
+			contentHash, err = songContentHash(songEntity.Format, content)
+			content.Close()
+			if err != nil {
+				logrus.Warnf("Unable to compute song %s content hash: %v", songEntity.SongId, err)
+				// Don't try again at next startup
+				contentHash = unavailableContentHash
+			}
+		}
+
+		_, err = txn.Exec("UPDATE song SET content_hash = ? WHERE song_id = ?", contentHash, songEntity.SongId)
+		if err != nil {
+			return err
+		}
+	}
+
+	return txn.Commit()
+}
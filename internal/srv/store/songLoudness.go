@@ -0,0 +1,297 @@
+package store
+
+import (
+	"database/sql"
+	"fmt"
+	"github.com/faiface/beep"
+	"github.com/faiface/beep/flac"
+	"github.com/faiface/beep/mp3"
+	"github.com/faiface/beep/vorbis"
+	"github.com/jmoiron/sqlx"
+	"github.com/jypelle/mifasol/internal/srv/entity"
+	"github.com/jypelle/mifasol/restApiV1"
+	"github.com/sirupsen/logrus"
+	"io"
+	"math"
+	"os"
+	"time"
+)
+
+const (
+	// replayGainReferenceLoudness is the ReplayGain 2.0 target level in LUFS
+	replayGainReferenceLoudness = -18.0
+
+	replayGainTrackGain = "REPLAYGAIN_TRACK_GAIN"
+	replayGainTrackPeak = "REPLAYGAIN_TRACK_PEAK"
+	replayGainAlbumGain = "REPLAYGAIN_ALBUM_GAIN"
+	replayGainAlbumPeak = "REPLAYGAIN_ALBUM_PEAK"
+)
+
+// formatReplayGain return the ReplayGain tag value of a loudness
+func formatReplayGain(loudness float64) string {
+	return fmt.Sprintf("%.2f dB", replayGainReferenceLoudness-loudness)
+}
+
+// formatReplayGainPeak return the ReplayGain tag value of a linear peak
+func formatReplayGainPeak(truePeak float64) string {
+	return fmt.Sprintf("%.6f", truePeak)
+}
+
+// decodeSongContent return the decoded audio stream of the song content, ok is false when no decoder is available for its format
+func decodeSongContent(format restApiV1.SongFormat, reader io.ReadCloser) (streamer beep.StreamSeekCloser, streamFormat beep.Format, ok bool, err error) {
+	switch format {
+	case restApiV1.SongFormatFlac:
+		streamer, streamFormat, err = flac.Decode(reader)
+	case restApiV1.SongFormatMp3:
+		streamer, streamFormat, err = mp3.Decode(reader)
+	case restApiV1.SongFormatOgg:
+		streamer, streamFormat, err = vorbis.Decode(reader)
+	default:
+		return nil, streamFormat, false, nil
+	}
+	if err != nil {
+		return nil, streamFormat, false, err
+	}
+	return streamer, streamFormat, true, nil
+}
+
+// analyzeSongLoudness decode the song content to compute its integrated loudness and true peak, ok is false when it can't be measured
+func analyzeSongLoudness(format restApiV1.SongFormat, reader io.ReadCloser, channels int64) (loudness float64, truePeak float64, ok bool, err error) {
+	streamer, streamFormat, ok, err := decodeSongContent(format, reader)
+	if !ok {
+		// No decoder available
+		return 0, 0, false, err
+	}
+	defer streamer.Close()
+
+	// Decoders always output stereo samples, mono content is duplicated
+	meterChannels := streamFormat.NumChannels
+	if channels == 1 || meterChannels < 1 {
+		meterChannels = 1
+	} else if meterChannels > 2 {
+		meterChannels = 2
+	}
+
+	meter := newLoudnessMeter(int(streamFormat.SampleRate), meterChannels)
+	samples := make([][2]float64, 4096)
+	for {
+		n, more := streamer.Stream(samples)
+		for _, sample := range samples[:n] {
+			meter.addFrame(sample[:])
+		}
+		if !more {
+			break
+		}
+	}
+	if streamer.Err() != nil {
+		return 0, 0, false, streamer.Err()
+	}
+
+	loudness, ok = meter.integratedLoudness()
+	return loudness, meter.truePeak, ok, nil
+}
+
+// requestSongAnalysis wake up the song analysis job
+func (s *Store) requestSongAnalysis() {
+	select {
+	case s.songAnalysisSignal <- struct{}{}:
+	default:
+	}
+}
+
+// runSongAnalysisJob analyse songs loudness & waveform each time it's requested, one song decoding at a time
+func (s *Store) runSongAnalysisJob() {
+	for range s.songAnalysisSignal {
+		err := s.analyzeSongsLoudness()
+		if err != nil {
+			logrus.Warnf("Unable to analyse songs loudness: %v", err)
+		}
+		err = s.analyzeSongsWaveform()
+		if err != nil {
+			logrus.Warnf("Unable to compute songs waveform: %v", err)
+		}
+	}
+}
+
+// analyzeSongsLoudness compute loudness & true peak of not yet analysed songs and refresh their albums.
+// Song tags are rewritten once per album, when none of its songs is waiting for analysis anymore
+func (s *Store) analyzeSongsLoudness() error {
+	songEntities := []entity.SongEntity{}
+	err := s.db.Select(&songEntities, "SELECT * FROM song WHERE loudness_analysis_fg = false")
+	if err != nil {
+		return err
+	}
+
+	if len(songEntities) == 0 {
+		return nil
+	}
+
+	logrus.Printf("Analysing loudness of %d songs ...", len(songEntities))
+
+	albumIds := make(map[restApiV1.AlbumId]bool)
+	for _, songEntity := range songEntities {
+		// Decoding is done outside of any transaction
+		var loudness, truePeak sql.NullFloat64
+		file, err := os.Open(s.getSongFileName(songEntity.SongId, songEntity.Format))
+		if err == nil {
+			loudness.Float64, truePeak.Float64, loudness.Valid, err = analyzeSongLoudness(songEntity.Format, file, songEntity.Channels)
+			truePeak.Valid = loudness.Valid
+			file.Close()
+		}
+		if err != nil {
+			logrus.Warnf("Unable to analyse song %s loudness: %v", songEntity.SongId, err)
+		}
+
+		err = s.storeSongLoudness(songEntity.SongId, loudness, truePeak, songEntity.AlbumId == restApiV1.UnknownAlbumId)
+		if err != nil {
+			return err
+		}
+
+		if loudness.Valid && songEntity.AlbumId != restApiV1.UnknownAlbumId {
+			albumIds[songEntity.AlbumId] = true
+		}
+	}
+
+	for albumId := range albumIds {
+		err = s.storeAlbumLoudness(albumId)
+		if err != nil {
+			return err
+		}
+	}
+
+	logrus.Printf("Loudness of %d songs analysed", len(songEntities))
+
+	return nil
+}
+
+// storeSongLoudness save the analysis result, rewriting the song tags when asked.
+// The song update timestamp is kept: analysis results don't require clients to sync the song again
+func (s *Store) storeSongLoudness(songId restApiV1.SongId, loudness sql.NullFloat64, truePeak sql.NullFloat64, refreshTag bool) error {
+	txn, err := s.db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer txn.Rollback()
+
+	result, err := txn.Exec("UPDATE song SET loudness = ?, true_peak = ?, loudness_analysis_fg = true WHERE song_id = ?", loudness, truePeak, songId)
+	if err != nil {
+		return err
+	}
+
+	// Song may have been deleted in the meantime
+	count, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if count > 0 && loudness.Valid && refreshTag {
+		var songEntity entity.SongEntity
+		err = txn.Get(&songEntity, "SELECT * FROM song WHERE song_id = ?", songId)
+		if err != nil {
+			return err
+		}
+		err = s.UpdateSongContentTag(txn, &songEntity)
+		if err != nil {
+			return err
+		}
+	}
+
+	return txn.Commit()
+}
+
+// storeAlbumLoudness aggregate the album songs analysis results and rewrite their tags,
+// unless some songs of the album are still waiting for analysis
+func (s *Store) storeAlbumLoudness(albumId restApiV1.AlbumId) error {
+	txn, err := s.db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer txn.Rollback()
+
+	var pendingCount int64
+	err = txn.Get(&pendingCount, "SELECT count(*) FROM song WHERE album_id = ? AND loudness_analysis_fg = false", albumId)
+	if err != nil {
+		return err
+	}
+	if pendingCount > 0 {
+		// Album will be refreshed with its last analysed song
+		return nil
+	}
+
+	found, _, err := s.updateAlbumLoudness(txn, albumId)
+	if err != nil {
+		return err
+	}
+
+	if found {
+		err = s.refreshAlbumSongsContentTag(txn, albumId)
+		if err != nil {
+			return err
+		}
+	}
+
+	return txn.Commit()
+}
+
+// refreshAlbumLoudness aggregate loudness & true peak of the album songs, rewriting their tags when it changes
+func (s *Store) refreshAlbumLoudness(txn *sqlx.Tx, albumId restApiV1.AlbumId) error {
+	_, changed, err := s.updateAlbumLoudness(txn, albumId)
+	if err != nil {
+		return err
+	}
+
+	if !changed {
+		return nil
+	}
+
+	return s.refreshAlbumSongsContentTag(txn, albumId)
+}
+
+// updateAlbumLoudness aggregate loudness & true peak of the album songs, changed is false when they stay the same
+func (s *Store) updateAlbumLoudness(txn *sqlx.Tx, albumId restApiV1.AlbumId) (found bool, changed bool, err error) {
+	if albumId == restApiV1.UnknownAlbumId {
+		return false, false, nil
+	}
+
+	var albumEntity entity.AlbumEntity
+	err = txn.Get(&albumEntity, `SELECT * FROM album WHERE album_id = ?`, albumId)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return false, false, nil
+		}
+		return false, false, err
+	}
+
+	songEntities := []entity.SongEntity{}
+	err = txn.Select(&songEntities, "SELECT * FROM song WHERE album_id = ? AND loudness IS NOT NULL", albumId)
+	if err != nil {
+		return false, false, err
+	}
+
+	// Album loudness is the duration weighted energy mean of its songs
+	var loudness, truePeak sql.NullFloat64
+	energy := 0.0
+	duration := 0.0
+	for _, songEntity := range songEntities {
+		songDuration := math.Max(float64(songEntity.Duration), 1)
+		energy += songDuration * loudnessToEnergy(songEntity.Loudness.Float64)
+		duration += songDuration
+		truePeak.Float64 = math.Max(truePeak.Float64, songEntity.TruePeak.Float64)
+	}
+	if len(songEntities) > 0 {
+		loudness.Float64 = energyToLoudness(energy / duration)
+		loudness.Valid = true
+		truePeak.Valid = true
+	}
+
+	if loudness == albumEntity.Loudness && truePeak == albumEntity.TruePeak {
+		return true, false, nil
+	}
+
+	_, err = txn.Exec("UPDATE album SET loudness = ?, true_peak = ?, update_ts = ? WHERE album_id = ?", loudness, truePeak, time.Now().UnixNano(), albumId)
+	if err != nil {
+		return false, false, err
+	}
+
+	return true, true, nil
+}
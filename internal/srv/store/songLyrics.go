@@ -0,0 +1,396 @@
+package store
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/binary"
+	"fmt"
+	"github.com/jmoiron/sqlx"
+	"github.com/jypelle/mifasol/internal/srv/entity"
+	"github.com/jypelle/mifasol/internal/srv/storeerror"
+	"github.com/jypelle/mifasol/restApiV1"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// lrcTimeTagRegexp match a LRC line time tag: [mm:ss], [mm:ss.xx] or [mm:ss.xxx]
+var lrcTimeTagRegexp = regexp.MustCompile(`^\[(\d+):(\d{1,2})(?:[.:](\d{1,3}))?\]`)
+
+// lrcOffsetRegexp match the LRC offset tag, in milliseconds
+var lrcOffsetRegexp = regexp.MustCompile(`(?i)^\[offset:\s*([+-]?\d+)\s*\]$`)
+
+// lrcWordTimeTagRegexp match the word time tags of enhanced LRC: <mm:ss.xx>
+var lrcWordTimeTagRegexp = regexp.MustCompile(`<\d+:\d{1,2}(?:[.:]\d{1,3})?>`)
+
+// normalizeLyrics check lyrics are utf-8 text and normalize line endings
+func normalizeLyrics(content []byte) (string, error) {
+	content = bytes.TrimPrefix(content, []byte("\xef\xbb\xbf"))
+	if !utf8.Valid(content) || bytes.IndexByte(content, 0) >= 0 {
+		return "", storeerror.ErrInvalidSongLyrics
+	}
+
+	lyrics := strings.ReplaceAll(string(content), "\r\n", "\n")
+	lyrics = strings.ReplaceAll(lyrics, "\r", "\n")
+
+	return strings.TrimSpace(lyrics), nil
+}
+
+// cleanTagLyrics return the normalized lyrics read from tags, invalid lyrics are ignored
+func cleanTagLyrics(rawLyrics string) string {
+	lyrics, err := normalizeLyrics([]byte(rawLyrics))
+	if err != nil {
+		return ""
+	}
+	return lyrics
+}
+
+// parseLrc return the time-synced lines of LRC lyrics, ordered by time, or nil for plain lyrics
+func parseLrc(lyrics string) []restApiV1.SongLyricsLine {
+	var lines []restApiV1.SongLyricsLine
+	var offset int64
+
+	for _, rawLine := range strings.Split(lyrics, "\n") {
+		rawLine = strings.TrimSpace(rawLine)
+
+		if match := lrcOffsetRegexp.FindStringSubmatch(rawLine); match != nil {
+			offset, _ = strconv.ParseInt(match[1], 10, 64)
+			continue
+		}
+
+		// A line may start with several time tags when it is repeated
+		var times []int64
+		for {
+			match := lrcTimeTagRegexp.FindStringSubmatch(rawLine)
+			if match == nil {
+				break
+			}
+			minutes, _ := strconv.ParseInt(match[1], 10, 64)
+			seconds, _ := strconv.ParseInt(match[2], 10, 64)
+			var milliseconds int64
+			if match[3] != "" {
+				milliseconds, _ = strconv.ParseInt(match[3], 10, 64)
+				for i := len(match[3]); i < 3; i++ {
+					milliseconds *= 10
+				}
+			}
+			times = append(times, (minutes*60+seconds)*1000+milliseconds)
+			rawLine = rawLine[len(match[0]):]
+		}
+
+		text := strings.TrimSpace(lrcWordTimeTagRegexp.ReplaceAllString(rawLine, ""))
+		for _, t := range times {
+			lines = append(lines, restApiV1.SongLyricsLine{Time: t, Text: text})
+		}
+	}
+
+	// A positive offset shift lyrics up
+	for ind := range lines {
+		lines[ind].Time -= offset
+		if lines[ind].Time < 0 {
+			lines[ind].Time = 0
+		}
+	}
+
+	sort.SliceStable(lines, func(i, j int) bool {
+		return lines[i].Time < lines[j].Time
+	})
+
+	return lines
+}
+
+// formatLrc return the LRC lyrics of time-synced lines
+func formatLrc(lines []restApiV1.SongLyricsLine) string {
+	var lrc strings.Builder
+	for ind, line := range lines {
+		if ind > 0 {
+			lrc.WriteString("\n")
+		}
+		lrc.WriteString(fmt.Sprintf("[%02d:%02d.%02d]%s", line.Time/60000, line.Time/1000%60, line.Time%1000/10, line.Text))
+	}
+	return lrc.String()
+}
+
+// unsyncLyrics return plain lyrics, without LRC tags
+func unsyncLyrics(lyrics string) string {
+	lines := parseLrc(lyrics)
+	if len(lines) == 0 {
+		return lyrics
+	}
+
+	var texts []string
+	for _, line := range lines {
+		texts = append(texts, line.Text)
+	}
+	return strings.Join(texts, "\n")
+}
+
+func (s *Store) ReadSongLyrics(externalTrn *sqlx.Tx, songId restApiV1.SongId) (*restApiV1.SongLyrics, error) {
+	var err error
+
+	// Check available transaction
+	txn := externalTrn
+	if txn == nil {
+		txn, err = s.db.Beginx()
+		if err != nil {
+			return nil, err
+		}
+		defer txn.Rollback()
+	}
+
+	var songEntity entity.SongEntity
+	err = txn.Get(&songEntity, "SELECT * FROM song WHERE song_id = ?", songId)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, storeerror.ErrNotFound
+		}
+		return nil, err
+	}
+
+	if !songEntity.LyricsFg {
+		return nil, storeerror.ErrNotFound
+	}
+
+	lines := parseLrc(songEntity.Lyrics)
+
+	return &restApiV1.SongLyrics{
+		SongId:   songId,
+		Text:     songEntity.Lyrics,
+		SyncedFg: len(lines) > 0,
+		Lines:    lines,
+	}, nil
+}
+
+func (s *Store) UpdateSongLyrics(externalTrn *sqlx.Tx, songId restApiV1.SongId, content []byte) (*restApiV1.Song, error) {
+	var err error
+
+	// Check available transaction
+	txn := externalTrn
+	if txn == nil {
+		txn, err = s.db.Beginx()
+		if err != nil {
+			return nil, err
+		}
+		defer txn.Rollback()
+	}
+
+	lyrics, err := normalizeLyrics(content)
+	if err != nil {
+		return nil, err
+	}
+	if lyrics == "" {
+		return nil, storeerror.ErrInvalidSongLyrics
+	}
+
+	err = s.storeSongLyrics(txn, songId, lyrics)
+	if err != nil {
+		return nil, err
+	}
+
+	song, err := s.ReadSong(txn, songId)
+	if err != nil {
+		return nil, err
+	}
+
+	// Commit transaction
+	if externalTrn == nil {
+		txn.Commit()
+	}
+
+	return song, nil
+}
+
+func (s *Store) DeleteSongLyrics(externalTrn *sqlx.Tx, songId restApiV1.SongId) (*restApiV1.Song, error) {
+	var err error
+
+	// Check available transaction
+	txn := externalTrn
+	if txn == nil {
+		txn, err = s.db.Beginx()
+		if err != nil {
+			return nil, err
+		}
+		defer txn.Rollback()
+	}
+
+	err = s.storeSongLyrics(txn, songId, "")
+	if err != nil {
+		return nil, err
+	}
+
+	song, err := s.ReadSong(txn, songId)
+	if err != nil {
+		return nil, err
+	}
+
+	// Commit transaction
+	if externalTrn == nil {
+		txn.Commit()
+	}
+
+	return song, nil
+}
+
+// storeSongLyrics save the song lyrics (empty lyrics remove them) and rewrite song tags
+func (s *Store) storeSongLyrics(txn *sqlx.Tx, songId restApiV1.SongId, lyrics string) error {
+	var songEntity entity.SongEntity
+	err := txn.Get(&songEntity, "SELECT * FROM song WHERE song_id = ?", songId)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return storeerror.ErrNotFound
+		}
+		return err
+	}
+
+	if songEntity.Lyrics == lyrics && songEntity.LyricsFg == (lyrics != "") {
+		return nil
+	}
+
+	songEntity.Lyrics = lyrics
+	songEntity.LyricsFg = lyrics != ""
+	songEntity.UpdateTs = time.Now().UnixNano()
+
+	_, err = txn.NamedExec(`
+		UPDATE song
+		SET lyrics = :lyrics,
+			lyrics_fg = :lyrics_fg,
+			update_ts = :update_ts
+		WHERE song_id = :song_id
+	`, &songEntity)
+	if err != nil {
+		return err
+	}
+
+	// Update tags in song content
+	return s.UpdateSongContentTag(txn, &songEntity)
+}
+
+// id3v2 SYLT frame constants
+const (
+	syltTimestampFormatMs = 2
+	syltContentTypeLyrics = 1
+)
+
+// parseSyltFrame decode the body of an id3v2 SYLT frame, only millisecond timestamps are supported
+func parseSyltFrame(body []byte) []restApiV1.SongLyricsLine {
+	if len(body) < 6 || body[4] != syltTimestampFormatMs {
+		return nil
+	}
+	encoding := body[0]
+
+	// Skip content descriptor
+	_, pos, ok := readId3String(body, 6, encoding)
+	if !ok {
+		return nil
+	}
+
+	var lines []restApiV1.SongLyricsLine
+	for pos < len(body) {
+		text, next, ok := readId3String(body, pos, encoding)
+		if !ok || next+4 > len(body) {
+			break
+		}
+		lines = append(lines, restApiV1.SongLyricsLine{
+			Time: int64(binary.BigEndian.Uint32(body[next:])),
+			Text: strings.TrimSpace(text),
+		})
+		pos = next + 4
+	}
+
+	sort.SliceStable(lines, func(i, j int) bool {
+		return lines[i].Time < lines[j].Time
+	})
+
+	return lines
+}
+
+// marshalSyltFrame encode time-synced lines as the body of an id3v2 SYLT frame, in utf-8 (id3v2.4) or utf-16 (id3v2.3)
+func marshalSyltFrame(lines []restApiV1.SongLyricsLine, utf8Encoding bool) []byte {
+	var encoding byte = 1
+	if utf8Encoding {
+		encoding = 3
+	}
+
+	buffer := new(bytes.Buffer)
+	buffer.WriteByte(encoding)
+	buffer.WriteString("XXX")
+	buffer.WriteByte(syltTimestampFormatMs)
+	buffer.WriteByte(syltContentTypeLyrics)
+	writeId3String(buffer, "", encoding)
+	for _, line := range lines {
+		writeId3String(buffer, line.Text, encoding)
+		binary.Write(buffer, binary.BigEndian, uint32(line.Time))
+	}
+
+	return buffer.Bytes()
+}
+
+// readId3String decode a null terminated id3v2 string starting at pos and return the position following the terminator
+func readId3String(data []byte, pos int, encoding byte) (string, int, bool) {
+	switch encoding {
+	case 1, 2:
+		// utf-16 with BOM or utf-16BE, terminated by two null bytes
+		for end := pos; end+1 < len(data); end += 2 {
+			if data[end] == 0 && data[end+1] == 0 {
+				return decodeUtf16(data[pos:end], encoding == 2), end + 2, true
+			}
+		}
+	default:
+		// iso-8859-1 or utf-8, terminated by a null byte
+		end := bytes.IndexByte(data[pos:], 0)
+		if end >= 0 {
+			raw := data[pos : pos+end]
+			if encoding == 0 {
+				runes := make([]rune, len(raw))
+				for ind, b := range raw {
+					runes[ind] = rune(b)
+				}
+				return string(runes), pos + end + 1, true
+			}
+			return string(raw), pos + end + 1, true
+		}
+	}
+	return "", len(data), false
+}
+
+// writeId3String encode a null terminated id3v2 string in utf-8 or utf-16 with BOM
+func writeId3String(buffer *bytes.Buffer, str string, encoding byte) {
+	if encoding == 3 {
+		buffer.WriteString(str)
+		buffer.WriteByte(0)
+		return
+	}
+
+	buffer.Write([]byte{0xFF, 0xFE})
+	for _, u := range utf16.Encode([]rune(str)) {
+		binary.Write(buffer, binary.LittleEndian, u)
+	}
+	buffer.Write([]byte{0, 0})
+}
+
+// decodeUtf16 decode utf-16 content, using its BOM when present
+func decodeUtf16(raw []byte, bigEndian bool) string {
+	if len(raw) >= 2 {
+		if raw[0] == 0xFF && raw[1] == 0xFE {
+			bigEndian = false
+			raw = raw[2:]
+		} else if raw[0] == 0xFE && raw[1] == 0xFF {
+			bigEndian = true
+			raw = raw[2:]
+		}
+	}
+
+	units := make([]uint16, len(raw)/2)
+	for ind := range units {
+		if bigEndian {
+			units[ind] = binary.BigEndian.Uint16(raw[ind*2:])
+		} else {
+			units[ind] = binary.LittleEndian.Uint16(raw[ind*2:])
+		}
+	}
+	return string(utf16.Decode(units))
+}
@@ -0,0 +1,598 @@
+package store
+
+import (
+	"encoding/binary"
+	"errors"
+	"github.com/jmoiron/sqlx"
+	"github.com/jypelle/mifasol/internal/srv/entity"
+	"github.com/jypelle/mifasol/restApiV1"
+	"github.com/sirupsen/logrus"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// iTunes metadata items
+const (
+	m4aItemTitle       = "\xa9nam"
+	m4aItemAlbum       = "\xa9alb"
+	m4aItemArtist      = "\xa9ART"
+	m4aItemAlbumArtist = "aART"
+	m4aItemComposer    = "\xa9wrt"
+	m4aItemGenre       = "\xa9gen"
+	m4aItemGenreId     = "gnre"
+	m4aItemDiscNumber  = "disk"
+	m4aItemTrackNumber = "trkn"
+	m4aItemDate        = "\xa9day"
+	m4aItemCover       = "covr"
+	m4aItemRating      = "rtng"
+	m4aItemLyrics      = "\xa9lyr"
+)
+
+// Well-known types of iTunes data boxes
+const (
+	m4aDataTypeImplicit = 0
+	m4aDataTypeUtf8     = 1
+	m4aDataTypeJpeg     = 13
+	m4aDataTypePng      = 14
+	m4aDataTypeInteger  = 21
+)
+
+// iTunes advisory rating
+const (
+	m4aRatingExplicit    = 1
+	m4aRatingExplicitOld = 4
+)
+
+var errUnsupportedMp4Stream = errors.New("Unsupported mp4 stream")
+
+func (s *Store) createSongNewFromM4aContent(externalTrn *sqlx.Tx, content *songContent, lastAlbumId restApiV1.AlbumId) (*restApiV1.SongNew, error) {
+
+	// Extract song meta from tags
+	boxes, err := readMp4Boxes(content)
+	if err != nil {
+		return nil, err
+	}
+
+	ilst := mp4Path(boxes, "moov", "udta", "meta", "ilst")
+	if ilst == nil {
+		ilst = &mp4Box{boxType: "ilst"}
+	}
+
+	var songNew *restApiV1.SongNew
+
+	var title = ""
+	var publicationYear *int64 = nil
+	var albumId = restApiV1.UnknownAlbumId
+	var discNumber *int64 = nil
+	var trackNumber *int64 = nil
+	var artistIds []restApiV1.ArtistId
+	var songArtists []restApiV1.SongArtist
+	var genreIds []restApiV1.GenreId
+	var explicitFg = false
+
+	// Check available transaction
+	txn := externalTrn
+	if txn == nil {
+		txn, err = s.db.Beginx()
+		if err != nil {
+			return nil, err
+		}
+		defer txn.Rollback()
+	}
+
+	// Extract title
+	title = normalizeString(m4aTextItem(ilst, m4aItemTitle))
+	logrus.Debugf("Title: %s", title)
+
+	// Extract album name
+	albumName := normalizeString(m4aTextItem(ilst, m4aItemAlbum))
+
+	// Extract album artists
+	albumArtistNames := strings.FieldsFunc(m4aTextItem(ilst, m4aItemAlbumArtist), func(r rune) bool { return r == ',' || r == ';' })
+
+	// Extract artists, falling back to album artist
+	rawArtistNames := m4aTextItem(ilst, m4aItemArtist)
+	if rawArtistNames == "" {
+		rawArtistNames = m4aTextItem(ilst, m4aItemAlbumArtist)
+	}
+	artistNames, featuringArtistNames := splitFeaturingArtistNames(rawArtistNames)
+
+	// Find Album Id
+	albumId, err = s.getAlbumIdFromAlbumName(txn, albumName, albumArtistNames, artistNames, lastAlbumId)
+	if err != nil {
+		return nil, err
+	}
+
+	logrus.Debugf("Album: %s", albumName)
+	logrus.Debugf("Album artists: %v", albumArtistNames)
+
+	// Extract disc & track number
+	if albumId != restApiV1.UnknownAlbumId {
+		_, value, ok := m4aItemData(ilst, m4aItemDiscNumber)
+		if ok && len(value) >= 4 {
+			parsedDiscNumber := int64(binary.BigEndian.Uint16(value[2:]))
+			if parsedDiscNumber > 0 {
+				discNumber = &parsedDiscNumber
+			}
+		}
+
+		_, value, ok = m4aItemData(ilst, m4aItemTrackNumber)
+		if ok && len(value) >= 4 {
+			parsedTrackNumber := int64(binary.BigEndian.Uint16(value[2:]))
+			if parsedTrackNumber > 0 {
+				trackNumber = &parsedTrackNumber
+			}
+		}
+	}
+
+	if discNumber != nil {
+		logrus.Debugf("Disc number: %d", *discNumber)
+	}
+	if trackNumber != nil {
+		logrus.Debugf("Track number: %d", *trackNumber)
+	}
+
+	// Extract year from "2004" or "2004-05-01T12:00:00Z"
+	rawDate := normalizeString(m4aTextItem(ilst, m4aItemDate))
+	if len(rawDate) > 4 {
+		rawDate = rawDate[:4]
+	}
+	parsedYearNumber, _ := strconv.ParseInt(rawDate, 10, 64)
+	if parsedYearNumber > 0 {
+		publicationYear = &parsedYearNumber
+	}
+
+	if publicationYear != nil {
+		logrus.Debugf("Publication year: %d", *publicationYear)
+	}
+
+	// Find Artist IDs
+	artistIds, err = s.getArtistIdsFromArtistNames(txn, artistNames)
+	if err != nil {
+		return nil, err
+	}
+
+	logrus.Debugf("Artists: %v", artistNames)
+
+	// Extract other credited artists, iTunes items have no performer nor conductor
+	composerNames := splitArtistNames(m4aTextItem(ilst, m4aItemComposer))
+
+	songArtists, err = s.getCreditedSongArtistsFromArtistNames(txn, featuringArtistNames, composerNames, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	logrus.Debugf("Featuring: %v, composers: %v", featuringArtistNames, composerNames)
+
+	// Extract genres, falling back to the ID3v1 genre index (1-based)
+	genreNames := splitGenreNames(m4aTextItem(ilst, m4aItemGenre))
+	if len(genreNames) == 0 {
+		_, value, ok := m4aItemData(ilst, m4aItemGenreId)
+		if ok && len(value) >= 2 {
+			genreName := id3v1GenreName(int(binary.BigEndian.Uint16(value)) - 1)
+			if genreName != "" {
+				genreNames = append(genreNames, genreName)
+			}
+		}
+	}
+
+	// Find Genre IDs
+	genreIds, err = s.getGenreIdsFromGenreNames(txn, genreNames)
+	if err != nil {
+		return nil, err
+	}
+
+	logrus.Debugf("Genres: %v", genreNames)
+
+	// Extract explicit flag
+	_, value, ok := m4aItemData(ilst, m4aItemRating)
+	if ok && len(value) > 0 {
+		explicitFg = value[0] == m4aRatingExplicit || value[0] == m4aRatingExplicitOld
+	}
+
+	// Extract lyrics
+	lyrics := cleanTagLyrics(m4aTextItem(ilst, m4aItemLyrics))
+
+	songNew = &restApiV1.SongNew{
+		SongMeta: restApiV1.SongMeta{
+			Name:            title,
+			Format:          restApiV1.SongFormatM4a,
+			Size:            content.size,
+			BitDepth:        restApiV1.SongBitDepthUnknown,
+			PublicationYear: publicationYear,
+			AlbumId:         albumId,
+			DiscNumber:      discNumber,
+			TrackNumber:     trackNumber,
+			ExplicitFg:      explicitFg,
+			ArtistIds:       artistIds,
+			Artists:         songArtists,
+			GenreIds:        genreIds,
+		},
+		Lyrics: lyrics,
+	}
+
+	// Extract audio properties
+	err = extractSongAudioProperties(content, &songNew.SongMeta)
+	if err != nil {
+		logrus.Warnf("Unable to extract audio properties: %v", err)
+	}
+
+	// Commit transaction
+	if externalTrn == nil {
+		txn.Commit()
+	}
+
+	return songNew, nil
+}
+
+func (s *Store) updateSongContentM4aTag(externalTrn *sqlx.Tx, songEntity *entity.SongEntity) error {
+
+	// region Extract tags
+	content, err := openSongContent(s.getSongFileName(songEntity.SongId, songEntity.Format))
+	if err != nil {
+		return err
+	}
+	defer content.Close()
+
+	boxes, err := readMp4Boxes(content)
+	if err != nil {
+		return err
+	}
+
+	// Locate moov and mdat to know if chunk offsets will move
+	var moov *mp4Box
+	moovBeforeMdat := false
+	for _, box := range boxes {
+		if box.boxType == "moov" && moov == nil {
+			moov = box
+			moovBeforeMdat = true
+		}
+		if box.boxType == "mdat" && moov == nil {
+			moovBeforeMdat = false
+			break
+		}
+	}
+	if moov == nil {
+		return errInvalidMp4Stream
+	}
+	oldMoovSize := moov.size()
+
+	ilst := m4aIlst(moov)
+
+	// endregion
+
+	// region Update tags with song meta
+
+	// Set title
+	m4aSetItem(ilst, m4aItemTitle, m4aDataTypeUtf8, []byte(songEntity.Name))
+
+	// Check available transaction
+	txn := externalTrn
+	if txn == nil {
+		txn, err = s.db.Beginx()
+		if err != nil {
+			return err
+		}
+		defer txn.Rollback()
+	}
+
+	// Set album (with album artists), disc & track number
+	m4aRemoveItem(ilst, m4aItemAlbum)
+	m4aRemoveItem(ilst, m4aItemAlbumArtist)
+	m4aRemoveItem(ilst, m4aItemDiscNumber)
+	m4aRemoveItem(ilst, m4aItemTrackNumber)
+	var album *restApiV1.Album
+	if songEntity.AlbumId != restApiV1.UnknownAlbumId {
+		album, err = s.ReadAlbum(txn, songEntity.AlbumId)
+		if err != nil {
+			return err
+		}
+		m4aSetItem(ilst, m4aItemAlbum, m4aDataTypeUtf8, []byte(album.Name))
+
+		if len(album.AlbumArtistIds) > 0 {
+			var albumArtistNames []string
+			for _, artistId := range album.AlbumArtistIds {
+				artist, err := s.ReadArtist(txn, artistId)
+				if err != nil {
+					return err
+				}
+				albumArtistNames = append(albumArtistNames, artist.Name)
+			}
+			m4aSetItem(ilst, m4aItemAlbumArtist, m4aDataTypeUtf8, []byte(strings.Join(albumArtistNames, ", ")))
+		}
+
+		if songEntity.DiscNumber.Valid {
+			value := make([]byte, 6)
+			binary.BigEndian.PutUint16(value[2:], uint16(songEntity.DiscNumber.Int64))
+			m4aSetItem(ilst, m4aItemDiscNumber, m4aDataTypeImplicit, value)
+		}
+		if songEntity.TrackNumber.Valid {
+			value := make([]byte, 8)
+			binary.BigEndian.PutUint16(value[2:], uint16(songEntity.TrackNumber.Int64))
+			m4aSetItem(ilst, m4aItemTrackNumber, m4aDataTypeImplicit, value)
+		}
+	}
+
+	// Set publication date
+	m4aRemoveItem(ilst, m4aItemDate)
+	if songEntity.PublicationYear.Valid {
+		m4aSetItem(ilst, m4aItemDate, m4aDataTypeUtf8, []byte(strconv.FormatInt(songEntity.PublicationYear.Int64, 10)))
+	}
+
+	// Set artists
+	artistNames, err := s.readSongArtistNames(txn, songEntity.SongId, restApiV1.ArtistRoleMain)
+	if err != nil {
+		return err
+	}
+	featuringArtistNames, err := s.readSongArtistNames(txn, songEntity.SongId, restApiV1.ArtistRoleFeaturing)
+	if err != nil {
+		return err
+	}
+	artistNamesStr := formatArtistNames(artistNames, featuringArtistNames)
+	m4aRemoveItem(ilst, m4aItemArtist)
+	if artistNamesStr != "" {
+		m4aSetItem(ilst, m4aItemArtist, m4aDataTypeUtf8, []byte(artistNamesStr))
+	}
+
+	// Set composers
+	composerNames, err := s.readSongArtistNames(txn, songEntity.SongId, restApiV1.ArtistRoleComposer)
+	if err != nil {
+		return err
+	}
+	m4aRemoveItem(ilst, m4aItemComposer)
+	if len(composerNames) > 0 {
+		m4aSetItem(ilst, m4aItemComposer, m4aDataTypeUtf8, []byte(strings.Join(composerNames, ", ")))
+	}
+
+	// Set genres
+	genreNames, err := s.readSongGenreNames(txn, songEntity)
+	if err != nil {
+		return err
+	}
+	m4aRemoveItem(ilst, m4aItemGenre)
+	m4aRemoveItem(ilst, m4aItemGenreId)
+	if len(genreNames) > 0 {
+		m4aSetItem(ilst, m4aItemGenre, m4aDataTypeUtf8, []byte(strings.Join(genreNames, ", ")))
+	}
+
+	// Set explicit flag
+	m4aRemoveItem(ilst, m4aItemRating)
+	if songEntity.ExplicitFg {
+		m4aSetItem(ilst, m4aItemRating, m4aDataTypeInteger, []byte{m4aRatingExplicit})
+	}
+
+	// Set lyrics
+	m4aRemoveItem(ilst, m4aItemLyrics)
+	if songEntity.LyricsFg {
+		m4aSetItem(ilst, m4aItemLyrics, m4aDataTypeUtf8, []byte(songEntity.Lyrics))
+	}
+
+	// Set album cover
+	if album != nil {
+		cover, err := s.readAlbumCover(album)
+		if err != nil {
+			return err
+		}
+
+		m4aRemoveItem(ilst, m4aItemCover)
+		if cover != nil {
+			dataType := m4aDataTypeJpeg
+			if cover.mimeType == "image/png" {
+				dataType = m4aDataTypePng
+			}
+			m4aSetItem(ilst, m4aItemCover, uint32(dataType), cover.content)
+		}
+	}
+
+	// endregion
+
+	// region Save tags
+
+	// Audio chunks located after moov move with it
+	if moovBeforeMdat {
+		mp4ShiftChunkOffsets(moov, int64(moov.size()-oldMoovSize))
+	}
+
+	err = rewriteSongFile(content, func(writer io.Writer) error {
+		return writeMp4Boxes(writer, boxes)
+	})
+	if err != nil {
+		return err
+	}
+
+	// Commit transaction
+	if externalTrn == nil {
+		txn.Commit()
+	}
+
+	// endregion
+	return nil
+}
+
+// m4aIlst return the ilst box of the moov box, creating the udta/meta/ilst hierarchy if needed
+func m4aIlst(moov *mp4Box) *mp4Box {
+	udta := moov.child("udta")
+	if udta == nil {
+		udta = &mp4Box{boxType: "udta"}
+		moov.children = append(moov.children, udta)
+	}
+
+	meta := udta.child("meta")
+	if meta == nil {
+		hdlr := &mp4Box{boxType: "hdlr", data: make([]byte, 4+4+4+12+1)}
+		copy(hdlr.data[8:], "mdirappl")
+		meta = &mp4Box{boxType: "meta", prefix: make([]byte, 4), children: []*mp4Box{hdlr}}
+		udta.children = append(udta.children, meta)
+	}
+
+	ilst := meta.child("ilst")
+	if ilst == nil {
+		ilst = &mp4Box{boxType: "ilst"}
+		meta.children = append(meta.children, ilst)
+	}
+
+	return ilst
+}
+
+// m4aItemData return the type & value of the first data box of an ilst item
+func m4aItemData(ilst *mp4Box, itemType string) (uint32, []byte, bool) {
+	item := ilst.child(itemType)
+	if item == nil {
+		return 0, nil, false
+	}
+	data := item.child("data")
+	if data == nil || len(data.data) < 8 {
+		return 0, nil, false
+	}
+
+	return binary.BigEndian.Uint32(data.data) & 0xFFFFFF, data.data[8:], true
+}
+
+// m4aTextItem return the value of a text ilst item
+func m4aTextItem(ilst *mp4Box, itemType string) string {
+	dataType, value, ok := m4aItemData(ilst, itemType)
+	if !ok || dataType != m4aDataTypeUtf8 {
+		return ""
+	}
+	return string(value)
+}
+
+// m4aSetItem replace the value of an ilst item
+func m4aSetItem(ilst *mp4Box, itemType string, dataType uint32, value []byte) {
+	data := make([]byte, 8+len(value))
+	binary.BigEndian.PutUint32(data, dataType)
+	copy(data[8:], value)
+
+	item := &mp4Box{boxType: itemType, children: []*mp4Box{{boxType: "data", data: data}}}
+
+	for idx, child := range ilst.children {
+		if child.boxType == itemType {
+			ilst.children[idx] = item
+			return
+		}
+	}
+	ilst.children = append(ilst.children, item)
+}
+
+// m4aRemoveItem remove every ilst item of the given type
+func m4aRemoveItem(ilst *mp4Box, itemType string) {
+	var children []*mp4Box
+	for _, child := range ilst.children {
+		if child.boxType != itemType {
+			children = append(children, child)
+		}
+	}
+	ilst.children = children
+}
+
+// extractM4aCover return the picture embedded in m4a content
+func extractM4aCover(content *songContent) []byte {
+	boxes, err := readMp4Boxes(content)
+	if err != nil {
+		return nil
+	}
+
+	ilst := mp4Path(boxes, "moov", "udta", "meta", "ilst")
+	if ilst == nil {
+		return nil
+	}
+
+	_, picture, ok := m4aItemData(ilst, m4aItemCover)
+	if !ok {
+		return nil
+	}
+
+	return picture
+}
+
+// extractM4aAudioProperties fill bit depth, duration, sample rate, channels and bitrate of the song meta from m4a content
+func extractM4aAudioProperties(content *songContent, songMeta *restApiV1.SongMeta) error {
+	boxes, err := readMp4Boxes(content)
+	if err != nil {
+		return err
+	}
+
+	moov := mp4Path(boxes, "moov")
+	if moov == nil {
+		return errInvalidMp4Stream
+	}
+
+	// Find the sound track
+	var mdia *mp4Box
+	for _, trak := range moov.children {
+		if trak.boxType != "trak" {
+			continue
+		}
+		hdlr := mp4Path(trak.children, "mdia", "hdlr")
+		if hdlr != nil && len(hdlr.data) >= 12 && string(hdlr.data[8:12]) == "soun" {
+			mdia = trak.child("mdia")
+			break
+		}
+	}
+	if mdia == nil {
+		return errUnsupportedMp4Stream
+	}
+
+	// Audio codec: AAC or ALAC
+	stsd := mp4Path(mdia.children, "minf", "stbl", "stsd")
+	if stsd == nil || len(stsd.data) < 44 {
+		return errInvalidMp4Stream
+	}
+	codec := string(stsd.data[12:16])
+	if codec != "mp4a" && codec != "alac" {
+		return errUnsupportedMp4Stream
+	}
+	songMeta.Channels = int64(binary.BigEndian.Uint16(stsd.data[32:]))
+	sampleSize := binary.BigEndian.Uint16(stsd.data[34:])
+	songMeta.SampleRate = int64(binary.BigEndian.Uint32(stsd.data[40:]) >> 16)
+
+	songMeta.BitDepth = restApiV1.SongBitDepthUnknown
+	if codec == "alac" {
+		switch sampleSize {
+		case 16:
+			songMeta.BitDepth = restApiV1.SongBitDepth16
+		case 24:
+			songMeta.BitDepth = restApiV1.SongBitDepth24
+		}
+	}
+
+	// Duration
+	mdhd := mdia.child("mdhd")
+	if mdhd == nil || len(mdhd.data) < 20 {
+		return errInvalidMp4Stream
+	}
+	var timescale, duration uint64
+	if mdhd.data[0] == 1 {
+		if len(mdhd.data) < 32 {
+			return errInvalidMp4Stream
+		}
+		timescale = uint64(binary.BigEndian.Uint32(mdhd.data[20:]))
+		duration = binary.BigEndian.Uint64(mdhd.data[24:])
+	} else {
+		timescale = uint64(binary.BigEndian.Uint32(mdhd.data[12:]))
+		duration = uint64(binary.BigEndian.Uint32(mdhd.data[16:]))
+	}
+
+	// High sample rates don't fit in the sample entry
+	if songMeta.SampleRate == 0 {
+		songMeta.SampleRate = int64(timescale)
+	}
+
+	songMeta.Duration = 0
+	songMeta.BitRate = 0
+	if timescale > 0 {
+		songMeta.Duration = int64(duration * 1000 / timescale)
+	}
+	if songMeta.Duration > 0 {
+		var mdatLength int64
+		for _, box := range boxes {
+			if box.boxType == "mdat" {
+				mdatLength += box.payload.Size()
+			}
+		}
+		songMeta.BitRate = mdatLength * 8 * 1000 / songMeta.Duration
+	}
+
+	return nil
+}
@@ -1,7 +1,8 @@
 package store
 
 import (
-	"bytes"
+	"encoding/binary"
+	"errors"
 	"github.com/bogem/id3v2/v2"
 	"github.com/jmoiron/sqlx"
 	"github.com/jypelle/mifasol/internal/srv/entity"
@@ -11,12 +12,10 @@ import (
 	"strings"
 )
 
-func (s *Store) createSongNewFromMp3Content(externalTrn *sqlx.Tx, content []byte, lastAlbumId restApiV1.AlbumId) (*restApiV1.SongNew, error) {
+func (s *Store) createSongNewFromMp3Content(externalTrn *sqlx.Tx, content *songContent, lastAlbumId restApiV1.AlbumId) (*restApiV1.SongNew, error) {
 
 	// Extract song meta from tags
-	reader := bytes.NewReader(content)
-
-	tag, err := id3v2.ParseReader(reader, id3v2.Options{Parse: true})
+	tag, err := id3v2.ParseReader(content.reader(), id3v2.Options{Parse: true})
 	if err != nil {
 		return nil, err
 	}
@@ -28,8 +27,11 @@ func (s *Store) createSongNewFromMp3Content(externalTrn *sqlx.Tx, content []byte
 	var title = ""
 	var publicationYear *int64 = nil
 	var albumId = restApiV1.UnknownAlbumId
+	var discNumber *int64 = nil
 	var trackNumber *int64 = nil
 	var artistIds []restApiV1.ArtistId
+	var songArtists []restApiV1.SongArtist
+	var genreIds []restApiV1.GenreId
 
 	// Check available transaction
 	txn := externalTrn
@@ -48,16 +50,40 @@ func (s *Store) createSongNewFromMp3Content(externalTrn *sqlx.Tx, content []byte
 	// Extract album name
 	albumName := normalizeString(tag.Album())
 
+	// Extract album artists
+	var albumArtistNames []string
+	for _, contatAlbumArtistNames := range strings.Split(tag.GetTextFrame(tag.CommonID("Band/Orchestra/Accompaniment")).Text, " - ") {
+		albumArtistNames = append(albumArtistNames, strings.FieldsFunc(contatAlbumArtistNames, func(r rune) bool { return r == ',' || r == ';' })...)
+	}
+
+	// Extract artists
+	var artistNames []string
+	var featuringArtistNames []string
+	for _, contatArtistNames := range strings.Split(tag.Artist(), " - ") {
+		mainArtistNames, contatFeaturingArtistNames := splitFeaturingArtistNames(contatArtistNames)
+		artistNames = append(artistNames, mainArtistNames...)
+		featuringArtistNames = append(featuringArtistNames, contatFeaturingArtistNames...)
+	}
+
 	// Find Album Id
-	albumId, err = s.getAlbumIdFromAlbumName(txn, albumName, lastAlbumId)
+	albumId, err = s.getAlbumIdFromAlbumName(txn, albumName, albumArtistNames, artistNames, lastAlbumId)
 	if err != nil {
 		return nil, err
 	}
 
 	logrus.Debugf("Album: %s", albumName)
+	logrus.Debugf("Album artists: %v", albumArtistNames)
 
-	// Extract track number
+	// Extract disc & track number
 	if albumId != restApiV1.UnknownAlbumId {
+		rawDiscNumber := strings.Split(tag.GetTextFrame(tag.CommonID("Part of a set")).Text, "/")
+		if len(rawDiscNumber) > 0 {
+			parsedDiscNumber, _ := strconv.ParseInt(normalizeString(rawDiscNumber[0]), 10, 64)
+			if parsedDiscNumber > 0 {
+				discNumber = &parsedDiscNumber
+			}
+		}
+
 		rawTrackNumber := strings.Split(tag.GetTextFrame(tag.CommonID("Track number/Position in set")).Text, "/")
 		if len(rawTrackNumber) > 0 {
 			parsedTrackNumber, _ := strconv.ParseInt(normalizeString(rawTrackNumber[0]), 10, 64)
@@ -67,6 +93,9 @@ func (s *Store) createSongNewFromMp3Content(externalTrn *sqlx.Tx, content []byte
 		}
 	}
 
+	if discNumber != nil {
+		logrus.Debugf("Disc number: %d", *discNumber)
+	}
 	if trackNumber != nil {
 		logrus.Debugf("Track number: %d", *trackNumber)
 	}
@@ -81,12 +110,6 @@ func (s *Store) createSongNewFromMp3Content(externalTrn *sqlx.Tx, content []byte
 		logrus.Debugf("Publication year: %d", *publicationYear)
 	}
 
-	// Extract artists
-	var artistNames []string
-	for _, contatArtistNames := range strings.Split(tag.Artist(), " - ") {
-		artistNames = append(artistNames, strings.FieldsFunc(contatArtistNames, func(r rune) bool { return r == ',' || r == ';' || r == ';' })...)
-	}
-
 	// Find Artist IDs
 	artistIds, err = s.getArtistIdsFromArtistNames(txn, artistNames)
 	if err != nil {
@@ -95,19 +118,64 @@ func (s *Store) createSongNewFromMp3Content(externalTrn *sqlx.Tx, content []byte
 
 	logrus.Debugf("Artists: %v", artistNames)
 
+	// Extract other credited artists
+	composerNames := splitArtistNames(tag.GetTextFrame(tag.CommonID("Composer")).Text)
+	conductorNames := splitArtistNames(tag.GetTextFrame(tag.CommonID("Conductor/performer refinement")).Text)
+
+	// Musician credits list alternates instruments and performer names
+	var rawPerformerNames []string
+	for ind, musicianCredit := range strings.Split(tag.GetTextFrame(tag.CommonID("Musician credits list")).Text, "\x00") {
+		if ind%2 == 1 {
+			rawPerformerNames = append(rawPerformerNames, musicianCredit)
+		}
+	}
+	performerNames := parsePerformerNames(rawPerformerNames)
+
+	songArtists, err = s.getCreditedSongArtistsFromArtistNames(txn, featuringArtistNames, composerNames, performerNames, conductorNames)
+	if err != nil {
+		return nil, err
+	}
+
+	logrus.Debugf("Featuring: %v, composers: %v, performers: %v, conductors: %v", featuringArtistNames, composerNames, performerNames, conductorNames)
+
+	// Extract genres
+	genreNames := parseId3GenreNames(tag.Genre())
+
+	// Find Genre IDs
+	genreIds, err = s.getGenreIdsFromGenreNames(txn, genreNames)
+	if err != nil {
+		return nil, err
+	}
+
+	logrus.Debugf("Genres: %v", genreNames)
+
+	// Extract lyrics & rating
+	lyrics := extractMp3Lyrics(tag)
+	rating := extractMp3Rating(tag)
+
 	songNew = &restApiV1.SongNew{
 		SongMeta: restApiV1.SongMeta{
 			Name:            title,
 			Format:          restApiV1.SongFormatMp3,
-			Size:            int64(len(content)),
+			Size:            content.size,
 			BitDepth:        bitDepth,
 			PublicationYear: publicationYear,
 			AlbumId:         albumId,
+			DiscNumber:      discNumber,
 			TrackNumber:     trackNumber,
 			ExplicitFg:      false,
 			ArtistIds:       artistIds,
+			Artists:         songArtists,
+			GenreIds:        genreIds,
 		},
-		Content: content,
+		Lyrics: lyrics,
+		Rating: rating,
+	}
+
+	// Extract audio properties
+	err = extractSongAudioProperties(content, &songNew.SongMeta)
+	if err != nil {
+		logrus.Warnf("Unable to extract audio properties: %v", err)
 	}
 
 	// Commit transaction
@@ -141,17 +209,51 @@ func (s *Store) updateSongContentMp3Tag(externalTrn *sqlx.Tx, songEntity *entity
 		defer txn.Rollback()
 	}
 
-	// Set album & track number
+	// Set album (with album artists), disc & track number
+	tag.DeleteFrames(tag.CommonID("Band/Orchestra/Accompaniment"))
+	tag.DeleteFrames(tag.CommonID("Part of a set"))
+	var album *restApiV1.Album
 	if songEntity.AlbumId != restApiV1.UnknownAlbumId {
-		album, err := s.ReadAlbum(txn, songEntity.AlbumId)
+		album, err = s.ReadAlbum(txn, songEntity.AlbumId)
 		if err != nil {
 			return err
 		}
 		tag.SetAlbum(album.Name)
 
+		if len(album.AlbumArtistIds) > 0 {
+			var albumArtistNames []string
+			for _, artistId := range album.AlbumArtistIds {
+				artist, err := s.ReadArtist(txn, artistId)
+				if err != nil {
+					return err
+				}
+				albumArtistNames = append(albumArtistNames, artist.Name)
+			}
+			tag.AddTextFrame(tag.CommonID("Band/Orchestra/Accompaniment"), tag.DefaultEncoding(), strings.Join(albumArtistNames, ", "))
+		}
+
+		if songEntity.DiscNumber.Valid {
+			tag.AddTextFrame(tag.CommonID("Part of a set"), tag.DefaultEncoding(), strconv.FormatInt(songEntity.DiscNumber.Int64, 10))
+		}
 		if songEntity.TrackNumber.Valid {
 			tag.AddTextFrame(tag.CommonID("Track number/Position in set"), tag.DefaultEncoding(), strconv.FormatInt(songEntity.TrackNumber.Int64, 10))
 		}
+
+		// Set album cover
+		cover, err := s.readAlbumCover(album)
+		if err != nil {
+			return err
+		}
+		tag.DeleteFrames(tag.CommonID("Attached picture"))
+		if cover != nil {
+			tag.AddAttachedPicture(id3v2.PictureFrame{
+				Encoding:    id3v2.EncodingUTF8,
+				MimeType:    cover.mimeType,
+				PictureType: id3v2.PTFrontCover,
+				Description: "Front cover",
+				Picture:     cover.content,
+			})
+		}
 	}
 
 	// Set publication date
@@ -160,20 +262,86 @@ func (s *Store) updateSongContentMp3Tag(externalTrn *sqlx.Tx, songEntity *entity
 	}
 
 	// Set artists
-	artistNamesStr := ""
+	artistNames, err := s.readSongArtistNames(txn, songEntity.SongId, restApiV1.ArtistRoleMain)
+	if err != nil {
+		return err
+	}
+	featuringArtistNames, err := s.readSongArtistNames(txn, songEntity.SongId, restApiV1.ArtistRoleFeaturing)
+	if err != nil {
+		return err
+	}
+	tag.SetArtist(formatArtistNames(artistNames, featuringArtistNames))
+
+	// Set other credited artists
+	composerNames, err := s.readSongArtistNames(txn, songEntity.SongId, restApiV1.ArtistRoleComposer)
+	if err != nil {
+		return err
+	}
+	tag.DeleteFrames(tag.CommonID("Composer"))
+	if len(composerNames) > 0 {
+		tag.AddTextFrame(tag.CommonID("Composer"), tag.DefaultEncoding(), strings.Join(composerNames, ", "))
+	}
+
+	conductorNames, err := s.readSongArtistNames(txn, songEntity.SongId, restApiV1.ArtistRoleConductor)
+	if err != nil {
+		return err
+	}
+	tag.DeleteFrames(tag.CommonID("Conductor/performer refinement"))
+	if len(conductorNames) > 0 {
+		tag.AddTextFrame(tag.CommonID("Conductor/performer refinement"), tag.DefaultEncoding(), strings.Join(conductorNames, ", "))
+	}
+
+	performerNames, err := s.readSongArtistNames(txn, songEntity.SongId, restApiV1.ArtistRolePerformer)
+	if err != nil {
+		return err
+	}
+	tag.DeleteFrames(tag.CommonID("Musician credits list"))
+	if len(performerNames) > 0 {
+		var musicianCredits []string
+		for _, performerName := range performerNames {
+			musicianCredits = append(musicianCredits, "performer", performerName)
+		}
+		tag.AddTextFrame(tag.CommonID("Musician credits list"), tag.DefaultEncoding(), strings.Join(musicianCredits, "\x00"))
+	}
 
-	artists, err := s.ReadArtists(txn, &restApiV1.ArtistFilter{SongId: &songEntity.SongId})
+	// Set genres
+	genreNames, err := s.readSongGenreNames(txn, songEntity)
 	if err != nil {
 		return err
 	}
-	for ind, artist := range artists {
-		if ind == 0 {
-			artistNamesStr = artist.Name
+	tag.DeleteFrames(tag.CommonID("Content type"))
+	if len(genreNames) > 0 {
+		tag.SetGenre(strings.Join(genreNames, ", "))
+	}
+
+	// Set lyrics, time-synced lyrics are also written in a SYLT frame
+	tag.DeleteFrames(tag.CommonID("Unsynchronised lyrics/text transcription"))
+	tag.DeleteFrames(mp3FrameSyncedLyrics)
+	if songEntity.LyricsFg {
+		tag.AddUnsynchronisedLyricsFrame(id3v2.UnsynchronisedLyricsFrame{
+			Encoding:          tag.DefaultEncoding(),
+			Language:          "XXX",
+			ContentDescriptor: "",
+			Lyrics:            unsyncLyrics(songEntity.Lyrics),
+		})
+		lines := parseLrc(songEntity.Lyrics)
+		if len(lines) > 0 {
+			tag.AddFrame(mp3FrameSyncedLyrics, id3v2.UnknownFrame{Body: marshalSyltFrame(lines, tag.Version() == 4)})
+		}
+	}
+
+	// Set replay gain, existing tags are kept until the song is analysed
+	if songEntity.Loudness.Valid {
+		setMp3UserDefinedText(tag, replayGainTrackGain, formatReplayGain(songEntity.Loudness.Float64))
+		setMp3UserDefinedText(tag, replayGainTrackPeak, formatReplayGainPeak(songEntity.TruePeak.Float64))
+		if album != nil && album.Loudness != nil {
+			setMp3UserDefinedText(tag, replayGainAlbumGain, formatReplayGain(*album.Loudness))
+			setMp3UserDefinedText(tag, replayGainAlbumPeak, formatReplayGainPeak(*album.TruePeak))
 		} else {
-			artistNamesStr += ", " + artist.Name
+			setMp3UserDefinedText(tag, replayGainAlbumGain, "")
+			setMp3UserDefinedText(tag, replayGainAlbumPeak, "")
 		}
 	}
-	tag.SetArtist(artistNamesStr)
 
 	// endregion
 
@@ -190,3 +358,259 @@ func (s *Store) updateSongContentMp3Tag(externalTrn *sqlx.Tx, songEntity *entity
 
 	return nil
 }
+
+// mp3FrameSyncedLyrics is the id of the id3v2 synchronised lyrics frame, not decoded by id3v2 library
+const mp3FrameSyncedLyrics = "SYLT"
+
+// extractMp3Lyrics return the lyrics of SYLT frames (as LRC) or USLT frames
+func extractMp3Lyrics(tag *id3v2.Tag) string {
+	for _, frame := range tag.GetFrames(mp3FrameSyncedLyrics) {
+		unknownFrame, ok := frame.(id3v2.UnknownFrame)
+		if ok {
+			lines := parseSyltFrame(unknownFrame.Body)
+			if len(lines) > 0 {
+				return cleanTagLyrics(formatLrc(lines))
+			}
+		}
+	}
+
+	for _, frame := range tag.GetFrames(tag.CommonID("Unsynchronised lyrics/text transcription")) {
+		lyricsFrame, ok := frame.(id3v2.UnsynchronisedLyricsFrame)
+		if ok {
+			if lyrics := cleanTagLyrics(lyricsFrame.Lyrics); lyrics != "" {
+				return lyrics
+			}
+		}
+	}
+
+	return ""
+}
+
+// setMp3UserDefinedText replace the TXXX frames with the given description, an empty value only remove them
+func setMp3UserDefinedText(tag *id3v2.Tag, description string, value string) {
+	frameId := tag.CommonID("User defined text information frame")
+	frames := tag.GetFrames(frameId)
+	tag.DeleteFrames(frameId)
+	for _, frame := range frames {
+		userDefinedTextFrame, ok := frame.(id3v2.UserDefinedTextFrame)
+		if !ok || !strings.EqualFold(userDefinedTextFrame.Description, description) {
+			tag.AddFrame(frameId, frame)
+		}
+	}
+
+	if value != "" {
+		tag.AddUserDefinedTextFrame(id3v2.UserDefinedTextFrame{
+			Encoding:    tag.DefaultEncoding(),
+			Description: description,
+			Value:       value,
+		})
+	}
+}
+
+// extractMp3Cover return the picture embedded in mp3 content, front cover first
+func extractMp3Cover(content *songContent) []byte {
+	tag, err := id3v2.ParseReader(content.reader(), id3v2.Options{Parse: true, ParseFrames: []string{"Attached picture"}})
+	if err != nil {
+		return nil
+	}
+	defer tag.Close()
+
+	var picture []byte
+	for _, frame := range tag.GetFrames(tag.CommonID("Attached picture")) {
+		pictureFrame, ok := frame.(id3v2.PictureFrame)
+		if ok {
+			if pictureFrame.PictureType == id3v2.PTFrontCover {
+				return pictureFrame.Picture
+			}
+			if picture == nil {
+				picture = pictureFrame.Picture
+			}
+		}
+	}
+
+	return picture
+}
+
+var mp3BitRates = [2][3][16]int64{
+	// MPEG 1: Layer I, Layer II, Layer III
+	{
+		{0, 32, 64, 96, 128, 160, 192, 224, 256, 288, 320, 352, 384, 416, 448, 0},
+		{0, 32, 48, 56, 64, 80, 96, 112, 128, 160, 192, 224, 256, 320, 384, 0},
+		{0, 32, 40, 48, 56, 64, 80, 96, 112, 128, 160, 192, 224, 256, 320, 0},
+	},
+	// MPEG 2 & 2.5: Layer I, Layer II, Layer III
+	{
+		{0, 32, 48, 56, 64, 80, 96, 112, 128, 144, 160, 176, 192, 224, 256, 0},
+		{0, 8, 16, 24, 32, 40, 48, 56, 64, 80, 96, 112, 128, 144, 160, 0},
+		{0, 8, 16, 24, 32, 40, 48, 56, 64, 80, 96, 112, 128, 144, 160, 0},
+	},
+}
+
+var mp3SampleRates = [4][3]int64{
+	{11025, 12000, 8000},  // MPEG 2.5
+	{0, 0, 0},             // Reserved
+	{22050, 24000, 16000}, // MPEG 2
+	{44100, 48000, 32000}, // MPEG 1
+}
+
+type mp3FrameHeader struct {
+	mpeg1           bool
+	layer           int // 1, 2 or 3
+	bitRate         int64
+	sampleRate      int64
+	channels        int64
+	samplesPerFrame int64
+	frameSize       int64
+}
+
+// parseMp3FrameHeader decode the 4 bytes header of a mpeg audio frame
+func parseMp3FrameHeader(b []byte) (*mp3FrameHeader, bool) {
+	if len(b) < 4 || b[0] != 0xFF || b[1]&0xE0 != 0xE0 {
+		return nil, false
+	}
+
+	versionIndex := (b[1] >> 3) & 0x03
+	layerIndex := (b[1] >> 1) & 0x03
+	bitRateIndex := b[2] >> 4
+	sampleRateIndex := (b[2] >> 2) & 0x03
+	padding := int64((b[2] >> 1) & 0x01)
+
+	if versionIndex == 1 || layerIndex == 0 || bitRateIndex == 0 || bitRateIndex == 15 || sampleRateIndex == 3 {
+		return nil, false
+	}
+
+	header := &mp3FrameHeader{
+		mpeg1:      versionIndex == 3,
+		layer:      int(4 - layerIndex),
+		sampleRate: mp3SampleRates[versionIndex][sampleRateIndex],
+		channels:   2,
+	}
+
+	if header.mpeg1 {
+		header.bitRate = mp3BitRates[0][header.layer-1][bitRateIndex] * 1000
+	} else {
+		header.bitRate = mp3BitRates[1][header.layer-1][bitRateIndex] * 1000
+	}
+
+	if b[3]>>6 == 3 {
+		header.channels = 1
+	}
+
+	switch {
+	case header.layer == 1:
+		header.samplesPerFrame = 384
+		header.frameSize = (12*header.bitRate/header.sampleRate + padding) * 4
+	case header.layer == 3 && !header.mpeg1:
+		header.samplesPerFrame = 576
+		header.frameSize = 72*header.bitRate/header.sampleRate + padding
+	default:
+		header.samplesPerFrame = 1152
+		header.frameSize = 144*header.bitRate/header.sampleRate + padding
+	}
+
+	return header, true
+}
+
+// mp3ScanLength is the length of the audio payload start scanned for the first frame and the VBR headers
+const mp3ScanLength = 1024 * 1024
+
+// extractMp3AudioProperties fill duration, sample rate, channels and bitrate of the song meta from mp3 content
+func extractMp3AudioProperties(mp3Content *songContent, songMeta *restApiV1.SongMeta) error {
+	payloadStart, payloadEnd, err := mp3AudioPayloadBounds(mp3Content)
+	if err != nil {
+		return err
+	}
+	content, err := mp3Content.readAt(payloadStart, min(payloadEnd-payloadStart, mp3ScanLength))
+	if err != nil {
+		return err
+	}
+	start := int64(0)
+	end := int64(len(content))
+
+	// Find first valid frame (next frame header must be consistent)
+	var header *mp3FrameHeader
+	for ; start+4 <= end; start++ {
+		h, ok := parseMp3FrameHeader(content[start:])
+		if !ok {
+			continue
+		}
+		next := start + h.frameSize
+		if next+4 <= end {
+			nh, ok := parseMp3FrameHeader(content[next:])
+			if !ok || nh.sampleRate != h.sampleRate || nh.layer != h.layer {
+				continue
+			}
+		}
+		header = h
+		break
+	}
+	if header == nil {
+		return errors.New("no mpeg audio frame found")
+	}
+
+	songMeta.SampleRate = header.sampleRate
+	songMeta.Channels = header.channels
+
+	var frameCount int64
+	var audioSize = payloadEnd - payloadStart - start
+	var encoderDelay, encoderPadding int64
+
+	// Look for a Xing/Info header (VBR & LAME) after side information
+	sideInfoSize := int64(32)
+	if header.mpeg1 && header.channels == 1 || !header.mpeg1 && header.channels == 2 {
+		sideInfoSize = 17
+	} else if !header.mpeg1 && header.channels == 1 {
+		sideInfoSize = 9
+	}
+	xing := start + 4 + sideInfoSize
+	if xing+8 <= end && (string(content[xing:xing+4]) == "Xing" || string(content[xing:xing+4]) == "Info") {
+		flags := binary.BigEndian.Uint32(content[xing+4:])
+		pos := xing + 8
+		if flags&0x01 != 0 && pos+4 <= end {
+			frameCount = int64(binary.BigEndian.Uint32(content[pos:]))
+			pos += 4
+		}
+		if flags&0x02 != 0 && pos+4 <= end {
+			audioSize = int64(binary.BigEndian.Uint32(content[pos:]))
+			pos += 4
+		}
+		if flags&0x04 != 0 {
+			// Skip TOC
+			pos += 100
+		}
+		if flags&0x08 != 0 {
+			// Skip quality indicator
+			pos += 4
+		}
+
+		// LAME extension: encoder delay & padding
+		if pos+24 <= end && (string(content[pos:pos+4]) == "LAME" || string(content[pos:pos+4]) == "Lavc" || string(content[pos:pos+4]) == "Lavf") {
+			encoderDelay = int64(content[pos+21])<<4 | int64(content[pos+22])>>4
+			encoderPadding = int64(content[pos+22]&0x0F)<<8 | int64(content[pos+23])
+		}
+	} else {
+		// Look for a VBRI header (Fraunhofer encoder)
+		vbri := start + 4 + 32
+		if vbri+18 <= end && string(content[vbri:vbri+4]) == "VBRI" {
+			audioSize = int64(binary.BigEndian.Uint32(content[vbri+10:]))
+			frameCount = int64(binary.BigEndian.Uint32(content[vbri+14:]))
+		}
+	}
+
+	if frameCount > 0 {
+		sampleCount := frameCount*header.samplesPerFrame - encoderDelay - encoderPadding
+		if sampleCount <= 0 {
+			sampleCount = frameCount * header.samplesPerFrame
+		}
+		songMeta.Duration = sampleCount * 1000 / header.sampleRate
+		if songMeta.Duration > 0 {
+			songMeta.BitRate = audioSize * 8 * 1000 / songMeta.Duration
+		}
+	} else {
+		// Constant bitrate
+		songMeta.BitRate = header.bitRate
+		songMeta.Duration = audioSize * 8 * 1000 / header.bitRate
+	}
+
+	return nil
+}
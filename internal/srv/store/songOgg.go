@@ -1,20 +1,43 @@
 package store
 
 import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"github.com/go-flac/flacvorbis"
+	"github.com/go-flac/go-flac"
 	"github.com/jmoiron/sqlx"
 	"github.com/jypelle/mifasol/internal/srv/entity"
 	"github.com/jypelle/mifasol/restApiV1"
+	"github.com/sirupsen/logrus"
+	"io"
 )
 
-func (s *Store) createSongNewFromOggContent(externalTrn *sqlx.Tx, content []byte, lastAlbumId restApiV1.AlbumId) (*restApiV1.SongNew, error) {
+const (
+	vorbisIdentificationHeader = "\x01vorbis"
+	vorbisCommentHeader        = "\x03vorbis"
+	vorbisSetupHeader          = "\x05vorbis"
+
+	vorbisFieldPicture = "METADATA_BLOCK_PICTURE"
+)
+
+var errUnsupportedOggStream = errors.New("Unsupported ogg stream")
+
+func (s *Store) createSongNewFromOggContent(externalTrn *sqlx.Tx, content *songContent, lastAlbumId restApiV1.AlbumId) (*restApiV1.SongNew, error) {
 
 	// Extract song meta from tags
-	// TODO
+	packets, _, err := readVorbisHeaderPackets(content.bufferedReader())
+	if err != nil {
+		return nil, err
+	}
 
-	var artistIds []restApiV1.ArtistId
+	cmt, err := parseOggVorbisComment(packets[1], vorbisCommentHeader)
+	if err != nil {
+		return nil, err
+	}
 
 	// Check available transaction
-	var err error
 	txn := externalTrn
 	if txn == nil {
 		txn, err = s.db.Beginx()
@@ -26,16 +49,29 @@ func (s *Store) createSongNewFromOggContent(externalTrn *sqlx.Tx, content []byte
 
 	songNew := &restApiV1.SongNew{
 		SongMeta: restApiV1.SongMeta{
-			Name:            "Unknown",
-			Format:          restApiV1.SongFormatOgg,
-			Size:            int64(len(content)),
-			PublicationYear: nil,
-			AlbumId:         restApiV1.UnknownAlbumId,
-			TrackNumber:     nil,
-			ExplicitFg:      false,
-			ArtistIds:       artistIds,
+			Format:     restApiV1.SongFormatOgg,
+			Size:       content.size,
+			BitDepth:   restApiV1.SongBitDepthUnknown,
+			ExplicitFg: false,
 		},
-		Content: content,
+	}
+
+	// Extract title, album, track number, year & artists
+	err = s.fillSongMetaFromVorbisComment(txn, cmt, lastAlbumId, &songNew.SongMeta)
+	if err != nil {
+		return nil, err
+	}
+
+	// Extract lyrics
+	songNew.Lyrics = extractVorbisLyrics(cmt)
+
+	// Extract rating
+	songNew.Rating = extractVorbisRating(cmt)
+
+	// Extract audio properties
+	err = extractSongAudioProperties(content, &songNew.SongMeta)
+	if err != nil {
+		logrus.Warnf("Unable to extract audio properties: %v", err)
 	}
 
 	// Commit transaction
@@ -47,6 +83,202 @@ func (s *Store) createSongNewFromOggContent(externalTrn *sqlx.Tx, content []byte
 }
 
 func (s *Store) updateSongContentOggTag(externalTrn *sqlx.Tx, songEntity *entity.SongEntity) error {
-	// TODO
+
+	// region Extract tags
+	content, err := openSongContent(s.getSongFileName(songEntity.SongId, songEntity.Format))
+	if err != nil {
+		return err
+	}
+	defer content.Close()
+
+	packets, _, err := readVorbisHeaderPackets(content.bufferedReader())
+	if err != nil {
+		return err
+	}
+
+	cmt, err := parseOggVorbisComment(packets[1], vorbisCommentHeader)
+	if err != nil {
+		return err
+	}
+
+	// endregion
+
+	// region Update tags with song meta
+
+	// Check available transaction
+	txn := externalTrn
+	if txn == nil {
+		txn, err = s.db.Beginx()
+		if err != nil {
+			return err
+		}
+		defer txn.Rollback()
+	}
+
+	album, err := s.fillVorbisCommentFromSong(txn, cmt, songEntity)
+	if err != nil {
+		return err
+	}
+
+	// Set album cover
+	if album != nil {
+		err = s.fillVorbisCommentCover(cmt, album)
+		if err != nil {
+			return err
+		}
+	}
+
+	// endregion
+
+	// region Save tags
+
+	packets[1] = marshalOggVorbisComment(cmt, vorbisCommentHeader, true)
+
+	err = rewriteSongFile(content, func(writer io.Writer) error {
+		return rewriteOggHeaderPackets(content.bufferedReader(), writer, packets)
+	})
+	if err != nil {
+		return err
+	}
+
+	// Commit transaction
+	if externalTrn == nil {
+		txn.Commit()
+	}
+
+	// endregion
+	return nil
+}
+
+// readVorbisHeaderPackets return identification, comment & setup headers of an ogg vorbis stream with the pages holding them
+func readVorbisHeaderPackets(reader io.Reader) ([][]byte, []*oggPage, error) {
+	packets, pages, _, err := readOggHeaderPages(reader, 3)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if !bytes.HasPrefix(packets[0], []byte(vorbisIdentificationHeader)) ||
+		!bytes.HasPrefix(packets[1], []byte(vorbisCommentHeader)) ||
+		!bytes.HasPrefix(packets[2], []byte(vorbisSetupHeader)) {
+		return nil, nil, errUnsupportedOggStream
+	}
+
+	return packets, pages, nil
+}
+
+// parseOggVorbisComment decode a vorbis comment packet (also used by opus)
+func parseOggVorbisComment(packet []byte, header string) (*flacvorbis.MetaDataBlockVorbisComment, error) {
+	if !bytes.HasPrefix(packet, []byte(header)) {
+		return nil, errUnsupportedOggStream
+	}
+
+	return flacvorbis.ParseFromMetaDataBlock(flac.MetaDataBlock{Type: flac.VorbisComment, Data: packet[len(header):]})
+}
+
+// marshalOggVorbisComment encode a vorbis comment packet, vorbis requires a final framing bit
+func marshalOggVorbisComment(cmt *flacvorbis.MetaDataBlockVorbisComment, header string, framingBit bool) []byte {
+	buffer := new(bytes.Buffer)
+	buffer.WriteString(header)
+	buffer.Write(cmt.Marshal().Data)
+	if framingBit {
+		buffer.WriteByte(1)
+	}
+
+	return buffer.Bytes()
+}
+
+// fillVorbisCommentCover replace the picture comment with the album cover
+func (s *Store) fillVorbisCommentCover(cmt *flacvorbis.MetaDataBlockVorbisComment, album *restApiV1.Album) error {
+	cover, err := s.readAlbumCover(album)
+	if err != nil {
+		return err
+	}
+
+	vorbisClean(cmt, vorbisFieldPicture)
+	if cover != nil {
+		cmt.Add(vorbisFieldPicture, base64.StdEncoding.EncodeToString(cover.marshalPictureBlock()))
+	}
+
+	return nil
+}
+
+// extractVorbisCommentCover return the picture embedded in vorbis comments, front cover first
+func extractVorbisCommentCover(cmt *flacvorbis.MetaDataBlockVorbisComment) []byte {
+	encodedPictureBlocks, err := cmt.Get(vorbisFieldPicture)
+	if err != nil {
+		return nil
+	}
+
+	var picture []byte
+	for _, encodedPictureBlock := range encodedPictureBlocks {
+		pictureBlock, err := base64.StdEncoding.DecodeString(encodedPictureBlock)
+		if err != nil {
+			continue
+		}
+		pictureType, data, ok := parsePictureBlock(pictureBlock)
+		if ok {
+			if pictureType == pictureBlockFrontCover {
+				return data
+			}
+			if picture == nil {
+				picture = data
+			}
+		}
+	}
+
+	return picture
+}
+
+// extractOggCover return the picture embedded in ogg vorbis content
+func extractOggCover(content *songContent) []byte {
+	packets, _, err := readVorbisHeaderPackets(content.bufferedReader())
+	if err != nil {
+		return nil
+	}
+
+	cmt, err := parseOggVorbisComment(packets[1], vorbisCommentHeader)
+	if err != nil {
+		return nil
+	}
+
+	return extractVorbisCommentCover(cmt)
+}
+
+// extractOggAudioProperties fill duration, sample rate, channels and bitrate of the song meta from ogg vorbis content
+func extractOggAudioProperties(content *songContent, songMeta *restApiV1.SongMeta) error {
+	reader := content.bufferedReader()
+	packets, pages, err := readVorbisHeaderPackets(reader)
+	if err != nil {
+		return err
+	}
+
+	identification := packets[0]
+	if len(identification) < 28 {
+		return errUnsupportedOggStream
+	}
+
+	songMeta.Channels = int64(identification[11])
+	songMeta.SampleRate = int64(binary.LittleEndian.Uint32(identification[12:]))
+	songMeta.Duration = 0
+	songMeta.BitRate = int64(int32(binary.LittleEndian.Uint32(identification[20:])))
+	if songMeta.BitRate < 0 {
+		songMeta.BitRate = 0
+	}
+
+	if songMeta.SampleRate > 0 {
+		lastGranule, err := readOggLastGranule(reader, pages[0].serial)
+		if err != nil {
+			return err
+		}
+		songMeta.Duration = int64(lastGranule * 1000 / uint64(songMeta.SampleRate))
+	}
+	if songMeta.Duration > 0 {
+		headerLength := 0
+		for _, packet := range packets {
+			headerLength += len(packet)
+		}
+		songMeta.BitRate = (content.size - int64(headerLength)) * 8 * 1000 / songMeta.Duration
+	}
+
 	return nil
 }
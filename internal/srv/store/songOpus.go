@@ -0,0 +1,213 @@
+package store
+
+import (
+	"bytes"
+	"encoding/binary"
+	"github.com/jmoiron/sqlx"
+	"github.com/jypelle/mifasol/internal/srv/entity"
+	"github.com/jypelle/mifasol/restApiV1"
+	"github.com/sirupsen/logrus"
+	"io"
+)
+
+const (
+	opusIdentificationHeader = "OpusHead"
+	opusCommentHeader        = "OpusTags"
+
+	// opusSampleRate is the decoding sample rate of every opus stream
+	opusSampleRate = 48000
+)
+
+func (s *Store) createSongNewFromOpusContent(externalTrn *sqlx.Tx, content *songContent, lastAlbumId restApiV1.AlbumId) (*restApiV1.SongNew, error) {
+
+	// Extract song meta from tags
+	packets, _, err := readOpusHeaderPackets(content.bufferedReader())
+	if err != nil {
+		return nil, err
+	}
+
+	cmt, err := parseOggVorbisComment(packets[1], opusCommentHeader)
+	if err != nil {
+		return nil, err
+	}
+
+	// Check available transaction
+	txn := externalTrn
+	if txn == nil {
+		txn, err = s.db.Beginx()
+		if err != nil {
+			return nil, err
+		}
+		defer txn.Rollback()
+	}
+
+	songNew := &restApiV1.SongNew{
+		SongMeta: restApiV1.SongMeta{
+			Format:     restApiV1.SongFormatOpus,
+			Size:       content.size,
+			BitDepth:   restApiV1.SongBitDepthUnknown,
+			ExplicitFg: false,
+		},
+	}
+
+	// Extract title, album, track number, year & artists
+	err = s.fillSongMetaFromVorbisComment(txn, cmt, lastAlbumId, &songNew.SongMeta)
+	if err != nil {
+		return nil, err
+	}
+
+	// Extract lyrics
+	songNew.Lyrics = extractVorbisLyrics(cmt)
+
+	// Extract rating
+	songNew.Rating = extractVorbisRating(cmt)
+
+	// Extract audio properties
+	err = extractSongAudioProperties(content, &songNew.SongMeta)
+	if err != nil {
+		logrus.Warnf("Unable to extract audio properties: %v", err)
+	}
+
+	// Commit transaction
+	if externalTrn == nil {
+		txn.Commit()
+	}
+
+	return songNew, nil
+}
+
+func (s *Store) updateSongContentOpusTag(externalTrn *sqlx.Tx, songEntity *entity.SongEntity) error {
+
+	// region Extract tags
+	content, err := openSongContent(s.getSongFileName(songEntity.SongId, songEntity.Format))
+	if err != nil {
+		return err
+	}
+	defer content.Close()
+
+	packets, _, err := readOpusHeaderPackets(content.bufferedReader())
+	if err != nil {
+		return err
+	}
+
+	cmt, err := parseOggVorbisComment(packets[1], opusCommentHeader)
+	if err != nil {
+		return err
+	}
+
+	// endregion
+
+	// region Update tags with song meta
+
+	// Check available transaction
+	txn := externalTrn
+	if txn == nil {
+		txn, err = s.db.Beginx()
+		if err != nil {
+			return err
+		}
+		defer txn.Rollback()
+	}
+
+	album, err := s.fillVorbisCommentFromSong(txn, cmt, songEntity)
+	if err != nil {
+		return err
+	}
+
+	// Set album cover
+	if album != nil {
+		err = s.fillVorbisCommentCover(cmt, album)
+		if err != nil {
+			return err
+		}
+	}
+
+	// endregion
+
+	// region Save tags
+
+	packets[1] = marshalOggVorbisComment(cmt, opusCommentHeader, false)
+
+	err = rewriteSongFile(content, func(writer io.Writer) error {
+		return rewriteOggHeaderPackets(content.bufferedReader(), writer, packets)
+	})
+	if err != nil {
+		return err
+	}
+
+	// Commit transaction
+	if externalTrn == nil {
+		txn.Commit()
+	}
+
+	// endregion
+	return nil
+}
+
+// readOpusHeaderPackets return identification & comment headers of an ogg opus stream with the pages holding them
+func readOpusHeaderPackets(reader io.Reader) ([][]byte, []*oggPage, error) {
+	packets, pages, _, err := readOggHeaderPages(reader, 2)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if !bytes.HasPrefix(packets[0], []byte(opusIdentificationHeader)) ||
+		!bytes.HasPrefix(packets[1], []byte(opusCommentHeader)) {
+		return nil, nil, errUnsupportedOggStream
+	}
+
+	return packets, pages, nil
+}
+
+// extractOpusCover return the picture embedded in ogg opus content
+func extractOpusCover(content *songContent) []byte {
+	packets, _, err := readOpusHeaderPackets(content.bufferedReader())
+	if err != nil {
+		return nil
+	}
+
+	cmt, err := parseOggVorbisComment(packets[1], opusCommentHeader)
+	if err != nil {
+		return nil
+	}
+
+	return extractVorbisCommentCover(cmt)
+}
+
+// extractOpusAudioProperties fill duration, sample rate, channels and bitrate of the song meta from ogg opus content
+func extractOpusAudioProperties(content *songContent, songMeta *restApiV1.SongMeta) error {
+	reader := content.bufferedReader()
+	packets, pages, err := readOpusHeaderPackets(reader)
+	if err != nil {
+		return err
+	}
+
+	identification := packets[0]
+	if len(identification) < 19 {
+		return errUnsupportedOggStream
+	}
+
+	songMeta.Channels = int64(identification[9])
+	songMeta.SampleRate = opusSampleRate
+	songMeta.Duration = 0
+	songMeta.BitRate = 0
+
+	// Granule positions count 48kHz samples, including the pre-skip ones
+	preSkip := uint64(binary.LittleEndian.Uint16(identification[10:]))
+	lastGranule, err := readOggLastGranule(reader, pages[0].serial)
+	if err != nil {
+		return err
+	}
+	if lastGranule > preSkip {
+		songMeta.Duration = int64((lastGranule - preSkip) * 1000 / opusSampleRate)
+	}
+	if songMeta.Duration > 0 {
+		headerLength := 0
+		for _, packet := range packets {
+			headerLength += len(packet)
+		}
+		songMeta.BitRate = (content.size - int64(headerLength)) * 8 * 1000 / songMeta.Duration
+	}
+
+	return nil
+}
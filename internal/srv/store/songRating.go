@@ -0,0 +1,281 @@
+package store
+
+import (
+	"database/sql"
+	"github.com/bogem/id3v2/v2"
+	"github.com/go-flac/flacvorbis"
+	"github.com/jmoiron/sqlx"
+	"github.com/jypelle/mifasol/internal/srv/entity"
+	"github.com/jypelle/mifasol/internal/srv/storeerror"
+	"github.com/jypelle/mifasol/internal/tool"
+	"github.com/jypelle/mifasol/restApiV1"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+func (s *Store) ReadSongRatings(externalTrn *sqlx.Tx, filter *restApiV1.SongRatingFilter) ([]restApiV1.SongRating, error) {
+	if s.serverConfig.DebugMode {
+		defer tool.TimeTrack(time.Now(), "ReadSongRatings")
+	}
+
+	var err error
+
+	// Check available transaction
+	txn := externalTrn
+	if txn == nil {
+		txn, err = s.db.Beginx()
+		if err != nil {
+			return nil, err
+		}
+		defer txn.Rollback()
+	}
+
+	queryArgs := make(map[string]interface{})
+	if filter.FromTs != nil {
+		queryArgs["from_ts"] = *filter.FromTs
+	}
+
+	rows, err := txn.NamedQuery(
+		`SELECT
+				r.*
+			FROM song_rating r
+			WHERE 1>0
+			`+tool.TernStr(filter.FromTs != nil, "AND r.update_ts >= :from_ts ", "")+`
+			ORDER BY r.update_ts ASC
+		`,
+		queryArgs,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	songRatings := []restApiV1.SongRating{}
+
+	for rows.Next() {
+		var songRatingEntity entity.SongRatingEntity
+		err = rows.StructScan(&songRatingEntity)
+		if err != nil {
+			return nil, err
+		}
+
+		var songRating restApiV1.SongRating
+		songRatingEntity.Fill(&songRating)
+		songRatings = append(songRatings, songRating)
+	}
+
+	return songRatings, nil
+}
+
+// UpdateSongRating create or replace the rating of a song by a user
+func (s *Store) UpdateSongRating(externalTrn *sqlx.Tx, songRatingMeta *restApiV1.SongRatingMeta) (*restApiV1.SongRating, error) {
+	var err error
+
+	if !restApiV1.IsSongRatingValid(songRatingMeta.Rating) {
+		return nil, storeerror.ErrInvalidSongRating
+	}
+
+	// Check available transaction
+	txn := externalTrn
+	if txn == nil {
+		txn, err = s.db.Beginx()
+		if err != nil {
+			return nil, err
+		}
+		defer txn.Rollback()
+	}
+
+	// Check user & song
+	var count int64
+	err = txn.Get(&count, "SELECT (SELECT count(*) FROM user WHERE user_id = ?) * (SELECT count(*) FROM song WHERE song_id = ?)", songRatingMeta.Id.UserId, songRatingMeta.Id.SongId)
+	if err != nil {
+		return nil, err
+	}
+	if count == 0 {
+		return nil, storeerror.ErrNotFound
+	}
+
+	songRatingEntity := entity.SongRatingEntity{
+		UpdateTs: time.Now().UnixNano(),
+	}
+	songRatingEntity.LoadMeta(songRatingMeta)
+
+	_, err = txn.NamedExec(`
+			INSERT OR REPLACE INTO song_rating (
+			    user_id,
+				song_id,
+				rating,
+			    update_ts
+			)
+			VALUES (
+			    :user_id,
+				:song_id,
+				:rating,
+				:update_ts
+			)
+		`, &songRatingEntity)
+	if err != nil {
+		return nil, err
+	}
+
+	// delete existing deletedSongRating
+	_, err = txn.Exec("DELETE FROM deleted_song_rating WHERE user_id = ? AND song_id = ?", songRatingEntity.UserId, songRatingEntity.SongId)
+	if err != nil {
+		return nil, err
+	}
+
+	// Commit transaction
+	if externalTrn == nil {
+		txn.Commit()
+	}
+
+	// Refresh smart playlists in background
+	s.requestSmartPlaylistsRefresh()
+
+	var songRating restApiV1.SongRating
+	songRatingEntity.Fill(&songRating)
+
+	return &songRating, nil
+}
+
+func (s *Store) DeleteSongRating(externalTrn *sqlx.Tx, songRatingId restApiV1.SongRatingId) (*restApiV1.SongRating, error) {
+	var err error
+
+	// Check available transaction
+	txn := externalTrn
+	if txn == nil {
+		txn, err = s.db.Beginx()
+		if err != nil {
+			return nil, err
+		}
+		defer txn.Rollback()
+	}
+
+	var songRatingEntity entity.SongRatingEntity
+	err = txn.Get(&songRatingEntity, "SELECT * FROM song_rating WHERE user_id = ? AND song_id = ?", songRatingId.UserId, songRatingId.SongId)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, storeerror.ErrNotFound
+		}
+		return nil, err
+	}
+
+	// Delete songRating
+	_, err = txn.Exec("DELETE FROM song_rating WHERE user_id = ? AND song_id = ?", songRatingId.UserId, songRatingId.SongId)
+	if err != nil {
+		return nil, err
+	}
+
+	// Archive songRatingId deletion
+	_, err = txn.NamedExec(`
+			INSERT OR REPLACE INTO deleted_song_rating (
+			    user_id,
+			    song_id,
+				delete_ts
+			)
+			VALUES (
+			    :user_id,
+			    :song_id,
+				:delete_ts
+			)
+	`, &entity.DeletedSongRatingEntity{
+		UserId:   songRatingEntity.UserId,
+		SongId:   songRatingEntity.SongId,
+		DeleteTs: time.Now().UnixNano()})
+	if err != nil {
+		return nil, err
+	}
+
+	// Commit transaction
+	if externalTrn == nil {
+		txn.Commit()
+	}
+
+	// Refresh smart playlists in background
+	s.requestSmartPlaylistsRefresh()
+
+	var songRating restApiV1.SongRating
+	songRatingEntity.Fill(&songRating)
+
+	return &songRating, nil
+}
+
+func (s *Store) GetDeletedSongRatingIds(externalTrn *sqlx.Tx, fromTs int64) ([]restApiV1.SongRatingId, error) {
+	if s.serverConfig.DebugMode {
+		defer tool.TimeTrack(time.Now(), "GetDeletedSongRatingIds")
+	}
+
+	var err error
+
+	// Check available transaction
+	txn := externalTrn
+	if txn == nil {
+		txn, err = s.db.Beginx()
+		if err != nil {
+			return nil, err
+		}
+		defer txn.Rollback()
+	}
+
+	var deletedSongRatingEntities []entity.DeletedSongRatingEntity
+	err = txn.Select(&deletedSongRatingEntities, "SELECT * FROM deleted_song_rating WHERE delete_ts >= ? ORDER BY delete_ts ASC", fromTs)
+	if err != nil {
+		return nil, err
+	}
+
+	songRatingIds := []restApiV1.SongRatingId{}
+	for _, deletedSongRatingEntity := range deletedSongRatingEntities {
+		songRatingIds = append(songRatingIds, restApiV1.SongRatingId{
+			UserId: deletedSongRatingEntity.UserId,
+			SongId: deletedSongRatingEntity.SongId,
+		})
+	}
+
+	return songRatingIds, nil
+}
+
+// extractMp3Rating return the stars of the first rated POPM frame, 0 when unrated.
+// POPM ratings go from 1 to 255, mapped the same way as most players (1, 64, 128, 196 & 255 for 1 to 5 stars)
+func extractMp3Rating(tag *id3v2.Tag) int64 {
+	for _, frame := range tag.GetFrames(tag.CommonID("Popularimeter")) {
+		popmFrame, ok := frame.(id3v2.PopularimeterFrame)
+		if !ok || popmFrame.Rating == 0 {
+			continue
+		}
+		switch {
+		case popmFrame.Rating < 32:
+			return 1
+		case popmFrame.Rating < 96:
+			return 2
+		case popmFrame.Rating < 160:
+			return 3
+		case popmFrame.Rating < 224:
+			return 4
+		default:
+			return 5
+		}
+	}
+	return 0
+}
+
+// extractVorbisRating return the stars of the first RATING vorbis comment, 0 when unrated.
+// Values up to 5 are stars, greater values are percentages
+func extractVorbisRating(cmt *flacvorbis.MetaDataBlockVorbisComment) int64 {
+	values, err := cmt.Get(vorbisFieldRating)
+	if err != nil {
+		return 0
+	}
+	for _, value := range values {
+		rawRating, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if err != nil || rawRating <= 0 || rawRating > 100 {
+			continue
+		}
+		if rawRating > restApiV1.SongRatingMax {
+			rawRating = rawRating * restApiV1.SongRatingMax / 100
+		}
+		return int64(math.Max(math.Round(rawRating), restApiV1.SongRatingMin))
+	}
+	return 0
+}
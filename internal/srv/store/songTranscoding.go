@@ -0,0 +1,179 @@
+package store
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"github.com/jypelle/mifasol/internal/srv/config"
+	"github.com/jypelle/mifasol/internal/srv/storeerror"
+	"github.com/jypelle/mifasol/restApiV1"
+	"github.com/sirupsen/logrus"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// transcodingLock serialize the transcodings of a song with the same profile
+type transcodingLock struct {
+	sync.Mutex
+	users int
+}
+
+// ReadTranscodingProfiles return the transcoding profiles available to stream songs
+func (s *Store) ReadTranscodingProfiles() []restApiV1.TranscodingProfile {
+	transcodingProfiles := []restApiV1.TranscodingProfile{}
+	for _, transcodingProfile := range s.serverConfig.TranscodingProfiles {
+		transcodingProfiles = append(transcodingProfiles, restApiV1.TranscodingProfile{
+			Name:   transcodingProfile.Name,
+			Format: restApiV1.SongFormatFromString(transcodingProfile.Format),
+		})
+	}
+	return transcodingProfiles
+}
+
+// ReadSongTranscodedContent return the song content transcoded with a profile.
+// The song is transcoded on first request and the result cached until the song is updated
+func (s *Store) ReadSongTranscodedContent(song *restApiV1.Song, profileName string) (*os.File, restApiV1.SongFormat, error) {
+	transcodingProfile, ok := s.serverConfig.GetTranscodingProfile(profileName)
+	if !ok {
+		return nil, restApiV1.SongFormatUnknown, storeerror.ErrUnknownTranscoding
+	}
+	format := restApiV1.SongFormatFromString(transcodingProfile.Format)
+
+	transcodedFileName := s.getSongTranscodedFileName(song, transcodingProfile.Name, format)
+
+	unlock := s.lockTranscoding(transcodedFileName)
+	defer unlock()
+
+	file, err := os.Open(transcodedFileName)
+	if os.IsNotExist(err) {
+		err = s.transcodeSong(song, transcodingProfile, transcodedFileName)
+		if err != nil {
+			return nil, restApiV1.SongFormatUnknown, err
+		}
+		file, err = os.Open(transcodedFileName)
+	}
+	if err != nil {
+		return nil, restApiV1.SongFormatUnknown, err
+	}
+
+	return file, format, nil
+}
+
+// getSongTranscodedFileName return the cache file name of a song transcoded with a profile, the song update timestamp invalidate older transcodings
+func (s *Store) getSongTranscodedFileName(song *restApiV1.Song, profileName string, format restApiV1.SongFormat) string {
+	return filepath.Join(s.serverConfig.GetCompleteConfigTranscodingsDirName(), profileName, string(song.Id)+"-"+strconv.FormatInt(song.UpdateTs, 10)+format.Extension())
+}
+
+// lockTranscoding wait for other transcodings to the same file and return the unlock function
+func (s *Store) lockTranscoding(transcodedFileName string) func() {
+	s.transcodingMutex.Lock()
+	lock, ok := s.transcodingLocks[transcodedFileName]
+	if !ok {
+		lock = &transcodingLock{}
+		s.transcodingLocks[transcodedFileName] = lock
+	}
+	lock.users++
+	s.transcodingMutex.Unlock()
+
+	lock.Lock()
+
+	return func() {
+		lock.Unlock()
+
+		s.transcodingMutex.Lock()
+		lock.users--
+		if lock.users == 0 {
+			delete(s.transcodingLocks, transcodedFileName)
+		}
+		s.transcodingMutex.Unlock()
+	}
+}
+
+// transcodeSong run the encoder command of a profile, the output is written in a temporary file then moved to the cache
+func (s *Store) transcodeSong(song *restApiV1.Song, transcodingProfile *config.TranscodingProfile, transcodedFileName string) error {
+	logrus.Debugf("Transcode song %s with profile %s", song.Id, transcodingProfile.Name)
+
+	transcodingDirName := filepath.Dir(transcodedFileName)
+	err := os.MkdirAll(transcodingDirName, 0770)
+	if err != nil {
+		return err
+	}
+
+	// Drop transcodings of previous song versions
+	s.removeSongTranscodedContents(song.Id, transcodingDirName)
+
+	outputFile, err := os.CreateTemp(transcodingDirName, "transcode-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(outputFile.Name())
+	defer outputFile.Close()
+
+	stdoutOutput := true
+	args := make([]string, len(transcodingProfile.Command))
+	for ind, arg := range transcodingProfile.Command {
+		if strings.Contains(arg, "{output}") {
+			stdoutOutput = false
+		}
+		arg = strings.ReplaceAll(arg, "{input}", s.GetSongFileName(song))
+		args[ind] = strings.ReplaceAll(arg, "{output}", outputFile.Name())
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(s.serverConfig.Timeout)*time.Second)
+	defer cancel()
+
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	cmd.Stderr = &stderr
+	if stdoutOutput {
+		cmd.Stdout = outputFile
+	}
+	err = cmd.Run()
+	if err != nil {
+		return fmt.Errorf("transcoding with profile %s failed: %v: %s", transcodingProfile.Name, err, strings.TrimSpace(stderr.String()))
+	}
+
+	err = outputFile.Close()
+	if err != nil {
+		return err
+	}
+	fileInfo, err := os.Stat(outputFile.Name())
+	if err != nil {
+		return err
+	}
+	if fileInfo.Size() == 0 {
+		return fmt.Errorf("transcoding with profile %s failed: empty output", transcodingProfile.Name)
+	}
+
+	return os.Rename(outputFile.Name(), transcodedFileName)
+}
+
+// removeSongTranscodedContents delete the cached transcodings of a song, in all profile folders when transcodingDirName is empty
+func (s *Store) removeSongTranscodedContents(songId restApiV1.SongId, transcodingDirName string) {
+	if transcodingDirName == "" {
+		transcodingDirName = filepath.Join(s.serverConfig.GetCompleteConfigTranscodingsDirName(), "*")
+	}
+	fileNames, _ := filepath.Glob(filepath.Join(transcodingDirName, string(songId)+"-*"))
+	for _, fileName := range fileNames {
+		err := os.Remove(fileName)
+		if err != nil {
+			logrus.Warnf("Unable to remove transcoded content %s: %v", fileName, err)
+		}
+	}
+}
+
+// removeTranscodingLeftovers delete temporary files of transcodings interrupted by a server stop
+func (s *Store) removeTranscodingLeftovers() {
+	fileNames, _ := filepath.Glob(filepath.Join(s.serverConfig.GetCompleteConfigTranscodingsDirName(), "*", "transcode-*"))
+	for _, fileName := range fileNames {
+		err := os.Remove(fileName)
+		if err != nil {
+			logrus.Warnf("Unable to remove transcoding leftover %s: %v", fileName, err)
+		}
+	}
+}
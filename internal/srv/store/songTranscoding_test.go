@@ -0,0 +1,189 @@
+package store
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jypelle/mifasol/internal/srv/config"
+	"github.com/jypelle/mifasol/restApiV1"
+)
+
+// stubEncoderScript log its arguments and copy the input, prefixed with "transcoded:", to the output file given as "--out=<file>"
+// or to the standard output. It fails after writing a partial output when its first argument is "fail"
+const stubEncoderScript = `
+echo "$@" >> "$STUB_ENCODER_LOG"
+if [ "$1" = "fail" ]; then
+	echo partial > "${3#--out=}"
+	echo "stub failure" >&2
+	exit 1
+fi
+if [ -n "$2" ]; then
+	exec > "${2#--out=}"
+fi
+printf "transcoded:"
+cat "$1"
+`
+
+func newTranscodingTestStore(t *testing.T) (*Store, string) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh is required by the stub encoder")
+	}
+
+	serverConfig := config.ServerConfig{ConfigDir: t.TempDir()}
+	serverConfig.ServerEditableConfig = config.NewServerEditableConfig(nil)
+	for _, dirName := range []string{
+		serverConfig.GetCompleteConfigSongsDirName(),
+		serverConfig.GetCompleteConfigAlbumsDirName(),
+		serverConfig.GetCompleteConfigDbDirName(),
+	} {
+		if err := os.MkdirAll(dirName, 0770); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	scriptFileName := filepath.Join(serverConfig.ConfigDir, "encoder.sh")
+	if err := ioutil.WriteFile(scriptFileName, []byte(stubEncoderScript), 0660); err != nil {
+		t.Fatal(err)
+	}
+	logFileName := filepath.Join(serverConfig.ConfigDir, "encoder.log")
+	t.Setenv("STUB_ENCODER_LOG", logFileName)
+
+	serverConfig.TranscodingProfiles = []config.TranscodingProfile{
+		{Name: "file", Format: restApiV1.SongFormatMp3.String(), Command: []string{"sh", scriptFileName, "{input}", "--out={output}"}},
+		{Name: "stdout", Format: restApiV1.SongFormatOgg.String(), Command: []string{"sh", scriptFileName, "{input}"}},
+		{Name: "failing", Format: restApiV1.SongFormatMp3.String(), Command: []string{"sh", scriptFileName, "fail", "{input}", "--out={output}"}},
+	}
+
+	return NewStore(&serverConfig), logFileName
+}
+
+// newTranscodingTestSong store a fake song content, encoders are stubbed so it doesn't need to be decodable
+func newTranscodingTestSong(t *testing.T, s *Store) *restApiV1.Song {
+	song := &restApiV1.Song{Id: "01TRANSCODINGTESTSONG00001", UpdateTs: 1}
+	song.Format = restApiV1.SongFormatFlac
+
+	if err := os.MkdirAll(s.GetSongDirName(song.Id), 0770); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(s.GetSongFileName(song), []byte("song content"), 0660); err != nil {
+		t.Fatal(err)
+	}
+
+	return song
+}
+
+func readEncoderLog(t *testing.T, logFileName string) []string {
+	content, err := ioutil.ReadFile(logFileName)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	return strings.Split(strings.TrimSpace(string(content)), "\n")
+}
+
+func readTranscodedContent(t *testing.T, s *Store, song *restApiV1.Song, profileName string) (string, restApiV1.SongFormat) {
+	file, format, err := s.ReadSongTranscodedContent(song, profileName)
+	if err != nil {
+		t.Fatalf("profile %s: %v", profileName, err)
+	}
+	defer file.Close()
+
+	content, err := ioutil.ReadAll(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(content), format
+}
+
+func TestTranscodingArgumentSubstitution(t *testing.T) {
+	s, logFileName := newTranscodingTestStore(t)
+	song := newTranscodingTestSong(t, s)
+
+	for _, profileName := range []string{"file", "stdout"} {
+		content, _ := readTranscodedContent(t, s, song, profileName)
+		if content != "transcoded:song content" {
+			t.Errorf("profile %s: unexpected transcoded content %q", profileName, content)
+		}
+	}
+
+	encoderCalls := readEncoderLog(t, logFileName)
+	if len(encoderCalls) != 2 {
+		t.Fatalf("expected 2 encoder calls, got %v", encoderCalls)
+	}
+
+	// {input} and {output} are replaced, even inside a longer argument
+	fileArgs := strings.Fields(encoderCalls[0])
+	if len(fileArgs) != 2 || fileArgs[0] != s.GetSongFileName(song) {
+		t.Errorf("unexpected encoder arguments %v", fileArgs)
+	} else {
+		outputFileName := strings.TrimPrefix(fileArgs[1], "--out=")
+		if filepath.Dir(outputFileName) != filepath.Join(s.serverConfig.GetCompleteConfigTranscodingsDirName(), "file") || strings.Contains(outputFileName, "{output}") {
+			t.Errorf("unexpected encoder output argument %s", fileArgs[1])
+		}
+	}
+
+	// Without {output}, the standard output is used
+	stdoutArgs := strings.Fields(encoderCalls[1])
+	if len(stdoutArgs) != 1 || stdoutArgs[0] != s.GetSongFileName(song) {
+		t.Errorf("unexpected encoder arguments %v", stdoutArgs)
+	}
+}
+
+func TestTranscodingCache(t *testing.T) {
+	s, logFileName := newTranscodingTestStore(t)
+	song := newTranscodingTestSong(t, s)
+
+	_, format := readTranscodedContent(t, s, song, "file")
+	if format != restApiV1.SongFormatMp3 {
+		t.Errorf("expected format %s, got %s", restApiV1.SongFormatMp3, format)
+	}
+	readTranscodedContent(t, s, song, "file")
+	if encoderCalls := readEncoderLog(t, logFileName); len(encoderCalls) != 1 {
+		t.Fatalf("expected the cached transcoding to be reused, got %d encoder calls", len(encoderCalls))
+	}
+
+	// A song update invalidate the cache and drop the previous transcoding
+	oldTranscodedFileName := s.getSongTranscodedFileName(song, "file", restApiV1.SongFormatMp3)
+	song.UpdateTs = 2
+	readTranscodedContent(t, s, song, "file")
+	if encoderCalls := readEncoderLog(t, logFileName); len(encoderCalls) != 2 {
+		t.Fatalf("expected a new transcoding after the song update, got %d encoder calls", len(encoderCalls))
+	}
+	if _, err := os.Stat(oldTranscodedFileName); !os.IsNotExist(err) {
+		t.Errorf("expected previous transcoding %s to be removed", oldTranscodedFileName)
+	}
+}
+
+func TestTranscodingFailure(t *testing.T) {
+	s, logFileName := newTranscodingTestStore(t)
+	song := newTranscodingTestSong(t, s)
+
+	_, _, err := s.ReadSongTranscodedContent(song, "failing")
+	if err == nil || !strings.Contains(err.Error(), "stub failure") {
+		t.Fatalf("expected encoder failure, got %v", err)
+	}
+	if encoderCalls := readEncoderLog(t, logFileName); len(encoderCalls) != 1 {
+		t.Fatalf("expected 1 encoder call, got %d", len(encoderCalls))
+	}
+
+	// Neither the partial output nor a cache file are left
+	fileNames, err := filepath.Glob(filepath.Join(s.serverConfig.GetCompleteConfigTranscodingsDirName(), "failing", "*"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fileNames) != 0 {
+		t.Errorf("expected no file left by the failing encoder, got %v", fileNames)
+	}
+
+	// Unknown profiles are rejected
+	_, _, err = s.ReadSongTranscodedContent(song, "unknown")
+	if err == nil {
+		t.Errorf("expected unknown profile to be rejected")
+	}
+}
@@ -0,0 +1,342 @@
+package store
+
+import (
+	"github.com/go-flac/flacvorbis"
+	"github.com/jmoiron/sqlx"
+	"github.com/jypelle/mifasol/internal/srv/entity"
+	"github.com/jypelle/mifasol/restApiV1"
+	"github.com/sirupsen/logrus"
+	"strconv"
+	"strings"
+)
+
+const (
+	vorbisFieldAlbumArtist    = "ALBUMARTIST"
+	vorbisFieldDiscNumber     = "DISCNUMBER"
+	vorbisFieldComposer       = "COMPOSER"
+	vorbisFieldConductor      = "CONDUCTOR"
+	vorbisFieldLyrics         = "LYRICS"
+	vorbisFieldUnsyncedLyrics = "UNSYNCEDLYRICS"
+	vorbisFieldRating         = "RATING"
+)
+
+// fillSongMetaFromVorbisComment extract title, album (with album artists), disc & track number, year, artists (with their roles) and genres from vorbis comments (flac & ogg)
+func (s *Store) fillSongMetaFromVorbisComment(txn *sqlx.Tx, cmt *flacvorbis.MetaDataBlockVorbisComment, lastAlbumId restApiV1.AlbumId, songMeta *restApiV1.SongMeta) error {
+
+	// Extract title
+	titles, err := cmt.Get(flacvorbis.FIELD_TITLE)
+	if err != nil {
+		return err
+	}
+	if len(titles) > 0 {
+		songMeta.Name = titles[0]
+	}
+	logrus.Debugf("Title: %s", songMeta.Name)
+
+	// Extract album name
+	albumName := ""
+	albumNames, err := cmt.Get(flacvorbis.FIELD_ALBUM)
+	if err != nil {
+		return err
+	}
+	if len(albumNames) > 0 {
+		albumName = normalizeString(albumNames[0])
+	}
+
+	// Extract album artists
+	vorbisAlbumArtistNames, err := cmt.Get(vorbisFieldAlbumArtist)
+	if err != nil {
+		return err
+	}
+	var albumArtistNames []string
+	for _, vorbisAlbumArtistName := range vorbisAlbumArtistNames {
+		albumArtistNames = append(albumArtistNames, strings.FieldsFunc(vorbisAlbumArtistName, func(r rune) bool { return r == ',' || r == ';' })...)
+	}
+
+	// Extract artists
+	vorbisArtistNames, err := cmt.Get(flacvorbis.FIELD_ARTIST)
+	if err != nil {
+		return err
+	}
+	var artistNames []string
+	var featuringArtistNames []string
+	for _, vorbisArtistName := range vorbisArtistNames {
+		mainArtistNames, vorbisFeaturingArtistNames := splitFeaturingArtistNames(vorbisArtistName)
+		artistNames = append(artistNames, mainArtistNames...)
+		featuringArtistNames = append(featuringArtistNames, vorbisFeaturingArtistNames...)
+	}
+
+	// Find Album Id
+	songMeta.AlbumId, err = s.getAlbumIdFromAlbumName(txn, albumName, albumArtistNames, artistNames, lastAlbumId)
+	if err != nil {
+		return err
+	}
+
+	logrus.Debugf("Album: %s", albumName)
+	logrus.Debugf("Album artists: %v", albumArtistNames)
+
+	// Extract disc & track number
+	songMeta.DiscNumber = nil
+	songMeta.TrackNumber = nil
+	if songMeta.AlbumId != restApiV1.UnknownAlbumId {
+		discNumbers, err := cmt.Get(vorbisFieldDiscNumber)
+		if err != nil {
+			return err
+		}
+
+		if len(discNumbers) > 0 {
+			parsedDiscNumber, _ := strconv.ParseInt(normalizeString(strings.Split(discNumbers[0], "/")[0]), 10, 64)
+			if parsedDiscNumber > 0 {
+				songMeta.DiscNumber = &parsedDiscNumber
+			}
+		}
+
+		trackNumbers, err := cmt.Get(flacvorbis.FIELD_TRACKNUMBER)
+		if err != nil {
+			return err
+		}
+
+		if len(trackNumbers) > 0 {
+			parsedTrackNumber, _ := strconv.ParseInt(normalizeString(trackNumbers[0]), 10, 64)
+			if parsedTrackNumber > 0 {
+				songMeta.TrackNumber = &parsedTrackNumber
+			}
+		}
+
+	}
+
+	if songMeta.DiscNumber != nil {
+		logrus.Debugf("Disc number: %d", *songMeta.DiscNumber)
+	}
+	if songMeta.TrackNumber != nil {
+		logrus.Debugf("Track number: %d", *songMeta.TrackNumber)
+	}
+
+	// Extract year
+	songMeta.PublicationYear = nil
+	yearNumbers, err := cmt.Get(flacvorbis.FIELD_DATE)
+	if err != nil {
+		return err
+	}
+
+	if len(yearNumbers) > 0 {
+		parsedYearNumber, _ := strconv.ParseInt(normalizeString(yearNumbers[0]), 10, 64)
+		if parsedYearNumber > 0 {
+			songMeta.PublicationYear = &parsedYearNumber
+		}
+	}
+
+	if songMeta.PublicationYear != nil {
+		logrus.Debugf("Publication year: %d", *songMeta.PublicationYear)
+	}
+
+	// Find Artist IDs
+	logrus.Debugf("Find artist ids")
+	songMeta.ArtistIds, err = s.getArtistIdsFromArtistNames(txn, artistNames)
+	if err != nil {
+		return err
+	}
+
+	logrus.Debugf("Artists: %v", artistNames)
+
+	// Extract other credited artists
+	vorbisComposerNames, err := cmt.Get(vorbisFieldComposer)
+	if err != nil {
+		return err
+	}
+	var composerNames []string
+	for _, vorbisComposerName := range vorbisComposerNames {
+		composerNames = append(composerNames, splitArtistNames(vorbisComposerName)...)
+	}
+
+	vorbisPerformerNames, err := cmt.Get(flacvorbis.FIELD_PERFORMER)
+	if err != nil {
+		return err
+	}
+	performerNames := parsePerformerNames(vorbisPerformerNames)
+
+	vorbisConductorNames, err := cmt.Get(vorbisFieldConductor)
+	if err != nil {
+		return err
+	}
+	var conductorNames []string
+	for _, vorbisConductorName := range vorbisConductorNames {
+		conductorNames = append(conductorNames, splitArtistNames(vorbisConductorName)...)
+	}
+
+	songMeta.Artists, err = s.getCreditedSongArtistsFromArtistNames(txn, featuringArtistNames, composerNames, performerNames, conductorNames)
+	if err != nil {
+		return err
+	}
+
+	logrus.Debugf("Featuring: %v, composers: %v, performers: %v, conductors: %v", featuringArtistNames, composerNames, performerNames, conductorNames)
+
+	// Extract genres
+	vorbisGenreNames, err := cmt.Get(flacvorbis.FIELD_GENRE)
+	if err != nil {
+		return err
+	}
+
+	var genreNames []string
+	for _, vorbisGenreName := range vorbisGenreNames {
+		genreNames = append(genreNames, splitGenreNames(vorbisGenreName)...)
+	}
+
+	// Find Genre IDs
+	songMeta.GenreIds, err = s.getGenreIdsFromGenreNames(txn, genreNames)
+	if err != nil {
+		return err
+	}
+
+	logrus.Debugf("Genres: %v", genreNames)
+
+	return nil
+}
+
+// fillVorbisCommentFromSong replace title, album (with album artists), disc & track number, year, artists (with their roles), genres and replay gain vorbis comments with song meta and return the song album
+func (s *Store) fillVorbisCommentFromSong(txn *sqlx.Tx, cmt *flacvorbis.MetaDataBlockVorbisComment, songEntity *entity.SongEntity) (*restApiV1.Album, error) {
+	var err error
+
+	// Set title
+	vorbisClean(cmt, flacvorbis.FIELD_TITLE)
+	cmt.Add(flacvorbis.FIELD_TITLE, songEntity.Name)
+
+	// Set album, disc & track number
+	vorbisClean(cmt, flacvorbis.FIELD_ALBUM)
+	vorbisClean(cmt, vorbisFieldAlbumArtist)
+	vorbisClean(cmt, vorbisFieldDiscNumber)
+	vorbisClean(cmt, flacvorbis.FIELD_TRACKNUMBER)
+	var album *restApiV1.Album
+	if songEntity.AlbumId != restApiV1.UnknownAlbumId {
+		album, err = s.ReadAlbum(txn, songEntity.AlbumId)
+		if err != nil {
+			return nil, err
+		}
+		cmt.Add(flacvorbis.FIELD_ALBUM, album.Name)
+
+		for _, artistId := range album.AlbumArtistIds {
+			artist, err := s.ReadArtist(txn, artistId)
+			if err != nil {
+				return nil, err
+			}
+			cmt.Add(vorbisFieldAlbumArtist, artist.Name)
+		}
+
+		if songEntity.DiscNumber.Valid {
+			cmt.Add(vorbisFieldDiscNumber, strconv.FormatInt(songEntity.DiscNumber.Int64, 10))
+		}
+		if songEntity.TrackNumber.Valid {
+			cmt.Add(flacvorbis.FIELD_TRACKNUMBER, strconv.FormatInt(songEntity.TrackNumber.Int64, 10))
+		}
+	}
+
+	// Set publication date
+	vorbisClean(cmt, flacvorbis.FIELD_DATE)
+	if songEntity.PublicationYear.Valid {
+		cmt.Add(flacvorbis.FIELD_DATE, strconv.FormatInt(songEntity.PublicationYear.Int64, 10))
+	}
+
+	// Set artists, featured artists are appended to main artists in a single comment
+	vorbisClean(cmt, flacvorbis.FIELD_ARTIST)
+	artistNames, err := s.readSongArtistNames(txn, songEntity.SongId, restApiV1.ArtistRoleMain)
+	if err != nil {
+		return nil, err
+	}
+	featuringArtistNames, err := s.readSongArtistNames(txn, songEntity.SongId, restApiV1.ArtistRoleFeaturing)
+	if err != nil {
+		return nil, err
+	}
+	if len(featuringArtistNames) > 0 {
+		cmt.Add(flacvorbis.FIELD_ARTIST, formatArtistNames(artistNames, featuringArtistNames))
+	} else {
+		for _, artistName := range artistNames {
+			cmt.Add(flacvorbis.FIELD_ARTIST, artistName)
+		}
+	}
+
+	// Set other credited artists
+	for _, credit := range []struct {
+		field string
+		role  restApiV1.ArtistRole
+	}{
+		{vorbisFieldComposer, restApiV1.ArtistRoleComposer},
+		{flacvorbis.FIELD_PERFORMER, restApiV1.ArtistRolePerformer},
+		{vorbisFieldConductor, restApiV1.ArtistRoleConductor},
+	} {
+		vorbisClean(cmt, credit.field)
+		creditedArtistNames, err := s.readSongArtistNames(txn, songEntity.SongId, credit.role)
+		if err != nil {
+			return nil, err
+		}
+		for _, creditedArtistName := range creditedArtistNames {
+			cmt.Add(credit.field, creditedArtistName)
+		}
+	}
+
+	// Set genres
+	vorbisClean(cmt, flacvorbis.FIELD_GENRE)
+	genreNames, err := s.readSongGenreNames(txn, songEntity)
+	if err != nil {
+		return nil, err
+	}
+	for _, genreName := range genreNames {
+		cmt.Add(flacvorbis.FIELD_GENRE, genreName)
+	}
+
+	// Set lyrics
+	vorbisClean(cmt, vorbisFieldLyrics)
+	vorbisClean(cmt, vorbisFieldUnsyncedLyrics)
+	if songEntity.LyricsFg {
+		cmt.Add(vorbisFieldLyrics, songEntity.Lyrics)
+	}
+
+	// Set replay gain, existing tags are kept until the song is analysed
+	if songEntity.Loudness.Valid {
+		vorbisClean(cmt, replayGainTrackGain)
+		vorbisClean(cmt, replayGainTrackPeak)
+		cmt.Add(replayGainTrackGain, formatReplayGain(songEntity.Loudness.Float64))
+		cmt.Add(replayGainTrackPeak, formatReplayGainPeak(songEntity.TruePeak.Float64))
+
+		vorbisClean(cmt, replayGainAlbumGain)
+		vorbisClean(cmt, replayGainAlbumPeak)
+		if album != nil && album.Loudness != nil {
+			cmt.Add(replayGainAlbumGain, formatReplayGain(*album.Loudness))
+			cmt.Add(replayGainAlbumPeak, formatReplayGainPeak(*album.TruePeak))
+		}
+	}
+
+	return album, nil
+}
+
+// extractVorbisLyrics return the lyrics of LYRICS or UNSYNCEDLYRICS vorbis comments
+func extractVorbisLyrics(cmt *flacvorbis.MetaDataBlockVorbisComment) string {
+	for _, field := range []string{vorbisFieldLyrics, vorbisFieldUnsyncedLyrics} {
+		values, err := cmt.Get(field)
+		if err != nil {
+			continue
+		}
+		for _, value := range values {
+			if lyrics := cleanTagLyrics(value); lyrics != "" {
+				return lyrics
+			}
+		}
+	}
+	return ""
+}
+
+// vorbisClean remove all comments with field name specified by the key parameter
+func vorbisClean(c *flacvorbis.MetaDataBlockVorbisComment, key string) error {
+	res := make([]string, 0)
+	for _, cmt := range c.Comments {
+		p := strings.SplitN(cmt, "=", 2)
+		if len(p) != 2 {
+			return flacvorbis.ErrorMalformedComment
+		}
+		if !strings.EqualFold(p[0], key) {
+			res = append(res, cmt)
+		}
+	}
+	c.Comments = res
+
+	return nil
+}
@@ -0,0 +1,164 @@
+package store
+
+import (
+	"database/sql"
+	"github.com/jmoiron/sqlx"
+	"github.com/jypelle/mifasol/internal/srv/entity"
+	"github.com/jypelle/mifasol/internal/srv/storeerror"
+	"github.com/jypelle/mifasol/restApiV1"
+	"github.com/sirupsen/logrus"
+	"io"
+	"math"
+	"os"
+	"time"
+)
+
+// computeSongWaveform decode the song content to compute the peak amplitude of each song slice, ok is false when it can't be decoded
+func computeSongWaveform(format restApiV1.SongFormat, reader io.ReadCloser, duration int64) (peaks []byte, ok bool, err error) {
+	streamer, streamFormat, ok, err := decodeSongContent(format, reader)
+	if !ok {
+		return nil, false, err
+	}
+	defer streamer.Close()
+
+	// Sample count is used to slice the song, fallback to the duration extracted on import
+	sampleCount := int64(streamer.Len())
+	if sampleCount <= 0 {
+		sampleCount = int64(streamFormat.SampleRate.N(time.Duration(duration) * time.Millisecond))
+	}
+	if sampleCount <= 0 {
+		return nil, false, nil
+	}
+
+	pointCount := int64(restApiV1.SongWaveformMaxPoints)
+	if sampleCount < pointCount {
+		pointCount = sampleCount
+	}
+
+	amplitudes := make([]float64, pointCount)
+	samples := make([][2]float64, 4096)
+	var position int64
+	for {
+		n, more := streamer.Stream(samples)
+		for _, sample := range samples[:n] {
+			ind := position * pointCount / sampleCount
+			if ind >= pointCount {
+				ind = pointCount - 1
+			}
+			amplitudes[ind] = math.Max(amplitudes[ind], math.Max(math.Abs(sample[0]), math.Abs(sample[1])))
+			position++
+		}
+		if !more {
+			break
+		}
+	}
+	if streamer.Err() != nil {
+		return nil, false, streamer.Err()
+	}
+
+	peaks = make([]byte, pointCount)
+	for ind, amplitude := range amplitudes {
+		peaks[ind] = byte(math.Round(math.Min(amplitude, 1) * 255))
+	}
+
+	return peaks, true, nil
+}
+
+// analyzeSongsWaveform compute the waveform of songs without one
+func (s *Store) analyzeSongsWaveform() error {
+	songEntities := []entity.SongEntity{}
+	err := s.db.Select(&songEntities, "SELECT * FROM song WHERE song_id NOT IN (SELECT song_id FROM song_waveform)")
+	if err != nil {
+		return err
+	}
+
+	if len(songEntities) == 0 {
+		return nil
+	}
+
+	logrus.Printf("Computing waveform of %d songs ...", len(songEntities))
+
+	for _, songEntity := range songEntities {
+		// Decoding is done outside of any transaction
+		var peaks []byte
+		file, err := os.Open(s.getSongFileName(songEntity.SongId, songEntity.Format))
+		if err == nil {
+			peaks, _, err = computeSongWaveform(songEntity.Format, file, songEntity.Duration)
+			file.Close()
+		}
+		if err != nil {
+			logrus.Warnf("Unable to compute song %s waveform: %v", songEntity.SongId, err)
+		}
+
+		// An empty waveform is stored for songs that can't be decoded
+		if peaks == nil {
+			peaks = []byte{}
+		}
+
+		// Song may have been deleted in the meantime
+		_, err = s.db.Exec(
+			"INSERT OR REPLACE INTO song_waveform (song_id, peaks, update_ts) SELECT song_id, ?, ? FROM song WHERE song_id = ?",
+			peaks,
+			time.Now().UnixNano(),
+			songEntity.SongId,
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	logrus.Printf("Waveform of %d songs computed", len(songEntities))
+
+	return nil
+}
+
+// ReadSongWaveform return the song waveform downsampled to the given number of points
+func (s *Store) ReadSongWaveform(externalTrn *sqlx.Tx, songId restApiV1.SongId, pointCount int) (*restApiV1.SongWaveform, error) {
+	var err error
+
+	// Check available transaction
+	txn := externalTrn
+	if txn == nil {
+		txn, err = s.db.Beginx()
+		if err != nil {
+			return nil, err
+		}
+		defer txn.Rollback()
+	}
+
+	// Waveform may not be computed yet
+	var songWaveformEntity entity.SongWaveformEntity
+	err = txn.Get(&songWaveformEntity, "SELECT * FROM song_waveform WHERE song_id = ?", songId)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, storeerror.ErrNotFound
+		}
+		return nil, err
+	}
+	if len(songWaveformEntity.Peaks) == 0 {
+		return nil, storeerror.ErrNotFound
+	}
+
+	return &restApiV1.SongWaveform{
+		SongId:   songId,
+		UpdateTs: songWaveformEntity.UpdateTs,
+		Peaks:    downsamplePeaks(songWaveformEntity.Peaks, pointCount),
+	}, nil
+}
+
+// downsamplePeaks return the highest peak of each group of peaks, to get pointCount points at most
+func downsamplePeaks(peaks []byte, pointCount int) []int {
+	if pointCount <= 0 || pointCount > len(peaks) {
+		pointCount = len(peaks)
+	}
+
+	points := make([]int, pointCount)
+	for ind := range points {
+		for _, peak := range peaks[ind*len(peaks)/pointCount : (ind+1)*len(peaks)/pointCount] {
+			if int(peak) > points[ind] {
+				points[ind] = int(peak)
+			}
+		}
+	}
+	return points
+}
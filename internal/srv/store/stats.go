@@ -0,0 +1,180 @@
+package store
+
+import (
+	"github.com/jmoiron/sqlx"
+	"github.com/jypelle/mifasol/internal/srv/storeerror"
+	"github.com/jypelle/mifasol/internal/tool"
+	"github.com/jypelle/mifasol/restApiV1"
+	"strconv"
+	"time"
+)
+
+// playStatsRow is a group of plays: its key followed by play count & listened duration
+type playStatsRow struct {
+	key       string
+	playStats restApiV1.PlayStats
+}
+
+// ReadUserStats compute the listening statistics of a user from plays started between fromTs (included) and toTs (excluded)
+func (s *Store) ReadUserStats(externalTrn *sqlx.Tx, userId restApiV1.UserId, fromTs *int64, toTs *int64) (*restApiV1.UserStats, error) {
+	if s.serverConfig.DebugMode {
+		defer tool.TimeTrack(time.Now(), "ReadUserStats")
+	}
+
+	var err error
+
+	// Check available transaction
+	txn := externalTrn
+	if txn == nil {
+		txn, err = s.db.Beginx()
+		if err != nil {
+			return nil, err
+		}
+		defer txn.Rollback()
+	}
+
+	var userCount int64
+	err = txn.Get(&userCount, "SELECT count(*) FROM user WHERE user_id = ?", userId)
+	if err != nil {
+		return nil, err
+	}
+	if userCount == 0 {
+		return nil, storeerror.ErrNotFound
+	}
+
+	queryArgs := map[string]interface{}{
+		"user_id":          userId,
+		"top_count":        restApiV1.StatsTopCount,
+		"main_role":        restApiV1.ArtistRoleMain,
+		"unknown_album_id": restApiV1.UnknownAlbumId,
+	}
+	if fromTs != nil {
+		queryArgs["from_ts"] = *fromTs
+	}
+	if toTs != nil {
+		queryArgs["to_ts"] = *toTs
+	}
+	playConditions := `p.user_id = :user_id
+			` + tool.TernStr(fromTs != nil, "AND p.start_ts >= :from_ts ", "") + `
+			` + tool.TernStr(toTs != nil, "AND p.start_ts < :to_ts ", "")
+
+	userStats := &restApiV1.UserStats{
+		UserId:     userId,
+		TopSongs:   []restApiV1.SongStats{},
+		TopArtists: []restApiV1.ArtistStats{},
+		TopAlbums:  []restApiV1.AlbumStats{},
+		Days:       []restApiV1.DayStats{},
+		Formats:    []restApiV1.FormatStats{},
+	}
+
+	// Totals
+	rows, err := selectPlayStats(txn, `
+			SELECT '', count(*), coalesce(sum(p.listened_duration), 0)
+			FROM play_event p
+			WHERE `+playConditions, queryArgs)
+	if err != nil {
+		return nil, err
+	}
+	userStats.PlayStats = rows[0].playStats
+
+	// Top songs
+	rows, err = selectPlayStats(txn, `
+			SELECT p.song_id, count(*) AS play_count, sum(p.listened_duration) AS listened_duration
+			FROM play_event p
+			WHERE `+playConditions+`
+			GROUP BY p.song_id
+			ORDER BY play_count DESC, listened_duration DESC, p.song_id
+			LIMIT :top_count`, queryArgs)
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		userStats.TopSongs = append(userStats.TopSongs, restApiV1.SongStats{SongId: restApiV1.SongId(row.key), PlayStats: row.playStats})
+	}
+
+	// Top artists, a play count for each main artist of the song
+	rows, err = selectPlayStats(txn, `
+			SELECT asg.artist_id, count(*) AS play_count, sum(p.listened_duration) AS listened_duration
+			FROM play_event p
+			JOIN artist_song asg ON asg.song_id = p.song_id AND asg.role = :main_role
+			WHERE `+playConditions+`
+			GROUP BY asg.artist_id
+			ORDER BY play_count DESC, listened_duration DESC, asg.artist_id
+			LIMIT :top_count`, queryArgs)
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		userStats.TopArtists = append(userStats.TopArtists, restApiV1.ArtistStats{ArtistId: restApiV1.ArtistId(row.key), PlayStats: row.playStats})
+	}
+
+	// Top albums
+	rows, err = selectPlayStats(txn, `
+			SELECT s.album_id, count(*) AS play_count, sum(p.listened_duration) AS listened_duration
+			FROM play_event p
+			JOIN song s ON s.song_id = p.song_id
+			WHERE `+playConditions+`
+			AND s.album_id <> :unknown_album_id
+			GROUP BY s.album_id
+			ORDER BY play_count DESC, listened_duration DESC, s.album_id
+			LIMIT :top_count`, queryArgs)
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		userStats.TopAlbums = append(userStats.TopAlbums, restApiV1.AlbumStats{AlbumId: restApiV1.AlbumId(row.key), PlayStats: row.playStats})
+	}
+
+	// Listening time per day, in server local time
+	rows, err = selectPlayStats(txn, `
+			SELECT date(p.start_ts / 1000000000, 'unixepoch', 'localtime') AS day, count(*), sum(p.listened_duration)
+			FROM play_event p
+			WHERE `+playConditions+`
+			GROUP BY day
+			ORDER BY day`, queryArgs)
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		userStats.Days = append(userStats.Days, restApiV1.DayStats{Day: row.key, PlayStats: row.playStats})
+	}
+
+	// Format mix
+	rows, err = selectPlayStats(txn, `
+			SELECT s.format, count(*) AS play_count, sum(p.listened_duration) AS listened_duration
+			FROM play_event p
+			JOIN song s ON s.song_id = p.song_id
+			WHERE `+playConditions+`
+			GROUP BY s.format
+			ORDER BY play_count DESC, listened_duration DESC, s.format`, queryArgs)
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		format, _ := strconv.ParseInt(row.key, 10, 64)
+		userStats.Formats = append(userStats.Formats, restApiV1.FormatStats{Format: restApiV1.SongFormat(format), PlayStats: row.playStats})
+	}
+
+	return userStats, nil
+}
+
+// selectPlayStats run a named query returning groups of plays
+func selectPlayStats(txn *sqlx.Tx, query string, queryArgs map[string]interface{}) ([]playStatsRow, error) {
+	rows, err := txn.NamedQuery(query, queryArgs)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var playStatsRows []playStatsRow
+	for rows.Next() {
+		var row playStatsRow
+		err = rows.Scan(&row.key, &row.playStats.PlayCount, &row.playStats.ListenedDuration)
+		if err != nil {
+			return nil, err
+		}
+		playStatsRows = append(playStatsRows, row)
+	}
+
+	return playStatsRows, rows.Err()
+}
@@ -7,11 +7,19 @@ import (
 	"github.com/jypelle/mifasol/restApiV1"
 	"github.com/sirupsen/logrus"
 	"os"
+	"sync"
 )
 
 type Store struct {
 	db           *sqlx.DB
 	serverConfig *config.ServerConfig
+
+	songAnalysisSignal          chan struct{}
+	scrobbleSignal              chan struct{}
+	smartPlaylistsRefreshSignal chan struct{}
+
+	transcodingMutex sync.Mutex
+	transcodingLocks map[string]*transcodingLock
 }
 
 func NewStore(serverConfig *config.ServerConfig) *Store {
@@ -26,8 +34,12 @@ func NewStore(serverConfig *config.ServerConfig) *Store {
 	db.SetMaxOpenConns(1)
 
 	store := &Store{
-		db:           db,
-		serverConfig: serverConfig,
+		db:                          db,
+		serverConfig:                serverConfig,
+		songAnalysisSignal:          make(chan struct{}, 1),
+		scrobbleSignal:              make(chan struct{}, 1),
+		smartPlaylistsRefreshSignal: make(chan struct{}, 1),
+		transcodingLocks:            make(map[string]*transcodingLock),
 	}
 
 	// Execute database migration scripts
@@ -81,6 +93,34 @@ func NewStore(serverConfig *config.ServerConfig) *Store {
 		logrus.Printf("No admin user found: the default user/password 'mifasol/mifasol' has been created ...")
 	}
 
+	// Remove uploads interrupted by a server stop
+	store.removeUploadLeftovers()
+	store.removeTranscodingLeftovers()
+
+	// Compute audio properties of songs imported with an older version
+	err = store.refreshSongsAudioProperties()
+	if err != nil {
+		logrus.Fatalf("Unable to compute songs audio properties: %v", err)
+	}
+
+	// Compute content hash of songs imported with an older version
+	err = store.refreshSongsContentHash()
+	if err != nil {
+		logrus.Fatalf("Unable to compute songs content hash: %v", err)
+	}
+
+	// Analyse loudness & waveform of new songs in background
+	go store.runSongAnalysisJob()
+	store.requestSongAnalysis()
+
+	// Forward listens to scrobbling services in background
+	go store.runScrobbleForwardingJob()
+	store.requestScrobbleForwarding()
+
+	// Evaluate smart playlists rules in background
+	go store.runSmartPlaylistsRefreshJob()
+	store.requestSmartPlaylistsRefresh()
+
 	return store
 }
 
@@ -14,6 +14,7 @@ func (s *Store) ReadSyncReport(fromTs int64) (*restApiV1.SyncReport, error) {
 	var syncReport restApiV1.SyncReport
 
 	var err error
+
 	var txn *sqlx.Tx
 	// Force db write lock to avoid sync timestamp overlap
 	txn, err = s.db.Beginx()
@@ -55,6 +56,16 @@ func (s *Store) ReadSyncReport(fromTs int64) (*restApiV1.SyncReport, error) {
 		return nil, errors.New("Unable to read deleted artist ids: " + err.Error())
 	}
 
+	// Genres
+	syncReport.Genres, err = s.ReadGenres(txn, &restApiV1.GenreFilter{FromTs: &fromTs})
+	if err != nil {
+		return nil, errors.New("Unable to read genres: " + err.Error())
+	}
+	syncReport.DeletedGenreIds, err = s.GetDeletedGenreIds(txn, fromTs)
+	if err != nil {
+		return nil, errors.New("Unable to read deleted genre ids: " + err.Error())
+	}
+
 	// Playlists
 	syncReport.Playlists, err = s.ReadPlaylists(txn, &restApiV1.PlaylistFilter{FromTs: &fromTs})
 	if err != nil {
@@ -65,6 +76,16 @@ func (s *Store) ReadSyncReport(fromTs int64) (*restApiV1.SyncReport, error) {
 		return nil, errors.New("Unable to read deleted playlist ids: " + err.Error())
 	}
 
+	// Playlist folders
+	syncReport.PlaylistFolders, err = s.ReadPlaylistFolders(txn, &restApiV1.PlaylistFolderFilter{FromTs: &fromTs})
+	if err != nil {
+		return nil, errors.New("Unable to read playlist folders: " + err.Error())
+	}
+	syncReport.DeletedPlaylistFolderIds, err = s.GetDeletedPlaylistFolderIds(txn, fromTs)
+	if err != nil {
+		return nil, errors.New("Unable to read deleted playlist folder ids: " + err.Error())
+	}
+
 	// Users
 	syncReport.Users, err = s.ReadUsers(txn, &restApiV1.UserFilter{FromTs: &fromTs})
 	if err != nil {
@@ -95,6 +116,16 @@ func (s *Store) ReadSyncReport(fromTs int64) (*restApiV1.SyncReport, error) {
 		return nil, errors.New("Unable to read deleted favorite song ids: " + err.Error())
 	}
 
+	// Song ratings
+	syncReport.SongRatings, err = s.ReadSongRatings(txn, &restApiV1.SongRatingFilter{FromTs: &fromTs})
+	if err != nil {
+		return nil, errors.New("Unable to read song ratings: " + err.Error())
+	}
+	syncReport.DeletedSongRatingIds, err = s.GetDeletedSongRatingIds(txn, fromTs)
+	if err != nil {
+		return nil, errors.New("Unable to read deleted song rating ids: " + err.Error())
+	}
+
 	return &syncReport, nil
 }
 
@@ -135,6 +166,12 @@ func (s *Store) ReadFileSyncReport(fromTs int64, userId restApiV1.UserId) (*rest
 		logrus.Panicf("Unable to read deleted playlist ids: %v", err)
 	}
 
+	// Playlist folders
+	fileSyncReport.PlaylistFolders, err = s.ReadPlaylistFolders(txn, &restApiV1.PlaylistFolderFilter{})
+	if err != nil {
+		logrus.Panicf("Unable to read playlist folders: %v", err)
+	}
+
 	return &fileSyncReport, nil
 }
 
@@ -156,6 +193,8 @@ func (s *Store) ReadFileSyncSongs(externalTrn *sqlx.Tx, favoriteFromTs int64, fa
 		return nil, err
 	}
 
+	albumDiscCounts := make(map[restApiV1.AlbumId]int64)
+
 	for _, song := range songs {
 		var fileSyncSong restApiV1.FileSyncSong
 
@@ -186,6 +225,23 @@ func (s *Store) ReadFileSyncSongs(externalTrn *sqlx.Tx, favoriteFromTs int64, fa
 
 			fileSyncSong.Filepath += tool.SanitizeFilename(album.Name) + "/"
 
+			// Multi-disc albums have a sub folder per disc
+			discCount, ok := albumDiscCounts[song.AlbumId]
+			if !ok {
+				discCount, err = s.countAlbumDiscs(txn, song.AlbumId)
+				if err != nil {
+					return nil, err
+				}
+				albumDiscCounts[song.AlbumId] = discCount
+			}
+			if discCount > 1 {
+				discNumber := int64(1)
+				if song.DiscNumber != nil {
+					discNumber = *song.DiscNumber
+				}
+				fileSyncSong.Filepath += fmt.Sprintf("CD%d/", discNumber)
+			}
+
 			if song.TrackNumber != nil {
 				fileSyncSong.Filepath += fmt.Sprintf("%02d - ", *song.TrackNumber)
 			}
@@ -261,6 +261,9 @@ func (s *Store) DeleteUser(externalTrn *sqlx.Tx, userId restApiV1.UserId) (*rest
 	var userEntity entity.UserEntity
 	err = txn.Get(&userEntity, `SELECT * FROM user WHERE user_id = ?`, userId)
 	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, storeerror.ErrNotFound
+		}
 		return nil, err
 	}
 
@@ -325,6 +328,48 @@ func (s *Store) DeleteUser(externalTrn *sqlx.Tx, userId restApiV1.UserId) (*rest
 		return nil, err
 	}
 
+	// Delete user's song ratings
+	queryArgs = make(map[string]interface{})
+	queryArgs["delete_ts"] = deleteTs
+	queryArgs["user_id"] = userId
+	_, err = txn.NamedExec(`
+			INSERT OR REPLACE INTO deleted_song_rating (
+			    user_id,
+			    song_id,
+				delete_ts
+			)
+			SELECT
+			    user_id,
+			    song_id,
+				:delete_ts
+			FROM song_rating
+			WHERE user_id = :user_id
+	`, queryArgs)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = txn.Exec("DELETE FROM song_rating WHERE user_id = ?", userId)
+	if err != nil {
+		return nil, err
+	}
+
+	// Delete user's play history
+	_, err = txn.Exec(`DELETE FROM play_event WHERE user_id = ?`, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	// Delete user's scrobbling settings & listens not yet forwarded
+	_, err = txn.Exec(`DELETE FROM scrobbling WHERE user_id = ?`, userId)
+	if err != nil {
+		return nil, err
+	}
+	_, err = txn.Exec(`DELETE FROM scrobble WHERE user_id = ?`, userId)
+	if err != nil {
+		return nil, err
+	}
+
 	// Delete user
 	_, err = txn.Exec(`DELETE FROM user WHERE user_id = ?`, userId)
 	if err != nil {
@@ -351,6 +396,9 @@ func (s *Store) DeleteUser(externalTrn *sqlx.Tx, userId restApiV1.UserId) (*rest
 		txn.Commit()
 	}
 
+	// Refresh smart playlists in background
+	s.requestSmartPlaylistsRefresh()
+
 	var user restApiV1.User
 	userEntity.Fill(&user)
 
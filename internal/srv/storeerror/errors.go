@@ -3,7 +3,20 @@ package storeerror
 import "errors"
 
 var (
-	ErrDeleteArtistWithSongs = errors.New("Unable to delete an artist linked to songs")
-	ErrDeleteAlbumWithSongs  = errors.New("Unable to delete an album linked to songs")
-	ErrNotFound              = errors.New("Unable to find the item")
+	ErrDeleteArtistWithSongs           = errors.New("Unable to delete an artist linked to songs")
+	ErrDeleteGenreWithSongs            = errors.New("Unable to delete a genre linked to songs")
+	ErrDeleteAlbumWithSongs            = errors.New("Unable to delete an album linked to songs")
+	ErrNotFound                        = errors.New("Unable to find the item")
+	ErrInvalidAlbumCover               = errors.New("Album cover must be a jpeg or png image")
+	ErrInvalidArtistPicture            = errors.New("Artist picture must be a jpeg or png image")
+	ErrDuplicateSong                   = errors.New("Song already present")
+	ErrInvalidSongLyrics               = errors.New("Song lyrics must be utf-8 plain text or lrc")
+	ErrInvalidPage                     = errors.New("Invalid page limit or cursor")
+	ErrUnknownTranscoding              = errors.New("Unknown transcoding profile")
+	ErrInvalidPlayEvent                = errors.New("Play event must have a positive listened duration")
+	ErrInvalidScrobbling               = errors.New("Scrobbling settings must have a known service, an http(s) api root url and the service credentials")
+	ErrInvalidSongRating               = errors.New("Song rating must be from 1 to 5 stars")
+	ErrInvalidPlaylistFolder           = errors.New("Playlist folder must have a name and an existing parent folder that is not inside it")
+	ErrDeletePlaylistFolderWithContent = errors.New("Unable to delete a playlist folder containing playlists or folders")
+	ErrInvalidSmartPlaylist            = errors.New("Smart playlist rules must have a valid year range, rating & counts, and a user for the favorite, rating & play count rules")
 )
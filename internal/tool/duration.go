@@ -0,0 +1,12 @@
+package tool
+
+import "fmt"
+
+// FormatDuration format a duration expressed in milliseconds as h:mm:ss or m:ss
+func FormatDuration(duration int64) string {
+	seconds := duration / 1000
+	if seconds >= 3600 {
+		return fmt.Sprintf("%d:%02d:%02d", seconds/3600, (seconds/60)%60, seconds%60)
+	}
+	return fmt.Sprintf("%d:%02d", seconds/60, seconds%60)
+}
@@ -28,6 +28,18 @@ func DeduplicateArtistId(slice []restApiV1.ArtistId) []restApiV1.ArtistId {
 	return list
 }
 
+func DeduplicateGenreId(slice []restApiV1.GenreId) []restApiV1.GenreId {
+	keys := make(map[restApiV1.GenreId]bool)
+	list := []restApiV1.GenreId{}
+	for _, entry := range slice {
+		if _, value := keys[entry]; !value {
+			keys[entry] = true
+			list = append(list, entry)
+		}
+	}
+	return list
+}
+
 func DeduplicateUserId(slice []restApiV1.UserId) []restApiV1.UserId {
 	keys := make(map[restApiV1.UserId]bool)
 	list := []restApiV1.UserId{}
@@ -0,0 +1,51 @@
+package tool
+
+import (
+	"github.com/jypelle/mifasol/restApiV1"
+	"strconv"
+	"time"
+)
+
+// StatsPeriod is a period of listening statistics offered by clients
+type StatsPeriod int64
+
+const (
+	StatsPeriodLast30Days StatsPeriod = iota
+	StatsPeriodThisYear
+	StatsPeriodLastYear
+	StatsPeriodAllTime
+)
+
+var StatsPeriods = []StatsPeriod{
+	StatsPeriodLast30Days,
+	StatsPeriodThisYear,
+	StatsPeriodLastYear,
+	StatsPeriodAllTime,
+}
+
+func (p StatsPeriod) Label(now time.Time) string {
+	switch p {
+	case StatsPeriodLast30Days:
+		return "Last 30 days"
+	case StatsPeriodThisYear:
+		return "This year (" + strconv.Itoa(now.Year()) + ")"
+	case StatsPeriodLastYear:
+		return "Year in review " + strconv.Itoa(now.Year()-1)
+	case StatsPeriodAllTime:
+		return "All time"
+	}
+	return ""
+}
+
+// Bounds return the first and last days of the period, formatted for the stats endpoint, empty when unbounded
+func (p StatsPeriod) Bounds(now time.Time) (from string, to string) {
+	switch p {
+	case StatsPeriodLast30Days:
+		return now.AddDate(0, 0, -29).Format(restApiV1.StatsDateLayout), now.Format(restApiV1.StatsDateLayout)
+	case StatsPeriodThisYear:
+		return strconv.Itoa(now.Year()) + "-01-01", ""
+	case StatsPeriodLastYear:
+		return strconv.Itoa(now.Year()-1) + "-01-01", strconv.Itoa(now.Year()-1) + "-12-31"
+	}
+	return "", ""
+}
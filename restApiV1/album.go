@@ -9,15 +9,21 @@ type Album struct {
 	Id         AlbumId    `json:"id"`
 	CreationTs int64      `json:"creationTs"`
 	UpdateTs   int64      `json:"updateTs"`
-	ArtistIds  []ArtistId `json:"artistIds"`
+	ArtistIds  []ArtistId `json:"artistIds"` // Explicit album artists or, when there is none, main artists of the album songs
+	CoverFg    bool       `json:"coverFg"`
+	Loudness   *float64   `json:"loudness"` // Integrated loudness in LUFS (EBU R128) of all the album songs
+	TruePeak   *float64   `json:"truePeak"` // True peak, linear scale
 	AlbumMeta
 }
 
 type AlbumMeta struct {
-	Name string `json:"name"`
+	Name           string     `json:"name"`
+	AlbumArtistIds []ArtistId `json:"albumArtistIds"` // Explicit album artists
 }
 
 func (a *AlbumMeta) Copy() *AlbumMeta {
 	var newAlbumMeta = *a
+	newAlbumMeta.AlbumArtistIds = make([]ArtistId, len(a.AlbumArtistIds))
+	copy(newAlbumMeta.AlbumArtistIds, a.AlbumArtistIds)
 	return &newAlbumMeta
 }
@@ -6,18 +6,48 @@ var UnknownArtistId ArtistId = "00000000000000000000000000"
 
 type ArtistId string
 
+type ArtistRole string
+
+const (
+	ArtistRoleMain      ArtistRole = "main"
+	ArtistRoleFeaturing ArtistRole = "featuring"
+	ArtistRoleComposer  ArtistRole = "composer"
+	ArtistRolePerformer ArtistRole = "performer"
+	ArtistRoleConductor ArtistRole = "conductor"
+)
+
+// IsValid check the role is a known one
+func (r ArtistRole) IsValid() bool {
+	switch r {
+	case ArtistRoleMain, ArtistRoleFeaturing, ArtistRoleComposer, ArtistRolePerformer, ArtistRoleConductor:
+		return true
+	}
+	return false
+}
+
 type Artist struct {
 	Id         ArtistId `json:"id"`
 	CreationTs int64    `json:"creationTs"`
 	UpdateTs   int64    `json:"updateTs"`
+	PictureFg  bool     `json:"pictureFg"`
 	ArtistMeta
 }
 
 type ArtistMeta struct {
-	Name string `json:"name"`
+	Name      string `json:"name"`
+	SortName  string `json:"sortName"`
+	Biography string `json:"biography"`
 }
 
 func (a *ArtistMeta) Copy() *ArtistMeta {
 	var newArtistMeta = *a
 	return &newArtistMeta
 }
+
+// GetSortName return the name used to sort artists
+func (a *ArtistMeta) GetSortName() string {
+	if a.SortName != "" {
+		return a.SortName
+	}
+	return a.Name
+}
@@ -15,10 +15,21 @@ const (
 	UnsupportedGrantTypeErrorCode ErrorCode = "unsupported_grant_type"
 	InvalideGrantErrorCode        ErrorCode = "invalid_grant"
 
-	DeleteArtistWithSongsErrorCode  ErrorCode = "delete_artist_with_songs"
-	DeleteAlbumWithSongsErrorCode   ErrorCode = "delete_album_with_songs"
-	DeleteUserYourselfErrorCode     ErrorCode = "delete_user_yourself"
-	CreateNotOwnedPlaylistErrorCode ErrorCode = "create_not_owned_playlist"
+	DeleteArtistWithSongsErrorCode           ErrorCode = "delete_artist_with_songs"
+	DeleteAlbumWithSongsErrorCode            ErrorCode = "delete_album_with_songs"
+	DeleteGenreWithSongsErrorCode            ErrorCode = "delete_genre_with_songs"
+	DeleteUserYourselfErrorCode              ErrorCode = "delete_user_yourself"
+	CreateNotOwnedPlaylistErrorCode          ErrorCode = "create_not_owned_playlist"
+	InvalidAlbumCoverErrorCode               ErrorCode = "invalid_album_cover"
+	InvalidArtistPictureErrorCode            ErrorCode = "invalid_artist_picture"
+	DuplicateSongErrorCode                   ErrorCode = "duplicate_song"
+	InvalidSongLyricsErrorCode               ErrorCode = "invalid_song_lyrics"
+	UnknownTranscodingProfileErrorCode       ErrorCode = "unknown_transcoding_profile"
+	InvalidScrobblingErrorCode               ErrorCode = "invalid_scrobbling"
+	InvalidSongRatingErrorCode               ErrorCode = "invalid_song_rating"
+	InvalidSmartPlaylistErrorCode            ErrorCode = "invalid_smart_playlist"
+	InvalidPlaylistFolderErrorCode           ErrorCode = "invalid_playlist_folder"
+	DeletePlaylistFolderWithContentErrorCode ErrorCode = "delete_playlist_folder_with_content"
 
 	ForbiddenErrorCode ErrorCode = "forbidden"
 
@@ -51,10 +62,32 @@ func (e ErrorCode) StatusCode() int {
 		return http.StatusInternalServerError
 	case DeleteAlbumWithSongsErrorCode:
 		return http.StatusInternalServerError
+	case DeleteGenreWithSongsErrorCode:
+		return http.StatusInternalServerError
 	case DeleteUserYourselfErrorCode:
 		return http.StatusInternalServerError
 	case CreateNotOwnedPlaylistErrorCode:
 		return http.StatusBadRequest
+	case InvalidAlbumCoverErrorCode:
+		return http.StatusBadRequest
+	case InvalidArtistPictureErrorCode:
+		return http.StatusBadRequest
+	case DuplicateSongErrorCode:
+		return http.StatusConflict
+	case InvalidSongLyricsErrorCode:
+		return http.StatusBadRequest
+	case UnknownTranscodingProfileErrorCode:
+		return http.StatusBadRequest
+	case InvalidScrobblingErrorCode:
+		return http.StatusBadRequest
+	case InvalidSongRatingErrorCode:
+		return http.StatusBadRequest
+	case InvalidSmartPlaylistErrorCode:
+		return http.StatusBadRequest
+	case InvalidPlaylistFolderErrorCode:
+		return http.StatusBadRequest
+	case DeletePlaylistFolderWithContentErrorCode:
+		return http.StatusInternalServerError
 	case ForbiddenErrorCode:
 		return http.StatusForbidden
 	}
@@ -1,47 +1,114 @@
 package restApiV1
 
+// Paging headers of list endpoints
+const (
+	TotalCountHeader = "X-Total-Count" // Number of items matching the filter, all pages included
+	NextCursorHeader = "X-Next-Cursor" // Cursor of the next page, missing on the last page
+)
+
 type ArtistFilterOrderBy string
 
-const ArtistFilterOrderByName ArtistFilterOrderBy = "name"
+const (
+	ArtistFilterOrderByName       ArtistFilterOrderBy = "name"
+	ArtistFilterOrderByCreationTs ArtistFilterOrderBy = "creationTs"
+	ArtistFilterOrderByUpdateTs   ArtistFilterOrderBy = "updateTs"
+)
 
 type ArtistFilter struct {
 	FromTs  *int64
 	Name    *string
 	SongId  *SongId
+	Role    *ArtistRole // Role of the artist on SongId, any role when nil
 	OrderBy *ArtistFilterOrderBy
+	Limit   *int    // Maximum number of artists, all artists when nil
+	Cursor  *string // Opaque cursor of the page to read, first page when nil
+}
+
+type GenreFilterOrderBy string
+
+const (
+	GenreFilterOrderByName       GenreFilterOrderBy = "name"
+	GenreFilterOrderByCreationTs GenreFilterOrderBy = "creationTs"
+	GenreFilterOrderByUpdateTs   GenreFilterOrderBy = "updateTs"
+)
+
+type GenreFilter struct {
+	FromTs  *int64
+	Name    *string
+	SongId  *SongId
+	OrderBy *GenreFilterOrderBy
+	Limit   *int    // Maximum number of genres, all genres when nil
+	Cursor  *string // Opaque cursor of the page to read, first page when nil
 }
 
 type AlbumFilterOrderBy string
 
-const AlbumFilterOrderByName AlbumFilterOrderBy = "name"
+const (
+	AlbumFilterOrderByName       AlbumFilterOrderBy = "name"
+	AlbumFilterOrderByCreationTs AlbumFilterOrderBy = "creationTs"
+	AlbumFilterOrderByUpdateTs   AlbumFilterOrderBy = "updateTs"
+)
 
 type AlbumFilter struct {
 	FromTs  *int64
 	Name    *string
 	OrderBy *AlbumFilterOrderBy
+	Limit   *int    // Maximum number of albums, all albums when nil
+	Cursor  *string // Opaque cursor of the page to read, first page when nil
+}
+
+type PlaylistFolderFilter struct {
+	FromTs *int64
 }
 
 type PlaylistFilterOrderBy string
 
-const PlaylistFilterOrderByName PlaylistFilterOrderBy = "name"
+const (
+	PlaylistFilterOrderByName       PlaylistFilterOrderBy = "name"
+	PlaylistFilterOrderByCreationTs PlaylistFilterOrderBy = "creationTs"
+	PlaylistFilterOrderByUpdateTs   PlaylistFilterOrderBy = "updateTs"
+)
 
 type PlaylistFilter struct {
 	FromTs         *int64
 	FavoriteUserId *UserId
 	FavoriteFromTs *int64
 	OrderBy        *PlaylistFilterOrderBy
+	Limit          *int    // Maximum number of playlists, all playlists when nil
+	Cursor         *string // Opaque cursor of the page to read, first page when nil
 }
 
 type SongFilterOrderBy string
 
-const SongFilterOrderByName SongFilterOrderBy = "name"
+const (
+	SongFilterOrderByName       SongFilterOrderBy = "name"
+	SongFilterOrderByDuration   SongFilterOrderBy = "duration"
+	SongFilterOrderByCreationTs SongFilterOrderBy = "creationTs"
+	SongFilterOrderByUpdateTs   SongFilterOrderBy = "updateTs"
+	SongFilterOrderByYear       SongFilterOrderBy = "year"  // Publication year, songs without year first
+	SongFilterOrderByTrack      SongFilterOrderBy = "track" // Album, disc & track number
+)
 
 type SongFilter struct {
-	FromTs   *int64
-	AlbumId  *AlbumId
-	ArtistId *ArtistId
-	Favorite *SongFilterFavorite
-	OrderBy  *SongFilterOrderBy
+	FromTs        *int64
+	AlbumId       *AlbumId
+	ArtistId      *ArtistId
+	ArtistRole    *ArtistRole // Role of ArtistId on the songs, any role when nil
+	GenreId       *GenreId
+	Favorite      *SongFilterFavorite
+	MinRating     *SongFilterMinRating
+	MinPlayCount  *SongFilterMinPlayCount
+	MinDuration   *int64 // in milliseconds
+	MaxDuration   *int64 // in milliseconds
+	MinYear       *int64 // Minimum publication year, songs without year excluded
+	MaxYear       *int64 // Maximum publication year, songs without year excluded
+	Format        *SongFormat
+	BitDepth      *SongBitDepth
+	ExplicitFg    *bool
+	MinCreationTs *int64 // Songs created at or after this timestamp
+	OrderBy       *SongFilterOrderBy
+	Limit         *int    // Maximum number of songs, all songs when nil
+	Cursor        *string // Opaque cursor of the page to read, first page when nil
 }
 
 type SongFilterFavorite struct {
@@ -49,6 +116,18 @@ type SongFilterFavorite struct {
 	FromTs int64
 }
 
+// SongFilterMinRating select the songs rated at least Rating stars by a user
+type SongFilterMinRating struct {
+	UserId UserId
+	Rating int64
+}
+
+// SongFilterMinPlayCount select the songs played at least PlayCount times by a user
+type SongFilterMinPlayCount struct {
+	UserId    UserId
+	PlayCount int64
+}
+
 type UserFilter struct {
 	FromTs  *int64
 	AdminFg *bool
@@ -63,3 +142,14 @@ type FavoritePlaylistFilter struct {
 type FavoriteSongFilter struct {
 	FromTs *int64
 }
+
+type SongRatingFilter struct {
+	FromTs *int64
+}
+
+type PlayEventFilter struct {
+	UserId *UserId
+	SongId *SongId
+	FromTs *int64 // Plays started at or after this timestamp
+	ToTs   *int64 // Plays started before this timestamp
+}
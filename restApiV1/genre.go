@@ -0,0 +1,23 @@
+package restApiV1
+
+// Genre
+
+var UnknownGenreId GenreId = "00000000000000000000000000"
+
+type GenreId string
+
+type Genre struct {
+	Id         GenreId `json:"id"`
+	CreationTs int64   `json:"creationTs"`
+	UpdateTs   int64   `json:"updateTs"`
+	GenreMeta
+}
+
+type GenreMeta struct {
+	Name string `json:"name"`
+}
+
+func (g *GenreMeta) Copy() *GenreMeta {
+	var newGenreMeta = *g
+	return &newGenreMeta
+}
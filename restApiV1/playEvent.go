@@ -0,0 +1,54 @@
+package restApiV1
+
+// Play event
+
+type PlayEventId string
+
+type ClientKind string
+
+const (
+	ClientKindConsole ClientKind = "console" // mifasolcli console player
+	ClientKindWeb     ClientKind = "web"     // Web assembly player
+	ClientKindOther   ClientKind = "other"
+)
+
+func (c ClientKind) IsValid() bool {
+	switch c {
+	case ClientKindConsole, ClientKindWeb, ClientKindOther:
+		return true
+	}
+	return false
+}
+
+// Listened duration, in milliseconds, above which a play is always reported
+const PlayMinListenedDuration = 4 * 60 * 1000
+
+type PlayEventMeta struct {
+	UserId           UserId     `json:"userId"`
+	SongId           SongId     `json:"songId"`
+	StartTs          int64      `json:"startTs"`          // Play start timestamp
+	ListenedDuration int64      `json:"listenedDuration"` // Listened duration in milliseconds, seeks excluded
+	ClientKind       ClientKind `json:"clientKind"`
+}
+
+type PlayEvent struct {
+	Id         PlayEventId `json:"id"`
+	CreationTs int64       `json:"creationTs"`
+	PlayEventMeta
+}
+
+// SongPlayStats summarize the plays of a song by a user
+type SongPlayStats struct {
+	SongId     SongId `json:"songId"`
+	PlayCount  int64  `json:"playCount"`
+	LastPlayTs int64  `json:"lastPlayTs"` // Start timestamp of the last play
+}
+
+// IsPlayReportable return true when a song has been listened long enough to report a play:
+// half of its duration or PlayMinListenedDuration
+func IsPlayReportable(listenedDuration int64, songDuration int64) bool {
+	if listenedDuration <= 0 {
+		return false
+	}
+	return listenedDuration >= PlayMinListenedDuration || (songDuration > 0 && listenedDuration*2 >= songDuration)
+}
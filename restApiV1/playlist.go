@@ -15,9 +15,47 @@ type Playlist struct {
 }
 
 type PlaylistMeta struct {
-	Name         string   `json:"name"`
-	SongIds      []SongId `json:"songIds"`
-	OwnerUserIds []UserId `json:"ownerUserIds"`
+	Name         string              `json:"name"`
+	SongIds      []SongId            `json:"songIds"`
+	OwnerUserIds []UserId            `json:"ownerUserIds"`
+	SmartRules   *SmartPlaylistRules `json:"smartRules,omitempty"` // Rules computing the songs of a smart playlist, nil for a static playlist
+	FolderId     *PlaylistFolderId   `json:"folderId,omitempty"`   // Folder containing the playlist, nil for a root playlist
+}
+
+// SmartPlaylistRules select the songs of a smart playlist, a song should match every defined rule
+type SmartPlaylistRules struct {
+	ArtistId        *ArtistId     `json:"artistId,omitempty"`
+	AlbumId         *AlbumId      `json:"albumId,omitempty"`
+	MinYear         *int64        `json:"minYear,omitempty"`
+	MaxYear         *int64        `json:"maxYear,omitempty"`
+	Format          *SongFormat   `json:"format,omitempty"`
+	BitDepth        *SongBitDepth `json:"bitDepth,omitempty"`
+	ExplicitFg      *bool         `json:"explicitFg,omitempty"`
+	AddedWithinDays *int64        `json:"addedWithinDays,omitempty"`
+	UserId          *UserId       `json:"userId,omitempty"` // User the favorite, rating & play count rules refer to
+	FavoriteFg      bool          `json:"favoriteFg,omitempty"`
+	MinRating       *int64        `json:"minRating,omitempty"`
+	MinPlayCount    *int64        `json:"minPlayCount,omitempty"`
+}
+
+// IsSmartPlaylistRulesValid check the year range, the rating & the counts, and that user rules have a user
+func IsSmartPlaylistRulesValid(r *SmartPlaylistRules) bool {
+	if r.MinYear != nil && r.MaxYear != nil && *r.MinYear > *r.MaxYear {
+		return false
+	}
+	if r.AddedWithinDays != nil && *r.AddedWithinDays <= 0 {
+		return false
+	}
+	if r.MinRating != nil && !IsSongRatingValid(*r.MinRating) {
+		return false
+	}
+	if r.MinPlayCount != nil && *r.MinPlayCount <= 0 {
+		return false
+	}
+	if r.UserId == nil && (r.FavoriteFg || r.MinRating != nil || r.MinPlayCount != nil) {
+		return false
+	}
+	return true
 }
 
 func (p *PlaylistMeta) Copy() *PlaylistMeta {
@@ -26,5 +64,13 @@ func (p *PlaylistMeta) Copy() *PlaylistMeta {
 	copy(newPlaylistMeta.SongIds, p.SongIds)
 	newPlaylistMeta.OwnerUserIds = make([]UserId, len(p.OwnerUserIds))
 	copy(newPlaylistMeta.OwnerUserIds, p.OwnerUserIds)
+	if p.SmartRules != nil {
+		smartRules := *p.SmartRules
+		newPlaylistMeta.SmartRules = &smartRules
+	}
+	if p.FolderId != nil {
+		folderId := *p.FolderId
+		newPlaylistMeta.FolderId = &folderId
+	}
 	return &newPlaylistMeta
 }
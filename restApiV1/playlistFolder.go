@@ -0,0 +1,26 @@
+package restApiV1
+
+// Playlist folder
+
+type PlaylistFolderId string
+
+type PlaylistFolder struct {
+	Id         PlaylistFolderId `json:"id"`
+	CreationTs int64            `json:"creationTs"`
+	UpdateTs   int64            `json:"updateTs"`
+	PlaylistFolderMeta
+}
+
+type PlaylistFolderMeta struct {
+	Name           string            `json:"name"`
+	ParentFolderId *PlaylistFolderId `json:"parentFolderId,omitempty"` // Parent folder, nil for a root folder
+}
+
+func (p *PlaylistFolderMeta) Copy() *PlaylistFolderMeta {
+	var newPlaylistFolderMeta = *p
+	if p.ParentFolderId != nil {
+		parentFolderId := *p.ParentFolderId
+		newPlaylistFolderMeta.ParentFolderId = &parentFolderId
+	}
+	return &newPlaylistFolderMeta
+}
@@ -0,0 +1,59 @@
+package restApiV1
+
+// Scrobbling
+
+type ScrobblingServiceType string
+
+const (
+	ScrobblingServiceTypeListenBrainz ScrobblingServiceType = "listenbrainz" // ListenBrainz api & compatible services (Maloja, ...)
+	ScrobblingServiceTypeLastFm       ScrobblingServiceType = "lastfm"       // Last.fm 2.0 api & compatible services (Libre.fm, ...)
+)
+
+var ScrobblingServiceTypes = []ScrobblingServiceType{
+	ScrobblingServiceTypeListenBrainz,
+	ScrobblingServiceTypeLastFm,
+}
+
+func (s ScrobblingServiceType) IsValid() bool {
+	switch s {
+	case ScrobblingServiceTypeListenBrainz, ScrobblingServiceTypeLastFm:
+		return true
+	}
+	return false
+}
+
+// DefaultApiRootUrl return the api root url of the reference service
+func (s ScrobblingServiceType) DefaultApiRootUrl() string {
+	switch s {
+	case ScrobblingServiceTypeListenBrainz:
+		return "https://api.listenbrainz.org"
+	case ScrobblingServiceTypeLastFm:
+		return "https://ws.audioscrobbler.com/2.0/"
+	}
+	return ""
+}
+
+// ScrobblingMeta hold the scrobbling settings of a user.
+// Token & ApiSecret are never sent back by the server: empty values keep the stored ones on update
+type ScrobblingMeta struct {
+	ServiceType ScrobblingServiceType `json:"serviceType"`
+	ApiRootUrl  string                `json:"apiRootUrl"`          // Service default when empty
+	Token       string                `json:"token,omitempty"`     // ListenBrainz user token or Last.fm session key
+	ApiKey      string                `json:"apiKey"`              // Last.fm only
+	ApiSecret   string                `json:"apiSecret,omitempty"` // Last.fm only
+}
+
+type Scrobbling struct {
+	UserId   UserId `json:"userId"`
+	UpdateTs int64  `json:"updateTs"`
+	ScrobblingMeta
+	PendingListenCount int64  `json:"pendingListenCount"` // Listens waiting to be forwarded
+	LastError          string `json:"lastError"`          // Last forwarding error, empty since the last success
+	LastErrorTs        int64  `json:"lastErrorTs"`
+}
+
+// NowPlayingMeta report the song a user just started to listen to
+type NowPlayingMeta struct {
+	UserId UserId `json:"userId"`
+	SongId SongId `json:"songId"`
+}
@@ -0,0 +1,18 @@
+package restApiV1
+
+// Search
+
+type SearchResultType string
+
+const (
+	SearchResultTypeSong     SearchResultType = "song"
+	SearchResultTypeAlbum    SearchResultType = "album"
+	SearchResultTypeArtist   SearchResultType = "artist"
+	SearchResultTypePlaylist SearchResultType = "playlist"
+)
+
+type SearchResult struct {
+	Type SearchResultType `json:"type"`
+	Id   string           `json:"id"` // Song, album, artist or playlist id depending on Type
+	Name string           `json:"name"`
+}
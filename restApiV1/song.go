@@ -6,6 +6,8 @@ const (
 	SongMimeTypeFlac = "audio/flac"
 	SongMimeTypeOgg  = "audio/ogg"
 	SongMimeTypeMp3  = "audio/mpeg"
+	SongMimeTypeOpus = "audio/ogg; codecs=opus"
+	SongMimeTypeM4a  = "audio/mp4"
 )
 
 type SongFormat int64
@@ -15,6 +17,8 @@ const (
 	SongFormatFlac
 	SongFormatMp3
 	SongFormatOgg
+	SongFormatOpus
+	SongFormatM4a
 )
 
 type SongBitDepth int64
@@ -43,6 +47,10 @@ func (s SongFormat) MimeType() string {
 		return SongMimeTypeOgg
 	case SongFormatMp3:
 		return SongMimeTypeMp3
+	case SongFormatOpus:
+		return SongMimeTypeOpus
+	case SongFormatM4a:
+		return SongMimeTypeM4a
 	}
 	return "application/octet-stream"
 }
@@ -55,6 +63,10 @@ func (s SongFormat) Extension() string {
 		return ".ogg"
 	case SongFormatMp3:
 		return ".mp3"
+	case SongFormatOpus:
+		return ".opus"
+	case SongFormatM4a:
+		return ".m4a"
 	}
 	return ".data"
 }
@@ -67,16 +79,43 @@ func (s SongFormat) String() string {
 		return "ogg"
 	case SongFormatMp3:
 		return "mp3"
+	case SongFormatOpus:
+		return "opus"
+	case SongFormatM4a:
+		return "m4a"
 	}
 	return "data"
 }
 
+// SongFormatFromString return the song format with the given name
+func SongFormatFromString(name string) SongFormat {
+	for _, songFormat := range []SongFormat{SongFormatFlac, SongFormatMp3, SongFormatOgg, SongFormatOpus, SongFormatM4a} {
+		if songFormat.String() == name {
+			return songFormat
+		}
+	}
+	return SongFormatUnknown
+}
+
+// SongFormatFromMimeType return the song format of a content type
+func SongFormatFromMimeType(mimeType string) SongFormat {
+	for _, songFormat := range []SongFormat{SongFormatFlac, SongFormatMp3, SongFormatOgg, SongFormatOpus, SongFormatM4a} {
+		if songFormat.MimeType() == mimeType {
+			return songFormat
+		}
+	}
+	return SongFormatUnknown
+}
+
 type SongId string
 
 type Song struct {
-	Id         SongId `json:"id"`
-	CreationTs int64  `json:"creationTs"`
-	UpdateTs   int64  `json:"updateTs"`
+	Id         SongId   `json:"id"`
+	CreationTs int64    `json:"creationTs"`
+	UpdateTs   int64    `json:"updateTs"`
+	Loudness   *float64 `json:"loudness"` // Integrated loudness in LUFS (EBU R128), nil until analysed
+	TruePeak   *float64 `json:"truePeak"` // True peak, linear scale
+	LyricsFg   bool     `json:"lyricsFg"`
 	SongMeta
 }
 
@@ -85,29 +124,50 @@ type SongMeta struct {
 	Format          SongFormat   `json:"format"`
 	Size            int64        `json:"size"`
 	BitDepth        SongBitDepth `json:"bitDepth"`
+	Duration        int64        `json:"duration"`   // Duration in milliseconds
+	SampleRate      int64        `json:"sampleRate"` // Sample rate in Hz
+	Channels        int64        `json:"channels"`
+	BitRate         int64        `json:"bitRate"` // Average bitrate in bits per second
 	PublicationYear *int64       `json:"publicationYear"`
 	AlbumId         AlbumId      `json:"albumId"`
+	DiscNumber      *int64       `json:"discNumber"`
 	TrackNumber     *int64       `json:"trackNumber"`
-	ArtistIds       []ArtistId   `json:"artistIds"`
+	ArtistIds       []ArtistId   `json:"artistIds"` // Main artists
+	Artists         []SongArtist `json:"artists"`   // Other credited artists (featuring, composers, performers, conductors)
+	GenreIds        []GenreId    `json:"genreIds"`
 	ExplicitFg      bool         `json:"explicitFg"`
 }
 
+type SongArtist struct {
+	ArtistId ArtistId   `json:"artistId"`
+	Role     ArtistRole `json:"role"`
+}
+
 func (s *SongMeta) Copy() *SongMeta {
 	var newSongMeta = *s
 	if s.PublicationYear != nil {
 		newPublicationYear := *s.PublicationYear
 		newSongMeta.PublicationYear = &newPublicationYear
 	}
+	if s.DiscNumber != nil {
+		newDiscNumber := *s.DiscNumber
+		newSongMeta.DiscNumber = &newDiscNumber
+	}
 	if s.TrackNumber != nil {
 		newTrackNumber := *s.TrackNumber
 		newSongMeta.TrackNumber = &newTrackNumber
 	}
 	newSongMeta.ArtistIds = make([]ArtistId, len(s.ArtistIds))
 	copy(newSongMeta.ArtistIds, s.ArtistIds)
+	newSongMeta.Artists = make([]SongArtist, len(s.Artists))
+	copy(newSongMeta.Artists, s.Artists)
+	newSongMeta.GenreIds = make([]GenreId, len(s.GenreIds))
+	copy(newSongMeta.GenreIds, s.GenreIds)
 	return &newSongMeta
 }
 
 type SongNew struct {
 	SongMeta
-	Content []byte `json:"content"`
+	Lyrics string `json:"lyrics"`           // Plain text or LRC time-synced lyrics
+	Rating int64  `json:"rating,omitempty"` // Stars given by the uploading user, unrated when 0
 }
@@ -0,0 +1,27 @@
+package restApiV1
+
+// Song lyrics
+
+type SongLyrics struct {
+	SongId   SongId           `json:"songId"`
+	Text     string           `json:"text"`     // Raw lyrics, in LRC format when synced
+	SyncedFg bool             `json:"syncedFg"` // Lyrics are time-synced
+	Lines    []SongLyricsLine `json:"lines"`    // Time-synced lines ordered by time, empty for plain lyrics
+}
+
+type SongLyricsLine struct {
+	Time int64  `json:"time"` // Start time in milliseconds
+	Text string `json:"text"`
+}
+
+// LineIndexAt return the index of the synced line sung at the given position in milliseconds, or -1 before the first line
+func (l *SongLyrics) LineIndexAt(position int64) int {
+	index := -1
+	for ind, line := range l.Lines {
+		if line.Time > position {
+			break
+		}
+		index = ind
+	}
+	return index
+}
@@ -0,0 +1,27 @@
+package restApiV1
+
+// Song ratings are expressed in stars, an unrated song has no rating
+const (
+	SongRatingMin = 1
+	SongRatingMax = 5
+)
+
+type SongRatingId struct {
+	UserId UserId `json:"userId"`
+	SongId SongId `json:"songId"`
+}
+
+type SongRatingMeta struct {
+	Id     SongRatingId `json:"id"`
+	Rating int64        `json:"rating"` // From SongRatingMin to SongRatingMax stars
+}
+
+type SongRating struct {
+	SongRatingMeta
+	UpdateTs int64 `json:"updateTs"`
+}
+
+// IsSongRatingValid check that a rating is a number of stars
+func IsSongRatingValid(rating int64) bool {
+	return rating >= SongRatingMin && rating <= SongRatingMax
+}
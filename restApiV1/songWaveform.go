@@ -0,0 +1,15 @@
+package restApiV1
+
+// Song waveform
+
+// SongWaveformMaxPoints is the resolution of stored waveforms, requests for more points are limited to it
+const SongWaveformMaxPoints = 1000
+
+// SongWaveformPointsParam is the query parameter setting the number of points of a song waveform
+const SongWaveformPointsParam = "points"
+
+type SongWaveform struct {
+	SongId   SongId `json:"songId"`
+	UpdateTs int64  `json:"updateTs"`
+	Peaks    []int  `json:"peaks"` // Peak amplitude of each song slice, from 0 (silence) to 255 (full scale)
+}
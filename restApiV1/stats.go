@@ -0,0 +1,53 @@
+package restApiV1
+
+// Listening statistics
+
+const (
+	StatsFromParam  = "from"       // First day of the period, included
+	StatsToParam    = "to"         // Last day of the period, included
+	StatsDateLayout = "2006-01-02" // Days are expressed in server local time
+	StatsTopCount   = 10           // Number of songs, artists & albums in tops
+)
+
+type PlayStats struct {
+	PlayCount        int64 `json:"playCount"`
+	ListenedDuration int64 `json:"listenedDuration"` // In milliseconds
+}
+
+type SongStats struct {
+	SongId SongId `json:"songId"`
+	PlayStats
+}
+
+type ArtistStats struct {
+	ArtistId ArtistId `json:"artistId"`
+	PlayStats
+}
+
+type AlbumStats struct {
+	AlbumId AlbumId `json:"albumId"`
+	PlayStats
+}
+
+type DayStats struct {
+	Day string `json:"day"` // Formatted with StatsDateLayout
+	PlayStats
+}
+
+type FormatStats struct {
+	Format SongFormat `json:"format"`
+	PlayStats
+}
+
+// UserStats summarize what a user listened to over a period
+type UserStats struct {
+	UserId UserId  `json:"userId"`
+	From   *string `json:"from"` // Start of the period, unbounded when nil
+	To     *string `json:"to"`   // End of the period, unbounded when nil
+	PlayStats
+	TopSongs   []SongStats   `json:"topSongs"`   // Most played songs first
+	TopArtists []ArtistStats `json:"topArtists"` // Most played main artists first
+	TopAlbums  []AlbumStats  `json:"topAlbums"`  // Most played albums first
+	Days       []DayStats    `json:"days"`       // Listened days, chronologically
+	Formats    []FormatStats `json:"formats"`    // Most played formats first
+}
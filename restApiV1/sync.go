@@ -5,16 +5,22 @@ type SyncReport struct {
 	DeletedSongIds             []SongId             `json:"deletedSongIds"`
 	Artists                    []Artist             `json:"artists"`
 	DeletedArtistIds           []ArtistId           `json:"deletedArtistIds"`
+	Genres                     []Genre              `json:"genres"`
+	DeletedGenreIds            []GenreId            `json:"deletedGenreIds"`
 	Albums                     []Album              `json:"albums"`
 	DeletedAlbumIds            []AlbumId            `json:"deletedAlbumIds"`
 	Playlists                  []Playlist           `json:"playlists"`
 	DeletedPlaylistIds         []PlaylistId         `json:"deletedPlaylistIds"`
+	PlaylistFolders            []PlaylistFolder     `json:"playlistFolders"`
+	DeletedPlaylistFolderIds   []PlaylistFolderId   `json:"deletedPlaylistFolderIds"`
 	Users                      []User               `json:"users"`
 	DeletedUserIds             []UserId             `json:"deletedUserIds"`
 	FavoritePlaylists          []FavoritePlaylist   `json:"favoritePlaylists"`
 	DeletedFavoritePlaylistIds []FavoritePlaylistId `json:"deletedFavoritePlaylistIds"`
 	FavoriteSongs              []FavoriteSong       `json:"favoriteSongs"`
 	DeletedFavoriteSongIds     []FavoriteSongId     `json:"deletedFavoriteSongIds"`
+	SongRatings                []SongRating         `json:"songRatings"`
+	DeletedSongRatingIds       []SongRatingId       `json:"deletedSongRatingIds"`
 	SyncTs                     int64                `json:"syncTs"`
 }
 
@@ -25,9 +31,10 @@ type FileSyncSong struct {
 }
 
 type FileSyncReport struct {
-	FileSyncSongs      []FileSyncSong `json:"fileSyncSongs"`
-	DeletedSongIds     []SongId       `json:"deletedSongIds"`
-	Playlists          []Playlist     `json:"playlists"`
-	DeletedPlaylistIds []PlaylistId   `json:"deletedPlaylistIds"`
-	SyncTs             int64          `json:"syncTs"`
+	FileSyncSongs      []FileSyncSong   `json:"fileSyncSongs"`
+	DeletedSongIds     []SongId         `json:"deletedSongIds"`
+	Playlists          []Playlist       `json:"playlists"`
+	DeletedPlaylistIds []PlaylistId     `json:"deletedPlaylistIds"`
+	PlaylistFolders    []PlaylistFolder `json:"playlistFolders"` // Every playlist folder, to mirror the folder tree
+	SyncTs             int64            `json:"syncTs"`
 }
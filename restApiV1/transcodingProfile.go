@@ -0,0 +1,11 @@
+package restApiV1
+
+// Transcoding profile
+
+// TranscodingProfileParam is the query parameter selecting the transcoding profile of a song content
+const TranscodingProfileParam = "profile"
+
+type TranscodingProfile struct {
+	Name   string     `json:"name"`
+	Format SongFormat `json:"format"` // Format of the transcoded content
+}
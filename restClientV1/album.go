@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"encoding/json"
 	"github.com/jypelle/mifasol/restApiV1"
+	"io"
 )
 
 func (c *RestClient) CreateAlbum(albumMeta *restApiV1.AlbumMeta) (*restApiV1.Album, ClientError) {
@@ -42,6 +43,14 @@ func (c *RestClient) ReadAlbums(albumFilter *restApiV1.AlbumFilter) ([]restApiV1
 	return albumList, nil
 }
 
+// NewAlbumPager return a pager reading the albums matching a filter, pageSize albums at a time
+func (c *RestClient) NewAlbumPager(albumFilter *restApiV1.AlbumFilter, pageSize int) *Pager[restApiV1.Album] {
+	filter := *albumFilter
+	filter.Limit = &pageSize
+	filter.Cursor = nil
+	return newPager[restApiV1.Album](c, "/albums", &filter, func(cursor *string) { filter.Cursor = cursor })
+}
+
 func (c *RestClient) UpdateAlbum(albumId restApiV1.AlbumId, albumMeta *restApiV1.AlbumMeta) (*restApiV1.Album, ClientError) {
 	var album *restApiV1.Album
 
@@ -75,3 +84,45 @@ func (c *RestClient) DeleteAlbum(albumId restApiV1.AlbumId) (*restApiV1.Album, C
 
 	return album, nil
 }
+
+func (c *RestClient) ReadAlbumCover(albumId restApiV1.AlbumId) (io.ReadCloser, int64, ClientError) {
+
+	response, cliErr := c.doGetRequest("/albumCovers/" + string(albumId))
+	if cliErr != nil {
+		return nil, 0, cliErr
+	}
+
+	return response.Body, response.ContentLength, nil
+}
+
+func (c *RestClient) UpdateAlbumCover(albumId restApiV1.AlbumId, contentType string, readerSource io.Reader) (*restApiV1.Album, ClientError) {
+	var album *restApiV1.Album
+
+	response, cliErr := c.doPutRequest("/albumCovers/"+string(albumId), contentType, readerSource)
+	if cliErr != nil {
+		return nil, cliErr
+	}
+	defer response.Body.Close()
+
+	if err := json.NewDecoder(response.Body).Decode(&album); err != nil {
+		return nil, NewClientError(err)
+	}
+
+	return album, nil
+}
+
+func (c *RestClient) DeleteAlbumCover(albumId restApiV1.AlbumId) (*restApiV1.Album, ClientError) {
+	var album *restApiV1.Album
+
+	response, cliErr := c.doDeleteRequest("/albumCovers/" + string(albumId))
+	if cliErr != nil {
+		return nil, cliErr
+	}
+	defer response.Body.Close()
+
+	if err := json.NewDecoder(response.Body).Decode(&album); err != nil {
+		return nil, NewClientError(err)
+	}
+
+	return album, nil
+}
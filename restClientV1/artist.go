@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"encoding/json"
 	"github.com/jypelle/mifasol/restApiV1"
+	"io"
 )
 
 func (c *RestClient) CreateArtist(artistMeta *restApiV1.ArtistMeta) (*restApiV1.Artist, ClientError) {
@@ -43,6 +44,14 @@ func (c *RestClient) ReadArtists(artistFilter *restApiV1.ArtistFilter) ([]restAp
 	return artistList, nil
 }
 
+// NewArtistPager return a pager reading the artists matching a filter, pageSize artists at a time
+func (c *RestClient) NewArtistPager(artistFilter *restApiV1.ArtistFilter, pageSize int) *Pager[restApiV1.Artist] {
+	filter := *artistFilter
+	filter.Limit = &pageSize
+	filter.Cursor = nil
+	return newPager[restApiV1.Artist](c, "/artists", &filter, func(cursor *string) { filter.Cursor = cursor })
+}
+
 func (c *RestClient) UpdateArtist(artistId restApiV1.ArtistId, artistMeta *restApiV1.ArtistMeta) (*restApiV1.Artist, ClientError) {
 	var artist *restApiV1.Artist
 
@@ -77,3 +86,45 @@ func (c *RestClient) DeleteArtist(artistId restApiV1.ArtistId) (*restApiV1.Artis
 
 	return artist, nil
 }
+
+func (c *RestClient) ReadArtistPicture(artistId restApiV1.ArtistId) (io.ReadCloser, int64, ClientError) {
+
+	response, cliErr := c.doGetRequest("/artistPictures/" + string(artistId))
+	if cliErr != nil {
+		return nil, 0, cliErr
+	}
+
+	return response.Body, response.ContentLength, nil
+}
+
+func (c *RestClient) UpdateArtistPicture(artistId restApiV1.ArtistId, contentType string, readerSource io.Reader) (*restApiV1.Artist, ClientError) {
+	var artist *restApiV1.Artist
+
+	response, cliErr := c.doPutRequest("/artistPictures/"+string(artistId), contentType, readerSource)
+	if cliErr != nil {
+		return nil, cliErr
+	}
+	defer response.Body.Close()
+
+	if err := json.NewDecoder(response.Body).Decode(&artist); err != nil {
+		return nil, NewClientError(err)
+	}
+
+	return artist, nil
+}
+
+func (c *RestClient) DeleteArtistPicture(artistId restApiV1.ArtistId) (*restApiV1.Artist, ClientError) {
+	var artist *restApiV1.Artist
+
+	response, cliErr := c.doDeleteRequest("/artistPictures/" + string(artistId))
+	if cliErr != nil {
+		return nil, cliErr
+	}
+	defer response.Body.Close()
+
+	if err := json.NewDecoder(response.Body).Decode(&artist); err != nil {
+		return nil, NewClientError(err)
+	}
+
+	return artist, nil
+}
@@ -0,0 +1,87 @@
+package restClientV1
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/jypelle/mifasol/restApiV1"
+)
+
+func (c *RestClient) CreateGenre(genreMeta *restApiV1.GenreMeta) (*restApiV1.Genre, ClientError) {
+	var genre *restApiV1.Genre
+
+	encodedGenreMeta, _ := json.Marshal(genreMeta)
+
+	response, cliErr := c.doPostRequest("/genres", JsonContentType, bytes.NewBuffer(encodedGenreMeta))
+	if cliErr != nil {
+		return nil, cliErr
+	}
+	defer response.Body.Close()
+
+	err := json.NewDecoder(response.Body).Decode(&genre)
+	if err != nil {
+		return nil, NewClientError(err)
+	}
+
+	return genre, nil
+}
+
+func (c *RestClient) ReadGenres(genreFilter *restApiV1.GenreFilter) ([]restApiV1.Genre, ClientError) {
+	var genreList []restApiV1.Genre
+
+	encodedGenreFilter, _ := json.Marshal(genreFilter)
+
+	response, cliErr := c.doGetRequestWithBody("/genres", JsonContentType, bytes.NewBuffer(encodedGenreFilter))
+	if cliErr != nil {
+		return nil, cliErr
+	}
+	defer response.Body.Close()
+
+	if err := json.NewDecoder(response.Body).Decode(&genreList); err != nil {
+		return nil, NewClientError(err)
+	}
+
+	return genreList, nil
+}
+
+// NewGenrePager return a pager reading the genres matching a filter, pageSize genres at a time
+func (c *RestClient) NewGenrePager(genreFilter *restApiV1.GenreFilter, pageSize int) *Pager[restApiV1.Genre] {
+	filter := *genreFilter
+	filter.Limit = &pageSize
+	filter.Cursor = nil
+	return newPager[restApiV1.Genre](c, "/genres", &filter, func(cursor *string) { filter.Cursor = cursor })
+}
+
+func (c *RestClient) UpdateGenre(genreId restApiV1.GenreId, genreMeta *restApiV1.GenreMeta) (*restApiV1.Genre, ClientError) {
+	var genre *restApiV1.Genre
+
+	encodedGenreMeta, _ := json.Marshal(genreMeta)
+
+	response, cliErr := c.doPutRequest("/genres/"+string(genreId), JsonContentType, bytes.NewBuffer(encodedGenreMeta))
+	if cliErr != nil {
+		return nil, cliErr
+	}
+	defer response.Body.Close()
+
+	err := json.NewDecoder(response.Body).Decode(&genre)
+	if err != nil {
+		return nil, NewClientError(err)
+	}
+
+	return genre, nil
+}
+
+func (c *RestClient) DeleteGenre(genreId restApiV1.GenreId) (*restApiV1.Genre, ClientError) {
+	var genre *restApiV1.Genre
+
+	response, cliErr := c.doDeleteRequest("/genres/" + string(genreId))
+	if cliErr != nil {
+		return nil, cliErr
+	}
+	defer response.Body.Close()
+
+	if err := json.NewDecoder(response.Body).Decode(&genre); err != nil {
+		return nil, NewClientError(err)
+	}
+
+	return genre, nil
+}
@@ -0,0 +1,75 @@
+package restClientV1
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/jypelle/mifasol/restApiV1"
+	"strconv"
+)
+
+// readPage read a page of a list endpoint and return the cursor of the next page (empty for the last page) & the total count of items
+func (c *RestClient) readPage(relativeUrl string, filter interface{}, list interface{}) (string, int64, ClientError) {
+	encodedFilter, _ := json.Marshal(filter)
+
+	response, cliErr := c.doGetRequestWithBody(relativeUrl, JsonContentType, bytes.NewBuffer(encodedFilter))
+	if cliErr != nil {
+		return "", 0, cliErr
+	}
+	defer response.Body.Close()
+
+	if err := json.NewDecoder(response.Body).Decode(list); err != nil {
+		return "", 0, NewClientError(err)
+	}
+
+	totalCount, _ := strconv.ParseInt(response.Header.Get(restApiV1.TotalCountHeader), 10, 64)
+
+	return response.Header.Get(restApiV1.NextCursorHeader), totalCount, nil
+}
+
+// Pager read the items of a list endpoint page by page
+type Pager[T any] struct {
+	client      *RestClient
+	relativeUrl string
+	filter      interface{}
+	setCursor   func(cursor *string)
+	totalCount  int64
+	done        bool
+}
+
+// newPager return a pager reading a list endpoint with a filter holding the page size, setCursor update the cursor of this filter
+func newPager[T any](c *RestClient, relativeUrl string, filter interface{}, setCursor func(cursor *string)) *Pager[T] {
+	return &Pager[T]{client: c, relativeUrl: relativeUrl, filter: filter, setCursor: setCursor}
+}
+
+// HasNext return true until the last page has been read
+func (p *Pager[T]) HasNext() bool {
+	return !p.done
+}
+
+// Next read the next page of items
+func (p *Pager[T]) Next() ([]T, ClientError) {
+	if p.done {
+		return nil, nil
+	}
+
+	var list []T
+
+	nextCursor, totalCount, cliErr := p.client.readPage(p.relativeUrl, p.filter, &list)
+	if cliErr != nil {
+		return nil, cliErr
+	}
+
+	p.totalCount = totalCount
+	if nextCursor == "" {
+		p.done = true
+	} else {
+		p.setCursor(&nextCursor)
+	}
+
+	return list, nil
+}
+
+// TotalCount return the number of items matching the filter, known once a page has been read
+func (p *Pager[T]) TotalCount() int64 {
+	return p.totalCount
+}
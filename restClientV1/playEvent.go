@@ -0,0 +1,59 @@
+package restClientV1
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/jypelle/mifasol/restApiV1"
+)
+
+func (c *RestClient) ReadPlayEvents(playEventFilter *restApiV1.PlayEventFilter) ([]restApiV1.PlayEvent, ClientError) {
+	var playEventList []restApiV1.PlayEvent
+
+	encodedPlayEventFilter, _ := json.Marshal(playEventFilter)
+
+	response, cliErr := c.doGetRequestWithBody("/plays", JsonContentType, bytes.NewBuffer(encodedPlayEventFilter))
+	if cliErr != nil {
+		return nil, cliErr
+	}
+	defer response.Body.Close()
+
+	if err := json.NewDecoder(response.Body).Decode(&playEventList); err != nil {
+		return nil, NewClientError(err)
+	}
+
+	return playEventList, nil
+}
+
+func (c *RestClient) CreatePlayEvent(playEventMeta *restApiV1.PlayEventMeta) (*restApiV1.PlayEvent, ClientError) {
+	var playEvent *restApiV1.PlayEvent
+
+	encodedPlayEventMeta, _ := json.Marshal(playEventMeta)
+
+	response, cliErr := c.doPostRequest("/plays", JsonContentType, bytes.NewBuffer(encodedPlayEventMeta))
+	if cliErr != nil {
+		return nil, cliErr
+	}
+	defer response.Body.Close()
+
+	if err := json.NewDecoder(response.Body).Decode(&playEvent); err != nil {
+		return nil, NewClientError(err)
+	}
+
+	return playEvent, nil
+}
+
+func (c *RestClient) ReadSongPlayStats(userId restApiV1.UserId) ([]restApiV1.SongPlayStats, ClientError) {
+	var songPlayStatsList []restApiV1.SongPlayStats
+
+	response, cliErr := c.doGetRequest("/songPlayStats/" + string(userId))
+	if cliErr != nil {
+		return nil, cliErr
+	}
+	defer response.Body.Close()
+
+	if err := json.NewDecoder(response.Body).Decode(&songPlayStatsList); err != nil {
+		return nil, NewClientError(err)
+	}
+
+	return songPlayStatsList, nil
+}
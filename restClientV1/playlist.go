@@ -43,6 +43,14 @@ func (c *RestClient) ReadPlaylists(playlistFilter *restApiV1.PlaylistFilter) ([]
 	return playlistList, nil
 }
 
+// NewPlaylistPager return a pager reading the playlists matching a filter, pageSize playlists at a time
+func (c *RestClient) NewPlaylistPager(playlistFilter *restApiV1.PlaylistFilter, pageSize int) *Pager[restApiV1.Playlist] {
+	filter := *playlistFilter
+	filter.Limit = &pageSize
+	filter.Cursor = nil
+	return newPager[restApiV1.Playlist](c, "/playlists", &filter, func(cursor *string) { filter.Cursor = cursor })
+}
+
 func (c *RestClient) UpdatePlaylist(playlistId restApiV1.PlaylistId, playlistMeta *restApiV1.PlaylistMeta) (*restApiV1.Playlist, ClientError) {
 	var playlist *restApiV1.Playlist
 
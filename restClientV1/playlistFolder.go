@@ -0,0 +1,79 @@
+package restClientV1
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/jypelle/mifasol/restApiV1"
+)
+
+func (c *RestClient) CreatePlaylistFolder(playlistFolderMeta *restApiV1.PlaylistFolderMeta) (*restApiV1.PlaylistFolder, ClientError) {
+	var playlistFolder *restApiV1.PlaylistFolder
+
+	encodedPlaylistFolderMeta, _ := json.Marshal(playlistFolderMeta)
+
+	response, cliErr := c.doPostRequest("/playlistFolders", JsonContentType, bytes.NewBuffer(encodedPlaylistFolderMeta))
+	if cliErr != nil {
+		return nil, cliErr
+	}
+	defer response.Body.Close()
+
+	err := json.NewDecoder(response.Body).Decode(&playlistFolder)
+	if err != nil {
+		return nil, NewClientError(err)
+	}
+
+	return playlistFolder, nil
+}
+
+func (c *RestClient) ReadPlaylistFolders(playlistFolderFilter *restApiV1.PlaylistFolderFilter) ([]restApiV1.PlaylistFolder, ClientError) {
+	var playlistFolderList []restApiV1.PlaylistFolder
+
+	encodedPlaylistFolderFilter, _ := json.Marshal(playlistFolderFilter)
+
+	response, cliErr := c.doGetRequestWithBody("/playlistFolders", JsonContentType, bytes.NewBuffer(encodedPlaylistFolderFilter))
+	if cliErr != nil {
+		return nil, cliErr
+	}
+	defer response.Body.Close()
+
+	if err := json.NewDecoder(response.Body).Decode(&playlistFolderList); err != nil {
+		return nil, NewClientError(err)
+	}
+
+	return playlistFolderList, nil
+}
+
+func (c *RestClient) UpdatePlaylistFolder(playlistFolderId restApiV1.PlaylistFolderId, playlistFolderMeta *restApiV1.PlaylistFolderMeta) (*restApiV1.PlaylistFolder, ClientError) {
+	var playlistFolder *restApiV1.PlaylistFolder
+
+	encodedPlaylistFolderMeta, _ := json.Marshal(playlistFolderMeta)
+
+	response, cliErr := c.doPutRequest("/playlistFolders/"+string(playlistFolderId), JsonContentType, bytes.NewBuffer(encodedPlaylistFolderMeta))
+	if cliErr != nil {
+		return nil, cliErr
+	}
+	defer response.Body.Close()
+
+	err := json.NewDecoder(response.Body).Decode(&playlistFolder)
+	if err != nil {
+		return nil, NewClientError(err)
+	}
+
+	return playlistFolder, nil
+}
+
+func (c *RestClient) DeletePlaylistFolder(playlistFolderId restApiV1.PlaylistFolderId) (*restApiV1.PlaylistFolder, ClientError) {
+	var playlistFolder *restApiV1.PlaylistFolder
+
+	response, cliErr := c.doDeleteRequest("/playlistFolders/" + string(playlistFolderId))
+	if cliErr != nil {
+		return nil, cliErr
+	}
+	defer response.Body.Close()
+
+	if err := json.NewDecoder(response.Body).Decode(&playlistFolder); err != nil {
+		return nil, NewClientError(err)
+	}
+
+	return playlistFolder, nil
+}
@@ -0,0 +1,69 @@
+package restClientV1
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/jypelle/mifasol/restApiV1"
+)
+
+func (c *RestClient) ReadScrobbling(userId restApiV1.UserId) (*restApiV1.Scrobbling, ClientError) {
+	var scrobbling *restApiV1.Scrobbling
+
+	response, cliErr := c.doGetRequest("/scrobblings/" + string(userId))
+	if cliErr != nil {
+		return nil, cliErr
+	}
+	defer response.Body.Close()
+
+	if err := json.NewDecoder(response.Body).Decode(&scrobbling); err != nil {
+		return nil, NewClientError(err)
+	}
+
+	return scrobbling, nil
+}
+
+func (c *RestClient) UpdateScrobbling(userId restApiV1.UserId, scrobblingMeta *restApiV1.ScrobblingMeta) (*restApiV1.Scrobbling, ClientError) {
+	var scrobbling *restApiV1.Scrobbling
+
+	encodedScrobblingMeta, _ := json.Marshal(scrobblingMeta)
+
+	response, cliErr := c.doPutRequest("/scrobblings/"+string(userId), JsonContentType, bytes.NewBuffer(encodedScrobblingMeta))
+	if cliErr != nil {
+		return nil, cliErr
+	}
+	defer response.Body.Close()
+
+	if err := json.NewDecoder(response.Body).Decode(&scrobbling); err != nil {
+		return nil, NewClientError(err)
+	}
+
+	return scrobbling, nil
+}
+
+func (c *RestClient) DeleteScrobbling(userId restApiV1.UserId) (*restApiV1.Scrobbling, ClientError) {
+	var scrobbling *restApiV1.Scrobbling
+
+	response, cliErr := c.doDeleteRequest("/scrobblings/" + string(userId))
+	if cliErr != nil {
+		return nil, cliErr
+	}
+	defer response.Body.Close()
+
+	if err := json.NewDecoder(response.Body).Decode(&scrobbling); err != nil {
+		return nil, NewClientError(err)
+	}
+
+	return scrobbling, nil
+}
+
+func (c *RestClient) SubmitNowPlaying(nowPlayingMeta *restApiV1.NowPlayingMeta) ClientError {
+	encodedNowPlayingMeta, _ := json.Marshal(nowPlayingMeta)
+
+	response, cliErr := c.doPostRequest("/nowPlaying", JsonContentType, bytes.NewBuffer(encodedNowPlayingMeta))
+	if cliErr != nil {
+		return cliErr
+	}
+	response.Body.Close()
+
+	return nil
+}
@@ -0,0 +1,24 @@
+package restClientV1
+
+import (
+	"encoding/json"
+	"github.com/jypelle/mifasol/restApiV1"
+	"net/url"
+	"strconv"
+)
+
+func (c *RestClient) Search(query string, limit int) ([]restApiV1.SearchResult, ClientError) {
+	var searchResults []restApiV1.SearchResult
+
+	response, cliErr := c.doGetRequest("/search?q=" + url.QueryEscape(query) + "&limit=" + strconv.Itoa(limit))
+	if cliErr != nil {
+		return nil, cliErr
+	}
+	defer response.Body.Close()
+
+	if err := json.NewDecoder(response.Body).Decode(&searchResults); err != nil {
+		return nil, NewClientError(err)
+	}
+
+	return searchResults, nil
+}
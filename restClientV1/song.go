@@ -5,6 +5,8 @@ import (
 	"encoding/json"
 	"github.com/jypelle/mifasol/restApiV1"
 	"io"
+	"net/url"
+	"strconv"
 )
 
 func (c *RestClient) ReadSongs(songFilter *restApiV1.SongFilter) ([]restApiV1.Song, ClientError) {
@@ -25,6 +27,14 @@ func (c *RestClient) ReadSongs(songFilter *restApiV1.SongFilter) ([]restApiV1.So
 	return songList, nil
 }
 
+// NewSongPager return a pager reading the songs matching a filter, pageSize songs at a time
+func (c *RestClient) NewSongPager(songFilter *restApiV1.SongFilter, pageSize int) *Pager[restApiV1.Song] {
+	filter := *songFilter
+	filter.Limit = &pageSize
+	filter.Cursor = nil
+	return newPager[restApiV1.Song](c, "/songs", &filter, func(cursor *string) { filter.Cursor = cursor })
+}
+
 func (c *RestClient) ReadSong(songId restApiV1.SongId) (*restApiV1.Song, ClientError) {
 	var song *restApiV1.Song
 
@@ -51,6 +61,21 @@ func (c *RestClient) ReadSongContent(songId restApiV1.SongId) (io.ReadCloser, in
 	return response.Body, response.ContentLength, nil
 }
 
+// ReadSongStreamContent return the song content transcoded with a profile, or the original content when profileName is empty
+func (c *RestClient) ReadSongStreamContent(songId restApiV1.SongId, profileName string) (io.ReadCloser, int64, restApiV1.SongFormat, ClientError) {
+	requestPath := "/songContents/" + string(songId)
+	if profileName != "" {
+		requestPath += "?" + restApiV1.TranscodingProfileParam + "=" + url.QueryEscape(profileName)
+	}
+
+	response, cliErr := c.doGetRequest(requestPath)
+	if cliErr != nil {
+		return nil, 0, restApiV1.SongFormatUnknown, cliErr
+	}
+
+	return response.Body, response.ContentLength, restApiV1.SongFormatFromMimeType(response.Header.Get("Content-Type")), nil
+}
+
 func (c *RestClient) CreateSongContent(format restApiV1.SongFormat, readerSource io.Reader) (*restApiV1.Song, ClientError) {
 	var song *restApiV1.Song
 
@@ -116,3 +141,67 @@ func (c *RestClient) DeleteSong(songId restApiV1.SongId) (*restApiV1.Song, Clien
 
 	return song, nil
 }
+
+func (c *RestClient) ReadSongLyrics(songId restApiV1.SongId) (*restApiV1.SongLyrics, ClientError) {
+	var songLyrics *restApiV1.SongLyrics
+
+	response, cliErr := c.doGetRequest("/songLyrics/" + string(songId))
+	if cliErr != nil {
+		return nil, cliErr
+	}
+	defer response.Body.Close()
+
+	if err := json.NewDecoder(response.Body).Decode(&songLyrics); err != nil {
+		return nil, NewClientError(err)
+	}
+
+	return songLyrics, nil
+}
+
+func (c *RestClient) ReadSongWaveform(songId restApiV1.SongId, pointCount int) (*restApiV1.SongWaveform, ClientError) {
+	var songWaveform *restApiV1.SongWaveform
+
+	response, cliErr := c.doGetRequest("/songWaveforms/" + string(songId) + "?" + restApiV1.SongWaveformPointsParam + "=" + strconv.Itoa(pointCount))
+	if cliErr != nil {
+		return nil, cliErr
+	}
+	defer response.Body.Close()
+
+	if err := json.NewDecoder(response.Body).Decode(&songWaveform); err != nil {
+		return nil, NewClientError(err)
+	}
+
+	return songWaveform, nil
+}
+
+func (c *RestClient) UpdateSongLyrics(songId restApiV1.SongId, readerSource io.Reader) (*restApiV1.Song, ClientError) {
+	var song *restApiV1.Song
+
+	response, cliErr := c.doPutRequest("/songLyrics/"+string(songId), "text/plain; charset=utf-8", readerSource)
+	if cliErr != nil {
+		return nil, cliErr
+	}
+	defer response.Body.Close()
+
+	if err := json.NewDecoder(response.Body).Decode(&song); err != nil {
+		return nil, NewClientError(err)
+	}
+
+	return song, nil
+}
+
+func (c *RestClient) DeleteSongLyrics(songId restApiV1.SongId) (*restApiV1.Song, ClientError) {
+	var song *restApiV1.Song
+
+	response, cliErr := c.doDeleteRequest("/songLyrics/" + string(songId))
+	if cliErr != nil {
+		return nil, cliErr
+	}
+	defer response.Body.Close()
+
+	if err := json.NewDecoder(response.Body).Decode(&song); err != nil {
+		return nil, NewClientError(err)
+	}
+
+	return song, nil
+}
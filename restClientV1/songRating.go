@@ -0,0 +1,41 @@
+package restClientV1
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/jypelle/mifasol/restApiV1"
+)
+
+func (c *RestClient) UpdateSongRating(songRatingMeta *restApiV1.SongRatingMeta) (*restApiV1.SongRating, ClientError) {
+	var songRating *restApiV1.SongRating
+
+	encodedSongRatingMeta, _ := json.Marshal(songRatingMeta)
+
+	response, cliErr := c.doPutRequest("/songRatings", JsonContentType, bytes.NewBuffer(encodedSongRatingMeta))
+	if cliErr != nil {
+		return nil, cliErr
+	}
+	defer response.Body.Close()
+
+	if err := json.NewDecoder(response.Body).Decode(&songRating); err != nil {
+		return nil, NewClientError(err)
+	}
+
+	return songRating, nil
+}
+
+func (c *RestClient) DeleteSongRating(songRatingId restApiV1.SongRatingId) (*restApiV1.SongRating, ClientError) {
+	var songRating *restApiV1.SongRating
+
+	response, cliErr := c.doDeleteRequest("/songRatings/" + string(songRatingId.UserId) + "/" + string(songRatingId.SongId))
+	if cliErr != nil {
+		return nil, cliErr
+	}
+	defer response.Body.Close()
+
+	if err := json.NewDecoder(response.Body).Decode(&songRating); err != nil {
+		return nil, NewClientError(err)
+	}
+
+	return songRating, nil
+}
@@ -0,0 +1,32 @@
+package restClientV1
+
+import (
+	"encoding/json"
+	"github.com/jypelle/mifasol/restApiV1"
+	"net/url"
+)
+
+// ReadUserStats retrieve the listening statistics of a user between two days (formatted with restApiV1.StatsDateLayout), empty bounds are unbounded
+func (c *RestClient) ReadUserStats(userId restApiV1.UserId, from string, to string) (*restApiV1.UserStats, ClientError) {
+	var userStats *restApiV1.UserStats
+
+	query := url.Values{}
+	if from != "" {
+		query.Set(restApiV1.StatsFromParam, from)
+	}
+	if to != "" {
+		query.Set(restApiV1.StatsToParam, to)
+	}
+
+	response, cliErr := c.doGetRequest("/stats/" + string(userId) + "?" + query.Encode())
+	if cliErr != nil {
+		return nil, cliErr
+	}
+	defer response.Body.Close()
+
+	if err := json.NewDecoder(response.Body).Decode(&userStats); err != nil {
+		return nil, NewClientError(err)
+	}
+
+	return userStats, nil
+}
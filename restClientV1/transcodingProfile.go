@@ -0,0 +1,22 @@
+package restClientV1
+
+import (
+	"encoding/json"
+	"github.com/jypelle/mifasol/restApiV1"
+)
+
+func (c *RestClient) ReadTranscodingProfiles() ([]restApiV1.TranscodingProfile, ClientError) {
+	var transcodingProfiles []restApiV1.TranscodingProfile
+
+	response, cliErr := c.doGetRequest("/transcodingProfiles")
+	if cliErr != nil {
+		return nil, cliErr
+	}
+	defer response.Body.Close()
+
+	if err := json.NewDecoder(response.Body).Decode(&transcodingProfiles); err != nil {
+		return nil, NewClientError(err)
+	}
+
+	return transcodingProfiles, nil
+}